@@ -16,14 +16,25 @@ var gitCommit = "unknown"
 //go:embed templates/** static/**
 var embedded embed.FS
 
+//go:embed third_party.json
+var thirdPartyManifest []byte
+
 func main() {
 	log.Printf("Kimmio Launcher %s (%s)", appVersion, gitCommit)
 	cfg := config.Load(buildMode)
 	launcher.SetBuildInfo(appVersion, gitCommit)
+	launcher.SetThirdPartyManifest(thirdPartyManifest)
 	if handled, exitCode := launcher.RunCLI(cfg, os.Args[1:], os.Stdout, os.Stderr); handled {
 		os.Exit(exitCode)
 	}
-	if err := launcher.Run(embedded, cfg); err != nil {
+	if handled, exitCode := launcher.RunSelfTest(embedded, cfg, os.Args[1:], os.Stdout, os.Stderr); handled {
+		os.Exit(exitCode)
+	}
+	opts, exitCode, ok := launcher.ParseRunFlags(os.Args[1:], os.Stderr)
+	if !ok {
+		os.Exit(exitCode)
+	}
+	if err := launcher.Run(embedded, cfg, opts); err != nil {
 		log.Fatal(err)
 	}
 }
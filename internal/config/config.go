@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -18,72 +19,225 @@ type Config struct {
 	EnableTimeout   time.Duration
 	ProfilePortMin  int
 	ProfilePortMax  int
+	GRPCPort        int
+	// MaintenanceWindow limits when automatic operations may run, e.g.
+	// "sat,sun 02:00-05:00". Empty means no restriction.
+	MaintenanceWindow string
+	// GitHubToken authenticates release lookups to avoid the low
+	// unauthenticated rate limit. It is never exposed over the API.
+	GitHubToken string
+	// UpdateManifestURL replaces the GitHub releases API with a JSON feed,
+	// for enterprises that mirror launcher builds internally.
+	UpdateManifestURL string
+	// NetworkMTU is the default MTU of profile networks; 0 keeps Docker's.
+	NetworkMTU int
+	// ReservedPorts are never used for profiles, e.g. "5432,8000-8010".
+	ReservedPorts []int
+	// AvoidDevPorts keeps automatic port assignment away from ports common
+	// dev servers use; they can still be chosen explicitly.
+	AvoidDevPorts bool
+	// TrashRetentionDays is how long deleted profiles keep their volumes
+	// before they are purged; 0 deletes immediately.
+	TrashRetentionDays int
+	// CLIConfigPath holds the CLI's named contexts (launcher URL, token,
+	// data directory). It is per user, independent of the data directory.
+	CLIConfigPath string
+	// Problems lists every setting that was invalid and replaced by a
+	// default or clamped, so startup can report it instead of failing
+	// silently.
+	Problems []Problem
+}
+
+// Problem describes a setting that could not be used as given.
+type Problem struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Message string `json:"message"`
+}
+
+// loader reads settings from the environment and records every value it
+// cannot use.
+type loader struct {
+	problems []Problem
+}
+
+func (l *loader) report(key, value, format string, args ...any) {
+	l.problems = append(l.problems, Problem{Key: key, Value: value, Message: fmt.Sprintf(format, args...)})
 }
 
 func Load(buildMode string) Config {
+	l := &loader{}
 	cfg := Config{
-		BuildMode:       strings.TrimSpace(buildMode),
-		ListenPort:      envInt("KIMMIO_PORT", 7331),
-		PortSearchRange: envInt("KIMMIO_PORT_SEARCH_RANGE", 100),
-		MaxProfiles:     envInt("KIMMIO_MAX_PROFILES", 3),
-		ActionTimeout:   envDuration("KIMMIO_ACTION_TIMEOUT", 2*time.Minute),
-		EnableTimeout:   envDuration("KIMMIO_ENABLE_TIMEOUT", 20*time.Minute),
-		ProfilePortMin:  envInt("KIMMIO_PROFILE_PORT_MIN", 8080),
-		ProfilePortMax:  envInt("KIMMIO_PROFILE_PORT_MAX", 9000),
+		BuildMode:          strings.TrimSpace(buildMode),
+		ListenPort:         l.intValue("KIMMIO_PORT", 7331),
+		PortSearchRange:    l.intValue("KIMMIO_PORT_SEARCH_RANGE", 100),
+		MaxProfiles:        l.intValue("KIMMIO_MAX_PROFILES", 3),
+		ActionTimeout:      l.durationValue("KIMMIO_ACTION_TIMEOUT", 2*time.Minute),
+		EnableTimeout:      l.durationValue("KIMMIO_ENABLE_TIMEOUT", 20*time.Minute),
+		ProfilePortMin:     l.intValue("KIMMIO_PROFILE_PORT_MIN", 8080),
+		ProfilePortMax:     l.intValue("KIMMIO_PROFILE_PORT_MAX", 9000),
+		GRPCPort:           l.intValue("KIMMIO_GRPC_PORT", 0),
+		MaintenanceWindow:  strings.TrimSpace(os.Getenv("KIMMIO_MAINTENANCE_WINDOW")),
+		GitHubToken:        strings.TrimSpace(os.Getenv("KIMMIO_GITHUB_TOKEN")),
+		UpdateManifestURL:  strings.TrimSpace(os.Getenv("KIMMIO_UPDATE_MANIFEST_URL")),
+		NetworkMTU:         l.intValue("KIMMIO_NETWORK_MTU", 0),
+		ReservedPorts:      l.portListValue("KIMMIO_RESERVED_PORTS"),
+		AvoidDevPorts:      l.boolValue("KIMMIO_AVOID_DEV_PORTS", true),
+		TrashRetentionDays: l.intValue("KIMMIO_TRASH_DAYS", 7),
 	}
 	cfg.DataDir = resolveDataDir(cfg.BuildMode)
 	if custom := strings.TrimSpace(os.Getenv("KIMMIO_DATA_DIR")); custom != "" {
 		cfg.DataDir = custom
 	}
+	cfg.CLIConfigPath = filepath.Join(userConfigBase(), "KimmioLauncher", "cli.json")
+	if custom := strings.TrimSpace(os.Getenv("KIMMIO_CLI_CONFIG")); custom != "" {
+		cfg.CLIConfigPath = custom
+	}
+	l.validate(&cfg)
+	cfg.Problems = l.problems
+	return cfg
+}
+
+// validate clamps values that parsed but are out of range.
+func (l *loader) validate(cfg *Config) {
+	if cfg.ListenPort < 1 || cfg.ListenPort > 65535 {
+		l.report("KIMMIO_PORT", strconv.Itoa(cfg.ListenPort), "must be a port between 1 and 65535; using 7331")
+		cfg.ListenPort = 7331
+	}
+	if cfg.GRPCPort < 0 || cfg.GRPCPort > 65535 {
+		l.report("KIMMIO_GRPC_PORT", strconv.Itoa(cfg.GRPCPort), "must be a port between 1 and 65535, or 0 to disable; gRPC is disabled")
+		cfg.GRPCPort = 0
+	}
+	if cfg.PortSearchRange < 0 {
+		l.report("KIMMIO_PORT_SEARCH_RANGE", strconv.Itoa(cfg.PortSearchRange), "must not be negative; using 0")
+		cfg.PortSearchRange = 0
+	}
 	if cfg.MaxProfiles < 1 {
+		l.report("KIMMIO_MAX_PROFILES", strconv.Itoa(cfg.MaxProfiles), "must be at least 1; using 1")
 		cfg.MaxProfiles = 1
 	}
+	if cfg.ActionTimeout <= 0 {
+		l.report("KIMMIO_ACTION_TIMEOUT", cfg.ActionTimeout.String(), "must be positive; using 2m0s")
+		cfg.ActionTimeout = 2 * time.Minute
+	}
 	if cfg.ProfilePortMin < 1024 {
+		l.report("KIMMIO_PROFILE_PORT_MIN", strconv.Itoa(cfg.ProfilePortMin), "must be at least 1024; using 1024")
 		cfg.ProfilePortMin = 1024
 	}
-	if cfg.ProfilePortMax <= cfg.ProfilePortMin {
-		cfg.ProfilePortMax = cfg.ProfilePortMin + 1000
+	if cfg.ProfilePortMax <= cfg.ProfilePortMin || cfg.ProfilePortMax > 65535 {
+		fixed := cfg.ProfilePortMin + 1000
+		if fixed > 65535 {
+			fixed = 65535
+		}
+		l.report("KIMMIO_PROFILE_PORT_MAX", strconv.Itoa(cfg.ProfilePortMax), "must be above KIMMIO_PROFILE_PORT_MIN and at most 65535; using %d", fixed)
+		cfg.ProfilePortMax = fixed
 	}
 	if cfg.EnableTimeout < cfg.ActionTimeout {
+		l.report("KIMMIO_ENABLE_TIMEOUT", cfg.EnableTimeout.String(), "must not be shorter than KIMMIO_ACTION_TIMEOUT; using %s", cfg.ActionTimeout)
 		cfg.EnableTimeout = cfg.ActionTimeout
 	}
-	return cfg
+	if cfg.NetworkMTU != 0 && (cfg.NetworkMTU < 576 || cfg.NetworkMTU > 9000) {
+		l.report("KIMMIO_NETWORK_MTU", strconv.Itoa(cfg.NetworkMTU), "must be between 576 and 9000, or 0 for Docker's default; using 0")
+		cfg.NetworkMTU = 0
+	}
+	if cfg.TrashRetentionDays < 0 {
+		l.report("KIMMIO_TRASH_DAYS", strconv.Itoa(cfg.TrashRetentionDays), "must not be negative; using 0")
+		cfg.TrashRetentionDays = 0
+	}
 }
 
 func resolveDataDir(buildMode string) string {
 	if buildMode != "prod" {
 		return "data"
 	}
+	base := userConfigBase()
+	if base == "" {
+		return "data"
+	}
+	return filepath.Join(base, "KimmioLauncher")
+}
+
+// userConfigBase is the per-user config directory, or "" when neither it
+// nor the home directory is known.
+func userConfigBase() string {
 	base, err := os.UserConfigDir()
 	if err != nil || strings.TrimSpace(base) == "" {
 		home, homeErr := os.UserHomeDir()
 		if homeErr != nil || strings.TrimSpace(home) == "" {
-			return "data"
+			return ""
 		}
 		base = filepath.Join(home, ".config")
 	}
-	return filepath.Join(base, "KimmioLauncher")
+	return base
 }
 
-func envInt(key string, fallback int) int {
+func (l *loader) intValue(key string, fallback int) int {
 	v := strings.TrimSpace(os.Getenv(key))
 	if v == "" {
 		return fallback
 	}
 	parsed, err := strconv.Atoi(v)
 	if err != nil {
+		l.report(key, v, "not a whole number; using %d", fallback)
 		return fallback
 	}
 	return parsed
 }
 
-func envDuration(key string, fallback time.Duration) time.Duration {
+func (l *loader) durationValue(key string, fallback time.Duration) time.Duration {
 	v := strings.TrimSpace(os.Getenv(key))
 	if v == "" {
 		return fallback
 	}
 	parsed, err := time.ParseDuration(v)
 	if err != nil {
+		l.report(key, v, "not a duration such as 90s or 5m; using %s", fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// portListValue parses a comma separated list of ports and inclusive
+// ranges. Invalid entries are reported and skipped.
+func (l *loader) portListValue(key string) []int {
+	var ports []int
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		from, to, isRange := strings.Cut(item, "-")
+		first, err := strconv.Atoi(strings.TrimSpace(from))
+		if err != nil {
+			l.report(key, item, "not a port or port range; entry ignored")
+			continue
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(strings.TrimSpace(to)); err != nil {
+				l.report(key, item, "not a port or port range; entry ignored")
+				continue
+			}
+		}
+		if first < 1 || last > 65535 || last < first || last-first > 1000 {
+			l.report(key, item, "ports must be 1-65535 and ranges at most 1000 ports; entry ignored")
+			continue
+		}
+		for p := first; p <= last; p++ {
+			ports = append(ports, p)
+		}
+	}
+	return ports
+}
+
+func (l *loader) boolValue(key string, fallback bool) bool {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(v)
+	if err != nil {
+		l.report(key, v, "not true or false; using %t", fallback)
 		return fallback
 	}
 	return parsed
@@ -0,0 +1,69 @@
+package launcher
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+)
+
+type thirdPartyModule struct {
+	Path        string `json:"path"`
+	Version     string `json:"version"`
+	License     string `json:"license"`
+	LicenseText string `json:"licenseText,omitempty"`
+}
+
+type thirdPartyManifest struct {
+	GoVersion string             `json:"goVersion"`
+	Modules   []thirdPartyModule `json:"modules"`
+}
+
+var launcherThirdParty = thirdPartyManifest{Modules: []thirdPartyModule{}}
+
+// SetThirdPartyManifest loads the license manifest generated by
+// scripts/generate-third-party-manifest and embedded into the binary.
+func SetThirdPartyManifest(raw []byte) {
+	var manifest thirdPartyManifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		logWarn("third_party_manifest_invalid", map[string]any{"error": err.Error()})
+		return
+	}
+	if manifest.Modules == nil {
+		manifest.Modules = []thirdPartyModule{}
+	}
+	launcherThirdParty = manifest
+}
+
+func (s *Server) handleLauncherAbout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"ok":         true,
+		"version":    launcherAppVersion,
+		"commit":     launcherGitCommit,
+		"goVersion":  runtime.Version(),
+		"modules":    linkedModules(),
+		"thirdParty": launcherThirdParty.Modules,
+	})
+}
+
+// linkedModules reports the dependency versions actually compiled into this
+// binary, which is what support needs even if the manifest is stale.
+func linkedModules() []map[string]string {
+	out := []map[string]string{}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return out
+	}
+	for _, dep := range info.Deps {
+		entry := map[string]string{"path": dep.Path, "version": dep.Version, "sum": dep.Sum}
+		if dep.Replace != nil {
+			entry["replacedBy"] = dep.Replace.Path + "@" + dep.Replace.Version
+		}
+		out = append(out, entry)
+	}
+	return out
+}
@@ -0,0 +1,552 @@
+package launcher
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Accounts let other machines use a launcher that listens on the LAN.
+// Without accounts the launcher behaves as before: anyone who can reach the
+// port may read, and only this machine may change anything. Once an account
+// exists, network clients must sign in. Admins get full control from any
+// address; viewers may only read status, logs and metrics. Requests from
+// this machine stay trusted as admin, so the CLI and a local browser keep
+// working without a login.
+
+const (
+	usersFileName     = "users.json"
+	sessionCookieName = "kimmio_session"
+	sessionTTL        = 12 * time.Hour
+
+	roleAdmin  = "admin"
+	roleViewer = "viewer"
+	// roleDeploy is for API tokens only: it may call the deploy hook and
+	// nothing else.
+	roleDeploy = "deploy"
+
+	minPasswordLength = 10
+)
+
+var (
+	ErrAccountNotFound = errors.New("account not found")
+	usernameRe         = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+	// passwordIterations is the PBKDF2 work factor; tests lower it.
+	passwordIterations = 210000
+)
+
+// Account is one launcher user. The hash never leaves the data directory.
+type Account struct {
+	Username     string `json:"username"`
+	Role         string `json:"role"`
+	PasswordHash string `json:"passwordHash,omitempty"`
+	CreatedAt    string `json:"createdAt"`
+}
+
+func (a Account) public() Account {
+	a.PasswordHash = ""
+	return a
+}
+
+type session struct {
+	username string
+	expires  time.Time
+}
+
+// accountStore keeps the accounts in users.json and the sessions in memory,
+// so a launcher restart signs everyone out. The file is re-read when it
+// changes, so accounts added with the CLI apply to a running launcher.
+type accountStore struct {
+	mu       sync.Mutex
+	path     string
+	modTime  time.Time
+	accounts []Account
+	sessions map[string]session
+}
+
+func newAccountStore(dataDir string) *accountStore {
+	a := &accountStore{path: filepath.Join(dataDir, usersFileName), sessions: map[string]session{}}
+	a.mu.Lock()
+	a.refreshLocked()
+	a.mu.Unlock()
+	return a
+}
+
+func (a *accountStore) refreshLocked() {
+	info, err := os.Stat(a.path)
+	if err != nil {
+		a.accounts, a.modTime = nil, time.Time{}
+		return
+	}
+	if info.ModTime().Equal(a.modTime) {
+		return
+	}
+	raw, err := os.ReadFile(a.path)
+	if err != nil {
+		logWarn("users_file_read_failed", map[string]any{"error": err.Error()})
+		return
+	}
+	var accounts []Account
+	if err := json.Unmarshal(raw, &accounts); err != nil {
+		logWarn("users_file_invalid", map[string]any{"error": err.Error()})
+		return
+	}
+	a.accounts, a.modTime = accounts, info.ModTime()
+}
+
+// enabled reports whether any account exists, i.e. whether network
+// clients must sign in.
+func (a *accountStore) enabled() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.refreshLocked()
+	return len(a.accounts) > 0
+}
+
+func (a *accountStore) list() []Account {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.refreshLocked()
+	out := make([]Account, 0, len(a.accounts))
+	for _, acc := range a.accounts {
+		out = append(out, acc.public())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Username < out[j].Username })
+	return out
+}
+
+// put creates the account or replaces its role and password.
+func (a *accountStore) put(username, role, password string) (Account, error) {
+	username = strings.ToLower(strings.TrimSpace(username))
+	role = strings.ToLower(strings.TrimSpace(role))
+	if !usernameRe.MatchString(username) {
+		return Account{}, ValidationError{Msg: "Username must be lowercase letters, digits, '.', '_' or '-'"}
+	}
+	if role != roleAdmin && role != roleViewer {
+		return Account{}, ValidationError{Msg: "Role must be admin or viewer"}
+	}
+	if len(password) < minPasswordLength {
+		return Account{}, ValidationError{Msg: fmt.Sprintf("Password must be at least %d characters", minPasswordLength)}
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return Account{}, err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.refreshLocked()
+	next := append([]Account{}, a.accounts...)
+	acc := Account{Username: username, Role: role, PasswordHash: hash, CreatedAt: time.Now().UTC().Format(time.RFC3339)}
+	replaced := false
+	for i := range next {
+		if next[i].Username == username {
+			acc.CreatedAt = next[i].CreatedAt
+			next[i] = acc
+			replaced = true
+		}
+	}
+	if !replaced {
+		next = append(next, acc)
+	}
+	if !hasAdmin(next) {
+		return Account{}, ValidationError{Msg: "At least one account must be an admin"}
+	}
+	if err := a.saveLocked(next); err != nil {
+		return Account{}, err
+	}
+	// A changed password or role takes effect on the next sign-in.
+	a.dropSessionsLocked(username)
+	return acc.public(), nil
+}
+
+func (a *accountStore) remove(username string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.refreshLocked()
+	next := []Account{}
+	for _, acc := range a.accounts {
+		if acc.Username != username {
+			next = append(next, acc)
+		}
+	}
+	if len(next) == len(a.accounts) {
+		return ErrAccountNotFound
+	}
+	if len(next) > 0 && !hasAdmin(next) {
+		return ValidationError{Msg: "Cannot remove the last admin while other accounts exist"}
+	}
+	if err := a.saveLocked(next); err != nil {
+		return err
+	}
+	a.dropSessionsLocked(username)
+	return nil
+}
+
+func hasAdmin(accounts []Account) bool {
+	for _, acc := range accounts {
+		if acc.Role == roleAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *accountStore) saveLocked(accounts []Account) error {
+	raw, err := json.MarshalIndent(accounts, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := a.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, a.path); err != nil {
+		return err
+	}
+	a.accounts = accounts
+	if info, err := os.Stat(a.path); err == nil {
+		a.modTime = info.ModTime()
+	}
+	return nil
+}
+
+func (a *accountStore) dropSessionsLocked(username string) {
+	for token, s := range a.sessions {
+		if s.username == username {
+			delete(a.sessions, token)
+		}
+	}
+}
+
+// login checks the credentials and opens a session. Unknown users cost a
+// hash as well, so they cannot be told apart by timing; hashing runs
+// outside the lock so sign-ins do not stall other requests.
+func (a *accountStore) login(username, password string) (Account, string, bool) {
+	username = strings.ToLower(strings.TrimSpace(username))
+	a.mu.Lock()
+	a.refreshLocked()
+	var acc Account
+	for _, candidate := range a.accounts {
+		if candidate.Username == username {
+			acc = candidate
+		}
+	}
+	a.mu.Unlock()
+	if !verifyPassword(acc.PasswordHash, password) || acc.Username == "" {
+		return Account{}, "", false
+	}
+	token := randomToken(48)
+	a.mu.Lock()
+	a.sessions[token] = session{username: username, expires: time.Now().Add(sessionTTL)}
+	a.mu.Unlock()
+	return acc.public(), token, true
+}
+
+func (a *accountStore) logout(token string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.sessions, token)
+}
+
+// sessionAccount returns the account behind a session token.
+func (a *accountStore) sessionAccount(token string) (Account, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.refreshLocked()
+	s, ok := a.sessions[token]
+	if !ok || token == "" {
+		return Account{}, false
+	}
+	if time.Now().After(s.expires) {
+		delete(a.sessions, token)
+		return Account{}, false
+	}
+	for _, acc := range a.accounts {
+		if acc.Username == s.username {
+			return acc.public(), true
+		}
+	}
+	delete(a.sessions, token)
+	return Account{}, false
+}
+
+// hashPassword derives a PBKDF2-HMAC-SHA256 key, stored as
+// "pbkdf2-sha256$<iterations>$<salt>$<key>".
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := pbkdf2SHA256([]byte(password), salt, passwordIterations, 32)
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", passwordIterations,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func verifyPassword(stored, password string) bool {
+	parts := strings.Split(stored, "$")
+	iterations := passwordIterations
+	var salt, want []byte
+	ok := len(parts) == 4 && parts[0] == "pbkdf2-sha256"
+	if ok {
+		var err1, err2, err3 error
+		iterations, err1 = strconv.Atoi(parts[1])
+		salt, err2 = base64.RawStdEncoding.DecodeString(parts[2])
+		want, err3 = base64.RawStdEncoding.DecodeString(parts[3])
+		ok = err1 == nil && err2 == nil && err3 == nil && iterations > 0 && len(want) > 0
+	}
+	if !ok {
+		iterations, salt, want = passwordIterations, make([]byte, 16), make([]byte, 32)
+	}
+	got := pbkdf2SHA256([]byte(password), salt, iterations, len(want))
+	return subtle.ConstantTimeCompare(got, want) == 1 && ok
+}
+
+// pbkdf2SHA256 implements RFC 8018 PBKDF2 with HMAC-SHA256.
+func pbkdf2SHA256(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	out := make([]byte, 0, keyLen)
+	var block [4]byte
+	for i := uint32(1); len(out) < keyLen; i++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(block[:], i)
+		prf.Write(block[:])
+		u := prf.Sum(nil)
+		t := append([]byte(nil), u...)
+		for n := 1; n < iterations; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		out = append(out, t...)
+	}
+	return out[:keyLen]
+}
+
+type accountCtxKey struct{}
+
+// requestAccount returns the signed-in account. Requests from this machine
+// and launchers without accounts carry none.
+func requestAccount(r *http.Request) (Account, bool) {
+	acc, ok := r.Context().Value(accountCtxKey{}).(Account)
+	return acc, ok
+}
+
+// isPublicPath lists what network clients may load before signing in.
+func isPublicPath(p string) bool {
+	return p == "/login" || p == "/logout" || p == "/api/system/health" || strings.HasPrefix(p, "/static/")
+}
+
+// isAdminOnlyPath lists the reads a viewer may not make: they expose
+// launcher configuration, registered launchers or account data.
+func isAdminOnlyPath(p string) bool {
+	for _, prefix := range []string{"/settings", "/api/settings", "/fleet", "/api/fleet", "/api/users", "/api/tokens", "/profiles/new"} {
+		if p == prefix || strings.HasPrefix(p, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// withAccounts resolves the caller once accounts exist and enforces roles.
+// It runs before withAuth, which then lets signed-in admins change state
+// from other machines. Callers withAPIToken already resolved pass through.
+func (s *Server) withAccounts(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := requestAccount(r); ok || s.accounts == nil || isLoopbackRequest(r) || isPublicPath(r.URL.Path) || !s.accounts.enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		var acc Account
+		ok := false
+		if c, err := r.Cookie(sessionCookieName); err == nil {
+			acc, ok = s.accounts.sessionAccount(c.Value)
+		}
+		if !ok {
+			if r.Method == http.MethodGet && !strings.HasPrefix(r.URL.Path, "/api/") {
+				http.Redirect(w, r, "/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+				return
+			}
+			http.Error(w, "unauthorized: sign in first", http.StatusUnauthorized)
+			return
+		}
+		if refuseForRole(w, r, acc) {
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), accountCtxKey{}, acc)))
+	})
+}
+
+// refuseForRole answers 403 when a viewer tries to change something or to
+// read an admin-only route.
+func refuseForRole(w http.ResponseWriter, r *http.Request, acc Account) bool {
+	if acc.Role == roleAdmin {
+		return false
+	}
+	if acc.Role == roleDeploy {
+		// Pipelines may follow the job the hook started.
+		if (r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, deployHookPrefix)) || (r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/jobs/")) {
+			return false
+		}
+		logWarn("request_forbidden_for_role", map[string]any{"user": acc.Username, "role": acc.Role, "path": r.URL.Path, "method": r.Method})
+		http.Error(w, "forbidden: deploy tokens may only call "+deployHookPrefix+"<profile>", http.StatusForbidden)
+		return true
+	}
+	readOnly := r.Method == http.MethodGet || r.Method == http.MethodHead
+	if readOnly && !isAdminOnlyPath(r.URL.Path) {
+		return false
+	}
+	logWarn("request_forbidden_for_role", map[string]any{"user": acc.Username, "role": acc.Role, "path": r.URL.Path, "method": r.Method})
+	http.Error(w, "forbidden: "+acc.Role+" access is read-only", http.StatusForbidden)
+	return true
+}
+
+// pageData adds the caller's account to a page's template data, so the
+// header can show who is signed in and pages can hide controls a viewer
+// cannot use.
+func (s *Server) pageData(r *http.Request, data map[string]any) map[string]any {
+	if acc, ok := requestAccount(r); ok {
+		data["Account"] = acc
+		data["ReadOnly"] = acc.Role != roleAdmin
+	}
+	return data
+}
+
+// safeNextPath keeps the post-login redirect on this launcher.
+func safeNextPath(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
+
+func (s *Server) handleLogin(ts *Templates) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		next := safeNextPath(r.FormValue("next"))
+		render := func(status int, msg string) {
+			csrfToken := ensureCSRFCookie(w, r)
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.WriteHeader(status)
+			if err := ts.RenderPageWithTemplate(w, "login.html", map[string]any{
+				"DockerRunning": "installed",
+				"LoginPage":     true,
+				"Next":          next,
+				"Error":         msg,
+				"CSRFToken":     csrfToken,
+			}); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+		}
+		switch r.Method {
+		case http.MethodGet:
+			if !s.accounts.enabled() {
+				http.Redirect(w, r, "/", http.StatusSeeOther)
+				return
+			}
+			render(http.StatusOK, "")
+		case http.MethodPost:
+			username := strings.ToLower(strings.TrimSpace(r.FormValue("username")))
+			ip := clientIP(r)
+			now := time.Now()
+			wait, attempts, lockout := s.logins.reserve(ip, username, now)
+			if wait > 0 {
+				auditLog("WARN", "login_blocked", map[string]any{"user": username, "remote": ip, "retryAfter": wait.Round(time.Second).String()})
+				w.Header().Set("Retry-After", strconv.Itoa(int(wait.Round(time.Second)/time.Second)))
+				render(http.StatusTooManyRequests, "Too many failed sign-ins. Try again in "+wait.Round(time.Second).String()+".")
+				return
+			}
+			acc, token, ok := s.accounts.login(username, r.FormValue("password"))
+			if !ok {
+				auditLog("WARN", "login_failed", map[string]any{"user": username, "remote": ip, "attempts": attempts})
+				if lockout > 0 {
+					auditLog("WARN", "login_locked", map[string]any{"user": username, "remote": ip, "attempts": attempts, "lockout": lockout.String()})
+				}
+				render(http.StatusUnauthorized, "Wrong username or password")
+				return
+			}
+			s.logins.succeed(ip, username)
+			http.SetCookie(w, &http.Cookie{
+				Name:     sessionCookieName,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				Secure:   r.TLS != nil,
+				SameSite: http.SameSiteStrictMode,
+				MaxAge:   int(sessionTTL / time.Second),
+			})
+			auditLog("INFO", "login_succeeded", map[string]any{"user": acc.Username, "role": acc.Role, "remote": ip})
+			http.Redirect(w, r, next, http.StatusSeeOther)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if c, err := r.Cookie(sessionCookieName); err == nil {
+		s.accounts.logout(c.Value)
+	}
+	http.SetCookie(w, &http.Cookie{Name: sessionCookieName, Value: "", Path: "/", MaxAge: -1, HttpOnly: true, SameSite: http.SameSiteStrictMode})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+// handleUsers serves GET/POST /api/users and DELETE /api/users/<name>.
+func (s *Server) handleUsers(w http.ResponseWriter, r *http.Request) {
+	name := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/users"), "/")
+	switch {
+	case name == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "users": s.accounts.list()})
+	case name == "" && r.Method == http.MethodPost:
+		var body struct {
+			Username string `json:"username"`
+			Role     string `json:"role"`
+			Password string `json:"password"`
+		}
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&body); err != nil {
+			http.Error(w, "invalid JSON body", bodyErrorStatus(err))
+			return
+		}
+		acc, err := s.accounts.put(body.Username, body.Role, body.Password)
+		if err != nil {
+			http.Error(w, err.Error(), httpStatusForError(err))
+			return
+		}
+		auditLog("INFO", "account_saved", map[string]any{"user": acc.Username, "role": acc.Role})
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "user": acc})
+	case name != "" && r.Method == http.MethodDelete:
+		if err := s.accounts.remove(name); err != nil {
+			http.Error(w, err.Error(), httpStatusForError(err))
+			return
+		}
+		auditLog("INFO", "account_removed", map[string]any{"user": name})
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
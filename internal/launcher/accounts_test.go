@@ -0,0 +1,202 @@
+package launcher
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func lowerPasswordCost(t *testing.T) {
+	prev := passwordIterations
+	passwordIterations = 1000
+	t.Cleanup(func() { passwordIterations = prev })
+}
+
+func TestPasswordHashRoundTrip(t *testing.T) {
+	lowerPasswordCost(t)
+	hash, err := hashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verifyPassword(hash, "correct horse") {
+		t.Fatal("expected the password to verify")
+	}
+	if verifyPassword(hash, "wrong horse!") || verifyPassword("", "") || verifyPassword("garbage", "correct horse") {
+		t.Fatal("expected wrong passwords and bad hashes to fail")
+	}
+}
+
+func TestAccountStoreKeepsAnAdmin(t *testing.T) {
+	lowerPasswordCost(t)
+	store := newAccountStore(t.TempDir())
+	if _, err := store.put("viewer1", roleViewer, "long-enough-pw"); err == nil {
+		t.Fatal("expected the first account to require an admin")
+	}
+	if _, err := store.put("root", roleAdmin, "short"); err == nil {
+		t.Fatal("expected a short password to be rejected")
+	}
+	if _, err := store.put("Root", roleAdmin, "long-enough-pw"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.put("viewer1", roleViewer, "long-enough-pw"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.remove("root"); err == nil {
+		t.Fatal("expected removing the last admin to fail while a viewer exists")
+	}
+	if _, err := store.put("root", roleViewer, "long-enough-pw"); err == nil {
+		t.Fatal("expected demoting the last admin to fail")
+	}
+	if err := store.remove("ghost"); err != ErrAccountNotFound {
+		t.Fatalf("expected ErrAccountNotFound, got %v", err)
+	}
+
+	// A second store on the same directory sees the accounts, as the CLI
+	// and a running launcher do.
+	other := newAccountStore(filepath.Dir(store.path))
+	if got := other.list(); len(got) != 2 || got[0].Username != "root" || got[0].PasswordHash != "" {
+		t.Fatalf("expected two redacted accounts, got %+v", got)
+	}
+	if _, _, ok := other.login("root", "wrong-password"); ok {
+		t.Fatal("expected a wrong password to be refused")
+	}
+	if _, token, ok := other.login("ROOT", "long-enough-pw"); !ok || token == "" {
+		t.Fatal("expected the admin to sign in")
+	}
+}
+
+func TestAccountsEnforceRolesForNetworkClients(t *testing.T) {
+	lowerPasswordCost(t)
+	srv := &Server{httpMetrics: newHTTPMetrics(), accounts: newAccountStore(t.TempDir())}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+	})
+	h := srv.httpHandler(mux)
+
+	origin := ""
+	do := func(method, path, session string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "http://launcher.lan"+path, nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+			req.Header.Set("Referer", origin+"/")
+		}
+		req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: "tok"})
+		req.Header.Set("X-CSRF-Token", "tok")
+		if session != "" {
+			req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: session})
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Without accounts network clients keep read access only.
+	if rec := do(http.MethodGet, "/api/profiles", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected reads without accounts, got %d", rec.Code)
+	}
+
+	if _, err := srv.accounts.put("root", roleAdmin, "long-enough-pw"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.accounts.put("watcher", roleViewer, "long-enough-pw"); err != nil {
+		t.Fatal(err)
+	}
+	_, adminSession, _ := srv.accounts.login("root", "long-enough-pw")
+	_, viewerSession, _ := srv.accounts.login("watcher", "long-enough-pw")
+
+	if rec := do(http.MethodGet, "/api/profiles", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a session, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/settings", ""); rec.Code != http.StatusSeeOther || !strings.HasPrefix(rec.Header().Get("Location"), "/login?next=") {
+		t.Fatalf("expected pages to redirect to the login, got %d %s", rec.Code, rec.Header().Get("Location"))
+	}
+	if rec := do(http.MethodGet, "/api/system/health", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected the health check to stay public, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/api/profiles", viewerSession); rec.Code != http.StatusOK {
+		t.Fatalf("expected a viewer to read, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/profiles/demo/enable", viewerSession); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected a viewer mutation to be refused, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/api/settings", viewerSession); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected settings to be admin-only, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/profiles/demo/enable", adminSession); rec.Code != http.StatusOK {
+		t.Fatalf("expected an admin mutation to pass, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Browsers on other machines send the launcher's own name as Origin.
+	origin = "http://launcher.lan"
+	if rec := do(http.MethodPost, "/login", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected a same-origin sign-in to pass, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/api/profiles/demo/enable", adminSession); rec.Code != http.StatusOK {
+		t.Fatalf("expected a same-origin admin mutation to pass, got %d %s", rec.Code, rec.Body.String())
+	}
+	origin = "http://evil.example"
+	if rec := do(http.MethodPost, "/login", ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected a cross-origin sign-in to be refused, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/profiles/demo/enable", adminSession); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected a cross-origin admin mutation to be refused, got %d", rec.Code)
+	}
+	origin = ""
+
+	// Changing the admin's password ends its sessions.
+	if _, err := srv.accounts.put("root", roleAdmin, "another-long-pw"); err != nil {
+		t.Fatal(err)
+	}
+	if rec := do(http.MethodPost, "/api/profiles/demo/enable", adminSession); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected the old session to be gone, got %d", rec.Code)
+	}
+}
+
+func TestLoginSetsSessionAndKeepsRedirectLocal(t *testing.T) {
+	lowerPasswordCost(t)
+	srv := newServiceTestServer(t)
+	if _, err := srv.accounts.put("root", roleAdmin, "long-enough-pw"); err != nil {
+		t.Fatal(err)
+	}
+	form := url.Values{"username": {"root"}, "password": {"long-enough-pw"}, "next": {"//evil.example/"}}
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	srv.handleLogin(nil)(rec, req)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/" {
+		t.Fatalf("expected a local redirect, got %d %s", rec.Code, rec.Header().Get("Location"))
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != sessionCookieName || !cookies[0].HttpOnly {
+		t.Fatalf("expected an HttpOnly session cookie, got %+v", cookies)
+	}
+	if acc, ok := srv.accounts.sessionAccount(cookies[0].Value); !ok || acc.Username != "root" {
+		t.Fatalf("expected the session to resolve to root, got %+v", acc)
+	}
+}
+
+func TestUserCLIAddsAccountsFromStdin(t *testing.T) {
+	lowerPasswordCost(t)
+	srv := newServiceTestServer(t)
+	prev := userPasswordInput
+	userPasswordInput = strings.NewReader("long-enough-pw\n")
+	defer func() { userPasswordInput = prev }()
+
+	var stdout, stderr bytes.Buffer
+	if code := runUserCLI(srv, []string{"add", "root", "--role", "admin"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected add to succeed, got %d: %s", code, stderr.String())
+	}
+	stdout.Reset()
+	if code := runUserCLI(srv, []string{"list"}, &stdout, &stderr); code != exitOK || !strings.Contains(stdout.String(), "root") {
+		t.Fatalf("expected root in the list, got %d: %s", code, stdout.String())
+	}
+	if code := runUserCLI(srv, []string{"remove", "ghost"}, &stdout, &stderr); code != exitNotFound {
+		t.Fatalf("expected a missing account to exit %d, got %d", exitNotFound, code)
+	}
+}
@@ -0,0 +1,238 @@
+package launcher
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The activity feed merges what the launcher already records: finished
+// actions from each profile's action log, and status changes, update
+// checks and sign-ins from audit.log. Nothing is stored twice.
+
+const (
+	defaultActivityLimit = 50
+	maxActivityLimit     = 200
+)
+
+const (
+	activityJob    = "job"
+	activityStatus = "status"
+	activityUpdate = "update"
+	activityAuth   = "auth"
+)
+
+var activityKinds = []string{activityJob, activityStatus, activityUpdate, activityAuth}
+
+// ActivityEntry is one line of the activity feed.
+type ActivityEntry struct {
+	At        string `json:"at"`
+	Kind      string `json:"kind"`
+	Level     string `json:"level"`
+	ProfileID string `json:"profileId,omitempty"`
+	Message   string `json:"message"`
+}
+
+type activityQuery struct {
+	Kinds []string
+	Limit int
+}
+
+func parseActivityQuery(values url.Values) (activityQuery, error) {
+	var errs fieldErrors
+	q := activityQuery{Limit: defaultActivityLimit}
+	for _, kind := range strings.Split(values.Get("kind"), ",") {
+		if kind = strings.ToLower(strings.TrimSpace(kind)); kind == "" {
+			continue
+		}
+		if !slices.Contains(activityKinds, kind) {
+			errs.add("kind", "query.kind", fmt.Errorf("kind must be one of %s", strings.Join(activityKinds, ", ")))
+			break
+		}
+		q.Kinds = append(q.Kinds, kind)
+	}
+	if raw := strings.TrimSpace(values.Get("limit")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxActivityLimit {
+			errs.add("limit", "query.limit", fmt.Errorf("limit must be between 1 and %d", maxActivityLimit))
+		}
+		q.Limit = n
+	}
+	return q, errs.err()
+}
+
+// actionLogActivity turns action log entries ("<time> [<action>] <result>:
+// <message>") into feed entries. Entries without an action, such as
+// "profile created", are kept as they are.
+func actionLogActivity(profiles []ProfileRequest) []ActivityEntry {
+	out := []ActivityEntry{}
+	for _, p := range profiles {
+		for _, line := range p.ActionLog {
+			at, rest, ok := strings.Cut(line, " ")
+			if !ok {
+				continue
+			}
+			entry := ActivityEntry{At: at, Kind: activityJob, Level: "info", ProfileID: p.ID, Message: p.ID + ": " + rest}
+			if !strings.HasPrefix(rest, "[") {
+				out = append(out, entry)
+				continue
+			}
+			if action, result, ok := strings.Cut(rest[1:], "] "); ok {
+				result, message, _ := strings.Cut(result, ": ")
+				if result == "failed" {
+					entry.Level = "error"
+				}
+				entry.Message = fmt.Sprintf("%s %s %s", p.ID, action, result)
+				if message != "" {
+					entry.Message += ": " + message
+				}
+			}
+			out = append(out, entry)
+		}
+	}
+	return out
+}
+
+// auditActivity turns audit records into feed entries, skipping events the
+// feed does not know.
+func auditActivity(records []map[string]any) []ActivityEntry {
+	out := []ActivityEntry{}
+	for _, rec := range records {
+		str := func(key string) string {
+			if v, ok := rec[key]; ok && v != nil {
+				return fmt.Sprint(v)
+			}
+			return ""
+		}
+		entry := ActivityEntry{At: str("ts"), Kind: activityAuth, Level: strings.ToLower(str("level"))}
+		switch str("msg") {
+		case "profile_status_changed":
+			entry.Kind, entry.ProfileID = activityStatus, str("profile")
+			entry.Message = fmt.Sprintf("%s went from %s to %s", str("profile"), str("from"), str("to"))
+		case "update_check":
+			entry.Kind = activityUpdate
+			switch {
+			case str("error") != "":
+				entry.Message = "Update check failed: " + str("error")
+			case str("launcher") != "":
+				entry.Message = fmt.Sprintf("Update check found launcher %s and %s profile updates", str("launcher"), str("profileUpdates"))
+			default:
+				entry.Message = fmt.Sprintf("Update check found %s profile updates", str("profileUpdates"))
+			}
+		case "login_succeeded":
+			entry.Message = fmt.Sprintf("%s signed in from %s", str("user"), str("remote"))
+		case "login_failed":
+			entry.Message = fmt.Sprintf("Failed sign-in for %s from %s", str("user"), str("remote"))
+		case "login_locked":
+			entry.Message = fmt.Sprintf("%s locked out for %s after %s failed sign-ins", str("user"), str("lockout"), str("attempts"))
+		case "login_blocked":
+			entry.Message = fmt.Sprintf("Blocked sign-in for locked-out %s from %s", str("user"), str("remote"))
+		case "api_token_rejected":
+			entry.Message = fmt.Sprintf("Rejected API token from %s for %s %s", str("remote"), str("method"), str("path"))
+		case "api_token_created":
+			entry.Message = fmt.Sprintf("API token %s created with role %s", str("name"), str("role"))
+		case "api_token_revoked":
+			entry.Message = fmt.Sprintf("API token %s revoked", str("name"))
+		case "account_saved":
+			entry.Message = fmt.Sprintf("Account %s saved with role %s", str("user"), str("role"))
+		case "account_removed":
+			entry.Message = fmt.Sprintf("Account %s removed", str("user"))
+		case "connection_sheet_downloaded":
+			entry.ProfileID = str("profile")
+			entry.Message = fmt.Sprintf("Connection sheet for %s downloaded from %s", str("profile"), str("remote"))
+		default:
+			continue
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+// readAuditRecords reads audit.log and its first rotation, oldest first.
+// Without an audit log (tests, or before the logger starts) it returns
+// nothing.
+func readAuditRecords() []map[string]any {
+	if auditLogger == nil {
+		return nil
+	}
+	auditLogger.mu.Lock()
+	defer auditLogger.mu.Unlock()
+	records := []map[string]any{}
+	for _, path := range []string{auditLogger.path + ".1", auditLogger.path} {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			var rec map[string]any
+			if json.Unmarshal(scanner.Bytes(), &rec) == nil {
+				records = append(records, rec)
+			}
+		}
+		f.Close()
+	}
+	return records
+}
+
+// buildActivity merges the sources newest first and applies q. Sign-in
+// events are left out unless includeAuth is set.
+func buildActivity(profiles []ProfileRequest, audit []map[string]any, q activityQuery, includeAuth bool) []ActivityEntry {
+	entries := append(actionLogActivity(profiles), auditActivity(audit)...)
+	out := []ActivityEntry{}
+	for _, e := range entries {
+		if e.Kind == activityAuth && !includeAuth {
+			continue
+		}
+		if len(q.Kinds) > 0 && !slices.Contains(q.Kinds, e.Kind) {
+			continue
+		}
+		out = append(out, e)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].At > out[j].At })
+	if q.Limit > 0 && len(out) > q.Limit {
+		out = out[:q.Limit]
+	}
+	return out
+}
+
+// activityAuthVisible hides sign-in events, which name users and client
+// addresses, from viewers.
+func activityAuthVisible(r *http.Request) bool {
+	acc, ok := requestAccount(r)
+	return !ok || acc.Role == roleAdmin
+}
+
+func (s *Server) handleActivity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q, err := parseActivityQuery(r.URL.Query())
+	if err != nil {
+		var ve ValidationError
+		errors.As(err, &ve)
+		writeValidationError(w, ve)
+		return
+	}
+	store, err := s.readStore(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load profiles: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	entries := buildActivity(store.Profiles, readAuditRecords(), q, activityAuthVisible(r))
+	if negotiateFormat(r, formatJSON) == formatFragment {
+		s.writeFragment(w, http.StatusOK, "activity-feed", entries)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "activity": entries})
+}
@@ -0,0 +1,90 @@
+package launcher
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildActivityMergesSources(t *testing.T) {
+	profiles := []ProfileRequest{{ID: "alpha", ActionLog: []string{
+		"2026-01-03T00:00:00Z [update] failed: pull failed",
+		"2026-01-01T00:00:00Z profile created",
+	}}}
+	audit := []map[string]any{
+		{"ts": "2026-01-02T00:00:00Z", "level": "WARN", "msg": "profile_status_changed", "profile": "alpha", "from": "running", "to": "unhealthy"},
+		{"ts": "2026-01-04T00:00:00Z", "level": "INFO", "msg": "update_check", "launcher": "2.0.0", "profileUpdates": 1},
+		{"ts": "2026-01-05T00:00:00Z", "level": "WARN", "msg": "login_failed", "user": "root", "remote": "10.0.0.2"},
+		{"ts": "2026-01-06T00:00:00Z", "level": "INFO", "msg": "something_else"},
+	}
+
+	entries := buildActivity(profiles, audit, activityQuery{}, true)
+	got := []string{}
+	for _, e := range entries {
+		got = append(got, e.Kind)
+	}
+	if strings.Join(got, ",") != "auth,update,job,status,job" {
+		t.Fatalf("expected the entries newest first, got %v", got)
+	}
+	if entries[2].Message != "alpha update failed: pull failed" || entries[2].Level != "error" {
+		t.Fatalf("unexpected job entry %+v", entries[2])
+	}
+	if entries[3].Message != "alpha went from running to unhealthy" || entries[3].Level != "warn" {
+		t.Fatalf("unexpected status entry %+v", entries[3])
+	}
+
+	if entries := buildActivity(profiles, audit, activityQuery{}, false); entries[0].Kind == activityAuth {
+		t.Fatalf("expected sign-in events to be hidden, got %+v", entries[0])
+	}
+	entries = buildActivity(profiles, audit, activityQuery{Kinds: []string{activityJob}, Limit: 1}, true)
+	if len(entries) != 1 || entries[0].At != "2026-01-03T00:00:00Z" {
+		t.Fatalf("expected the newest job entry, got %+v", entries)
+	}
+
+	if _, err := parseActivityQuery(url.Values{"kind": {"bogus"}, "limit": {"0"}}); err == nil || len(err.(ValidationError).Fields) != 2 {
+		t.Fatalf("expected two field errors, got %v", err)
+	}
+}
+
+func TestHealthCacheReportsStatusChanges(t *testing.T) {
+	c := newHealthCache()
+	if changes := c.store([]ProfileRequest{{ID: "alpha", RuntimeStatus: "running"}}); len(changes) != 0 {
+		t.Fatalf("expected no change on the first probe, got %+v", changes)
+	}
+	changes := c.store([]ProfileRequest{{ID: "alpha", RuntimeStatus: "unhealthy"}})
+	if len(changes) != 1 || changes[0] != (statusTransition{ProfileID: "alpha", From: "running", To: "unhealthy"}) {
+		t.Fatalf("expected running to unhealthy, got %+v", changes)
+	}
+}
+
+func TestActivityAPIReadsAuditLog(t *testing.T) {
+	prevAudit := auditLogger
+	auditLogger = &structuredLogger{path: filepath.Join(t.TempDir(), "audit.log"), perm: 0o600, maxSize: defaultLogMaxSizeBytes, maxBackup: 1}
+	defer func() { auditLogger = prevAudit }()
+
+	srv := newServiceTestServer(t)
+	srv.storeHealth([]ProfileRequest{{ID: "alpha", RuntimeStatus: "running"}})
+	srv.storeHealth([]ProfileRequest{{ID: "alpha", RuntimeStatus: "stopped"}})
+
+	rec := httptest.NewRecorder()
+	srv.handleActivity(rec, httptest.NewRequest(http.MethodGet, "/api/activity?kind=status", nil))
+	var payload struct {
+		Activity []ActivityEntry `json:"activity"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Activity) != 1 || payload.Activity[0].Message != "alpha went from running to stopped" {
+		t.Fatalf("expected the recorded status change, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	srv.handleActivity(rec, httptest.NewRequest(http.MethodGet, "/api/activity?limit=1000", nil))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"query.limit"`) {
+		t.Fatalf("expected a validation error, got %d %s", rec.Code, rec.Body.String())
+	}
+}
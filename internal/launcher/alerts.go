@@ -0,0 +1,178 @@
+package launcher
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Resource alerts are checked against the usage history after every sample.
+// A memory alert fires once every sample of the last MemoryMinutes is above
+// MemoryPercent of the profile's memory limit; a disk alert fires as soon
+// as the profile's volumes grow past DiskGB. A firing alert is posted as a
+// notification, which also reaches the notification channels, and marks the
+// profile "alerting" until usage drops back under the threshold.
+
+const (
+	noticeResourceAlert = "resource_alert"
+
+	alertMemory = "memory"
+	alertDisk   = "disk"
+
+	defaultAlertMemoryMinutes = 5
+	maxAlertMemoryMinutes     = 24 * 60
+	// defaultMemoryLimit mirrors the mem_limit compose uses when a profile
+	// leaves its memory limit empty.
+	defaultMemoryLimit = "4024M"
+)
+
+// AlertSettings are a profile's resource alert thresholds. Zero turns an
+// alert off.
+type AlertSettings struct {
+	MemoryPercent int     `json:"memoryPercent,omitempty"`
+	MemoryMinutes int     `json:"memoryMinutes,omitempty"`
+	DiskGB        float64 `json:"diskGB,omitempty"`
+}
+
+func normalizeAlertSettings(a *AlertSettings) error {
+	if a.MemoryPercent < 0 || a.MemoryPercent > 100 {
+		return fieldError("alertMemoryPercent", "alert.memory_percent", "memory alert must be between 1 and 100 percent, or 0 for none")
+	}
+	if a.MemoryMinutes < 0 || a.MemoryMinutes > maxAlertMemoryMinutes {
+		return fieldError("alertMemoryMinutes", "alert.memory_minutes", fmt.Sprintf("memory alert duration must be between 1 and %d minutes", maxAlertMemoryMinutes))
+	}
+	if a.MemoryPercent == 0 {
+		a.MemoryMinutes = 0
+	} else if a.MemoryMinutes == 0 {
+		a.MemoryMinutes = defaultAlertMemoryMinutes
+	}
+	if a.DiskGB < 0 || math.IsNaN(a.DiskGB) || math.IsInf(a.DiskGB, 0) {
+		return fieldError("alertDiskGB", "alert.disk", "disk alert must be a positive size in GB, or 0 for none")
+	}
+	return nil
+}
+
+// memoryLimitBytes reads a compose memory limit such as "512mb" or "2g".
+// Compose treats the units as binary.
+func memoryLimitBytes(mem string) int64 {
+	mem = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(mem), " ", ""))
+	if mem == "" {
+		mem = strings.ToLower(defaultMemoryLimit)
+	}
+	mem = strings.TrimSuffix(mem, "b")
+	mult := int64(1)
+	switch {
+	case strings.HasSuffix(mem, "k"):
+		mult = 1 << 10
+	case strings.HasSuffix(mem, "m"):
+		mult = 1 << 20
+	case strings.HasSuffix(mem, "g"):
+		mult = 1 << 30
+	}
+	value, err := strconv.ParseFloat(strings.TrimRight(mem, "kmg"), 64)
+	if err != nil {
+		return 0
+	}
+	return int64(value * float64(mult))
+}
+
+// firingAlerts returns which of the profile's alerts fire for its usage
+// history, each with a message describing it.
+func firingAlerts(p ProfileRequest, samples []UsageSample, now time.Time) map[string]string {
+	firing := map[string]string{}
+	if len(samples) == 0 {
+		return firing
+	}
+	if p.Alerts.MemoryPercent > 0 {
+		limit := memoryLimitBytes(p.Resources.Limits.Memory)
+		window := time.Duration(p.Alerts.MemoryMinutes) * time.Minute
+		threshold := float64(limit) * float64(p.Alerts.MemoryPercent) / 100
+		recent := 0
+		above := limit > 0
+		for _, sample := range samples {
+			if sample.At.Before(now.Add(-window)) {
+				continue
+			}
+			recent++
+			above = above && float64(sample.MemoryBytes) > threshold
+		}
+		if above && recent >= p.Alerts.MemoryMinutes {
+			firing[alertMemory] = fmt.Sprintf("%s has used more than %d%% of its %s memory limit for %d minutes",
+				p.ID, p.Alerts.MemoryPercent, formatBytes(limit), p.Alerts.MemoryMinutes)
+		}
+	}
+	if p.Alerts.DiskGB > 0 {
+		last := samples[len(samples)-1]
+		if float64(last.DiskBytes) > p.Alerts.DiskGB*1e9 {
+			firing[alertDisk] = fmt.Sprintf("%s uses %.1f GB of disk, above its %g GB alert", p.ID, float64(last.DiskBytes)/1e9, p.Alerts.DiskGB)
+		}
+	}
+	return firing
+}
+
+// checkResourceAlerts refreshes the resource alert notices and sends the
+// ones that started firing. An alert that keeps firing keeps its notice, so
+// it is only sent once; the notice ID carries the start time, so dismissing
+// one does not hide the next time the profile runs hot.
+func (s *Server) checkResourceAlerts(profiles []ProfileRequest, now time.Time) {
+	current := map[string]Notification{}
+	for _, n := range s.notices.ofKind(noticeResourceAlert) {
+		current[n.ProfileID+":"+n.Metric] = n
+	}
+	notices := []Notification{}
+	for _, p := range profiles {
+		if !p.Enabled || p.Archived || p.DeletedAt != "" {
+			continue
+		}
+		if p.Alerts.MemoryPercent == 0 && p.Alerts.DiskGB == 0 {
+			continue
+		}
+		samples := s.usage.since(p.ID, now.Add(-time.Duration(max(p.Alerts.MemoryMinutes, 1))*time.Minute))
+		for metric, message := range firingAlerts(p, samples, now) {
+			n, ok := current[p.ID+":"+metric]
+			if !ok {
+				n = Notification{
+					ID:        noticeResourceAlert + ":" + p.ID + ":" + metric + ":" + now.UTC().Format("20060102T150405"),
+					Kind:      noticeResourceAlert,
+					ProfileID: p.ID,
+					Metric:    metric,
+					CreatedAt: now.UTC().Format(time.RFC3339),
+				}
+				logWarn("resource_alert_firing", map[string]any{"profile_id": p.ID, "metric": metric})
+			}
+			n.Message = message
+			notices = append(notices, n)
+		}
+	}
+	for key := range current {
+		id, metric, _ := strings.Cut(key, ":")
+		if !containsAlert(notices, id, metric) {
+			logInfo("resource_alert_resolved", map[string]any{"profile_id": id, "metric": metric})
+		}
+	}
+	s.sendNotifications(s.notices.replaceKinds([]string{noticeResourceAlert}, notices))
+}
+
+func containsAlert(notices []Notification, profileID, metric string) bool {
+	for _, n := range notices {
+		if n.ProfileID == profileID && n.Metric == metric {
+			return true
+		}
+	}
+	return false
+}
+
+// alertingMetrics lists the metrics with a firing alert for each profile.
+func (s *Server) alertingMetrics() map[string][]string {
+	out := map[string][]string{}
+	for _, n := range s.notices.ofKind(noticeResourceAlert) {
+		out[n.ProfileID] = append(out[n.ProfileID], n.Metric)
+	}
+	for _, metrics := range out {
+		sort.Strings(metrics)
+	}
+	return out
+}
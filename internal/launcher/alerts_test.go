@@ -0,0 +1,80 @@
+package launcher
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAlertSettingsAndMemoryLimits(t *testing.T) {
+	a := AlertSettings{MemoryPercent: 90}
+	if err := normalizeAlertSettings(&a); err != nil || a.MemoryMinutes != defaultAlertMemoryMinutes {
+		t.Fatalf("expected the default duration, got %+v (%v)", a, err)
+	}
+	for _, bad := range []AlertSettings{{MemoryPercent: 120}, {MemoryPercent: 90, MemoryMinutes: -1}, {DiskGB: -2}} {
+		if err := normalizeAlertSettings(&bad); err == nil {
+			t.Fatalf("expected %+v to be rejected", bad)
+		}
+	}
+	for mem, want := range map[string]int64{"512mb": 512 << 20, "2g": 2 << 30, "1.5 GB": 3 << 29, "": 4024 << 20, "bogus": 0} {
+		if got := memoryLimitBytes(mem); got != want {
+			t.Fatalf("memoryLimitBytes(%q) = %d, want %d", mem, got, want)
+		}
+	}
+}
+
+func TestResourceAlertsNotifyOnceAndResolve(t *testing.T) {
+	srv := newServiceTestServer(t)
+	profile := ProfileRequest{ID: "alpha", Version: "1.0.0", Enabled: true, Alerts: AlertSettings{MemoryPercent: 90, MemoryMinutes: 5, DiskGB: 10}}
+	profile.Resources.Limits.Memory = "1g"
+	if err := writeProfileStoreAtomic(srv.dbPath, ProfileStore{Profiles: []ProfileRequest{profile}}); err != nil {
+		t.Fatal(err)
+	}
+	memory := int64(950 << 20)
+	prevStats, prevDisk := readProfileStats, readProfileDisk
+	readProfileStats = func(context.Context) (map[string]containerUsage, error) {
+		return map[string]containerUsage{"alpha": {memory: memory}}, nil
+	}
+	readProfileDisk = func(context.Context) (map[string]int64, error) {
+		return map[string]int64{"alpha": 2e9}, nil
+	}
+	defer func() { readProfileStats, readProfileDisk = prevStats, prevDisk }()
+
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < 4; i++ {
+		srv.sampleUsage(context.Background(), start.Add(time.Duration(i)*time.Minute), i == 0)
+	}
+	if got := srv.alertingMetrics(); len(got) != 0 {
+		t.Fatalf("expected no alert before five minutes, got %v", got)
+	}
+	srv.sampleUsage(context.Background(), start.Add(4*time.Minute), false)
+	srv.sampleUsage(context.Background(), start.Add(5*time.Minute), false)
+	alerts := srv.notices.ofKind(noticeResourceAlert)
+	if len(alerts) != 1 || alerts[0].Metric != alertMemory || alerts[0].ProfileID != "alpha" {
+		t.Fatalf("expected one memory alert, got %+v", alerts)
+	}
+	profiles, err := srv.Profiles().List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(profiles) != 1 || len(profiles[0].Alerting) != 1 || profiles[0].Alerting[0] != alertMemory {
+		t.Fatalf("expected the profile to be marked alerting, got %+v", profiles)
+	}
+
+	// An update check must not drop the alert, and a still-firing alert
+	// keeps its notice instead of being sent again.
+	srv.notices.replace([]Notification{{ID: "launcher_update:9.0.0", Kind: noticeLauncherUpdate, Version: "9.0.0"}})
+	srv.sampleUsage(context.Background(), start.Add(6*time.Minute), false)
+	if again := srv.notices.ofKind(noticeResourceAlert); len(again) != 1 || again[0].ID != alerts[0].ID {
+		t.Fatalf("expected the same alert to keep firing, got %+v", again)
+	}
+
+	memory = 100 << 20
+	srv.sampleUsage(context.Background(), start.Add(7*time.Minute), false)
+	if got := srv.notices.ofKind(noticeResourceAlert); len(got) != 0 {
+		t.Fatalf("expected the alert to resolve, got %+v", got)
+	}
+	if len(srv.notices.ofKind(noticeLauncherUpdate)) != 1 {
+		t.Fatal("expected alerts to leave update notices alone")
+	}
+}
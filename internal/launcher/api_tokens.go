@@ -0,0 +1,305 @@
+package launcher
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// API tokens let scripts and other launchers call the HTTP API without a
+// browser. A request with "Authorization: Bearer <token>" is authenticated
+// by the token alone: it skips the loopback, Origin and CSRF checks, which
+// only exist because browsers attach cookies on their own. Browsers never
+// add this header cross-site, so cookie traffic stays protected as before.
+// A request that sends the header with an unknown token is refused rather
+// than treated as a browser request.
+
+const (
+	apiTokensFileName = "api_tokens.json"
+	apiTokenPrefix    = "kml_"
+	maxAPITokens      = 100
+)
+
+var ErrAPITokenNotFound = errors.New("API token not found")
+
+// APIToken is one issued token. Only its SHA-256 is stored; the token is
+// shown once, when it is created.
+type APIToken struct {
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	Hash      string `json:"hash,omitempty"`
+	Hint      string `json:"hint"`
+	CreatedAt string `json:"createdAt"`
+	// Profiles limits a deploy token to these profiles; empty allows all.
+	Profiles []string `json:"profiles,omitempty"`
+}
+
+func (t APIToken) public() APIToken {
+	t.Hash = ""
+	return t
+}
+
+// allowsProfile reports whether the token may deploy profile id.
+func (t APIToken) allowsProfile(id string) bool {
+	return len(t.Profiles) == 0 || slices.Contains(t.Profiles, id)
+}
+
+// tokenStore keeps the tokens in api_tokens.json and, like the account
+// store, re-reads the file when it changes so tokens created with the CLI
+// work on a running launcher.
+type tokenStore struct {
+	mu      sync.Mutex
+	path    string
+	modTime time.Time
+	tokens  []APIToken
+}
+
+func newTokenStore(dataDir string) *tokenStore {
+	t := &tokenStore{path: filepath.Join(dataDir, apiTokensFileName)}
+	t.mu.Lock()
+	t.refreshLocked()
+	t.mu.Unlock()
+	return t
+}
+
+func (t *tokenStore) refreshLocked() {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		t.tokens, t.modTime = nil, time.Time{}
+		return
+	}
+	if info.ModTime().Equal(t.modTime) {
+		return
+	}
+	raw, err := os.ReadFile(t.path)
+	if err != nil {
+		logWarn("api_tokens_file_read_failed", map[string]any{"error": err.Error()})
+		return
+	}
+	var tokens []APIToken
+	if err := json.Unmarshal(raw, &tokens); err != nil {
+		logWarn("api_tokens_file_invalid", map[string]any{"error": err.Error()})
+		return
+	}
+	t.tokens, t.modTime = tokens, info.ModTime()
+}
+
+func (t *tokenStore) list() []APIToken {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.refreshLocked()
+	out := make([]APIToken, 0, len(t.tokens))
+	for _, tok := range t.tokens {
+		out = append(out, tok.public())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// create issues a token and returns it with its secret; a token with the
+// same name is replaced. profiles limits a deploy token to those profiles.
+func (t *tokenStore) create(name, role string, profiles []string) (APIToken, string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	role = strings.ToLower(strings.TrimSpace(role))
+	if !usernameRe.MatchString(name) {
+		return APIToken{}, "", ValidationError{Msg: "Token name must be lowercase letters, digits, '.', '_' or '-'"}
+	}
+	if role != roleAdmin && role != roleViewer && role != roleDeploy {
+		return APIToken{}, "", ValidationError{Msg: "Role must be admin, viewer or deploy"}
+	}
+	allowed := []string{}
+	for _, id := range profiles {
+		id = normalizeProfileID(id)
+		if id == "" || slices.Contains(allowed, id) {
+			continue
+		}
+		if !profileIDRe.MatchString(id) {
+			return APIToken{}, "", ValidationError{Msg: "Invalid profile id " + id}
+		}
+		allowed = append(allowed, id)
+	}
+	if len(allowed) > 0 && role != roleDeploy {
+		return APIToken{}, "", ValidationError{Msg: "Only deploy tokens can be limited to profiles"}
+	}
+	slices.Sort(allowed)
+	if len(allowed) == 0 {
+		allowed = nil
+	}
+	buf := make([]byte, 30)
+	if _, err := rand.Read(buf); err != nil {
+		return APIToken{}, "", err
+	}
+	secret := apiTokenPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	tok := APIToken{
+		Name:      name,
+		Role:      role,
+		Hash:      hashAPIToken(secret),
+		Hint:      secret[len(secret)-4:],
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		Profiles:  allowed,
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.refreshLocked()
+	next := []APIToken{}
+	for _, existing := range t.tokens {
+		if existing.Name != name {
+			next = append(next, existing)
+		}
+	}
+	if len(next) >= maxAPITokens {
+		return APIToken{}, "", ValidationError{Msg: "Too many API tokens; revoke unused ones first"}
+	}
+	if err := t.saveLocked(append(next, tok)); err != nil {
+		return APIToken{}, "", err
+	}
+	return tok.public(), secret, nil
+}
+
+func (t *tokenStore) revoke(name string) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.refreshLocked()
+	next := []APIToken{}
+	for _, tok := range t.tokens {
+		if tok.Name != name {
+			next = append(next, tok)
+		}
+	}
+	if len(next) == len(t.tokens) {
+		return ErrAPITokenNotFound
+	}
+	return t.saveLocked(next)
+}
+
+// lookup returns the token matching secret. Tokens carry 240 random bits,
+// so comparing their hashes leaks nothing useful.
+func (t *tokenStore) lookup(secret string) (APIToken, bool) {
+	if !strings.HasPrefix(secret, apiTokenPrefix) {
+		return APIToken{}, false
+	}
+	hash := hashAPIToken(secret)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.refreshLocked()
+	for _, tok := range t.tokens {
+		if tok.Hash == hash {
+			return tok.public(), true
+		}
+	}
+	return APIToken{}, false
+}
+
+func (t *tokenStore) saveLocked(tokens []APIToken) error {
+	raw, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := t.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, t.path); err != nil {
+		return err
+	}
+	t.tokens = tokens
+	if info, err := os.Stat(t.path); err == nil {
+		t.modTime = info.ModTime()
+	}
+	return nil
+}
+
+func hashAPIToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+type apiTokenCtxKey struct{}
+
+// isTokenRequest reports whether withAPIToken authenticated the request.
+func isTokenRequest(r *http.Request) bool {
+	_, ok := r.Context().Value(apiTokenCtxKey{}).(APIToken)
+	return ok
+}
+
+// withAPIToken authenticates requests that carry an Authorization header
+// and applies the token's role. Requests without the header pass through
+// to the cookie-based checks.
+func (s *Server) withAPIToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := strings.TrimSpace(r.Header.Get("Authorization"))
+		if header == "" || s.tokens == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		scheme, secret, _ := strings.Cut(header, " ")
+		tok, ok := APIToken{}, false
+		if strings.EqualFold(scheme, "Bearer") {
+			tok, ok = s.tokens.lookup(strings.TrimSpace(secret))
+		}
+		if !ok {
+			auditLog("WARN", "api_token_rejected", map[string]any{"remote": clientIP(r), "path": r.URL.Path, "method": r.Method})
+			w.Header().Set("WWW-Authenticate", `Bearer realm="kimmio-launcher"`)
+			http.Error(w, "unauthorized: invalid API token", http.StatusUnauthorized)
+			return
+		}
+		acc := Account{Username: "token:" + tok.Name, Role: tok.Role}
+		if refuseForRole(w, r, acc) {
+			return
+		}
+		ctx := context.WithValue(r.Context(), accountCtxKey{}, acc)
+		ctx = context.WithValue(ctx, apiTokenCtxKey{}, tok)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// handleAPITokens serves GET/POST /api/tokens and DELETE /api/tokens/<name>.
+func (s *Server) handleAPITokens(w http.ResponseWriter, r *http.Request) {
+	name := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/tokens"), "/")
+	switch {
+	case name == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "tokens": s.tokens.list()})
+	case name == "" && r.Method == http.MethodPost:
+		var body struct {
+			Name     string   `json:"name"`
+			Role     string   `json:"role"`
+			Profiles []string `json:"profiles"`
+		}
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&body); err != nil {
+			http.Error(w, "invalid JSON body", bodyErrorStatus(err))
+			return
+		}
+		tok, secret, err := s.tokens.create(body.Name, body.Role, body.Profiles)
+		if err != nil {
+			http.Error(w, err.Error(), httpStatusForError(err))
+			return
+		}
+		auditLog("INFO", "api_token_created", map[string]any{"name": tok.Name, "role": tok.Role, "profiles": tok.Profiles})
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "token": tok, "secret": secret})
+	case name != "" && r.Method == http.MethodDelete:
+		if err := s.tokens.revoke(name); err != nil {
+			http.Error(w, err.Error(), httpStatusForError(err))
+			return
+		}
+		auditLog("INFO", "api_token_revoked", map[string]any{"name": name})
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
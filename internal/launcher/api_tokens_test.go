@@ -0,0 +1,110 @@
+package launcher
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestTokenStoreKeepsOnlyHashes(t *testing.T) {
+	store := newTokenStore(t.TempDir())
+	if _, _, err := store.create("ci", "owner", nil); err == nil {
+		t.Fatal("expected an unknown role to be rejected")
+	}
+	tok, secret, err := store.create("CI", roleAdmin, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.Name != "ci" || tok.Hash != "" || !strings.HasPrefix(secret, apiTokenPrefix) || !strings.HasSuffix(secret, tok.Hint) {
+		t.Fatalf("unexpected token %+v / %q", tok, secret)
+	}
+	raw, err := os.ReadFile(store.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), secret) {
+		t.Fatal("expected the secret not to be stored")
+	}
+	if got, ok := newTokenStore(t.TempDir()).lookup(secret); ok {
+		t.Fatalf("expected another data dir not to know the token, got %+v", got)
+	}
+	if got, ok := store.lookup(secret); !ok || got.Name != "ci" {
+		t.Fatalf("expected the token to resolve, got %+v", got)
+	}
+	if err := store.revoke("ci"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.lookup(secret); ok {
+		t.Fatal("expected a revoked token to stop working")
+	}
+	if err := store.revoke("ci"); err != ErrAPITokenNotFound {
+		t.Fatalf("expected ErrAPITokenNotFound, got %v", err)
+	}
+}
+
+func TestAPITokensSkipBrowserChecks(t *testing.T) {
+	srv := &Server{httpMetrics: newHTTPMetrics(), tokens: newTokenStore(t.TempDir())}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+	})
+	h := srv.httpHandler(mux)
+	_, admin, _ := srv.tokens.create("deploy", roleAdmin, nil)
+	_, viewer, _ := srv.tokens.create("dashboard", roleViewer, nil)
+
+	do := func(method, path, remote, auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "http://launcher.lan"+path, nil)
+		req.RemoteAddr = remote
+		req.Header.Set("Origin", "https://elsewhere.example")
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/api/profiles/demo/enable", "192.0.2.1:1234", "Bearer "+admin); rec.Code != http.StatusOK {
+		t.Fatalf("expected an admin token to mutate without CSRF, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/api/profiles", "192.0.2.1:1234", "Bearer "+viewer); rec.Code != http.StatusOK {
+		t.Fatalf("expected a viewer token to read, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/profiles/demo/enable", "192.0.2.1:1234", "Bearer "+viewer); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected a viewer token mutation to be refused, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/api/tokens", "192.0.2.1:1234", "Bearer "+viewer); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected token management to be admin-only, got %d", rec.Code)
+	}
+	for _, auth := range []string{"Bearer kml_nope", "Basic dXNlcjpwYXNz"} {
+		if rec := do(http.MethodGet, "/api/profiles", "127.0.0.1:1234", auth); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("expected %q to be refused even from loopback, got %d", auth, rec.Code)
+		}
+	}
+	// Browser traffic without the header is checked as before.
+	if rec := do(http.MethodPost, "/api/profiles/demo/enable", "127.0.0.1:1234", ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected a cookie-less browser mutation to be refused, got %d", rec.Code)
+	}
+}
+
+func TestTokenCLIPrintsSecretOnce(t *testing.T) {
+	srv := newServiceTestServer(t)
+	var stdout, stderr bytes.Buffer
+	if code := runTokenCLI(srv, []string{"create", "ci", "--role", "viewer"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected create to succeed, got %d: %s", code, stderr.String())
+	}
+	secret := strings.TrimSpace(stdout.String())
+	if _, ok := srv.tokens.lookup(secret); !ok {
+		t.Fatalf("expected stdout to hold only the token, got %q", stdout.String())
+	}
+	stdout.Reset()
+	if code := runTokenCLI(srv, []string{"list"}, &stdout, &stderr); code != exitOK || strings.Contains(stdout.String(), secret) || !strings.Contains(stdout.String(), "ci") {
+		t.Fatalf("expected the list to name the token without its secret, got %s", stdout.String())
+	}
+	if code := runTokenCLI(srv, []string{"revoke", "ghost"}, &stdout, &stderr); code != exitNotFound {
+		t.Fatalf("expected a missing token to exit %d, got %d", exitNotFound, code)
+	}
+}
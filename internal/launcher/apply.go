@@ -0,0 +1,366 @@
+package launcher
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// `apply -f profiles.yaml` makes the profiles match a file kept under
+// version control. Only the fields a file names are reconciled: a profile
+// without `enabled` keeps its state, env keys not listed are left alone, and
+// profiles missing from the file are never deleted.
+
+// ApplySpec is one desired profile in an apply file.
+type ApplySpec struct {
+	ID      string            `yaml:"id"`
+	Version string            `yaml:"version"`
+	Port    int               `yaml:"port"`
+	Env     map[string]string `yaml:"env"`
+	Enabled *bool             `yaml:"enabled"`
+}
+
+type applyFile struct {
+	Profiles []ApplySpec `yaml:"profiles"`
+}
+
+// applyStep is one change apply makes. Action is "create", "configure" or a
+// profile action run through the service.
+type applyStep struct {
+	ProfileID string
+	Action    string
+	Detail    string
+	spec      ApplySpec
+}
+
+func (st applyStep) String() string {
+	if st.Detail == "" {
+		return st.ProfileID + ": " + st.Action
+	}
+	return fmt.Sprintf("%s: %s (%s)", st.ProfileID, st.Action, st.Detail)
+}
+
+// parseApplyFile reads and checks an apply file. JSON is accepted too, as
+// it is valid YAML.
+func parseApplyFile(b []byte) ([]ApplySpec, error) {
+	var file applyFile
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, ValidationError{Msg: "invalid apply file: " + err.Error()}
+	}
+	if len(file.Profiles) == 0 {
+		return nil, ValidationError{Msg: "apply file lists no profiles"}
+	}
+	seen := map[string]bool{}
+	var errs fieldErrors
+	for i := range file.Profiles {
+		spec := &file.Profiles[i]
+		spec.ID = normalizeProfileID(spec.ID)
+		spec.Version = strings.TrimSpace(spec.Version)
+		switch {
+		case !profileIDRe.MatchString(spec.ID):
+			errs.add("profiles", "id.invalid", fmt.Errorf("profile %d: invalid id %q", i+1, spec.ID))
+			continue
+		case seen[spec.ID]:
+			errs.add("profiles", "id.duplicate", fmt.Errorf("profile %s is listed twice", spec.ID))
+		}
+		seen[spec.ID] = true
+		if spec.Version != "" && !versionTagRe.MatchString(spec.Version) {
+			errs.add("version", "version.invalid", fmt.Errorf("profile %s: invalid version tag %q", spec.ID, spec.Version))
+		}
+		if spec.Port < 0 || spec.Port > 65535 {
+			errs.add("hostPort", "port.range", fmt.Errorf("profile %s: port must be in range 1..65535", spec.ID))
+		}
+		for k, v := range spec.Env {
+			if !isSafeEnvKey(k) {
+				errs.add("env", "env.invalid", fmt.Errorf("profile %s: invalid env key %q", spec.ID, k))
+			}
+			spec.Env[k] = strings.TrimSpace(v)
+		}
+	}
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+	return file.Profiles, nil
+}
+
+// planApply lists the steps that bring current to specs, in the order they
+// run. current holds each profile's env including its secrets. A profile
+// that is stopped does not get a new version or config built before it
+// stops, and one that stays running is recreated once to pick up config.
+func planApply(specs []ApplySpec, current []ProfileRequest) ([]applyStep, error) {
+	steps := []applyStep{}
+	for _, spec := range specs {
+		add := func(action, detail string) {
+			steps = append(steps, applyStep{ProfileID: spec.ID, Action: action, Detail: detail, spec: spec})
+		}
+		idx := findProfileIndex(ProfileStore{Profiles: current}, spec.ID)
+		if idx < 0 {
+			if spec.Port == 0 {
+				return nil, ValidationError{Msg: fmt.Sprintf("profile %s does not exist yet; set a port to create it", spec.ID)}
+			}
+			version := spec.Version
+			if version == "" {
+				version = "latest"
+			}
+			add("create", fmt.Sprintf("version %s, port %d", version, spec.Port))
+			if spec.Enabled != nil && *spec.Enabled {
+				add("enable", "")
+			}
+			continue
+		}
+
+		p := current[idx]
+		switch {
+		case p.DeletedAt != "":
+			return nil, ValidationError{Msg: fmt.Sprintf("profile %s is in the trash; restore it before applying", spec.ID)}
+		case p.Archived:
+			return nil, ValidationError{Msg: fmt.Sprintf("profile %s is archived; unarchive it before applying", spec.ID)}
+		}
+		changes := []string{}
+		if port := profilePort(p); spec.Port != 0 && spec.Port != port {
+			changes = append(changes, fmt.Sprintf("port %d -> %d", port, spec.Port))
+		}
+		if keys := changedEnvKeys(p.Env, spec.Env); len(keys) > 0 {
+			changes = append(changes, "env "+strings.Join(keys, ", "))
+		}
+		newVersion := spec.Version != "" && spec.Version != p.Version
+		enabled := p.Enabled
+		if spec.Enabled != nil {
+			enabled = *spec.Enabled
+		}
+
+		if p.Enabled && !enabled {
+			add("stop", "")
+		}
+		if len(changes) > 0 {
+			add("configure", strings.Join(changes, "; "))
+		}
+		if newVersion {
+			add("version", p.Version+" -> "+spec.Version)
+		}
+		switch {
+		case enabled && !p.Enabled:
+			add("enable", "")
+		case enabled && len(changes) > 0 && !newVersion:
+			add("recreate", "")
+		case enabled && !newVersion && containersStopped(p):
+			add("enable", "containers stopped")
+		}
+	}
+	return steps, nil
+}
+
+// containersStopped reports an enabled profile whose containers were
+// stopped outside the launcher, e.g. with docker stop. Crash-looping
+// profiles are left alone: starting them again would not help.
+func containersStopped(p ProfileRequest) bool {
+	if !p.Enabled || p.RuntimeStatus != "unhealthy" {
+		return false
+	}
+	for _, svc := range p.Services {
+		switch svc.State {
+		case "exited", "dead", "created":
+			return true
+		}
+	}
+	return false
+}
+
+func profilePort(p ProfileRequest) int {
+	if len(p.Ports) == 0 {
+		return 0
+	}
+	return p.Ports[0].Host
+}
+
+// changedEnvKeys lists the keys in want whose values differ from current.
+// Only key names are returned, so secrets never reach the output.
+func changedEnvKeys(current, want map[string]string) []string {
+	keys := []string{}
+	for k, v := range want {
+		if cur, ok := current[k]; !ok || strings.TrimSpace(cur) != v {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// applyState returns the profiles with their runtime status and with their
+// secrets merged into Env, as planApply compares them.
+func (s *Server) applyState(ctx context.Context) ([]ProfileRequest, error) {
+	profiles, err := s.profilesWithStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range profiles {
+		env := maps.Clone(profiles[i].Env)
+		if env == nil {
+			env = map[string]string{}
+		}
+		maps.Copy(env, loadProfileSecrets(profiles[i].ID))
+		profiles[i].Env = env
+	}
+	return profiles, nil
+}
+
+func (s *Server) runApplyStep(ctx context.Context, st applyStep) error {
+	switch st.Action {
+	case "create":
+		req := ProfileRequest{
+			ID:      st.spec.ID,
+			Version: st.spec.Version,
+			Ports:   []PortMapping{{Container: 3000, Host: st.spec.Port}},
+			Env:     maps.Clone(st.spec.Env),
+		}
+		_, err := s.Profiles().Create(ctx, req)
+		return err
+	case "configure":
+		return s.configureProfile(ctx, st.spec.ID, st.spec.Port, st.spec.Env)
+	case "version":
+		return s.Profiles().RunAction(ctx, st.ProfileID, "version", st.spec.Version, 0, false)
+	default:
+		// The plan recreates only profiles the file names; the file is the
+		// operator's confirmation.
+		return s.Profiles().RunAction(ctx, st.ProfileID, st.Action, "", 0, true)
+	}
+}
+
+// configureProfile moves a profile to another host port and sets env keys,
+// keeping secrets in the profile's secret file. The containers pick the
+// change up on their next enable, recreate or version update.
+func (s *Server) configureProfile(ctx context.Context, id string, port int, env map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	store, err := s.loadStoreLocked(ctx)
+	if err != nil {
+		return err
+	}
+	idx := findProfileIndex(store, id)
+	if idx < 0 {
+		return ErrProfileNotFound
+	}
+	p := store.Profiles[idx]
+	publicEnv, secretEnv := splitSecretEnv(env)
+	p.Env = maps.Clone(p.Env)
+	if p.Env == nil {
+		p.Env = map[string]string{}
+	}
+	maps.Copy(p.Env, publicEnv)
+	secrets := loadProfileSecrets(id)
+	maps.Copy(secrets, secretEnv)
+
+	all := maps.Clone(p.Env)
+	maps.Copy(all, secrets)
+	if err := validateProfileEnv(p.Version, all); err != nil {
+		return err
+	}
+	if port != 0 && port != profilePort(p) {
+		p.Ports = slices.Clone(p.Ports)
+		if len(p.Ports) == 0 {
+			p.Ports = []PortMapping{{Container: 3000}}
+		}
+		p.Ports[0].Host = port
+		others := ProfileStore{Profiles: slices.Delete(slices.Clone(store.Profiles), idx, idx+1)}
+		if err := validateCreateConstraints(p, others); err != nil {
+			return err
+		}
+	}
+	p.Revision++
+	store.Profiles[idx] = p
+	if len(secretEnv) > 0 {
+		return s.commitStoreTxLocked("apply", id, store, secretsTxOps(id, secrets)...)
+	}
+	return s.writeStoreLocked(store)
+}
+
+func runApplyCLI(srv *Server, args []string, stdout, stderr io.Writer) int {
+	path, dryRun := "", false
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-f", "--file":
+			if i+1 >= len(args) {
+				writeApplyCLIUsage(stderr)
+				return exitUsage
+			}
+			i++
+			path = args[i]
+		case "--dry-run":
+			dryRun = true
+		case "help", "-h", "--help":
+			writeApplyCLIUsage(stdout)
+			return 0
+		default:
+			writeApplyCLIUsage(stderr)
+			return exitUsage
+		}
+	}
+	if strings.TrimSpace(path) == "" {
+		writeApplyCLIUsage(stderr)
+		return exitUsage
+	}
+	if activeCLIContext.URL != "" {
+		fmt.Fprintln(stderr, "apply works on the data directory; use a context without a URL or run it on the launcher's host.")
+		return exitUsage
+	}
+
+	var raw []byte
+	var err error
+	if path == "-" {
+		raw, err = io.ReadAll(os.Stdin)
+	} else {
+		raw, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return cliFail(stderr, "Failed to read apply file", err)
+	}
+	specs, err := parseApplyFile(raw)
+	if err != nil {
+		return cliFail(stderr, "Invalid apply file", err)
+	}
+	ctx := context.Background()
+	current, err := srv.applyState(ctx)
+	if err != nil {
+		return cliFail(stderr, "Failed to load profiles", err)
+	}
+	steps, err := planApply(specs, current)
+	if err != nil {
+		return cliFail(stderr, "Cannot apply", err)
+	}
+	if len(steps) == 0 {
+		fmt.Fprintln(stdout, "Profiles already match the file.")
+		return 0
+	}
+	if dryRun {
+		fmt.Fprintln(stdout, "Planned changes:")
+		for _, st := range steps {
+			fmt.Fprintf(stdout, "  %s\n", st)
+		}
+		return 0
+	}
+	for i, st := range steps {
+		fmt.Fprintf(stdout, "[%d/%d] %s\n", i+1, len(steps), st)
+		if err := srv.runApplyStep(ctx, st); err != nil {
+			auditLog("WARN", "profiles_apply_failed", map[string]any{"profile": st.ProfileID, "action": st.Action, "error": err.Error()})
+			return cliFail(stderr, fmt.Sprintf("%s %s failed", st.ProfileID, st.Action), err)
+		}
+	}
+	auditLog("INFO", "profiles_applied", map[string]any{"file": path, "steps": len(steps)})
+	fmt.Fprintf(stdout, "Applied %d changes.\n", len(steps))
+	return 0
+}
+
+func writeApplyCLIUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: apply -f <profiles.yaml|-> [--dry-run]")
+}
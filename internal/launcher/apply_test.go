@@ -0,0 +1,130 @@
+package launcher
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestParseApplyFile(t *testing.T) {
+	specs, err := parseApplyFile([]byte(`
+profiles:
+  - id: Alpha
+    version: 1.1.0
+    port: 8090
+    env:
+      APP_DOMAIN: shop.example.com
+      MAX_UPLOAD_MB: 50
+    enabled: true
+  - id: beta
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(specs) != 2 || specs[0].ID != "alpha" || specs[0].Env["MAX_UPLOAD_MB"] != "50" || specs[0].Enabled == nil || !*specs[0].Enabled || specs[1].Enabled != nil {
+		t.Fatalf("unexpected specs %+v", specs)
+	}
+	for _, bad := range []string{
+		"profiles: []",
+		"profiles:\n  - id: alpha\n  - id: alpha\n",
+		"profiles:\n  - id: alpha\n    colour: red\n",
+		"profiles:\n  - id: alpha\n    env:\n      lower: x\n",
+	} {
+		if _, err := parseApplyFile([]byte(bad)); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestPlanApply(t *testing.T) {
+	on, off := true, false
+	current := []ProfileRequest{
+		{ID: "alpha", Version: "1.0.0", Enabled: true, Ports: []PortMapping{{Host: 8088}}, Env: map[string]string{"APP_DOMAIN": "old.example.com"}},
+		{ID: "beta", Version: "1.0.0", Enabled: true, Ports: []PortMapping{{Host: 8089}}},
+		{ID: "gamma", Version: "1.0.0", Ports: []PortMapping{{Host: 8087}}},
+	}
+	specs := []ApplySpec{
+		{ID: "alpha", Version: "1.0.0", Env: map[string]string{"APP_DOMAIN": "new.example.com"}},
+		{ID: "beta", Version: "1.1.0", Enabled: &off},
+		{ID: "gamma", Port: 8087, Enabled: &on},
+		{ID: "delta", Port: 8091, Enabled: &on},
+	}
+	steps, err := planApply(specs, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, st := range steps {
+		got = append(got, st.String())
+	}
+	want := []string{
+		"alpha: configure (env APP_DOMAIN)",
+		"alpha: recreate",
+		"beta: stop",
+		"beta: version (1.0.0 -> 1.1.0)",
+		"gamma: enable",
+		"delta: create (version latest, port 8091)",
+		"delta: enable",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected plan:\n%s", strings.Join(got, "\n"))
+	}
+
+	if steps, _ := planApply([]ApplySpec{{ID: "alpha", Version: "1.0.0"}}, current); len(steps) != 0 {
+		t.Fatalf("expected no steps for a matching profile, got %v", steps)
+	}
+	if _, err := planApply([]ApplySpec{{ID: "delta"}}, current); err == nil {
+		t.Fatal("expected a new profile without a port to be rejected")
+	}
+	trashed := []ProfileRequest{{ID: "alpha", DeletedAt: "2026-01-01T00:00:00Z"}}
+	if _, err := planApply([]ApplySpec{{ID: "alpha"}}, trashed); err == nil || !strings.Contains(err.Error(), "restore") {
+		t.Fatalf("expected a trashed profile to be refused, got %v", err)
+	}
+}
+
+func TestApplyConfiguresAndCreatesProfiles(t *testing.T) {
+	srv := newServiceTestServer(t)
+	ctx := context.Background()
+	specs, err := parseApplyFile([]byte(`
+profiles:
+  - id: alpha
+    port: 18095
+    env:
+      APP_DOMAIN: shop.example.com
+  - id: beta
+    version: 1.0.0
+    port: 18096
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := srv.applyState(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps, err := planApply(specs, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, st := range steps {
+		if err := srv.runApplyStep(ctx, st); err != nil {
+			t.Fatalf("%s: %v", st, err)
+		}
+	}
+
+	alpha, err := srv.Profiles().Get(ctx, "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profilePort(alpha) != 18095 || alpha.Env["APP_DOMAIN"] != "shop.example.com" || alpha.Revision != 2 {
+		t.Fatalf("expected alpha reconfigured, got %+v", alpha)
+	}
+	if beta, err := srv.Profiles().Get(ctx, "beta"); err != nil || profilePort(beta) != 18096 {
+		t.Fatalf("expected beta created, got %+v %v", beta, err)
+	}
+
+	current, _ = srv.applyState(ctx)
+	if steps, err := planApply(specs, current); err != nil || len(steps) != 0 {
+		t.Fatalf("expected a second apply to change nothing, got %v %v", steps, err)
+	}
+}
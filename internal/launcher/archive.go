@@ -0,0 +1,50 @@
+package launcher
+
+import "context"
+
+// checkArchivedAction keeps archived profiles frozen: they can only be
+// restored or deleted, so nothing (including workflows) starts them by
+// accident.
+func checkArchivedAction(p ProfileRequest, action string) error {
+	switch {
+	case p.Archived && action != "unarchive" && action != "delete":
+		return ValidationError{Msg: "profile " + p.ID + " is archived; unarchive it first"}
+	case !p.Archived && action == "unarchive":
+		return ValidationError{Msg: "profile " + p.ID + " is not archived"}
+	}
+	return nil
+}
+
+// performArchive stops the stack but keeps its volumes, secrets and compose
+// files, so unarchiving and enabling brings the instance back with its data.
+func (s *Server) performArchive(id, jobID string, parent context.Context) error {
+	ctx, cancel := context.WithTimeout(parent, appCfg.ActionTimeout)
+	defer cancel()
+	record := context.WithoutCancel(parent)
+
+	s.updateJobStep(jobID, "down", "running", "Stopping compose stack (volumes are kept)", 35, "")
+	if err := s.compose.down(ctx, id, false); err != nil {
+		_ = s.markProfileResult(record, id, "archive", "failed", err.Error(), "")
+		return err
+	}
+	logInfo("profile_archived", map[string]any{"profile_id": id})
+	return s.markProfileResult(record, id, "archive", "success", "Profile archived; data preserved", "")
+}
+
+// performUnarchive returns the profile to the main list, stopped.
+func (s *Server) performUnarchive(id string, parent context.Context) error {
+	logInfo("profile_unarchived", map[string]any{"profile_id": id})
+	return s.markProfileResult(context.WithoutCancel(parent), id, "unarchive", "success", "Profile restored from archive", "")
+}
+
+// splitArchivedProfiles separates the main list from the archive.
+func splitArchivedProfiles(profiles []ProfileRequest) (active, archived []ProfileRequest) {
+	for _, p := range profiles {
+		if p.Archived {
+			archived = append(archived, p)
+		} else {
+			active = append(active, p)
+		}
+	}
+	return active, archived
+}
@@ -0,0 +1,27 @@
+package launcher
+
+import "testing"
+
+func TestCheckArchivedAction(t *testing.T) {
+	archived := ProfileRequest{ID: "alpha", Archived: true}
+	for _, action := range []string{"enable", "recreate", "version", "archive"} {
+		if err := checkArchivedAction(archived, action); err == nil {
+			t.Fatalf("expected %s to be rejected for an archived profile", action)
+		}
+	}
+	for _, action := range []string{"unarchive", "delete"} {
+		if err := checkArchivedAction(archived, action); err != nil {
+			t.Fatalf("%s should be allowed: %v", action, err)
+		}
+	}
+	if err := checkArchivedAction(ProfileRequest{ID: "beta"}, "unarchive"); err == nil {
+		t.Fatalf("expected unarchive of an active profile to be rejected")
+	}
+}
+
+func TestSplitArchivedProfiles(t *testing.T) {
+	active, archived := splitArchivedProfiles([]ProfileRequest{{ID: "a"}, {ID: "b", Archived: true}, {ID: "c"}})
+	if len(active) != 2 || len(archived) != 1 || archived[0].ID != "b" {
+		t.Fatalf("unexpected split: %v / %v", active, archived)
+	}
+}
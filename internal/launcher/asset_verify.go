@@ -0,0 +1,135 @@
+package launcher
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+var (
+	ErrChecksumMissing  = errors.New("no checksum listed for asset")
+	ErrChecksumMismatch = errors.New("asset checksum mismatch")
+)
+
+// launcherAsset is a downloadable launcher build together with the release
+// assets that allow it to be verified before installation.
+type launcherAsset struct {
+	Name         string
+	URL          string
+	ChecksumURL  string
+	SignatureURL string
+	// SHA256 is set when the feed publishes the digest inline.
+	SHA256 string
+}
+
+// chooseVerifiedLauncherAsset picks the platform asset and its checksum. An
+// asset without a published checksum is not offered for download.
+func chooseVerifiedLauncherAsset(release githubRelease, goos, goarch string) (launcherAsset, bool) {
+	url := chooseLauncherAssetURL(release, goos, goarch)
+	if url == "" {
+		return launcherAsset{}, false
+	}
+	asset := launcherAsset{URL: url}
+	for _, a := range release.Assets {
+		if a.BrowserDownloadURL == url {
+			asset.Name = strings.TrimSpace(a.Name)
+			break
+		}
+	}
+	asset.SHA256 = release.Checksums[strings.ToLower(asset.Name)]
+	asset.ChecksumURL, asset.SignatureURL = findLauncherVerificationAssets(release, asset.Name)
+	return asset, asset.ChecksumURL != "" || asset.SHA256 != ""
+}
+
+// findLauncherVerificationAssets prefers a per-asset checksum file
+// ("<asset>.sha256") and falls back to a combined checksums list. The
+// signature, when published, covers whichever checksum file was chosen.
+func findLauncherVerificationAssets(release githubRelease, assetName string) (checksumURL, signatureURL string) {
+	if assetName == "" {
+		return "", ""
+	}
+	byName := map[string]string{}
+	for _, a := range release.Assets {
+		name := strings.ToLower(strings.TrimSpace(a.Name))
+		if name != "" && a.BrowserDownloadURL != "" {
+			byName[name] = a.BrowserDownloadURL
+		}
+	}
+	lower := strings.ToLower(assetName)
+	candidates := []string{lower + ".sha256", lower + ".sha256sum", "checksums.txt", "sha256sums", "sha256sums.txt"}
+	for _, name := range candidates {
+		url, ok := byName[name]
+		if !ok {
+			continue
+		}
+		for _, sigName := range []string{name + ".sig", name + ".asc", lower + ".sig", lower + ".asc"} {
+			if sig, ok := byName[sigName]; ok {
+				return url, sig
+			}
+		}
+		return url, ""
+	}
+	return "", ""
+}
+
+// checksumForAsset reads a sha256sum-style file ("<hex>  <name>" per line,
+// optionally "*<name>" for binary mode). A file holding a single bare digest
+// applies to the asset it was published for.
+func checksumForAsset(checksums []byte, assetName string) (string, error) {
+	assetName = path.Base(strings.TrimSpace(assetName))
+	var bare []string
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		switch len(fields) {
+		case 0:
+			continue
+		case 1:
+			bare = append(bare, fields[0])
+		default:
+			name := path.Base(strings.TrimPrefix(fields[len(fields)-1], "*"))
+			if strings.EqualFold(name, assetName) {
+				return normalizeSHA256(fields[0])
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if len(bare) == 1 {
+		return normalizeSHA256(bare[0])
+	}
+	return "", fmt.Errorf("%w: %s", ErrChecksumMissing, assetName)
+}
+
+func normalizeSHA256(v string) (string, error) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if b, err := hex.DecodeString(v); err != nil || len(b) != sha256.Size {
+		return "", fmt.Errorf("invalid sha256 digest %q", v)
+	}
+	return v, nil
+}
+
+// verifyLauncherAsset hashes the downloaded asset and compares it with the
+// digest published for assetName. Installers must call it before running
+// or replacing anything with the download.
+func verifyLauncherAsset(r io.Reader, assetName string, checksums []byte) error {
+	want, err := checksumForAsset(checksums, assetName)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("%w: %s has sha256 %s, expected %s", ErrChecksumMismatch, assetName, got, want)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package launcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestChooseVerifiedLauncherAsset(t *testing.T) {
+	release := githubRelease{
+		Assets: []struct {
+			Name               string `json:"name"`
+			BrowserDownloadURL string `json:"browser_download_url"`
+		}{
+			{Name: "Kimmio-Launcher-1.2.0-linux-amd64.deb", BrowserDownloadURL: "https://example/linux.deb"},
+			{Name: "Kimmio-Launcher-1.2.0-linux-amd64.deb.sha256", BrowserDownloadURL: "https://example/linux.deb.sha256"},
+			{Name: "Kimmio-Launcher-1.2.0-linux-amd64.deb.sha256.sig", BrowserDownloadURL: "https://example/linux.deb.sha256.sig"},
+			{Name: "Kimmio-Launcher-1.2.0-macos-arm64.dmg", BrowserDownloadURL: "https://example/macos-arm64.dmg"},
+			{Name: "checksums.txt", BrowserDownloadURL: "https://example/checksums.txt"},
+		},
+	}
+
+	asset, ok := chooseVerifiedLauncherAsset(release, "linux", "amd64")
+	if !ok || asset.ChecksumURL != "https://example/linux.deb.sha256" || asset.SignatureURL != "https://example/linux.deb.sha256.sig" {
+		t.Fatalf("unexpected linux asset: %+v ok=%v", asset, ok)
+	}
+	asset, ok = chooseVerifiedLauncherAsset(release, "darwin", "arm64")
+	if !ok || asset.ChecksumURL != "https://example/checksums.txt" || asset.SignatureURL != "" {
+		t.Fatalf("expected combined checksum file for macOS, got %+v ok=%v", asset, ok)
+	}
+
+	release.Assets = release.Assets[:1]
+	if _, ok := chooseVerifiedLauncherAsset(release, "linux", "amd64"); ok {
+		t.Fatalf("expected asset without checksum to be unverifiable")
+	}
+}
+
+func TestVerifyLauncherAsset(t *testing.T) {
+	payload := "launcher-binary"
+	sum := sha256.Sum256([]byte(payload))
+	digest := hex.EncodeToString(sum[:])
+	checksums := []byte(strings.Repeat("0", 64) + "  other.deb\n" + digest + " *dist/kimmio.deb\n")
+
+	if err := verifyLauncherAsset(strings.NewReader(payload), "kimmio.deb", checksums); err != nil {
+		t.Fatalf("expected checksum to verify: %v", err)
+	}
+	if err := verifyLauncherAsset(strings.NewReader("tampered"), "kimmio.deb", checksums); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("expected ErrChecksumMismatch, got %v", err)
+	}
+	if err := verifyLauncherAsset(strings.NewReader(payload), "missing.deb", checksums); !errors.Is(err, ErrChecksumMissing) {
+		t.Fatalf("expected ErrChecksumMissing, got %v", err)
+	}
+	if err := verifyLauncherAsset(strings.NewReader(payload), "kimmio.deb", []byte(digest+"\n")); err != nil {
+		t.Fatalf("expected bare digest file to verify: %v", err)
+	}
+}
@@ -0,0 +1,414 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// A backup archives a profile's Postgres, MinIO and app data, one tarball
+// per volume, into DataDir/backups/<id>/<name>/ with a backup.json
+// manifest written last, so a directory without one is an unfinished
+// backup. The stack is stopped while the archives are taken, as a running
+// database does not leave consistent files, and started again afterwards.
+// Backups outlive the profile: a purged profile can be created again under
+// the same ID and restored. The profile's secrets file is kept with the
+// data, as the data is encrypted with its key and purge deletes it. Redis
+// data is not included.
+
+const (
+	backupManifestName = "backup.json"
+	backupSecretsName  = "secrets.env"
+	backupTimeout      = 2 * time.Hour
+	// latestBackupName restores the newest backup without naming it.
+	latestBackupName = "latest"
+)
+
+var (
+	ErrBackupNotFound = errors.New("backup not found")
+
+	backupNameRe = regexp.MustCompile(`^\d{8}T\d{6}Z$`)
+
+	backupVolumes = []string{"postgres_data", "minio_data", "kimmio_data"}
+)
+
+// ProfileBackup is the manifest of one backup.
+type ProfileBackup struct {
+	Name      string         `json:"name"`
+	ProfileID string         `json:"profileId"`
+	Version   string         `json:"version"`
+	CreatedAt time.Time      `json:"createdAt"`
+	Volumes   []BackupVolume `json:"volumes"`
+	// Secrets names the copy of the profile's secrets file, if it had one.
+	Secrets string `json:"secrets,omitempty"`
+}
+
+// BackupVolume is the archive of one volume within a backup.
+type BackupVolume struct {
+	Volume string `json:"volume"`
+	File   string `json:"file"`
+	Bytes  int64  `json:"bytes"`
+}
+
+// backupArchiveName is the file a backup keeps one location's data in.
+func backupArchiveName(v profileDataVolume) string {
+	return v.Dir + ".tar.gz"
+}
+
+func profileBackupDir(id string) string {
+	return filepath.Join(appCfg.DataDir, "backups", id)
+}
+
+// The docker calls of a backup and a restore; tests replace them.
+var (
+	archiveDataLocation = func(ctx context.Context, location, dir, file string) error {
+		return runDataHelper(ctx, []string{"-v", location + ":/data:ro", "-v", dir + ":/backup"}, `tar -czf "/backup/$1" -C /data .`, file)
+	}
+	extractDataLocation = func(ctx context.Context, dir, file, location string) error {
+		return runDataHelper(ctx, []string{"-v", dir + ":/backup:ro", "-v", location + ":/data"}, `tar -xzf "/backup/$1" -C /data`, file)
+	}
+)
+
+// ListBackups returns the finished backups of a profile, newest first.
+func (p *ProfileService) ListBackups(ctx context.Context, id string) ([]ProfileBackup, error) {
+	if _, err := p.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	return listProfileBackups(normalizeProfileID(id))
+}
+
+func listProfileBackups(id string) ([]ProfileBackup, error) {
+	entries, err := os.ReadDir(profileBackupDir(id))
+	if os.IsNotExist(err) {
+		return []ProfileBackup{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	backups := []ProfileBackup{}
+	for _, e := range entries {
+		if !e.IsDir() || !backupNameRe.MatchString(e.Name()) {
+			continue
+		}
+		if b, err := readProfileBackup(id, e.Name()); err == nil {
+			backups = append(backups, b)
+		}
+	}
+	slices.SortFunc(backups, func(a, b ProfileBackup) int { return strings.Compare(b.Name, a.Name) })
+	return backups, nil
+}
+
+func readProfileBackup(id, name string) (ProfileBackup, error) {
+	if !backupNameRe.MatchString(name) {
+		return ProfileBackup{}, ErrBackupNotFound
+	}
+	raw, err := os.ReadFile(filepath.Join(profileBackupDir(id), name, backupManifestName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ProfileBackup{}, ErrBackupNotFound
+		}
+		return ProfileBackup{}, err
+	}
+	var b ProfileBackup
+	if err := json.Unmarshal(raw, &b); err != nil {
+		return ProfileBackup{}, fmt.Errorf("backup %s has an unreadable manifest: %w", name, err)
+	}
+	return b, nil
+}
+
+// StartBackup queues a job archiving the profile's data.
+func (p *ProfileService) StartBackup(ctx context.Context, id string, expectedRevision int) (*ActionJob, error) {
+	id = normalizeProfileID(id)
+	if !profileIDRe.MatchString(id) {
+		return nil, ValidationError{Msg: "invalid profile id"}
+	}
+	s := p.srv
+	if _, err := s.profileForDataJob(ctx, id, "backup", expectedRevision); err != nil {
+		return nil, err
+	}
+	return s.enqueueProfileJob(id, "backup", func(jobID string, ctx context.Context) error {
+		return s.performBackup(id, jobID, ctx)
+	})
+}
+
+// StartRestoreBackup queues a job replacing the profile's data with a
+// backup; name "latest" picks the newest one.
+func (p *ProfileService) StartRestoreBackup(ctx context.Context, id, name string, expectedRevision int) (*ActionJob, error) {
+	id = normalizeProfileID(id)
+	if !profileIDRe.MatchString(id) {
+		return nil, ValidationError{Msg: "invalid profile id"}
+	}
+	s := p.srv
+	if _, err := s.profileForDataJob(ctx, id, "restore-backup", expectedRevision); err != nil {
+		return nil, err
+	}
+	if name == latestBackupName {
+		backups, err := listProfileBackups(id)
+		if err != nil {
+			return nil, err
+		}
+		if len(backups) == 0 {
+			return nil, ErrBackupNotFound
+		}
+		name = backups[0].Name
+	}
+	backup, err := readProfileBackup(id, name)
+	if err != nil {
+		return nil, err
+	}
+	return s.enqueueProfileJob(id, "restore-backup", func(jobID string, ctx context.Context) error {
+		return s.performRestoreBackup(id, backup, jobID, ctx)
+	})
+}
+
+// profileForDataJob loads a profile whose data a backup or restore may
+// touch: not trashed, not archived, and at the expected revision.
+func (s *Server) profileForDataJob(ctx context.Context, id, action string, expectedRevision int) (ProfileRequest, error) {
+	store, idx, err := s.getProfileForAction(ctx, id)
+	if err != nil {
+		return ProfileRequest{}, normalizeNotFound(err)
+	}
+	profile := store.Profiles[idx]
+	if expectedRevision > 0 && profile.Revision != expectedRevision {
+		return ProfileRequest{}, RevisionConflictError{Expected: expectedRevision, Current: profile}
+	}
+	if err := checkTrashedAction(store, idx, action); err != nil {
+		return ProfileRequest{}, err
+	}
+	if err := checkArchivedAction(profile, action); err != nil {
+		return ProfileRequest{}, err
+	}
+	return profile, nil
+}
+
+// stopForDataJob stops a running stack and returns the function that
+// starts it again.
+func (s *Server) stopForDataJob(ctx context.Context, profile ProfileRequest, jobID string) (func(context.Context) error, error) {
+	if !profile.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+	s.updateJobStep(jobID, "down", "running", "Stopping compose stack", 10, "")
+	if err := s.compose.down(ctx, profile.ID, false); err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) error {
+		s.updateJobStep(jobID, "up", "running", "Starting the stack again", 90, "")
+		return s.performEnable(profile.ID, jobID, ctx)
+	}, nil
+}
+
+func (s *Server) performBackup(id, jobID string, parent context.Context) error {
+	ctx, cancel := context.WithTimeout(parent, backupTimeout)
+	defer cancel()
+	record := context.WithoutCancel(parent)
+
+	store, idx, err := s.getProfileForAction(ctx, id)
+	if err != nil {
+		return err
+	}
+	profile := store.Profiles[idx]
+	volumes := []profileDataVolume{}
+	for _, v := range profileDataVolumes {
+		if slices.Contains(backupVolumes, v.Volume) && dataLocationExists(ctx, dataLocation(profile, profile.DataDir, v), profile.DataDir == "") {
+			volumes = append(volumes, v)
+		}
+	}
+	if len(volumes) == 0 {
+		err := errors.New("the profile has no data yet; enable it first")
+		_ = s.markProfileResult(record, id, "backup", "failed", err.Error(), "")
+		return err
+	}
+	now := time.Now().UTC()
+	backup := ProfileBackup{Name: now.Format("20060102T150405Z"), ProfileID: id, Version: profile.Version, CreatedAt: now.Truncate(time.Second)}
+	dir := filepath.Join(profileBackupDir(id), backup.Name)
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return err
+	}
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		if os.IsExist(err) {
+			err = fmt.Errorf("backup %s already exists; try again in a second", backup.Name)
+		}
+		_ = s.markProfileResult(record, id, "backup", "failed", err.Error(), "")
+		return err
+	}
+
+	restart, err := s.stopForDataJob(ctx, profile, jobID)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		_ = s.markProfileResult(record, id, "backup", "failed", err.Error(), "")
+		return err
+	}
+	fail := func(err error) error {
+		_ = os.RemoveAll(dir)
+		_ = s.markProfileResult(record, id, "backup", "failed", err.Error(), "")
+		if upErr := restart(record); upErr != nil {
+			logWarn("backup_restart_failed", map[string]any{"profile_id": id, "error": upErr.Error()})
+		}
+		return err
+	}
+	var total int64
+	for i, v := range volumes {
+		file := backupArchiveName(v)
+		s.updateJobStep(jobID, "archive", "running", fmt.Sprintf("Archiving %s data", v.Dir), 20+60*i/len(volumes), "")
+		if err := archiveDataLocation(ctx, dataLocation(profile, profile.DataDir, v), dir, file); err != nil {
+			return fail(fmt.Errorf("archive %s data: %w", v.Dir, err))
+		}
+		info, err := os.Stat(filepath.Join(dir, file))
+		if err != nil {
+			return fail(fmt.Errorf("archive %s data: %w", v.Dir, err))
+		}
+		backup.Volumes = append(backup.Volumes, BackupVolume{Volume: v.Volume, File: file, Bytes: info.Size()})
+		total += info.Size()
+	}
+	secrets, err := os.ReadFile(secretFilePath(id))
+	switch {
+	case err == nil:
+		if err := writeFileSynced(filepath.Join(dir, backupSecretsName), secrets, 0o600); err != nil {
+			return fail(fmt.Errorf("save secrets: %w", err))
+		}
+		backup.Secrets = backupSecretsName
+	case !os.IsNotExist(err):
+		return fail(fmt.Errorf("read secrets: %w", err))
+	}
+	raw, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return fail(err)
+	}
+	if err := writeFileSynced(filepath.Join(dir, backupManifestName), raw, 0o644); err != nil {
+		return fail(err)
+	}
+	auditLog("INFO", "profile_backup_created", map[string]any{"profile_id": id, "backup": backup.Name, "volumes": len(backup.Volumes), "bytes": total})
+	if err := s.markProfileResult(record, id, "backup", "success", fmt.Sprintf("Backup %s saved (%s)", backup.Name, formatBytes(total)), ""); err != nil {
+		return err
+	}
+	return restart(parent)
+}
+
+// performRestoreBackup empties each backed-up location and unpacks its
+// archive there. A failed restore leaves the stack stopped, as the data
+// is then only partly restored.
+func (s *Server) performRestoreBackup(id string, backup ProfileBackup, jobID string, parent context.Context) error {
+	ctx, cancel := context.WithTimeout(parent, backupTimeout)
+	defer cancel()
+	record := context.WithoutCancel(parent)
+
+	store, idx, err := s.getProfileForAction(ctx, id)
+	if err != nil {
+		return err
+	}
+	profile := store.Profiles[idx]
+	fail := func(err error) error {
+		_ = s.markProfileResult(record, id, "restore-backup", "failed", err.Error()+"; the profile stays stopped until a restore succeeds", "")
+		return err
+	}
+	restart, err := s.stopForDataJob(ctx, profile, jobID)
+	if err != nil {
+		_ = s.markProfileResult(record, id, "restore-backup", "failed", err.Error(), "")
+		return err
+	}
+	dir := filepath.Join(profileBackupDir(id), backup.Name)
+	for i, bv := range backup.Volumes {
+		vi := slices.IndexFunc(profileDataVolumes, func(v profileDataVolume) bool { return v.Volume == bv.Volume })
+		if vi < 0 {
+			return fail(fmt.Errorf("backup %s lists an unknown volume %q", backup.Name, bv.Volume))
+		}
+		v := profileDataVolumes[vi]
+		// Only archives the launcher names itself are unpacked, whatever
+		// the manifest says.
+		if bv.File != backupArchiveName(v) {
+			return fail(fmt.Errorf("backup %s lists an unexpected archive %q for %s", backup.Name, bv.File, v.Volume))
+		}
+		location := dataLocation(profile, profile.DataDir, v)
+		s.updateJobStep(jobID, "restore", "running", fmt.Sprintf("Restoring %s data", v.Dir), 20+60*i/len(backup.Volumes), "")
+		if profile.DataDir == "" {
+			if err := createDataVolume(ctx, profile, v); err != nil {
+				return fail(fmt.Errorf("create volume %s: %w", location, err))
+			}
+		} else if err := os.MkdirAll(location, 0o755); err != nil {
+			return fail(err)
+		}
+		if err := clearDataLocation(ctx, location); err != nil {
+			return fail(fmt.Errorf("empty %s: %w", location, err))
+		}
+		if err := extractDataLocation(ctx, dir, bv.File, location); err != nil {
+			return fail(fmt.Errorf("restore %s data: %w", v.Dir, err))
+		}
+	}
+	// The data only opens with the keys it was written with, which a
+	// re-created profile has replaced.
+	if backup.Secrets != "" {
+		if err := restoreBackupSecrets(id, filepath.Join(dir, backupSecretsName)); err != nil {
+			return fail(fmt.Errorf("restore secrets: %w", err))
+		}
+	}
+	auditLog("WARN", "profile_backup_restored", map[string]any{"profile_id": id, "backup": backup.Name, "volumes": len(backup.Volumes)})
+	if err := s.markProfileResult(record, id, "restore-backup", "success", "Data restored from backup "+backup.Name, ""); err != nil {
+		return err
+	}
+	return restart(parent)
+}
+
+func restoreBackupSecrets(id, path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(secretFilePath(id)), 0o700); err != nil {
+		return err
+	}
+	return writeFileSynced(secretFilePath(id), raw, 0o600)
+}
+
+// handleProfileBackups serves GET /api/profiles/<id>/backups.
+func (s *Server) handleProfileBackups(w http.ResponseWriter, r *http.Request, id string) {
+	backups, err := s.Profiles().ListBackups(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatusForError(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "backups": backups})
+}
+
+// handleProfileBackup serves POST /api/profiles/<id>/backup.
+func (s *Server) handleProfileBackup(w http.ResponseWriter, r *http.Request, id string) {
+	expectedRevision, err := expectedRevisionFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	job, err := s.Profiles().StartBackup(r.Context(), id, expectedRevision)
+	if err != nil {
+		s.writeActionError(w, "backup", err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"ok": true, "jobId": job.ID})
+}
+
+// handleProfileRestoreBackup serves POST
+// /api/profiles/<id>/backups/<name>/restore. It overwrites the profile's
+// data, so it needs a confirmation like the other destructive actions.
+func (s *Server) handleProfileRestoreBackup(w http.ResponseWriter, r *http.Request, id, name string) {
+	expectedRevision, err := expectedRevisionFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.checkConfirmation(r, id, "restore-backup"); err != nil {
+		http.Error(w, err.Error(), httpStatusForError(err))
+		return
+	}
+	job, err := s.Profiles().StartRestoreBackup(r.Context(), id, name, expectedRevision)
+	if err != nil {
+		s.writeActionError(w, "restore-backup", err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"ok": true, "jobId": job.ID})
+}
@@ -0,0 +1,208 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// installFakeBackupArchives writes a small archive per location and
+// records what is unpacked where.
+func installFakeBackupArchives(t *testing.T) *[]string {
+	t.Helper()
+	archiveOld, extractOld := archiveDataLocation, extractDataLocation
+	t.Cleanup(func() { archiveDataLocation, extractDataLocation = archiveOld, extractOld })
+	extracted := []string{}
+	archiveDataLocation = func(_ context.Context, location, dir, file string) error {
+		return os.WriteFile(filepath.Join(dir, file), []byte(location), 0o644)
+	}
+	extractDataLocation = func(_ context.Context, dir, file, location string) error {
+		raw, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return err
+		}
+		extracted = append(extracted, string(raw)+" -> "+location)
+		return nil
+	}
+	return &extracted
+}
+
+func TestBackupAndRestoreProfileData(t *testing.T) {
+	srv := newServiceTestServer(t)
+	f := &fakeDataLocations{data: map[string]dataDigest{
+		"alpha_postgres_data": {Files: 1200, Sum: "pg"},
+		"alpha_redis_data":    {Files: 1, Sum: "redis"},
+		"alpha_kimmio_data":   {Files: 3, Sum: "app"},
+	}}
+	installFakeDataLocations(t, f)
+	extracted := installFakeBackupArchives(t)
+
+	if err := srv.performBackup("alpha", "", context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	backups, err := srv.Profiles().ListBackups(context.Background(), "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 || len(backups[0].Volumes) != 2 || backups[0].Volumes[0].File != "postgres.tar.gz" || backups[0].Volumes[1].Volume != "kimmio_data" {
+		t.Fatalf("expected one backup of postgres and app data, got %+v", backups)
+	}
+	if p, _ := srv.Profiles().Get(context.Background(), "alpha"); p.LastAction != "backup" || p.LastActionStatus != "success" {
+		t.Fatalf("expected a successful backup on the profile, got %+v", p)
+	}
+
+	// An unfinished backup, without a manifest, is not listed.
+	if err := os.MkdirAll(filepath.Join(profileBackupDir("alpha"), "20990101T000000Z"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if backups, _ := srv.Profiles().ListBackups(context.Background(), "alpha"); len(backups) != 1 {
+		t.Fatalf("expected the unfinished backup skipped, got %+v", backups)
+	}
+
+	f.removed = nil
+	if err := srv.performRestoreBackup("alpha", backups[0], "", context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(f.removed, []string{"alpha_postgres_data", "alpha_kimmio_data"}) {
+		t.Fatalf("expected the backed-up volumes emptied first, got %v", f.removed)
+	}
+	want := []string{"alpha_postgres_data -> alpha_postgres_data", "alpha_kimmio_data -> alpha_kimmio_data"}
+	if !slices.Equal(*extracted, want) {
+		t.Fatalf("expected the archives unpacked into their volumes, got %v", *extracted)
+	}
+	if p, _ := srv.Profiles().Get(context.Background(), "alpha"); p.LastAction != "restore-backup" || p.LastActionStatus != "success" {
+		t.Fatalf("expected a successful restore on the profile, got %+v", p)
+	}
+}
+
+func TestRestoreBackupAfterPurgeBringsBackSecrets(t *testing.T) {
+	srv := newServiceTestServer(t)
+	fc := newFakeCompose()
+	defer fc.closeAll()
+	srv.compose = fc
+	installFakeDataLocations(t, &fakeDataLocations{data: map[string]dataDigest{"alpha_postgres_data": {Files: 10, Sum: "pg"}}})
+	installFakeBackupArchives(t)
+	if err := saveProfileSecrets("alpha", map[string]string{"ENC_KEY_V0": "old-key", "JWT_SECRET": "old-jwt"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.performBackup("alpha", "", context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	backups, err := listProfileBackups("alpha")
+	if err != nil || len(backups) != 1 || backups[0].Secrets != backupSecretsName {
+		t.Fatalf("expected a backup with the secrets, got %+v %v", backups, err)
+	}
+	if info, err := os.Stat(filepath.Join(profileBackupDir("alpha"), backups[0].Name, backupSecretsName)); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected a private copy of the secrets, got %v %v", info, err)
+	}
+
+	if err := srv.performDelete("alpha", "", context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.createProfile(context.Background(), ProfileRequest{ID: "alpha", Version: "1.0.0", Ports: []PortMapping{{Container: 3000, Host: 8088}}, Env: map[string]string{}}); err != nil {
+		t.Fatal(err)
+	}
+	if key := loadProfileSecrets("alpha")["ENC_KEY_V0"]; key == "" || key == "old-key" {
+		t.Fatalf("expected the new profile to get a new key, got %q", key)
+	}
+
+	if err := srv.performRestoreBackup("alpha", backups[0], "", context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if secrets := loadProfileSecrets("alpha"); secrets["ENC_KEY_V0"] != "old-key" || secrets["JWT_SECRET"] != "old-jwt" {
+		t.Fatalf("expected the backed-up secrets restored, got %v", secrets)
+	}
+}
+
+func TestBackupEndpoints(t *testing.T) {
+	srv := newServiceTestServer(t)
+	installFakeDataLocations(t, &fakeDataLocations{data: map[string]dataDigest{}})
+	installFakeBackupArchives(t)
+
+	if err := srv.performBackup("alpha", "", context.Background()); err == nil || !strings.Contains(err.Error(), "no data yet") {
+		t.Fatalf("expected a profile without data to be refused, got %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.handleProfileAction(rec, httptest.NewRequest(http.MethodGet, "/api/profiles/alpha/backups", nil))
+	var body struct {
+		Backups []ProfileBackup `json:"backups"`
+	}
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &body) != nil || body.Backups == nil || len(body.Backups) != 0 {
+		t.Fatalf("expected an empty list, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	for _, tc := range []struct {
+		path, body string
+		want       int
+	}{
+		{"/api/profiles/alpha/backups/20260101T000000Z/restore", `{}`, http.StatusPreconditionRequired},
+		{"/api/profiles/alpha/backups/20260101T000000Z/restore", `{"confirm":"alpha"}`, http.StatusNotFound},
+		{"/api/profiles/alpha/backups/latest/restore", `{"confirm":"alpha"}`, http.StatusNotFound},
+		{"/api/profiles/missing/backup", ``, http.StatusNotFound},
+	} {
+		rec := httptest.NewRecorder()
+		srv.handleProfileAction(rec, httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body)))
+		if rec.Code != tc.want {
+			t.Fatalf("%s: expected %d, got %d %s", tc.path, tc.want, rec.Code, rec.Body.String())
+		}
+	}
+
+	srv.activeProfiles["alpha"] = "job-1"
+	rec = httptest.NewRecorder()
+	srv.handleProfileAction(rec, httptest.NewRequest(http.MethodPost, "/api/profiles/alpha/backup", nil))
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "job-1") {
+		t.Fatalf("expected a busy profile to be refused, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRestoreBackupRefusesCraftedManifests(t *testing.T) {
+	srv := newServiceTestServer(t)
+	installFakeDataLocations(t, &fakeDataLocations{data: map[string]dataDigest{"alpha_postgres_data": {Files: 10, Sum: "pg"}}})
+	extracted := installFakeBackupArchives(t)
+	if err := srv.performBackup("alpha", "", context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	backups, err := listProfileBackups("alpha")
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("expected one backup, got %+v %v", backups, err)
+	}
+	crafted := backups[0]
+	crafted.Volumes = []BackupVolume{{Volume: "postgres_data", File: "x;touch${IFS}pwned;.tar.gz"}}
+	if err := srv.performRestoreBackup("alpha", crafted, "", context.Background()); err == nil || !strings.Contains(err.Error(), "unexpected archive") {
+		t.Fatalf("expected a crafted archive name to be refused, got %v", err)
+	}
+	if len(*extracted) != 0 {
+		t.Fatalf("expected nothing unpacked, got %v", *extracted)
+	}
+
+	// "latest" names the newest backup.
+	job, err := srv.Profiles().StartRestoreBackup(context.Background(), "alpha", latestBackupName, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, err := srv.Jobs().Get(job.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.FinishedAt != "" {
+			if got.Status != "succeeded" || len(*extracted) != 1 {
+				t.Fatalf("expected the latest backup restored, got %+v %v", got, *extracted)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s did not finish", job.ID)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,95 @@
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// The profile limit can be raised at runtime, so the launcher reports how
+// much room is left and warns, without refusing, when the memory limits of
+// the profiles would not fit in the memory Docker has. Profiles without a
+// limit count with the compose default. Docker Desktop runs containers in
+// a VM, so its memory, not the host's, is what counts.
+
+const hostMemoryTTL = 10 * time.Minute
+
+// ProfileCapacity is how many profiles fit and how much memory they claim.
+type ProfileCapacity struct {
+	Max       int `json:"max"`
+	Used      int `json:"used"`
+	Remaining int `json:"remaining"`
+	// MemoryLimitBytes sums the memory limits of the active profiles.
+	MemoryLimitBytes int64 `json:"memoryLimitBytes"`
+	// HostMemoryBytes is the memory Docker reports; zero when unknown.
+	HostMemoryBytes int64    `json:"hostMemoryBytes,omitempty"`
+	Warnings        []string `json:"warnings"`
+}
+
+// MemoryLimit and HostMemory format the byte counts for the settings page.
+func (c ProfileCapacity) MemoryLimit() string { return formatBytes(c.MemoryLimitBytes) }
+func (c ProfileCapacity) HostMemory() string  { return formatBytes(c.HostMemoryBytes) }
+
+var hostMemoryCache struct {
+	mu    sync.Mutex
+	bytes int64
+	at    time.Time
+}
+
+// hostMemoryBytes is replaced in tests.
+var hostMemoryBytes = cachedDockerMemory
+
+func cachedDockerMemory(ctx context.Context) int64 {
+	hostMemoryCache.mu.Lock()
+	defer hostMemoryCache.mu.Unlock()
+	if hostMemoryCache.bytes > 0 && time.Since(hostMemoryCache.at) < hostMemoryTTL {
+		return hostMemoryCache.bytes
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	out, err := dockerInfoField(ctx, "{{.MemTotal}}", func(info engineInfo) string { return strconv.FormatInt(info.MemTotal, 10) })
+	if err != nil {
+		return 0
+	}
+	n, err := strconv.ParseInt(out, 10, 64)
+	if err != nil || n <= 0 {
+		return 0
+	}
+	hostMemoryCache.bytes, hostMemoryCache.at = n, time.Now()
+	return n
+}
+
+// profileCapacity reports the room left under maxProfiles for the active
+// profiles in store.
+func profileCapacity(store ProfileStore, maxProfiles int, hostMemory int64) ProfileCapacity {
+	c := ProfileCapacity{Max: maxProfiles, Used: activeProfileCount(store), HostMemoryBytes: hostMemory, Warnings: []string{}}
+	for _, p := range store.Profiles {
+		// Archived profiles are stopped for good and claim no memory.
+		if p.DeletedAt == "" && !p.Archived {
+			c.MemoryLimitBytes += memoryLimitBytes(p.Resources.Limits.Memory)
+		}
+	}
+	c.Remaining = max(c.Max-c.Used, 0)
+	if c.Used > c.Max {
+		c.Warnings = append(c.Warnings, fmt.Sprintf("%d profiles exist, more than the limit of %d; no new profiles can be created until some are deleted", c.Used, c.Max))
+	}
+	if hostMemory <= 0 {
+		return c
+	}
+	if c.MemoryLimitBytes > hostMemory {
+		c.Warnings = append(c.Warnings, fmt.Sprintf("The memory limits of the existing profiles (%s) exceed the %s Docker has", formatBytes(c.MemoryLimitBytes), formatBytes(hostMemory)))
+	} else if full := c.MemoryLimitBytes + int64(c.Remaining)*memoryLimitBytes(""); full > hostMemory {
+		c.Warnings = append(c.Warnings, fmt.Sprintf("At the default memory limit, %d profiles would claim %s, more than the %s Docker has", c.Max, formatBytes(full), formatBytes(hostMemory)))
+	}
+	return c
+}
+
+func (s *Server) profileCapacity(ctx context.Context) (ProfileCapacity, error) {
+	store, err := s.readStore(ctx)
+	if err != nil {
+		return ProfileCapacity{}, err
+	}
+	return profileCapacity(store, maxProfilesLimit(), hostMemoryBytes(ctx)), nil
+}
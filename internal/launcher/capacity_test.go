@@ -0,0 +1,58 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProfileCapacityWarnsAboutMemory(t *testing.T) {
+	profile := func(id, mem string) ProfileRequest {
+		p := ProfileRequest{ID: id}
+		p.Resources.Limits.Memory = mem
+		return p
+	}
+	store := ProfileStore{Profiles: []ProfileRequest{
+		profile("alpha", "2g"),
+		profile("beta", "2g"),
+		{ID: "gone", DeletedAt: "2026-01-01T00:00:00Z"},
+	}}
+
+	c := profileCapacity(store, 3, 8<<30)
+	if c.Used != 2 || c.Remaining != 1 || c.MemoryLimitBytes != 4<<30 || len(c.Warnings) != 0 {
+		t.Fatalf("expected room for one more without warnings, got %+v", c)
+	}
+	if c = profileCapacity(store, 10, 8<<30); len(c.Warnings) != 1 || !strings.Contains(c.Warnings[0], "At the default memory limit") {
+		t.Fatalf("expected a warning about filling the limit, got %+v", c.Warnings)
+	}
+	if c = profileCapacity(store, 3, 3<<30); len(c.Warnings) != 1 || !strings.Contains(c.Warnings[0], "existing profiles") {
+		t.Fatalf("expected a warning about the existing limits, got %+v", c.Warnings)
+	}
+	if c = profileCapacity(store, 1, 0); c.Remaining != 0 || len(c.Warnings) != 1 {
+		t.Fatalf("expected a warning about exceeding the limit, got %+v", c)
+	}
+}
+
+func TestSettingsReportCapacityWarnings(t *testing.T) {
+	srv := newServiceTestServer(t)
+	srv.settings = newSettingsStore(t.TempDir())
+	defer publishSettings(defaultSettings())
+	prev := hostMemoryBytes
+	hostMemoryBytes = func(context.Context) int64 { return 8 << 30 }
+	defer func() { hostMemoryBytes = prev }()
+
+	rec := httptest.NewRecorder()
+	srv.handleSettings(rec, httptest.NewRequest(http.MethodPut, "/api/settings", strings.NewReader(`{"maxProfiles":20}`)))
+	var payload struct {
+		Capacity ProfileCapacity `json:"capacity"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 JSON, got %d %s", rec.Code, rec.Body.String())
+	}
+	if payload.Capacity.Max != 20 || payload.Capacity.Remaining != 19 || len(payload.Capacity.Warnings) != 1 {
+		t.Fatalf("expected the raised limit to be saved with a warning, got %+v", payload.Capacity)
+	}
+}
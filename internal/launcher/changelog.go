@@ -0,0 +1,99 @@
+package launcher
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const launcherStateFileName = "launcher-state.json"
+
+type launcherState struct {
+	LastSeenVersion string `json:"lastSeenVersion"`
+}
+
+// changelogTracker remembers the last launcher version whose release notes
+// the user has seen, so a self-update is followed by a "what's new" page.
+type changelogTracker struct {
+	mu      sync.Mutex
+	path    string
+	pending string
+}
+
+// newChangelogTracker compares the running version with lastSeenVersion. A
+// fresh data directory records the current version without showing notes,
+// and dev builds never show them.
+func newChangelogTracker(dataDir, current string) *changelogTracker {
+	c := &changelogTracker{path: filepath.Join(dataDir, launcherStateFileName)}
+	current = strings.TrimSpace(current)
+	if current == "" || current == "dev" {
+		return c
+	}
+	var state launcherState
+	raw, err := os.ReadFile(c.path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		if err := c.save(current); err != nil {
+			logWarn("launcher_state_write_failed", map[string]any{"error": err.Error()})
+		}
+		return c
+	case err != nil:
+		logWarn("launcher_state_read_failed", map[string]any{"error": err.Error()})
+		return c
+	}
+	if err := json.Unmarshal(raw, &state); err != nil {
+		logWarn("launcher_state_invalid", map[string]any{"error": err.Error()})
+	}
+	if isNewerVersion(current, state.LastSeenVersion) {
+		c.pending = current
+	}
+	return c
+}
+
+func (c *changelogTracker) pendingVersion() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pending
+}
+
+func (c *changelogTracker) markSeen() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pending == "" {
+		return nil
+	}
+	if err := c.save(c.pending); err != nil {
+		return err
+	}
+	c.pending = ""
+	return nil
+}
+
+func (c *changelogTracker) save(version string) error {
+	raw, err := json.MarshalIndent(launcherState{LastSeenVersion: version}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+func (s *Server) handleWhatsNewSeen(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := s.changelog.markSeen(); err != nil {
+		http.Error(w, "Failed to save launcher state: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+}
@@ -0,0 +1,45 @@
+package launcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChangelogTrackerPendingAfterUpgrade(t *testing.T) {
+	tmp := t.TempDir()
+
+	if c := newChangelogTracker(tmp, "1.0.0"); c.pendingVersion() != "" {
+		t.Fatalf("expected fresh install to show no changelog, got %q", c.pendingVersion())
+	}
+	if _, err := os.Stat(filepath.Join(tmp, launcherStateFileName)); err != nil {
+		t.Fatalf("expected launcher state to be recorded: %v", err)
+	}
+	if c := newChangelogTracker(tmp, "1.0.0"); c.pendingVersion() != "" {
+		t.Fatalf("expected no changelog for the same version")
+	}
+
+	c := newChangelogTracker(tmp, "1.1.0")
+	if c.pendingVersion() != "1.1.0" {
+		t.Fatalf("expected pending changelog for 1.1.0, got %q", c.pendingVersion())
+	}
+	if err := c.markSeen(); err != nil {
+		t.Fatal(err)
+	}
+	if c.pendingVersion() != "" {
+		t.Fatalf("expected pending changelog to clear")
+	}
+	if c := newChangelogTracker(tmp, "1.1.0"); c.pendingVersion() != "" {
+		t.Fatalf("expected seen version to persist")
+	}
+}
+
+func TestChangelogTrackerIgnoresDevBuilds(t *testing.T) {
+	tmp := t.TempDir()
+	if c := newChangelogTracker(tmp, "dev"); c.pendingVersion() != "" {
+		t.Fatalf("expected dev build to show no changelog")
+	}
+	if _, err := os.Stat(filepath.Join(tmp, launcherStateFileName)); !os.IsNotExist(err) {
+		t.Fatalf("expected dev build not to write launcher state, got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package launcher
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -16,7 +17,10 @@ func RunCLI(cfg config.Config, args []string, stdout, stderr io.Writer) (handled
 	if len(args) == 0 {
 		return false, 0
 	}
-	if strings.ToLower(strings.TrimSpace(args[0])) != "profile" {
+	command := strings.ToLower(strings.TrimSpace(args[0]))
+	switch command {
+	case "profile", "apply", "job", "tui", "completion", "context", "config", "user", "token", "__complete":
+	default:
 		return false, 0
 	}
 
@@ -26,12 +30,70 @@ func RunCLI(cfg config.Config, args []string, stdout, stderr io.Writer) (handled
 	if stderr == nil {
 		stderr = os.Stderr
 	}
+	// --quiet silences regular output; errors still go to stderr and the
+	// exit code tells scripts what happened.
+	args, quiet := stripQuietFlag(args)
+	if quiet {
+		stdout = io.Discard
+	}
+
+	args, contextName, ok := stripContextFlag(args)
+	if !ok {
+		fmt.Fprintln(stderr, "--context requires a context name")
+		return true, exitUsage
+	}
+	if command == "context" {
+		return true, runContextCLI(cfg.CLIConfigPath, args[1:], stdout, stderr)
+	}
+	cliCtx, err := selectCLIContext(cfg.CLIConfigPath, contextName)
+	if err != nil {
+		return true, cliFail(stderr, "Failed to select context", err)
+	}
+	activeCLIContext = cliCtx
+	if cliCtx.URL != "" && !runsAgainstContextURL(args) {
+		fmt.Fprintf(stderr, "%s works on the data directory; use a context without a URL or run it on the launcher's host.\n", strings.Join(args[:min(len(args), 3)], " "))
+		return true, exitUsage
+	}
+	if cliCtx.DataDir != "" {
+		cfg.DataDir = cliCtx.DataDir
+	}
 
 	appCfg = cfg
 	srv := NewServer(cfg)
+	switch command {
+	case "config":
+		return true, runConfigCLI(args[1:], stdout, stderr)
+	case "user":
+		return true, runUserCLI(srv, args[1:], stdout, stderr)
+	case "token":
+		return true, runTokenCLI(srv, args[1:], stdout, stderr)
+	case "apply":
+		return true, runApplyCLI(srv, args[1:], stdout, stderr)
+	case "job":
+		return true, runJobCLI(args[1:], stdout, stderr)
+	case "tui":
+		return true, runTUICLI(srv, args[1:], stdout, stderr)
+	case "completion":
+		return true, runCompletionCLI(args[1:], stdout, stderr)
+	case "__complete":
+		return true, runCompleteCLI(srv, args[1:], stdout)
+	}
 	return true, runProfileCLI(srv, args[1:], stdout, stderr)
 }
 
+func stripQuietFlag(args []string) ([]string, bool) {
+	out := make([]string, 0, len(args))
+	quiet := false
+	for _, arg := range args {
+		if arg == "--quiet" || arg == "-q" {
+			quiet = true
+			continue
+		}
+		out = append(out, arg)
+	}
+	return out, quiet
+}
+
 func normalizeCLIArgs(args []string) []string {
 	if len(args) == 0 {
 		return args
@@ -45,7 +107,7 @@ func normalizeCLIArgs(args []string) []string {
 func runProfileCLI(srv *Server, args []string, stdout, stderr io.Writer) int {
 	if len(args) == 0 {
 		writeProfileCLIUsage(stderr)
-		return 2
+		return exitUsage
 	}
 
 	cmd := strings.ToLower(strings.TrimSpace(args[0]))
@@ -56,62 +118,80 @@ func runProfileCLI(srv *Server, args []string, stdout, stderr io.Writer) int {
 	case "list":
 		if len(args) != 1 {
 			writeProfileCLIUsage(stderr)
-			return 2
+			return exitUsage
 		}
 		return runProfileList(srv, stdout, stderr)
 	}
 
 	if len(args) < 2 {
 		writeProfileCLIUsage(stderr)
-		return 2
+		return exitUsage
 	}
 
-	profileID := strings.ToLower(strings.TrimSpace(args[0]))
+	// A display name works wherever an ID does, except on a context URL,
+	// whose names this machine does not know.
+	profileID := normalizeProfileID(args[0])
+	if activeCLIContext.URL == "" {
+		profileID = srv.resolveProfileRef(context.Background(), args[0])
+	}
 	action := strings.ToLower(strings.TrimSpace(args[1]))
 	switch action {
+	case "rename":
+		if len(args) < 3 {
+			writeProfileCLIUsage(stderr)
+			return exitUsage
+		}
+		return runProfileRename(srv, profileID, strings.Join(args[2:], " "), stdout, stderr)
 	case "info":
 		if len(args) != 2 {
 			writeProfileCLIUsage(stderr)
-			return 2
+			return exitUsage
 		}
 		return runProfileInfo(srv, profileID, stdout, stderr)
+	case "logs":
+		return runProfileLogs(srv, profileID, args[2:], stdout, stderr)
+	case "enable":
+		wait := len(args) == 3 && strings.TrimSpace(args[2]) == "--wait"
+		if len(args) > 3 || (len(args) == 3 && !wait) {
+			writeProfileCLIUsage(stderr)
+			return exitUsage
+		}
+		return runProfileEnable(srv, profileID, wait, stdout, stderr)
 	case "update":
 		version := "latest"
 		if len(args) > 3 {
 			writeProfileCLIUsage(stderr)
-			return 2
+			return exitUsage
 		}
 		if len(args) == 3 {
 			version = strings.TrimSpace(args[2])
 		}
 		return runProfileUpdate(srv, profileID, version, stdout, stderr)
-	case "delete":
+	case "delete", "purge":
 		if len(args) != 2 {
 			writeProfileCLIUsage(stderr)
-			return 2
+			return exitUsage
 		}
-		return runProfileDelete(srv, profileID, stdout, stderr)
+		return runProfileDelete(srv, profileID, action, stdout, stderr)
 	default:
 		fmt.Fprintf(stderr, "Unknown profile action: %s\n", action)
 		writeProfileCLIUsage(stderr)
-		return 2
+		return exitUsage
 	}
 }
 
 func runProfileList(srv *Server, stdout, stderr io.Writer) int {
-	store, err := loadProfileStore(srv.dbPath)
+	profiles, err := srv.Profiles().List(context.Background())
 	if err != nil {
-		fmt.Fprintf(stderr, "Failed to load profiles: %v\n", err)
-		return 1
+		return cliFail(stderr, "Failed to load profiles", err)
 	}
-	if len(store.Profiles) == 0 {
+	if len(profiles) == 0 {
 		fmt.Fprintln(stdout, "No profiles found.")
 		return 0
 	}
 
-	profiles := applyHealthStatus(store.Profiles)
 	tw := tabwriter.NewWriter(stdout, 0, 8, 2, ' ', 0)
-	fmt.Fprintln(tw, "ID\tVERSION\tPORT\tSTATUS\tENABLED")
+	fmt.Fprintln(tw, "ID\tNAME\tVERSION\tPORT\tSTATUS\tENABLED")
 	for _, p := range profiles {
 		port := 0
 		if len(p.Ports) > 0 {
@@ -121,7 +201,7 @@ func runProfileList(srv *Server, stdout, stderr io.Writer) int {
 		if status == "" {
 			status = "unknown"
 		}
-		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%t\n", p.ID, p.Version, port, status, p.Enabled)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%t\n", p.ID, p.Title(), p.Version, port, status, p.Enabled)
 	}
 	_ = tw.Flush()
 	return 0
@@ -130,22 +210,18 @@ func runProfileList(srv *Server, stdout, stderr io.Writer) int {
 func runProfileInfo(srv *Server, profileID string, stdout, stderr io.Writer) int {
 	if !profileIDRe.MatchString(profileID) {
 		fmt.Fprintf(stderr, "Invalid profile name: %s\n", profileID)
-		return 2
+		return exitValidation
 	}
 
-	store, err := loadProfileStore(srv.dbPath)
+	p, err := srv.Profiles().Get(context.Background(), profileID)
 	if err != nil {
-		fmt.Fprintf(stderr, "Failed to load profiles: %v\n", err)
-		return 1
-	}
-	profiles := applyHealthStatus(store.Profiles)
-	idx := findProfileIndex(ProfileStore{Profiles: profiles}, profileID)
-	if idx < 0 {
-		fmt.Fprintf(stderr, "Profile not found: %s\n", profileID)
-		return 1
+		if errors.Is(err, ErrProfileNotFound) {
+			fmt.Fprintf(stderr, "Profile not found: %s\n", profileID)
+			return exitNotFound
+		}
+		return cliFail(stderr, "Failed to load profiles", err)
 	}
 
-	p := profiles[idx]
 	port := 0
 	if len(p.Ports) > 0 {
 		port = p.Ports[0].Host
@@ -156,12 +232,20 @@ func runProfileInfo(srv *Server, profileID string, stdout, stderr io.Writer) int
 	}
 
 	fmt.Fprintf(stdout, "ID: %s\n", p.ID)
+	fmt.Fprintf(stdout, "Name: %s\n", p.Title())
 	fmt.Fprintf(stdout, "Version: %s\n", p.Version)
+	fmt.Fprintf(stdout, "Revision: %d\n", p.Revision)
 	fmt.Fprintf(stdout, "Host Port: %d\n", port)
 	fmt.Fprintf(stdout, "Domain: %s\n", domain)
 	fmt.Fprintf(stdout, "Enabled: %t\n", p.Enabled)
 	fmt.Fprintf(stdout, "Running: %t\n", p.Running)
 	fmt.Fprintf(stdout, "Runtime Status: %s\n", p.RuntimeStatus)
+	if p.DataDir != "" {
+		fmt.Fprintf(stdout, "Data Directory: %s\n", p.DataDir)
+	}
+	if p.ContainerRuntime != "" {
+		fmt.Fprintf(stdout, "Container Runtime: %s\n", p.ContainerRuntime)
+	}
 	if p.LastAction != "" {
 		fmt.Fprintf(stdout, "Last Action: %s\n", p.LastAction)
 	}
@@ -174,13 +258,22 @@ func runProfileInfo(srv *Server, profileID string, stdout, stderr io.Writer) int
 	if p.LastActionAt != "" {
 		fmt.Fprintf(stdout, "Last Action At: %s\n", p.LastActionAt)
 	}
+	if p.Links != nil {
+		links := p.Links.absolute(cliLauncherBase())
+		fmt.Fprintf(stdout, "App URL: %s\n", links.App)
+		if links.MinIO != "" {
+			fmt.Fprintf(stdout, "MinIO URL: %s\n", links.MinIO)
+		}
+		fmt.Fprintf(stdout, "Logs: %s\n", links.Logs)
+		fmt.Fprintf(stdout, "Metrics: %s\n", links.Metrics)
+	}
 	return 0
 }
 
 func runProfileUpdate(srv *Server, profileID, version string, stdout, stderr io.Writer) int {
 	if !profileIDRe.MatchString(profileID) {
 		fmt.Fprintf(stderr, "Invalid profile name: %s\n", profileID)
-		return 2
+		return exitValidation
 	}
 	version = strings.TrimSpace(version)
 	if version == "" {
@@ -188,49 +281,78 @@ func runProfileUpdate(srv *Server, profileID, version string, stdout, stderr io.
 	}
 	if !versionTagRe.MatchString(version) {
 		fmt.Fprintf(stderr, "Invalid version tag: %s\n", version)
-		return 2
+		return exitValidation
 	}
-	if _, _, err := srv.getProfileForAction(profileID); err != nil {
+	store, idx, err := srv.getProfileForAction(context.Background(), profileID)
+	if err != nil {
 		if os.IsNotExist(err) {
 			fmt.Fprintf(stderr, "Profile not found: %s\n", profileID)
-			return 1
+			return exitNotFound
 		}
-		fmt.Fprintf(stderr, "Failed to load profile: %v\n", err)
-		return 1
+		return cliFail(stderr, "Failed to load profile", err)
 	}
 
 	fmt.Fprintf(stdout, "Updating profile %s to version %s...\n", profileID, version)
-	if err := srv.performVersionUpdate(profileID, version, "", context.Background()); err != nil {
-		fmt.Fprintf(stderr, "Update failed: %v\n", err)
-		return 1
+	if err := srv.Profiles().RunAction(context.Background(), profileID, "version", version, store.Profiles[idx].Revision, false); err != nil {
+		return cliFail(stderr, "Update failed", err)
 	}
 	fmt.Fprintf(stdout, "Profile %s updated to version %s.\n", profileID, version)
 	return 0
 }
 
-func runProfileDelete(srv *Server, profileID string, stdout, stderr io.Writer) int {
+// runProfileRename sets the display name; "" clears it.
+func runProfileRename(srv *Server, profileID, name string, stdout, stderr io.Writer) int {
+	p, err := srv.Profiles().Rename(context.Background(), profileID, name, 0)
+	if err != nil {
+		if errors.Is(err, ErrProfileNotFound) {
+			fmt.Fprintf(stderr, "Profile not found: %s\n", profileID)
+			return exitNotFound
+		}
+		return cliFail(stderr, "Rename failed", err)
+	}
+	if p.DisplayName == "" {
+		fmt.Fprintf(stdout, "Profile %s is shown by its ID again.\n", p.ID)
+		return 0
+	}
+	fmt.Fprintf(stdout, "Profile %s is now shown as %q.\n", p.ID, p.DisplayName)
+	return 0
+}
+
+// runProfileDelete moves a profile to the trash (action "delete") or
+// removes a trashed profile and its volumes for good (action "purge").
+func runProfileDelete(srv *Server, profileID, action string, stdout, stderr io.Writer) int {
 	if !profileIDRe.MatchString(profileID) {
 		fmt.Fprintf(stderr, "Invalid profile name: %s\n", profileID)
-		return 2
+		return exitValidation
 	}
 
 	fmt.Fprintf(stdout, "Deleting profile %s...\n", profileID)
-	if err := srv.performDelete(profileID, "", context.Background()); err != nil {
-		if os.IsNotExist(err) {
+	// Naming the profile on the command line is the confirmation.
+	if err := srv.Profiles().RunAction(context.Background(), profileID, action, "", 0, true); err != nil {
+		if errors.Is(err, ErrProfileNotFound) {
 			fmt.Fprintf(stderr, "Profile not found: %s\n", profileID)
-			return 1
+			return exitNotFound
 		}
-		fmt.Fprintf(stderr, "Delete failed: %v\n", err)
-		return 1
+		return cliFail(stderr, "Delete failed", err)
+	}
+	if action == "delete" && appCfg.TrashRetentionDays > 0 {
+		fmt.Fprintf(stdout, "Profile %s moved to trash; its data is purged after %d days.\n", profileID, appCfg.TrashRetentionDays)
+		return 0
 	}
 	fmt.Fprintf(stdout, "Profile %s deleted.\n", profileID)
 	return 0
 }
 
 func writeProfileCLIUsage(w io.Writer) {
-	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "Usage (add --quiet to print errors only):")
 	fmt.Fprintln(w, "  profile list")
 	fmt.Fprintln(w, "  profile <name> info")
+	fmt.Fprintln(w, "  profile <name> logs [service] [--tail N] [--follow]")
+	fmt.Fprintln(w, "  profile <name> enable [--wait]")
 	fmt.Fprintln(w, "  profile <name> update [version]")
+	fmt.Fprintln(w, "  profile <name> rename <display name>")
 	fmt.Fprintln(w, "  profile <name> delete")
+	fmt.Fprintln(w, "  profile <name> purge")
+	fmt.Fprintln(w, "  apply -f <profiles.yaml> [--dry-run]")
+	fmt.Fprintln(w, "  job follow <job-id>")
 }
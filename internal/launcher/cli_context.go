@@ -0,0 +1,300 @@
+package launcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+)
+
+var contextNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+// cliContext names one launcher the CLI can talk to. URL is used by the
+// commands that drive a running launcher (enable, job follow, tui); DataDir
+// replaces the data directory for the commands that read it directly.
+type cliContext struct {
+	Name    string `json:"name"`
+	URL     string `json:"url,omitempty"`
+	Token   string `json:"token,omitempty"`
+	DataDir string `json:"dataDir,omitempty"`
+}
+
+type cliConfig struct {
+	Current  string       `json:"current,omitempty"`
+	Contexts []cliContext `json:"contexts"`
+}
+
+// activeCLIContext is the context selected for the current CLI invocation;
+// the zero value means the local launcher.
+var activeCLIContext cliContext
+
+// runsAgainstContextURL reports whether a CLI command can be sent to a
+// context's URL. The others work on a data directory, and with a URL set
+// they would quietly act on this machine's instead of the server's.
+func runsAgainstContextURL(args []string) bool {
+	fields := make([]string, 0, 3)
+	for _, arg := range args {
+		if len(fields) == 3 {
+			break
+		}
+		fields = append(fields, strings.ToLower(strings.TrimSpace(arg)))
+	}
+	switch fields[0] {
+	case "user", "token":
+		return false
+	case "profile":
+		if len(fields) < 2 {
+			return true
+		}
+		switch fields[1] {
+		case "help", "-h", "--help":
+			return true
+		case "list":
+			return false
+		}
+		return len(fields) < 3 || fields[2] == "enable" || fields[2] == "logs"
+	}
+	return true
+}
+
+func loadCLIConfig(path string) (cliConfig, error) {
+	var cfg cliConfig
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if len(bytesTrimSpace(b)) == 0 {
+		return cfg, nil
+	}
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// saveCLIConfig writes the file readable by the owner only, since contexts
+// may hold API tokens.
+func saveCLIConfig(path string, cfg cliConfig) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (c cliConfig) find(name string) int {
+	for i, ctx := range c.Contexts {
+		if ctx.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// selectCLIContext resolves the context for this invocation: the --context
+// flag when given, otherwise the one chosen with "context use".
+func selectCLIContext(path, override string) (cliContext, error) {
+	cfg, err := loadCLIConfig(path)
+	if err != nil {
+		return cliContext{}, err
+	}
+	name := cfg.Current
+	if override != "" {
+		name = override
+	}
+	if name == "" {
+		return cliContext{}, nil
+	}
+	idx := cfg.find(name)
+	if idx < 0 {
+		return cliContext{}, fmt.Errorf("context %q does not exist: %w", name, os.ErrNotExist)
+	}
+	return cfg.Contexts[idx], nil
+}
+
+// stripContextFlag removes "--context <name>" or "--context=<name>" from
+// args and returns the name.
+func stripContextFlag(args []string) ([]string, string, bool) {
+	out := make([]string, 0, len(args))
+	name := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--context":
+			if i+1 >= len(args) {
+				return args, "", false
+			}
+			name = strings.TrimSpace(args[i+1])
+			i++
+		case strings.HasPrefix(arg, "--context="):
+			name = strings.TrimSpace(strings.TrimPrefix(arg, "--context="))
+		default:
+			out = append(out, arg)
+		}
+	}
+	return out, name, true
+}
+
+func validateContextURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ValidationError{Msg: "Context URL must be an http(s) URL, e.g. http://127.0.0.1:7331"}
+	}
+	return nil
+}
+
+func runContextCLI(path string, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		writeContextCLIUsage(stderr)
+		return exitUsage
+	}
+	cfg, err := loadCLIConfig(path)
+	if err != nil {
+		return cliFail(stderr, "Failed to read CLI config", err)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(args[0])) {
+	case "help", "-h", "--help":
+		writeContextCLIUsage(stdout)
+		return 0
+	case "list":
+		if len(args) != 1 {
+			writeContextCLIUsage(stderr)
+			return exitUsage
+		}
+		if len(cfg.Contexts) == 0 {
+			fmt.Fprintln(stdout, "No contexts defined; using the local launcher.")
+			return 0
+		}
+		tw := tabwriter.NewWriter(stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "CURRENT\tNAME\tURL\tDATA DIR\tTOKEN")
+		for _, ctx := range cfg.Contexts {
+			current := ""
+			if ctx.Name == cfg.Current {
+				current = "*"
+			}
+			token := ""
+			if ctx.Token != "" {
+				token = "set"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", current, ctx.Name, ctx.URL, ctx.DataDir, token)
+		}
+		_ = tw.Flush()
+		return 0
+	case "add":
+		return runContextAdd(path, cfg, args[1:], stdout, stderr)
+	case "use":
+		if len(args) != 2 {
+			writeContextCLIUsage(stderr)
+			return exitUsage
+		}
+		name := strings.TrimSpace(args[1])
+		if cfg.find(name) < 0 {
+			fmt.Fprintf(stderr, "Context not found: %s\n", name)
+			return exitNotFound
+		}
+		cfg.Current = name
+		if err := saveCLIConfig(path, cfg); err != nil {
+			return cliFail(stderr, "Failed to save CLI config", err)
+		}
+		fmt.Fprintf(stdout, "Switched to context %s.\n", name)
+		return 0
+	case "remove":
+		if len(args) != 2 {
+			writeContextCLIUsage(stderr)
+			return exitUsage
+		}
+		name := strings.TrimSpace(args[1])
+		idx := cfg.find(name)
+		if idx < 0 {
+			fmt.Fprintf(stderr, "Context not found: %s\n", name)
+			return exitNotFound
+		}
+		cfg.Contexts = append(cfg.Contexts[:idx], cfg.Contexts[idx+1:]...)
+		if cfg.Current == name {
+			cfg.Current = ""
+		}
+		if err := saveCLIConfig(path, cfg); err != nil {
+			return cliFail(stderr, "Failed to save CLI config", err)
+		}
+		fmt.Fprintf(stdout, "Removed context %s.\n", name)
+		return 0
+	default:
+		writeContextCLIUsage(stderr)
+		return exitUsage
+	}
+}
+
+// runContextAdd creates a context or replaces the one with the same name.
+func runContextAdd(path string, cfg cliConfig, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		writeContextCLIUsage(stderr)
+		return exitUsage
+	}
+	ctx := cliContext{Name: strings.ToLower(strings.TrimSpace(args[0]))}
+	if !contextNameRe.MatchString(ctx.Name) {
+		fmt.Fprintf(stderr, "Invalid context name: %s\n", args[0])
+		return exitValidation
+	}
+	rest := args[1:]
+	for i := 0; i < len(rest); i++ {
+		if i+1 >= len(rest) {
+			writeContextCLIUsage(stderr)
+			return exitUsage
+		}
+		value := strings.TrimSpace(rest[i+1])
+		switch rest[i] {
+		case "--url":
+			ctx.URL = strings.TrimRight(value, "/")
+		case "--token":
+			ctx.Token = value
+		case "--data-dir":
+			ctx.DataDir = value
+		default:
+			writeContextCLIUsage(stderr)
+			return exitUsage
+		}
+		i++
+	}
+	if ctx.URL != "" {
+		if err := validateContextURL(ctx.URL); err != nil {
+			return cliFail(stderr, "Invalid context", err)
+		}
+	}
+
+	if idx := cfg.find(ctx.Name); idx >= 0 {
+		cfg.Contexts[idx] = ctx
+	} else {
+		cfg.Contexts = append(cfg.Contexts, ctx)
+	}
+	if err := saveCLIConfig(path, cfg); err != nil {
+		return cliFail(stderr, "Failed to save CLI config", err)
+	}
+	fmt.Fprintf(stdout, "Saved context %s. Activate it with: context use %s\n", ctx.Name, ctx.Name)
+	return 0
+}
+
+func writeContextCLIUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  context list")
+	fmt.Fprintln(w, "  context add <name> [--url <url>] [--token <token>] [--data-dir <dir>]")
+	fmt.Fprintln(w, "  context use <name>")
+	fmt.Fprintln(w, "  context remove <name>")
+	fmt.Fprintln(w, "Any command accepts --context <name> to use a context once.")
+}
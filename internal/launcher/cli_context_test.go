@@ -0,0 +1,161 @@
+package launcher
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"launcher/internal/config"
+)
+
+func TestRunCLI_ContextLifecycle(t *testing.T) {
+	cfg := config.Load("dev")
+	cfg.DataDir = t.TempDir()
+	cfg.CLIConfigPath = filepath.Join(t.TempDir(), "cli.json")
+	run := func(args ...string) (int, string) {
+		var out, errOut bytes.Buffer
+		_, code := RunCLI(cfg, args, &out, &errOut)
+		return code, out.String() + errOut.String()
+	}
+
+	if code, out := run("context", "add", "server", "--url", "ftp://example"); code != exitValidation {
+		t.Fatalf("expected invalid URL to be rejected, got %d: %s", code, out)
+	}
+	if code, out := run("context", "add", "server", "--url", "http://127.0.0.1:17331/", "--token", "secret"); code != exitOK {
+		t.Fatalf("add failed: %d %s", code, out)
+	}
+	if code, _ := run("context", "use", "laptop"); code != exitNotFound {
+		t.Fatalf("expected unknown context to be rejected, got %d", code)
+	}
+	if code, out := run("context", "use", "server"); code != exitOK {
+		t.Fatalf("use failed: %d %s", code, out)
+	}
+	_, out := run("context", "list")
+	if !strings.Contains(out, "*") || !strings.Contains(out, "http://127.0.0.1:17331 ") || strings.Contains(out, "secret") {
+		t.Fatalf("unexpected list output %q", out)
+	}
+	if info, err := os.Stat(cfg.CLIConfigPath); err != nil || (runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0) {
+		t.Fatalf("expected a private CLI config file, got %v (%v)", info, err)
+	}
+
+	if code, _ := run("context", "remove", "server"); code != exitOK {
+		t.Fatalf("remove failed: %d", code)
+	}
+	stored, err := loadCLIConfig(cfg.CLIConfigPath)
+	if err != nil || stored.Current != "" || len(stored.Contexts) != 0 {
+		t.Fatalf("expected removing the current context to clear it, got %+v (%v)", stored, err)
+	}
+}
+
+func TestRunCLI_ContextDataDir(t *testing.T) {
+	cfg := config.Load("dev")
+	cfg.DataDir = t.TempDir()
+	cfg.CLIConfigPath = filepath.Join(t.TempDir(), "cli.json")
+	other := t.TempDir()
+	if err := writeProfileStoreAtomic(filepath.Join(other, "profiles.json"), ProfileStore{Profiles: []ProfileRequest{{ID: "remote-one", Version: "1.0.0"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveCLIConfig(cfg.CLIConfigPath, cliConfig{Contexts: []cliContext{{Name: "other", DataDir: other}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out, errOut bytes.Buffer
+	if _, code := RunCLI(cfg, []string{"profile", "list", "--context", "other"}, &out, &errOut); code != exitOK {
+		t.Fatalf("expected exit 0, got %d (%s)", code, errOut.String())
+	}
+	if !strings.Contains(out.String(), "remote-one") {
+		t.Fatalf("expected profiles from the context data dir, got %q", out.String())
+	}
+	out.Reset()
+	if _, code := RunCLI(cfg, []string{"profile", "list", "--context=missing"}, &out, &errOut); code != exitNotFound {
+		t.Fatalf("expected unknown --context to fail with %d, got %d", exitNotFound, code)
+	}
+}
+
+func TestRunCLI_ContextURLDrivesRemoteLauncher(t *testing.T) {
+	var gotAuth, gotPath string
+	ts := httptest.NewServer(chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth, gotPath = r.Header.Get("Authorization"), r.URL.Path
+		writeJSON(w, http.StatusAccepted, map[string]any{"ok": true, "jobId": "job-9"})
+	}), withAuth, withCSRF))
+	defer ts.Close()
+
+	cfg := config.Load("dev")
+	cfg.DataDir = t.TempDir()
+	cfg.CLIConfigPath = filepath.Join(t.TempDir(), "cli.json")
+	if err := saveCLIConfig(cfg.CLIConfigPath, cliConfig{Current: "server", Contexts: []cliContext{{Name: "server", URL: ts.URL, Token: "secret"}}}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { activeCLIContext = cliContext{} }()
+
+	var out, errOut bytes.Buffer
+	if _, code := RunCLI(cfg, []string{"profile", "alpha", "enable"}, &out, &errOut); code != exitOK {
+		t.Fatalf("expected exit 0, got %d (%s)", code, errOut.String())
+	}
+	if gotPath != "/api/profiles/alpha/enable" || gotAuth != "Bearer secret" || !strings.Contains(out.String(), "job-9") {
+		t.Fatalf("unexpected remote call path=%q auth=%q out=%q", gotPath, gotAuth, out.String())
+	}
+}
+
+func TestRunCLI_ContextURLStreamsLogs(t *testing.T) {
+	var gotQuery string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Path + "?" + r.URL.RawQuery
+		_, _ = io.WriteString(w, "postgres-1  | ready to accept connections\n")
+	}))
+	defer ts.Close()
+
+	cfg := config.Load("dev")
+	cfg.DataDir = t.TempDir()
+	cfg.CLIConfigPath = filepath.Join(t.TempDir(), "cli.json")
+	if err := saveCLIConfig(cfg.CLIConfigPath, cliConfig{Current: "server", Contexts: []cliContext{{Name: "server", URL: ts.URL}}}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { activeCLIContext = cliContext{} }()
+
+	var out, errOut bytes.Buffer
+	if _, code := RunCLI(cfg, []string{"profile", "alpha", "logs", "postgres", "--follow"}, &out, &errOut); code != exitOK {
+		t.Fatalf("expected exit 0, got %d (%s)", code, errOut.String())
+	}
+	if gotQuery != "/api/profiles/alpha/logs?follow=true&service=postgres&tail=200" || !strings.Contains(out.String(), "ready to accept connections") {
+		t.Fatalf("unexpected remote logs call %q out=%q", gotQuery, out.String())
+	}
+}
+
+func TestRunCLI_ContextURLRefusesDataDirCommands(t *testing.T) {
+	called := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+	defer ts.Close()
+
+	cfg := config.Load("dev")
+	cfg.DataDir = t.TempDir()
+	cfg.CLIConfigPath = filepath.Join(t.TempDir(), "cli.json")
+	if err := saveCLIConfig(cfg.CLIConfigPath, cliConfig{Current: "server", Contexts: []cliContext{{Name: "server", URL: ts.URL}}}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { activeCLIContext = cliContext{} }()
+
+	for _, args := range [][]string{
+		{"profile", "list"},
+		{"profile", "alpha", "info"},
+		{"profile", "alpha", "update", "1.2.0"},
+		{"profile", "alpha", "purge"},
+		{"profile", "alpha", "rename", "Shop"},
+		{"user", "list"},
+		{"token", "list"},
+	} {
+		var out, errOut bytes.Buffer
+		if _, code := RunCLI(cfg, args, &out, &errOut); code != exitUsage || !strings.Contains(errOut.String(), "works on the data directory") {
+			t.Fatalf("%v: expected the command refused, got %d (%s)", args, code, errOut.String())
+		}
+	}
+	if called {
+		t.Fatal("expected no request to the context URL")
+	}
+}
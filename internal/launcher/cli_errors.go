@@ -0,0 +1,88 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// CLI exit codes. Wrapper scripts branch on these, so existing values must
+// not change.
+const (
+	exitOK                = 0
+	exitFailure           = 1
+	exitUsage             = 2
+	exitNotFound          = 3
+	exitValidation        = 4
+	exitDockerUnavailable = 5
+	exitConflict          = 6
+	exitTimeout           = 124
+	exitCanceled          = 130
+)
+
+// remoteError is a failed request to a running launcher; the status code
+// keeps the failure class across the HTTP boundary.
+type remoteError struct {
+	Status int
+	Msg    string
+}
+
+func (e remoteError) Error() string { return e.Msg }
+
+// cliExitCode classifies err into one of the exit codes above.
+func cliExitCode(err error) int {
+	var ve ValidationError
+	var remote remoteError
+	msg := strings.ToLower(err.Error())
+	switch {
+	case errors.As(err, &remote):
+		switch {
+		case remote.Status == http.StatusNotFound:
+			return exitNotFound
+		case remote.Status == http.StatusBadRequest:
+			return exitValidation
+		case remote.Status == http.StatusConflict, remote.Status == http.StatusPreconditionRequired:
+			return exitConflict
+		}
+		return exitFailure
+	case errors.Is(err, ErrProfileNotFound), errors.Is(err, ErrJobNotFound), errors.Is(err, ErrAccountNotFound), errors.Is(err, ErrAPITokenNotFound), errors.Is(err, os.ErrNotExist):
+		return exitNotFound
+	case errors.As(err, &ve), errors.Is(err, ErrUnknownAction), errors.Is(err, ErrProfileLimitReached):
+		return exitValidation
+	case errors.Is(err, ErrProfileBusy), errors.Is(err, ErrProfileExists), errors.Is(err, ErrRevisionConflict), errors.Is(err, ErrJobCompleted), errors.Is(err, ErrJobNotPausable):
+		return exitConflict
+	case errors.Is(err, context.Canceled):
+		return exitCanceled
+	case errors.Is(err, context.DeadlineExceeded), strings.Contains(msg, "deadline exceeded"), strings.Contains(msg, "timeout"):
+		return exitTimeout
+	case isDockerUnavailableError(msg):
+		return exitDockerUnavailable
+	default:
+		return exitFailure
+	}
+}
+
+func isDockerUnavailableError(msg string) bool {
+	for _, marker := range []string{
+		"docker binary not found",
+		"cannot connect to the docker daemon",
+		"is the docker daemon running",
+		"error during connect",
+		"docker desktop is not running",
+	} {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// cliFail prints what failed and returns the matching exit code.
+func cliFail(stderr io.Writer, what string, err error) int {
+	fmt.Fprintf(stderr, "%s: %v\n", what, err)
+	return cliExitCode(err)
+}
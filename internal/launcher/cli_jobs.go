@@ -0,0 +1,270 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const jobFollowInterval = time.Second
+
+// launcherClient talks to a launcher that is already running, so CLI
+// commands can start and follow jobs that outlive the CLI process.
+type launcherClient struct {
+	base string
+	http *http.Client
+	csrf string
+	// token is sent as a bearer token for launchers reached through a
+	// context.
+	token string
+}
+
+// findRunningLauncher returns the launcher of the active context when it
+// names a URL. Otherwise it looks for a launcher on the port it last wrote
+// to the data directory, then on the configured listen port.
+func findRunningLauncher() (*launcherClient, bool) {
+	if activeCLIContext.URL != "" {
+		// No probe: a configured launcher that is down should fail loudly
+		// instead of falling back to running the job locally.
+		return &launcherClient{
+			base:  activeCLIContext.URL,
+			http:  &http.Client{Timeout: 10 * time.Second},
+			csrf:  randomToken(48),
+			token: activeCLIContext.Token,
+		}, true
+	}
+	ports := []int{}
+	if raw, err := os.ReadFile(filepath.Join(appCfg.DataDir, "launcher-port")); err == nil {
+		if p, err := strconv.Atoi(strings.TrimSpace(string(raw))); err == nil {
+			ports = append(ports, p)
+		}
+	}
+	ports = append(ports, normalizeListenPort(appCfg.ListenPort))
+	for _, port := range ports {
+		c := &launcherClient{
+			base: fmt.Sprintf("http://127.0.0.1:%d", port),
+			http: &http.Client{Timeout: 10 * time.Second},
+			csrf: randomToken(48),
+		}
+		probe := http.Client{Timeout: 700 * time.Millisecond}
+		resp, err := probe.Get(c.base + "/api/system/health")
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+func (c *launcherClient) request(method, path string) (*http.Request, error) {
+	req, err := http.NewRequest(method, c.base+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	// The mutation guard only compares the cookie with the header, which a
+	// local client can satisfy directly.
+	req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: c.csrf})
+	req.Header.Set("X-CSRF-Token", c.csrf)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return req, nil
+}
+
+func (c *launcherClient) do(method, path string, out any) error {
+	req, err := c.request(method, path)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode >= 300 {
+		return remoteError{Status: resp.StatusCode, Msg: strings.TrimSpace(string(body))}
+	}
+	return json.Unmarshal(body, out)
+}
+
+// stream copies the body of a GET to w as it arrives, with no timeout, for
+// responses that last as long as the user watches them.
+func (c *launcherClient) stream(path string, w io.Writer) error {
+	req, err := c.request(http.MethodGet, path)
+	if err != nil {
+		return err
+	}
+	resp, err := (&http.Client{Transport: c.http.Transport}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		return remoteError{Status: resp.StatusCode, Msg: strings.TrimSpace(string(body))}
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+func (c *launcherClient) startAction(profileID, action string) (string, error) {
+	var resp struct {
+		JobID string `json:"jobId"`
+	}
+	err := c.do(http.MethodPost, "/api/profiles/"+profileID+"/"+action, &resp)
+	return resp.JobID, err
+}
+
+func (c *launcherClient) job(jobID string) (ActionJob, error) {
+	var resp struct {
+		Job ActionJob `json:"job"`
+	}
+	err := c.do(http.MethodGet, "/api/jobs/"+url.PathEscape(jobID), &resp)
+	return resp.Job, err
+}
+
+// jobExitCode maps a finished job onto the CLI exit status.
+func jobExitCode(status string) int {
+	switch status {
+	case "succeeded":
+		return exitOK
+	case "timeout":
+		return exitTimeout
+	case "canceled":
+		return exitCanceled
+	default:
+		return exitFailure
+	}
+}
+
+// renderJobProgress formats one progress line with a 20 column bar.
+func renderJobProgress(job ActionJob) string {
+	progress := job.Progress
+	if progress < 0 {
+		progress = 0
+	}
+	if progress > 100 {
+		progress = 100
+	}
+	filled := progress / 5
+	line := fmt.Sprintf("[%s%s] %3d%%", strings.Repeat("#", filled), strings.Repeat("-", 20-filled), progress)
+	if job.Step != "" {
+		line += " [" + job.Step + "]"
+	}
+	if job.Message != "" {
+		line += " " + job.Message
+	}
+	if job.ETA != "" {
+		line += " - " + job.ETA
+	}
+	return line
+}
+
+// followJob polls fetch until the job finishes. On a terminal the bar is
+// redrawn in place; otherwise a line is printed whenever it changes, which
+// keeps logs of scripted runs readable.
+func followJob(fetch func() (ActionJob, error), stdout, stderr io.Writer, interval time.Duration) int {
+	tty := isTerminalWriter(stdout)
+	last := ""
+	for {
+		job, err := fetch()
+		if err != nil {
+			if tty && last != "" {
+				fmt.Fprintln(stdout)
+			}
+			return cliFail(stderr, "Failed to read job status", err)
+		}
+		line := renderJobProgress(job)
+		if line != last {
+			if tty {
+				fmt.Fprintf(stdout, "\r\033[K%s", line)
+			} else {
+				fmt.Fprintln(stdout, line)
+			}
+			last = line
+		}
+		if isTerminalJobStatus(job.Status) {
+			if tty {
+				fmt.Fprintln(stdout)
+			}
+			if job.Status != "succeeded" && job.Error != "" {
+				fmt.Fprintf(stderr, "Job %s %s: %s\n", job.ID, job.Status, job.Error)
+			}
+			return jobExitCode(job.Status)
+		}
+		time.Sleep(interval)
+	}
+}
+
+func isTerminalWriter(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// runProfileEnable starts the enable job on the running launcher, printing
+// the job id or, with --wait, following it to the end. Without a running
+// launcher the job runs in this process, so the command always waits.
+func runProfileEnable(srv *Server, profileID string, wait bool, stdout, stderr io.Writer) int {
+	if !profileIDRe.MatchString(profileID) {
+		fmt.Fprintf(stderr, "Invalid profile name: %s\n", profileID)
+		return exitValidation
+	}
+	if client, ok := findRunningLauncher(); ok {
+		jobID, err := client.startAction(profileID, "enable")
+		if err != nil {
+			return cliFail(stderr, "Enable failed", err)
+		}
+		if !wait {
+			fmt.Fprintf(stdout, "Enable started for %s (job %s). Follow it with: job follow %s\n", profileID, jobID, jobID)
+			return 0
+		}
+		return followJob(func() (ActionJob, error) { return client.job(jobID) }, stdout, stderr, jobFollowInterval)
+	}
+
+	job, err := srv.Profiles().StartAction(context.Background(), profileID, "enable", "", 0, false)
+	if err != nil {
+		if errors.Is(err, ErrProfileNotFound) {
+			fmt.Fprintf(stderr, "Profile not found: %s\n", profileID)
+			return exitNotFound
+		}
+		return cliFail(stderr, "Enable failed", err)
+	}
+	jobID := job.ID
+	return followJob(func() (ActionJob, error) { return srv.Jobs().Get(jobID) }, stdout, stderr, jobFollowInterval)
+}
+
+func runJobCLI(args []string, stdout, stderr io.Writer) int {
+	if len(args) != 2 || strings.ToLower(strings.TrimSpace(args[0])) != "follow" {
+		writeJobCLIUsage(stderr)
+		return exitUsage
+	}
+	jobID := strings.TrimSpace(args[1])
+	client, ok := findRunningLauncher()
+	if !ok {
+		fmt.Fprintln(stderr, "No running launcher found; jobs only exist while the launcher runs.")
+		return exitNotFound
+	}
+	return followJob(func() (ActionJob, error) { return client.job(jobID) }, stdout, stderr, jobFollowInterval)
+}
+
+func writeJobCLIUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  job follow <job-id>")
+}
@@ -0,0 +1,49 @@
+package launcher
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFollowJobExitCodes(t *testing.T) {
+	for status, want := range map[string]int{"succeeded": 0, "failed": 1, "timeout": 124, "canceled": 130} {
+		steps := []ActionJob{
+			{ID: "j1", Status: "running", Step: "up", Message: "Starting", Progress: 30, ETA: "about 2 min left"},
+			{ID: "j1", Status: "running", Step: "up", Message: "Starting", Progress: 30, ETA: "about 2 min left"},
+			{ID: "j1", Status: status, Message: "Done", Progress: 100, Error: "boom"},
+		}
+		i := 0
+		fetch := func() (ActionJob, error) {
+			job := steps[i]
+			i++
+			return job, nil
+		}
+		var out, errOut bytes.Buffer
+		if got := followJob(fetch, &out, &errOut, 0); got != want {
+			t.Fatalf("%s: expected exit %d, got %d", status, want, got)
+		}
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if len(lines) != 2 || lines[0] != "[######--------------]  30% [up] Starting - about 2 min left" {
+			t.Fatalf("%s: unexpected progress output %q", status, out.String())
+		}
+		if (status == "succeeded") != (errOut.Len() == 0) {
+			t.Fatalf("%s: unexpected stderr %q", status, errOut.String())
+		}
+	}
+}
+
+func TestLauncherClientPassesMutationGuard(t *testing.T) {
+	ts := httptest.NewServer(chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusAccepted, map[string]any{"ok": true, "jobId": "job-7"})
+	}), withAuth, withCSRF))
+	defer ts.Close()
+
+	c := &launcherClient{base: ts.URL, http: ts.Client(), csrf: "token"}
+	jobID, err := c.startAction("alpha", "enable")
+	if err != nil || jobID != "job-7" {
+		t.Fatalf("expected job-7, got %q (%v)", jobID, err)
+	}
+}
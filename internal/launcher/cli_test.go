@@ -2,6 +2,8 @@ package launcher
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -118,6 +120,9 @@ func TestRunCLI_ProfileInfo(t *testing.T) {
 	if !strings.Contains(text, "Domain: local.test") {
 		t.Fatalf("expected info output to contain profile domain, got: %s", text)
 	}
+	if !strings.Contains(text, "App URL: https://local.test\n") || !strings.Contains(text, "/api/profiles/alpha/logs\n") {
+		t.Fatalf("expected info output to contain the profile links, got: %s", text)
+	}
 }
 
 func TestRunCLI_ProfileUpdateDefaultsToLatest(t *testing.T) {
@@ -152,7 +157,7 @@ func TestRunCLI_ProfileUpdateDefaultsToLatest(t *testing.T) {
 		t.Fatalf("expected exitCode=0, got %d, err=%s", exitCode, errOut.String())
 	}
 
-	updated, err := loadProfileStore(storePath)
+	updated, err := loadProfileStore(context.Background(), storePath)
 	if err != nil {
 		t.Fatalf("loadProfileStore failed: %v", err)
 	}
@@ -196,11 +201,67 @@ func TestRunCLI_ProfileDelete(t *testing.T) {
 		t.Fatalf("expected exitCode=0, got %d, err=%s", exitCode, errOut.String())
 	}
 
-	updated, err := loadProfileStore(storePath)
+	updated, err := loadProfileStore(context.Background(), storePath)
+	if err != nil {
+		t.Fatalf("loadProfileStore failed: %v", err)
+	}
+	if len(updated.Profiles) != 1 || updated.Profiles[0].DeletedAt == "" {
+		t.Fatalf("expected profile to be moved to trash, got %+v", updated.Profiles)
+	}
+
+	handled, exitCode = RunCLI(cfg, []string{"profile", "alpha", "purge"}, &out, &errOut)
+	if !handled || exitCode != 0 {
+		t.Fatalf("expected purge to succeed, got exitCode=%d, err=%s", exitCode, errOut.String())
+	}
+	updated, err = loadProfileStore(context.Background(), storePath)
 	if err != nil {
 		t.Fatalf("loadProfileStore failed: %v", err)
 	}
 	if len(updated.Profiles) != 0 {
-		t.Fatalf("expected 0 profiles after delete, got %d", len(updated.Profiles))
+		t.Fatalf("expected 0 profiles after purge, got %d", len(updated.Profiles))
+	}
+}
+
+func TestRunCLI_ExitCodes(t *testing.T) {
+	cfg := config.Load("dev")
+	cfg.DataDir = t.TempDir()
+	appCfg = cfg
+	if err := writeProfileStoreAtomic(filepath.Join(cfg.DataDir, "profiles.json"), ProfileStore{Profiles: []ProfileRequest{{ID: "alpha", Version: "1.0.0"}}}); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"profile"}, exitUsage},
+		{[]string{"profile", "missing", "info"}, exitNotFound},
+		{[]string{"profile", "Bad_Name!", "info"}, exitValidation},
+		{[]string{"profile", "alpha", "update", "bad tag"}, exitValidation},
+		{[]string{"profile", "alpha", "purge"}, exitValidation},
+		{[]string{"profile", "alpha", "info", "--quiet"}, exitOK},
+	}
+	for _, tc := range cases {
+		var out, errOut bytes.Buffer
+		if _, code := RunCLI(cfg, tc.args, &out, &errOut); code != tc.want {
+			t.Fatalf("%v: expected exit %d, got %d (%s)", tc.args, tc.want, code, errOut.String())
+		}
+		if tc.want == exitOK && out.Len() != 0 {
+			t.Fatalf("%v: expected --quiet to silence output, got %q", tc.args, out.String())
+		}
+	}
+}
+
+func TestCLIExitCodeClassification(t *testing.T) {
+	cases := map[error]int{
+		ProfileBusyError{JobID: "j"}:                          exitConflict,
+		remoteError{Status: 404, Msg: "Job not found"}:        exitNotFound,
+		errors.New("Cannot connect to the Docker daemon"):     exitDockerUnavailable,
+		errors.New("compose up: context deadline exceeded"):   exitTimeout,
+		errors.New("exit status 17: something else happened"): exitFailure,
+	}
+	for err, want := range cases {
+		if got := cliExitCode(err); got != want {
+			t.Fatalf("%v: expected %d, got %d", err, want, got)
+		}
 	}
 }
@@ -0,0 +1,88 @@
+package launcher
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// runTokenCLI manages API tokens in the data directory. The secret is
+// printed once, on create.
+func runTokenCLI(srv *Server, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		writeTokenCLIUsage(stderr)
+		return exitUsage
+	}
+	switch strings.ToLower(strings.TrimSpace(args[0])) {
+	case "help", "-h", "--help":
+		writeTokenCLIUsage(stdout)
+		return 0
+	case "list":
+		if len(args) != 1 {
+			writeTokenCLIUsage(stderr)
+			return exitUsage
+		}
+		tokens := srv.tokens.list()
+		if len(tokens) == 0 {
+			fmt.Fprintln(stdout, "No API tokens.")
+			return 0
+		}
+		tw := tabwriter.NewWriter(stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tROLE\tPROFILES\tENDS WITH\tCREATED")
+		for _, tok := range tokens {
+			profiles := "-"
+			if tok.Role == roleDeploy {
+				profiles = "all"
+			}
+			if len(tok.Profiles) > 0 {
+				profiles = strings.Join(tok.Profiles, ",")
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", tok.Name, tok.Role, profiles, tok.Hint, tok.CreatedAt)
+		}
+		_ = tw.Flush()
+		return 0
+	case "create":
+		if len(args) < 4 || args[2] != "--role" || len(args)%2 != 0 {
+			writeTokenCLIUsage(stderr)
+			return exitUsage
+		}
+		profiles := []string{}
+		for i := 4; i < len(args); i += 2 {
+			if args[i] != "--profile" {
+				writeTokenCLIUsage(stderr)
+				return exitUsage
+			}
+			profiles = append(profiles, args[i+1])
+		}
+		tok, secret, err := srv.tokens.create(args[1], args[3], profiles)
+		if err != nil {
+			return cliFail(stderr, "Failed to create token", err)
+		}
+		fmt.Fprintf(stderr, "Created %s token %s. It is shown only once:\n", tok.Role, tok.Name)
+		fmt.Fprintln(stdout, secret)
+		return 0
+	case "revoke":
+		if len(args) != 2 {
+			writeTokenCLIUsage(stderr)
+			return exitUsage
+		}
+		name := strings.ToLower(strings.TrimSpace(args[1]))
+		if err := srv.tokens.revoke(name); err != nil {
+			return cliFail(stderr, "Failed to revoke token", err)
+		}
+		fmt.Fprintf(stdout, "Revoked token %s.\n", name)
+		return 0
+	default:
+		writeTokenCLIUsage(stderr)
+		return exitUsage
+	}
+}
+
+func writeTokenCLIUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  token list")
+	fmt.Fprintln(w, "  token create <name> --role admin|viewer|deploy [--profile <id>]...")
+	fmt.Fprintln(w, "  token revoke <name>")
+	fmt.Fprintln(w, "Send the token as \"Authorization: Bearer <token>\".")
+}
@@ -0,0 +1,83 @@
+package launcher
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// userPasswordInput is where "user add" reads the password from, so it
+// never appears in shell history or the process list.
+var userPasswordInput io.Reader = os.Stdin
+
+// runUserCLI manages launcher accounts in the data directory. A running
+// launcher picks the changes up on its next request.
+func runUserCLI(srv *Server, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		writeUserCLIUsage(stderr)
+		return exitUsage
+	}
+	switch strings.ToLower(strings.TrimSpace(args[0])) {
+	case "help", "-h", "--help":
+		writeUserCLIUsage(stdout)
+		return 0
+	case "list":
+		if len(args) != 1 {
+			writeUserCLIUsage(stderr)
+			return exitUsage
+		}
+		accounts := srv.accounts.list()
+		if len(accounts) == 0 {
+			fmt.Fprintln(stdout, "No accounts; network clients have read-only access without signing in.")
+			return 0
+		}
+		tw := tabwriter.NewWriter(stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "USERNAME\tROLE\tCREATED")
+		for _, acc := range accounts {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", acc.Username, acc.Role, acc.CreatedAt)
+		}
+		_ = tw.Flush()
+		return 0
+	case "add":
+		if len(args) != 4 || args[2] != "--role" {
+			writeUserCLIUsage(stderr)
+			return exitUsage
+		}
+		fmt.Fprintf(stderr, "Password for %s: ", args[1])
+		line, err := bufio.NewReader(userPasswordInput).ReadString('\n')
+		fmt.Fprintln(stderr)
+		if err != nil && line == "" {
+			return cliFail(stderr, "Failed to read password", err)
+		}
+		acc, err := srv.accounts.put(args[1], args[3], strings.TrimRight(line, "\r\n"))
+		if err != nil {
+			return cliFail(stderr, "Failed to save account", err)
+		}
+		fmt.Fprintf(stdout, "Saved %s account %s.\n", acc.Role, acc.Username)
+		return 0
+	case "remove":
+		if len(args) != 2 {
+			writeUserCLIUsage(stderr)
+			return exitUsage
+		}
+		name := strings.ToLower(strings.TrimSpace(args[1]))
+		if err := srv.accounts.remove(name); err != nil {
+			return cliFail(stderr, "Failed to remove account", err)
+		}
+		fmt.Fprintf(stdout, "Removed account %s.\n", name)
+		return 0
+	default:
+		writeUserCLIUsage(stderr)
+		return exitUsage
+	}
+}
+
+func writeUserCLIUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  user list")
+	fmt.Fprintln(w, "  user add <name> --role admin|viewer   (reads the password from stdin)")
+	fmt.Fprintln(w, "  user remove <name>")
+}
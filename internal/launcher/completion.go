@@ -0,0 +1,193 @@
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The generated scripts call the hidden "__complete profiles" command, so
+// profile IDs stay current without regenerating the script.
+const bashCompletion = `# bash completion for %[1]s
+_%[2]s() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    local words=()
+    case "$COMP_CWORD" in
+        1) words=(profile apply job tui context config user token selftest completion) ;;
+        2)
+            case "${COMP_WORDS[1]}" in
+                profile) words=(list help $(%[1]s __complete profiles 2>/dev/null)) ;;
+                apply) words=(-f --dry-run) ;;
+                job) words=(follow) ;;
+                context) words=(list add use remove) ;;
+                config) words=(check) ;;
+                user) words=(list add remove) ;;
+                token) words=(list create revoke) ;;
+                completion) words=(bash zsh fish powershell) ;;
+            esac
+            ;;
+        3)
+            if [[ "${COMP_WORDS[1]}" == profile && "${COMP_WORDS[2]}" != list ]]; then
+                words=(%[3]s)
+            fi
+            ;;
+        4)
+            if [[ "${COMP_WORDS[1]}" == profile && "${COMP_WORDS[3]}" == enable ]]; then
+                words=(--wait)
+            elif [[ "${COMP_WORDS[1]}" == profile && "${COMP_WORDS[3]}" == update ]]; then
+                words=(latest)
+            fi
+            ;;
+    esac
+    COMPREPLY=($(compgen -W "${words[*]}" -- "$cur"))
+}
+complete -F _%[2]s %[1]s
+`
+
+const zshCompletion = `#compdef %[1]s
+_%[2]s() {
+    local -a candidates
+    case $CURRENT in
+        2) candidates=(profile apply job tui context config user token selftest completion) ;;
+        3)
+            case $words[2] in
+                profile) candidates=(list help ${(f)"$(%[1]s __complete profiles 2>/dev/null)"}) ;;
+                apply) candidates=(-f --dry-run) ;;
+                job) candidates=(follow) ;;
+                context) candidates=(list add use remove) ;;
+                config) candidates=(check) ;;
+                user) candidates=(list add remove) ;;
+                token) candidates=(list create revoke) ;;
+                completion) candidates=(bash zsh fish powershell) ;;
+            esac
+            ;;
+        4)
+            if [[ $words[2] == profile && $words[3] != list ]]; then
+                candidates=(%[3]s)
+            fi
+            ;;
+        5)
+            if [[ $words[2] == profile && $words[4] == enable ]]; then
+                candidates=(--wait)
+            elif [[ $words[2] == profile && $words[4] == update ]]; then
+                candidates=(latest)
+            fi
+            ;;
+    esac
+    compadd -a candidates
+}
+compdef _%[2]s %[1]s
+`
+
+const fishCompletion = `# fish completion for %[1]s
+complete -c %[1]s -f
+complete -c %[1]s -n "__fish_use_subcommand" -a "profile apply job tui context config user token selftest completion"
+complete -c %[1]s -n "__fish_seen_subcommand_from profile; and test (count (commandline -opc)) -eq 2" -a "list help (%[1]s __complete profiles 2>/dev/null)"
+complete -c %[1]s -n "__fish_seen_subcommand_from profile; and test (count (commandline -opc)) -eq 3" -a "%[3]s"
+complete -c %[1]s -n "__fish_seen_subcommand_from enable; and test (count (commandline -opc)) -eq 4" -a "--wait"
+complete -c %[1]s -n "__fish_seen_subcommand_from apply" -a "-f --dry-run"
+complete -c %[1]s -n "__fish_seen_subcommand_from job; and test (count (commandline -opc)) -eq 2" -a "follow"
+complete -c %[1]s -n "__fish_seen_subcommand_from context; and test (count (commandline -opc)) -eq 2" -a "list add use remove"
+complete -c %[1]s -n "__fish_seen_subcommand_from config" -a "check"
+complete -c %[1]s -n "__fish_seen_subcommand_from user; and test (count (commandline -opc)) -eq 2" -a "list add remove"
+complete -c %[1]s -n "__fish_seen_subcommand_from token; and test (count (commandline -opc)) -eq 2" -a "list create revoke"
+complete -c %[1]s -n "__fish_seen_subcommand_from completion" -a "bash zsh fish powershell"
+`
+
+const powershellCompletion = `# PowerShell completion for %[1]s
+Register-ArgumentCompleter -Native -CommandName '%[1]s', '%[1]s.exe' -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $words = @($commandAst.CommandElements | Select-Object -Skip 1 | ForEach-Object { $_.ToString() })
+    if ($wordToComplete) { $words = @($words | Select-Object -SkipLast 1) }
+    $candidates = switch ($words.Count) {
+        0 { 'profile', 'apply', 'job', 'tui', 'context', 'config', 'user', 'token', 'selftest', 'completion' }
+        1 {
+            switch ($words[0]) {
+                'profile' { @('list', 'help') + @(& '%[1]s' __complete profiles 2>$null) }
+                'apply' { '-f', '--dry-run' }
+                'job' { 'follow' }
+                'context' { 'list', 'add', 'use', 'remove' }
+                'config' { 'check' }
+                'user' { 'list', 'add', 'remove' }
+                'token' { 'list', 'create', 'revoke' }
+                'completion' { 'bash', 'zsh', 'fish', 'powershell' }
+            }
+        }
+        2 { if ($words[0] -eq 'profile' -and $words[1] -ne 'list') { '%[4]s' -split ' ' } }
+        3 {
+            if ($words[0] -eq 'profile' -and $words[2] -eq 'enable') { '--wait' }
+            elseif ($words[0] -eq 'profile' -and $words[2] -eq 'update') { 'latest' }
+        }
+    }
+    $candidates | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
+`
+
+// cliProfileActions are the per-profile CLI commands offered by completion.
+var cliProfileActions = []string{"info", "logs", "enable", "update", "rename", "delete", "purge"}
+
+func runCompletionCLI(args []string, stdout, stderr io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprintln(stderr, "Usage: completion bash|zsh|fish|powershell")
+		return exitUsage
+	}
+	prog := completionProgramName()
+	fn := strings.NewReplacer("-", "_", ".", "_").Replace(prog)
+	actions := strings.Join(cliProfileActions, " ")
+	switch strings.ToLower(strings.TrimSpace(args[0])) {
+	case "bash":
+		fmt.Fprintf(stdout, bashCompletion, prog, fn, actions)
+	case "zsh":
+		fmt.Fprintf(stdout, zshCompletion, prog, fn, actions)
+	case "fish":
+		fmt.Fprintf(stdout, fishCompletion, prog, fn, actions)
+	case "powershell":
+		fmt.Fprintf(stdout, powershellCompletion, prog, fn, actions, actions)
+	default:
+		fmt.Fprintf(stderr, "Unsupported shell: %s\n", args[0])
+		return exitUsage
+	}
+	return 0
+}
+
+func completionProgramName() string {
+	name := strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+	if name == "" || name == "." {
+		return "kimmio-launcher"
+	}
+	return name
+}
+
+// runCompleteCLI prints one completion candidate per line. Profile IDs come
+// from the store, or from the running launcher when the store cannot be
+// read (e.g. a different data directory in this shell).
+func runCompleteCLI(srv *Server, args []string, stdout io.Writer) int {
+	if len(args) != 1 || args[0] != "profiles" {
+		return exitUsage
+	}
+	var ids []string
+	if store, err := srv.readStore(context.Background()); err == nil {
+		for _, p := range store.Profiles {
+			ids = append(ids, p.ID)
+		}
+	} else if client, ok := findRunningLauncher(); ok {
+		var resp struct {
+			Profiles []ProfileRequest `json:"profiles"`
+		}
+		if err := client.do(http.MethodGet, "/api/profiles", &resp); err == nil {
+			for _, p := range resp.Profiles {
+				ids = append(ids, p.ID)
+			}
+		}
+	}
+	for _, id := range ids {
+		fmt.Fprintln(stdout, id)
+	}
+	return 0
+}
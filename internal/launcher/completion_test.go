@@ -0,0 +1,45 @@
+package launcher
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"launcher/internal/config"
+)
+
+func TestRunCLI_Completion(t *testing.T) {
+	cfg := config.Load("dev")
+	cfg.DataDir = t.TempDir()
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		var out, errOut bytes.Buffer
+		handled, code := RunCLI(cfg, []string{"completion", shell}, &out, &errOut)
+		if !handled || code != 0 {
+			t.Fatalf("%s: expected success, got %d: %s", shell, code, errOut.String())
+		}
+		script := out.String()
+		if !strings.Contains(script, "__complete profiles") || !strings.Contains(script, "purge") || strings.Contains(script, "%!") {
+			t.Fatalf("%s: unexpected script:\n%s", shell, script)
+		}
+	}
+	if _, code := RunCLI(cfg, []string{"completion", "tcsh"}, &bytes.Buffer{}, &bytes.Buffer{}); code != 2 {
+		t.Fatalf("expected unsupported shell to fail with 2, got %d", code)
+	}
+}
+
+func TestRunCLI_CompleteProfiles(t *testing.T) {
+	cfg := config.Load("dev")
+	cfg.DataDir = t.TempDir()
+	store := ProfileStore{Profiles: []ProfileRequest{{ID: "alpha"}, {ID: "beta", DeletedAt: "2026-01-01T00:00:00Z"}}}
+	if err := writeProfileStoreAtomic(filepath.Join(cfg.DataDir, "profiles.json"), store); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, code := RunCLI(cfg, []string{"__complete", "profiles"}, &out, &bytes.Buffer{}); code != 0 {
+		t.Fatalf("expected success, got %d", code)
+	}
+	if out.String() != "alpha\nbeta\n" {
+		t.Fatalf("unexpected candidates %q", out.String())
+	}
+}
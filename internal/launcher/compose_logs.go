@@ -0,0 +1,152 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// profileLogServices are the compose services whose logs can be asked for
+// by name.
+var profileLogServices = []string{appServiceName, "postgres", "redis", "minio"}
+
+var errNoComposeProject = errors.New("profile has no compose project yet; enable it to see its logs")
+
+// streamComposeLogs writes `docker compose logs` of a profile's project to
+// w until the output ends or, with follow, until ctx is done. service ""
+// means every service. Tests replace it.
+var streamComposeLogs = func(ctx context.Context, id, service string, tail int, follow bool, w io.Writer) error {
+	composeDir := profileComposeDir(id)
+	if _, err := os.Stat(filepath.Join(composeDir, "compose.yaml")); err != nil {
+		if os.IsNotExist(err) {
+			return errNoComposeProject
+		}
+		return err
+	}
+	args := []string{"-p", dockerProjectName(id), "-f", "compose.yaml", "logs", "--no-color", "--tail", strconv.Itoa(tail)}
+	if follow {
+		args = append(args, "--follow")
+	}
+	if service != "" {
+		args = append(args, service)
+	}
+	cmd, err := composeCommandWithContext(ctx, args...)
+	if err != nil {
+		return err
+	}
+	cmd.Dir = composeDir
+	cmd.Stdout = w
+	cmd.Stderr = w
+	if err := cmd.Run(); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+// flushWriter sends every write to the client at once, so followed logs
+// show up as they are printed.
+type flushWriter struct {
+	w io.Writer
+	f http.Flusher
+}
+
+func (fw flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	fw.f.Flush()
+	return n, err
+}
+
+// handleProfileLogsFollow streams the logs of a profile's project until the
+// client goes away.
+func (s *Server) handleProfileLogsFollow(w http.ResponseWriter, r *http.Request, id, service string, tail int) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	if _, err := s.Profiles().Get(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), httpStatusForError(err))
+		return
+	}
+	if _, err := os.Stat(filepath.Join(profileComposeDir(id), "compose.yaml")); err != nil {
+		http.Error(w, "No containers found for "+id+"; enable the profile to see its logs", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	disableWriteDeadline(w)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	if err := streamComposeLogs(r.Context(), id, service, tail, true, flushWriter{w: w, f: flusher}); err != nil {
+		fmt.Fprintf(w, "==> logs stopped: %v\n", err)
+		flusher.Flush()
+	}
+}
+
+// runProfileLogs prints the logs of a profile's project, following them
+// with --follow until interrupted.
+func runProfileLogs(srv *Server, profileID string, args []string, stdout, stderr io.Writer) int {
+	service, tail, follow := "", defaultProfileLogLines, false
+	for i := 0; i < len(args); i++ {
+		switch arg := strings.TrimSpace(args[i]); {
+		case arg == "--follow" || arg == "-f":
+			follow = true
+		case arg == "--tail" && i+1 < len(args):
+			i++
+			n, err := strconv.Atoi(strings.TrimSpace(args[i]))
+			if err != nil || n < 1 || n > maxProfileLogLines {
+				fmt.Fprintf(stderr, "--tail must be between 1 and %d\n", maxProfileLogLines)
+				return exitValidation
+			}
+			tail = n
+		case service == "" && !strings.HasPrefix(arg, "-"):
+			service = arg
+		default:
+			writeProfileCLIUsage(stderr)
+			return exitUsage
+		}
+	}
+	if service != "" && !slices.Contains(profileLogServices, service) {
+		fmt.Fprintf(stderr, "Unknown service: %s (one of %s)\n", service, strings.Join(profileLogServices, ", "))
+		return exitValidation
+	}
+	// A launcher on another machine runs the compose command itself.
+	if activeCLIContext.URL != "" {
+		client, _ := findRunningLauncher()
+		q := url.Values{"tail": {strconv.Itoa(tail)}}
+		if service != "" {
+			q.Set("service", service)
+		}
+		if follow {
+			q.Set("follow", "true")
+		}
+		if err := client.stream("/api/profiles/"+url.PathEscape(profileID)+"/logs?"+q.Encode(), stdout); err != nil {
+			return cliFail(stderr, "Failed to read logs", err)
+		}
+		return 0
+	}
+	if _, err := srv.Profiles().Get(context.Background(), profileID); err != nil {
+		if errors.Is(err, ErrProfileNotFound) {
+			fmt.Fprintf(stderr, "Profile not found: %s\n", profileID)
+			return exitNotFound
+		}
+		return cliFail(stderr, "Failed to load profiles", err)
+	}
+	if err := streamComposeLogs(context.Background(), profileID, service, tail, follow, stdout); err != nil {
+		if errors.Is(err, errNoComposeProject) {
+			fmt.Fprintf(stderr, "No containers found for %s; enable the profile to see its logs\n", profileID)
+			return exitNotFound
+		}
+		return cliFail(stderr, "Failed to read logs", err)
+	}
+	return 0
+}
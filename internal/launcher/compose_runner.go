@@ -0,0 +1,34 @@
+package launcher
+
+import "context"
+
+// composeRunner starts and stops the compose stack of a profile. The
+// launcher runs it with Docker; `launcher selftest` swaps in fakeCompose to
+// check the job lifecycle on machines without Docker.
+type composeRunner interface {
+	up(ctx context.Context, profile ProfileRequest, action string, onProgress composeProgressFn) error
+	down(ctx context.Context, id string, removeVolumes bool) error
+	restart(ctx context.Context, id string) error
+}
+
+type dockerCompose struct {
+	srv *Server
+}
+
+func (d dockerCompose) up(ctx context.Context, profile ProfileRequest, action string, onProgress composeProgressFn) error {
+	return d.srv.runProfileComposeUp(ctx, profile, action, onProgress)
+}
+
+func (d dockerCompose) down(ctx context.Context, id string, removeVolumes bool) error {
+	if err := runProfileComposeDown(ctx, id, removeVolumes); err != nil {
+		return err
+	}
+	if removeVolumes {
+		return d.srv.removeProfileData(ctx, id)
+	}
+	return nil
+}
+
+func (d dockerCompose) restart(ctx context.Context, id string) error {
+	return runProfileComposeRestart(ctx, id)
+}
@@ -0,0 +1,62 @@
+package launcher
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"launcher/internal/config"
+)
+
+// configWarnings lists every setting that was not used as given: values
+// config.Load replaced or clamped, and settings only the launcher can
+// validate.
+func configWarnings() []config.Problem {
+	problems := append([]config.Problem{}, appCfg.Problems...)
+	if _, err := parseMaintenanceWindow(appCfg.MaintenanceWindow); err != nil {
+		// maintenanceAllows fails closed, so automatic operations stay paused.
+		problems = append(problems, config.Problem{
+			Key:     "KIMMIO_MAINTENANCE_WINDOW",
+			Value:   appCfg.MaintenanceWindow,
+			Message: err.Error() + "; automatic operations stay paused",
+		})
+	}
+	return problems
+}
+
+func logConfigWarnings() {
+	for _, p := range configWarnings() {
+		logWarn("config_invalid", map[string]any{"key": p.Key, "value": p.Value, "error": p.Message})
+	}
+}
+
+// runConfigCLI prints the effective configuration and every problem found
+// while loading it; problems make it exit with exitValidation.
+func runConfigCLI(args []string, stdout, stderr io.Writer) int {
+	if len(args) != 1 || args[0] != "check" {
+		fmt.Fprintln(stderr, "Usage: config check")
+		return exitUsage
+	}
+	settings := sanitizedConfig()
+	settings["dataDir"] = appCfg.DataDir
+	settings["cliConfigPath"] = appCfg.CLIConfigPath
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(stdout, "%s: %v\n", key, settings[key])
+	}
+
+	problems := configWarnings()
+	if len(problems) == 0 {
+		fmt.Fprintln(stdout, "\nConfiguration OK.")
+		return 0
+	}
+	fmt.Fprintf(stderr, "\n%d invalid setting(s):\n", len(problems))
+	for _, p := range problems {
+		fmt.Fprintf(stderr, "  %s=%q: %s\n", p.Key, p.Value, p.Message)
+	}
+	return exitValidation
+}
@@ -0,0 +1,55 @@
+package launcher
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"launcher/internal/config"
+)
+
+func TestConfigLoadReportsInvalidValues(t *testing.T) {
+	t.Setenv("KIMMIO_ACTION_TIMEOUT", "soon")
+	t.Setenv("KIMMIO_MAX_PROFILES", "0")
+	t.Setenv("KIMMIO_RESERVED_PORTS", "5432,abc")
+	t.Setenv("KIMMIO_AVOID_DEV_PORTS", "maybe")
+	cfg := config.Load("dev")
+
+	keys := map[string]bool{}
+	for _, p := range cfg.Problems {
+		keys[p.Key] = true
+	}
+	for _, key := range []string{"KIMMIO_ACTION_TIMEOUT", "KIMMIO_MAX_PROFILES", "KIMMIO_RESERVED_PORTS", "KIMMIO_AVOID_DEV_PORTS"} {
+		if !keys[key] {
+			t.Fatalf("expected a problem for %s, got %+v", key, cfg.Problems)
+		}
+	}
+	if cfg.MaxProfiles != 1 || len(cfg.ReservedPorts) != 1 || !cfg.AvoidDevPorts {
+		t.Fatalf("expected invalid values to fall back, got %+v", cfg)
+	}
+}
+
+func TestRunCLI_ConfigCheck(t *testing.T) {
+	cfg := config.Load("dev")
+	cfg.DataDir = t.TempDir()
+	var out, errOut bytes.Buffer
+	if _, code := RunCLI(cfg, []string{"config", "check"}, &out, &errOut); code != exitOK {
+		t.Fatalf("expected a clean config to pass, got %d (%s)", code, errOut.String())
+	}
+	if !strings.Contains(out.String(), "maxProfiles: ") || !strings.Contains(out.String(), "dataDir: "+cfg.DataDir) {
+		t.Fatalf("expected the effective config, got %q", out.String())
+	}
+
+	t.Setenv("KIMMIO_ENABLE_TIMEOUT", "1x")
+	cfg = config.Load("dev")
+	cfg.DataDir = t.TempDir()
+	cfg.MaintenanceWindow = "someday"
+	out.Reset()
+	errOut.Reset()
+	if _, code := RunCLI(cfg, []string{"config", "check"}, &out, &errOut); code != exitValidation {
+		t.Fatalf("expected exit %d, got %d", exitValidation, code)
+	}
+	if !strings.Contains(errOut.String(), "KIMMIO_ENABLE_TIMEOUT") || !strings.Contains(errOut.String(), "KIMMIO_MAINTENANCE_WINDOW") {
+		t.Fatalf("expected both problems to be listed, got %q", errOut.String())
+	}
+}
@@ -0,0 +1,128 @@
+package launcher
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const confirmTokenTTL = 2 * time.Minute
+
+var ErrConfirmationRequired = errors.New(`confirmation required: send {"confirm": "<profile-id>"} or a token from POST /api/profiles/<id>/confirm-token`)
+
+// isDestructiveAction reports whether action can lose data or break
+// existing sessions, and therefore needs an explicit confirmation from
+// whoever asks for it.
+func isDestructiveAction(action string) bool {
+	switch action {
+	case "delete", "purge", "recreate", "regenerate-secrets", "restore-backup":
+		return true
+	default:
+		return false
+	}
+}
+
+type confirmGrant struct {
+	target  string
+	action  string
+	expires time.Time
+}
+
+// confirmTokens holds short-lived, single-use confirmation tokens, each
+// bound to one target and action.
+type confirmTokens struct {
+	mu     sync.Mutex
+	grants map[string]confirmGrant
+}
+
+func newConfirmTokens() *confirmTokens {
+	return &confirmTokens{grants: map[string]confirmGrant{}}
+}
+
+func (c *confirmTokens) issue(target, action string, now time.Time) (string, time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pruneLocked(now)
+	token := randomToken(32)
+	expires := now.Add(confirmTokenTTL)
+	c.grants[token] = confirmGrant{target: target, action: action, expires: expires}
+	return token, expires
+}
+
+// consume reports whether token confirms action on target; a matching token
+// is spent.
+func (c *confirmTokens) consume(token, target, action string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pruneLocked(now)
+	grant, ok := c.grants[token]
+	if !ok || grant.target != target || grant.action != action {
+		return false
+	}
+	delete(c.grants, token)
+	return true
+}
+
+func (c *confirmTokens) pruneLocked(now time.Time) {
+	for token, grant := range c.grants {
+		if !now.Before(grant.expires) {
+			delete(c.grants, token)
+		}
+	}
+}
+
+// checkConfirmation accepts {"confirm": target} or {"confirmToken": "..."}
+// in the JSON body, or the token in the X-Confirm-Token header.
+func (s *Server) checkConfirmation(r *http.Request, target, action string) error {
+	var body struct {
+		Confirm      string `json:"confirm"`
+		ConfirmToken string `json:"confirmToken"`
+	}
+	if r.Body != nil {
+		if err := json.NewDecoder(io.LimitReader(r.Body, 4096)).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			return ValidationError{Msg: "invalid JSON body"}
+		}
+	}
+	if strings.TrimSpace(body.Confirm) == target {
+		return nil
+	}
+	token := strings.TrimSpace(body.ConfirmToken)
+	if token == "" {
+		token = strings.TrimSpace(r.Header.Get("X-Confirm-Token"))
+	}
+	if token != "" && s.confirmations.consume(token, target, action, time.Now()) {
+		return nil
+	}
+	return ErrConfirmationRequired
+}
+
+func (s *Server) handleConfirmToken(w http.ResponseWriter, r *http.Request, id string) {
+	var body struct {
+		Action string `json:"action"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", bodyErrorStatus(err))
+		return
+	}
+	action := strings.ToLower(strings.TrimSpace(body.Action))
+	if !isDestructiveAction(action) {
+		http.Error(w, "action does not need confirmation", http.StatusBadRequest)
+		return
+	}
+	if _, _, err := s.getProfileForAction(r.Context(), id); err != nil {
+		err = normalizeNotFound(err)
+		http.Error(w, err.Error(), httpStatusForError(err))
+		return
+	}
+	token, expires := s.confirmations.issue(id, action, time.Now())
+	writeJSON(w, http.StatusOK, map[string]any{
+		"ok":        true,
+		"token":     token,
+		"action":    action,
+		"expiresAt": expires.UTC().Format(time.RFC3339),
+	})
+}
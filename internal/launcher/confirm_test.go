@@ -0,0 +1,58 @@
+package launcher
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDestructiveActionRequiresConfirmation(t *testing.T) {
+	srv := newServiceTestServer(t)
+	// A busy profile makes an accepted request stop at 409 instead of
+	// running docker.
+	srv.activeProfiles["alpha"] = "job-1"
+
+	send := func(method, path, body string, header map[string]string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		srv.handleProfileAction(rec, req)
+		return rec.Code
+	}
+
+	if code := send(http.MethodDelete, "/api/profiles/alpha", "", nil); code != http.StatusPreconditionRequired {
+		t.Fatalf("expected 428 without confirmation, got %d", code)
+	}
+	if code := send(http.MethodPost, "/api/profiles/alpha/recreate", `{"confirm":"beta"}`, nil); code != http.StatusPreconditionRequired {
+		t.Fatalf("expected 428 for the wrong profile id, got %d", code)
+	}
+	if code := send(http.MethodPost, "/api/profiles/alpha/recreate", `{"confirm":"alpha"}`, nil); code != http.StatusConflict {
+		t.Fatalf("expected confirmed request to reach the job queue, got %d", code)
+	}
+	if code := send(http.MethodPost, "/api/profiles/alpha/stop", "", nil); code != http.StatusConflict {
+		t.Fatalf("stop must not need confirmation, got %d", code)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.handleProfileAction(rec, httptest.NewRequest(http.MethodPost, "/api/profiles/alpha/confirm-token", strings.NewReader(`{"action":"regenerate-secrets"}`)))
+	var issued struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &issued); err != nil || issued.Token == "" {
+		t.Fatalf("expected a token, got %d: %s", rec.Code, rec.Body.String())
+	}
+	header := map[string]string{"X-Confirm-Token": issued.Token}
+	if code := send(http.MethodDelete, "/api/profiles/alpha", "", header); code != http.StatusPreconditionRequired {
+		t.Fatalf("token must be bound to its action, got %d", code)
+	}
+	if code := send(http.MethodPost, "/api/profiles/alpha/regenerate-secrets", "", header); code != http.StatusConflict {
+		t.Fatalf("expected token to confirm the action, got %d", code)
+	}
+	if code := send(http.MethodPost, "/api/profiles/alpha/regenerate-secrets", "", header); code != http.StatusPreconditionRequired {
+		t.Fatalf("expected token to be single use, got %d", code)
+	}
+}
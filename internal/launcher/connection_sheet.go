@@ -0,0 +1,170 @@
+package launcher
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// A new profile's connection sheet lists where to reach it and the
+// credentials the launcher generated for it. The create API returns it in
+// full, and it can be downloaded in full once more; after that the sheet
+// only shows secrets masked. A marker next to the profile's secrets file
+// tracks the pending download, so it survives restarts and removing the
+// marker decides which request gets the secrets.
+
+const maskedSecret = "********"
+
+// connectionSheetKeys are the compose env values the sheet lists, in order.
+// The app reads the encryption key as ENC_KEY_V1; the launcher and the
+// schema call it ENC_KEY_V0.
+var connectionSheetKeys = []struct{ Name, Env, Label string }{
+	{"POSTGRES_HOST", "POSTGRES_HOST", "Database host"},
+	{"POSTGRES_PORT", "POSTGRES_PORT", "Database port"},
+	{"POSTGRES_DB", "POSTGRES_DB", "Database name"},
+	{"POSTGRES_USER", "POSTGRES_USER", "Database user"},
+	{"POSTGRES_PASSWORD", "POSTGRES_PASSWORD", "Database password"},
+	{"REDIS_PASSWORD", "REDIS_PASSWORD", "Redis password"},
+	{"MINIO_ROOT_USER", "MINIO_ROOT_USER", "Object storage user"},
+	{"MINIO_ROOT_PASSWORD", "MINIO_ROOT_PASSWORD", "Object storage password"},
+	{"JWT_SECRET", "JWT_SECRET", "Session signing secret"},
+	{"ENC_KEY_V0", "ENC_KEY_V1", "Encryption key"},
+}
+
+// ConnectionSheet is the hand-over document for a new profile.
+type ConnectionSheet struct {
+	ProfileID   string            `json:"profileId"`
+	AppURL      string            `json:"appUrl"`
+	Credentials []SheetCredential `json:"credentials"`
+	Setup       []string          `json:"setup"`
+	// Masked is set when secret values were replaced by maskedSecret.
+	Masked      bool   `json:"masked"`
+	GeneratedAt string `json:"generatedAt"`
+}
+
+type SheetCredential struct {
+	Name   string `json:"name"`
+	Label  string `json:"label"`
+	Value  string `json:"value"`
+	Secret bool   `json:"secret,omitempty"`
+}
+
+func connectionSheetMarkerPath(profileID string) string {
+	return filepath.Join(appCfg.DataDir, "secrets", profileID+".sheet")
+}
+
+func markConnectionSheetPending(profileID string) error {
+	return os.WriteFile(connectionSheetMarkerPath(profileID), nil, 0o600)
+}
+
+func connectionSheetPending(profileID string) bool {
+	_, err := os.Stat(connectionSheetMarkerPath(profileID))
+	return err == nil
+}
+
+// pendingConnectionSheets lists the profiles whose sheet can still be
+// downloaded in full, for admins only.
+func pendingConnectionSheets(r *http.Request, profiles []ProfileRequest) []string {
+	if acc, ok := requestAccount(r); ok && acc.Role != roleAdmin {
+		return nil
+	}
+	out := []string{}
+	for _, p := range profiles {
+		if connectionSheetPending(p.ID) {
+			out = append(out, p.ID)
+		}
+	}
+	return out
+}
+
+// takeConnectionSheet consumes the pending download and reports whether
+// this caller got it.
+func takeConnectionSheet(profileID string) bool {
+	return os.Remove(connectionSheetMarkerPath(profileID)) == nil
+}
+
+// buildConnectionSheet reads the values the profile's containers get, so
+// defaults and generated secrets show exactly as the app sees them.
+func buildConnectionSheet(profile ProfileRequest, reveal bool) ConnectionSheet {
+	env := map[string]string{}
+	for _, line := range strings.Split(buildComposeEnv(profile), "\n") {
+		if k, v, ok := strings.Cut(line, "="); ok {
+			env[k] = v
+		}
+	}
+	sheet := ConnectionSheet{
+		ProfileID:   profile.ID,
+		AppURL:      profileBaseURL(profile),
+		Credentials: []SheetCredential{},
+		Masked:      !reveal,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Setup: []string{
+			"Enable the profile from the launcher to start it.",
+			"Open " + profileBaseURL(profile) + " to finish setting up the app.",
+			"Store the secrets below somewhere safe; the launcher shows them masked from now on.",
+		},
+	}
+	for _, key := range connectionSheetKeys {
+		cred := SheetCredential{Name: key.Name, Label: key.Label, Value: env[key.Env], Secret: isSecretEnvKey(key.Name)}
+		if cred.Secret && !reveal {
+			cred.Value = maskedSecret
+		}
+		sheet.Credentials = append(sheet.Credentials, cred)
+	}
+	return sheet
+}
+
+// Text renders the sheet for the clipboard or a download.
+func (c ConnectionSheet) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Kimmio profile %s\n", c.ProfileID)
+	fmt.Fprintf(&b, "App URL: %s\n\n", c.AppURL)
+	for _, cred := range c.Credentials {
+		fmt.Fprintf(&b, "%s (%s): %s\n", cred.Label, cred.Name, cred.Value)
+	}
+	b.WriteString("\n")
+	for _, step := range c.Setup {
+		fmt.Fprintf(&b, "- %s\n", step)
+	}
+	if c.Masked {
+		b.WriteString("\nSecrets are masked; the sheet shows them only once.\n")
+	}
+	fmt.Fprintf(&b, "\nGenerated %s\n", c.GeneratedAt)
+	return b.String()
+}
+
+// handleConnectionSheet serves the sheet. GET always masks the secrets;
+// the first POST by an admin shows them and uses up the download, so only
+// a request that passed the CSRF and Origin checks can take it. Viewers
+// always get it masked.
+func (s *Server) handleConnectionSheet(w http.ResponseWriter, r *http.Request, id string) {
+	store, err := s.readStore(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load profiles: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	idx := findProfileIndex(store, id)
+	if idx < 0 {
+		http.Error(w, ErrProfileNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	reveal := false
+	if acc, ok := requestAccount(r); r.Method == http.MethodPost && (!ok || acc.Role == roleAdmin) {
+		reveal = takeConnectionSheet(id)
+	}
+	if reveal {
+		auditLog("INFO", "connection_sheet_downloaded", map[string]any{"profile": id, "remote": clientIP(r)})
+	}
+	sheet := buildConnectionSheet(store.Profiles[idx], reveal)
+	w.Header().Set("Cache-Control", "no-store")
+	if acceptsJSON(r) {
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "sheet": sheet, "text": sheet.Text()})
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+id+`-connection-sheet.txt"`)
+	_, _ = w.Write([]byte(sheet.Text()))
+}
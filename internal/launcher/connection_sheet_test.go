@@ -0,0 +1,61 @@
+package launcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConnectionSheetShowsSecretsOnce(t *testing.T) {
+	srv := newServiceTestServer(t)
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("failed to pick free port: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	_ = ln.Close()
+
+	body := fmt.Sprintf(`{"id":"beta","version":"latest","ports":[{"container":3000,"host":%d}],"env":{"JWT_SECRET":"jwt-secret-for-the-sheet-test-0123456789"}}`, port)
+	req := httptest.NewRequest(http.MethodPost, "/api/profiles", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	srv.handleCreateProfile(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		ConnectionSheet    ConnectionSheet `json:"connectionSheet"`
+		ConnectionSheetURL string          `json:"connectionSheetUrl"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if created.ConnectionSheet.Masked || !strings.Contains(created.ConnectionSheet.Text(), "jwt-secret-for-the-sheet-test-0123456789") {
+		t.Fatalf("expected the create response to carry the secrets, got %+v", created.ConnectionSheet)
+	}
+
+	download := func(method string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.handleProfileAction(rec, httptest.NewRequest(method, created.ConnectionSheetURL, nil))
+		return rec
+	}
+	// A GET, which skips the CSRF checks, never shows or uses up the secrets.
+	if peek := download(http.MethodGet); peek.Code != http.StatusOK || strings.Contains(peek.Body.String(), "jwt-secret-for-the-sheet-test-0123456789") {
+		t.Fatalf("expected a GET to be masked, got %d %s", peek.Code, peek.Body.String())
+	}
+	first := download(http.MethodPost)
+	if first.Code != http.StatusOK || !strings.Contains(first.Header().Get("Content-Disposition"), "beta-connection-sheet.txt") || !strings.Contains(first.Body.String(), "jwt-secret-for-the-sheet-test-0123456789") {
+		t.Fatalf("expected the full sheet once, got %d %s", first.Code, first.Body.String())
+	}
+	second := download(http.MethodPost)
+	if strings.Contains(second.Body.String(), "jwt-secret-for-the-sheet-test-0123456789") || !strings.Contains(second.Body.String(), "Session signing secret (JWT_SECRET): "+maskedSecret) {
+		t.Fatalf("expected secrets masked after the download, got %s", second.Body.String())
+	}
+	if !strings.Contains(second.Body.String(), "Database user (POSTGRES_USER): postgres") {
+		t.Fatalf("expected non-secret values to stay visible, got %s", second.Body.String())
+	}
+}
@@ -0,0 +1,56 @@
+package launcher
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+)
+
+// countPortConnections reports how many TCP connections are open to a host
+// port. The second result is false when the platform's tables could not be
+// read. It is swapped out in tests.
+var countPortConnections = platformEstablishedConnections
+
+// countProcNetEstablished counts sockets in ESTABLISHED state (01) whose
+// local port is port in a /proc/net/tcp style table.
+func countProcNetEstablished(table string, port int) int {
+	n := 0
+	sc := bufio.NewScanner(strings.NewReader(table))
+	sc.Scan() // header
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 4 || fields[3] != "01" {
+			continue
+		}
+		_, hexPort, ok := strings.Cut(fields[1], ":")
+		if !ok {
+			continue
+		}
+		if p, err := strconv.ParseInt(hexPort, 16, 32); err == nil && int(p) == port {
+			n++
+		}
+	}
+	return n
+}
+
+// countNetstatEstablished counts ESTABLISHED lines of netstat output whose
+// local address, the field at localField, ends in sep and the port:
+// "TCP 127.0.0.1:8080 ..." on Windows, "tcp4 0 0 127.0.0.1.8080 ..." on
+// macOS and the BSDs.
+func countNetstatEstablished(out string, port, localField int, sep string) int {
+	suffix := sep + strconv.Itoa(port)
+	n := 0
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) <= localField || !strings.HasSuffix(fields[localField], suffix) {
+			continue
+		}
+		for _, f := range fields[localField+1:] {
+			if f == "ESTABLISHED" {
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
@@ -0,0 +1,16 @@
+//go:build !linux && !windows
+
+package launcher
+
+import (
+	"context"
+	"os/exec"
+)
+
+func platformEstablishedConnections(ctx context.Context, port int) (int, bool) {
+	out, err := exec.CommandContext(ctx, "netstat", "-an", "-p", "tcp").Output()
+	if err != nil {
+		return 0, false
+	}
+	return countNetstatEstablished(string(out), port, 3, "."), true
+}
@@ -0,0 +1,140 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Profiles run on Docker, or on Podman when Docker is not installed.
+// Podman's CLI takes the same commands as docker's, so the launcher runs
+// the same commands against whichever binary it found; only compose
+// differs, since Podman may ship it as podman-compose rather than as a
+// "podman compose" subcommand.
+
+const (
+	runtimeDocker = "docker"
+	runtimePodman = "podman"
+)
+
+var errNoContainerRuntime = errors.New("docker binary not found (Podman was not found either)")
+
+// containerRuntime is the engine profiles run on.
+type containerRuntime struct {
+	Name string
+	Bin  string
+	// ComposeBin runs compose on its own; empty means "<Bin> compose".
+	ComposeBin string
+}
+
+var (
+	runtimeOnce     sync.Once
+	detectedRuntime containerRuntime
+	runtimeErr      error
+)
+
+// activeContainerRuntime detects the runtime once per launcher run.
+func activeContainerRuntime() (containerRuntime, error) {
+	runtimeOnce.Do(func() {
+		detectedRuntime, runtimeErr = detectContainerRuntime(findDockerBinary, exec.LookPath)
+	})
+	return detectedRuntime, runtimeErr
+}
+
+func detectContainerRuntime(findDocker func() (string, error), lookPath func(string) (string, error)) (containerRuntime, error) {
+	if bin, err := findDocker(); err == nil {
+		return containerRuntime{Name: runtimeDocker, Bin: bin}, nil
+	}
+	bin, err := lookPath("podman")
+	if err != nil {
+		return containerRuntime{}, errNoContainerRuntime
+	}
+	rt := containerRuntime{Name: runtimePodman, Bin: bin}
+	if composeBin, err := lookPath("podman-compose"); err == nil {
+		rt.ComposeBin = composeBin
+	}
+	return rt, nil
+}
+
+func findDockerBinary() (string, error) {
+	if p, err := exec.LookPath("docker"); err == nil {
+		return p, nil
+	}
+	candidates := []string{
+		"/usr/local/bin/docker",
+		"/opt/homebrew/bin/docker",
+		"/Applications/Docker.app/Contents/Resources/bin/docker",
+		"/usr/bin/docker",
+		"/snap/bin/docker",
+		`C:\Program Files\Docker\Docker\resources\bin\docker.exe`,
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	return "", errors.New("docker binary not found")
+}
+
+// composeCommandWithContext runs compose with args, the arguments that
+// follow "docker compose".
+func composeCommandWithContext(ctx context.Context, args ...string) (*exec.Cmd, error) {
+	rt, err := activeContainerRuntime()
+	if err != nil {
+		return nil, err
+	}
+	if rt.ComposeBin != "" {
+		return dockerCommandWithContext(ctx, rt.ComposeBin, args...), nil
+	}
+	return dockerCommandWithContext(ctx, rt.Bin, append([]string{"compose"}, args...)...), nil
+}
+
+// containerRuntimeName is the name of the active runtime, or "" when there
+// is none.
+func containerRuntimeName() string {
+	rt, err := activeContainerRuntime()
+	if err != nil {
+		return ""
+	}
+	return rt.Name
+}
+
+// podmanSocketHost is the address of Podman's Docker-compatible API
+// socket, or "" when the Podman service is not running, in which case
+// the launcher uses the podman command for everything.
+func podmanSocketHost() string {
+	candidates := []string{}
+	if xdgRuntime := strings.TrimSpace(os.Getenv("XDG_RUNTIME_DIR")); xdgRuntime != "" {
+		candidates = append(candidates, filepath.Join(xdgRuntime, "podman", "podman.sock"))
+	}
+	candidates = append(candidates, "/run/podman/podman.sock")
+	for _, sock := range candidates {
+		if info, err := os.Stat(sock); err == nil && !info.IsDir() {
+			return "unix://" + sock
+		}
+	}
+	return ""
+}
+
+func (s *Server) setProfileContainerRuntime(ctx context.Context, id, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	store, err := s.loadStoreLocked(ctx)
+	if err != nil {
+		return err
+	}
+	idx := findProfileIndex(store, id)
+	if idx < 0 {
+		return ErrProfileNotFound
+	}
+	if store.Profiles[idx].ContainerRuntime == name {
+		return nil
+	}
+	store.Profiles[idx].ContainerRuntime = name
+	store.Profiles[idx].Revision++
+	return s.writeStoreLocked(store)
+}
@@ -0,0 +1,51 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDetectContainerRuntime(t *testing.T) {
+	noDocker := func() (string, error) { return "", errors.New("docker binary not found") }
+	paths := func(found map[string]string) func(string) (string, error) {
+		return func(name string) (string, error) {
+			if p, ok := found[name]; ok {
+				return p, nil
+			}
+			return "", errors.New("not found")
+		}
+	}
+
+	rt, err := detectContainerRuntime(func() (string, error) { return "/usr/bin/docker", nil }, paths(map[string]string{"podman": "/usr/bin/podman"}))
+	if err != nil || rt.Name != runtimeDocker || rt.Bin != "/usr/bin/docker" {
+		t.Fatalf("expected Docker to win when installed, got %+v, %v", rt, err)
+	}
+	rt, err = detectContainerRuntime(noDocker, paths(map[string]string{"podman": "/usr/bin/podman"}))
+	if err != nil || rt.Name != runtimePodman || rt.ComposeBin != "" {
+		t.Fatalf("expected podman with its compose subcommand, got %+v, %v", rt, err)
+	}
+	rt, err = detectContainerRuntime(noDocker, paths(map[string]string{"podman": "/usr/bin/podman", "podman-compose": "/usr/bin/podman-compose"}))
+	if err != nil || rt.ComposeBin != "/usr/bin/podman-compose" {
+		t.Fatalf("expected podman-compose to run compose, got %+v, %v", rt, err)
+	}
+	if _, err := detectContainerRuntime(noDocker, paths(nil)); !isDockerUnavailableError(err.Error()) {
+		t.Fatalf("expected a missing runtime to read as Docker unavailable, got %v", err)
+	}
+}
+
+func TestSetProfileContainerRuntime(t *testing.T) {
+	srv := newServiceTestServer(t)
+	ctx := context.Background()
+	before, _ := srv.Profiles().Get(ctx, "alpha")
+	if err := srv.setProfileContainerRuntime(ctx, "alpha", runtimePodman); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.setProfileContainerRuntime(ctx, "alpha", runtimePodman); err != nil {
+		t.Fatal(err)
+	}
+	after, _ := srv.Profiles().Get(ctx, "alpha")
+	if after.ContainerRuntime != runtimePodman || after.Revision != before.Revision+1 {
+		t.Fatalf("expected one recorded change, got %q at revision %d (was %d)", after.ContainerRuntime, after.Revision, before.Revision)
+	}
+}
@@ -0,0 +1,190 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	runtimeCrashLooping = "crash-looping"
+
+	// A service that restarted this often and came back up within
+	// crashLoopRecent of the probe is treated as crash-looping rather than
+	// merely unhealthy.
+	crashLoopRestarts = 3
+	crashLoopRecent   = 2 * time.Minute
+	crashLogLines     = 20
+	inspectTimeout    = 5 * time.Second
+)
+
+// ServiceState is one compose service's container as docker inspect reports
+// it.
+type ServiceState struct {
+	Service      string `json:"service"`
+	ContainerID  string `json:"containerId"`
+	State        string `json:"state"`
+	Health       string `json:"health,omitempty"`
+	RestartCount int    `json:"restartCount"`
+	ExitCode     int    `json:"exitCode"`
+	StartedAt    string `json:"startedAt,omitempty"`
+	FinishedAt   string `json:"finishedAt,omitempty"`
+}
+
+type dockerInspectState struct {
+	ID           string `json:"Id"`
+	RestartCount int    `json:"RestartCount"`
+	State        struct {
+		Status     string `json:"Status"`
+		Restarting bool   `json:"Restarting"`
+		ExitCode   int    `json:"ExitCode"`
+		StartedAt  string `json:"StartedAt"`
+		FinishedAt string `json:"FinishedAt"`
+		Health     *struct {
+			Status string `json:"Status"`
+		} `json:"Health"`
+	} `json:"State"`
+	Config struct {
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+}
+
+// inspectProfileServices reads restart counts and exit codes of every
+// container in the profile's compose project, including stopped ones.
+func inspectProfileServices(parent context.Context, profileID string) ([]ServiceState, error) {
+	ctx, cancel := context.WithTimeout(parent, inspectTimeout)
+	defer cancel()
+	dockerBin, err := dockerBinaryPath()
+	if err != nil {
+		return nil, err
+	}
+	ps, err := composeCommandWithContext(ctx, "-p", dockerProjectName(profileID), "ps", "-a", "-q")
+	if err != nil {
+		return nil, err
+	}
+	out, err := ps.Output()
+	if err != nil {
+		return nil, err
+	}
+	ids := strings.Fields(string(out))
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if engine, err := newDockerEngine(); err == nil {
+		inspected := make([]dockerInspectState, 0, len(ids))
+		for _, id := range ids {
+			var c dockerInspectState
+			if err := engine.inspectContainer(ctx, id, &c); err != nil {
+				return nil, err
+			}
+			inspected = append(inspected, c)
+		}
+		return serviceStates(inspected), nil
+	}
+	raw, err := dockerCommandWithContext(ctx, dockerBin, append([]string{"inspect"}, ids...)...).Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseServiceStates(raw)
+}
+
+func parseServiceStates(raw []byte) ([]ServiceState, error) {
+	var inspected []dockerInspectState
+	if err := json.Unmarshal(raw, &inspected); err != nil {
+		return nil, err
+	}
+	return serviceStates(inspected), nil
+}
+
+func serviceStates(inspected []dockerInspectState) []ServiceState {
+	states := make([]ServiceState, 0, len(inspected))
+	for _, c := range inspected {
+		st := ServiceState{
+			Service:      c.Config.Labels["com.docker.compose.service"],
+			ContainerID:  c.ID,
+			State:        c.State.Status,
+			RestartCount: c.RestartCount,
+			ExitCode:     c.State.ExitCode,
+			StartedAt:    c.State.StartedAt,
+			FinishedAt:   c.State.FinishedAt,
+		}
+		if c.State.Restarting {
+			st.State = "restarting"
+		}
+		if c.State.Health != nil {
+			st.Health = c.State.Health.Status
+		}
+		if len(st.ContainerID) > 12 {
+			st.ContainerID = st.ContainerID[:12]
+		}
+		states = append(states, st)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Service < states[j].Service })
+	return states
+}
+
+// crashLoopingService returns the first service that keeps restarting: it
+// has restarted at least crashLoopRestarts times and is restarting now or
+// started again only moments ago.
+func crashLoopingService(states []ServiceState, now time.Time) (ServiceState, bool) {
+	for _, st := range states {
+		if st.RestartCount < crashLoopRestarts {
+			continue
+		}
+		if st.State == "restarting" || st.State == "exited" {
+			return st, true
+		}
+		if started, err := time.Parse(time.RFC3339Nano, st.StartedAt); err == nil && now.Sub(started) < crashLoopRecent {
+			return st, true
+		}
+	}
+	return ServiceState{}, false
+}
+
+// containerLogTail returns the last lines a container wrote, stdout and
+// stderr interleaved.
+func containerLogTail(parent context.Context, containerID string, lines int) ([]string, error) {
+	if containerID == "" {
+		return nil, errors.New("container id is required")
+	}
+	ctx, cancel := context.WithTimeout(parent, inspectTimeout)
+	defer cancel()
+	dockerBin, err := dockerBinaryPath()
+	if err != nil {
+		return nil, err
+	}
+	out, err := dockerCommandWithContext(ctx, dockerBin, "logs", "--tail", strconv.Itoa(lines), containerID).CombinedOutput()
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimRight(string(out), "\n")
+	if text == "" {
+		return nil, nil
+	}
+	return strings.Split(text, "\n"), nil
+}
+
+// applyServiceStates attaches container details to an enabled profile and
+// upgrades "unhealthy" to crash-looping when a service keeps restarting.
+func applyServiceStates(ctx context.Context, profile *ProfileRequest) {
+	states, err := inspectProfileServices(ctx, profile.ID)
+	if err != nil {
+		return
+	}
+	profile.Services = states
+	if profile.RuntimeStatus != "unhealthy" {
+		return
+	}
+	st, ok := crashLoopingService(states, time.Now())
+	if !ok {
+		return
+	}
+	profile.RuntimeStatus = runtimeCrashLooping
+	if lines, err := containerLogTail(ctx, st.ContainerID, crashLogLines); err == nil {
+		profile.CrashLog = lines
+	}
+}
@@ -0,0 +1,47 @@
+package launcher
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseServiceStates(t *testing.T) {
+	raw := []byte(`[
+		{"Id":"0123456789abcdef","RestartCount":5,"State":{"Status":"running","Restarting":true,"ExitCode":137,"StartedAt":"2026-01-02T03:04:05.123456789Z"},"Config":{"Labels":{"com.docker.compose.service":"kimmio_app"}}},
+		{"Id":"fedcba9876543210","RestartCount":0,"State":{"Status":"running","ExitCode":0,"Health":{"Status":"healthy"}},"Config":{"Labels":{"com.docker.compose.service":"db"}}}
+	]`)
+	states, err := parseServiceStates(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 2 || states[0].Service != "db" || states[0].Health != "healthy" {
+		t.Fatalf("unexpected states: %+v", states)
+	}
+	app := states[1]
+	if app.State != "restarting" || app.RestartCount != 5 || app.ExitCode != 137 || app.ContainerID != "0123456789ab" {
+		t.Fatalf("unexpected app state: %+v", app)
+	}
+}
+
+func TestCrashLoopingService(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 5, 0, 0, time.UTC)
+	recent := now.Add(-30 * time.Second).Format(time.RFC3339Nano)
+	old := now.Add(-time.Hour).Format(time.RFC3339Nano)
+
+	if _, ok := crashLoopingService([]ServiceState{{Service: "a", State: "running", RestartCount: 2, StartedAt: recent}}, now); ok {
+		t.Fatalf("two restarts should not count as a crash loop")
+	}
+	if _, ok := crashLoopingService([]ServiceState{{Service: "a", State: "running", RestartCount: 9, StartedAt: old}}, now); ok {
+		t.Fatalf("a service that has been up for an hour is not crash-looping")
+	}
+	st, ok := crashLoopingService([]ServiceState{
+		{Service: "db", State: "running"},
+		{Service: "kimmio_app", State: "running", RestartCount: 4, StartedAt: recent},
+	}, now)
+	if !ok || st.Service != "kimmio_app" {
+		t.Fatalf("expected kimmio_app to be crash-looping, got %+v ok=%v", st, ok)
+	}
+	if _, ok := crashLoopingService([]ServiceState{{Service: "a", State: "restarting", RestartCount: 3}}, now); !ok {
+		t.Fatalf("expected restarting service to be crash-looping")
+	}
+}
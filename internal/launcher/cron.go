@@ -0,0 +1,269 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedules are cron expressions with five fields (minute hour day-of-month
+// month day-of-week), for example "30 3 * * *" for every night or
+// "0 18 * * mon-fri" for weekday evenings. Fields take *, lists, ranges and
+// steps ("*/15", "1-5", "mon,wed"), months and days also their names; day 0
+// and 7 are Sunday. @hourly, @daily, @weekly, @monthly and @yearly are
+// shorthands. As in cron, when both day fields are restricted either may
+// match. Several expressions are separated by ";". The older "[days ]HH:MM"
+// form, e.g. "sat,sun 04:00", is still accepted.
+
+const maxScheduleLength = 256
+
+var (
+	cronMacros = map[string]string{
+		"@hourly":   "0 * * * *",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@weekly":   "0 0 * * 0",
+		"@monthly":  "0 0 1 * *",
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+	}
+	cronMonthNames = map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}
+)
+
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var cronFields = [5]cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: cronMonthNames},
+	{name: "day of week", min: 0, max: 7},
+}
+
+// cronExpr is one parsed expression; each field is a set of allowed values.
+type cronExpr struct {
+	minute [60]bool
+	hour   [24]bool
+	dom    [32]bool
+	month  [13]bool
+	dow    [7]bool
+	// domAny and dowAny are set when the day field is "*".
+	domAny, dowAny bool
+}
+
+// schedule is every expression of a schedule string.
+type schedule []cronExpr
+
+func parseSchedule(raw string) (schedule, error) {
+	var sched schedule
+	for _, part := range strings.Split(raw, ";") {
+		part = strings.TrimSpace(strings.ToLower(part))
+		if part == "" {
+			continue
+		}
+		var (
+			expr cronExpr
+			err  error
+		)
+		if fields := strings.Fields(part); strings.HasPrefix(part, "@") || len(fields) == 5 {
+			expr, err = parseCronExpr(part)
+		} else {
+			expr, err = parseClockSchedule(fields)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", part, err)
+		}
+		sched = append(sched, expr)
+	}
+	return sched, nil
+}
+
+func normalizeSchedule(v string) (string, error) {
+	v = strings.TrimSpace(v)
+	if len(v) > maxScheduleLength {
+		return "", fmt.Errorf("schedule must be at most %d characters", maxScheduleLength)
+	}
+	if _, err := parseSchedule(v); err != nil {
+		return "", err
+	}
+	return v, nil
+}
+
+func parseCronExpr(raw string) (cronExpr, error) {
+	if strings.HasPrefix(raw, "@") {
+		expanded, ok := cronMacros[raw]
+		if !ok {
+			return cronExpr{}, errors.New("unknown shorthand " + raw)
+		}
+		raw = expanded
+	}
+	fields := strings.Fields(raw)
+	if len(fields) != 5 {
+		return cronExpr{}, errors.New("expected five fields: minute hour day-of-month month day-of-week")
+	}
+	var expr cronExpr
+	sets := [5][]bool{expr.minute[:], expr.hour[:], expr.dom[:], expr.month[:], make([]bool, 8)}
+	for i, f := range cronFields {
+		if err := parseCronField(fields[i], f, sets[i]); err != nil {
+			return cronExpr{}, err
+		}
+	}
+	for d := 0; d < 7; d++ {
+		expr.dow[d] = sets[4][d] || (d == 0 && sets[4][7])
+	}
+	expr.domAny = fields[2] == "*"
+	expr.dowAny = fields[4] == "*"
+	return expr, nil
+}
+
+// parseCronField sets set[v] for every value the field allows.
+func parseCronField(raw string, f cronField, set []bool) error {
+	for _, item := range strings.Split(raw, ",") {
+		rng, stepRaw, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepRaw)
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid step %q in %s", stepRaw, f.name)
+			}
+			step = n
+		}
+		lo, hi := f.min, f.max
+		if f.name == "day of week" {
+			hi = 6
+		}
+		if rng != "*" {
+			from, to, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = cronValue(from, f); err != nil {
+				return err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = cronValue(to, f); err != nil {
+					return err
+				}
+			} else if hasStep {
+				hi = f.max
+			}
+			if hi < lo {
+				return fmt.Errorf("range %q in %s runs backwards", rng, f.name)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return nil
+}
+
+func cronValue(raw string, f cronField) (int, error) {
+	if f.name == "day of week" {
+		for name, day := range weekdayNames {
+			if raw == name {
+				return int(day), nil
+			}
+		}
+	}
+	if v, ok := f.names[raw]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s must be between %d and %d, got %q", f.name, f.min, f.max, raw)
+	}
+	return v, nil
+}
+
+// parseClockSchedule reads the older "[days ]HH:MM" form.
+func parseClockSchedule(fields []string) (cronExpr, error) {
+	var expr cronExpr
+	expr.domAny = true
+	for d := range expr.dom {
+		expr.dom[d] = true
+	}
+	for m := range expr.month {
+		expr.month[m] = true
+	}
+	switch len(fields) {
+	case 1:
+		expr.dowAny = true
+		for d := range expr.dow {
+			expr.dow[d] = true
+		}
+	case 2:
+		if err := parseMaintenanceDays(fields[0], &expr.dow); err != nil {
+			return cronExpr{}, err
+		}
+	default:
+		return cronExpr{}, errors.New(`expected a cron expression or "[days ]HH:MM"`)
+	}
+	minute, err := parseClock(fields[len(fields)-1])
+	if err != nil || minute >= 24*60 {
+		return cronExpr{}, errors.New("time must be HH:MM between 00:00 and 23:59")
+	}
+	expr.hour[minute/60] = true
+	expr.minute[minute%60] = true
+	return expr, nil
+}
+
+func (e cronExpr) dayMatches(t time.Time) bool {
+	dom, dow := e.dom[t.Day()], e.dow[t.Weekday()]
+	switch {
+	case e.domAny && e.dowAny:
+		return true
+	case e.domAny:
+		return dow
+	case e.dowAny:
+		return dom
+	}
+	return dom || dow
+}
+
+// next returns the first matching minute after t, in t's location, or the
+// zero time when there is none within five years (e.g. "0 0 31 2 *").
+func (e cronExpr) next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	for limit := t.AddDate(5, 0, 0); t.Before(limit); {
+		switch {
+		case !e.month[t.Month()]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !e.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case !e.hour[t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case !e.minute[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// next returns the earliest run of any expression after t.
+func (s schedule) next(t time.Time) time.Time {
+	var first time.Time
+	for _, e := range s {
+		if n := e.next(t); !n.IsZero() && (first.IsZero() || n.Before(first)) {
+			first = n
+		}
+	}
+	return first
+}
+
+// dueBetween reports whether a scheduled minute falls in (from, to].
+func (s schedule) dueBetween(from, to time.Time) bool {
+	n := s.next(from)
+	return !n.IsZero() && !n.After(to)
+}
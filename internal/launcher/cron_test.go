@@ -0,0 +1,100 @@
+package launcher
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone data unavailable")
+	}
+	cases := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2026, 3, 4, 10, 7, 0, 0, time.UTC), time.Date(2026, 3, 4, 10, 15, 0, 0, time.UTC)},
+		{"0 18 * * mon-fri", time.Date(2026, 3, 6, 18, 0, 0, 0, time.UTC), time.Date(2026, 3, 9, 18, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan,jul *", time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC), time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: the 15th or any Sunday.
+		{"0 9 15 * 0", time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 8, 9, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC), time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"sat,sun 04:00", time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 7, 4, 0, 0, 0, time.UTC)},
+		// 02:30 does not exist on the spring-forward day in Berlin.
+		{"30 2 * * *", time.Date(2026, 3, 28, 12, 0, 0, 0, berlin), time.Date(2026, 3, 30, 2, 30, 0, 0, berlin)},
+	}
+	for _, tc := range cases {
+		sched, err := parseSchedule(tc.expr)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.expr, err)
+		}
+		if got := sched.next(tc.from); !got.Equal(tc.want) {
+			t.Fatalf("%s: expected %s after %s, got %s", tc.expr, tc.want, tc.from, got)
+		}
+	}
+
+	impossible, _ := parseSchedule("0 0 31 2 *")
+	if !impossible.next(time.Now()).IsZero() {
+		t.Fatal("expected no run for February 31st")
+	}
+	for _, bad := range []string{"60 * * * *", "* * * *", "5-1 * * * *", "*/0 * * * *", "@often", "0 0 * * funday"} {
+		if _, err := normalizeSchedule(bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestSchedulesPersistAcrossRestarts(t *testing.T) {
+	srv := newServiceTestServer(t)
+	if err := writeProfileStoreAtomic(srv.dbPath, ProfileStore{Profiles: []ProfileRequest{
+		{ID: "alpha", Version: "1.0.0", StartSchedule: "0 8 * * *", StopSchedule: "0 19 * * *", TimeZone: "UTC"},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2026, 3, 4, 7, 59, 30, 0, time.UTC)
+	srv.runSchedules(context.Background(), from, from.Add(time.Minute))
+	var jobID string
+	srv.jobMu.Lock()
+	for id, job := range srv.jobs {
+		if job.Action != "enable" {
+			t.Fatalf("expected the stopped profile started, got %+v", job)
+		}
+		jobID = id
+	}
+	srv.jobMu.Unlock()
+	if jobID == "" {
+		t.Fatal("expected a scheduled start job")
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if job, err := srv.Jobs().Get(jobID); err == nil && isTerminalJobStatus(job.Status) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("start job did not finish")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	// A new server over the same data dir sees the last check and run.
+	restarted := newScheduleStore(filepath.Dir(srv.dbPath))
+	state := restarted.load()
+	if state.CheckedAt != "2026-03-04T08:00:30Z" || state.LastRuns["alpha/start"].JobID != jobID {
+		t.Fatalf("expected the scheduler state saved, got %+v", state)
+	}
+	store, err := srv.readStore(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	runs := srv.scheduledRuns(store.Profiles, from)
+	if len(runs) != 2 || runs[0].Kind != "start" || runs[0].NextRun != "2026-03-04T08:00:00Z" || runs[0].LastJobID != jobID {
+		t.Fatalf("unexpected schedules %+v", runs)
+	}
+	if runs[1].Kind != "stop" || runs[1].NextRun != "2026-03-04T19:00:00Z" || runs[1].TimeZone != "UTC" {
+		t.Fatalf("unexpected stop schedule %+v", runs[1])
+	}
+}
@@ -0,0 +1,129 @@
+package launcher
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const dashboardFailureLimit = 5
+
+// Dashboard is the summary behind GET /api/dashboard: what the home page
+// and the TUI show above the profile list, in one request.
+type Dashboard struct {
+	Profiles       DashboardProfiles  `json:"profiles"`
+	Jobs           map[string]int     `json:"jobs"`
+	DiskBytes      int64              `json:"diskBytes"`
+	Updates        DashboardUpdates   `json:"updates"`
+	RecentFailures []DashboardFailure `json:"recentFailures"`
+	GeneratedAt    string             `json:"generatedAt"`
+}
+
+// Disk formats DiskBytes for the profiles page.
+func (d Dashboard) Disk() string { return formatBytes(d.DiskBytes) }
+
+type DashboardProfiles struct {
+	// Total counts every profile outside the trash, archived ones included,
+	// as the profile limit does.
+	Total     int `json:"total"`
+	Archived  int `json:"archived"`
+	Trashed   int `json:"trashed"`
+	Max       int `json:"max"`
+	Remaining int `json:"remaining"`
+	// ByStatus counts the profiles outside the archive and the trash by
+	// runtime status.
+	ByStatus map[string]int `json:"byStatus"`
+}
+
+type DashboardUpdates struct {
+	// Launcher is the newer launcher version, if there is one.
+	Launcher string `json:"launcher,omitempty"`
+	// Profiles lists the profiles a newer Kimmio version is available for.
+	Profiles []string `json:"profiles"`
+}
+
+// DashboardFailure is the last action of a profile that failed.
+type DashboardFailure struct {
+	ProfileID string `json:"profileId"`
+	Action    string `json:"action"`
+	Message   string `json:"message"`
+	At        string `json:"at"`
+}
+
+// buildDashboard summarizes profiles, which carry their runtime status.
+// Disk usage is the last sampled size of each profile's volumes, so the
+// summary never waits for Docker.
+func (s *Server) buildDashboard(profiles []ProfileRequest) Dashboard {
+	d := Dashboard{
+		Profiles:       DashboardProfiles{Max: maxProfilesLimit(), ByStatus: map[string]int{}},
+		Jobs:           s.jobCounts(),
+		Updates:        DashboardUpdates{Profiles: []string{}},
+		RecentFailures: []DashboardFailure{},
+		GeneratedAt:    time.Now().UTC().Format(time.RFC3339),
+	}
+	for _, p := range profiles {
+		switch {
+		case p.DeletedAt != "":
+			d.Profiles.Trashed++
+			continue
+		case p.Archived:
+			d.Profiles.Archived++
+		default:
+			status := p.RuntimeStatus
+			if status == "" {
+				status = "unknown"
+			}
+			d.Profiles.ByStatus[status]++
+		}
+		d.Profiles.Total++
+		if s.usage != nil {
+			d.DiskBytes += s.usage.lastDisk(p.ID)
+		}
+		if p.LastActionStatus == "failed" {
+			d.RecentFailures = append(d.RecentFailures, DashboardFailure{
+				ProfileID: p.ID,
+				Action:    p.LastAction,
+				Message:   p.LastActionResult,
+				At:        p.LastActionAt,
+			})
+		}
+	}
+	d.Profiles.Remaining = max(d.Profiles.Max-d.Profiles.Total, 0)
+	sort.Slice(d.RecentFailures, func(i, j int) bool { return d.RecentFailures[i].At > d.RecentFailures[j].At })
+	if len(d.RecentFailures) > dashboardFailureLimit {
+		d.RecentFailures = d.RecentFailures[:dashboardFailureLimit]
+	}
+
+	if s.notices != nil {
+		for _, n := range s.notices.ofKind(noticeLauncherUpdate) {
+			d.Updates.Launcher = n.Version
+		}
+		for _, n := range s.notices.ofKind(noticeProfileUpdate) {
+			d.Updates.Profiles = append(d.Updates.Profiles, n.ProfileID)
+		}
+		sort.Strings(d.Updates.Profiles)
+	}
+	return d
+}
+
+func (s *Server) dashboard(ctx context.Context) (Dashboard, error) {
+	profiles, err := s.Profiles().List(ctx)
+	if err != nil {
+		return Dashboard{}, err
+	}
+	return s.buildDashboard(profiles), nil
+}
+
+func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	d, err := s.dashboard(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load profiles: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "dashboard": d})
+}
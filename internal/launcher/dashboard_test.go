@@ -0,0 +1,67 @@
+package launcher
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildDashboardCountsProfiles(t *testing.T) {
+	srv := newServiceTestServer(t)
+	if err := srv.usage.add("alpha", UsageSample{At: time.Now(), DiskBytes: 2048}); err != nil {
+		t.Fatal(err)
+	}
+	srv.notices.replace([]Notification{
+		{ID: "launcher_update:2.0.0", Kind: noticeLauncherUpdate, Version: "2.0.0"},
+		{ID: "profile_update:beta:1.1.0", Kind: noticeProfileUpdate, ProfileID: "beta", Version: "1.1.0"},
+	})
+
+	d := srv.buildDashboard([]ProfileRequest{
+		{ID: "alpha", RuntimeStatus: "running"},
+		{ID: "beta", RuntimeStatus: "stopped", LastAction: "update", LastActionStatus: "failed", LastActionResult: "pull failed", LastActionAt: "2026-01-02T00:00:00Z"},
+		{ID: "gamma", LastAction: "enable", LastActionStatus: "failed", LastActionAt: "2026-01-03T00:00:00Z"},
+		{ID: "delta", Archived: true},
+		{ID: "old", DeletedAt: "2026-01-01T00:00:00Z", LastActionStatus: "failed"},
+	})
+
+	if d.Profiles.Total != 4 || d.Profiles.Archived != 1 || d.Profiles.Trashed != 1 {
+		t.Fatalf("unexpected profile counts %+v", d.Profiles)
+	}
+	if d.Profiles.ByStatus["running"] != 1 || d.Profiles.ByStatus["stopped"] != 1 || d.Profiles.ByStatus["unknown"] != 1 {
+		t.Fatalf("unexpected status counts %v", d.Profiles.ByStatus)
+	}
+	if d.DiskBytes != 2048 {
+		t.Fatalf("expected the sampled disk usage, got %d", d.DiskBytes)
+	}
+	if d.Updates.Launcher != "2.0.0" || strings.Join(d.Updates.Profiles, ",") != "beta" {
+		t.Fatalf("unexpected updates %+v", d.Updates)
+	}
+	if len(d.RecentFailures) != 2 || d.RecentFailures[0].ProfileID != "gamma" || d.RecentFailures[1].Message != "pull failed" {
+		t.Fatalf("expected the failures newest first without trashed profiles, got %+v", d.RecentFailures)
+	}
+}
+
+func TestDashboardAPI(t *testing.T) {
+	srv := newServiceTestServer(t)
+	rec := httptest.NewRecorder()
+	srv.handleDashboard(rec, httptest.NewRequest(http.MethodGet, "/api/dashboard", nil))
+	var payload struct {
+		OK        bool      `json:"ok"`
+		Dashboard Dashboard `json:"dashboard"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || !payload.OK || payload.Dashboard.Profiles.Total != 1 || payload.Dashboard.Jobs["total"] != 0 {
+		t.Fatalf("unexpected dashboard %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	srv.handleDashboard(rec, httptest.NewRequest(http.MethodPost, "/api/dashboard", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+}
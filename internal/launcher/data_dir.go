@@ -0,0 +1,368 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Profiles keep their data in named Docker volumes. A profile with DataDir
+// set keeps the Postgres, Redis, MinIO and app data in subdirectories of
+// that host directory instead, e.g. on a second drive. The first start
+// after DataDir is set moves the data over from the named volumes.
+
+// profileDataVolume is one volume that moves to a subdirectory of DataDir.
+// kimmio_run only holds runtime state and stays a named volume.
+type profileDataVolume struct {
+	Volume string
+	Dir    string
+	Target string
+}
+
+var profileDataVolumes = []profileDataVolume{
+	{Volume: "postgres_data", Dir: "postgres", Target: "/var/lib/postgresql/data"},
+	{Volume: "redis_data", Dir: "redis", Target: "/data"},
+	{Volume: "minio_data", Dir: "minio", Target: "/data"},
+	{Volume: "kimmio_data", Dir: "kimmio", Target: "/app/.data"},
+}
+
+// systemDataDirs lists host directories a profile's data may not go in or
+// under, and systemParentDirs ones it may only go below. The directory is
+// mounted read-write into the containers, and recreate and purge delete
+// subdirectories of it.
+func systemDataDirs() (within, parents []string) {
+	switch runtime.GOOS {
+	case "windows":
+		root := os.Getenv("SystemDrive") + `\`
+		return []string{os.Getenv("SystemRoot"), os.Getenv("ProgramFiles"), os.Getenv("ProgramFiles(x86)"), os.Getenv("ProgramData")},
+			[]string{filepath.Join(root, "Users")}
+	case "darwin":
+		return []string{"/System", "/Library", "/Applications", "/bin", "/sbin", "/usr", "/etc", "/dev", "/private/etc", "/private/var/db"},
+			[]string{"/Users", "/Volumes", "/private", "/var", "/tmp", "/opt"}
+	default:
+		return []string{"/bin", "/boot", "/dev", "/etc", "/lib", "/lib32", "/lib64", "/libx32", "/proc", "/run", "/sbin", "/sys", "/usr", "/var/lib", "/var/log", "/var/run", "/var/cache", "/snap"},
+			[]string{"/home", "/mnt", "/media", "/opt", "/srv", "/var", "/tmp", "/root"}
+	}
+}
+
+// normalizeProfileDataDir cleans dir and rejects paths that are relative,
+// that the compose .env file cannot carry unquoted, or that would hand a
+// system directory or the launcher's own data to the containers.
+func normalizeProfileDataDir(dir string) (string, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return "", nil
+	}
+	if !filepath.IsAbs(dir) {
+		return "", fieldError("dataDir", "dataDir.relative", "data directory must be an absolute path")
+	}
+	if strings.ContainsAny(dir, "$#\"'`\r\n") {
+		return "", fieldError("dataDir", "dataDir.invalid", "data directory must not contain $, #, quotes or line breaks")
+	}
+	dir = filepath.Clean(dir)
+	if filepath.Dir(dir) == dir {
+		return "", fieldError("dataDir", "dataDir.root", "data directory must not be the root of a drive")
+	}
+	if own, err := filepath.Abs(appCfg.DataDir); appCfg.DataDir != "" && err == nil && (pathWithin(own, dir) || pathWithin(dir, own)) {
+		return "", fieldError("dataDir", "dataDir.launcher", "data directory must not overlap the launcher's data directory "+own)
+	}
+	within, parents := systemDataDirs()
+	for _, sys := range within {
+		if sys != "" && pathWithin(dir, sys) {
+			return "", fieldError("dataDir", "dataDir.system", "data directory must not be in the system directory "+sys)
+		}
+	}
+	for _, sys := range parents {
+		if strings.EqualFold(dir, sys) {
+			return "", fieldError("dataDir", "dataDir.system", "data directory must be a directory inside "+sys+", not "+sys+" itself")
+		}
+	}
+	return dir, nil
+}
+
+// checkDataDirConflicts refuses a data directory that another profile
+// uses, contains, or lives in.
+func checkDataDirConflicts(id, dir string, profiles []ProfileRequest) error {
+	if dir == "" {
+		return nil
+	}
+	for _, p := range profiles {
+		if p.ID == id || p.DataDir == "" {
+			continue
+		}
+		if pathWithin(dir, p.DataDir) || pathWithin(p.DataDir, dir) {
+			return fieldError("dataDir", "dataDir.taken", fmt.Sprintf("data directory overlaps the one of profile %s", p.ID))
+		}
+	}
+	return nil
+}
+
+// pathWithin reports whether path is dir or inside it.
+func pathWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// prepareProfileDataDir creates the data subdirectories and checks the
+// launcher can write to them, so a wrong path fails before any pull.
+func prepareProfileDataDir(dir string) error {
+	for _, v := range profileDataVolumes {
+		sub := filepath.Join(dir, v.Dir)
+		if err := os.MkdirAll(sub, 0o755); err != nil {
+			return fmt.Errorf("data directory %s cannot be created: %w", sub, err)
+		}
+	}
+	probe, err := os.CreateTemp(dir, ".kimmio-write-check-*")
+	if err != nil {
+		return fmt.Errorf("data directory %s is not writable: %w", dir, err)
+	}
+	probe.Close()
+	_ = os.Remove(probe.Name())
+	return nil
+}
+
+// profileInstanceID is the INSTANCE_ID the compose file names volumes by.
+func profileInstanceID(profile ProfileRequest) string {
+	return envValue(profile.Env, "INSTANCE_ID", profile.ID)
+}
+
+// composeDataVolume is the service volume entry of v: the named volume, or
+// a bind mount of its DataDir subdirectory.
+func composeDataVolume(profile ProfileRequest, v profileDataVolume) string {
+	if profile.DataDir == "" {
+		return "      - " + v.Volume + ":" + v.Target + "\n"
+	}
+	return "      - type: bind\n" +
+		"        source: ${KIMMIO_DATA_DIR}/" + v.Dir + "\n" +
+		"        target: " + v.Target + "\n"
+}
+
+func composeDataVolumeFor(profile ProfileRequest, volume string) string {
+	for _, v := range profileDataVolumes {
+		if v.Volume == volume {
+			return composeDataVolume(profile, v)
+		}
+	}
+	return "      - " + volume + "\n"
+}
+
+// composeVolumeDefinitions declares the named volumes the stack uses.
+func composeVolumeDefinitions(profile ProfileRequest) string {
+	var b strings.Builder
+	b.WriteString("volumes:\n")
+	for _, name := range []string{"postgres_data", "redis_data", "kimmio_data", "kimmio_run", "minio_data"} {
+		if profile.DataDir != "" && name != "kimmio_run" {
+			continue
+		}
+		b.WriteString("  " + name + ":\n")
+		b.WriteString("    name: ${INSTANCE_ID}_" + name + "\n")
+		b.WriteString("    labels: *launcher-labels\n")
+	}
+	return b.String()
+}
+
+// The docker calls that move and remove bind-mounted data. They run in a
+// container of the pinned Postgres image, which is pulled for the stack
+// anyway, so files owned by the container users can be copied and removed
+// without root on the host. Tests replace them.
+var (
+	dockerVolumeExists = func(ctx context.Context, name string) bool {
+		if engine, err := newDockerEngine(); err == nil {
+			return engine.volumeExists(ctx, name)
+		}
+		dockerBin, err := dockerBinaryPath()
+		if err != nil {
+			return false
+		}
+		return dockerCommandWithContext(ctx, dockerBin, "volume", "inspect", name).Run() == nil
+	}
+	copyVolumeToDir = func(ctx context.Context, volume, dir string) error {
+		return runDataHelper(ctx, []string{"-v", volume + ":/from:ro", "-v", dir + ":/to"}, "cp -a /from/. /to/")
+	}
+	removeDockerVolume = func(ctx context.Context, name string) error {
+		if engine, err := newDockerEngine(); err == nil {
+			return engine.removeVolume(ctx, name)
+		}
+		dockerBin, err := dockerBinaryPath()
+		if err != nil {
+			return err
+		}
+		if out, err := dockerCommandWithContext(ctx, dockerBin, "volume", "rm", name).CombinedOutput(); err != nil {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+	wipeProfileDataDir = func(ctx context.Context, dir string) error {
+		paths := make([]string, 0, len(profileDataVolumes))
+		for _, v := range profileDataVolumes {
+			paths = append(paths, "/data/"+v.Dir)
+		}
+		return runDataHelper(ctx, []string{"-v", dir + ":/data"}, "rm -rf "+strings.Join(paths, " "))
+	}
+)
+
+func runDataHelper(ctx context.Context, mounts []string, script string, args ...string) error {
+	_, err := runDataHelperOutput(ctx, mounts, script, args...)
+	return err
+}
+
+// runDataHelperOutput runs script in the helper container and returns what
+// it printed. args reach the script as $1, $2, ..., never as shell code.
+func runDataHelperOutput(ctx context.Context, mounts []string, script string, scriptArgs ...string) (string, error) {
+	dockerBin, err := dockerBinaryPath()
+	if err != nil {
+		return "", err
+	}
+	args := append([]string{"run", "--rm", "--entrypoint", "sh"}, mounts...)
+	args = append(args, postgresImage, "-c", script, "sh")
+	args = append(args, scriptArgs...)
+	out, err := dockerCommandWithContext(ctx, dockerBin, args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
+
+// migrateProfileVolumes moves data from the named volumes into DataDir.
+// A subdirectory that already holds files is left alone, so the move
+// happens once; a volume is removed only after its copy succeeded.
+func migrateProfileVolumes(ctx context.Context, profile ProfileRequest, notify composeProgressFn) error {
+	for _, v := range profileDataVolumes {
+		dir := filepath.Join(profile.DataDir, v.Dir)
+		if entries, err := os.ReadDir(dir); err != nil || len(entries) > 0 {
+			continue
+		}
+		name := profileInstanceID(profile) + "_" + v.Volume
+		if !dockerVolumeExists(ctx, name) {
+			continue
+		}
+		notify("migrate", fmt.Sprintf("Moving %s data from volume %s to %s", v.Dir, name, dir), 58)
+		if err := copyVolumeToDir(ctx, name, dir); err != nil {
+			return fmt.Errorf("move volume %s to %s: %w", name, dir, err)
+		}
+		logInfo("profile_volume_migrated", map[string]any{"profile_id": profile.ID, "volume": name, "dir": dir})
+		if err := removeDockerVolume(ctx, name); err != nil {
+			logWarn("profile_volume_remove_failed", map[string]any{"profile_id": profile.ID, "volume": name, "error": err.Error()})
+		}
+	}
+	return nil
+}
+
+// removeProfileData deletes the data a profile keeps in its data directory,
+// as compose down --volumes does for named volumes. The directory itself,
+// chosen by the user, stays.
+func (s *Server) removeProfileData(ctx context.Context, id string) error {
+	store, err := s.readStore(ctx)
+	if err != nil {
+		return err
+	}
+	idx := findProfileIndex(store, id)
+	if idx < 0 || store.Profiles[idx].DataDir == "" {
+		return nil
+	}
+	dir := store.Profiles[idx].DataDir
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+	if err := wipeProfileDataDir(ctx, dir); err != nil {
+		return fmt.Errorf("remove data in %s: %w", dir, err)
+	}
+	return nil
+}
+
+// SetDataDir moves a stopped profile's data to dir; the move itself happens
+// on the next start. Once set, only a storage migration changes or clears
+// the directory, as anything else would leave the data behind.
+func (p *ProfileService) SetDataDir(ctx context.Context, id, dir string, expectedRevision int) (ProfileRequest, error) {
+	id = normalizeProfileID(id)
+	if !profileIDRe.MatchString(id) {
+		return ProfileRequest{}, ValidationError{Msg: "invalid profile id"}
+	}
+	dir, err := normalizeProfileDataDir(dir)
+	if err != nil {
+		return ProfileRequest{}, err
+	}
+	if dir == "" {
+		return ProfileRequest{}, fieldError("dataDir", "dataDir.required", "data directory is required")
+	}
+	s := p.srv
+	if jobID, busy := s.activeProfileJob(id); busy {
+		return ProfileRequest{}, ProfileBusyError{JobID: jobID}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	store, err := s.loadStoreLocked(ctx)
+	if err != nil {
+		return ProfileRequest{}, err
+	}
+	idx := findProfileIndex(store, id)
+	if idx < 0 {
+		return ProfileRequest{}, ErrProfileNotFound
+	}
+	profile := &store.Profiles[idx]
+	if expectedRevision > 0 && profile.Revision != expectedRevision {
+		return ProfileRequest{}, RevisionConflictError{Expected: expectedRevision, Current: *profile}
+	}
+	if profile.DataDir == dir {
+		return *profile, nil
+	}
+	if profile.DataDir != "" {
+		return ProfileRequest{}, fieldError("dataDir", "dataDir.locked", "the data directory of a profile can only be changed by a storage migration")
+	}
+	if profile.Enabled {
+		return ProfileRequest{}, fieldError("dataDir", "dataDir.running", "stop the profile before moving its data")
+	}
+	if err := checkDataDirConflicts(id, dir, store.Profiles); err != nil {
+		return ProfileRequest{}, err
+	}
+	if err := prepareProfileDataDir(dir); err != nil {
+		return ProfileRequest{}, fieldError("dataDir", "dataDir.unwritable", err.Error())
+	}
+	profile.DataDir = dir
+	profile.Revision++
+	entry := time.Now().UTC().Format(time.RFC3339) + " [data-dir] success: data moves to " + dir + " on the next start"
+	profile.ActionLog = append([]string{entry}, profile.ActionLog...)
+	if len(profile.ActionLog) > 8 {
+		profile.ActionLog = profile.ActionLog[:8]
+	}
+	if err := s.writeStoreLocked(store); err != nil {
+		return ProfileRequest{}, err
+	}
+	auditLog("INFO", "profile_data_dir_set", map[string]any{"profile_id": id, "data_dir": dir})
+	return *profile, nil
+}
+
+// handleProfileDataDir serves POST /api/profiles/<id>/data-dir with a body
+// of {"dataDir": "/mnt/data/shop"}.
+func (s *Server) handleProfileDataDir(w http.ResponseWriter, r *http.Request, id string) {
+	expectedRevision, err := expectedRevisionFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var body struct {
+		DataDir string `json:"dataDir"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", bodyErrorStatus(err))
+		return
+	}
+	profile, err := s.Profiles().SetDataDir(r.Context(), id, body.DataDir, expectedRevision)
+	if err != nil {
+		var ve ValidationError
+		if errors.As(err, &ve) {
+			writeValidationError(w, ve)
+			return
+		}
+		s.writeActionError(w, "data-dir", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "profile": profile})
+}
@@ -0,0 +1,131 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestComposeYAMLBindsDataDir(t *testing.T) {
+	profile := ProfileRequest{ID: "alpha", Version: "1.0.0", DataDir: "/mnt/data/alpha"}
+	out := buildComposeYAML(profile)
+	var doc struct {
+		Services map[string]struct {
+			Volumes []any `yaml:"volumes"`
+		} `yaml:"services"`
+		Volumes map[string]any `yaml:"volumes"`
+	}
+	if err := yaml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("compose file does not parse: %v\n%s", err, out)
+	}
+	if len(doc.Volumes) != 1 || doc.Volumes["kimmio_run"] == nil {
+		t.Fatalf("expected only kimmio_run as a named volume, got %v", doc.Volumes)
+	}
+	bind, ok := doc.Services["postgres"].Volumes[0].(map[string]any)
+	if !ok || bind["type"] != "bind" || bind["source"] != "${KIMMIO_DATA_DIR}/postgres" || bind["target"] != "/var/lib/postgresql/data" {
+		t.Fatalf("expected a bind mount for postgres, got %v", doc.Services["postgres"].Volumes)
+	}
+	if !strings.Contains(buildComposeEnv(profile), "\nKIMMIO_DATA_DIR=/mnt/data/alpha\n") {
+		t.Fatal("expected the data directory in the compose env")
+	}
+
+	profile.DataDir = ""
+	if out := buildComposeYAML(profile); !strings.Contains(out, "      - postgres_data:/var/lib/postgresql/data\n") || !strings.Contains(out, "  minio_data:\n    name: ${INSTANCE_ID}_minio_data\n") {
+		t.Fatalf("expected named volumes without a data directory:\n%s", out)
+	}
+}
+
+func TestMigrateProfileVolumesCopiesOnce(t *testing.T) {
+	dir := t.TempDir()
+	if err := prepareProfileDataDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	// Redis already has data here, so its volume is left alone.
+	if err := os.WriteFile(filepath.Join(dir, "redis", "appendonly.aof"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	existsOld, copyOld, removeOld := dockerVolumeExists, copyVolumeToDir, removeDockerVolume
+	defer func() { dockerVolumeExists, copyVolumeToDir, removeDockerVolume = existsOld, copyOld, removeOld }()
+	var copied, removed []string
+	dockerVolumeExists = func(_ context.Context, name string) bool { return name != "alpha_minio_data" }
+	copyVolumeToDir = func(_ context.Context, volume, target string) error {
+		if volume == "alpha_kimmio_data" {
+			return errors.New("disk full")
+		}
+		copied = append(copied, volume+" -> "+filepath.Base(target))
+		return nil
+	}
+	removeDockerVolume = func(_ context.Context, name string) error {
+		removed = append(removed, name)
+		return nil
+	}
+
+	err := migrateProfileVolumes(context.Background(), ProfileRequest{ID: "alpha", DataDir: dir}, func(string, string, int) {})
+	if err == nil || !strings.Contains(err.Error(), "alpha_kimmio_data") {
+		t.Fatalf("expected the failed copy to be reported, got %v", err)
+	}
+	if !slices.Equal(copied, []string{"alpha_postgres_data -> postgres"}) || !slices.Equal(removed, []string{"alpha_postgres_data"}) {
+		t.Fatalf("unexpected migration: copied %v, removed %v", copied, removed)
+	}
+}
+
+func TestSetDataDirRules(t *testing.T) {
+	srv := newServiceTestServer(t)
+	ctx := context.Background()
+	var ve ValidationError
+
+	if _, err := srv.Profiles().SetDataDir(ctx, "alpha", "relative/path", 0); !errors.As(err, &ve) {
+		t.Fatalf("expected a relative path to be refused, got %v", err)
+	}
+	dir := filepath.Join(t.TempDir(), "alpha")
+	p, err := srv.Profiles().SetDataDir(ctx, "alpha", dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.DataDir != dir || p.Revision != 2 {
+		t.Fatalf("unexpected profile after setting the data directory: %+v", p)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "postgres")); err != nil {
+		t.Fatalf("expected the data subdirectories to be created: %v", err)
+	}
+	if _, err := srv.Profiles().SetDataDir(ctx, "alpha", t.TempDir(), 0); !errors.As(err, &ve) || ve.Fields[0].Code != "dataDir.locked" {
+		t.Fatalf("expected the data directory to be locked once set, got %v", err)
+	}
+	if err := checkDataDirConflicts("beta", filepath.Join(dir, "nested"), []ProfileRequest{p}); err == nil {
+		t.Fatal("expected a directory inside another profile's to be refused")
+	}
+}
+
+func TestNormalizeProfileDataDirRefusesSystemPaths(t *testing.T) {
+	prev := appCfg.DataDir
+	defer func() { appCfg.DataDir = prev }()
+	appCfg.DataDir = filepath.Join(t.TempDir(), "launcher")
+
+	within, parents := systemDataDirs()
+	cases := map[string]string{
+		filepath.VolumeName(appCfg.DataDir) + string(filepath.Separator): "dataDir.root",
+		appCfg.DataDir:                              "dataDir.launcher",
+		filepath.Dir(appCfg.DataDir):                "dataDir.launcher",
+		filepath.Join(appCfg.DataDir, "profiles"):   "dataDir.launcher",
+		within[len(within)-1]:                       "dataDir.system",
+		filepath.Join(within[len(within)-1], "app"): "dataDir.system",
+		parents[0]: "dataDir.system",
+	}
+	for dir, code := range cases {
+		_, err := normalizeProfileDataDir(dir)
+		var ve ValidationError
+		if !errors.As(err, &ve) || ve.Fields[0].Code != code {
+			t.Fatalf("%s: expected %s, got %v", dir, code, err)
+		}
+	}
+	ok := filepath.Join(parents[0], "shop")
+	if dir, err := normalizeProfileDataDir(ok + string(filepath.Separator)); err != nil || dir != ok {
+		t.Fatalf("expected a directory inside %s to be accepted, got %q %v", parents[0], dir, err)
+	}
+}
@@ -0,0 +1,69 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDeleteBusyProfileReturnsActiveJob(t *testing.T) {
+	srv := newServiceTestServer(t)
+	srv.activeProfiles["alpha"] = "job-1"
+
+	rec := httptest.NewRecorder()
+	srv.handleProfileAction(rec, httptest.NewRequest(http.MethodDelete, "/api/profiles/alpha", strings.NewReader(`{"confirm":"alpha"}`)))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("expected a JSON body: %v", err)
+	}
+	if payload["activeJobId"] != "job-1" || payload["hint"] == nil {
+		t.Fatalf("expected the active job and a force hint, got %v", payload)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.handleProfileAction(rec, httptest.NewRequest(http.MethodDelete, "/api/profiles/alpha?force=maybe", strings.NewReader(`{"confirm":"alpha"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid force value, got %d", rec.Code)
+	}
+}
+
+func TestCancelProfileJobWaitsForRelease(t *testing.T) {
+	srv := newServiceTestServer(t)
+	if err := srv.Jobs().CancelProfileJob(context.Background(), "alpha"); err != nil {
+		t.Fatalf("expected nothing to cancel, got %v", err)
+	}
+
+	started := make(chan struct{})
+	job, err := srv.enqueueProfileJob("alpha", "start", func(jobID string, ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	if err := srv.ensureNoOtherJob("alpha", "other"); !errors.Is(err, ErrProfileBusy) {
+		t.Fatalf("expected the running job to block others, got %v", err)
+	}
+	if err := srv.ensureNoOtherJob("alpha", job.ID); err != nil {
+		t.Fatalf("the job holding the profile must pass, got %v", err)
+	}
+
+	if err := srv.Jobs().CancelProfileJob(context.Background(), "alpha"); err != nil {
+		t.Fatalf("expected the job to be canceled, got %v", err)
+	}
+	if active, ok := srv.activeProfileJob("alpha"); ok {
+		t.Fatalf("expected the profile to be released, still held by %s", active)
+	}
+	if got, _ := srv.snapshotJob(job.ID); got.Status != "canceled" {
+		t.Fatalf("expected the job to end canceled, got %q", got.Status)
+	}
+}
@@ -0,0 +1,70 @@
+package launcher
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// The deploy hook lets CI update a profile after publishing an image tag:
+//
+//	POST /api/hooks/deploy/<profile>  {"version": "1.4.2"}
+//
+// It starts the version action as a job and returns the job id for the
+// pipeline to poll. The hook only accepts API tokens; create one with the
+// deploy role so a leaked CI secret can do nothing else, and limit it to the
+// profiles the pipeline deploys.
+
+const deployHookPrefix = "/api/hooks/deploy/"
+
+func (s *Server) handleDeployHook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !isTokenRequest(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="kimmio-launcher"`)
+		http.Error(w, "unauthorized: deploy hooks need an API token", http.StatusUnauthorized)
+		return
+	}
+	id := normalizeProfileID(strings.Trim(strings.TrimPrefix(r.URL.Path, deployHookPrefix), "/"))
+	version := strings.TrimSpace(r.URL.Query().Get("version"))
+	if strings.Contains(strings.ToLower(r.Header.Get("Content-Type")), "application/json") {
+		var body struct {
+			Version string `json:"version"`
+		}
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "invalid JSON body", bodyErrorStatus(err))
+			return
+		}
+		if v := strings.TrimSpace(body.Version); v != "" {
+			version = v
+		}
+	}
+	if version == "" {
+		version = "latest"
+	}
+
+	tok, _ := r.Context().Value(apiTokenCtxKey{}).(APIToken)
+	fields := map[string]any{"profile": id, "version": version, "token": tok.Name, "remote": clientIP(r)}
+	if tok.Role == roleDeploy && !tok.allowsProfile(id) {
+		fields["error"] = "token not allowed for this profile"
+		auditLog("WARN", "deploy_hook_failed", fields)
+		http.Error(w, "Forbidden: this token cannot deploy "+id, http.StatusForbidden)
+		return
+	}
+	job, err := s.Profiles().StartAction(r.Context(), id, "version", version, 0, false)
+	if err != nil {
+		fields["error"] = err.Error()
+		auditLog("WARN", "deploy_hook_failed", fields)
+		http.Error(w, err.Error(), httpStatusForError(err))
+		return
+	}
+	fields["job_id"] = job.ID
+	auditLog("INFO", "deploy_hook_triggered", fields)
+	writeJSON(w, http.StatusAccepted, map[string]any{"ok": true, "jobId": job.ID, "profileId": id, "version": version})
+}
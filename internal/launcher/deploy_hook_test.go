@@ -0,0 +1,90 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeployHook(t *testing.T) {
+	srv := newServiceTestServer(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc(deployHookPrefix, srv.handleDeployHook)
+	mux.HandleFunc("/api/jobs/", srv.handleJobRoute)
+	mux.HandleFunc("/api/profiles/", srv.handleProfileAction)
+	h := srv.httpHandler(mux)
+	_, deploy, _ := srv.tokens.create("ci", roleDeploy, nil)
+	_, viewer, _ := srv.tokens.create("dashboard", roleViewer, nil)
+
+	do := func(method, path, auth, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "http://launcher.lan"+path, strings.NewReader(body))
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("Content-Type", "application/json")
+		if auth != "" {
+			req.Header.Set("Authorization", "Bearer "+auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/api/hooks/deploy/alpha", deploy, `{"version": "1.1.0"}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected the hook to start a job, got %d %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		JobID   string `json:"jobId"`
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.JobID == "" || resp.Version != "1.1.0" {
+		t.Fatalf("unexpected response %s", rec.Body.String())
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rec := do(http.MethodGet, "/api/jobs/"+resp.JobID, deploy, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected a deploy token to follow its job, got %d", rec.Code)
+		}
+		if strings.Contains(rec.Body.String(), `"status":"succeeded"`) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job did not finish: %s", rec.Body.String())
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if p, err := srv.Profiles().Get(context.Background(), "alpha"); err != nil || p.Version != "1.1.0" {
+		t.Fatalf("expected alpha on 1.1.0, got %q %v", p.Version, err)
+	}
+
+	if rec := do(http.MethodPost, "/api/profiles/alpha/stop", deploy, ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected a deploy token to be refused elsewhere, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/hooks/deploy/alpha", viewer, ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected a viewer token to be refused, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/hooks/deploy/missing", deploy, ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected an unknown profile to be 404, got %d", rec.Code)
+	}
+	if _, _, err := srv.tokens.create("viewer-limited", roleViewer, []string{"alpha"}); err == nil {
+		t.Fatal("expected only deploy tokens to take a profile list")
+	}
+	_, staging, err := srv.tokens.create("ci-staging", roleDeploy, []string{"Staging"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := do(http.MethodPost, "/api/hooks/deploy/alpha", staging, ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected a token limited to staging to be refused for alpha, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/hooks/deploy/alpha", nil)
+	rec = httptest.NewRecorder()
+	srv.handleDeployHook(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected the hook to require a token even locally, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package launcher
+
+import "syscall"
+
+func diskFreeBytes(path string) (int64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return int64(st.Bavail) * int64(st.Bsize), nil
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package launcher
+
+import "errors"
+
+// Docker on Windows keeps its data inside the WSL2 VM, so there is no host
+// path to measure.
+func diskFreeBytes(path string) (int64, error) {
+	return 0, errors.New("disk space check is not supported on windows")
+}
@@ -0,0 +1,146 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A profile's ID names its compose project, volumes and secret files, so
+// it never changes. DisplayName is the name people see instead; it can be
+// changed at any time and falls back to the ID when unset.
+
+const maxDisplayNameLength = 64
+
+// Title is the name the UI and CLI show for the profile.
+func (p ProfileRequest) Title() string {
+	if p.DisplayName != "" {
+		return p.DisplayName
+	}
+	return p.ID
+}
+
+// normalizeDisplayName collapses runs of whitespace and rejects names that
+// cannot be shown on one line. An empty name clears the display name.
+func normalizeDisplayName(name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	if utf8.RuneCountInString(name) > maxDisplayNameLength {
+		return "", fieldError("displayName", "displayName.length", fmt.Sprintf("display name must be at most %d characters", maxDisplayNameLength))
+	}
+	if strings.IndexFunc(name, unicode.IsControl) >= 0 {
+		return "", fieldError("displayName", "displayName.invalid", "display name must not contain control characters")
+	}
+	return name, nil
+}
+
+// Rename sets the profile's display name; an empty name clears it. It
+// works in any state, including archived and trashed profiles, as only the
+// label changes.
+func (p *ProfileService) Rename(ctx context.Context, id, name string, expectedRevision int) (ProfileRequest, error) {
+	id = normalizeProfileID(id)
+	if !profileIDRe.MatchString(id) {
+		return ProfileRequest{}, ValidationError{Msg: "invalid profile id"}
+	}
+	name, err := normalizeDisplayName(name)
+	if err != nil {
+		return ProfileRequest{}, err
+	}
+	s := p.srv
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	store, err := s.loadStoreLocked(ctx)
+	if err != nil {
+		return ProfileRequest{}, err
+	}
+	idx := findProfileIndex(store, id)
+	if idx < 0 {
+		return ProfileRequest{}, ErrProfileNotFound
+	}
+	profile := &store.Profiles[idx]
+	if expectedRevision > 0 && profile.Revision != expectedRevision {
+		return ProfileRequest{}, RevisionConflictError{Expected: expectedRevision, Current: *profile}
+	}
+	if profile.DisplayName == name {
+		return *profile, nil
+	}
+	previous := profile.DisplayName
+	profile.DisplayName = name
+	profile.Revision++
+	entry := time.Now().UTC().Format(time.RFC3339) + " [rename] success: shown as " + profile.Title()
+	profile.ActionLog = append([]string{entry}, profile.ActionLog...)
+	if len(profile.ActionLog) > 8 {
+		profile.ActionLog = profile.ActionLog[:8]
+	}
+	if err := s.writeStoreLocked(store); err != nil {
+		return ProfileRequest{}, err
+	}
+	auditLog("INFO", "profile_renamed", map[string]any{"profile_id": id, "from": previous, "to": name})
+	return *profile, nil
+}
+
+// resolveProfileRef maps what a user typed to a profile ID: an existing
+// ID as is, otherwise the one profile whose display name matches, ignoring
+// case. Anything else comes back normalized, for the caller to report.
+func (s *Server) resolveProfileRef(ctx context.Context, ref string) string {
+	id := normalizeProfileID(ref)
+	store, err := s.readStore(ctx)
+	if err != nil || findProfileIndex(store, id) >= 0 {
+		return id
+	}
+	return profileByDisplayName(store.Profiles, ref, id)
+}
+
+// profileByDisplayName is the ID of the one profile displayed as name, or
+// fallback when none or several are.
+func profileByDisplayName(profiles []ProfileRequest, name, fallback string) string {
+	name = strings.Join(strings.Fields(name), " ")
+	match := ""
+	for _, p := range profiles {
+		if p.DisplayName == "" || !strings.EqualFold(p.DisplayName, name) {
+			continue
+		}
+		if match != "" {
+			return fallback
+		}
+		match = p.ID
+	}
+	if match == "" {
+		return fallback
+	}
+	return match
+}
+
+// handleProfileRename serves POST /api/profiles/<id>/name with a body of
+// {"displayName": "..."}. If-Match guards against renaming a profile
+// changed meanwhile, as for actions.
+func (s *Server) handleProfileRename(w http.ResponseWriter, r *http.Request, id string) {
+	expectedRevision, err := expectedRevisionFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var body struct {
+		DisplayName string `json:"displayName"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", bodyErrorStatus(err))
+		return
+	}
+	profile, err := s.Profiles().Rename(r.Context(), id, body.DisplayName, expectedRevision)
+	if err != nil {
+		var ve ValidationError
+		if errors.As(err, &ve) {
+			writeValidationError(w, ve)
+			return
+		}
+		s.writeActionError(w, "rename", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "profile": profile})
+}
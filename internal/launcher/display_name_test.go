@@ -0,0 +1,86 @@
+package launcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"launcher/internal/config"
+)
+
+func TestRenameProfileKeepsID(t *testing.T) {
+	srv := newServiceTestServer(t)
+
+	rec := httptest.NewRecorder()
+	srv.handleProfileAction(rec, httptest.NewRequest(http.MethodPost, "/api/profiles/alpha/name", strings.NewReader(`{"displayName":"  Shop   Production "}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var payload struct {
+		Profile ProfileRequest `json:"profile"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Profile.ID != "alpha" || payload.Profile.DisplayName != "Shop Production" || payload.Profile.Title() != "Shop Production" {
+		t.Fatalf("unexpected profile after rename: %+v", payload.Profile)
+	}
+
+	// A stale revision is refused like for actions.
+	req := httptest.NewRequest(http.MethodPost, "/api/profiles/alpha/name", strings.NewReader(`{"displayName":"Other"}`))
+	req.Header.Set("If-Match", `"1"`)
+	rec = httptest.NewRecorder()
+	srv.handleProfileAction(rec, req)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a stale revision, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.handleProfileAction(rec, httptest.NewRequest(http.MethodPost, "/api/profiles/alpha/name", strings.NewReader(`{"displayName":"`+strings.Repeat("x", maxDisplayNameLength+1)+`"}`)))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "displayName") {
+		t.Fatalf("expected a displayName validation error, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	p, err := srv.Profiles().Rename(context.Background(), "alpha", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.DisplayName != "" || p.Title() != "alpha" {
+		t.Fatalf("expected an empty name to fall back to the ID, got %+v", p)
+	}
+}
+
+func TestRunCLI_ProfileByDisplayName(t *testing.T) {
+	tmp := t.TempDir()
+	cfg := config.Load("dev")
+	cfg.DataDir = tmp
+	appCfg = cfg
+	store := ProfileStore{Profiles: []ProfileRequest{
+		{ID: "alpha", DisplayName: "Shop Production", Version: "1.0.0", Ports: []PortMapping{{Container: 3000, Host: 8088}}},
+		{ID: "beta", DisplayName: "Twin", Version: "1.0.0", Ports: []PortMapping{{Container: 3000, Host: 8089}}},
+		{ID: "gamma", DisplayName: "twin", Version: "1.0.0", Ports: []PortMapping{{Container: 3000, Host: 8090}}},
+	}}
+	if err := writeProfileStoreAtomic(filepath.Join(tmp, "profiles.json"), store); err != nil {
+		t.Fatal(err)
+	}
+
+	var out, errOut bytes.Buffer
+	if _, code := RunCLI(cfg, []string{"profile", "shop production", "info"}, &out, &errOut); code != 0 {
+		t.Fatalf("expected the display name to resolve, got %d: %s", code, errOut.String())
+	}
+	if !strings.Contains(out.String(), "ID: alpha\nName: Shop Production\n") {
+		t.Fatalf("unexpected info output: %s", out.String())
+	}
+
+	// An ambiguous name matches nothing.
+	out.Reset()
+	errOut.Reset()
+	if _, code := RunCLI(cfg, []string{"profile", "Twin", "info"}, &out, &errOut); code == 0 {
+		t.Fatalf("expected an ambiguous display name to fail, got: %s", out.String())
+	}
+}
@@ -1,6 +1,7 @@
 package launcher
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
@@ -22,8 +23,10 @@ func (s *Server) performEnable(id, jobID string, parent context.Context) error {
 
 	ctx, cancel := context.WithTimeout(parent, actionTimeout)
 	defer cancel()
+	// Results are persisted even when the job is canceled mid-flight.
+	record := context.WithoutCancel(parent)
 
-	store, idx, err := s.getProfileForAction(id)
+	store, idx, err := s.getProfileForAction(ctx, id)
 	if err != nil {
 		return err
 	}
@@ -52,13 +55,13 @@ func (s *Server) performEnable(id, jobID string, parent context.Context) error {
 		})
 	}
 
-	if err := runProfileComposeUp(ctx, profile, progress); err != nil {
+	if err := s.compose.up(ctx, profile, "enable", progress); err != nil {
 		logError("profile_enable_failed", map[string]any{"profile_id": id, "error": err.Error()})
-		_ = s.markProfileResult(id, "enable", "failed", err.Error(), "")
+		_ = s.markProfileResult(record, id, "enable", "failed", err.Error(), "")
 		return err
 	}
 	startingUntil := time.Now().UTC().Add(45 * time.Second).Format(time.RFC3339)
-	if err := s.markProfileResult(id, "enable", "success", "Enable requested; waiting for health", startingUntil); err != nil {
+	if err := s.markProfileResult(record, id, "enable", "success", "Enable requested; waiting for health", startingUntil); err != nil {
 		return err
 	}
 	s.updateJobStep(jobID, "health", "running", "Waiting for health", 85, "")
@@ -67,67 +70,72 @@ func (s *Server) performEnable(id, jobID string, parent context.Context) error {
 			return ctx.Err()
 		}
 		logWarn("profile_enable_health_pending", map[string]any{"profile_id": id})
-		_ = s.markProfileResult(id, "enable", "warning", "Instance did not become healthy yet", startingUntil)
+		_ = s.markProfileResult(record, id, "enable", "warning", "Instance did not become healthy yet", startingUntil)
 		return nil
 	}
 	logInfo("profile_enable_succeeded", map[string]any{"profile_id": id})
-	return s.markProfileResult(id, "enable", "success", "Instance is healthy", "")
+	return s.markProfileResult(record, id, "enable", "success", "Instance is healthy", "")
 }
 
 func (s *Server) performStop(id, jobID string, parent context.Context) error {
 	ctx, cancel := context.WithTimeout(parent, appCfg.ActionTimeout)
 	defer cancel()
+	record := context.WithoutCancel(parent)
 
 	s.updateJobStep(jobID, "down", "running", "Stopping compose stack", 35, "")
-	if err := runProfileComposeDown(ctx, id, false); err != nil {
-		_ = s.markProfileResult(id, "stop", "failed", err.Error(), "")
+	if err := s.compose.down(ctx, id, false); err != nil {
+		_ = s.markProfileResult(record, id, "stop", "failed", err.Error(), "")
 		return err
 	}
-	return s.markProfileResult(id, "stop", "success", "Profile stopped", "")
+	return s.markProfileResult(record, id, "stop", "success", "Profile stopped", "")
 }
 
 func (s *Server) performRecreate(id, jobID string, parent context.Context) error {
 	ctx, cancel := context.WithTimeout(parent, appCfg.ActionTimeout)
 	defer cancel()
+	record := context.WithoutCancel(parent)
 
-	store, idx, err := s.getProfileForAction(id)
+	store, idx, err := s.getProfileForAction(ctx, id)
 	if err != nil {
 		return err
 	}
 	profile := store.Profiles[idx]
 
 	s.updateJobStep(jobID, "down", "running", "Resetting stack and volumes", 30, "")
-	if err := runProfileComposeDown(ctx, id, true); err != nil {
-		_ = s.markProfileResult(id, "recreate", "failed", err.Error(), "")
+	if err := s.compose.down(ctx, id, true); err != nil {
+		_ = s.markProfileResult(record, id, "recreate", "failed", err.Error(), "")
 		return err
 	}
 	s.updateJobStep(jobID, "up", "running", "Starting fresh stack", 60, "")
-	if err := runProfileComposeUp(ctx, profile, func(step, message string, progress int) {
+	if err := s.compose.up(ctx, profile, "recreate", func(step, message string, progress int) {
 		s.updateJobStep(jobID, step, "running", message, progress, "")
 	}); err != nil {
-		_ = s.markProfileResult(id, "recreate", "failed", err.Error(), "")
+		_ = s.markProfileResult(record, id, "recreate", "failed", err.Error(), "")
 		return err
 	}
 	startingUntil := time.Now().UTC().Add(45 * time.Second).Format(time.RFC3339)
-	if err := s.markProfileResult(id, "recreate", "success", "Recreate requested; waiting for health", startingUntil); err != nil {
+	if err := s.markProfileResult(record, id, "recreate", "success", "Recreate requested; waiting for health", startingUntil); err != nil {
 		return err
 	}
 	if ok := waitForProfileHealthOrCanceled(ctx, profile, 6, 2*time.Second); !ok {
 		if errors.Is(ctx.Err(), context.Canceled) {
 			return ctx.Err()
 		}
-		_ = s.markProfileResult(id, "recreate", "warning", "Instance did not become healthy yet", startingUntil)
+		_ = s.markProfileResult(record, id, "recreate", "warning", "Instance did not become healthy yet", startingUntil)
 		return nil
 	}
-	return s.markProfileResult(id, "recreate", "success", "Instance is healthy", "")
+	return s.markProfileResult(record, id, "recreate", "success", "Instance is healthy", "")
 }
 
 func (s *Server) performDelete(id, jobID string, parent context.Context) error {
 	ctx, cancel := context.WithTimeout(parent, appCfg.ActionTimeout)
 	defer cancel()
+	if err := s.ensureNoOtherJob(id, jobID); err != nil {
+		return err
+	}
 
 	s.mu.Lock()
-	store, err := loadProfileStore(s.dbPath)
+	store, err := s.loadStoreLocked(ctx)
 	if err != nil {
 		s.mu.Unlock()
 		return err
@@ -140,12 +148,12 @@ func (s *Server) performDelete(id, jobID string, parent context.Context) error {
 	s.mu.Unlock()
 
 	s.updateJobStep(jobID, "cleanup", "running", "Removing stack and volumes", 45, "")
-	if err := runProfileComposeDown(ctx, id, true); err != nil {
+	if err := s.compose.down(ctx, id, true); err != nil {
 		return err
 	}
 
 	s.mu.Lock()
-	store, err = loadProfileStore(s.dbPath)
+	store, err = s.loadStoreLocked(ctx)
 	if err != nil {
 		s.mu.Unlock()
 		return err
@@ -156,23 +164,19 @@ func (s *Server) performDelete(id, jobID string, parent context.Context) error {
 		return os.ErrNotExist
 	}
 	store.Profiles = append(store.Profiles[:idx], store.Profiles[idx+1:]...)
-	err = writeProfileStoreAtomic(s.dbPath, store)
+	err = s.commitStoreTxLocked("delete", id, store,
+		txRemove(profileComposeDir(id)), txRemove(secretFilePath(id)), txRemove(connectionSheetMarkerPath(id)))
 	s.mu.Unlock()
-	if err != nil {
-		return err
-	}
-
-	_ = os.RemoveAll(profileComposeDir(id))
-	_ = os.Remove(secretFilePath(id))
-	return nil
+	return err
 }
 
 func (s *Server) performVersionUpdate(id, newVersion, jobID string, parent context.Context) error {
 	ctx, cancel := context.WithTimeout(parent, appCfg.ActionTimeout)
 	defer cancel()
+	record := context.WithoutCancel(parent)
 
 	s.mu.Lock()
-	store, err := loadProfileStore(s.dbPath)
+	store, err := s.loadStoreLocked(ctx)
 	if err != nil {
 		s.mu.Unlock()
 		return err
@@ -186,36 +190,38 @@ func (s *Server) performVersionUpdate(id, newVersion, jobID string, parent conte
 	oldVersion := oldProfile.Version
 	store.Profiles[idx].Version = newVersion
 	store.Profiles[idx].LastRequestedVersion = newVersion
-	err = writeProfileStoreAtomic(s.dbPath, store)
+	store.Profiles[idx].Revision++
+	err = s.writeStoreLocked(store)
 	s.mu.Unlock()
 	if err != nil {
 		return err
 	}
 
 	if !oldProfile.Enabled {
-		return s.markProfileResult(id, "version", "success", "Version updated to "+newVersion, "")
+		return s.markProfileResult(record, id, "version", "success", "Version updated to "+newVersion, "")
 	}
 
 	s.updateJobStep(jobID, "up", "running", "Rebuilding with new version", 45, "")
 	newProfile := oldProfile
 	newProfile.Version = newVersion
-	if err := runProfileComposeUp(ctx, newProfile, nil); err != nil {
+	if err := s.compose.up(ctx, newProfile, "version", nil); err != nil {
 		s.updateJobStep(jobID, "cleanup", "running", "Rolling back to previous version", 75, "")
-		rollbackErr := runProfileComposeUp(ctx, oldProfile, nil)
-		_ = s.restoreVersion(id, oldVersion, rollbackErr == nil)
+		rollbackErr := s.compose.up(ctx, oldProfile, "version", nil)
+		_ = s.restoreVersion(record, id, oldVersion, rollbackErr == nil)
 		if rollbackErr != nil {
 			return fmt.Errorf("update failed: %v; rollback failed: %v", err, rollbackErr)
 		}
 		return fmt.Errorf("update failed and rolled back: %w", err)
 	}
-	return s.markProfileResult(id, "version", "success", "Version updated to "+newVersion, "")
+	return s.markProfileResult(record, id, "version", "success", "Version updated to "+newVersion, "")
 }
 
 func (s *Server) performRegenerateSecrets(id, jobID string, parent context.Context) error {
 	ctx, cancel := context.WithTimeout(parent, appCfg.ActionTimeout)
 	defer cancel()
+	record := context.WithoutCancel(parent)
 
-	store, idx, err := s.getProfileForAction(id)
+	store, idx, err := s.getProfileForAction(ctx, id)
 	if err != nil {
 		return err
 	}
@@ -225,24 +231,33 @@ func (s *Server) performRegenerateSecrets(id, jobID string, parent context.Conte
 		"JWT_SECRET": randomToken(48),
 		"ENC_KEY_V0": randomBase64Key32(),
 	}
+	// Only the app keys rotate; credentials for external services stay.
+	existing := loadProfileSecrets(id)
+	for _, key := range []string{smtpPasswordKey, ssoClientSecretKey} {
+		if v := existing[key]; v != "" {
+			newSecrets[key] = v
+		}
+	}
 	if err := saveProfileSecrets(id, newSecrets); err != nil {
-		_ = s.markProfileResult(id, "regenerate-secrets", "failed", err.Error(), "")
+		_ = s.markProfileResult(record, id, "regenerate-secrets", "failed", err.Error(), "")
 		return err
 	}
 
 	if !profile.Enabled {
-		return s.markProfileResult(id, "regenerate-secrets", "success", "Secrets regenerated", "")
+		return s.markProfileResult(record, id, "regenerate-secrets", "success", "Secrets regenerated", "")
 	}
 
 	s.updateJobStep(jobID, "up", "running", "Applying regenerated secrets", 50, "")
-	if err := runProfileComposeUp(ctx, profile, nil); err != nil {
-		_ = s.markProfileResult(id, "regenerate-secrets", "failed", err.Error(), "")
+	if err := s.compose.up(ctx, profile, "regenerate-secrets", nil); err != nil {
+		_ = s.markProfileResult(record, id, "regenerate-secrets", "failed", err.Error(), "")
 		return err
 	}
-	return s.markProfileResult(id, "regenerate-secrets", "success", "Secrets regenerated and applied", "")
+	return s.markProfileResult(record, id, "regenerate-secrets", "success", "Secrets regenerated and applied", "")
 }
 
-func runProfileComposeUp(ctx context.Context, profile ProfileRequest, onProgress composeProgressFn) error {
+// runProfileComposeUp writes the compose files and starts the stack,
+// pulling the image as the profile's pull policy for action says.
+func (s *Server) runProfileComposeUp(ctx context.Context, profile ProfileRequest, action string, onProgress composeProgressFn) error {
 	notify := func(step, message string, progress int) {
 		if onProgress != nil {
 			onProgress(step, message, progress)
@@ -250,12 +265,22 @@ func runProfileComposeUp(ctx context.Context, profile ProfileRequest, onProgress
 	}
 
 	notify("prepare", "Preparing compose files", 18)
+	profile, err := s.resolveProfilePlatform(ctx, profile, notify)
+	if err != nil {
+		return err
+	}
+	s.wake.release(profile.ID)
 	composeDir := profileComposeDir(profile.ID)
 	if err := os.MkdirAll(composeDir, 0o755); err != nil {
 		return err
 	}
+	if profile.DataDir != "" {
+		if err := prepareProfileDataDir(profile.DataDir); err != nil {
+			return err
+		}
+	}
 
-	if err := os.WriteFile(filepath.Join(composeDir, "compose.yaml"), []byte(buildComposeYAML()), 0o644); err != nil {
+	if err := os.WriteFile(filepath.Join(composeDir, "compose.yaml"), []byte(buildComposeYAML(profile)), 0o644); err != nil {
 		return err
 	}
 
@@ -274,23 +299,26 @@ func runProfileComposeUp(ctx context.Context, profile ProfileRequest, onProgress
 	if strings.TrimSpace(profile.Version) == "" {
 		image = "kimmio/kimmio-app:latest"
 	}
-	notify("pull", "Pulling Docker image "+image+" (can take several minutes)", 30)
-	if err := pullImageWithRetry(ctx, dockerBin, image, 3, func(attempt, attempts int) {
-		if attempts <= 1 {
-			notify("pull", "Pulling Docker image "+image, 30)
-			return
-		}
-		notify("pull", fmt.Sprintf("Pulling Docker image %s (attempt %d/%d)", image, attempt, attempts), 30+(attempt-1)*5)
-	}); err != nil {
+	policy := profile.PullPolicy.forAction(action)
+	if err := s.prePullImages(ctx, dockerBin, profile, image, policy, notify); err != nil {
 		return err
 	}
+	if profile.DataDir != "" {
+		if err := migrateProfileVolumes(ctx, profile, notify); err != nil {
+			return err
+		}
+	}
 
 	notify("up", "Starting containers", 60)
+	output := jobOutputFrom(ctx)
 	var lastErr error
 	for attempt := 1; attempt <= 3; attempt++ {
-		cmd := dockerCommandWithContext(ctx, dockerBin, "compose", "-p", project, "-f", "compose.yaml", "up", "-d", "--build")
+		cmd, err := composeCommandWithContext(ctx, composeUpArgs(project, policy)...)
+		if err != nil {
+			return err
+		}
 		cmd.Dir = composeDir
-		out, err := cmd.CombinedOutput()
+		out, err := runCommandStreamed(cmd, func(line string) { output("compose", line) })
 		if err == nil {
 			logInfo("compose_up_succeeded", map[string]any{
 				"profile_id": profile.ID,
@@ -300,6 +328,9 @@ func runProfileComposeUp(ctx context.Context, profile ProfileRequest, onProgress
 			if attempt > 1 {
 				logInfo("compose_up_retry_succeeded", map[string]any{"profile_id": profile.ID, "attempt": attempt})
 			}
+			if err := s.setProfileContainerRuntime(ctx, profile.ID, containerRuntimeName()); err != nil {
+				logWarn("profile_runtime_record_failed", map[string]any{"profile_id": profile.ID, "error": err.Error()})
+			}
 			notify("up", "Containers started; validating health", 78)
 			return nil
 		}
@@ -311,7 +342,9 @@ func runProfileComposeUp(ctx context.Context, profile ProfileRequest, onProgress
 			"error":      strings.TrimSpace(string(out)),
 		})
 		if attempt < 3 {
-			time.Sleep(time.Duration(attempt) * 2 * time.Second)
+			if err := sleepContext(ctx, time.Duration(attempt)*2*time.Second); err != nil {
+				return err
+			}
 		}
 	}
 	if lastErr != nil {
@@ -322,7 +355,7 @@ func runProfileComposeUp(ctx context.Context, profile ProfileRequest, onProgress
 
 func waitForProfileHealthOrCanceled(ctx context.Context, profile ProfileRequest, attempts int, sleep time.Duration) bool {
 	for i := 0; i < attempts; i++ {
-		if isProfileHealthy(profile) {
+		if isProfileHealthy(ctx, profile) {
 			return true
 		}
 		if i < attempts-1 {
@@ -336,6 +369,18 @@ func waitForProfileHealthOrCanceled(ctx context.Context, profile ProfileRequest,
 	return false
 }
 
+// sleepContext pauses for d, returning ctx.Err() early if ctx ends first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
 func runProfileComposeDown(ctx context.Context, id string, removeVolumes bool) error {
 	composeDir := profileComposeDir(id)
 	if _, err := os.Stat(filepath.Join(composeDir, "compose.yaml")); err != nil {
@@ -344,29 +389,145 @@ func runProfileComposeDown(ctx context.Context, id string, removeVolumes bool) e
 		}
 		return err
 	}
-	args := []string{"compose", "-p", dockerProjectName(id), "-f", "compose.yaml", "down"}
+	args := []string{"-p", dockerProjectName(id), "-f", "compose.yaml", "down"}
 	if removeVolumes {
 		args = append(args, "--volumes", "--remove-orphans")
 	}
-	dockerBin, err := dockerBinaryPath()
+	cmd, err := composeCommandWithContext(ctx, args...)
 	if err != nil {
 		return err
 	}
-	cmd := dockerCommandWithContext(ctx, dockerBin, args...)
 	cmd.Dir = composeDir
-	out, err := cmd.CombinedOutput()
+	output := jobOutputFrom(ctx)
+	out, err := runCommandStreamed(cmd, func(line string) { output("compose", line) })
 	if err != nil {
 		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
 	}
 	return nil
 }
 
-func pullImageWithRetry(ctx context.Context, dockerBin, image string, attempts int, onAttempt func(attempt, attempts int)) error {
+// resolveProfilePlatform checks before the pull that the tag has an image
+// the host can run. On arm64 hosts a tag without an arm64 image switches
+// the profile to the amd64 image, saved as its platform override, instead
+// of failing the pull. An unreachable registry decides nothing.
+func (s *Server) resolveProfilePlatform(ctx context.Context, profile ProfileRequest, notify composeProgressFn) (ProfileRequest, error) {
+	version := strings.TrimSpace(profile.Version)
+	if version == "" {
+		version = "latest"
+	}
+	tag, ok, err := fetchRegistryTag(ctx, version)
+	if err != nil || !ok {
+		return profile, nil
+	}
+	platform, runnable := platformForTag(profile, tag)
+	if !runnable {
+		return profile, fmt.Errorf("Kimmio %s has no image for linux/%s; choose another version", version, profileArch(profile))
+	}
+	if platform == profile.Platform {
+		return profile, nil
+	}
+	if err := s.setProfilePlatform(ctx, profile.ID, platform); err != nil {
+		return profile, err
+	}
+	logInfo("profile_platform_auto_set", map[string]any{"profile_id": profile.ID, "version": version, "platform": platform})
+	profile.Platform = platform
+	notify("prepare", fmt.Sprintf("Kimmio %s has no linux/%s image; using %s. %s", version, hostArch(), platform, emulationGuidance()), 20)
+	return profile, nil
+}
+
+func (s *Server) setProfilePlatform(ctx context.Context, id, platform string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	store, err := s.loadStoreLocked(ctx)
+	if err != nil {
+		return err
+	}
+	idx := findProfileIndex(store, id)
+	if idx < 0 {
+		return ErrProfileNotFound
+	}
+	store.Profiles[idx].Platform = platform
+	store.Profiles[idx].Revision++
+	return s.writeStoreLocked(store)
+}
+
+// pullProfileImage pulls the profile's image, reporting layer progress and an
+// ETA based on earlier pulls, and records how long the pull took.
+func (s *Server) pullProfileImage(ctx context.Context, dockerBin string, profile ProfileRequest, image string, notify composeProgressFn) error {
+	version := strings.TrimSpace(profile.Version)
+	if version == "" {
+		version = "latest"
+	}
+	var bytes int64
+	if tag, ok, err := fetchRegistryTag(ctx, version); err == nil && ok {
+		bytes = tag.FullSize
+		digests := []string{tag.Digest}
+		if img, ok := tag.imageForPlatform(profileArch(profile)); ok {
+			bytes = img.Size
+			digests = append(digests, img.Digest)
+		}
+		// The local image already matches the registry: pulling would
+		// only compare layers.
+		for _, digest := range digests {
+			if digest != "" && localImageHasDigest(ctx, image, digest) {
+				notify("pull", "Image "+image+" is up to date", 55)
+				return nil
+			}
+		}
+	}
+	eta := time.Duration(0)
+	if store, err := s.readStore(ctx); err == nil {
+		eta = estimatePullDuration(store.PullStats, version, bytes)
+	}
+	label := "Pulling Docker image " + image + " (can take several minutes)"
+	if eta > 0 {
+		label = fmt.Sprintf("Pulling Docker image %s (%s based on previous pulls)", image, formatETA(eta))
+	} else if bytes > 0 {
+		label = fmt.Sprintf("Pulling Docker image %s (%s, can take several minutes)", image, formatBytes(bytes))
+	}
+	if isEmulated(profile) {
+		label += ". " + emulationGuidance()
+	}
+	notify("pull", label, 30)
+
+	started := time.Now()
+	result, err := pullImageWithRetry(ctx, dockerBin, image, profile.Platform, 3, func(attempt, attempts int) {
+		if attempts <= 1 {
+			notify("pull", label, 30)
+			return
+		}
+		notify("pull", fmt.Sprintf("Pulling Docker image %s (attempt %d/%d)", image, attempt, attempts), 30+(attempt-1)*5)
+	}, func(p *pullProgress) {
+		if len(p.layers) > 0 {
+			notify("pull", label+": "+p.describe(), p.within(pullProgressStart, pullProgressEnd))
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if result.upToDate || result.existing == len(result.layers) {
+		return nil
+	}
+	s.recordPullStat(context.WithoutCancel(ctx), PullStat{
+		Version:    version,
+		Bytes:      bytes,
+		DurationMs: time.Since(started).Milliseconds(),
+		PulledAt:   time.Now().UTC().Format(time.RFC3339),
+	})
+	return nil
+}
+
+func pullImageWithRetry(ctx context.Context, dockerBin, image, platform string, attempts int, onAttempt func(attempt, attempts int), onProgress func(*pullProgress)) (*pullProgress, error) {
 	if attempts < 1 {
 		attempts = 1
 	}
 	var lastErr error
+	gate := pauseGateFrom(ctx)
+	output := jobOutputFrom(ctx)
 	for attempt := 1; attempt <= attempts; attempt++ {
+		if err := gate.wait(ctx); err != nil {
+			return nil, err
+		}
 		if onAttempt != nil {
 			onAttempt(attempt, attempts)
 		}
@@ -375,29 +536,100 @@ func pullImageWithRetry(ctx context.Context, dockerBin, image string, attempts i
 			"attempt": attempt,
 			"total":   attempts,
 		})
-		cmd := dockerCommandWithContext(ctx, dockerBin, "pull", image)
-		out, err := cmd.CombinedOutput()
+		progress := newPullProgress()
+		pullCtx, done := gate.start(ctx)
+		out, err := runDockerPull(pullCtx, dockerBin, image, platform, func(line string) {
+			output(image, line)
+			if progress.parse(line) && onProgress != nil {
+				onProgress(progress)
+			}
+		})
+		done()
+		if err != nil && ctx.Err() == nil && gate.isPaused() {
+			// Paused: the same attempt starts again on resume.
+			attempt--
+			continue
+		}
 		if err == nil {
 			logInfo("docker_pull_succeeded", map[string]any{
-				"image":   image,
-				"attempt": attempt,
+				"image":      image,
+				"attempt":    attempt,
+				"layers":     len(progress.layers),
+				"up_to_date": progress.upToDate,
 			})
-			return nil
+			return progress, nil
+		}
+		lastErr = err
+		var ee EngineError
+		if !errors.As(err, &ee) {
+			lastErr = fmt.Errorf("%w: %s", err, strings.TrimSpace(out))
 		}
-		lastErr = fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
 		logWarn("docker_pull_attempt_failed", map[string]any{
 			"image":   image,
 			"attempt": attempt,
-			"error":   strings.TrimSpace(string(out)),
+			"error":   strings.TrimSpace(out),
 		})
 		if attempt < attempts {
-			time.Sleep(time.Duration(attempt) * 2 * time.Second)
+			if err := sleepContext(ctx, time.Duration(attempt)*2*time.Second); err != nil {
+				return nil, err
+			}
 		}
 	}
 	if lastErr != nil {
-		return fmt.Errorf("%s", friendlyDockerError(lastErr.Error()))
+		return nil, fmt.Errorf("%s", dockerErrorMessage(lastErr))
+	}
+	return nil, fmt.Errorf("failed to pull image")
+}
+
+// runDockerPull streams docker pull output line by line and returns the
+// combined output for error reporting. With a bandwidth limit set it pulls
+// through the limiting proxy when the daemon can reach it.
+func runDockerPull(ctx context.Context, dockerBin, image, platform string, onLine func(string)) (string, error) {
+	out, err := pullThroughProxy(ctx, dockerBin, image, platform, onLine)
+	if err == nil || ctx.Err() != nil {
+		return out, err
+	}
+	if !errors.Is(err, errPullLimitUnavailable) {
+		logWarn("pull_limit_failed", map[string]any{"image": image, "error": strings.TrimSpace(out + " " + err.Error())})
 	}
-	return fmt.Errorf("failed to pull image")
+	return runDockerPullRef(ctx, dockerBin, image, platform, onLine)
+}
+
+// runDockerPullRef pulls through the Engine API, falling back to the
+// docker CLI where the API is not reachable or the registry wants
+// credentials only the CLI knows.
+func runDockerPullRef(ctx context.Context, dockerBin, image, platform string, onLine func(string)) (string, error) {
+	if engine, err := newDockerEngine(); err == nil {
+		out, err := engine.pullImage(ctx, image, platform, onLine)
+		if !isEngineAuthError(err) || dockerBin == "" {
+			return out, err
+		}
+	}
+	if dockerBin == "" {
+		return "", errors.New("docker binary not found")
+	}
+	args := []string{"pull", image}
+	if platform != "" {
+		args = []string{"pull", "--platform", platform, image}
+	}
+	cmd := dockerCommandWithContext(ctx, dockerBin, args...)
+	var stdoutText, stderrText strings.Builder
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+	cmd.Stderr = &stderrText
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		line := scanner.Text()
+		stdoutText.WriteString(line + "\n")
+		onLine(line)
+	}
+	err = cmd.Wait()
+	return stdoutText.String() + stderrText.String(), err
 }
 
 func isFirstProfileInstall(profileID string) bool {
@@ -409,12 +641,17 @@ func isFirstProfileInstall(profileID string) bool {
 func friendlyDockerError(raw string) string {
 	msg := strings.ToLower(strings.TrimSpace(raw))
 	switch {
-	case strings.Contains(msg, "cannot connect to the docker daemon"):
-		return "Docker daemon is not reachable. Start Docker Desktop (or Docker service) and try again."
+	case strings.Contains(msg, "cannot connect to the docker daemon"),
+		strings.Contains(msg, "permission denied while trying to connect to the docker daemon"),
+		// Windows reports a missing engine pipe instead.
+		strings.Contains(msg, "error during connect"), strings.Contains(msg, "pipe/docker"):
+		return dockerUnreachableError()
+	case strings.Contains(msg, "no matching manifest for windows"), strings.Contains(msg, "image operating system \"linux\" cannot be used on this platform"):
+		return "Docker Desktop is set to Windows containers. Right-click its tray icon and choose Switch to Linux containers, then retry."
 	case strings.Contains(msg, "pull access denied"), strings.Contains(msg, "manifest unknown"), strings.Contains(msg, "not found"):
 		return "Unable to pull Kimmio image tag. Verify the selected version exists and try again."
 	case strings.Contains(msg, "port is already allocated"), strings.Contains(msg, "address already in use"):
-		return "Host port is already in use by another process. Choose another profile port."
+		return portConflictMessage(raw)
 	case strings.Contains(msg, "no space left on device"):
 		return "Not enough disk space for Docker image/containers. Free up space and retry."
 	case strings.Contains(msg, "context deadline exceeded"), strings.Contains(msg, "timeout"):
@@ -438,11 +675,37 @@ func dockerProjectName(id string) string {
 	return "kimmio-" + strings.Trim(clean, "-")
 }
 
-func buildComposeYAML() string {
-	return `services:
+// buildComposeYAML renders the stack. A non-empty platform pins the app
+// image, which Docker then runs under emulation when it differs from the
+// host architecture.
+func buildComposeYAML(profile ProfileRequest) string {
+	platformLine := ""
+	if profile.Platform != "" {
+		platformLine = "    platform: " + profile.Platform + "\n"
+	}
+	mtu := effectiveNetworkMTU(profile.Network)
+	publicOptions := composeNetworkOptions(profile.Network.PublicSubnet, mtu)
+	internalOptions := composeNetworkOptions(profile.Network.InternalSubnet, mtu)
+	// Docker cannot publish ports from an internal network, so host mode
+	// relies on the loopback binding instead.
+	internalLine := "    internal: true\n"
+	if profile.Network.HostMode {
+		internalLine = ""
+	}
+	return `x-launcher-labels: &launcher-labels
+  ` + labelManagedBy + `: ` + managedByLauncher + `
+  ` + labelProfileID + `: ${KIMMIO_PROFILE_ID}
+
+x-launcher-service-labels: &launcher-service-labels
+  ` + labelManagedBy + `: ` + managedByLauncher + `
+  ` + labelProfileID + `: ${KIMMIO_PROFILE_ID}
+  ` + labelLauncherVersion + `: ${KIMMIO_LAUNCHER_VERSION}
+
+services:
   kimmio_app:
     image: ${KIMMIO_APP_IMAGE}
-    restart: always
+` + platformLine + `    restart: always
+    labels: *launcher-service-labels
     depends_on:
       - postgres
       - redis
@@ -466,22 +729,26 @@ func buildComposeYAML() string {
       POSTGRES_USER: ${POSTGRES_USER}
       POSTGRES_PASSWORD: ${POSTGRES_PASSWORD}
       POSTGRES_DB: ${POSTGRES_DB}
-      ALLOW_LOCALHOST_DOMAIN_IN_PROD: true
+      SMTP_HOST: ${SMTP_HOST}
+      SMTP_PORT: ${SMTP_PORT}
+      SMTP_USER: ${SMTP_USER}
+      SMTP_PASSWORD: ${SMTP_PASSWORD}
+      SMTP_FROM: ${SMTP_FROM}
+      SMTP_SECURITY: ${SMTP_SECURITY}
+      SSO_PROVIDER: ${SSO_PROVIDER}
+      SSO_CLIENT_ID: ${SSO_CLIENT_ID}
+      SSO_CLIENT_SECRET: ${SSO_CLIENT_SECRET}
+      SSO_ISSUER_URL: ${SSO_ISSUER_URL}
+      SSO_REDIRECT_URL: ${SSO_REDIRECT_URL}
+      PUBLIC_URL: ${PUBLIC_URL}
+      WEBSOCKET_URL: ${WEBSOCKET_URL}
+      TLS_CERT_FILE: ${TLS_CERT_FILE}
+      TLS_KEY_FILE: ${TLS_KEY_FILE}
+` + composeAppEnvironment(profile) + composeLocaleEnvironment(true) + `      ALLOW_LOCALHOST_DOMAIN_IN_PROD: true
       ALLOW_HTTP_DOMAIN_IN_PROD: true
-    ports:
-      - "${APP_PORT}:${APP_PORT}"
-    networks:
-      - public
-      - internal
-    volumes:
-      - kimmio_data:/app/.data
-      - kimmio_run:/app/.run
-    healthcheck:
-      test: [ "CMD", "wget", "-qO-", "http://localhost:$${APP_PORT}/health" ]
-      interval: 30s
-      timeout: 5s
-      retries: 5
-    deploy:
+` + composeAppNetworking(profile.Network) + `    volumes:
+` + composeDataVolumeFor(profile, "kimmio_data") + `      - kimmio_run:/app/.run
+` + composeTLSVolumes(profile) + composeHealthcheck(profile) + `    deploy:
       resources:
         limits:
           cpus: "${CPU_LIMIT}"
@@ -491,51 +758,53 @@ func buildComposeYAML() string {
           memory: 256M
 
   postgres:
-    image: pgvector/pgvector:pg16
+    image: ` + postgresImage + `
     restart: always
+    labels: *launcher-service-labels
+    command: [ "postgres", "-c", "timezone=${TZ}", "-c", "log_timezone=${TZ}" ]
     environment:
       POSTGRES_USER: ${POSTGRES_USER}
       POSTGRES_PASSWORD: ${POSTGRES_PASSWORD}
       POSTGRES_DB: ${POSTGRES_DB}
-    networks:
+` + composeLocaleEnvironment(false) + `` + composeLoopbackPort(profile.Network, "POSTGRES_PORT", "5432") + `    networks:
       - internal
     volumes:
-      - postgres_data:/var/lib/postgresql/data
-    healthcheck:
+` + composeDataVolumeFor(profile, "postgres_data") + `    healthcheck:
       test: [ "CMD-SHELL", "pg_isready -U $${POSTGRES_USER}" ]
       interval: 10s
       timeout: 5s
       retries: 5
 
   redis:
-    image: redis:7.2
+    image: ` + redisImage + `
     restart: always
+    labels: *launcher-service-labels
     command: >
       redis-server
       --appendonly yes
       --requirepass ${REDIS_PASSWORD}
-    networks:
+    environment:
+` + composeLocaleEnvironment(true) + `` + composeLoopbackPort(profile.Network, "REDIS_PORT", "6379") + `    networks:
       - internal
     volumes:
-      - redis_data:/data
-    healthcheck:
+` + composeDataVolumeFor(profile, "redis_data") + `    healthcheck:
       test: [ "CMD", "redis-cli", "-a", "${REDIS_PASSWORD}", "ping" ]
       interval: 10s
       timeout: 3s
       retries: 5
 
   minio:
-    image: minio/minio:RELEASE.2024-01-31T20-20-33Z
+    image: ` + minioImage + `
     restart: always
+    labels: *launcher-service-labels
     command: server /data --console-address ":9001"
     environment:
       MINIO_ROOT_USER: ${MINIO_ROOT_USER}
       MINIO_ROOT_PASSWORD: ${MINIO_ROOT_PASSWORD}
-    networks:
+` + composeLocaleEnvironment(true) + composeLoopbackPort(profile.Network, "MINIO_ROOT_PORT", "9000") + `    networks:
       - internal
     volumes:
-      - minio_data:/data
-    healthcheck:
+` + composeDataVolumeFor(profile, "minio_data") + `    healthcheck:
       test: [ "CMD", "curl", "-f", "http://localhost:9000/minio/health/live" ]
       interval: 30s
       timeout: 5s
@@ -544,22 +813,12 @@ func buildComposeYAML() string {
 networks:
   public:
     driver: bridge
-  internal:
+    labels: *launcher-labels
+` + publicOptions + `  internal:
     driver: bridge
-    internal: true
-
-volumes:
-  postgres_data:
-    name: ${INSTANCE_ID}_postgres_data
-  redis_data:
-    name: ${INSTANCE_ID}_redis_data
-  kimmio_data:
-    name: ${INSTANCE_ID}_kimmio_data
-  kimmio_run:
-    name: ${INSTANCE_ID}_kimmio_run
-  minio_data:
-    name: ${INSTANCE_ID}_minio_data
-`
+    labels: *launcher-labels
+` + internalOptions + internalLine + `
+` + composeVolumeDefinitions(profile)
 }
 
 func buildComposeEnv(profile ProfileRequest) string {
@@ -613,6 +872,20 @@ func buildComposeEnv(profile ProfileRequest) string {
 	domainEnv := appDomain
 	if strings.EqualFold(strings.TrimSpace(appDomain), "localhost") {
 		domainEnv = "http://localhost:" + strconv.Itoa(hostPort)
+	} else if profile.TLS.configured() {
+		domainEnv = "https://" + strings.TrimSpace(appDomain)
+	}
+	postgresHost, postgresPort := "postgres", "5432"
+	redisHost, redisPort := "redis", "6379"
+	minioHost, minioPort := "minio", "9000"
+	if profile.Network.HostMode {
+		postgresHost, postgresPort = "127.0.0.1", strconv.Itoa(hostPort+1)
+		redisHost, redisPort = "127.0.0.1", strconv.Itoa(hostPort+2)
+		minioHost, minioPort = "127.0.0.1", strconv.Itoa(hostPort+3)
+	}
+	smtpPort := ""
+	if profile.SMTP.Port > 0 {
+		smtpPort = strconv.Itoa(profile.SMTP.Port)
 	}
 	lines := []string{
 		"JWT_SECRET=" + jwtSecret,
@@ -623,21 +896,37 @@ func buildComposeEnv(profile ProfileRequest) string {
 		"DOMAIN=" + domainEnv,
 		"WEBSOCKET_PORT=" + envValue(mergedEnv, "WEBSOCKET_PORT", strconv.Itoa(hostPort)),
 		"KIMMIO_APP_IMAGE=kimmio/kimmio-app:" + version,
+		"KIMMIO_PROFILE_ID=" + profile.ID,
+		"KIMMIO_LAUNCHER_VERSION=" + launcherAppVersion,
 		"POSTGRES_USER=" + envValue(mergedEnv, "POSTGRES_USER", "postgres"),
 		"POSTGRES_PASSWORD=" + envValue(mergedEnv, "POSTGRES_PASSWORD", "postgres"),
-		"POSTGRES_HOST=" + envValue(mergedEnv, "POSTGRES_HOST", "postgres"),
+		"POSTGRES_HOST=" + envValue(mergedEnv, "POSTGRES_HOST", postgresHost),
 		"POSTGRES_DB=" + envValue(mergedEnv, "POSTGRES_DB", profile.ID),
-		"POSTGRES_PORT=" + envValue(mergedEnv, "POSTGRES_PORT", "5432"),
-		"REDIS_HOST=" + envValue(mergedEnv, "REDIS_HOST", "redis"),
-		"REDIS_PORT=" + envValue(mergedEnv, "REDIS_PORT", "6379"),
+		"POSTGRES_PORT=" + envValue(mergedEnv, "POSTGRES_PORT", postgresPort),
+		"REDIS_HOST=" + envValue(mergedEnv, "REDIS_HOST", redisHost),
+		"REDIS_PORT=" + envValue(mergedEnv, "REDIS_PORT", redisPort),
 		"REDIS_PASSWORD=" + envValue(mergedEnv, "REDIS_PASSWORD", profile.ID+"_redis_pw"),
 		"MINIO_ROOT_USER=" + envValue(mergedEnv, "MINIO_ROOT_USER", "minio_"+base),
 		"MINIO_ROOT_PASSWORD=" + envValue(mergedEnv, "MINIO_ROOT_PASSWORD", profile.ID+"_minio_pw"),
-		"MINIO_ROOT_HOST=" + envValue(mergedEnv, "MINIO_ROOT_HOST", "minio"),
-		"MINIO_ROOT_PORT=" + envValue(mergedEnv, "MINIO_ROOT_PORT", "9000"),
+		"MINIO_ROOT_HOST=" + envValue(mergedEnv, "MINIO_ROOT_HOST", minioHost),
+		"MINIO_ROOT_PORT=" + envValue(mergedEnv, "MINIO_ROOT_PORT", minioPort),
+		"SMTP_HOST=" + envValue(mergedEnv, "SMTP_HOST", profile.SMTP.Host),
+		"SMTP_PORT=" + envValue(mergedEnv, "SMTP_PORT", smtpPort),
+		"SMTP_USER=" + envValue(mergedEnv, "SMTP_USER", profile.SMTP.Username),
+		"SMTP_PASSWORD=" + envValue(mergedEnv, smtpPasswordKey, ""),
+		"SMTP_FROM=" + envValue(mergedEnv, "SMTP_FROM", profile.SMTP.From),
+		"SMTP_SECURITY=" + envValue(mergedEnv, "SMTP_SECURITY", profile.SMTP.Security),
+		"TZ=" + profileTimeZone(profile),
+		"LANG=" + profileLocale(profile),
 		"MEMORY_LIMIT=" + mem,
 		"CPU_LIMIT=" + fmt.Sprintf("%.2f", cpus),
 	}
+	lines = append(lines, ssoEnvLines(profile, mergedEnv)...)
+	lines = append(lines, tlsEnvLines(profile, mergedEnv)...)
+	lines = append(lines, appEnvLines(profile, mergedEnv)...)
+	if profile.DataDir != "" {
+		lines = append(lines, "KIMMIO_DATA_DIR="+profile.DataDir)
+	}
 
 	return strings.Join(lines, "\n") + "\n"
 }
@@ -661,16 +950,3 @@ func envValue(values map[string]string, key, fallback string) string {
 	}
 	return fallback
 }
-
-func waitForProfileHealth(profile ProfileRequest, attempts int, sleep time.Duration) bool {
-	if attempts <= 0 {
-		attempts = 1
-	}
-	for i := 0; i < attempts; i++ {
-		if isProfileHealthy(profile) {
-			return true
-		}
-		time.Sleep(sleep)
-	}
-	return false
-}
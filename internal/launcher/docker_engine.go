@@ -0,0 +1,295 @@
+package launcher
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// The launcher talks to the Docker Engine API directly for pulls, image
+// checks, daemon info, container inspect, exec and stats, and volumes, so
+// pulls stream structured progress and failures come back as errors with a
+// status code instead of CLI text. Compose has no Engine API, so stacks are
+// still brought up and down, and their containers listed, with the docker
+// binary. Logs, disk usage, events, the helper containers that copy data
+// and the daemon version also still go through it.
+//
+// Only plain unix:// and tcp:// daemons are spoken to directly. Windows
+// named pipes, ssh:// and TLS daemons return errEngineUnsupported and the
+// callers fall back to the docker CLI.
+
+const dockerEngineAPIVersion = "v1.41"
+
+var errEngineUnsupported = errors.New("docker engine API not supported for this DOCKER_HOST")
+
+// EngineError is an error reported by the Docker daemon.
+type EngineError struct {
+	// StatusCode is the HTTP status of the API response, or 0 for an error
+	// reported in the middle of a stream such as a pull.
+	StatusCode int
+	Message    string
+}
+
+func (e EngineError) Error() string {
+	return e.Message
+}
+
+type dockerEngine struct {
+	client *http.Client
+	base   string
+}
+
+// newDockerEngine returns a client for the daemon docker commands use.
+// Swappable so tests can point it at a fake daemon.
+var newDockerEngine = func() (*dockerEngine, error) {
+	return dockerEngineFor(effectiveDockerHost())
+}
+
+func dockerEngineFor(host string) (*dockerEngine, error) {
+	scheme, rest, _ := strings.Cut(host, "://")
+	switch {
+	case scheme == "unix" && rest != "":
+		dialer := &net.Dialer{Timeout: 5 * time.Second}
+		transport := &http.Transport{DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", rest)
+		}}
+		return &dockerEngine{client: &http.Client{Transport: transport}, base: "http://docker"}, nil
+	case scheme == "tcp" && rest != "" && os.Getenv("DOCKER_TLS_VERIFY") == "":
+		return &dockerEngine{client: &http.Client{}, base: "http://" + rest}, nil
+	default:
+		return nil, errEngineUnsupported
+	}
+}
+
+func (e *dockerEngine) do(ctx context.Context, method, path string, query url.Values) (*http.Response, error) {
+	return e.send(ctx, method, path, query, nil)
+}
+
+// send is do with a JSON request body; a nil body sends none.
+func (e *dockerEngine) send(ctx context.Context, method, path string, query url.Values, body any) (*http.Response, error) {
+	u := e.base + "/" + dockerEngineAPIVersion + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		return nil, readEngineError(resp)
+	}
+	return resp, nil
+}
+
+func readEngineError(resp *http.Response) error {
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var body struct {
+		Message string `json:"message"`
+	}
+	msg := strings.TrimSpace(string(raw))
+	if json.Unmarshal(raw, &body) == nil && body.Message != "" {
+		msg = body.Message
+	}
+	if msg == "" {
+		msg = resp.Status
+	}
+	return EngineError{StatusCode: resp.StatusCode, Message: msg}
+}
+
+func (e *dockerEngine) getJSON(ctx context.Context, path string, out any) error {
+	resp, err := e.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (e *dockerEngine) ping(ctx context.Context) error {
+	resp, err := e.do(ctx, http.MethodGet, "/_ping", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// engineInfo is the part of GET /info the launcher reads.
+type engineInfo struct {
+	NCPU            int    `json:"NCPU"`
+	MemTotal        int64  `json:"MemTotal"`
+	Architecture    string `json:"Architecture"`
+	OperatingSystem string `json:"OperatingSystem"`
+	DockerRootDir   string `json:"DockerRootDir"`
+	ServerVersion   string `json:"ServerVersion"`
+}
+
+func (e *dockerEngine) info(ctx context.Context) (engineInfo, error) {
+	var info engineInfo
+	err := e.getJSON(ctx, "/info", &info)
+	return info, err
+}
+
+// engineImage is the part of GET /images/<name>/json the launcher reads.
+type engineImage struct {
+	ID          string   `json:"Id"`
+	RepoDigests []string `json:"RepoDigests"`
+}
+
+func (e *dockerEngine) inspectImage(ctx context.Context, image string) (engineImage, error) {
+	var img engineImage
+	err := e.getJSON(ctx, "/images/"+image+"/json", &img)
+	return img, err
+}
+
+func (e *dockerEngine) tagImage(ctx context.Context, source, target string) error {
+	repo, tag := splitImageTag(target)
+	resp, err := e.do(ctx, http.MethodPost, "/images/"+source+"/tag", url.Values{"repo": {repo}, "tag": {tag}})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (e *dockerEngine) removeImage(ctx context.Context, image string) error {
+	resp, err := e.do(ctx, http.MethodDelete, "/images/"+image, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// enginePullMessage is one line of the JSON stream POST /images/create
+// answers with.
+type enginePullMessage struct {
+	ID             string `json:"id"`
+	Status         string `json:"status"`
+	ProgressDetail struct {
+		Current int64  `json:"current"`
+		Total   int64  `json:"total"`
+		Units   string `json:"units"`
+	} `json:"progressDetail"`
+	Error       string `json:"error"`
+	ErrorDetail struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errorDetail"`
+}
+
+// pullImage pulls image and hands each progress message to onLine in the
+// form docker pull prints it ("<layer>: <status>"), so the same progress
+// parsing serves both. Downloading and Extracting messages end with their
+// sizes ("12.5 MiB/45.2 MiB"). It returns the messages for error reporting.
+func (e *dockerEngine) pullImage(ctx context.Context, image, platform string, onLine func(string)) (string, error) {
+	repo, tag := splitImageTag(image)
+	query := url.Values{"fromImage": {repo}, "tag": {tag}}
+	if platform != "" {
+		query.Set("platform", platform)
+	}
+	resp, err := e.do(ctx, http.MethodPost, "/images/create", query)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var out strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var msg enginePullMessage
+		if json.Unmarshal(scanner.Bytes(), &msg) != nil {
+			continue
+		}
+		if msg.Error != "" || msg.ErrorDetail.Message != "" {
+			text := msg.ErrorDetail.Message
+			if text == "" {
+				text = msg.Error
+			}
+			out.WriteString(text + "\n")
+			return out.String(), EngineError{StatusCode: msg.ErrorDetail.Code, Message: text}
+		}
+		line := msg.Status
+		if d := msg.ProgressDetail; d.Total > 0 && d.Units == "" {
+			line += " " + formatBytes(d.Current) + "/" + formatBytes(d.Total)
+		}
+		if msg.ID != "" {
+			line = msg.ID + ": " + line
+		}
+		out.WriteString(line + "\n")
+		onLine(line)
+	}
+	if err := scanner.Err(); err != nil {
+		return out.String(), err
+	}
+	return out.String(), ctx.Err()
+}
+
+// splitImageTag splits "repo:tag" and defaults the tag to latest. A digest
+// reference stays whole in repo.
+func splitImageTag(image string) (string, string) {
+	if strings.Contains(image, "@") {
+		return image, ""
+	}
+	slash := strings.LastIndex(image, "/")
+	if i := strings.LastIndex(image, ":"); i > slash {
+		return image[:i], image[i+1:]
+	}
+	return image, "latest"
+}
+
+// isEngineAuthError reports a pull the daemon refused for lack of
+// credentials. The docker CLI reads credential helpers the API cannot, so
+// such pulls are retried with it.
+func isEngineAuthError(err error) bool {
+	var ee EngineError
+	if !errors.As(err, &ee) {
+		return false
+	}
+	msg := strings.ToLower(ee.Message)
+	return ee.StatusCode == http.StatusUnauthorized || strings.Contains(msg, "unauthorized") || strings.Contains(msg, "authentication required")
+}
+
+// dockerErrorMessage explains a failed docker operation, using the status
+// code of an Engine API error when there is one.
+func dockerErrorMessage(err error) string {
+	var ee EngineError
+	var opErr *net.OpError
+	switch {
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		return dockerUnreachableError()
+	case errors.As(err, &ee) && ee.StatusCode == http.StatusNotFound:
+		return "Unable to pull Kimmio image tag. Verify the selected version exists and try again."
+	case errors.As(err, &ee):
+		return friendlyDockerError(ee.Message)
+	case err == nil:
+		return ""
+	default:
+		return friendlyDockerError(fmt.Sprint(err))
+	}
+}
@@ -0,0 +1,200 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// Container and volume calls of the Engine API. Callers fall back to the
+// docker CLI when newDockerEngine reports the daemon unsupported.
+
+// engineContainer is one entry of GET /containers/json.
+type engineContainer struct {
+	ID     string            `json:"Id"`
+	Names  []string          `json:"Names"`
+	Labels map[string]string `json:"Labels"`
+}
+
+// listContainers returns the running containers carrying every label, each
+// given as "key=value".
+func (e *dockerEngine) listContainers(ctx context.Context, labels ...string) ([]engineContainer, error) {
+	filters, err := json.Marshal(map[string][]string{"label": labels})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := e.do(ctx, http.MethodGet, "/containers/json", url.Values{"filters": {string(filters)}})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var containers []engineContainer
+	err = json.NewDecoder(resp.Body).Decode(&containers)
+	return containers, err
+}
+
+// inspectContainer decodes GET /containers/<id>/json, the document docker
+// inspect prints, into out.
+func (e *dockerEngine) inspectContainer(ctx context.Context, id string, out any) error {
+	return e.getJSON(ctx, "/containers/"+url.PathEscape(id)+"/json", out)
+}
+
+// execInContainer runs cmd in a running container, as docker exec does,
+// and returns its exit code. The output is discarded.
+func (e *dockerEngine) execInContainer(ctx context.Context, id string, cmd []string) (int, error) {
+	resp, err := e.send(ctx, http.MethodPost, "/containers/"+url.PathEscape(id)+"/exec", nil,
+		map[string]any{"Cmd": cmd, "AttachStdout": true, "AttachStderr": true})
+	if err != nil {
+		return 0, err
+	}
+	var created struct {
+		ID string `json:"Id"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if err != nil {
+		return 0, err
+	}
+	// The attached start answers with the command's output and ends when
+	// the command does.
+	resp, err = e.send(ctx, http.MethodPost, "/exec/"+url.PathEscape(created.ID)+"/start", nil,
+		map[string]any{"Detach": false, "Tty": false})
+	if err != nil {
+		return 0, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	var result struct {
+		ExitCode int  `json:"ExitCode"`
+		Running  bool `json:"Running"`
+	}
+	if err := e.getJSON(ctx, "/exec/"+url.PathEscape(created.ID)+"/json", &result); err != nil {
+		return 0, err
+	}
+	if result.Running {
+		return 0, ctx.Err()
+	}
+	return result.ExitCode, nil
+}
+
+// engineCPUStats is the CPU part of GET /containers/<id>/stats.
+type engineCPUStats struct {
+	CPUUsage struct {
+		TotalUsage  uint64   `json:"total_usage"`
+		PercpuUsage []uint64 `json:"percpu_usage"`
+	} `json:"cpu_usage"`
+	SystemUsage uint64 `json:"system_cpu_usage"`
+	OnlineCPUs  int    `json:"online_cpus"`
+}
+
+// engineStats is the part of GET /containers/<id>/stats the launcher reads.
+type engineStats struct {
+	CPUStats    engineCPUStats `json:"cpu_stats"`
+	PreCPUStats engineCPUStats `json:"precpu_stats"`
+	MemoryStats struct {
+		Usage int64            `json:"usage"`
+		Limit int64            `json:"limit"`
+		Stats map[string]int64 `json:"stats"`
+	} `json:"memory_stats"`
+	Networks map[string]struct {
+		RxBytes int64 `json:"rx_bytes"`
+		TxBytes int64 `json:"tx_bytes"`
+	} `json:"networks"`
+	PidsStats struct {
+		Current int `json:"current"`
+	} `json:"pids_stats"`
+}
+
+// cpuPercent is the CPU use between the two samples, one core counting as
+// 100%, as docker stats computes it.
+func (s engineStats) cpuPercent() float64 {
+	cpuDelta := float64(s.CPUStats.CPUUsage.TotalUsage) - float64(s.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(s.CPUStats.SystemUsage) - float64(s.PreCPUStats.SystemUsage)
+	cpus := s.CPUStats.OnlineCPUs
+	if cpus == 0 {
+		cpus = len(s.CPUStats.CPUUsage.PercpuUsage)
+	}
+	if cpuDelta <= 0 || systemDelta <= 0 {
+		return 0
+	}
+	return cpuDelta / systemDelta * float64(cpus) * 100
+}
+
+// memoryUsed leaves out the page cache the kernel can reclaim, as docker
+// stats does for cgroup v1 and v2.
+func (s engineStats) memoryUsed() int64 {
+	m := s.MemoryStats
+	if v, ok := m.Stats["total_inactive_file"]; ok && v < m.Usage {
+		return m.Usage - v
+	}
+	if v := m.Stats["inactive_file"]; v < m.Usage {
+		return m.Usage - v
+	}
+	return m.Usage
+}
+
+func (s engineStats) network() (rx, tx int64) {
+	for _, n := range s.Networks {
+		rx += n.RxBytes
+		tx += n.TxBytes
+	}
+	return rx, tx
+}
+
+// containerStats reads one sample of each container at once, as docker
+// stats --no-stream does; the daemon takes about a second per sample.
+// Containers that stopped in the meantime are left out.
+func (e *dockerEngine) containerStats(ctx context.Context, ids []string) map[string]engineStats {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	stats := map[string]engineStats{}
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			resp, err := e.do(ctx, http.MethodGet, "/containers/"+url.PathEscape(id)+"/stats", url.Values{"stream": {"false"}})
+			if err != nil {
+				return
+			}
+			defer resp.Body.Close()
+			var st engineStats
+			if json.NewDecoder(resp.Body).Decode(&st) != nil {
+				return
+			}
+			mu.Lock()
+			stats[id] = st
+			mu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+	return stats
+}
+
+// volumeExists reports whether the named volume exists.
+func (e *dockerEngine) volumeExists(ctx context.Context, name string) bool {
+	var volume struct {
+		Name string `json:"Name"`
+	}
+	return e.getJSON(ctx, "/volumes/"+url.PathEscape(name), &volume) == nil
+}
+
+func (e *dockerEngine) createVolume(ctx context.Context, name string, labels map[string]string) error {
+	resp, err := e.send(ctx, http.MethodPost, "/volumes/create", nil, map[string]any{"Name": name, "Labels": labels})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (e *dockerEngine) removeVolume(ctx context.Context, name string) error {
+	resp, err := e.do(ctx, http.MethodDelete, "/volumes/"+url.PathEscape(name), nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
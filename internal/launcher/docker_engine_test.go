@@ -0,0 +1,253 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeDockerDaemon serves handler on a unix socket and returns an engine
+// talking to it.
+func fakeDockerDaemon(t *testing.T, handler http.HandlerFunc) *dockerEngine {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "engine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	sock := filepath.Join(dir, "docker.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	srv := &http.Server{Handler: handler}
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+	engine, err := dockerEngineFor("unix://" + sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return engine
+}
+
+func TestDockerEnginePullStreamsProgress(t *testing.T) {
+	var query string
+	engine := fakeDockerDaemon(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		if r.URL.Path != "/"+dockerEngineAPIVersion+"/images/create" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"status":"Pulling from kimmio/kimmio-app","id":"1.2.0"}
+{"status":"Pulling fs layer","id":"abc"}
+{"status":"Downloading","progressDetail":{"current":1048576,"total":4194304},"progress":"[====>   ]","id":"abc"}
+{"status":"Pull complete","id":"abc"}
+{"status":"Status: Downloaded newer image for kimmio/kimmio-app:1.2.0"}
+`))
+	})
+	progress := newPullProgress()
+	var lines []string
+	if _, err := engine.pullImage(context.Background(), "kimmio/kimmio-app:1.2.0", "linux/amd64", func(line string) {
+		lines = append(lines, line)
+		progress.parse(line)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(lines, "abc: Downloading 1.0 MiB/4.0 MiB") {
+		t.Fatalf("expected the download sizes in the output, got %q", lines)
+	}
+	if done, total := progress.counts(); done != 1 || total != 1 {
+		t.Fatalf("expected one completed layer, got %d/%d", done, total)
+	}
+	if !strings.Contains(query, "fromImage=kimmio%2Fkimmio-app") || !strings.Contains(query, "tag=1.2.0") || !strings.Contains(query, "platform=linux%2Famd64") {
+		t.Fatalf("unexpected pull query %q", query)
+	}
+}
+
+func TestDockerEngineErrorsAreStructured(t *testing.T) {
+	engine := fakeDockerDaemon(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/images/create") {
+			w.Write([]byte(`{"status":"Pulling from private/app"}
+{"errorDetail":{"message":"pull access denied for private/app, repository does not exist"},"error":"pull access denied"}
+`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"No such image: kimmio/kimmio-app:9.9.9"}`))
+	})
+
+	_, err := engine.inspectImage(context.Background(), "kimmio/kimmio-app:9.9.9")
+	var ee EngineError
+	if !errors.As(err, &ee) || ee.StatusCode != http.StatusNotFound || ee.Message != "No such image: kimmio/kimmio-app:9.9.9" {
+		t.Fatalf("expected a 404 engine error, got %#v", err)
+	}
+	if msg := dockerErrorMessage(err); !strings.Contains(msg, "Verify the selected version") {
+		t.Fatalf("unexpected message %q", msg)
+	}
+
+	_, err = engine.pullImage(context.Background(), "private/app", "", func(string) {})
+	if !errors.As(err, &ee) || !strings.Contains(ee.Message, "pull access denied") {
+		t.Fatalf("expected the stream error, got %v", err)
+	}
+	if isEngineAuthError(err) {
+		t.Fatal("a missing repository is not an auth error")
+	}
+	if !isEngineAuthError(EngineError{StatusCode: http.StatusUnauthorized, Message: "unauthorized"}) {
+		t.Fatal("expected 401 to be an auth error")
+	}
+}
+
+func TestDockerEngineFor(t *testing.T) {
+	for _, host := range []string{"npipe:////./pipe/docker_engine", "ssh://user@box", ""} {
+		if _, err := dockerEngineFor(host); !errors.Is(err, errEngineUnsupported) {
+			t.Fatalf("%q: expected the CLI fallback, got %v", host, err)
+		}
+	}
+	if _, err := dockerEngineFor("tcp://127.0.0.1:2375"); err != nil {
+		t.Fatalf("expected plain tcp to be supported, got %v", err)
+	}
+	engine, err := dockerEngineFor("unix:///nonexistent/docker.sock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg := dockerErrorMessage(engine.ping(context.Background())); msg != dockerUnreachableError() {
+		t.Fatalf("expected an unreachable daemon, got %q", msg)
+	}
+}
+
+func TestSplitImageTag(t *testing.T) {
+	cases := map[string][2]string{
+		"kimmio/kimmio-app:1.2.0":       {"kimmio/kimmio-app", "1.2.0"},
+		"redis":                         {"redis", "latest"},
+		"localhost:5000/app":            {"localhost:5000/app", "latest"},
+		"localhost:5000/app:2":          {"localhost:5000/app", "2"},
+		"redis@sha256:0123456789abcdef": {"redis@sha256:0123456789abcdef", ""},
+	}
+	for in, want := range cases {
+		if repo, tag := splitImageTag(in); repo != want[0] || tag != want[1] {
+			t.Fatalf("%s: got %s %s", in, repo, tag)
+		}
+	}
+}
+
+func TestIsDockerRunningAsksTheEngineFirst(t *testing.T) {
+	engine := fakeDockerDaemon(t, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/_ping") {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte("OK"))
+	})
+	prev := newDockerEngine
+	defer func() { newDockerEngine = prev }()
+	newDockerEngine = func() (*dockerEngine, error) { return engine, nil }
+	// Whether or not this machine has a docker command, the daemon answers.
+	if got := IsDockerRunning(); got != "installed" {
+		t.Fatalf("expected a reachable daemon to count as installed, got %q", got)
+	}
+}
+
+func TestDockerEngineExecReturnsExitCode(t *testing.T) {
+	var cmd []string
+	engine := fakeDockerDaemon(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + strings.TrimPrefix(r.URL.Path, "/"+dockerEngineAPIVersion) {
+		case "POST /containers/abc/exec":
+			var body struct{ Cmd []string }
+			json.NewDecoder(r.Body).Decode(&body)
+			cmd = body.Cmd
+			w.Write([]byte(`{"Id":"exec1"}`))
+		case "POST /exec/exec1/start":
+			w.Write([]byte("probe output"))
+		case "GET /exec/exec1/json":
+			w.Write([]byte(`{"Running":false,"ExitCode":3}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	code, err := engine.execInContainer(context.Background(), "abc", []string{"sh", "-c", "exit 3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != 3 || !slices.Equal(cmd, []string{"sh", "-c", "exit 3"}) {
+		t.Fatalf("code %d, cmd %q", code, cmd)
+	}
+}
+
+func TestDockerEngineServiceStats(t *testing.T) {
+	var filters string
+	engine := fakeDockerDaemon(t, func(w http.ResponseWriter, r *http.Request) {
+		switch strings.TrimPrefix(r.URL.Path, "/"+dockerEngineAPIVersion) {
+		case "/containers/json":
+			filters = r.URL.Query().Get("filters")
+			w.Write([]byte(`[{"Id":"c1","Names":["/kimmio-demo-app-1"],"Labels":{"com.docker.compose.service":"app"}},
+{"Id":"c2","Names":["/kimmio-demo-db-1"],"Labels":{"com.docker.compose.service":"db"}}]`))
+		case "/containers/c1/stats":
+			if r.URL.Query().Get("stream") != "false" {
+				t.Errorf("stats query %q", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"cpu_stats":{"cpu_usage":{"total_usage":300},"system_cpu_usage":2000,"online_cpus":2},
+"precpu_stats":{"cpu_usage":{"total_usage":100},"system_cpu_usage":1000},
+"memory_stats":{"usage":5000,"limit":8000,"stats":{"inactive_file":1000}},
+"networks":{"eth0":{"rx_bytes":10,"tx_bytes":20},"eth1":{"rx_bytes":1,"tx_bytes":2}},
+"pids_stats":{"current":7}}`))
+		default:
+			// c2 stopped between the listing and the sample.
+			http.Error(w, `{"message":"No such container"}`, http.StatusNotFound)
+		}
+	})
+	stats, err := engineServiceStats(context.Background(), engine, "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ServiceStats{{Service: "app", Container: "kimmio-demo-app-1", CPUPercent: 40, MemoryBytes: 4000,
+		MemoryLimitBytes: 8000, NetRxBytes: 11, NetTxBytes: 22, PIDs: 7}}
+	if !slices.Equal(stats, want) {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if !strings.Contains(filters, labelProfileID+"=demo") || !strings.Contains(filters, labelManagedBy+"="+managedByLauncher) {
+		t.Fatalf("filters = %s", filters)
+	}
+}
+
+func TestDockerEngineVolumes(t *testing.T) {
+	var created struct {
+		Name   string
+		Labels map[string]string
+	}
+	var removed string
+	engine := fakeDockerDaemon(t, func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/"+dockerEngineAPIVersion)
+		switch {
+		case r.Method == http.MethodPost && path == "/volumes/create":
+			json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{}`))
+		case r.Method == http.MethodDelete:
+			removed = path
+			w.WriteHeader(http.StatusNoContent)
+		case path == "/volumes/present":
+			w.Write([]byte(`{"Name":"present"}`))
+		default:
+			http.Error(w, `{"message":"no such volume"}`, http.StatusNotFound)
+		}
+	})
+	ctx := context.Background()
+	if !engine.volumeExists(ctx, "present") || engine.volumeExists(ctx, "missing") {
+		t.Fatal("volumeExists does not follow the daemon")
+	}
+	if err := engine.createVolume(ctx, "kimmio-demo_pgdata", map[string]string{labelProfileID: "demo"}); err != nil {
+		t.Fatal(err)
+	}
+	if created.Name != "kimmio-demo_pgdata" || created.Labels[labelProfileID] != "demo" {
+		t.Fatalf("created %+v", created)
+	}
+	if err := engine.removeVolume(ctx, "kimmio-demo_pgdata"); err != nil || removed != "/volumes/kimmio-demo_pgdata" {
+		t.Fatalf("removed %q: %v", removed, err)
+	}
+}
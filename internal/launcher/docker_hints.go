@@ -0,0 +1,218 @@
+package launcher
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// On Windows an unreachable Docker daemon is often really WSL 2 missing or
+// Docker Desktop on a backend the machine cannot run; on Linux, a socket
+// the user may not open or a rootless daemon the launcher does not know
+// about. dockerHints looks for those causes, so errors and the system
+// health report name the fix instead of only saying Docker is down.
+
+const (
+	dockerUnreachableMessage = "Docker daemon is not reachable. Start Docker Desktop (or Docker service) and try again."
+	dockerHintTimeout        = 5 * time.Second
+)
+
+// DockerHint is a likely cause of an unreachable Docker daemon.
+type DockerHint struct {
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+func dockerHints() []DockerHint {
+	ctx, cancel := context.WithTimeout(context.Background(), dockerHintTimeout)
+	defer cancel()
+	return platformDockerHints(ctx)
+}
+
+// dockerUnreachableError names the most likely cause when one is found.
+func dockerUnreachableError() string {
+	if hints := dockerHints(); len(hints) > 0 {
+		return "Docker daemon is not reachable. " + hints[0].Message
+	}
+	return dockerUnreachableMessage
+}
+
+// DockerResources are what Docker can use. Under Docker Desktop these are
+// the limits of its VM, not of the machine.
+type DockerResources struct {
+	CPUs            int    `json:"cpus"`
+	MemoryBytes     int64  `json:"memoryBytes"`
+	Arch            string `json:"arch"`
+	OperatingSystem string `json:"operatingSystem"`
+}
+
+func dockerResources(ctx context.Context) (DockerResources, bool) {
+	ctx, cancel := context.WithTimeout(ctx, dockerHintTimeout)
+	defer cancel()
+	out, err := dockerInfoField(ctx, "{{.NCPU}}|{{.MemTotal}}|{{.Architecture}}|{{.OperatingSystem}}", func(info engineInfo) string {
+		return fmt.Sprintf("%d|%d|%s|%s", info.NCPU, info.MemTotal, info.Architecture, info.OperatingSystem)
+	})
+	if err != nil {
+		return DockerResources{}, false
+	}
+	return parseDockerResources(out)
+}
+
+func parseDockerResources(out string) (DockerResources, bool) {
+	parts := strings.SplitN(strings.TrimSpace(out), "|", 4)
+	if len(parts) != 4 {
+		return DockerResources{}, false
+	}
+	cpus, err1 := strconv.Atoi(parts[0])
+	mem, err2 := strconv.ParseInt(parts[1], 10, 64)
+	if err1 != nil || err2 != nil {
+		return DockerResources{}, false
+	}
+	return DockerResources{CPUs: cpus, MemoryBytes: mem, Arch: parts[2], OperatingSystem: parts[3]}, true
+}
+
+// dockerResourceHints flags a Docker too small for one profile at the
+// default limits, and Apple Silicon emulating profiles without Rosetta.
+func dockerResourceHints(res DockerResources, profiles []ProfileRequest) []DockerHint {
+	hints := []DockerHint{}
+	where := "the Docker daemon's configuration"
+	if strings.Contains(res.OperatingSystem, "Docker Desktop") {
+		where = "Docker Desktop > Settings > Resources"
+	}
+	if need := memoryLimitBytes(defaultMemoryLimit); res.MemoryBytes > 0 && res.MemoryBytes < need {
+		hints = append(hints, DockerHint{Check: "docker-memory", Message: fmt.Sprintf("Docker can use %s of memory, less than one profile's default limit of %s. Raise it in %s.", formatBytes(res.MemoryBytes), formatBytes(need), where)})
+	}
+	if res.CPUs > 0 && res.CPUs < 2 {
+		hints = append(hints, DockerHint{Check: "docker-cpu", Message: fmt.Sprintf("Docker can use %d CPU; Kimmio needs at least 2. Raise it in %s.", res.CPUs, where)})
+	}
+	if isAppleSilicon() {
+		for _, p := range profiles {
+			if p.DeletedAt != "" || p.Archived || !isEmulated(p) {
+				continue
+			}
+			if enabled, known := dockerDesktopRosetta(); known && !enabled {
+				hints = append(hints, DockerHint{Check: "rosetta", Message: "Some profiles run the amd64 image. " + rosettaGuidance})
+			}
+			break
+		}
+	}
+	return hints
+}
+
+// windowsDockerHints turns what the Windows probes found into hints, most
+// fundamental first: without WSL 2 nothing else matters. wslOut and wslErr
+// are the output and error of `wsl --status`; settings is Docker Desktop's
+// settings file, if one was found.
+func windowsDockerHints(wslOut string, wslErr error, settings []byte, desktopRunning bool) []DockerHint {
+	hints := []DockerHint{}
+	add := func(check, message string) {
+		hints = append(hints, DockerHint{Check: check, Message: message})
+	}
+	status := strings.ToLower(wslOut)
+	switch {
+	case wslErr != nil && strings.TrimSpace(wslOut) == "",
+		strings.Contains(status, "is not installed"),
+		strings.Contains(status, "optional component is not enabled"):
+		add("wsl", "WSL 2 is not installed. Run `wsl --install` in an administrator PowerShell, restart Windows, then start Docker Desktop.")
+	case strings.Contains(status, "virtual machine platform"), strings.Contains(status, "virtualization"):
+		add("virtualization", "WSL 2 cannot start virtual machines. Enable the Virtual Machine Platform Windows feature and virtualization in the BIOS/UEFI, then restart Windows.")
+	case strings.Contains(status, "kernel component"), strings.Contains(status, "wsl --update"):
+		add("wsl-kernel", "WSL 2 needs an update. Run `wsl --update` in an administrator PowerShell, then start Docker Desktop.")
+	}
+	if len(hints) == 0 && dockerDesktopUsesHyperV(settings) {
+		add("docker-backend", `Docker Desktop is set to the Hyper-V backend. Unless Hyper-V is enabled on this machine, turn on "Use the WSL 2 based engine" in Docker Desktop > Settings > General.`)
+	}
+	if len(hints) == 0 && !desktopRunning {
+		add("docker-desktop", "Docker Desktop is not running. Start it from the Start menu and wait until it shows Engine running.")
+	}
+	return hints
+}
+
+// linuxDockerProbe is what the Linux checks found.
+type linuxDockerProbe struct {
+	// Host is the daemon address docker commands use.
+	Host string
+	// InfoError is the output of a failed `docker info`.
+	InfoError    string
+	SocketExists bool
+	// SocketGroup owns the socket; InSocketGroup is set when this process
+	// has that group and ListedInGroup when the user is a member, which
+	// only applies to sessions started after joining.
+	SocketGroup    string
+	InSocketGroup  bool
+	ListedInGroup  bool
+	RootlessSocket string
+}
+
+func linuxDockerHints(p linuxDockerProbe) []DockerHint {
+	hints := []DockerHint{}
+	add := func(check, message string) {
+		hints = append(hints, DockerHint{Check: check, Message: message})
+	}
+	socket, isUnix := strings.CutPrefix(p.Host, "unix://")
+	group := p.SocketGroup
+	if group == "" {
+		group = "docker"
+	}
+	rootless := p.RootlessSocket != "" && socket != p.RootlessSocket
+	switch {
+	case strings.Contains(strings.ToLower(p.InfoError), "permission denied"):
+		switch {
+		case rootless:
+			add("rootless", fmt.Sprintf("Permission denied on %s, but a rootless Docker runs at %s. Set the Docker socket in Settings to unix://%s.", socket, p.RootlessSocket, p.RootlessSocket))
+		case p.ListedInGroup && !p.InSocketGroup:
+			add("docker-group", fmt.Sprintf("You joined the %s group after this session started. Sign out and back in, or start the launcher from `newgrp %s`.", group, group))
+		default:
+			add("docker-group", fmt.Sprintf("Permission denied on %s. Add your user to the %s group with `sudo usermod -aG %s $USER` and sign in again, or set up rootless Docker and set the Docker socket in Settings.", socket, group, group))
+		}
+	case isUnix && !p.SocketExists:
+		if rootless {
+			add("rootless", fmt.Sprintf("No Docker socket at %s, but a rootless Docker runs at %s. Set the Docker socket in Settings to unix://%s.", socket, p.RootlessSocket, p.RootlessSocket))
+			break
+		}
+		add("docker-service", fmt.Sprintf("No Docker socket at %s. Start Docker with `sudo systemctl start docker`, or `systemctl --user start docker` for rootless Docker.", socket))
+	}
+	return hints
+}
+
+// dockerDesktopUsesHyperV reads the WSL engine switch from Docker Desktop's
+// settings.
+func dockerDesktopUsesHyperV(settings []byte) bool {
+	enabled, found := dockerDesktopSetting(settings, "wslEngineEnabled")
+	return found && !enabled
+}
+
+// dockerDesktopSetting reads a switch from Docker Desktop's settings. Older
+// releases write settings.json with camelCase keys, newer ones
+// settings-store.json with the same keys capitalized.
+func dockerDesktopSetting(settings []byte, key string) (value, found bool) {
+	var values map[string]any
+	if json.Unmarshal(settings, &values) != nil {
+		return false, false
+	}
+	for k, v := range values {
+		if strings.EqualFold(k, key) {
+			value, found = v.(bool)
+			return value, found
+		}
+	}
+	return false, false
+}
+
+// decodeWSLOutput decodes wsl.exe output, which is UTF-16LE unless
+// WSL_UTF8 is set.
+func decodeWSLOutput(b []byte) string {
+	if len(b) < 2 || len(b)%2 != 0 || (b[1] != 0 && !(b[0] == 0xff && b[1] == 0xfe)) {
+		return string(b)
+	}
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, binary.LittleEndian.Uint16(b[i:]))
+	}
+	return strings.TrimPrefix(string(utf16.Decode(units)), "\ufeff")
+}
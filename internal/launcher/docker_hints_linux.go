@@ -0,0 +1,50 @@
+//go:build linux
+
+package launcher
+
+import (
+	"context"
+	"os"
+	"os/user"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// platformDockerHints asks docker why it fails and checks the socket and
+// the user's groups.
+func platformDockerHints(ctx context.Context) []DockerHint {
+	probe := linuxDockerProbe{Host: effectiveDockerHost(), RootlessSocket: rootlessDockerSocket()}
+	if dockerBin, err := dockerBinaryPath(); err == nil {
+		out, err := dockerCommandWithContext(ctx, dockerBin, "info").CombinedOutput()
+		if err == nil {
+			return nil
+		}
+		probe.InfoError = string(out)
+	}
+	socket, ok := strings.CutPrefix(probe.Host, "unix://")
+	if !ok {
+		return linuxDockerHints(probe)
+	}
+	info, err := os.Stat(socket)
+	if err != nil {
+		return linuxDockerHints(probe)
+	}
+	probe.SocketExists = true
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		gid := strconv.FormatUint(uint64(st.Gid), 10)
+		if g, err := user.LookupGroupId(gid); err == nil {
+			probe.SocketGroup = g.Name
+		}
+		if groups, err := os.Getgroups(); err == nil {
+			probe.InSocketGroup = slices.Contains(groups, int(st.Gid)) || os.Getgid() == int(st.Gid)
+		}
+		if u, err := user.Current(); err == nil {
+			if ids, err := u.GroupIds(); err == nil {
+				probe.ListedInGroup = slices.Contains(ids, gid)
+			}
+		}
+	}
+	return linuxDockerHints(probe)
+}
@@ -0,0 +1,11 @@
+//go:build !windows && !linux
+
+package launcher
+
+import "context"
+
+// platformDockerHints has nothing to add on macOS, where Docker Desktop
+// owns the socket; the generic message covers a stopped daemon there.
+func platformDockerHints(ctx context.Context) []DockerHint {
+	return nil
+}
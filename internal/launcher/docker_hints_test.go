@@ -0,0 +1,120 @@
+package launcher
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestWindowsDockerHints(t *testing.T) {
+	checks := func(hints []DockerHint) string {
+		out := []string{}
+		for _, h := range hints {
+			out = append(out, h.Check)
+		}
+		return strings.Join(out, ",")
+	}
+	cases := []struct {
+		name     string
+		wslOut   string
+		wslErr   error
+		settings string
+		running  bool
+		want     string
+	}{
+		{"wsl missing", "", errors.New(`exec: "wsl": executable file not found`), "", false, "wsl"},
+		{"wsl not installed", "The Windows Subsystem for Linux is not installed. You can install by running 'wsl.exe --install'.", errors.New("exit status 1"), "", false, "wsl"},
+		{"no virtualization", "Please enable the Virtual Machine Platform Windows feature and ensure virtualization is enabled in the BIOS.", nil, "", true, "virtualization"},
+		{"old kernel", "WSL 2 requires an update to its kernel component.", nil, "", true, "wsl-kernel"},
+		{"hyper-v backend", "Default Version: 2", nil, `{"WslEngineEnabled": false}`, true, "docker-backend"},
+		{"desktop stopped", "Default Version: 2", nil, `{"wslEngineEnabled": true}`, false, "docker-desktop"},
+		{"nothing found", "Default Version: 2", nil, `{"wslEngineEnabled": true}`, true, ""},
+	}
+	for _, c := range cases {
+		if got := checks(windowsDockerHints(c.wslOut, c.wslErr, []byte(c.settings), c.running)); got != c.want {
+			t.Errorf("%s: expected %q, got %q", c.name, c.want, got)
+		}
+	}
+}
+
+func TestDecodeWSLOutput(t *testing.T) {
+	text := "Default Version: 2\r\n"
+	raw := []byte{0xff, 0xfe}
+	for _, u := range utf16.Encode([]rune(text)) {
+		raw = append(raw, byte(u), byte(u>>8))
+	}
+	if got := decodeWSLOutput(raw); got != text {
+		t.Fatalf("expected UTF-16 output decoded, got %q", got)
+	}
+	if got := decodeWSLOutput([]byte(text)); got != text {
+		t.Fatalf("expected UTF-8 output kept, got %q", got)
+	}
+}
+
+func TestFriendlyDockerErrorWindowsContainers(t *testing.T) {
+	msg := friendlyDockerError("no matching manifest for windows/amd64 10.0.22631 in the manifest list entries")
+	if !strings.Contains(msg, "Switch to Linux containers") {
+		t.Fatalf("expected the Windows containers hint, got %q", msg)
+	}
+	msg = friendlyDockerError("error during connect: open //./pipe/dockerDesktopLinuxEngine: The system cannot find the file specified.")
+	if !strings.HasPrefix(msg, "Docker daemon is not reachable.") {
+		t.Fatalf("expected the daemon message for a missing engine pipe, got %q", msg)
+	}
+}
+
+func TestDockerResourceHints(t *testing.T) {
+	res, ok := parseDockerResources("1|2147483648|aarch64|Docker Desktop\n")
+	if !ok || res.CPUs != 1 || res.MemoryBytes != 2<<30 || res.Arch != "aarch64" {
+		t.Fatalf("unexpected resources %+v", res)
+	}
+	hints := dockerResourceHints(res, nil)
+	if len(hints) != 2 || hints[0].Check != "docker-memory" || !strings.Contains(hints[0].Message, "Docker Desktop > Settings > Resources") || hints[1].Check != "docker-cpu" {
+		t.Fatalf("expected memory and CPU hints, got %+v", hints)
+	}
+	if hints := dockerResourceHints(DockerResources{CPUs: 8, MemoryBytes: 16 << 30}, nil); len(hints) != 0 {
+		t.Fatalf("expected no hints for a roomy Docker, got %+v", hints)
+	}
+}
+
+func TestLinuxDockerHints(t *testing.T) {
+	denied := "permission denied while trying to connect to the Docker daemon socket at unix:///var/run/docker.sock"
+	cases := []struct {
+		name  string
+		probe linuxDockerProbe
+		want  string
+	}{
+		{"not in group", linuxDockerProbe{Host: defaultDockerHost, InfoError: denied, SocketExists: true, SocketGroup: "docker"}, "sudo usermod -aG docker $USER"},
+		{"stale session", linuxDockerProbe{Host: defaultDockerHost, InfoError: denied, SocketExists: true, SocketGroup: "docker", ListedInGroup: true}, "newgrp docker"},
+		{"rootless", linuxDockerProbe{Host: defaultDockerHost, InfoError: denied, SocketExists: true, RootlessSocket: "/run/user/1000/docker.sock"}, "unix:///run/user/1000/docker.sock"},
+		{"no daemon", linuxDockerProbe{Host: defaultDockerHost, InfoError: "Cannot connect to the Docker daemon"}, "sudo systemctl start docker"},
+	}
+	for _, c := range cases {
+		hints := linuxDockerHints(c.probe)
+		if len(hints) != 1 || !strings.Contains(hints[0].Message, c.want) {
+			t.Errorf("%s: expected a hint mentioning %q, got %+v", c.name, c.want, hints)
+		}
+	}
+	if hints := linuxDockerHints(linuxDockerProbe{Host: "tcp://10.0.0.5:2375", InfoError: "connection refused"}); len(hints) != 0 {
+		t.Fatalf("expected no socket hints for a TCP daemon, got %+v", hints)
+	}
+}
+
+func TestDockerHostSetting(t *testing.T) {
+	srv := &Server{settings: newSettingsStore(t.TempDir())}
+	defer publishSettings(defaultSettings())
+	for _, bad := range []string{"docker.sock", "unix://relative.sock", "http://example.com"} {
+		if _, err := srv.settings.update(SettingsPatch{DockerHost: &bad}); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+	path := " /run/user/1000/docker.sock "
+	st, err := srv.settings.update(SettingsPatch{DockerHost: &path})
+	if err != nil || st.DockerHost != "unix:///run/user/1000/docker.sock" {
+		t.Fatalf("expected the path as a unix address, got %q %v", st.DockerHost, err)
+	}
+	t.Setenv("DOCKER_HOST", "tcp://127.0.0.1:2375")
+	if host := effectiveDockerHost(); host != "unix:///run/user/1000/docker.sock" {
+		t.Fatalf("expected the setting to win over DOCKER_HOST, got %q", host)
+	}
+}
@@ -0,0 +1,33 @@
+//go:build windows
+
+package launcher
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// platformDockerHints asks wsl.exe for its status, reads Docker Desktop's
+// settings and looks for its process.
+func platformDockerHints(ctx context.Context) []DockerHint {
+	raw, wslErr := exec.CommandContext(ctx, "wsl", "--status").CombinedOutput()
+
+	var settings []byte
+	if appData := os.Getenv("APPDATA"); appData != "" {
+		for _, name := range []string{"settings-store.json", "settings.json"} {
+			if b, err := os.ReadFile(filepath.Join(appData, "Docker", name)); err == nil {
+				settings = b
+				break
+			}
+		}
+	}
+
+	running := true
+	if out, err := exec.CommandContext(ctx, "tasklist", "/FI", "IMAGENAME eq Docker Desktop.exe", "/FO", "CSV", "/NH").Output(); err == nil {
+		running = strings.Contains(strings.ToLower(string(out)), "docker desktop.exe")
+	}
+	return windowsDockerHints(decodeWSLOutput(raw), wslErr, settings, running)
+}
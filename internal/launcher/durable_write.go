@@ -0,0 +1,83 @@
+package launcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Files the launcher cannot afford to lose, profiles.json above all, are
+// replaced through a temporary file that is synced before it takes the
+// real file's place, and the directory is synced after, so a power cut
+// leaves the old content or the new one instead of an empty file.
+
+// writeFileSynced replaces path through a synced temporary file, so
+// readers see the old content or the new, never part of it.
+func writeFileSynced(path string, data []byte, mode os.FileMode) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := replaceFile(tmp, path); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// syncDir makes a rename in dir durable. Not every platform can sync a
+// directory, so failures are ignored.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		d.Close()
+	}
+}
+
+// storeChecksum is the checksum profiles.json carries: the SHA-256 of the
+// store encoded without one.
+func storeChecksum(store ProfileStore) (string, error) {
+	store.Checksum = ""
+	b, err := json.Marshal(store)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// encodeProfileStore is profiles.json's content for store, checksum
+// included.
+func encodeProfileStore(store ProfileStore) ([]byte, error) {
+	sum, err := storeChecksum(store)
+	if err != nil {
+		return nil, err
+	}
+	store.Checksum = sum
+	return json.MarshalIndent(store, "", "  ")
+}
+
+// storeChecksumMatches reports whether a loaded store still matches the
+// checksum it was written with. Stores written before checksums existed
+// match.
+func storeChecksumMatches(store ProfileStore) bool {
+	if store.Checksum == "" {
+		return true
+	}
+	sum, err := storeChecksum(store)
+	return err == nil && sum == store.Checksum
+}
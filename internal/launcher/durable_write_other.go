@@ -0,0 +1,10 @@
+//go:build !windows
+
+package launcher
+
+import "os"
+
+// replaceFile moves src over dst; rename replaces atomically here.
+func replaceFile(src, dst string) error {
+	return os.Rename(src, dst)
+}
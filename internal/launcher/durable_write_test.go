@@ -0,0 +1,72 @@
+package launcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteProfileStoreAtomicAddsChecksum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	store := ProfileStore{Profiles: []ProfileRequest{{ID: "alpha", Revision: 1}}}
+	if err := writeProfileStoreAtomic(path, store); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("expected no temp file left behind")
+	}
+	loaded, err := loadProfileStore(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(loaded.Checksum, "sha256:") || !storeChecksumMatches(loaded) {
+		t.Fatalf("expected a matching checksum, got %q", loaded.Checksum)
+	}
+
+	// Rewriting what was loaded keeps the checksum valid.
+	loaded.Profiles[0].Version = "1.2.0"
+	if err := writeProfileStoreAtomic(path, loaded); err != nil {
+		t.Fatal(err)
+	}
+	again, err := loadProfileStore(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !storeChecksumMatches(again) || again.Checksum == loaded.Checksum {
+		t.Fatalf("expected a new matching checksum, got %q", again.Checksum)
+	}
+}
+
+func TestCheckDataDirIntegrityWarnsOnDamagedStore(t *testing.T) {
+	tmp := t.TempDir()
+	path := filepath.Join(tmp, "profiles.json")
+	if err := writeProfileStoreAtomic(path, ProfileStore{Profiles: []ProfileRequest{}}); err != nil {
+		t.Fatal(err)
+	}
+	if warnings := integrityWarnings(checkDataDirIntegrity(tmp)); len(warnings) != 0 {
+		t.Fatalf("expected no warnings, got %+v", warnings)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := strings.Replace(string(b), `"profiles": []`, `"profiles": [{"id":"alpha"}]`, 1)
+	if err := os.WriteFile(path, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	warnings := integrityWarnings(checkDataDirIntegrity(tmp))
+	if len(warnings) == 0 || !strings.Contains(warnings[0].Message, "checksum") {
+		t.Fatalf("expected a checksum warning, got %+v", warnings)
+	}
+
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	warnings = integrityWarnings(checkDataDirIntegrity(tmp))
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Message, "empty") {
+		t.Fatalf("expected an empty-file warning, got %+v", warnings)
+	}
+}
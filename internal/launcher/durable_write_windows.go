@@ -0,0 +1,18 @@
+//go:build windows
+
+package launcher
+
+import "golang.org/x/sys/windows"
+
+// replaceFile moves src over dst, returning only once the move is on disk.
+func replaceFile(src, dst string) error {
+	from, err := windows.UTF16PtrFromString(src)
+	if err != nil {
+		return err
+	}
+	to, err := windows.UTF16PtrFromString(dst)
+	if err != nil {
+		return err
+	}
+	return windows.MoveFileEx(from, to, windows.MOVEFILE_REPLACE_EXISTING|windows.MOVEFILE_WRITE_THROUGH)
+}
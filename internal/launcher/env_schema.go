@@ -0,0 +1,293 @@
+package launcher
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// envschema.json declares the environment each Kimmio release understands.
+// A schema applies from its minVersion up to the next schema; "latest" and
+// other non-numeric tags use the newest one. Vars marked app are passed to
+// the container as-is and rendered on the create page; the others feed the
+// launcher's own compose env and may only be overridden.
+//
+//go:embed envschema.json
+var envSchemaJSON []byte
+
+const (
+	envTypeString = "string"
+	envTypeName   = "name"
+	envTypeInt    = "int"
+	envTypeBool   = "bool"
+	envTypePort   = "port"
+	envTypeHost   = "host"
+	envTypeURL    = "url"
+	envTypeEmail  = "email"
+	envTypeEnum   = "enum"
+	envTypeKey32  = "key32"
+)
+
+// EnvVar describes one variable of a Kimmio release's environment.
+type EnvVar struct {
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	Required  bool     `json:"required,omitempty"`
+	Secret    bool     `json:"secret,omitempty"`
+	Default   string   `json:"default,omitempty"`
+	Docs      string   `json:"docs"`
+	Values    []string `json:"values,omitempty"`
+	MinLength int      `json:"minLength,omitempty"`
+	App       bool     `json:"app,omitempty"`
+}
+
+type EnvSchema struct {
+	MinVersion string   `json:"minVersion"`
+	Vars       []EnvVar `json:"vars"`
+}
+
+var (
+	envSchemas     = mustParseEnvSchemas(envSchemaJSON)
+	envNameValueRe = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,62}$`)
+	numericTagRe   = regexp.MustCompile(`^v?\d+(\.\d+)*([-+].*)?$`)
+)
+
+func mustParseEnvSchemas(raw []byte) []EnvSchema {
+	var file struct {
+		Schemas []EnvSchema `json:"schemas"`
+	}
+	if err := json.Unmarshal(raw, &file); err != nil {
+		panic("invalid envschema.json: " + err.Error())
+	}
+	if len(file.Schemas) == 0 {
+		panic("envschema.json declares no schemas")
+	}
+	for _, schema := range file.Schemas {
+		for _, v := range schema.Vars {
+			if !isSafeEnvKey(v.Name) || !isKnownEnvType(v.Type) {
+				panic(fmt.Sprintf("envschema.json: invalid var %q of type %q", v.Name, v.Type))
+			}
+		}
+	}
+	sort.SliceStable(file.Schemas, func(i, j int) bool {
+		return isNewerVersion(file.Schemas[j].MinVersion, file.Schemas[i].MinVersion)
+	})
+	return file.Schemas
+}
+
+func isKnownEnvType(t string) bool {
+	switch t {
+	case envTypeString, envTypeName, envTypeInt, envTypeBool, envTypePort, envTypeHost,
+		envTypeURL, envTypeEmail, envTypeEnum, envTypeKey32:
+		return true
+	default:
+		return false
+	}
+}
+
+// envSchemaFor returns the schema that applies to a Kimmio version tag.
+func envSchemaFor(version string) EnvSchema {
+	newest := envSchemas[len(envSchemas)-1]
+	version = strings.TrimSpace(version)
+	if !numericTagRe.MatchString(version) {
+		return newest
+	}
+	chosen := envSchemas[0]
+	for _, schema := range envSchemas {
+		if !isNewerVersion(schema.MinVersion, version) {
+			chosen = schema
+		}
+	}
+	return chosen
+}
+
+func (s EnvSchema) lookup(name string) (EnvVar, bool) {
+	for _, v := range s.Vars {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return EnvVar{}, false
+}
+
+// appVars lists the variables passed straight to the app, in schema order.
+func (s EnvSchema) appVars() []EnvVar {
+	out := []EnvVar{}
+	for _, v := range s.Vars {
+		if v.App {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// isSecretEnvKey reports whether any schema marks the variable secret, so
+// secrets stay out of profiles.json regardless of the profile's version.
+func isSecretEnvKey(name string) bool {
+	if name == "FLUMIO_ENC_KEY_V0" {
+		return true
+	}
+	for _, schema := range envSchemas {
+		if v, ok := schema.lookup(name); ok && v.Secret {
+			return true
+		}
+	}
+	return false
+}
+
+// validateProfileEnv checks env against the version's schema, trimming
+// values and dropping empty ones. Unknown variables are rejected: the
+// launcher only writes variables the schema declares, so anything else
+// would be silently ignored.
+func validateProfileEnv(version string, env map[string]string) error {
+	schema := envSchemaFor(version)
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var errs fieldErrors
+	for _, k := range keys {
+		v, ok := schema.lookup(k)
+		if !ok {
+			if !isSafeEnvKey(k) {
+				errs.add(envFormField(k), "env.key", fmt.Errorf("invalid env key: %q", k))
+				continue
+			}
+			errs.add(envFormField(k), "env.unknown", fmt.Errorf("unknown env key %s for Kimmio %s", k, strings.TrimSpace(version)))
+			continue
+		}
+		value := strings.TrimSpace(env[k])
+		if value == "" {
+			delete(env, k)
+			continue
+		}
+		normalized, err := v.validate(value)
+		if err != nil {
+			errs.add(envFormField(k), "env.invalid", err)
+			continue
+		}
+		env[k] = normalized
+	}
+	for _, v := range schema.Vars {
+		if v.Required && v.Default == "" && env[v.Name] == "" {
+			errs.add(envFormField(v.Name), "env.required", fmt.Errorf("%s is required", v.Name))
+		}
+	}
+	return errs.err()
+}
+
+// envFormField is the create form input that carries the variable.
+func envFormField(key string) string {
+	switch key {
+	case "JWT_SECRET":
+		return "jwtSecret"
+	case "ENC_KEY_V0":
+		return "encKeyV0"
+	case "APP_DOMAIN":
+		return "domain"
+	case smtpPasswordKey:
+		return "smtpPassword"
+	case ssoClientSecretKey:
+		return "ssoClientSecret"
+	}
+	return "env_" + key
+}
+
+func (v EnvVar) validate(value string) (string, error) {
+	if strings.ContainsAny(value, "\r\n") {
+		return "", fmt.Errorf("%s must not contain line breaks", v.Name)
+	}
+	if len(value) < v.MinLength {
+		return "", fmt.Errorf("%s must be at least %d characters", v.Name, v.MinLength)
+	}
+	switch v.Type {
+	case envTypeName:
+		if !envNameValueRe.MatchString(value) {
+			return "", fmt.Errorf("%s may only contain letters, digits, dots, dashes and underscores", v.Name)
+		}
+	case envTypeInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return "", fmt.Errorf("%s must be a whole number", v.Name)
+		}
+	case envTypeBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("%s must be true or false", v.Name)
+		}
+		return strconv.FormatBool(b), nil
+	case envTypePort:
+		if n, err := strconv.Atoi(value); err != nil || n < 1 || n > 65535 {
+			return "", fmt.Errorf("%s must be a port between 1 and 65535", v.Name)
+		}
+	case envTypeHost:
+		if !isValidDomain(value) {
+			return "", fmt.Errorf("%s must be hostname only (example: localhost or app.example.com)", v.Name)
+		}
+		return strings.ToLower(value), nil
+	case envTypeURL:
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "", fmt.Errorf("%s must be an http or https URL", v.Name)
+		}
+	case envTypeEmail:
+		if _, err := mail.ParseAddress(value); err != nil {
+			return "", fmt.Errorf("%s must be an email address", v.Name)
+		}
+	case envTypeEnum:
+		for _, allowed := range v.Values {
+			if strings.EqualFold(value, allowed) {
+				return allowed, nil
+			}
+		}
+		return "", fmt.Errorf("%s must be one of %s", v.Name, strings.Join(v.Values, ", "))
+	case envTypeKey32:
+		if !isValidEncryptionKeyValue(value) {
+			return "", fmt.Errorf("%s must be base64 for 32 bytes (legacy 32-char keys also accepted)", v.Name)
+		}
+	}
+	return value, nil
+}
+
+// appEnvLines renders the schema's app variables for the compose env file.
+func appEnvLines(profile ProfileRequest, env map[string]string) []string {
+	vars := envSchemaFor(profile.Version).appVars()
+	lines := make([]string, 0, len(vars))
+	for _, v := range vars {
+		lines = append(lines, v.Name+"="+envValue(env, v.Name, v.Default))
+	}
+	return lines
+}
+
+// composeAppEnvironment renders the matching keys of the app service's
+// environment block.
+func composeAppEnvironment(profile ProfileRequest) string {
+	var b strings.Builder
+	for _, v := range envSchemaFor(profile.Version).appVars() {
+		b.WriteString("      " + v.Name + ": ${" + v.Name + "}\n")
+	}
+	return b.String()
+}
+
+func (s *Server) handleEnvSchema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	version := strings.TrimSpace(r.URL.Query().Get("version"))
+	if version == "" {
+		version = "latest"
+	}
+	if !versionTagRe.MatchString(version) {
+		http.Error(w, "invalid version tag", http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "version": version, "schema": envSchemaFor(version)})
+}
@@ -0,0 +1,55 @@
+package launcher
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateProfileEnvTypes(t *testing.T) {
+	env := map[string]string{"APP_DOMAIN": " App.Example.com ", "LOG_LEVEL": "DEBUG", "POSTGRES_PORT": ""}
+	if err := validateProfileEnv("latest", env); err != nil {
+		t.Fatal(err)
+	}
+	if env["APP_DOMAIN"] != "app.example.com" || env["LOG_LEVEL"] != "debug" {
+		t.Fatalf("expected normalized values, got %v", env)
+	}
+	if _, ok := env["POSTGRES_PORT"]; ok {
+		t.Fatalf("expected empty value to be dropped")
+	}
+	for _, bad := range []map[string]string{
+		{"APP_DOMAIN": "http://example.com"},
+		{"LOG_LEVEL": "verbose"},
+		{"POSTGRES_PORT": "70000"},
+		{"JWT_SECRET": "short"},
+		{"ENC_KEY_V0": "not-a-key"},
+		{"UNKNOWN_SETTING": "1"},
+		{"bad-key": "1"},
+	} {
+		if err := validateProfileEnv("1.0.0", bad); err == nil {
+			t.Fatalf("expected %v to be rejected", bad)
+		}
+	}
+}
+
+func TestEnvSchemaForVersion(t *testing.T) {
+	if len(envSchemaFor("latest").Vars) == 0 || len(envSchemaFor("0.1.0").Vars) == 0 {
+		t.Fatalf("expected a schema for every version")
+	}
+	if _, ok := envSchemaFor("2.3.4").lookup("APP_DOMAIN"); !ok {
+		t.Fatalf("expected APP_DOMAIN in schema")
+	}
+	if !isSecretEnvKey("POSTGRES_PASSWORD") || isSecretEnvKey("APP_DOMAIN") {
+		t.Fatalf("unexpected secret flags")
+	}
+}
+
+func TestAppEnvVarsReachCompose(t *testing.T) {
+	p := ProfileRequest{ID: "alpha", Version: "latest", Env: map[string]string{"LOG_LEVEL": "warn"}}
+	if !strings.Contains(buildComposeYAML(p), "      LOG_LEVEL: ${LOG_LEVEL}\n") {
+		t.Fatalf("expected LOG_LEVEL in compose environment")
+	}
+	env := strings.Join(appEnvLines(p, p.Env), "\n")
+	if !strings.Contains(env, "LOG_LEVEL=warn") {
+		t.Fatalf("unexpected app env lines:\n%s", env)
+	}
+}
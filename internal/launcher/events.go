@@ -0,0 +1,101 @@
+package launcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const eventsHeartbeatInterval = 15 * time.Second
+
+type serverEvent struct {
+	Name string
+	Data any
+}
+
+// eventHub fans server events out to /api/events subscribers. Slow
+// subscribers miss events rather than blocking publishers.
+type eventHub struct {
+	mu   sync.Mutex
+	subs map[chan serverEvent]struct{}
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{subs: map[chan serverEvent]struct{}{}}
+}
+
+func (h *eventHub) subscribe() (<-chan serverEvent, func()) {
+	return h.subscribeSize(16)
+}
+
+// subscribeSize is subscribe with room for size events, for subscribers
+// that expect bursts.
+func (h *eventHub) subscribeSize(size int) (<-chan serverEvent, func()) {
+	ch := make(chan serverEvent, size)
+	h.mu.Lock()
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subs, ch)
+		h.mu.Unlock()
+	}
+}
+
+func (h *eventHub) publish(name string, data any) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- serverEvent{Name: name, Data: data}:
+		default:
+		}
+	}
+}
+
+func (s *Server) publishEvent(name string, data any) {
+	if s.events != nil {
+		s.events.publish(name, data)
+	}
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	disableWriteDeadline(w)
+
+	events, unsubscribe := s.events.subscribe()
+	defer unsubscribe()
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(eventsHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case ev := <-events:
+			b, err := json.Marshal(ev.Data)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Name, b)
+		}
+		flusher.Flush()
+	}
+}
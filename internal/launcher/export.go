@@ -0,0 +1,236 @@
+package launcher
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GET /api/export/<dataset> hands the launcher's own records to
+// spreadsheets and reporting tools, as CSV or JSON. Each dataset is a
+// table with fixed columns, so both formats carry the same rows.
+
+const (
+	exportJobs    = "jobs"
+	exportActions = "actions"
+	exportMetrics = "metrics"
+	exportUptime  = "uptime"
+
+	exportFormatCSV  = "csv"
+	exportFormatJSON = "json"
+)
+
+var exportDatasets = []string{exportJobs, exportActions, exportMetrics, exportUptime}
+
+// exportTable is a dataset ready to write. Cells are strings, numbers or
+// times, and nil where a value is unknown.
+type exportTable struct {
+	Columns []string
+	Rows    [][]any
+}
+
+func (t *exportTable) add(row ...any) {
+	t.Rows = append(t.Rows, row)
+}
+
+type exportQuery struct {
+	Dataset   string
+	Format    string
+	ProfileID string
+}
+
+func parseExportQuery(dataset string, values url.Values) (exportQuery, error) {
+	var errs fieldErrors
+	q := exportQuery{Dataset: strings.ToLower(strings.TrimSpace(dataset)), Format: exportFormatJSON}
+	if !slices.Contains(exportDatasets, q.Dataset) {
+		errs.add("dataset", "path.dataset", fmt.Errorf("dataset must be one of %s", strings.Join(exportDatasets, ", ")))
+	}
+	if raw := strings.ToLower(strings.TrimSpace(values.Get("format"))); raw != "" {
+		if raw != exportFormatCSV && raw != exportFormatJSON {
+			errs.add("format", "query.format", fmt.Errorf("format must be csv or json"))
+		}
+		q.Format = raw
+	}
+	if raw := strings.ToLower(strings.TrimSpace(values.Get("profile"))); raw != "" {
+		if !profileIDRe.MatchString(raw) {
+			errs.add("profile", "query.profile", fmt.Errorf("profile must be a profile id"))
+		}
+		q.ProfileID = raw
+	}
+	return q, errs.err()
+}
+
+// exportJobTable lists the jobs run since the launcher started, oldest
+// first.
+func (s *Server) exportJobTable(keep func(string) bool) exportTable {
+	t := exportTable{Columns: []string{"id", "profileId", "action", "status", "error", "startedAt", "finishedAt", "durationMs"}}
+	s.jobMu.Lock()
+	jobs := make([]ActionJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		if keep(job.ProfileID) {
+			jobs = append(jobs, *job)
+		}
+	}
+	s.jobMu.Unlock()
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].StartedAt < jobs[j].StartedAt })
+	for _, job := range jobs {
+		var durationMs any
+		started, err1 := time.Parse(time.RFC3339, job.StartedAt)
+		finished, err2 := time.Parse(time.RFC3339, job.FinishedAt)
+		if err1 == nil && err2 == nil {
+			durationMs = finished.Sub(started).Milliseconds()
+		}
+		t.add(job.ID, job.ProfileID, job.Action, job.Status, job.Error, job.StartedAt, job.FinishedAt, durationMs)
+	}
+	return t
+}
+
+// exportActionTable splits each profile's action log ("<time> [<action>]
+// <result>: <message>") into columns, oldest first.
+func exportActionTable(profiles []ProfileRequest) exportTable {
+	t := exportTable{Columns: []string{"at", "profileId", "action", "result", "message"}}
+	for _, p := range profiles {
+		for i := len(p.ActionLog) - 1; i >= 0; i-- {
+			at, rest, ok := strings.Cut(p.ActionLog[i], " ")
+			if !ok {
+				continue
+			}
+			action, result, message := "", "", rest
+			if strings.HasPrefix(rest, "[") {
+				if a, r, ok := strings.Cut(rest[1:], "] "); ok {
+					action = a
+					result, message, _ = strings.Cut(r, ": ")
+				}
+			}
+			t.add(at, p.ID, action, result, message)
+		}
+	}
+	sort.SliceStable(t.Rows, func(i, j int) bool { return t.Rows[i][0].(string) < t.Rows[j][0].(string) })
+	return t
+}
+
+func (s *Server) exportMetricTable(profiles []ProfileRequest) exportTable {
+	t := exportTable{Columns: []string{"at", "profileId", "cpuPercent", "memoryBytes", "diskBytes"}}
+	for _, p := range profiles {
+		for _, sample := range s.usage.since(p.ID, time.Time{}) {
+			t.add(sample.At, p.ID, sample.CPUPercent, sample.MemoryBytes, sample.DiskBytes)
+		}
+	}
+	return t
+}
+
+func (s *Server) exportUptimeTable(profiles []ProfileRequest) exportTable {
+	t := exportTable{Columns: []string{"hour", "profileId", "upSeconds", "downSeconds"}}
+	for _, p := range profiles {
+		for _, b := range s.uptime.history(p.ID) {
+			t.add(b.Hour, p.ID, b.UpSeconds, b.DownSeconds)
+		}
+	}
+	return t
+}
+
+func exportCell(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func writeExportCSV(w http.ResponseWriter, name string, t exportTable) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`.csv"`)
+	cw := csv.NewWriter(w)
+	_ = cw.Write(t.Columns)
+	for _, row := range t.Rows {
+		record := make([]string, len(row))
+		for i, v := range row {
+			record[i] = exportCell(v)
+		}
+		_ = cw.Write(record)
+	}
+	cw.Flush()
+}
+
+func writeExportJSON(w http.ResponseWriter, name string, q exportQuery, t exportTable) {
+	rows := make([]map[string]any, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		obj := make(map[string]any, len(row))
+		for i, v := range row {
+			obj[t.Columns[i]] = v
+		}
+		rows = append(rows, obj)
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`.json"`)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"ok":          true,
+		"dataset":     q.Dataset,
+		"profileId":   q.ProfileID,
+		"generatedAt": time.Now().UTC().Format(time.RFC3339),
+		"columns":     t.Columns,
+		"rows":        rows,
+	})
+}
+
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q, err := parseExportQuery(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/export/"), "/"), r.URL.Query())
+	if err != nil {
+		var ve ValidationError
+		errors.As(err, &ve)
+		writeValidationError(w, ve)
+		return
+	}
+	store, err := s.readStore(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load profiles: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	profiles := store.Profiles
+	if q.ProfileID != "" {
+		idx := findProfileIndex(store, q.ProfileID)
+		if idx < 0 {
+			http.Error(w, "Profile not found", http.StatusNotFound)
+			return
+		}
+		profiles = profiles[idx : idx+1]
+	}
+
+	var t exportTable
+	switch q.Dataset {
+	case exportJobs:
+		t = s.exportJobTable(func(id string) bool { return q.ProfileID == "" || id == q.ProfileID })
+	case exportActions:
+		t = exportActionTable(profiles)
+	case exportMetrics:
+		t = s.exportMetricTable(profiles)
+	case exportUptime:
+		t = s.exportUptimeTable(profiles)
+	}
+	name := "kimmio-" + q.Dataset
+	if q.ProfileID != "" {
+		name += "-" + q.ProfileID
+	}
+	if q.Format == exportFormatCSV {
+		writeExportCSV(w, name, t)
+		return
+	}
+	writeExportJSON(w, name, q, t)
+}
@@ -0,0 +1,88 @@
+package launcher
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportDatasets(t *testing.T) {
+	srv := newServiceTestServer(t)
+	if err := srv.markProfileResult(context.Background(), "alpha", "enable", "failed", "pull denied, retry later", ""); err != nil {
+		t.Fatal(err)
+	}
+	srv.jobs["job-1"] = &ActionJob{ID: "job-1", ProfileID: "alpha", Action: "enable", Status: "succeeded", StartedAt: "2026-04-02T10:00:00Z", FinishedAt: "2026-04-02T10:00:42Z"}
+	srv.jobs["job-2"] = &ActionJob{ID: "job-2", ProfileID: "beta", Action: "stop", Status: "running", StartedAt: "2026-04-02T10:01:00Z"}
+	at := time.Date(2026, 4, 2, 10, 0, 0, 0, time.UTC)
+	if err := srv.usage.add("alpha", UsageSample{At: at, CPUPercent: 12.5, MemoryBytes: 1 << 20}); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.uptime.record("alpha", true, at, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.handleExport(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+	readCSV := func(path string) [][]string {
+		t.Helper()
+		rec := get(path)
+		if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") {
+			t.Fatalf("%s: expected CSV, got %d %s", path, rec.Code, rec.Body.String())
+		}
+		records, err := csv.NewReader(rec.Body).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return records
+	}
+
+	jobs := readCSV("/api/export/jobs?format=csv&profile=alpha")
+	if len(jobs) != 2 || jobs[1][0] != "job-1" || jobs[1][7] != "42000" {
+		t.Fatalf("unexpected jobs %q", jobs)
+	}
+	if all := readCSV("/api/export/jobs?format=csv"); len(all) != 3 || all[2][7] != "" {
+		t.Fatalf("expected both jobs, the running one without a duration, got %q", all)
+	}
+	actions := readCSV("/api/export/actions?format=CSV")
+	last := actions[len(actions)-1]
+	if actions[0][0] != "at" || last[1] != "alpha" || last[2] != "enable" || last[3] != "failed" || last[4] != "pull denied, retry later" {
+		t.Fatalf("unexpected actions %q", actions)
+	}
+	if metrics := readCSV("/api/export/metrics?format=csv"); len(metrics) != 2 || metrics[1][0] != "2026-04-02T10:00:00Z" || metrics[1][2] != "12.5" || metrics[1][3] != "1048576" {
+		t.Fatalf("unexpected metrics %q", metrics)
+	}
+
+	rec := get("/api/export/uptime?profile=alpha")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Header().Get("Content-Disposition"), "kimmio-uptime-alpha.json") {
+		t.Fatalf("expected a JSON download, got %d %v", rec.Code, rec.Header())
+	}
+	var body struct {
+		Columns []string         `json:"columns"`
+		Rows    []map[string]any `json:"rows"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Columns) != 4 || len(body.Rows) != 1 || body.Rows[0]["upSeconds"] != float64(60) || body.Rows[0]["profileId"] != "alpha" {
+		t.Fatalf("unexpected uptime export %s", rec.Body.String())
+	}
+
+	for path, code := range map[string]int{
+		"/api/export/logs":                 http.StatusBadRequest,
+		"/api/export/jobs?format=xlsx":     http.StatusBadRequest,
+		"/api/export/jobs?profile=Bad!":    http.StatusBadRequest,
+		"/api/export/metrics?profile=nope": http.StatusNotFound,
+	} {
+		if rec := get(path); rec.Code != code {
+			t.Fatalf("%s: expected %d, got %d", path, code, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fleet mode registers other launchers by URL and API token so one UI can
+// list and drive their profiles. Requests to /api/fleet/<name>/api/... are
+// forwarded to the remote launcher's /api/... with the same client the CLI
+// uses for contexts: its own CSRF cookie and header plus the bearer token.
+// Only the profile and job routes are forwarded, so a registered launcher
+// cannot be reconfigured from here.
+
+const (
+	fleetFileName     = "fleet.json"
+	fleetProxyTimeout = 30 * time.Second
+	maxFleetLaunchers = 50
+)
+
+var (
+	ErrFleetLauncherNotFound = errors.New("launcher not registered")
+	fleetProxyPrefixes       = []string{"profiles", "jobs"}
+)
+
+// FleetLauncher is one registered remote launcher. The token never leaves
+// this launcher: the API reports only whether one is set.
+type FleetLauncher struct {
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	Token    string `json:"token,omitempty"`
+	AddedAt  string `json:"addedAt"`
+	HasToken bool   `json:"hasToken"`
+}
+
+func (l FleetLauncher) redacted() FleetLauncher {
+	l.HasToken = l.Token != ""
+	l.Token = ""
+	return l
+}
+
+func (l FleetLauncher) client() *launcherClient {
+	return &launcherClient{
+		base:  l.URL,
+		http:  &http.Client{Timeout: fleetProxyTimeout},
+		csrf:  randomToken(48),
+		token: l.Token,
+	}
+}
+
+// fleetStore keeps the registered launchers in fleet.json, readable by the
+// owner only since it holds API tokens.
+type fleetStore struct {
+	mu        sync.Mutex
+	path      string
+	launchers []FleetLauncher
+}
+
+func newFleetStore(dataDir string) *fleetStore {
+	f := &fleetStore{path: filepath.Join(dataDir, fleetFileName)}
+	raw, err := os.ReadFile(f.path)
+	if err != nil {
+		return f
+	}
+	if err := json.Unmarshal(raw, &f.launchers); err != nil {
+		logWarn("fleet_file_invalid", map[string]any{"error": err.Error()})
+	}
+	return f
+}
+
+func (f *fleetStore) list() []FleetLauncher {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]FleetLauncher, 0, len(f.launchers))
+	for _, l := range f.launchers {
+		out = append(out, l.redacted())
+	}
+	return out
+}
+
+func (f *fleetStore) get(name string) (FleetLauncher, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, l := range f.launchers {
+		if l.Name == name {
+			return l, nil
+		}
+	}
+	return FleetLauncher{}, ErrFleetLauncherNotFound
+}
+
+// put adds the launcher or replaces the one with the same name. An empty
+// token keeps the stored one, so editing a URL does not require re-entering
+// the token.
+func (f *fleetStore) put(l FleetLauncher) (FleetLauncher, error) {
+	l.Name = strings.ToLower(strings.TrimSpace(l.Name))
+	l.URL = strings.TrimRight(strings.TrimSpace(l.URL), "/")
+	l.Token = strings.TrimSpace(l.Token)
+	l.HasToken = false
+	if !contextNameRe.MatchString(l.Name) {
+		return l, ValidationError{Msg: "Launcher name must be lowercase letters, digits, '.', '_' or '-'"}
+	}
+	if u, err := url.Parse(l.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" {
+		return l, ValidationError{Msg: "Launcher URL must be an http(s) URL without a path, e.g. https://client-a.example.com:7331"}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	next := append([]FleetLauncher{}, f.launchers...)
+	idx := -1
+	for i := range next {
+		if next[i].Name == l.Name {
+			idx = i
+		}
+	}
+	if idx >= 0 {
+		if l.Token == "" {
+			l.Token = next[idx].Token
+		}
+		l.AddedAt = next[idx].AddedAt
+		next[idx] = l
+	} else {
+		if len(next) >= maxFleetLaunchers {
+			return l, ValidationError{Msg: fmt.Sprintf("At most %d launchers can be registered", maxFleetLaunchers)}
+		}
+		l.AddedAt = time.Now().UTC().Format(time.RFC3339)
+		next = append(next, l)
+	}
+	if err := f.saveLocked(next); err != nil {
+		return l, err
+	}
+	return l.redacted(), nil
+}
+
+func (f *fleetStore) remove(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	next := []FleetLauncher{}
+	for _, l := range f.launchers {
+		if l.Name != name {
+			next = append(next, l)
+		}
+	}
+	if len(next) == len(f.launchers) {
+		return ErrFleetLauncherNotFound
+	}
+	return f.saveLocked(next)
+}
+
+func (f *fleetStore) saveLocked(launchers []FleetLauncher) error {
+	raw, err := json.MarshalIndent(launchers, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		return err
+	}
+	f.launchers = launchers
+	return nil
+}
+
+func (s *Server) handleFleet(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "launchers": s.fleet.list()})
+	case http.MethodPost:
+		var l FleetLauncher
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&l); err != nil {
+			http.Error(w, "invalid JSON body", bodyErrorStatus(err))
+			return
+		}
+		saved, err := s.fleet.put(l)
+		if err != nil {
+			http.Error(w, err.Error(), httpStatusForError(err))
+			return
+		}
+		logInfo("fleet_launcher_saved", map[string]any{"name": saved.Name, "host": hostOf(saved.URL)})
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "launcher": saved})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleFleetRoute serves DELETE /api/fleet/<name> and forwards
+// /api/fleet/<name>/api/<path> to the remote launcher.
+func (s *Server) handleFleetRoute(w http.ResponseWriter, r *http.Request) {
+	name, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/fleet/"), "/")
+	name = strings.ToLower(strings.TrimSpace(name))
+	if rest == "" {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := s.fleet.remove(name); err != nil {
+			http.Error(w, err.Error(), httpStatusForError(err))
+			return
+		}
+		logInfo("fleet_launcher_removed", map[string]any{"name": name})
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+		return
+	}
+	remotePath, ok := strings.CutPrefix(rest, "api/")
+	if !ok || !isFleetProxyPath(remotePath) {
+		http.NotFound(w, r)
+		return
+	}
+	l, err := s.fleet.get(name)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatusForError(err))
+		return
+	}
+	s.forwardToLauncher(w, r, l, "/api/"+remotePath)
+}
+
+func isFleetProxyPath(p string) bool {
+	if strings.Contains(p, "..") {
+		return false
+	}
+	for _, prefix := range fleetProxyPrefixes {
+		if p == prefix || strings.HasPrefix(p, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardToLauncher relays the request and the remote answer. The local
+// CSRF and origin checks already ran; the remote applies its own. The
+// answer is served from this launcher's origin, so it only ever goes out
+// as JSON, plain text or an event stream, never as a page that could run
+// script next to the local session. Job and log streams stay open as long
+// as the client watches; everything else gets fleetProxyTimeout.
+func (s *Server) forwardToLauncher(w http.ResponseWriter, r *http.Request, l FleetLauncher, path string) {
+	c := l.client()
+	target := c.base + path
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	deadline := time.AfterFunc(fleetProxyTimeout, cancel)
+	defer deadline.Stop()
+	req, err := http.NewRequestWithContext(ctx, r.Method, target, r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, h := range []string{"Content-Type", "If-Match", "X-Confirm-Token"} {
+		if v := r.Header.Get(h); v != "" {
+			req.Header.Set(h, v)
+		}
+	}
+	req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: c.csrf})
+	req.Header.Set("X-CSRF-Token", c.csrf)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := (&http.Client{Transport: c.http.Transport}).Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		logWarn("fleet_proxy_failed", map[string]any{"name": l.Name, "path": path, "error": err.Error()})
+		http.Error(w, fmt.Sprintf("Launcher %s is unreachable: %v", l.Name, err), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	ct, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	stream := ct == "text/event-stream" || r.URL.Query().Get("follow") == "true"
+	switch {
+	case ct == "application/json":
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	case ct == "text/event-stream" && stream:
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+	default:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	flusher, canFlush := w.(http.Flusher)
+	if !stream || !canFlush || resp.StatusCode != http.StatusOK {
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, io.LimitReader(resp.Body, 8<<20))
+		return
+	}
+	deadline.Stop()
+	disableWriteDeadline(w)
+	w.WriteHeader(resp.StatusCode)
+	flusher.Flush()
+	_, _ = io.Copy(flushWriter{w: w, f: flusher}, resp.Body)
+}
+
+func hostOf(raw string) string {
+	if u, err := url.Parse(raw); err == nil {
+		return u.Host
+	}
+	return ""
+}
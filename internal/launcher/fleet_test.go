@@ -0,0 +1,128 @@
+package launcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestFleetStoreKeepsTokensPrivate(t *testing.T) {
+	dir := t.TempDir()
+	f := newFleetStore(dir)
+	saved, err := f.put(FleetLauncher{Name: "Client-A", URL: "https://a.example.com:7331/", Token: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Name != "client-a" || saved.URL != "https://a.example.com:7331" || saved.Token != "" || !saved.HasToken {
+		t.Fatalf("unexpected saved launcher %+v", saved)
+	}
+	// Re-registering without a token keeps the stored one.
+	if _, err := f.put(FleetLauncher{Name: "client-a", URL: "https://b.example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	reloaded := newFleetStore(dir)
+	l, err := reloaded.get("client-a")
+	if err != nil || l.Token != "secret" || l.URL != "https://b.example.com" {
+		t.Fatalf("expected the token to survive an update, got %+v (%v)", l, err)
+	}
+	if info, err := os.Stat(reloaded.path); err != nil || (runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0) {
+		t.Fatalf("expected fleet.json readable by the owner only, got %v (%v)", info.Mode(), err)
+	}
+	for _, bad := range []FleetLauncher{{Name: "bad name", URL: "https://a.example.com"}, {Name: "ok", URL: "ftp://a.example.com"}, {Name: "ok", URL: "https://a.example.com/api"}} {
+		if _, err := f.put(bad); err == nil {
+			t.Fatalf("expected %+v to be rejected", bad)
+		}
+	}
+}
+
+func TestFleetForwardsProfileRoutes(t *testing.T) {
+	var got *http.Request
+	var gotBody string
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		writeJSON(w, http.StatusAccepted, map[string]any{"ok": true, "jobId": "job-1"})
+	}))
+	defer remote.Close()
+
+	srv := newServiceTestServer(t)
+	srv.fleet = newFleetStore(t.TempDir())
+	if _, err := srv.fleet.put(FleetLauncher{Name: "client-a", URL: remote.URL, Token: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/fleet/client-a/api/profiles/alpha/version?x=1", strings.NewReader(`{"version":"1.2.0"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"3"`)
+	rec := httptest.NewRecorder()
+	srv.handleFleetRoute(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected the remote status, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got.URL.Path != "/api/profiles/alpha/version" || got.URL.RawQuery != "x=1" || gotBody != `{"version":"1.2.0"}` || got.Header.Get("If-Match") != `"3"` {
+		t.Fatalf("unexpected forwarded request %s %s %q", got.URL.Path, got.URL.RawQuery, gotBody)
+	}
+	cookie, err := got.Cookie(csrfCookieName)
+	if err != nil || cookie.Value != got.Header.Get("X-CSRF-Token") || got.Header.Get("Authorization") != "Bearer secret" {
+		t.Fatalf("expected CSRF cookie, header and bearer token, got %v", got.Header)
+	}
+
+	for path, want := range map[string]int{
+		"/api/fleet/client-a/api/settings":      http.StatusNotFound,
+		"/api/fleet/client-a/api/jobs/../trash": http.StatusNotFound,
+		"/api/fleet/missing/api/profiles":       http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		srv.handleFleetRoute(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Fatalf("%s: expected %d, got %d", path, want, rec.Code)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	srv.handleFleet(rec, httptest.NewRequest(http.MethodGet, "/api/fleet", nil))
+	var listed struct {
+		Launchers []map[string]any `json:"launchers"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &listed); err != nil || len(listed.Launchers) != 1 || listed.Launchers[0]["token"] != nil {
+		t.Fatalf("expected the token to be redacted, got %s", rec.Body.String())
+	}
+}
+
+func TestFleetServesRemoteAnswersAsData(t *testing.T) {
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/stream") {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "event: job\ndata: {}\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<script>alert(1)</script>")
+	}))
+	defer remote.Close()
+
+	srv := newServiceTestServer(t)
+	srv.fleet = newFleetStore(t.TempDir())
+	if _, err := srv.fleet.put(FleetLauncher{Name: "client-a", URL: remote.URL}); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.handleFleetRoute(rec, httptest.NewRequest(http.MethodGet, "/api/fleet/client-a/api/profiles/alpha", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" || rec.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Fatalf("expected remote HTML served as plain text, got %q %v", ct, rec.Header())
+	}
+
+	rec = httptest.NewRecorder()
+	srv.handleFleetRoute(rec, httptest.NewRequest(http.MethodGet, "/api/fleet/client-a/api/jobs/job-1/stream", nil))
+	if rec.Header().Get("Content-Type") != "text/event-stream" || !rec.Flushed || rec.Body.String() != "event: job\ndata: {}\n\n" {
+		t.Fatalf("expected the job stream relayed, got %v %q", rec.Header(), rec.Body.String())
+	}
+}
@@ -0,0 +1,100 @@
+package launcher
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// githubRateLimitFallback is used when GitHub refuses a request without
+// saying when the limit resets.
+const githubRateLimitFallback = time.Minute
+
+var ErrGitHubRateLimited = errors.New("github api rate limited")
+
+type GitHubRateLimitError struct {
+	Until time.Time
+}
+
+func (e GitHubRateLimitError) Error() string {
+	return "GitHub API rate-limited until " + e.Until.Local().Format("2006-01-02 15:04 MST")
+}
+
+func (e GitHubRateLimitError) Is(target error) bool { return target == ErrGitHubRateLimited }
+
+// githubRateLimit remembers when the API may be called again, so update
+// checks back off instead of burning requests that are sure to fail.
+type githubRateLimit struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+var githubRate = &githubRateLimit{}
+
+func (g *githubRateLimit) check(now time.Time) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if now.Before(g.until) {
+		return GitHubRateLimitError{Until: g.until}
+	}
+	return nil
+}
+
+// observe reads the rate-limit headers of a response. It returns an error
+// when the response itself was refused because of the limit.
+func (g *githubRateLimit) observe(resp *http.Response, now time.Time) error {
+	remaining := strings.TrimSpace(resp.Header.Get("X-RateLimit-Remaining"))
+	reset := time.Time{}
+	if v, err := strconv.ParseInt(strings.TrimSpace(resp.Header.Get("X-RateLimit-Reset")), 10, 64); err == nil {
+		reset = time.Unix(v, 0)
+	}
+	retryAfter := time.Duration(0)
+	if v, err := strconv.Atoi(strings.TrimSpace(resp.Header.Get("Retry-After"))); err == nil && v > 0 {
+		retryAfter = time.Duration(v) * time.Second
+	}
+
+	limited := (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) &&
+		(remaining == "0" || retryAfter > 0)
+	if !limited && remaining != "0" {
+		return nil
+	}
+
+	until := reset
+	if retryAfter > 0 {
+		until = now.Add(retryAfter)
+	}
+	if !until.After(now) {
+		if !limited {
+			return nil
+		}
+		until = now.Add(githubRateLimitFallback)
+	}
+	g.mu.Lock()
+	if until.After(g.until) {
+		g.until = until
+	}
+	g.mu.Unlock()
+	if !limited {
+		return nil
+	}
+	logWarn("github_rate_limited", map[string]any{"until": until.UTC().Format(time.RFC3339)})
+	return GitHubRateLimitError{Until: until}
+}
+
+// newGitHubRequest builds an API request, authenticated when a token is
+// configured. Authenticated calls get a far higher hourly limit.
+func newGitHubRequest(url string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "kimmio-launcher")
+	if token := strings.TrimSpace(appCfg.GitHubToken); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req, nil
+}
@@ -0,0 +1,66 @@
+package launcher
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFetchGitHubReleaseBacksOffWhenRateLimited(t *testing.T) {
+	prevRate, prevToken := githubRate, appCfg.GitHubToken
+	githubRate = &githubRateLimit{}
+	appCfg.GitHubToken = "secret"
+	defer func() { githubRate, appCfg.GitHubToken = prevRate, prevToken }()
+
+	reset := time.Now().Add(30 * time.Minute).Unix()
+	calls := 0
+	var gotAuth string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		gotAuth = r.Header.Get("Authorization")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	_, err := fetchGitHubRelease(ts.URL)
+	var limited GitHubRateLimitError
+	if !errors.As(err, &limited) || limited.Until.Unix() != reset {
+		t.Fatalf("expected rate limit until %d, got %v", reset, err)
+	}
+	if gotAuth != "Bearer secret" {
+		t.Fatalf("expected token to be sent, got %q", gotAuth)
+	}
+	if _, err := fetchGitHubRelease(ts.URL); !errors.Is(err, ErrGitHubRateLimited) {
+		t.Fatalf("expected second call to back off, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected no request while rate-limited, got %d calls", calls)
+	}
+}
+
+func TestGitHubRateLimitObserveRetryAfter(t *testing.T) {
+	g := &githubRateLimit{}
+	now := time.Now()
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	resp.Header.Set("Retry-After", "120")
+	if err := g.observe(resp, now); !errors.Is(err, ErrGitHubRateLimited) {
+		t.Fatalf("expected rate limit error, got %v", err)
+	}
+	if err := g.check(now.Add(time.Minute)); err == nil {
+		t.Fatalf("expected calls to stay blocked during Retry-After")
+	}
+	if err := g.check(now.Add(3 * time.Minute)); err != nil {
+		t.Fatalf("expected calls to resume, got %v", err)
+	}
+
+	ok := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	ok.Header.Set("X-RateLimit-Remaining", "59")
+	if err := g.observe(ok, now); err != nil {
+		t.Fatalf("unexpected error for successful response: %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// grpcMethodFunc handles one unary RPC using dynamic request/response messages.
+type grpcMethodFunc func(s *Server, ctx context.Context, req protoreflect.Message) (protoreflect.Message, error)
+
+type grpcHandler interface{ grpcServer() *Server }
+
+func (s *Server) grpcServer() *Server { return s }
+
+// startGRPCServer serves the management API on 127.0.0.1:<port>. Like the
+// HTTP API it only accepts local connections.
+func (s *Server) startGRPCServer(port int) error {
+	gs, err := s.newGRPCServer()
+	if err != nil {
+		return err
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:"+strconv.Itoa(port))
+	if err != nil {
+		return err
+	}
+	logInfo("grpc_server_start", map[string]any{"port": port})
+	go func() {
+		if err := gs.Serve(ln); err != nil {
+			logError("grpc_server_stopped", map[string]any{"error": err.Error()})
+		}
+	}()
+	return nil
+}
+
+func (s *Server) newGRPCServer() (*grpc.Server, error) {
+	fd, err := launcherProtoFile()
+	if err != nil {
+		return nil, err
+	}
+	gs := grpc.NewServer()
+	for i := 0; i < fd.Services().Len(); i++ {
+		svc := fd.Services().Get(i)
+		desc := &grpc.ServiceDesc{
+			ServiceName: string(svc.FullName()),
+			HandlerType: (*grpcHandler)(nil),
+			Metadata:    fd.Path(),
+		}
+		for j := 0; j < svc.Methods().Len(); j++ {
+			method := svc.Methods().Get(j)
+			impl, ok := grpcMethods[string(method.Name())]
+			if !ok {
+				continue
+			}
+			desc.Methods = append(desc.Methods, grpc.MethodDesc{
+				MethodName: string(method.Name()),
+				Handler:    grpcUnaryHandler(method, impl),
+			})
+		}
+		gs.RegisterService(desc, s)
+	}
+	reflection.Register(gs)
+	return gs, nil
+}
+
+func grpcUnaryHandler(method protoreflect.MethodDescriptor, impl grpcMethodFunc) func(any, context.Context, func(any) error, grpc.UnaryServerInterceptor) (any, error) {
+	return func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+		req := dynamicpb.NewMessage(method.Input())
+		if err := dec(req); err != nil {
+			return nil, err
+		}
+		s := srv.(grpcHandler).grpcServer()
+		call := func(ctx context.Context, in any) (any, error) {
+			out, err := impl(s, ctx, in.(*dynamicpb.Message))
+			if err != nil {
+				return nil, err
+			}
+			return out.Interface(), nil
+		}
+		if interceptor == nil {
+			return call(ctx, req)
+		}
+		info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + string(method.Parent().FullName()) + "/" + string(method.Name())}
+		return interceptor(ctx, req, info, call)
+	}
+}
+
+var grpcMethods = map[string]grpcMethodFunc{
+	"ListProfiles": grpcListProfiles,
+	"GetProfile":   grpcGetProfile,
+	"RunAction":    grpcRunAction,
+	"GetJob":       grpcGetJob,
+	"CancelJob":    grpcCancelJob,
+}
+
+func grpcListProfiles(s *Server, ctx context.Context, req protoreflect.Message) (protoreflect.Message, error) {
+	profiles, err := s.Profiles().List(ctx)
+	if err != nil {
+		return nil, grpcStatusForError(err)
+	}
+	fd := req.Descriptor().ParentFile()
+	resp := newProtoMessage(fd, "ListProfilesResponse")
+	list := resp.Mutable(resp.Descriptor().Fields().ByName("profiles")).List()
+	for _, p := range profiles {
+		list.Append(protoreflect.ValueOfMessage(profileToProto(fd, p)))
+	}
+	return resp, nil
+}
+
+func grpcGetProfile(s *Server, ctx context.Context, req protoreflect.Message) (protoreflect.Message, error) {
+	p, err := s.Profiles().Get(ctx, protoGetString(req, "id"))
+	if err != nil {
+		return nil, grpcStatusForError(err)
+	}
+	return profileToProto(req.Descriptor().ParentFile(), p), nil
+}
+
+func grpcRunAction(s *Server, ctx context.Context, req protoreflect.Message) (protoreflect.Message, error) {
+	id := protoGetString(req, "id")
+	confirmed := strings.TrimSpace(protoGetString(req, "confirm")) == normalizeProfileID(id)
+	job, err := s.Profiles().StartAction(ctx, id, protoGetString(req, "action"), protoGetString(req, "version"), protoGetInt(req, "expected_revision"), confirmed)
+	if err != nil {
+		return nil, grpcStatusForError(err)
+	}
+	resp := newProtoMessage(req.Descriptor().ParentFile(), "RunActionResponse")
+	protoSet(resp, "job_id", job.ID)
+	return resp, nil
+}
+
+func grpcGetJob(s *Server, _ context.Context, req protoreflect.Message) (protoreflect.Message, error) {
+	job, err := s.Jobs().Get(protoGetString(req, "id"))
+	if err != nil {
+		return nil, grpcStatusForError(err)
+	}
+	resp := newProtoMessage(req.Descriptor().ParentFile(), "Job")
+	protoSet(resp, "id", job.ID)
+	protoSet(resp, "profile_id", job.ProfileID)
+	protoSet(resp, "action", job.Action)
+	protoSet(resp, "step", job.Step)
+	protoSet(resp, "status", job.Status)
+	protoSet(resp, "message", job.Message)
+	protoSet(resp, "progress", job.Progress)
+	protoSet(resp, "error", job.Error)
+	protoSet(resp, "logs", job.Logs)
+	protoSet(resp, "started_at", job.StartedAt)
+	protoSet(resp, "finished_at", job.FinishedAt)
+	return resp, nil
+}
+
+func grpcCancelJob(s *Server, _ context.Context, req protoreflect.Message) (protoreflect.Message, error) {
+	if err := s.Jobs().Cancel(protoGetString(req, "id")); err != nil {
+		return nil, grpcStatusForError(err)
+	}
+	resp := newProtoMessage(req.Descriptor().ParentFile(), "CancelJobResponse")
+	protoSet(resp, "canceled", true)
+	return resp, nil
+}
+
+// grpcStatusForError maps service layer errors onto gRPC status codes.
+func grpcStatusForError(err error) error {
+	var ve ValidationError
+	switch {
+	case errors.As(err, &ve), errors.Is(err, ErrUnknownAction):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ErrProfileNotFound), errors.Is(err, ErrJobNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, ErrProfileExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, ErrRevisionConflict):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, ErrProfileBusy), errors.Is(err, ErrJobCompleted), errors.Is(err, ErrProfileLimitReached),
+		errors.Is(err, ErrConfirmationRequired):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func profileToProto(fd protoreflect.FileDescriptor, p ProfileRequest) *dynamicpb.Message {
+	m := newProtoMessage(fd, "Profile")
+	protoSet(m, "id", p.ID)
+	protoSet(m, "display_name", p.DisplayName)
+	protoSet(m, "version", p.Version)
+	protoSet(m, "revision", p.Revision)
+	protoSet(m, "host_port", profileHostPort(p))
+	protoSet(m, "enabled", p.Enabled)
+	protoSet(m, "running", p.Running)
+	protoSet(m, "runtime_status", p.RuntimeStatus)
+	protoSet(m, "active_job_id", p.ActiveJobID)
+	protoSet(m, "last_action", p.LastAction)
+	protoSet(m, "last_action_status", p.LastActionStatus)
+	protoSet(m, "last_action_result", p.LastActionResult)
+	protoSet(m, "last_action_at", p.LastActionAt)
+	return m
+}
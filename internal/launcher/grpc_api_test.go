@@ -0,0 +1,92 @@
+package launcher
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"launcher/internal/config"
+)
+
+func TestGRPCListProfilesAndGetJob(t *testing.T) {
+	tmp := t.TempDir()
+	cfg := config.Load("dev")
+	cfg.DataDir = tmp
+	appCfg = cfg
+	srv := NewServer(cfg)
+	srv.dbPath = filepath.Join(tmp, "profiles.json")
+	if err := writeProfileStoreAtomic(srv.dbPath, ProfileStore{Profiles: []ProfileRequest{
+		{ID: "alpha", Version: "1.0.0", Ports: []PortMapping{{Container: 3000, Host: 8123}}},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	srv.jobs["job1"] = &ActionJob{ID: "job1", ProfileID: "alpha", Status: "running", Progress: 30, Logs: []string{"a"}}
+
+	gs, err := srv.newGRPCServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = gs.Serve(ln) }()
+	defer gs.Stop()
+
+	conn, err := grpc.NewClient(ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	fd, err := launcherProtoFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	listResp := newProtoMessage(fd, "ListProfilesResponse")
+	if err := conn.Invoke(ctx, "/kimmio.launcher.v1.ProfileService/ListProfiles", newProtoMessage(fd, "ListProfilesRequest"), listResp); err != nil {
+		t.Fatalf("ListProfiles failed: %v", err)
+	}
+	profiles := listResp.Get(listResp.Descriptor().Fields().ByName("profiles")).List()
+	if profiles.Len() != 1 {
+		t.Fatalf("expected 1 profile, got %d", profiles.Len())
+	}
+	first := profiles.Get(0).Message()
+	if protoGetString(first, "id") != "alpha" || first.Get(first.Descriptor().Fields().ByName("host_port")).Int() != 8123 {
+		t.Fatalf("unexpected profile message: %v", first)
+	}
+
+	jobReq := newProtoMessage(fd, "GetJobRequest")
+	protoSet(jobReq, "id", "job1")
+	jobResp := newProtoMessage(fd, "Job")
+	if err := conn.Invoke(ctx, "/kimmio.launcher.v1.JobService/GetJob", jobReq, jobResp); err != nil {
+		t.Fatalf("GetJob failed: %v", err)
+	}
+	if got := jobResp.Get(jobResp.Descriptor().Fields().ByName("progress")).Int(); got != 30 {
+		t.Fatalf("expected progress 30, got %d", got)
+	}
+
+	missing := newProtoMessage(fd, "GetProfileRequest")
+	protoSet(missing, "id", "nope-profile")
+	err = conn.Invoke(ctx, "/kimmio.launcher.v1.ProfileService/GetProfile", missing, newProtoMessage(fd, "Profile"))
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+
+	purge := newProtoMessage(fd, "RunActionRequest")
+	protoSet(purge, "id", "alpha")
+	protoSet(purge, "action", "purge")
+	err = conn.Invoke(ctx, "/kimmio.launcher.v1.ProfileService/RunAction", purge, newProtoMessage(fd, "RunActionResponse"))
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected an unconfirmed purge to fail its precondition, got %v", err)
+	}
+}
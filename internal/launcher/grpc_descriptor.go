@@ -0,0 +1,192 @@
+package launcher
+
+import (
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// The gRPC API is described by api/proto/launcher/v1/launcher.proto. Its
+// descriptor is assembled here instead of generated so the build does not
+// depend on protoc; messages are handled through dynamicpb.
+
+const grpcProtoPackage = "kimmio.launcher.v1"
+
+var (
+	grpcFileOnce sync.Once
+	grpcFile     protoreflect.FileDescriptor
+	grpcFileErr  error
+)
+
+func launcherProtoFile() (protoreflect.FileDescriptor, error) {
+	grpcFileOnce.Do(func() {
+		fd, err := protodesc.NewFile(launcherFileDescriptorProto(), protoregistry.GlobalFiles)
+		if err != nil {
+			grpcFileErr = err
+			return
+		}
+		// Registration makes the services discoverable through gRPC reflection.
+		if err := protoregistry.GlobalFiles.RegisterFile(fd); err != nil {
+			grpcFileErr = err
+			return
+		}
+		grpcFile = fd
+	})
+	return grpcFile, grpcFileErr
+}
+
+func launcherFileDescriptorProto() *descriptorpb.FileDescriptorProto {
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING
+	i32 := descriptorpb.FieldDescriptorProto_TYPE_INT32
+	boolean := descriptorpb.FieldDescriptorProto_TYPE_BOOL
+
+	return &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("launcher/v1/launcher.proto"),
+		Package: proto.String(grpcProtoPackage),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			protoMessage("Profile",
+				protoField("id", 1, str),
+				protoField("version", 2, str),
+				protoField("host_port", 3, i32),
+				protoField("enabled", 4, boolean),
+				protoField("running", 5, boolean),
+				protoField("runtime_status", 6, str),
+				protoField("active_job_id", 7, str),
+				protoField("last_action", 8, str),
+				protoField("last_action_status", 9, str),
+				protoField("last_action_result", 10, str),
+				protoField("last_action_at", 11, str),
+				protoField("revision", 12, i32),
+				protoField("display_name", 13, str),
+			),
+			protoMessage("ListProfilesRequest"),
+			protoMessage("ListProfilesResponse", protoRepeatedMessage("profiles", 1, "Profile")),
+			protoMessage("GetProfileRequest", protoField("id", 1, str)),
+			protoMessage("RunActionRequest",
+				protoField("id", 1, str),
+				protoField("action", 2, str),
+				protoField("version", 3, str),
+				protoField("expected_revision", 4, i32),
+				protoField("confirm", 5, str),
+			),
+			protoMessage("RunActionResponse", protoField("job_id", 1, str)),
+			protoMessage("Job",
+				protoField("id", 1, str),
+				protoField("profile_id", 2, str),
+				protoField("action", 3, str),
+				protoField("step", 4, str),
+				protoField("status", 5, str),
+				protoField("message", 6, str),
+				protoField("progress", 7, i32),
+				protoField("error", 8, str),
+				protoRepeated(protoField("logs", 9, str)),
+				protoField("started_at", 10, str),
+				protoField("finished_at", 11, str),
+			),
+			protoMessage("GetJobRequest", protoField("id", 1, str)),
+			protoMessage("CancelJobRequest", protoField("id", 1, str)),
+			protoMessage("CancelJobResponse", protoField("canceled", 1, boolean)),
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{
+			protoService("ProfileService",
+				protoMethod("ListProfiles", "ListProfilesRequest", "ListProfilesResponse"),
+				protoMethod("GetProfile", "GetProfileRequest", "Profile"),
+				protoMethod("RunAction", "RunActionRequest", "RunActionResponse"),
+			),
+			protoService("JobService",
+				protoMethod("GetJob", "GetJobRequest", "Job"),
+				protoMethod("CancelJob", "CancelJobRequest", "CancelJobResponse"),
+			),
+		},
+	}
+}
+
+func protoMessage(name string, fields ...*descriptorpb.FieldDescriptorProto) *descriptorpb.DescriptorProto {
+	return &descriptorpb.DescriptorProto{Name: proto.String(name), Field: fields}
+}
+
+func protoField(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+	return &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		Number:   proto.Int32(number),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:     typ.Enum(),
+		JsonName: proto.String(protoJSONName(name)),
+	}
+}
+
+func protoRepeated(field *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+	field.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	return field
+}
+
+func protoRepeatedMessage(name string, number int32, messageName string) *descriptorpb.FieldDescriptorProto {
+	field := protoRepeated(protoField(name, number, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE))
+	field.TypeName = proto.String("." + grpcProtoPackage + "." + messageName)
+	return field
+}
+
+func protoService(name string, methods ...*descriptorpb.MethodDescriptorProto) *descriptorpb.ServiceDescriptorProto {
+	return &descriptorpb.ServiceDescriptorProto{Name: proto.String(name), Method: methods}
+}
+
+func protoMethod(name, input, output string) *descriptorpb.MethodDescriptorProto {
+	return &descriptorpb.MethodDescriptorProto{
+		Name:       proto.String(name),
+		InputType:  proto.String("." + grpcProtoPackage + "." + input),
+		OutputType: proto.String("." + grpcProtoPackage + "." + output),
+	}
+}
+
+func protoJSONName(name string) string {
+	out := make([]byte, 0, len(name))
+	upper := false
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c == '_' {
+			upper = true
+			continue
+		}
+		if upper && c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		upper = false
+		out = append(out, c)
+	}
+	return string(out)
+}
+
+func newProtoMessage(fd protoreflect.FileDescriptor, name string) *dynamicpb.Message {
+	return dynamicpb.NewMessage(fd.Messages().ByName(protoreflect.Name(name)))
+}
+
+func protoGetString(m protoreflect.Message, name string) string {
+	return m.Get(m.Descriptor().Fields().ByName(protoreflect.Name(name))).String()
+}
+
+func protoGetInt(m protoreflect.Message, name string) int {
+	return int(m.Get(m.Descriptor().Fields().ByName(protoreflect.Name(name))).Int())
+}
+
+func protoSet(m protoreflect.Message, name string, v any) {
+	field := m.Descriptor().Fields().ByName(protoreflect.Name(name))
+	switch val := v.(type) {
+	case string:
+		m.Set(field, protoreflect.ValueOfString(val))
+	case bool:
+		m.Set(field, protoreflect.ValueOfBool(val))
+	case int:
+		m.Set(field, protoreflect.ValueOfInt32(int32(val)))
+	case []string:
+		list := m.Mutable(field).List()
+		for _, item := range val {
+			list.Append(protoreflect.ValueOfString(item))
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package launcher
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	healthCheckHTTP      = "http"
+	healthCheckTCP       = "tcp"
+	healthCheckContainer = "container"
+	healthCheckCommand   = "command"
+)
+
+const appServiceName = "kimmio_app"
+
+// healthProbeTimeout bounds a single probe, both in the launcher and in the
+// compose healthcheck, so a slow response counts the same in both places.
+const healthProbeTimeout = 5 * time.Second
+
+// HealthSettings controls how the launcher probes a profile. The zero value
+// keeps the historical behavior: GET http://localhost:<port>/health.
+type HealthSettings struct {
+	Type               string `json:"type,omitempty"`
+	Command            string `json:"command,omitempty"`
+	Scheme             string `json:"scheme,omitempty"`
+	Path               string `json:"path,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
+}
+
+func normalizeHealthSettings(h *HealthSettings) error {
+	h.Type = strings.ToLower(strings.TrimSpace(h.Type))
+	switch h.Type {
+	case "", healthCheckHTTP, healthCheckTCP, healthCheckContainer:
+	case healthCheckCommand:
+		if strings.TrimSpace(h.Command) == "" {
+			return fieldError("healthCommand", "health.command_required", "health command is required for command health checks")
+		}
+	default:
+		return fieldError("healthType", "health.type", "health type must be http, tcp, container or command")
+	}
+	h.Command = strings.TrimSpace(h.Command)
+	if len(h.Command) > 512 {
+		return fieldError("healthCommand", "health.command_length", "health command must be at most 512 characters")
+	}
+	h.Scheme = strings.ToLower(strings.TrimSpace(h.Scheme))
+	switch h.Scheme {
+	case "", "http", "https":
+	default:
+		return fieldError("healthScheme", "health.scheme", "health scheme must be http or https")
+	}
+	h.Path = strings.TrimSpace(h.Path)
+	if h.Path != "" && !strings.HasPrefix(h.Path, "/") {
+		return fieldError("healthPath", "health.path", "health path must start with /")
+	}
+	if strings.ContainsAny(h.Path, " \t\r\n") {
+		return fieldError("healthPath", "health.path", "health path must not contain whitespace")
+	}
+	return nil
+}
+
+// isProfileHealthy runs the profile's configured probe. Probes give up as soon
+// as ctx is done, so a canceled job or request never waits on a slow app.
+func isProfileHealthy(ctx context.Context, profile ProfileRequest) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	switch profile.Health.Type {
+	case healthCheckTCP:
+		return probeProfileTCP(ctx, profile)
+	case healthCheckContainer:
+		return probeProfileContainerHealth(ctx, profile)
+	case healthCheckCommand:
+		return probeProfileCommand(ctx, profile)
+	default:
+		return probeProfileHTTP(ctx, profile)
+	}
+}
+
+func profileHostPort(profile ProfileRequest) int {
+	if len(profile.Ports) > 0 {
+		return profile.Ports[0].Host
+	}
+	return 0
+}
+
+func probeProfileHTTP(parent context.Context, profile ProfileRequest) bool {
+	hostPort := profileHostPort(profile)
+	if hostPort <= 0 {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(parent, healthProbeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, profileHealthURL(profile, strconv.Itoa(hostPort)), nil)
+	if err != nil {
+		return false
+	}
+	// Apps behind APP_DOMAIN may route or issue certificates by host name, so
+	// probe as that host while still connecting to the local port.
+	req.Host = profileHealthHost(profile)
+
+	client := http.Client{}
+	if profile.Health.Scheme == "https" {
+		tlsCfg := &tls.Config{InsecureSkipVerify: profile.Health.InsecureSkipVerify}
+		if req.Host != "" {
+			tlsCfg.ServerName = req.Host
+		}
+		client.Transport = &http.Transport{TLSClientConfig: tlsCfg}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode >= 200 && resp.StatusCode < 300
+}
+
+// profileHealthURL is the URL the HTTP probe requests, relative to the app's
+// own port so it works from the host and from inside the container.
+func profileHealthURL(profile ProfileRequest, port string) string {
+	scheme := profile.Health.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+	path := profile.Health.Path
+	if path == "" {
+		path = "/health"
+	}
+	return scheme + "://localhost:" + port + path
+}
+
+// profileHealthHost is the Host header probes send: APP_DOMAIN unless it is
+// localhost.
+func profileHealthHost(profile ProfileRequest) string {
+	domain := strings.TrimSpace(profile.Env["APP_DOMAIN"])
+	if domain == "" || strings.EqualFold(domain, "localhost") {
+		return ""
+	}
+	return domain
+}
+
+// composeHealthcheck renders the app service's healthcheck from the same
+// HealthSettings the launcher probes with, so "healthy" in docker ps and in
+// the UI agree. Container checks defer to Docker, which runs the default
+// HTTP probe.
+func composeHealthcheck(profile ProfileRequest) string {
+	var shell string
+	switch profile.Health.Type {
+	case healthCheckTCP:
+		shell = "nc -z 127.0.0.1 ${APP_PORT}"
+	case healthCheckCommand:
+		// The command is user text; keep compose from interpolating it.
+		shell = strings.ReplaceAll(profile.Health.Command, "$", "$$")
+	default:
+		shell = "wget -q -O /dev/null -T " + strconv.Itoa(int(healthProbeTimeout/time.Second))
+		if profile.Health.Scheme == "https" && profile.Health.InsecureSkipVerify {
+			shell += " --no-check-certificate"
+		}
+		if host := profileHealthHost(profile); host != "" {
+			shell += " --header 'Host: " + host + "'"
+		}
+		shell += " " + profileHealthURL(profile, "${APP_PORT}")
+	}
+	test, _ := json.Marshal([]string{"CMD-SHELL", shell})
+	return "    healthcheck:\n" +
+		"      test: " + string(test) + "\n" +
+		"      interval: 30s\n" +
+		"      timeout: " + healthProbeTimeout.String() + "\n" +
+		"      retries: 5\n"
+}
+
+func probeProfileTCP(ctx context.Context, profile ProfileRequest) bool {
+	hostPort := profileHostPort(profile)
+	if hostPort <= 0 {
+		return false
+	}
+	dialer := net.Dialer{Timeout: healthProbeTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", "127.0.0.1:"+strconv.Itoa(hostPort))
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+func probeProfileContainerHealth(parent context.Context, profile ProfileRequest) bool {
+	ctx, cancel := context.WithTimeout(parent, 5*time.Second)
+	defer cancel()
+	dockerBin, containerID, err := profileAppContainer(ctx, profile.ID)
+	if err != nil {
+		return false
+	}
+	var status string
+	if engine, err := newDockerEngine(); err == nil {
+		var c dockerInspectState
+		if err := engine.inspectContainer(ctx, containerID, &c); err != nil {
+			return false
+		}
+		status = c.State.Status
+		if c.State.Health != nil {
+			status = c.State.Health.Status
+		}
+	} else {
+		out, err := dockerCommandWithContext(ctx, dockerBin, "inspect", "--format", "{{if .State.Health}}{{.State.Health.Status}}{{else}}{{.State.Status}}{{end}}", containerID).Output()
+		if err != nil {
+			return false
+		}
+		status = strings.TrimSpace(string(out))
+	}
+	// Containers without a HEALTHCHECK report their plain state instead.
+	switch status {
+	case "healthy", "running":
+		return true
+	default:
+		return false
+	}
+}
+
+func probeProfileCommand(parent context.Context, profile ProfileRequest) bool {
+	ctx, cancel := context.WithTimeout(parent, 10*time.Second)
+	defer cancel()
+	dockerBin, containerID, err := profileAppContainer(ctx, profile.ID)
+	if err != nil {
+		return false
+	}
+	if engine, err := newDockerEngine(); err == nil {
+		code, err := engine.execInContainer(ctx, containerID, []string{"sh", "-c", profile.Health.Command})
+		return err == nil && code == 0
+	}
+	return dockerCommandWithContext(ctx, dockerBin, "exec", containerID, "sh", "-c", profile.Health.Command).Run() == nil
+}
+
+func profileAppContainer(ctx context.Context, profileID string) (string, string, error) {
+	dockerBin, err := dockerBinaryPath()
+	if err != nil {
+		return "", "", err
+	}
+	ps, err := composeCommandWithContext(ctx, "-p", dockerProjectName(profileID), "ps", "-q", appServiceName)
+	if err != nil {
+		return "", "", err
+	}
+	out, err := ps.Output()
+	if err != nil {
+		return "", "", err
+	}
+	containerID := strings.TrimSpace(strings.Split(strings.TrimSpace(string(out)), "\n")[0])
+	if containerID == "" {
+		return "", "", errors.New("app container is not running")
+	}
+	return dockerBin, containerID, nil
+}
@@ -0,0 +1,271 @@
+package launcher
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const healthCacheInterval = 15 * time.Second
+
+type cachedHealth struct {
+	Running       bool
+	RuntimeStatus string
+	Services      []ServiceState
+	CrashLog      []string
+	CheckedAt     time.Time
+}
+
+type healthCache struct {
+	mu       sync.RWMutex
+	statuses map[string]cachedHealth
+}
+
+func newHealthCache() *healthCache {
+	return &healthCache{statuses: map[string]cachedHealth{}}
+}
+
+func (c *healthCache) get(id string, maxAge time.Duration) (cachedHealth, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.statuses[id]
+	if !ok || time.Since(entry.CheckedAt) > maxAge {
+		return cachedHealth{}, false
+	}
+	return entry, true
+}
+
+// statusTransition is a runtime status change the health cache saw between
+// two probes of a profile.
+type statusTransition struct {
+	ProfileID string
+	From, To  string
+}
+
+// store records probe results and returns the status changes. Profiles
+// probed for the first time, or again after an action invalidated them,
+// have nothing to compare with and report no change.
+func (c *healthCache) store(profiles []ProfileRequest) []statusTransition {
+	now := time.Now().UTC()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var changes []statusTransition
+	for _, p := range profiles {
+		prev, ok := c.statuses[p.ID]
+		if ok && prev.RuntimeStatus != "" && p.RuntimeStatus != "" && prev.RuntimeStatus != p.RuntimeStatus {
+			changes = append(changes, statusTransition{ProfileID: p.ID, From: prev.RuntimeStatus, To: p.RuntimeStatus})
+		}
+		c.statuses[p.ID] = cachedHealth{Running: p.Running, RuntimeStatus: p.RuntimeStatus, Services: p.Services, CrashLog: p.CrashLog, CheckedAt: now}
+	}
+	return changes
+}
+
+func sameServiceStates(a, b []ServiceState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *healthCache) invalidate(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.statuses, id)
+}
+
+// startHealthMonitor keeps the health cache warm so status reads never block
+// on probes. The interval comes from the settings and a change takes effect
+// immediately.
+func (s *Server) startHealthMonitor(ctx context.Context) {
+	go func() {
+		for {
+			s.refreshHealthCache(ctx)
+			timer := time.NewTimer(s.settings.get().healthInterval())
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-s.settings.changed():
+				timer.Stop()
+			case <-timer.C:
+			}
+		}
+	}()
+}
+
+func (s *Server) refreshHealthCache(ctx context.Context) {
+	store, err := s.readStore(ctx)
+	if err != nil {
+		logWarn("health_cache_refresh_failed", map[string]any{"error": err.Error()})
+		return
+	}
+	profiles := applyHealthStatus(ctx, store.Profiles)
+	if ctx.Err() != nil {
+		// Probes cut short by shutdown would read as unhealthy; keep the old entries.
+		return
+	}
+	s.storeHealth(profiles)
+	s.recordUptime(profiles, time.Now(), s.settings.get().healthInterval())
+}
+
+// storeHealth caches probe results and records status changes in the audit
+// log for the activity feed.
+func (s *Server) storeHealth(profiles []ProfileRequest) {
+	for _, change := range s.health.store(profiles) {
+		level := "WARN"
+		if change.To == "running" {
+			level = "INFO"
+		}
+		auditLog(level, "profile_status_changed", map[string]any{"profile": change.ProfileID, "from": change.From, "to": change.To})
+		go s.notifyStatusChange(change, time.Now())
+	}
+}
+
+// profilesWithStatus returns stored profiles decorated with cached health,
+// probing synchronously only for profiles the monitor has not seen yet.
+func (s *Server) profilesWithStatus(ctx context.Context) ([]ProfileRequest, error) {
+	store, err := s.readStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+	profiles := make([]ProfileRequest, len(store.Profiles))
+	copy(profiles, store.Profiles)
+	missing := []int{}
+	for i := range profiles {
+		if entry, ok := s.health.get(profiles[i].ID, 2*s.settings.get().healthInterval()); ok {
+			profiles[i].Running = entry.Running
+			profiles[i].RuntimeStatus = entry.RuntimeStatus
+			profiles[i].Services = entry.Services
+			profiles[i].CrashLog = entry.CrashLog
+			continue
+		}
+		missing = append(missing, i)
+	}
+	if len(missing) > 0 {
+		probe := make([]ProfileRequest, 0, len(missing))
+		for _, i := range missing {
+			probe = append(probe, profiles[i])
+		}
+		probed := applyHealthStatus(ctx, probe)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		s.storeHealth(probed)
+		for n, i := range missing {
+			profiles[i] = probed[n]
+		}
+	}
+	alerting := s.alertingMetrics()
+	now := time.Now()
+	for i := range profiles {
+		profiles[i].Alerting = alerting[profiles[i].ID]
+		profiles[i].Links = profileLinks(profiles[i])
+		profiles[i].Uptime = s.uptime.uptime(profiles[i].ID, now)
+	}
+	return s.attachActiveJobs(profiles), nil
+}
+
+func (s *Server) handleListProfiles(w http.ResponseWriter, r *http.Request) {
+	query, err := parseProfileQuery(r.URL.Query(), 0)
+	if err != nil {
+		var ve ValidationError
+		errors.As(err, &ve)
+		writeValidationError(w, ve)
+		return
+	}
+	profiles, err := s.Profiles().List(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load profiles: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if negotiateFormat(r, formatJSON) == formatFragment {
+		kept, _ := splitTrashedProfiles(profiles)
+		active, _ := splitArchivedProfiles(kept)
+		active, _ = query.apply(active)
+		s.writeFragment(w, http.StatusOK, "profile-list", active)
+		return
+	}
+	profiles, total := query.apply(profiles)
+	payload := map[string]any{
+		"ok":       true,
+		"profiles": profiles,
+		"total":    total,
+	}
+	if query.PageSize > 0 {
+		payload["page"] = query.Page
+		payload["pageSize"] = query.PageSize
+		payload["pages"] = query.pages(total)
+	}
+	writeConditionalJSON(w, r, payload)
+}
+
+func (s *Server) handleProfileStatus(w http.ResponseWriter, r *http.Request, id string) {
+	p, err := s.Profiles().Get(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatusForError(err))
+		return
+	}
+	if negotiateFormat(r, formatJSON) == formatFragment {
+		s.writeFragment(w, http.StatusOK, "profile-row", p)
+		return
+	}
+	writeConditionalJSON(w, r, map[string]any{
+		"ok":            true,
+		"id":            p.ID,
+		"revision":      p.Revision,
+		"enabled":       p.Enabled,
+		"running":       p.Running,
+		"runtimeStatus": p.RuntimeStatus,
+		"alerting":      p.Alerting,
+		"activeJobId":   p.ActiveJobID,
+		"services":      p.Services,
+		"crashLog":      p.CrashLog,
+		"uptime":        p.Uptime,
+	})
+}
+
+// writeConditionalJSON answers with 304 when the client already holds the
+// same representation. Only the ETag decides: the answer mixes stored
+// settings, probes and jobs, which change at times no single clock tracks,
+// so no Last-Modified is sent and If-Modified-Since is ignored.
+func writeConditionalJSON(w http.ResponseWriter, r *http.Request, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sum := sha256.Sum256(b)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if inm := strings.TrimSpace(r.Header.Get("If-None-Match")); inm != "" && etagMatches(inm, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(append(b, '\n'))
+}
+
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package launcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"launcher/internal/config"
+)
+
+func TestListProfilesSupportsETag(t *testing.T) {
+	tmp := t.TempDir()
+	cfg := config.Load("dev")
+	cfg.DataDir = tmp
+	appCfg = cfg
+	srv := NewServer(cfg)
+	srv.dbPath = filepath.Join(tmp, "profiles.json")
+	if err := writeProfileStoreAtomic(srv.dbPath, ProfileStore{Profiles: []ProfileRequest{{ID: "alpha", Version: "latest"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	first := httptest.NewRecorder()
+	srv.handleProfiles(first, httptest.NewRequest(http.MethodGet, "/api/profiles", nil))
+	if first.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", first.Code)
+	}
+	etag := first.Header().Get("ETag")
+	if etag == "" {
+		t.Fatalf("expected ETag header")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/profiles", nil)
+	req.Header.Set("If-None-Match", etag)
+	second := httptest.NewRecorder()
+	srv.handleProfiles(second, req)
+	if second.Code != http.StatusNotModified {
+		t.Fatalf("expected 304, got %d", second.Code)
+	}
+
+	if err := srv.markProfileResult(context.Background(), "alpha", "stop", "success", "Profile stopped", ""); err != nil {
+		t.Fatal(err)
+	}
+	third := httptest.NewRecorder()
+	srv.handleProfiles(third, req)
+	if third.Code != http.StatusOK {
+		t.Fatalf("expected 200 after profile change, got %d", third.Code)
+	}
+
+	// Without an ETag there is nothing to compare: a client sending only
+	// If-Modified-Since always gets the current list.
+	since := httptest.NewRequest(http.MethodGet, "/api/profiles", nil)
+	since.Header.Set("If-Modified-Since", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	fourth := httptest.NewRecorder()
+	srv.handleProfiles(fourth, since)
+	if fourth.Code != http.StatusOK || fourth.Header().Get("Last-Modified") != "" {
+		t.Fatalf("expected 200 without Last-Modified, got %d %v", fourth.Code, fourth.Header())
+	}
+}
@@ -0,0 +1,139 @@
+package launcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsProfileHealthyHTTPSWithDomainHost(t *testing.T) {
+	var gotHost string
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost = r.Host
+		if r.URL.Path != "/ready" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, _ := strconv.Atoi(u.Port())
+	profile := ProfileRequest{
+		ID:     "tls-profile",
+		Ports:  []PortMapping{{Container: 3000, Host: port}},
+		Env:    map[string]string{"APP_DOMAIN": "app.example.com"},
+		Health: HealthSettings{Scheme: "https", Path: "/ready"},
+	}
+
+	if isProfileHealthy(context.Background(), profile) {
+		t.Fatalf("expected self-signed certificate to fail without insecure flag")
+	}
+	profile.Health.InsecureSkipVerify = true
+	if !isProfileHealthy(context.Background(), profile) {
+		t.Fatalf("expected https probe to succeed with insecure flag")
+	}
+	if gotHost != "app.example.com" {
+		t.Fatalf("expected domain host header, got %q", gotHost)
+	}
+}
+
+func TestNormalizeHealthSettingsRejectsInvalidValues(t *testing.T) {
+	if err := normalizeHealthSettings(&HealthSettings{Scheme: "ftp"}); err == nil {
+		t.Fatalf("expected invalid scheme error")
+	}
+	if err := normalizeHealthSettings(&HealthSettings{Path: "health"}); err == nil {
+		t.Fatalf("expected invalid path error")
+	}
+	h := HealthSettings{Scheme: " HTTPS ", Path: "/health"}
+	if err := normalizeHealthSettings(&h); err != nil || h.Scheme != "https" {
+		t.Fatalf("unexpected normalize result: %+v err=%v", h, err)
+	}
+}
+
+func TestIsProfileHealthyTCP(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, _ := strconv.Atoi(u.Port())
+	profile := ProfileRequest{
+		ID:     "tcp-profile",
+		Ports:  []PortMapping{{Container: 3000, Host: port}},
+		Health: HealthSettings{Type: healthCheckTCP},
+	}
+	if !isProfileHealthy(context.Background(), profile) {
+		t.Fatalf("expected tcp probe to succeed even though /health returns 404")
+	}
+	profile.Health.Type = healthCheckHTTP
+	if isProfileHealthy(context.Background(), profile) {
+		t.Fatalf("expected http probe to fail on 404")
+	}
+}
+
+func TestNormalizeHealthSettingsRequiresCommand(t *testing.T) {
+	if err := normalizeHealthSettings(&HealthSettings{Type: healthCheckCommand}); err == nil {
+		t.Fatalf("expected missing command error")
+	}
+	if err := normalizeHealthSettings(&HealthSettings{Type: "grpc"}); err == nil {
+		t.Fatalf("expected unknown type error")
+	}
+}
+
+func TestRetryProfileHealthStopsWhenCanceled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, _ := strconv.Atoi(u.Port())
+	profile := ProfileRequest{ID: "slow", Ports: []PortMapping{{Container: 3000, Host: port}}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if retryProfileHealth(ctx, profile, 10, time.Second) {
+		t.Fatalf("expected unhealthy result")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("expected retries to stop at the deadline, took %s", elapsed)
+	}
+	if _, err := loadProfileStore(ctx, "unused.json"); err == nil {
+		t.Fatalf("expected store load to fail after the deadline")
+	}
+}
+
+func TestComposeHealthcheckFollowsHealthSettings(t *testing.T) {
+	def := composeHealthcheck(ProfileRequest{})
+	if !strings.Contains(def, `"wget -q -O /dev/null -T 5 http://localhost:${APP_PORT}/health"`) || !strings.Contains(def, "timeout: 5s") {
+		t.Fatalf("unexpected default healthcheck:\n%s", def)
+	}
+	https := composeHealthcheck(ProfileRequest{
+		Env:    map[string]string{"APP_DOMAIN": "app.example.com"},
+		Health: HealthSettings{Scheme: "https", Path: "/ready", InsecureSkipVerify: true},
+	})
+	if !strings.Contains(https, "--no-check-certificate --header 'Host: app.example.com' https://localhost:${APP_PORT}/ready") {
+		t.Fatalf("unexpected https healthcheck:\n%s", https)
+	}
+	if tcp := composeHealthcheck(ProfileRequest{Health: HealthSettings{Type: healthCheckTCP}}); !strings.Contains(tcp, "nc -z 127.0.0.1 ${APP_PORT}") {
+		t.Fatalf("unexpected tcp healthcheck:\n%s", tcp)
+	}
+	cmd := composeHealthcheck(ProfileRequest{Health: HealthSettings{Type: healthCheckCommand, Command: `test -f "$HOME/ready"`}})
+	if !strings.Contains(cmd, `"CMD-SHELL","test -f \"$$HOME/ready\""`) {
+		t.Fatalf("expected escaped command healthcheck:\n%s", cmd)
+	}
+}
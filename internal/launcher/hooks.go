@@ -0,0 +1,201 @@
+package launcher
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Action hooks are shell commands a profile runs before and after it is
+// enabled, stopped or updated, for example to notify another system or
+// warm a cache. They run as the launcher's user, so they only run while
+// the allowActionHooks setting is on. A failing pre hook stops the action;
+// a failing post hook is reported in the job log but the action stands.
+//
+// Hooks get the profile in KIMMIO_PROFILE_ID, KIMMIO_ACTION (enable, stop
+// or update), KIMMIO_HOOK (pre or post), KIMMIO_VERSION, KIMMIO_PORT,
+// KIMMIO_PROFILE_DIR and KIMMIO_JOB_ID; updates also set
+// KIMMIO_PREVIOUS_VERSION. Their output goes to the job log.
+
+// ActionHooks are a profile's hook commands; empty ones are skipped.
+type ActionHooks struct {
+	PreEnable  string `json:"preEnable,omitempty"`
+	PostEnable string `json:"postEnable,omitempty"`
+	PreStop    string `json:"preStop,omitempty"`
+	PostStop   string `json:"postStop,omitempty"`
+	PreUpdate  string `json:"preUpdate,omitempty"`
+	PostUpdate string `json:"postUpdate,omitempty"`
+}
+
+const (
+	hookTimeout       = 5 * time.Minute
+	maxHookLength     = 1024
+	maxHookOutputLogs = 40
+)
+
+var errActionHooksDisabled = errors.New("action hooks are turned off in the launcher settings")
+
+func actionHooksAllowed() bool {
+	st := liveSettings.Load()
+	return st != nil && st.AllowActionHooks
+}
+
+func (h ActionHooks) empty() bool {
+	return h == ActionHooks{}
+}
+
+// forAction returns the hook name and pre and post commands of a profile
+// action; actions without hooks return an empty name.
+func (h ActionHooks) forAction(action string) (name, pre, post string) {
+	switch action {
+	case "enable":
+		return "enable", h.PreEnable, h.PostEnable
+	case "stop":
+		return "stop", h.PreStop, h.PostStop
+	case "version":
+		return "update", h.PreUpdate, h.PostUpdate
+	}
+	return "", "", ""
+}
+
+func normalizeActionHooks(h *ActionHooks) error {
+	fields := []struct {
+		name string
+		v    *string
+	}{
+		{"hookPreEnable", &h.PreEnable}, {"hookPostEnable", &h.PostEnable},
+		{"hookPreStop", &h.PreStop}, {"hookPostStop", &h.PostStop},
+		{"hookPreUpdate", &h.PreUpdate}, {"hookPostUpdate", &h.PostUpdate},
+	}
+	for _, f := range fields {
+		*f.v = strings.TrimSpace(*f.v)
+		if len(*f.v) > maxHookLength {
+			return fieldError(f.name, "hook.length", fmt.Sprintf("hook commands must be at most %d characters", maxHookLength))
+		}
+	}
+	if !h.empty() && !actionHooksAllowed() {
+		return fieldError("hookPreEnable", "hook.disabled", errActionHooksDisabled.Error())
+	}
+	return nil
+}
+
+// withActionHooks wraps run with the profile's hooks for action.
+func (s *Server) withActionHooks(id, action, version string, run func(jobID string, ctx context.Context) error) func(jobID string, ctx context.Context) error {
+	return func(jobID string, ctx context.Context) error {
+		store, idx, err := s.getProfileForAction(ctx, id)
+		if err != nil {
+			return run(jobID, ctx)
+		}
+		profile := store.Profiles[idx]
+		name, pre, post := profile.Hooks.forAction(action)
+		if pre == "" && post == "" {
+			return run(jobID, ctx)
+		}
+		if !actionHooksAllowed() {
+			s.appendJobLog(jobID, "hook", "Skipping "+name+" hooks: "+errActionHooksDisabled.Error())
+			return run(jobID, ctx)
+		}
+		env := hookEnv(profile, jobID, name, version)
+		if pre != "" {
+			if err := s.runActionHook(ctx, profile.ID, jobID, "pre-"+name, pre, env); err != nil {
+				return fmt.Errorf("pre-%s hook failed: %w", name, err)
+			}
+		}
+		if err := run(jobID, ctx); err != nil {
+			return err
+		}
+		if post != "" {
+			// The action is done; a post hook must not turn it into a failure.
+			if err := s.runActionHook(context.WithoutCancel(ctx), profile.ID, jobID, "post-"+name, post, env); err != nil {
+				s.appendJobLog(jobID, "hook", "post-"+name+" hook failed: "+err.Error())
+			}
+		}
+		return nil
+	}
+}
+
+func hookEnv(p ProfileRequest, jobID, action, version string) []string {
+	env := append(os.Environ(),
+		"KIMMIO_PROFILE_ID="+p.ID,
+		"KIMMIO_ACTION="+action,
+		"KIMMIO_PORT="+strconv.Itoa(profilePort(p)),
+		"KIMMIO_PROFILE_DIR="+profileComposeDir(p.ID),
+		"KIMMIO_JOB_ID="+jobID,
+	)
+	if action == "update" {
+		return append(env, "KIMMIO_VERSION="+version, "KIMMIO_PREVIOUS_VERSION="+p.Version)
+	}
+	return append(env, "KIMMIO_VERSION="+p.Version)
+}
+
+// shellCommand runs command through the platform shell.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "/bin/sh", "-c", command)
+}
+
+// runActionHook runs command through the platform shell, copying its
+// output into the job log line by line.
+func (s *Server) runActionHook(parent context.Context, profileID, jobID, hook, command string, env []string) error {
+	ctx, cancel := context.WithTimeout(parent, hookTimeout)
+	defer cancel()
+	cmd := shellCommand(ctx, command)
+	stage, _, _ := strings.Cut(hook, "-")
+	cmd.Env = append(env, "KIMMIO_HOOK="+stage)
+	if info, err := os.Stat(profileComposeDir(profileID)); err == nil && info.IsDir() {
+		cmd.Dir = profileComposeDir(profileID)
+	}
+	// Commands left running in the background must not hold the job.
+	cmd.WaitDelay = 5 * time.Second
+	out, w := io.Pipe()
+	cmd.Stdout, cmd.Stderr = w, w
+
+	s.appendJobLog(jobID, "hook", "Running "+hook+" hook")
+	started := time.Now()
+	if err := cmd.Start(); err != nil {
+		w.Close()
+		return err
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sc := bufio.NewScanner(out)
+		lines := 0
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			if line == "" {
+				continue
+			}
+			if lines++; lines <= maxHookOutputLogs {
+				s.appendJobLog(jobID, "hook", line)
+			} else if lines == maxHookOutputLogs+1 {
+				s.appendJobLog(jobID, "hook", "(further output omitted)")
+			}
+		}
+		io.Copy(io.Discard, out)
+	}()
+	err := cmd.Wait()
+	w.Close()
+	<-done
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", hookTimeout)
+	}
+	fields := map[string]any{"profile_id": profileID, "hook": hook, "duration_ms": time.Since(started).Milliseconds()}
+	if err != nil {
+		fields["error"] = err.Error()
+		logWarn("action_hook_failed", fields)
+		return err
+	}
+	logInfo("action_hook_finished", fields)
+	return nil
+}
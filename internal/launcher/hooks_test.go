@@ -0,0 +1,130 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestActionHooksWrapUpdates(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks in this test are POSIX shell commands")
+	}
+	srv := newServiceTestServer(t)
+	defer publishSettings(defaultSettings())
+	ctx := context.Background()
+
+	hooks := ActionHooks{PreUpdate: `echo "updating $KIMMIO_PROFILE_ID from $KIMMIO_PREVIOUS_VERSION to $KIMMIO_VERSION"`, PostUpdate: `echo "$KIMMIO_HOOK done" >&2`}
+	if err := normalizeActionHooks(&hooks); err == nil {
+		t.Fatal("expected hooks to be refused while the setting is off")
+	}
+	on := true
+	if _, err := srv.settings.update(SettingsPatch{AllowActionHooks: &on}); err != nil {
+		t.Fatal(err)
+	}
+	if err := normalizeActionHooks(&hooks); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeProfileStoreAtomic(srv.dbPath, ProfileStore{Profiles: []ProfileRequest{{ID: "alpha", Version: "1.0.0", Hooks: hooks}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	job := runTestAction(t, srv, "1.1.0")
+	if job.Status != "succeeded" {
+		t.Fatalf("expected the update to succeed, got %+v", job)
+	}
+	logs := strings.Join(job.Logs, "\n")
+	for _, want := range []string{"Running pre-update hook", "updating alpha from 1.0.0 to 1.1.0", "post done"} {
+		if !strings.Contains(logs, want) {
+			t.Fatalf("expected %q in the job log:\n%s", want, logs)
+		}
+	}
+
+	store, _ := srv.readStore(ctx)
+	store.Profiles[0].Hooks.PreUpdate = "echo refusing; exit 3"
+	if err := writeProfileStoreAtomic(srv.dbPath, store); err != nil {
+		t.Fatal(err)
+	}
+	job = runTestAction(t, srv, "1.2.0")
+	if job.Status != "failed" || !strings.Contains(job.Error, "pre-update hook failed") {
+		t.Fatalf("expected a failing pre hook to stop the update, got %+v", job)
+	}
+	if p, _ := srv.Profiles().Get(ctx, "alpha"); p.Version != "1.1.0" {
+		t.Fatalf("expected the version left at 1.1.0, got %s", p.Version)
+	}
+}
+
+func runTestAction(t *testing.T, srv *Server, version string) ActionJob {
+	t.Helper()
+	started, err := srv.Profiles().StartAction(context.Background(), "alpha", "version", version, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		job, err := srv.Jobs().Get(started.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv.jobMu.Lock()
+		_, busy := srv.activeProfiles["alpha"]
+		srv.jobMu.Unlock()
+		if isTerminalJobStatus(job.Status) && !busy {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job did not finish: %+v", job)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestActionHooksTurnOnLocallyOnly(t *testing.T) {
+	srv := newServiceTestServer(t)
+	defer publishSettings(defaultSettings())
+	req := httptest.NewRequest(http.MethodPut, "http://launcher.lan/api/settings", strings.NewReader(`{"allowActionHooks": true}`))
+	req.RemoteAddr = "192.0.2.1:1234"
+	rec := httptest.NewRecorder()
+	srv.handleSettings(rec, req)
+	if rec.Code != http.StatusForbidden || srv.settings.get().AllowActionHooks {
+		t.Fatalf("expected a remote request to be refused, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "http://localhost/api/settings", strings.NewReader(`{"allowActionHooks": true}`))
+	req.RemoteAddr = "127.0.0.1:1234"
+	rec = httptest.NewRecorder()
+	srv.handleSettings(rec, req)
+	if rec.Code != http.StatusOK || !srv.settings.get().AllowActionHooks {
+		t.Fatalf("expected a local request to turn hooks on, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestActionHooksSetLocallyOnly(t *testing.T) {
+	srv := newServiceTestServer(t)
+	st := defaultSettings()
+	st.AllowActionHooks = true
+	publishSettings(st)
+	defer publishSettings(defaultSettings())
+	body := `{"id":"beta","version":"1.0.0","ports":[{"container":3000,"host":8090}],"hooks":{"preEnable":"touch /tmp/x"}}`
+
+	remote := httptest.NewRequest(http.MethodPost, "http://launcher.lan/api/profiles", strings.NewReader(body))
+	remote.RemoteAddr = "192.0.2.1:1234"
+	token := httptest.NewRequest(http.MethodPost, "http://localhost/api/profiles", strings.NewReader(body))
+	token.RemoteAddr = "127.0.0.1:1234"
+	token = token.WithContext(context.WithValue(token.Context(), apiTokenCtxKey{}, APIToken{Name: "ci", Role: roleAdmin}))
+	for _, req := range []*http.Request{remote, token} {
+		rec := httptest.NewRecorder()
+		srv.handleProfiles(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("expected hooks from %s to be refused, got %d %s", req.RemoteAddr, rec.Code, rec.Body.String())
+		}
+	}
+	if _, err := srv.Profiles().Get(context.Background(), "beta"); !errors.Is(err, ErrProfileNotFound) {
+		t.Fatalf("expected no profile to be created, got %v", err)
+	}
+}
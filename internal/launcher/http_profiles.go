@@ -1,7 +1,6 @@
 package launcher
 
 import (
-	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -9,6 +8,7 @@ import (
 	"net"
 	"net/http"
 	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
 )
@@ -17,51 +17,171 @@ var profileIDRe = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{2,63}$`)
 var versionTagRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
 var domainRe = regexp.MustCompile(`^[a-zA-Z0-9.-]+$`)
 
+func (s *Server) handleProfiles(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		s.handleListProfiles(w, r)
+		return
+	}
+	s.handleCreateProfile(w, r)
+}
+
 func (s *Server) handleCreateProfile(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	// Form posts from scripts expect the old redirect; the create page
+	// asks for JSON so it can show errors next to the inputs, and gets
+	// itself back with the errors filled in when it posts natively.
 	req, fromForm, err := decodeProfileRequest(r)
+	format := formatJSON
+	if fromForm {
+		format = formatPage
+	}
+	format = negotiateFormat(r, format)
 	if err != nil {
-		http.Error(w, "Invalid request: "+err.Error(), http.StatusBadRequest)
+		var ve ValidationError
+		if !errors.As(err, &ve) {
+			http.Error(w, "Invalid request: "+err.Error(), bodyErrorStatus(err))
+			return
+		}
+		s.writeCreateError(w, r, format, req, ve)
 		return
 	}
-
-	if err := validateAndNormalize(&req); err != nil {
-		http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+	// Hooks run commands on this computer, so only it may set them, as
+	// with turning them on in the settings.
+	if !req.Hooks.empty() && (!isLoopbackRequest(r) || isTokenRequest(r)) {
+		http.Error(w, "Forbidden: action hooks can only be set from this computer", http.StatusForbidden)
 		return
 	}
-
-	err = s.createProfile(req)
+	if !req.TLS.sameFiles(TLSSettings{}) && (!isLoopbackRequest(r) || isTokenRequest(r)) {
+		http.Error(w, tlsFilesForbidden, http.StatusForbidden)
+		return
+	}
+	created, err := s.Profiles().Create(r.Context(), req)
 	if err != nil {
-		if errors.Is(err, ErrProfileLimitReached) {
-			http.Error(w, fmt.Sprintf("Validation error: profile limit reached (max %d)", appCfg.MaxProfiles), http.StatusBadRequest)
-			return
-		}
-		if errors.Is(err, ErrProfileExists) {
-			http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
-			return
-		}
 		var ve ValidationError
-		if errors.As(err, &ve) {
-			http.Error(w, "Validation error: "+ve.Error(), http.StatusBadRequest)
+		switch {
+		case errors.Is(err, ErrProfileLimitReached):
+			ve = ValidationError{Msg: fmt.Sprintf("profile limit reached (max %d)", maxProfilesLimit())}
+		case errors.Is(err, ErrProfileExists):
+			ve = fieldError("id", "id.taken", err.Error())
+		case errors.As(err, &ve):
+		default:
+			http.Error(w, "DB error: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
-		http.Error(w, "DB error: "+err.Error(), http.StatusInternalServerError)
+		s.writeCreateError(w, r, format, req, ve)
 		return
 	}
 
-	if fromForm {
+	switch format {
+	case formatPage:
 		http.Redirect(w, r, "/", http.StatusSeeOther)
 		return
+	case formatFragment:
+		w.Header().Set("HX-Trigger", "profileCreated")
+		s.writeFragment(w, http.StatusCreated, "profile-row", created)
+		return
 	}
 
 	writeJSON(w, http.StatusCreated, map[string]any{
-		"ok":      true,
-		"created": true,
-		"profile": req,
+		"ok":                 true,
+		"created":            true,
+		"profile":            created,
+		"connectionSheet":    buildConnectionSheet(created, true),
+		"connectionSheetUrl": "/api/profiles/" + created.ID + "/connection-sheet",
+	})
+}
+
+func (s *Server) writeCreateError(w http.ResponseWriter, r *http.Request, format responseFormat, req ProfileRequest, ve ValidationError) {
+	switch format {
+	case formatFragment:
+		s.writeFragment(w, http.StatusBadRequest, "form-errors", map[string]any{
+			"Error":  "Validation error: " + ve.Error(),
+			"Fields": ve.Fields,
+		})
+	case formatPage:
+		s.renderCreateForm(w, r, req, ve)
+	default:
+		writeValidationError(w, ve)
+	}
+}
+
+// writeValidationError answers 400 with the error envelope: the message
+// plus one entry per invalid field.
+func writeValidationError(w http.ResponseWriter, ve ValidationError) {
+	fields := ve.Fields
+	if fields == nil {
+		fields = []FieldError{}
+	}
+	writeJSON(w, http.StatusBadRequest, map[string]any{
+		"ok":     false,
+		"error":  "Validation error: " + ve.Error(),
+		"fields": fields,
+	})
+}
+
+// renderCreateForm answers a failed form post with the create page again,
+// filled in with what was submitted and the errors next to their inputs.
+// Passwords and secret variables are not echoed back.
+func (s *Server) renderCreateForm(w http.ResponseWriter, r *http.Request, req ProfileRequest, ve ValidationError) {
+	if s.pages == nil {
+		http.Error(w, "Validation error: "+ve.Error(), http.StatusBadRequest)
+		return
+	}
+	store, err := s.readStore(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load profiles: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(req.Ports) == 0 {
+		req.Ports = []PortMapping{{Container: 3000}}
+	}
+	env := map[string]string{}
+	for key, value := range req.Env {
+		switch {
+		case key == "JWT_SECRET" || key == "ENC_KEY_V0":
+			// The form shows these in plain text already.
+		case key == smtpPasswordKey || key == ssoClientSecretKey || isSecretEnvKey(key):
+			continue
+		}
+		env[key] = value
+	}
+	req.Env = env
+
+	data := s.createPageData(w, r, store, req)
+	fieldErrs := map[string]string{}
+	for _, f := range ve.Fields {
+		if _, seen := fieldErrs[f.Field]; !seen {
+			fieldErrs[f.Field] = f.Message
+		}
+	}
+	data["FieldErrors"] = fieldErrs
+	data["FormError"] = "Validation error: " + ve.Error()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusBadRequest)
+	if err := s.pages.RenderPageWithTemplate(w, "profile-create.html", data); err != nil {
+		logError("create_form_render_failed", map[string]any{"error": err.Error()})
+	}
+}
+
+// createPageData is what the create page needs to show profile.
+func (s *Server) createPageData(w http.ResponseWriter, r *http.Request, store ProfileStore, profile ProfileRequest) map[string]any {
+	return s.pageData(r, map[string]any{
+		"DockerRunning": IsDockerRunning(),
+		"Profile":       profile,
+		"AppEnv":        envSchemaFor(profile.Version).appVars(),
+		"HostPort":      profile.Ports[0].Host,
+		"IsEdit":        false,
+		"ProfileCount":  activeProfileCount(store),
+		"MaxProfiles":   maxProfilesLimit(),
+		"MaxReached":    activeProfileCount(store) >= maxProfilesLimit(),
+		"Remaining":     max(maxProfilesLimit()-activeProfileCount(store), 0),
+		"CSRFToken":     ensureCSRFCookie(w, r),
+		"FieldErrors":   map[string]string{},
 	})
 }
 
@@ -72,9 +192,13 @@ func decodeProfileRequest(r *http.Request) (ProfileRequest, bool, error) {
 		var req ProfileRequest
 		dec := json.NewDecoder(r.Body)
 		dec.DisallowUnknownFields()
-		if err := dec.Decode(&req); err == nil {
+		err := dec.Decode(&req)
+		if err == nil {
 			return req, false, nil
 		}
+		if bodyErrorStatus(err) == http.StatusRequestEntityTooLarge {
+			return ProfileRequest{}, false, fmt.Errorf("request body too large: %w", err)
+		}
 		if ct == "application/json" {
 			return ProfileRequest{}, false, errors.New("invalid JSON body")
 		}
@@ -109,8 +233,9 @@ func decodeProfileRequest(r *http.Request) (ProfileRequest, bool, error) {
 	}
 
 	req := ProfileRequest{
-		ID:      id,
-		Version: version,
+		ID:          id,
+		DisplayName: r.FormValue("displayName"),
+		Version:     version,
 		Ports: []PortMapping{
 			{Container: 3000, Host: hostPort},
 		},
@@ -127,16 +252,118 @@ func decodeProfileRequest(r *http.Request) (ProfileRequest, bool, error) {
 	}
 	req.Resources.Limits.Memory = mem
 	req.Resources.Limits.CPUs = cpus
+	req.Health.Type = strings.TrimSpace(r.FormValue("healthType"))
+	req.Health.Command = strings.TrimSpace(r.FormValue("healthCommand"))
+	req.Health.Scheme = strings.TrimSpace(r.FormValue("healthScheme"))
+	req.Health.Path = strings.TrimSpace(r.FormValue("healthPath"))
+	req.Health.InsecureSkipVerify = r.FormValue("healthInsecure") != ""
+	req.DataDir = strings.TrimSpace(r.FormValue("dataDir"))
+	req.MaintenanceWindow = strings.TrimSpace(r.FormValue("maintenanceWindow"))
+	req.RestartSchedule = strings.TrimSpace(r.FormValue("restartSchedule"))
+	req.StartSchedule = strings.TrimSpace(r.FormValue("startSchedule"))
+	req.StopSchedule = strings.TrimSpace(r.FormValue("stopSchedule"))
+	req.Hooks = ActionHooks{
+		PreEnable:  r.FormValue("hookPreEnable"),
+		PostEnable: r.FormValue("hookPostEnable"),
+		PreStop:    r.FormValue("hookPreStop"),
+		PostStop:   r.FormValue("hookPostStop"),
+		PreUpdate:  r.FormValue("hookPreUpdate"),
+		PostUpdate: r.FormValue("hookPostUpdate"),
+	}
+	if hours := strings.TrimSpace(r.FormValue("autoStopHours")); hours != "" {
+		n, err := strconv.Atoi(hours)
+		if err != nil {
+			return req, true, fieldError("autoStopHours", "number.format", "auto-stop hours must be a number")
+		}
+		req.AutoStopHours = n
+	}
+	req.WakeOnRequest = r.FormValue("wakeOnRequest") != ""
+	if pct := strings.TrimSpace(r.FormValue("alertMemoryPercent")); pct != "" {
+		n, err := strconv.Atoi(pct)
+		if err != nil {
+			return req, true, fieldError("alertMemoryPercent", "number.format", "memory alert percent must be a number")
+		}
+		req.Alerts.MemoryPercent = n
+	}
+	if minutes := strings.TrimSpace(r.FormValue("alertMemoryMinutes")); minutes != "" {
+		n, err := strconv.Atoi(minutes)
+		if err != nil {
+			return req, true, fieldError("alertMemoryMinutes", "number.format", "memory alert minutes must be a number")
+		}
+		req.Alerts.MemoryMinutes = n
+	}
+	if gb := strings.TrimSpace(r.FormValue("alertDiskGB")); gb != "" {
+		n, err := strconv.ParseFloat(gb, 64)
+		if err != nil {
+			return req, true, fieldError("alertDiskGB", "number.format", "disk alert must be a number of GB")
+		}
+		req.Alerts.DiskGB = n
+	}
+	req.Labels = strings.Split(r.FormValue("labels"), ",")
+	req.Platform = strings.TrimSpace(r.FormValue("platform"))
+	req.PullPolicy = PullPolicy{
+		Default:  r.FormValue("pullPolicy"),
+		Enable:   r.FormValue("pullPolicyEnable"),
+		Update:   r.FormValue("pullPolicyUpdate"),
+		Recreate: r.FormValue("pullPolicyRecreate"),
+	}
+	req.TimeZone = strings.TrimSpace(r.FormValue("timeZone"))
+	req.Locale = strings.TrimSpace(r.FormValue("locale"))
+	req.Network.PublicSubnet = strings.TrimSpace(r.FormValue("networkPublicSubnet"))
+	req.Network.InternalSubnet = strings.TrimSpace(r.FormValue("networkInternalSubnet"))
+	if mtu := strings.TrimSpace(r.FormValue("networkMTU")); mtu != "" {
+		n, err := strconv.Atoi(mtu)
+		if err != nil {
+			return req, true, fieldError("networkMTU", "number.format", "network MTU must be a number")
+		}
+		req.Network.MTU = n
+	}
+	req.Network.ExtraHosts = strings.FieldsFunc(r.FormValue("networkExtraHosts"), func(c rune) bool {
+		return c == ',' || c == '\n'
+	})
+	req.Network.HostMode = r.FormValue("networkHostMode") != ""
+	req.SMTP.Host = strings.TrimSpace(r.FormValue("smtpHost"))
+	req.SMTP.Username = strings.TrimSpace(r.FormValue("smtpUsername"))
+	req.SMTP.From = strings.TrimSpace(r.FormValue("smtpFrom"))
+	req.SMTP.Security = strings.TrimSpace(r.FormValue("smtpSecurity"))
+	if port := strings.TrimSpace(r.FormValue("smtpPort")); port != "" {
+		n, err := strconv.Atoi(port)
+		if err != nil {
+			return req, true, fieldError("smtpPort", "number.format", "SMTP port must be a number")
+		}
+		req.SMTP.Port = n
+	}
+	if password := r.FormValue("smtpPassword"); password != "" {
+		req.Env[smtpPasswordKey] = password
+	}
+	for key, values := range r.PostForm {
+		if name, ok := strings.CutPrefix(key, "env_"); ok && len(values) > 0 && strings.TrimSpace(values[0]) != "" {
+			req.Env[name] = strings.TrimSpace(values[0])
+		}
+	}
+	req.SSO.Provider = strings.TrimSpace(r.FormValue("ssoProvider"))
+	req.SSO.ClientID = strings.TrimSpace(r.FormValue("ssoClientId"))
+	req.SSO.Tenant = strings.TrimSpace(r.FormValue("ssoTenant"))
+	req.SSO.IssuerURL = strings.TrimSpace(r.FormValue("ssoIssuerUrl"))
+	if secret := strings.TrimSpace(r.FormValue("ssoClientSecret")); secret != "" {
+		req.Env[ssoClientSecretKey] = secret
+	}
+	req.TLS.CertFile = strings.TrimSpace(r.FormValue("tlsCertFile"))
+	req.TLS.KeyFile = strings.TrimSpace(r.FormValue("tlsKeyFile"))
+	req.TLS.Proxy = r.FormValue("tlsProxy") != ""
 
 	return req, true, nil
 }
 
+// validateAndNormalize checks every input and reports all invalid ones
+// together as a ValidationError with one FieldError each.
 func validateAndNormalize(req *ProfileRequest) error {
+	var errs fieldErrors
 	req.ID = strings.ToLower(strings.TrimSpace(req.ID))
 	req.Version = strings.TrimSpace(req.Version)
 
 	if !profileIDRe.MatchString(req.ID) {
-		return errors.New("id must be lowercase letters/numbers/dashes, length 3-64 (e.g. omega-production-01)")
+		errs.add("id", "id.invalid", errors.New("id must be lowercase letters/numbers/dashes, length 3-64 (e.g. omega-production-01)"))
 	}
 
 	if req.Version == "" {
@@ -147,20 +374,82 @@ func validateAndNormalize(req *ProfileRequest) error {
 		req.Ports = []PortMapping{{Container: 3000, Host: 8080}}
 	}
 	if req.Ports[0].Host <= 0 || req.Ports[0].Host > 65535 {
-		return errors.New("host port must be in range 1..65535")
+		errs.add("hostPort", "port.range", errors.New("host port must be in range 1..65535"))
 	}
 	if req.Ports[0].Container <= 0 || req.Ports[0].Container > 65535 {
 		req.Ports[0].Container = 3000
 	}
 
+	if name, err := normalizeDisplayName(req.DisplayName); err != nil {
+		errs.add("displayName", "displayName.invalid", err)
+	} else {
+		req.DisplayName = name
+	}
+
+	if dir, err := normalizeProfileDataDir(req.DataDir); err != nil {
+		errs.add("dataDir", "dataDir.invalid", err)
+	} else {
+		req.DataDir = dir
+	}
+
+	if labels, err := normalizeProfileLabels(req.Labels); err != nil {
+		errs.add("labels", "labels.invalid", err)
+	} else {
+		req.Labels = labels
+	}
+
 	mem := strings.TrimSpace(req.Resources.Limits.Memory)
 	if mem != "" && !isValidMem(mem) {
-		return errors.New("memory must look like 512mb / 1gb / 2g / 4096m (or empty for default)")
+		errs.add("memory", "memory.format", errors.New("memory must look like 512mb / 1gb / 2g / 4096m (or empty for default)"))
 	}
 	req.Resources.Limits.Memory = mem
 
 	if req.Resources.Limits.CPUs < 0 {
-		return errors.New("cpus cannot be negative")
+		errs.add("cpus", "cpus.negative", errors.New("cpus cannot be negative"))
+	}
+
+	if err := normalizeHealthSettings(&req.Health); err != nil {
+		errs.add("healthType", "health.invalid", err)
+	}
+	if window, err := normalizeMaintenanceWindow(req.MaintenanceWindow); err != nil {
+		errs.add("maintenanceWindow", "maintenance.format", err)
+	} else {
+		req.MaintenanceWindow = window
+	}
+	if schedule, err := normalizeSchedule(req.RestartSchedule); err != nil {
+		errs.add("restartSchedule", "restart.format", err)
+	} else {
+		req.RestartSchedule = schedule
+	}
+	if schedule, err := normalizeSchedule(req.StartSchedule); err != nil {
+		errs.add("startSchedule", "schedule.format", err)
+	} else {
+		req.StartSchedule = schedule
+	}
+	if schedule, err := normalizeSchedule(req.StopSchedule); err != nil {
+		errs.add("stopSchedule", "schedule.format", err)
+	} else {
+		req.StopSchedule = schedule
+	}
+	if err := validateAutoStopHours(req.AutoStopHours); err != nil {
+		errs.add("autoStopHours", "autostop.range", err)
+	}
+	if err := normalizeAlertSettings(&req.Alerts); err != nil {
+		errs.add("alertMemoryPercent", "alert.invalid", err)
+	}
+	if platform, err := normalizePlatform(req.Platform); err != nil {
+		errs.add("platform", "platform.invalid", err)
+	} else {
+		req.Platform = platform
+	}
+	if err := normalizePullPolicy(&req.PullPolicy); err != nil {
+		errs.add("pullPolicy", "pull.policy", err)
+	}
+	if err := normalizeNetworkSettings(&req.Network); err != nil {
+		errs.add("networkPublicSubnet", "network.invalid", err)
+	}
+	if err := normalizeActionHooks(&req.Hooks); err != nil {
+		errs.add("hookPreEnable", "hook.invalid", err)
 	}
 
 	if req.Env == nil {
@@ -171,22 +460,35 @@ func validateAndNormalize(req *ProfileRequest) error {
 		req.Env["ENC_KEY_V0"] = strings.TrimSpace(req.Env["FLUMIO_ENC_KEY_V0"])
 	}
 	delete(req.Env, "FLUMIO_ENC_KEY_V0")
-	for k := range req.Env {
-		if !isSafeEnvKey(k) {
-			return fmt.Errorf("invalid env key: %q", k)
-		}
+	// TZ used to be an app variable; it is now the profile's time zone.
+	if req.TimeZone == "" {
+		req.TimeZone = req.Env["TZ"]
+	}
+	delete(req.Env, "TZ")
+	if timeZone, err := normalizeTimeZone(req.TimeZone); err != nil {
+		errs.add("timeZone", "timezone.invalid", err)
+	} else {
+		req.TimeZone = timeZone
+	}
+	if locale, err := normalizeLocale(req.Locale); err != nil {
+		errs.add("locale", "locale.format", err)
+	} else {
+		req.Locale = locale
 	}
-	if domain := strings.TrimSpace(req.Env["APP_DOMAIN"]); domain != "" && !isValidDomain(domain) {
-		return errors.New("domain must be hostname only (example: localhost or app.example.com)")
+	if err := validateProfileEnv(req.Version, req.Env); err != nil {
+		errs.add("env", "env.invalid", err)
 	}
-	if key := strings.TrimSpace(req.Env["ENC_KEY_V0"]); key != "" && !isValidEncryptionKeyValue(key) {
-		return errors.New("ENC_KEY_V0 must be base64 for 32 bytes (legacy 32-char keys also accepted)")
+	if err := normalizeSMTPSettings(&req.SMTP, req.Env[smtpPasswordKey]); err != nil {
+		errs.add("smtpHost", "smtp.invalid", err)
 	}
-	if jwt := strings.TrimSpace(req.Env["JWT_SECRET"]); jwt != "" && len(jwt) < 32 {
-		return errors.New("JWT_SECRET must be at least 32 characters")
+	if err := normalizeSSOSettings(&req.SSO, req.Env[ssoClientSecretKey]); err != nil {
+		errs.add("ssoProvider", "sso.invalid", err)
+	}
+	if err := normalizeTLSSettings(&req.TLS, req.Env["APP_DOMAIN"]); err != nil {
+		errs.add("tlsCertFile", "tls.invalid", err)
 	}
 
-	return nil
+	return errs.err()
 }
 
 func isValidMem(v string) bool {
@@ -229,20 +531,90 @@ func (s *Server) handleProfileAction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(parts) == 2 && parts[1] == "status" && r.Method == http.MethodGet {
+		s.handleProfileStatus(w, r, id)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "incidents" && r.Method == http.MethodGet {
+		s.handleProfileIncidents(w, r, id)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "update-check" && r.Method == http.MethodGet {
+		s.handleProfileUpdateCheck(w, r, id)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "connection-sheet" && (r.Method == http.MethodGet || r.Method == http.MethodPost) {
+		s.handleConnectionSheet(w, r, id)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "logs" && r.Method == http.MethodGet {
+		s.handleProfileLogs(w, r, id)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "metrics" && r.Method == http.MethodGet {
+		s.handleProfileMetrics(w, r, id)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "stats" && r.Method == http.MethodGet {
+		s.handleProfileStats(w, r, id)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "confirm-token" && r.Method == http.MethodPost {
+		s.handleConfirmToken(w, r, id)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "data-dir" && r.Method == http.MethodPost {
+		s.handleProfileDataDir(w, r, id)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "storage" && r.Method == http.MethodPost {
+		s.handleProfileStorage(w, r, id)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "name" && r.Method == http.MethodPost {
+		s.handleProfileRename(w, r, id)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "test-email" && r.Method == http.MethodPost {
+		s.handleProfileTestEmail(w, r, id)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "backup" && r.Method == http.MethodPost {
+		s.handleProfileBackup(w, r, id)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "backups" && r.Method == http.MethodGet {
+		s.handleProfileBackups(w, r, id)
+		return
+	}
+
+	if len(parts) == 4 && parts[1] == "backups" && parts[3] == "restore" && r.Method == http.MethodPost {
+		s.handleProfileRestoreBackup(w, r, id, parts[2])
+		return
+	}
+
 	if len(parts) == 1 {
-		if r.Method != http.MethodDelete {
+		switch r.Method {
+		case http.MethodPut:
+			s.handleProfileUpdate(w, r, id)
+		case http.MethodDelete:
+			s.writeActionJob(w, r, id, "delete", "")
+		default:
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-		job, err := s.enqueueProfileJob(id, "delete", func(jobID string, ctx context.Context) error {
-			s.updateJobStep(jobID, "down", "running", "Stopping profile", 20, "")
-			return s.performDelete(id, jobID, ctx)
-		})
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusConflict)
-			return
 		}
-		writeJSON(w, http.StatusAccepted, map[string]any{"ok": true, "jobId": job.ID})
 		return
 	}
 
@@ -252,65 +624,121 @@ func (s *Server) handleProfileAction(w http.ResponseWriter, r *http.Request) {
 	}
 
 	action := strings.ToLower(strings.TrimSpace(parts[1]))
-	switch action {
-	case "enable":
-		job, err := s.enqueueProfileJob(id, action, func(jobID string, ctx context.Context) error {
-			return s.performEnable(id, jobID, ctx)
-		})
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusConflict)
-			return
-		}
-		writeJSON(w, http.StatusAccepted, map[string]any{"ok": true, "jobId": job.ID})
+	if !isProfileAction(action) {
+		http.NotFound(w, r)
 		return
-	case "stop":
-		job, err := s.enqueueProfileJob(id, action, func(jobID string, ctx context.Context) error {
-			return s.performStop(id, jobID, ctx)
-		})
+	}
+	version := ""
+	if action == "version" {
+		newVersion, err := parseVersionFromRequest(r)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusConflict)
+			http.Error(w, "Version update failed: "+err.Error(), bodyErrorStatus(err))
 			return
 		}
-		writeJSON(w, http.StatusAccepted, map[string]any{"ok": true, "jobId": job.ID})
+		version = newVersion
+	}
+	s.writeActionJob(w, r, id, action, version)
+}
+
+func (s *Server) writeActionJob(w http.ResponseWriter, r *http.Request, id, action, version string) {
+	expectedRevision, err := expectedRevisionFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
-	case "recreate":
-		job, err := s.enqueueProfileJob(id, action, func(jobID string, ctx context.Context) error {
-			return s.performRecreate(id, jobID, ctx)
-		})
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusConflict)
+	}
+	confirmed := false
+	if isDestructiveAction(action) {
+		if err := s.checkConfirmation(r, id, action); err != nil {
+			http.Error(w, err.Error(), httpStatusForError(err))
 			return
 		}
-		writeJSON(w, http.StatusAccepted, map[string]any{"ok": true, "jobId": job.ID})
-		return
-	case "version":
-		newVersion, err := parseVersionFromRequest(r)
+		confirmed = true
+	}
+	// A confirmed delete with force=true cancels the job in its way first.
+	if action == "delete" && r.URL.Query().Has("force") {
+		force, err := strconv.ParseBool(r.URL.Query().Get("force"))
 		if err != nil {
-			http.Error(w, "Version update failed: "+err.Error(), http.StatusBadRequest)
+			http.Error(w, "force must be true or false", http.StatusBadRequest)
 			return
 		}
-		job, err := s.enqueueProfileJob(id, action, func(jobID string, ctx context.Context) error {
-			return s.performVersionUpdate(id, newVersion, jobID, ctx)
-		})
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusConflict)
-			return
+		if force {
+			if err := s.Jobs().CancelProfileJob(r.Context(), id); err != nil {
+				s.writeActionError(w, action, err)
+				return
+			}
 		}
-		writeJSON(w, http.StatusAccepted, map[string]any{"ok": true, "jobId": job.ID})
+	}
+	job, err := s.Profiles().StartAction(r.Context(), id, action, version, expectedRevision, confirmed)
+	if err != nil {
+		s.writeActionError(w, action, err)
 		return
-	case "regenerate-secrets":
-		job, err := s.enqueueProfileJob(id, action, func(jobID string, ctx context.Context) error {
-			return s.performRegenerateSecrets(id, jobID, ctx)
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"ok": true, "jobId": job.ID})
+}
+
+// writeActionError answers a rejected action. Both kinds of 409 are JSON so
+// clients can tell them apart: a stale revision carries the stored profile,
+// a busy profile the job holding it.
+func (s *Server) writeActionError(w http.ResponseWriter, action string, err error) {
+	var conflict RevisionConflictError
+	if errors.As(err, &conflict) {
+		writeJSON(w, http.StatusConflict, map[string]any{
+			"ok":      false,
+			"error":   err.Error(),
+			"profile": conflict.Current,
 		})
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusConflict)
-			return
+		return
+	}
+	var busy ProfileBusyError
+	if errors.As(err, &busy) {
+		payload := map[string]any{
+			"ok":          false,
+			"error":       err.Error(),
+			"activeJobId": busy.JobID,
 		}
-		writeJSON(w, http.StatusAccepted, map[string]any{"ok": true, "jobId": job.ID})
+		if job, ok := s.snapshotJob(busy.JobID); ok {
+			payload["activeAction"] = job.Action
+		}
+		if action == "delete" {
+			payload["hint"] = "retry with force=true to cancel the running job first"
+		}
+		writeJSON(w, http.StatusConflict, payload)
 		return
+	}
+	http.Error(w, err.Error(), httpStatusForError(err))
+}
+
+// expectedRevisionFromRequest reads the If-Match precondition carrying the
+// profile revision the client last saw. Without the header it returns 0,
+// which skips the check: the precondition is opt-in so clients that never
+// read the profile, such as deploy hooks and the CLI, keep working.
+func expectedRevisionFromRequest(r *http.Request) (int, error) {
+	raw := strings.TrimSpace(r.Header.Get("If-Match"))
+	if raw == "" || raw == "*" {
+		return 0, nil
+	}
+	raw = strings.Trim(strings.TrimPrefix(raw, "W/"), `"`)
+	rev, err := strconv.Atoi(raw)
+	if err != nil || rev < 1 {
+		return 0, errors.New("If-Match must be a profile revision")
+	}
+	return rev, nil
+}
+
+// httpStatusForError maps service layer errors onto HTTP status codes.
+func httpStatusForError(err error) int {
+	var ve ValidationError
+	switch {
+	case errors.As(err, &ve):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrProfileNotFound), errors.Is(err, ErrJobNotFound), errors.Is(err, ErrWorkflowNotFound), errors.Is(err, ErrUnknownAction), errors.Is(err, ErrFleetLauncherNotFound), errors.Is(err, ErrAccountNotFound), errors.Is(err, ErrAPITokenNotFound), errors.Is(err, ErrBackupNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrProfileBusy), errors.Is(err, ErrJobCompleted), errors.Is(err, ErrJobNotPausable), errors.Is(err, ErrProfileExists), errors.Is(err, ErrRevisionConflict):
+		return http.StatusConflict
+	case errors.Is(err, ErrConfirmationRequired):
+		return http.StatusPreconditionRequired
 	default:
-		http.NotFound(w, r)
-		return
+		return http.StatusInternalServerError
 	}
 }
 
@@ -321,6 +749,9 @@ func parseVersionFromRequest(r *http.Request) (string, error) {
 			Version string `json:"version"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			if bodyErrorStatus(err) == http.StatusRequestEntityTooLarge {
+				return "", fmt.Errorf("request body too large: %w", err)
+			}
 			return "", errors.New("invalid JSON body")
 		}
 		newVersion = strings.TrimSpace(body.Version)
@@ -336,26 +767,47 @@ func parseVersionFromRequest(r *http.Request) (string, error) {
 
 func validateCreateConstraints(req ProfileRequest, store ProfileStore) error {
 	if len(req.Ports) == 0 {
-		return ValidationError{Msg: "host port is required"}
+		return fieldError("hostPort", "port.required", "host port is required")
 	}
 	hostPort := req.Ports[0].Host
 	if hostPort < 1024 {
-		return ValidationError{Msg: "host port must be >= 1024 (reserved ports are blocked)"}
+		return fieldError("hostPort", "port.reserved", "host port must be >= 1024 (reserved ports are blocked)")
 	}
-	reserved := map[int]bool{appCfg.ListenPort: true}
+	reserved := reservedPorts()
 	if reserved[hostPort] {
-		return ValidationError{Msg: fmt.Sprintf("host port %d is reserved", hostPort)}
+		return fieldError("hostPort", "port.reserved", fmt.Sprintf("host port %d is reserved", hostPort))
+	}
+	ports := profileHostPorts(req)
+	for _, port := range ports[1:] {
+		if reserved[port] || port > 65535 {
+			return fieldError("networkHostMode", "port.unavailable", fmt.Sprintf("host network mode needs port %d, which is unavailable", port))
+		}
 	}
 	for _, p := range store.Profiles {
-		if len(p.Ports) > 0 && p.Ports[0].Host == hostPort {
-			return ValidationError{Msg: fmt.Sprintf("host port %d is already used by profile %s", hostPort, p.ID)}
+		for _, used := range profileHostPorts(p) {
+			for _, port := range ports {
+				if used == port {
+					return fieldError("hostPort", "port.taken", fmt.Sprintf("host port %d is already used by profile %s", port, p.ID))
+				}
+			}
 		}
 	}
-	ln, err := net.Listen("tcp", ":"+strconv.Itoa(hostPort))
-	if err != nil {
-		return ValidationError{Msg: fmt.Sprintf("host port %d is unavailable on this machine", hostPort)}
+	if err := checkDataDirConflicts(req.ID, req.DataDir, store.Profiles); err != nil {
+		return err
+	}
+	if err := checkSubnetConflicts(req, store.Profiles); err != nil {
+		return fieldError("networkPublicSubnet", "network.conflict", err.Error())
+	}
+	if req.Network.HostMode && runtime.GOOS != "linux" {
+		return fieldError("networkHostMode", "network.host_mode", "host network mode requires a Linux Docker host")
+	}
+	for _, port := range ports {
+		ln, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+		if err != nil {
+			return fieldError("hostPort", "port.in_use", portInUseMessage(port))
+		}
+		_ = ln.Close()
 	}
-	_ = ln.Close()
 	return nil
 }
 
@@ -0,0 +1,108 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Profiles with autoStopHours set are stopped once their host port has had
+// no open connection for that long, so a forgotten development instance
+// stops using the laptop's memory and CPU. Traffic is sampled every minute;
+// an open browser tab keeps its connection and counts as activity.
+
+const (
+	idleCheckInterval = time.Minute
+	maxAutoStopHours  = 168
+)
+
+func validateAutoStopHours(hours int) error {
+	if hours < 0 || hours > maxAutoStopHours {
+		return fmt.Errorf("auto-stop must be between 1 and %d hours, or 0 to keep running", maxAutoStopHours)
+	}
+	return nil
+}
+
+// idleTracker remembers when each watched profile last had traffic. It is
+// owned by the monitor goroutine.
+type idleTracker map[string]time.Time
+
+func (s *Server) startIdleMonitor(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		seen := idleTracker{}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.stopIdleProfiles(ctx, seen, now)
+			}
+		}
+	}()
+}
+
+// stopIdleProfiles samples each watched profile's connections and starts an
+// auto-stop job for those idle past their limit. A profile is only stopped
+// after it was seen idle for the whole period, so a launcher restart or a
+// failed sample never stops it early.
+func (s *Server) stopIdleProfiles(ctx context.Context, seen idleTracker, now time.Time) {
+	store, err := s.readStore(ctx)
+	if err != nil {
+		logWarn("idle_check_load_failed", map[string]any{"error": err.Error()})
+		return
+	}
+	watched := map[string]bool{}
+	for _, p := range store.Profiles {
+		if !p.Enabled || p.Archived || p.DeletedAt != "" || p.AutoStopHours <= 0 || len(p.Ports) == 0 {
+			continue
+		}
+		watched[p.ID] = true
+		conns, ok := countPortConnections(ctx, p.Ports[0].Host)
+		last, known := seen[p.ID]
+		if !known || !ok || conns > 0 {
+			seen[p.ID] = now
+			continue
+		}
+		if now.Sub(last) < time.Duration(p.AutoStopHours)*time.Hour {
+			continue
+		}
+		id, hours := p.ID, p.AutoStopHours
+		job, err := s.enqueueProfileJob(id, "auto-stop", func(jobID string, ctx context.Context) error {
+			return s.performAutoStop(id, hours, jobID, ctx)
+		})
+		var busy ProfileBusyError
+		if errors.As(err, &busy) {
+			logInfo("profile_auto_stop_skipped", map[string]any{"profile_id": id, "reason": "job_running", "job_id": busy.JobID})
+			continue
+		}
+		if err != nil {
+			logWarn("profile_auto_stop_failed", map[string]any{"profile_id": id, "error": err.Error()})
+			continue
+		}
+		logInfo("profile_auto_stop_started", map[string]any{"profile_id": id, "job_id": job.ID, "idle_hours": hours})
+		delete(seen, id)
+	}
+	for id := range seen {
+		if !watched[id] {
+			delete(seen, id)
+		}
+	}
+}
+
+// performAutoStop stops the stack like performStop and records why, so the
+// profile list can offer to enable it again.
+func (s *Server) performAutoStop(id string, hours int, jobID string, parent context.Context) error {
+	ctx, cancel := context.WithTimeout(parent, appCfg.ActionTimeout)
+	defer cancel()
+	record := context.WithoutCancel(parent)
+
+	s.updateJobStep(jobID, "down", "running", "Stopping idle profile", 35, "")
+	if err := s.compose.down(ctx, id, false); err != nil {
+		_ = s.markProfileResult(record, id, "auto-stop", "failed", err.Error(), "")
+		return err
+	}
+	return s.markProfileResult(record, id, "auto-stop", "success", fmt.Sprintf("Stopped after %d h without traffic", hours), "")
+}
@@ -0,0 +1,81 @@
+package launcher
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCountEstablishedConnections(t *testing.T) {
+	proc := `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 100
+   1: 0100007F:1F90 0100007F:D431 01 00000000:00000000 00:00000000 00000000     0        0 101
+   2: 0100007F:D431 0100007F:1F90 01 00000000:00000000 00:00000000 00000000     0        0 102
+`
+	if n := countProcNetEstablished(proc, 8080); n != 1 {
+		t.Fatalf("expected 1 established connection to 8080, got %d", n)
+	}
+	windows := "  TCP    0.0.0.0:8080     0.0.0.0:0        LISTENING\n  TCP    127.0.0.1:8080   127.0.0.1:54321  ESTABLISHED\n  TCP    [::1]:8080       [::1]:54322      ESTABLISHED\n  TCP    127.0.0.1:54321  127.0.0.1:8080   ESTABLISHED\n"
+	if n := countNetstatEstablished(windows, 8080, 1, ":"); n != 2 {
+		t.Fatalf("expected 2 windows connections, got %d", n)
+	}
+	bsd := "tcp4  0  0  127.0.0.1.8080  127.0.0.1.54321  ESTABLISHED\ntcp46 0  0  *.8080  *.*  LISTEN\n"
+	if n := countNetstatEstablished(bsd, 8080, 3, "."); n != 1 {
+		t.Fatalf("expected 1 bsd connection, got %d", n)
+	}
+}
+
+func TestIdleProfilesAreStoppedAfterTheirLimit(t *testing.T) {
+	srv := newServiceTestServer(t)
+	if err := writeProfileStoreAtomic(srv.dbPath, ProfileStore{Profiles: []ProfileRequest{
+		{ID: "alpha", Version: "1.0.0", Enabled: true, AutoStopHours: 2, Ports: []PortMapping{{Container: 3000, Host: 8088}}},
+		{ID: "beta", Version: "1.0.0", Enabled: true, Ports: []PortMapping{{Container: 3000, Host: 8089}}},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	conns := 0
+	prev := countPortConnections
+	countPortConnections = func(context.Context, int) (int, bool) { return conns, true }
+	defer func() { countPortConnections = prev }()
+
+	seen := idleTracker{}
+	start := time.Date(2026, 3, 4, 9, 0, 0, 0, time.UTC)
+	srv.stopIdleProfiles(context.Background(), seen, start)
+	conns = 1
+	srv.stopIdleProfiles(context.Background(), seen, start.Add(time.Hour))
+	conns = 0
+	srv.stopIdleProfiles(context.Background(), seen, start.Add(150*time.Minute))
+	if len(srv.jobs) != 0 {
+		t.Fatalf("expected traffic to reset the idle timer, got %d jobs", len(srv.jobs))
+	}
+	if _, ok := seen["beta"]; ok {
+		t.Fatal("expected profiles without auto-stop not to be tracked")
+	}
+
+	srv.stopIdleProfiles(context.Background(), seen, start.Add(3*time.Hour))
+	srv.jobMu.Lock()
+	var jobID string
+	for id, job := range srv.jobs {
+		if job.ProfileID != "alpha" || job.Action != "auto-stop" {
+			t.Fatalf("expected an auto-stop of the idle profile, got %+v", job)
+		}
+		jobID = id
+	}
+	srv.jobMu.Unlock()
+	if jobID == "" {
+		t.Fatal("expected an auto-stop job")
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if job, err := srv.Jobs().Get(jobID); err == nil && isTerminalJobStatus(job.Status) {
+			srv.jobMu.Lock()
+			_, busy := srv.activeProfiles["alpha"]
+			srv.jobMu.Unlock()
+			if !busy {
+				return
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("auto-stop job did not finish")
+}
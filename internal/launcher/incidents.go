@@ -0,0 +1,307 @@
+package launcher
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The incident timeline of a profile is built when it is asked for, from
+// what is already recorded: status changes and failed jobs in audit.log,
+// and the container events the Docker daemon still remembers. Compose
+// starts every service with "restart: always", so Docker restarting a
+// container that died is the auto-heal attempt.
+//
+// A problem opens an incident, which lasts until the health monitor sees
+// the profile running (or stopped) again. A new problem within
+// incidentFlapGap of the recovery continues the same incident, so a
+// profile flapping between running and unhealthy reads as one incident.
+
+const (
+	incidentFlapGap      = 10 * time.Minute
+	defaultIncidentRange = 7 * 24 * time.Hour
+	dockerEventsTimeout  = 5 * time.Second
+	dockerStopDieWithin  = time.Minute
+	incidentSourceStatus = "status"
+	incidentSourceJob    = "job"
+	incidentSourceDocker = "docker"
+	// What an event does to the incident it falls in.
+	incidentEffectNone     = 0
+	incidentEffectDown     = 1
+	incidentEffectUp       = 2
+	incidentEffectProblem  = 3
+	incidentEffectAutoHeal = 4
+)
+
+// IncidentEvent is one line of an incident.
+type IncidentEvent struct {
+	At      string `json:"at"`
+	Source  string `json:"source"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+
+	at     time.Time
+	effect int
+}
+
+// Incident is a period in which a profile had problems.
+type Incident struct {
+	Start           string `json:"start"`
+	End             string `json:"end,omitempty"`
+	Ongoing         bool   `json:"ongoing"`
+	DurationSeconds int64  `json:"durationSeconds"`
+	Level           string `json:"level"`
+	Summary         string `json:"summary"`
+	// DownCount is how often the profile went down; more than once means
+	// it was flapping.
+	DownCount int             `json:"downCount"`
+	Flapping  bool            `json:"flapping"`
+	AutoHeals int             `json:"autoHeals"`
+	Events    []IncidentEvent `json:"events"`
+
+	start, end time.Time
+	down       bool
+}
+
+// statusIncidentEvents reads the profile's status changes and failed jobs
+// from audit records.
+func statusIncidentEvents(records []map[string]any, id string, since time.Time) []IncidentEvent {
+	out := []IncidentEvent{}
+	for _, rec := range records {
+		str := func(key string) string {
+			if v, ok := rec[key]; ok && v != nil {
+				return fmt.Sprint(v)
+			}
+			return ""
+		}
+		if str("profile") != id {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, str("ts"))
+		if err != nil || at.Before(since) {
+			continue
+		}
+		e := IncidentEvent{at: at, Level: strings.ToLower(str("level"))}
+		switch str("msg") {
+		case "profile_status_changed":
+			e.Source = incidentSourceStatus
+			e.Message = fmt.Sprintf("Went from %s to %s", str("from"), str("to"))
+			switch str("to") {
+			case "unhealthy", runtimeCrashLooping:
+				e.effect = incidentEffectDown
+			case "running", "stopped":
+				e.effect = incidentEffectUp
+			}
+		case "job_failed":
+			e.Source, e.effect = incidentSourceJob, incidentEffectProblem
+			e.Message = fmt.Sprintf("%s %s: %s", str("action"), str("status"), str("error"))
+		default:
+			continue
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// dockerEvent is a line of docker events --format '{{json .}}'.
+type dockerEvent struct {
+	Action string `json:"Action"`
+	Time   int64  `json:"time"`
+	Actor  struct {
+		ID         string            `json:"ID"`
+		Attributes map[string]string `json:"Attributes"`
+	} `json:"Actor"`
+}
+
+// Swappable so tests can read events without Docker.
+var readDockerEvents = dockerContainerEvents
+
+func dockerContainerEvents(parent context.Context, id string, since, until time.Time) (string, error) {
+	ctx, cancel := context.WithTimeout(parent, dockerEventsTimeout)
+	defer cancel()
+	dockerBin, err := dockerBinaryPath()
+	if err != nil {
+		return "", err
+	}
+	args := []string{"events", "--since", strconv.FormatInt(since.Unix(), 10), "--until", strconv.FormatInt(until.Unix(), 10), "--filter", "type=container"}
+	args = append(args, managedResourceFilters(id)...)
+	args = append(args, "--format", "{{json .}}")
+	out, err := dockerCommandWithContext(ctx, dockerBin, args...).Output()
+	return string(out), err
+}
+
+// parseDockerIncidentEvents turns container events into incident events.
+// A container that dies shortly after it was killed was stopped on
+// purpose; one that dies on its own and starts again was restarted by
+// Docker.
+func parseDockerIncidentEvents(out string) []IncidentEvent {
+	var events []dockerEvent
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		var ev dockerEvent
+		if json.Unmarshal(scanner.Bytes(), &ev) == nil && ev.Action != "" {
+			events = append(events, ev)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time < events[j].Time })
+
+	killedAt := map[string]time.Time{}
+	crashed := map[string]bool{}
+	result := []IncidentEvent{}
+	for _, ev := range events {
+		at := time.Unix(ev.Time, 0).UTC()
+		service := ev.Actor.Attributes["com.docker.compose.service"]
+		if service == "" {
+			service = ev.Actor.Attributes["name"]
+		}
+		e := IncidentEvent{at: at, Source: incidentSourceDocker, Level: "info"}
+		switch action := ev.Action; {
+		case action == "kill":
+			killedAt[ev.Actor.ID] = at
+			continue
+		case action == "oom":
+			e.Level, e.effect = "error", incidentEffectProblem
+			e.Message = service + " ran out of memory"
+		case action == "die":
+			code := ev.Actor.Attributes["exitCode"]
+			if k, ok := killedAt[ev.Actor.ID]; (ok && at.Sub(k) <= dockerStopDieWithin) || code == "0" {
+				e.Message = service + " stopped"
+				break
+			}
+			crashed[ev.Actor.ID] = true
+			e.Level, e.effect = "error", incidentEffectProblem
+			e.Message = fmt.Sprintf("%s exited with code %s", service, code)
+		case action == "start":
+			if !crashed[ev.Actor.ID] {
+				continue
+			}
+			delete(crashed, ev.Actor.ID)
+			e.effect = incidentEffectAutoHeal
+			e.Message = "Docker restarted " + service
+		case strings.HasPrefix(action, "health_status: unhealthy"):
+			e.Level, e.effect = "warn", incidentEffectProblem
+			e.Message = service + " failed its health check"
+		default:
+			continue
+		}
+		e.At = at.Format(time.RFC3339)
+		result = append(result, e)
+	}
+	return result
+}
+
+// groupIncidents folds events, in any order, into incidents, newest first.
+// Events outside any incident are dropped.
+func groupIncidents(events []IncidentEvent, now time.Time) []Incident {
+	sort.SliceStable(events, func(i, j int) bool { return events[i].at.Before(events[j].at) })
+	out := []Incident{}
+	var cur *Incident
+	finish := func() {
+		if cur == nil {
+			return
+		}
+		cur.Ongoing = cur.down
+		end := now
+		if !cur.Ongoing {
+			end = cur.end
+			cur.End = end.Format(time.RFC3339)
+		}
+		cur.Start = cur.start.Format(time.RFC3339)
+		cur.DurationSeconds = int64(end.Sub(cur.start) / time.Second)
+		cur.Flapping = cur.DownCount > 1
+		cur.Level = "warn"
+		for _, e := range cur.Events {
+			if e.Level == "error" {
+				cur.Level = "error"
+			}
+		}
+		out = append(out, *cur)
+		cur = nil
+	}
+	for _, e := range events {
+		if e.At == "" {
+			e.At = e.at.Format(time.RFC3339)
+		}
+		if cur != nil && !cur.down && e.at.Sub(cur.end) > incidentFlapGap {
+			finish()
+		}
+		switch e.effect {
+		case incidentEffectDown, incidentEffectProblem:
+			if cur == nil {
+				cur = &Incident{start: e.at, Summary: e.Message}
+			}
+			if e.effect == incidentEffectDown && !cur.down {
+				cur.DownCount++
+				cur.down = true
+			}
+			if !cur.down {
+				cur.end = e.at
+			}
+		case incidentEffectUp:
+			if cur == nil {
+				continue
+			}
+			if cur.down {
+				cur.down = false
+				cur.end = e.at
+			}
+		default:
+			if cur == nil {
+				continue
+			}
+			if e.effect == incidentEffectAutoHeal {
+				cur.AutoHeals++
+			}
+		}
+		cur.Events = append(cur.Events, e)
+	}
+	finish()
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Start > out[j].Start })
+	return out
+}
+
+func parseIncidentRange(raw string) (time.Duration, error) {
+	if strings.TrimSpace(raw) == "" {
+		return defaultIncidentRange, nil
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(raw))
+	if err != nil || d < time.Hour || d > uptimeRetention {
+		return 0, ValidationError{Msg: fmt.Sprintf("range must be a duration between 1h and %s, such as 24h or 168h", uptimeRetention)}
+	}
+	return d, nil
+}
+
+func (s *Server) handleProfileIncidents(w http.ResponseWriter, r *http.Request, id string) {
+	d, err := parseIncidentRange(r.URL.Query().Get("range"))
+	if err != nil {
+		http.Error(w, err.Error(), httpStatusForError(err))
+		return
+	}
+	if _, err := s.Profiles().Get(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), httpStatusForError(err))
+		return
+	}
+	now := time.Now().UTC()
+	since := now.Add(-d)
+	events := statusIncidentEvents(readAuditRecords(), id, since)
+	dockerEvents := true
+	if out, err := readDockerEvents(r.Context(), id, since, now); err != nil {
+		dockerEvents = false
+		logWarn("incident_docker_events_failed", map[string]any{"profile_id": id, "error": err.Error()})
+	} else {
+		events = append(events, parseDockerIncidentEvents(out)...)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"ok":           true,
+		"profileId":    id,
+		"range":        d.String(),
+		"dockerEvents": dockerEvents,
+		"incidents":    groupIncidents(events, now),
+	})
+}
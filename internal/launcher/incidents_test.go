@@ -0,0 +1,116 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGroupIncidentsMergesFlapping(t *testing.T) {
+	base := time.Date(2026, 4, 2, 10, 0, 0, 0, time.UTC)
+	at := func(min int) string { return base.Add(time.Duration(min) * time.Minute).Format(time.RFC3339) }
+	status := func(min int, from, to string) map[string]any {
+		return map[string]any{"ts": at(min), "level": "WARN", "msg": "profile_status_changed", "profile": "alpha", "from": from, "to": to}
+	}
+	records := []map[string]any{
+		// Flapping: down twice within incidentFlapGap.
+		status(0, "running", "unhealthy"),
+		status(2, "unhealthy", "running"),
+		status(5, "running", "unhealthy"),
+		status(8, "unhealthy", "running"),
+		// Quiet for an hour, then an enable that fails.
+		{"ts": at(70), "level": "WARN", "msg": "job_failed", "profile": "alpha", "action": "enable", "status": "failed", "error": "pull denied"},
+		// Another profile and an event the timeline does not know.
+		status(75, "running", "unhealthy"),
+		{"ts": at(76), "level": "INFO", "msg": "login_succeeded", "profile": "alpha"},
+		// Down until now.
+		status(120, "running", runtimeCrashLooping),
+	}
+	records[5]["profile"] = "beta"
+
+	docker := parseDockerIncidentEvents(
+		`{"Action":"die","time":` + unix(base.Add(61*time.Minute)) + `,"Actor":{"ID":"c1","Attributes":{"com.docker.compose.service":"app","exitCode":"0"}}}
+{"Action":"die","time":` + unix(base.Add(1*time.Minute)) + `,"Actor":{"ID":"c1","Attributes":{"com.docker.compose.service":"app","exitCode":"137"}}}
+{"Action":"start","time":` + unix(base.Add(1*time.Minute+5*time.Second)) + `,"Actor":{"ID":"c1","Attributes":{"com.docker.compose.service":"app"}}}
+{"Action":"kill","time":` + unix(base.Add(119*time.Minute)) + `,"Actor":{"ID":"c2","Attributes":{"com.docker.compose.service":"db"}}}
+{"Action":"die","time":` + unix(base.Add(119*time.Minute+2*time.Second)) + `,"Actor":{"ID":"c2","Attributes":{"com.docker.compose.service":"db","exitCode":"143"}}}
+not json`)
+	if len(docker) != 4 {
+		t.Fatalf("expected 4 docker events, got %+v", docker)
+	}
+
+	now := base.Add(130 * time.Minute)
+	events := append(statusIncidentEvents(records, "alpha", base.Add(-time.Hour)), docker...)
+	got := groupIncidents(events, now)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 incidents, got %+v", got)
+	}
+
+	ongoing, failedJob, flapping := got[0], got[1], got[2]
+	if !ongoing.Ongoing || ongoing.End != "" || ongoing.DurationSeconds != 10*60 || ongoing.DownCount != 1 || ongoing.Start != at(120) {
+		t.Fatalf("unexpected ongoing incident %+v", ongoing)
+	}
+	// The db stopped on purpose just before and the clean exit at 61
+	// minutes fall outside any incident.
+	if len(ongoing.Events) != 1 {
+		t.Fatalf("expected only the status change, got %+v", ongoing.Events)
+	}
+	if failedJob.Ongoing || failedJob.DurationSeconds != 0 || failedJob.Level != "warn" || failedJob.Summary != "enable failed: pull denied" {
+		t.Fatalf("unexpected job incident %+v", failedJob)
+	}
+	if !flapping.Flapping || flapping.DownCount != 2 || flapping.AutoHeals != 1 || flapping.Level != "error" || flapping.End != at(8) || flapping.DurationSeconds != 8*60 {
+		t.Fatalf("unexpected flapping incident %+v", flapping)
+	}
+	if len(flapping.Events) != 6 || flapping.Events[1].Message != "app exited with code 137" || flapping.Events[2].Message != "Docker restarted app" {
+		t.Fatalf("unexpected flapping events %+v", flapping.Events)
+	}
+}
+
+func unix(t time.Time) string {
+	b, _ := json.Marshal(t.Unix())
+	return string(b)
+}
+
+func TestProfileIncidentsEndpoint(t *testing.T) {
+	srv := newServiceTestServer(t)
+	defer func(orig func(context.Context, string, time.Time, time.Time) (string, error)) {
+		readDockerEvents = orig
+	}(readDockerEvents)
+	readDockerEvents = func(context.Context, string, time.Time, time.Time) (string, error) {
+		return "", errors.New("docker not available")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/profiles/alpha/incidents?range=24h", nil)
+	rec := httptest.NewRecorder()
+	srv.handleProfileAction(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %s", rec.Code, rec.Body.String())
+	}
+	var body struct {
+		DockerEvents bool       `json:"dockerEvents"`
+		Range        string     `json:"range"`
+		Incidents    []Incident `json:"incidents"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.DockerEvents || body.Range != "24h0m0s" || body.Incidents == nil {
+		t.Fatalf("unexpected response %s", rec.Body.String())
+	}
+
+	for path, code := range map[string]int{
+		"/api/profiles/alpha/incidents?range=90d":   http.StatusBadRequest,
+		"/api/profiles/alpha/incidents?range=1000h": http.StatusBadRequest,
+		"/api/profiles/nope/incidents":              http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		srv.handleProfileAction(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != code {
+			t.Fatalf("%s: expected %d, got %d", path, code, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+type IntegrityIssue struct {
+	Check    string `json:"check"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+const (
+	integrityRepaired = "repaired"
+	integrityWarning  = "warning"
+)
+
+func checkDataDirIntegrity(dataDir string) []IntegrityIssue {
+	issues := []IntegrityIssue{}
+	add := func(check, severity, message string) {
+		issues = append(issues, IntegrityIssue{Check: check, Severity: severity, Message: message})
+	}
+
+	dbPath := filepath.Join(dataDir, "profiles.json")
+	if _, err := os.Stat(dbPath + ".tmp"); err == nil {
+		if err := os.Remove(dbPath + ".tmp"); err == nil {
+			add("profiles", integrityRepaired, "Removed leftover profiles.json.tmp from an interrupted write")
+		}
+	}
+
+	// Finish interrupted profile changes before anything reads their files.
+	issues = append(issues, recoverProfileTransactions(dataDir)...)
+
+	if info, err := os.Stat(dbPath); err == nil && info.Size() == 0 {
+		add("profiles", integrityWarning, "profiles.json is empty; it may have been cut short by a power loss, restore it from a backup")
+	}
+	store, err := loadProfileStore(context.Background(), dbPath)
+	storeOK := err == nil
+	if err != nil {
+		add("profiles", integrityWarning, "profiles.json cannot be read: "+err.Error())
+	} else if !storeChecksumMatches(store) {
+		add("profiles", integrityWarning, "profiles.json does not match its checksum; it was edited by hand or damaged on disk")
+	}
+
+	if runtime.GOOS != "windows" {
+		issues = append(issues, repairSecretsPermissions(filepath.Join(dataDir, "secrets"))...)
+	}
+
+	if storeOK {
+		issues = append(issues, checkComposeDirs(filepath.Join(dataDir, "compose"), store)...)
+	}
+
+	portFile := filepath.Join(dataDir, "launcher-port")
+	if b, err := os.ReadFile(portFile); err == nil {
+		port, convErr := strconv.Atoi(strings.TrimSpace(string(b)))
+		if convErr != nil || port <= 0 || isTCPPortAvailable(port) {
+			if err := os.Remove(portFile); err == nil {
+				add("port_file", integrityRepaired, "Removed stale launcher-port file")
+			}
+		}
+	}
+
+	return issues
+}
+
+func repairSecretsPermissions(secretsDir string) []IntegrityIssue {
+	issues := []IntegrityIssue{}
+	info, err := os.Stat(secretsDir)
+	if err != nil {
+		return issues
+	}
+	if info.Mode().Perm() != 0o700 {
+		if err := os.Chmod(secretsDir, 0o700); err != nil {
+			issues = append(issues, IntegrityIssue{Check: "secrets", Severity: integrityWarning, Message: "Secrets directory permissions are too open and could not be fixed: " + err.Error()})
+		} else {
+			issues = append(issues, IntegrityIssue{Check: "secrets", Severity: integrityRepaired, Message: fmt.Sprintf("Secrets directory permissions changed from %o to 700", info.Mode().Perm())})
+		}
+	}
+	entries, err := os.ReadDir(secretsDir)
+	if err != nil {
+		return issues
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		fi, err := entry.Info()
+		if err != nil || fi.Mode().Perm() == 0o600 {
+			continue
+		}
+		path := filepath.Join(secretsDir, entry.Name())
+		if err := os.Chmod(path, 0o600); err != nil {
+			issues = append(issues, IntegrityIssue{Check: "secrets", Severity: integrityWarning, Message: "Secret file " + entry.Name() + " has open permissions and could not be fixed: " + err.Error()})
+			continue
+		}
+		issues = append(issues, IntegrityIssue{Check: "secrets", Severity: integrityRepaired, Message: "Secret file " + entry.Name() + " permissions changed to 600"})
+	}
+	return issues
+}
+
+func checkComposeDirs(composeRoot string, store ProfileStore) []IntegrityIssue {
+	issues := []IntegrityIssue{}
+	known := map[string]bool{}
+	for _, p := range store.Profiles {
+		known[p.ID] = true
+	}
+	entries, err := os.ReadDir(composeRoot)
+	if err != nil && !os.IsNotExist(err) {
+		issues = append(issues, IntegrityIssue{Check: "compose", Severity: integrityWarning, Message: "Compose directory cannot be read: " + err.Error()})
+		return issues
+	}
+	present := map[string]bool{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		present[entry.Name()] = true
+		if !known[entry.Name()] {
+			// Containers may still be running from this directory, so it is reported rather than removed.
+			issues = append(issues, IntegrityIssue{Check: "compose", Severity: integrityWarning, Message: "Compose directory " + entry.Name() + " does not belong to any profile"})
+		}
+	}
+	for _, p := range store.Profiles {
+		if p.Enabled && !present[p.ID] {
+			issues = append(issues, IntegrityIssue{Check: "compose", Severity: integrityWarning, Message: "Profile " + p.ID + " is enabled but has no compose files; enable it again to regenerate them"})
+		}
+	}
+	return issues
+}
+
+func integrityWarnings(issues []IntegrityIssue) []IntegrityIssue {
+	out := []IntegrityIssue{}
+	for _, issue := range issues {
+		if issue.Severity == integrityWarning {
+			out = append(out, issue)
+		}
+	}
+	return out
+}
+
+func logIntegrityIssues(issues []IntegrityIssue) {
+	for _, issue := range issues {
+		fields := map[string]any{"check": issue.Check, "message": issue.Message}
+		if issue.Severity == integrityRepaired {
+			logInfo("data_dir_integrity_repaired", fields)
+		} else {
+			logWarn("data_dir_integrity_warning", fields)
+		}
+	}
+}
+
+func (s *Server) handleSystemHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	warnings := integrityWarnings(s.integrityIssues)
+	docker := IsDockerRunning()
+	payload := map[string]any{
+		"ok":         true,
+		"healthy":    len(warnings) == 0,
+		"docker":     docker,
+		"dockerHost": effectiveDockerHost(),
+		"issues":     s.integrityIssues,
+		"warnings":   warnings,
+	}
+	hints := []DockerHint{}
+	if docker != "installed" {
+		hints = append(hints, dockerHints()...)
+	} else if res, ok := dockerResources(r.Context()); ok {
+		payload["dockerResources"] = res
+		profiles := []ProfileRequest{}
+		if store, err := s.readStore(r.Context()); err == nil {
+			profiles = store.Profiles
+		}
+		hints = append(hints, dockerResourceHints(res, profiles)...)
+	}
+	payload["dockerHints"] = hints
+	writeJSON(w, http.StatusOK, payload)
+}
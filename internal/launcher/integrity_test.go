@@ -0,0 +1,86 @@
+package launcher
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestCheckDataDirIntegrityRepairsSafeProblems(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission checks are not applied on windows")
+	}
+	tmp := t.TempDir()
+	secretsDir := filepath.Join(tmp, "secrets")
+	if err := os.MkdirAll(secretsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(secretsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(secretsDir, "alpha.env"), []byte("JWT_SECRET=x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "profiles.json.tmp"), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "launcher-port"), []byte("not-a-port\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	issues := checkDataDirIntegrity(tmp)
+	if len(integrityWarnings(issues)) != 0 {
+		t.Fatalf("expected no unresolved warnings, got %+v", issues)
+	}
+
+	info, err := os.Stat(secretsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o700 {
+		t.Fatalf("expected secrets dir 0700, got %o", info.Mode().Perm())
+	}
+	info, err = os.Stat(filepath.Join(secretsDir, "alpha.env"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected secret file 0600, got %o", info.Mode().Perm())
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "profiles.json.tmp")); !os.IsNotExist(err) {
+		t.Fatalf("expected leftover tmp file to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "launcher-port")); !os.IsNotExist(err) {
+		t.Fatalf("expected stale port file to be removed")
+	}
+}
+
+func TestCheckDataDirIntegrityReportsUnresolvedProblems(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmp, "compose", "orphan"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	store := `{"profiles":[{"id":"alpha","enabled":true}]}`
+	if err := os.WriteFile(filepath.Join(tmp, "profiles.json"), []byte(store), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	warnings := integrityWarnings(checkDataDirIntegrity(tmp))
+	if len(warnings) != 2 {
+		t.Fatalf("expected 2 warnings, got %+v", warnings)
+	}
+	joined := warnings[0].Message + " | " + warnings[1].Message
+	if !strings.Contains(joined, "orphan") || !strings.Contains(joined, "alpha") {
+		t.Fatalf("unexpected warnings: %s", joined)
+	}
+
+	if err := os.WriteFile(filepath.Join(tmp, "profiles.json"), []byte("{broken"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	warnings = integrityWarnings(checkDataDirIntegrity(tmp))
+	if len(warnings) != 1 || warnings[0].Check != "profiles" {
+		t.Fatalf("expected corrupted profiles warning, got %+v", warnings)
+	}
+}
@@ -0,0 +1,146 @@
+package launcher
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// A job's image pulls can be paused, for example to free the connection
+// for a call. Pausing stops the running docker pulls; on resume they start
+// again, and Docker keeps the layers that were complete. Only pulls pause:
+// a job past its pull step runs on. A paused pull still counts toward the
+// action's timeout.
+
+type pauseGateCtxKey struct{}
+
+// pauseGate stops and holds the pulls of one job.
+type pauseGate struct {
+	mu      sync.Mutex
+	paused  bool
+	resumed chan struct{}
+	nextID  int
+	active  map[int]context.CancelFunc
+}
+
+func newPauseGate() *pauseGate {
+	return &pauseGate{active: map[int]context.CancelFunc{}}
+}
+
+func pauseGateFrom(ctx context.Context) *pauseGate {
+	g, _ := ctx.Value(pauseGateCtxKey{}).(*pauseGate)
+	return g
+}
+
+// start registers a pull; pausing cancels the returned context. done must
+// be called when the pull returns.
+func (g *pauseGate) start(ctx context.Context) (context.Context, func()) {
+	if g == nil {
+		return ctx, func() {}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	g.mu.Lock()
+	id := g.nextID
+	g.nextID++
+	g.active[id] = cancel
+	g.mu.Unlock()
+	return ctx, func() {
+		g.mu.Lock()
+		delete(g.active, id)
+		g.mu.Unlock()
+		cancel()
+	}
+}
+
+func (g *pauseGate) pause() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.paused || len(g.active) == 0 {
+		return false
+	}
+	g.paused = true
+	g.resumed = make(chan struct{})
+	for _, cancel := range g.active {
+		cancel()
+	}
+	return true
+}
+
+func (g *pauseGate) resume() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.paused {
+		return false
+	}
+	g.paused = false
+	close(g.resumed)
+	return true
+}
+
+func (g *pauseGate) isPaused() bool {
+	if g == nil {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.paused
+}
+
+// wait blocks while the gate is paused.
+func (g *pauseGate) wait(ctx context.Context) error {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	paused, resumed := g.paused, g.resumed
+	g.mu.Unlock()
+	if !paused {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-resumed:
+		return nil
+	}
+}
+
+// setJobPaused pauses or resumes the pulls of a running job.
+func (s *Server) setJobPaused(jobID string, paused bool) error {
+	s.jobMu.Lock()
+	defer s.jobMu.Unlock()
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return ErrJobNotFound
+	}
+	if isTerminalJobStatus(job.Status) {
+		return ErrJobCompleted
+	}
+	if job.pause == nil {
+		return ErrJobNotPausable
+	}
+	message := "Pull resumed"
+	if paused {
+		if !job.pause.pause() {
+			return ErrJobNotPausable
+		}
+		message = "Pull paused"
+	} else if !job.pause.resume() {
+		return nil
+	}
+	job.Paused = paused
+	job.Message = message
+	job.Logs = append(job.Logs, time.Now().UTC().Format(time.RFC3339)+" ["+job.Step+"] "+message)
+	if len(job.Logs) > 100 {
+		job.Logs = job.Logs[len(job.Logs)-100:]
+	}
+	return nil
+}
+
+func (j *JobService) Pause(id string) error {
+	return j.srv.setJobPaused(id, true)
+}
+
+func (j *JobService) Resume(id string) error {
+	return j.srv.setJobPaused(id, false)
+}
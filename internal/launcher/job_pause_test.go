@@ -0,0 +1,65 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPauseJobPulls(t *testing.T) {
+	srv := newServiceTestServer(t)
+	pulling := make(chan struct{})
+	var pulls int
+	job, err := srv.enqueueProfileJob("alpha", "enable", func(jobID string, ctx context.Context) error {
+		srv.updateJobStep(jobID, "pull", "running", "Pulling", 30, "")
+		gate := pauseGateFrom(ctx)
+		for {
+			if err := gate.wait(ctx); err != nil {
+				return err
+			}
+			pullCtx, done := gate.start(ctx)
+			if pulls++; pulls == 1 {
+				close(pulling)
+				<-pullCtx.Done()
+				done()
+				continue
+			}
+			done()
+			return nil
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-pulling
+	if err := srv.Jobs().Pause(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := srv.Jobs().Get(job.ID); !got.Paused || got.Message != "Pull paused" {
+		t.Fatalf("expected the job paused, got %+v", got)
+	}
+	if err := srv.Jobs().Pause(job.ID); !errors.Is(err, ErrJobNotPausable) {
+		t.Fatalf("expected a second pause to be refused, got %v", err)
+	}
+	if err := srv.Jobs().Resume(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, _ := srv.Jobs().Get(job.ID)
+		if got.Status == "succeeded" {
+			if got.Paused || pulls != 2 {
+				t.Fatalf("expected the pull started again once, got %d pulls %+v", pulls, got)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job did not finish: %+v", got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := srv.Jobs().Pause(job.ID); !errors.Is(err, ErrJobCompleted) {
+		t.Fatalf("expected a finished job to be refused, got %v", err)
+	}
+}
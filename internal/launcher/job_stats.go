@@ -0,0 +1,120 @@
+package launcher
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"sort"
+	"time"
+)
+
+const maxJobStats = 50
+
+// JobStat records how long one successful action took. Machine identifies
+// the host so a data directory moved to faster hardware does not keep
+// reporting the old durations.
+type JobStat struct {
+	Action       string `json:"action"`
+	Version      string `json:"version"`
+	FirstInstall bool   `json:"firstInstall,omitempty"`
+	Machine      string `json:"machine"`
+	DurationMs   int64  `json:"durationMs"`
+	FinishedAt   string `json:"finishedAt"`
+}
+
+func jobMachine() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return host + "/" + runtime.GOOS + "-" + runtime.GOARCH
+}
+
+// estimateJobDuration returns the median duration of comparable earlier
+// runs, preferring the closest match: same machine and version, then same
+// machine, then any machine. First installs are only compared with first
+// installs since they include the image pull. It returns zero without
+// history.
+func estimateJobDuration(stats []JobStat, key JobStat) time.Duration {
+	tiers := []func(JobStat) bool{
+		func(st JobStat) bool { return st.Machine == key.Machine && st.Version == key.Version },
+		func(st JobStat) bool { return st.Machine == key.Machine },
+		func(JobStat) bool { return true },
+	}
+	for _, match := range tiers {
+		var durations []int64
+		for _, st := range stats {
+			if st.Action == key.Action && st.FirstInstall == key.FirstInstall && st.DurationMs > 0 && match(st) {
+				durations = append(durations, st.DurationMs)
+			}
+		}
+		if len(durations) > 0 {
+			sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+			return time.Duration(durations[len(durations)/2]) * time.Millisecond
+		}
+	}
+	return 0
+}
+
+// jobStatKey describes the job about to run for profileID, with version
+// overriding the stored profile version (as the version action does).
+func (s *Server) jobStatKey(ctx context.Context, profileID, action, version string) JobStat {
+	key := JobStat{Action: action, Version: version, Machine: jobMachine()}
+	if key.Version == "" {
+		if store, idx, err := s.getProfileForAction(ctx, profileID); err == nil {
+			key.Version = store.Profiles[idx].Version
+		}
+	}
+	key.FirstInstall = action == "enable" && isFirstProfileInstall(profileID)
+	return key
+}
+
+// attachJobEstimate records what the job is and how long it should take, so
+// snapshots can report the remaining time and completion can be recorded.
+func (s *Server) attachJobEstimate(ctx context.Context, jobID string, key JobStat) {
+	var estimate time.Duration
+	if store, err := s.readStore(ctx); err == nil {
+		estimate = estimateJobDuration(store.JobStats, key)
+	}
+	s.jobMu.Lock()
+	defer s.jobMu.Unlock()
+	if job, ok := s.jobs[jobID]; ok {
+		job.statKey = key
+		job.EstimatedMs = estimate.Milliseconds()
+	}
+}
+
+func (s *Server) recordJobStat(ctx context.Context, stat JobStat) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	store, err := s.loadStoreLocked(ctx)
+	if err != nil {
+		logWarn("job_stat_record_failed", map[string]any{"error": err.Error()})
+		return
+	}
+	store.JobStats = append(store.JobStats, stat)
+	if len(store.JobStats) > maxJobStats {
+		store.JobStats = store.JobStats[len(store.JobStats)-maxJobStats:]
+	}
+	if err := s.writeStoreLocked(store); err != nil {
+		logWarn("job_stat_record_failed", map[string]any{"error": err.Error()})
+	}
+}
+
+// applyJobETA fills the remaining-time fields of a running job snapshot.
+func applyJobETA(job *ActionJob, now time.Time) {
+	if job.EstimatedMs <= 0 || isTerminalJobStatus(job.Status) || job.StartedAt == "" {
+		return
+	}
+	started, err := time.Parse(time.RFC3339, job.StartedAt)
+	if err != nil {
+		return
+	}
+	remaining := time.Duration(job.EstimatedMs)*time.Millisecond - now.Sub(started)
+	if remaining <= 0 {
+		job.ETA = "taking longer than usual"
+		return
+	}
+	job.RemainingMs = remaining.Milliseconds()
+	job.ETA = formatETA(remaining) + " left"
+}
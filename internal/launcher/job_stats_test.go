@@ -0,0 +1,52 @@
+package launcher
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEstimateJobDuration(t *testing.T) {
+	stats := []JobStat{
+		{Action: "enable", Version: "1.0.0", Machine: "other", DurationMs: 90_000},
+		{Action: "enable", Version: "1.0.0", Machine: "here", FirstInstall: true, DurationMs: 300_000},
+		{Action: "enable", Version: "1.0.0", Machine: "here", DurationMs: 20_000},
+		{Action: "enable", Version: "2.0.0", Machine: "here", DurationMs: 40_000},
+		{Action: "stop", Version: "1.0.0", Machine: "here", DurationMs: 5_000},
+	}
+	cases := []struct {
+		key  JobStat
+		want time.Duration
+	}{
+		{JobStat{Action: "enable", Version: "1.0.0", Machine: "here"}, 20 * time.Second},
+		{JobStat{Action: "enable", Version: "3.0.0", Machine: "here"}, 40 * time.Second},
+		{JobStat{Action: "enable", Version: "1.0.0", Machine: "new"}, 40 * time.Second},
+		{JobStat{Action: "enable", Version: "1.0.0", Machine: "here", FirstInstall: true}, 5 * time.Minute},
+		{JobStat{Action: "recreate", Version: "1.0.0", Machine: "here"}, 0},
+	}
+	for _, tc := range cases {
+		if got := estimateJobDuration(stats, tc.key); got != tc.want {
+			t.Fatalf("estimate for %+v: got %v, want %v", tc.key, got, tc.want)
+		}
+	}
+}
+
+func TestApplyJobETA(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	job := ActionJob{Status: "running", StartedAt: now.Add(-time.Minute).Format(time.RFC3339), EstimatedMs: (5 * time.Minute).Milliseconds()}
+	applyJobETA(&job, now)
+	if job.RemainingMs != (4*time.Minute).Milliseconds() || job.ETA != "about 4 min left" {
+		t.Fatalf("unexpected ETA %d %q", job.RemainingMs, job.ETA)
+	}
+
+	late := ActionJob{Status: "running", StartedAt: now.Add(-10 * time.Minute).Format(time.RFC3339), EstimatedMs: 60_000}
+	applyJobETA(&late, now)
+	if late.ETA != "taking longer than usual" {
+		t.Fatalf("unexpected ETA for overdue job %q", late.ETA)
+	}
+
+	done := ActionJob{Status: "succeeded", StartedAt: job.StartedAt, EstimatedMs: 60_000}
+	applyJobETA(&done, now)
+	if done.ETA != "" {
+		t.Fatalf("finished jobs have no ETA")
+	}
+}
@@ -0,0 +1,172 @@
+package launcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GET /api/jobs/<id>/stream follows a job over server-sent events instead
+// of polling. It starts with a "job" event holding the job as GET
+// /api/jobs/<id> returns it, then sends a "step" event for every progress
+// update and an "output" event for every line docker prints while pulling
+// or starting the stack, and ends with a "done" event holding the finished
+// job. Output lines are only streamed; the job log keeps its 100 step
+// messages.
+
+const jobStreamBuffer = 256
+
+type jobOutputCtxKey struct{}
+
+// jobOutputFrom returns where the job running under ctx sends command
+// output; outside a job the output is dropped.
+func jobOutputFrom(ctx context.Context) func(source, line string) {
+	if out, ok := ctx.Value(jobOutputCtxKey{}).(func(source, line string)); ok {
+		return out
+	}
+	return func(string, string) {}
+}
+
+func (s *Server) withJobOutput(ctx context.Context, jobID string) context.Context {
+	return context.WithValue(ctx, jobOutputCtxKey{}, func(source, line string) {
+		s.publishJobEvent("output", map[string]any{"jobId": jobID, "source": source, "line": line})
+	})
+}
+
+func (s *Server) publishJobEvent(name string, data map[string]any) {
+	if s.jobEvents != nil {
+		s.jobEvents.publish(name, data)
+	}
+}
+
+// publishJobStepLocked announces a job update. Callers hold s.jobMu.
+func (s *Server) publishJobStepLocked(job *ActionJob) {
+	s.publishJobEvent("step", map[string]any{
+		"jobId":    job.ID,
+		"step":     job.Step,
+		"status":   job.Status,
+		"message":  job.Message,
+		"progress": job.Progress,
+		"error":    job.Error,
+	})
+}
+
+// lineWriter hands each complete line written to it to onLine. Docker
+// redraws progress with carriage returns, which end a line too.
+type lineWriter struct {
+	mu      sync.Mutex
+	pending []byte
+	onLine  func(string)
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.IndexAny(w.pending, "\r\n")
+		if i < 0 {
+			break
+		}
+		if line := strings.TrimSpace(string(w.pending[:i])); line != "" {
+			w.onLine(line)
+		}
+		w.pending = w.pending[i+1:]
+	}
+	return len(p), nil
+}
+
+func (w *lineWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if line := strings.TrimSpace(string(w.pending)); line != "" {
+		w.onLine(line)
+	}
+	w.pending = nil
+}
+
+// runCommandStreamed is cmd.CombinedOutput that also hands each output
+// line to onLine as it is printed.
+func runCommandStreamed(cmd *exec.Cmd, onLine func(string)) ([]byte, error) {
+	var buf bytes.Buffer
+	lines := &lineWriter{onLine: onLine}
+	out := io.MultiWriter(&buf, lines)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err := cmd.Run()
+	lines.flush()
+	return buf.Bytes(), err
+}
+
+func (s *Server) handleJobStream(w http.ResponseWriter, r *http.Request, jobID string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	// Subscribe before the snapshot so no update falls in between.
+	events, unsubscribe := s.jobEvents.subscribeSize(jobStreamBuffer)
+	defer unsubscribe()
+	job, err := s.Jobs().Get(jobID)
+	if err != nil {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	disableWriteDeadline(w)
+
+	send := func(name string, data any) {
+		b, err := json.Marshal(data)
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, b)
+		flusher.Flush()
+	}
+	finish := func() {
+		if done, ok := s.snapshotJob(jobID); ok {
+			send("done", done)
+		}
+	}
+	send("job", job)
+	if isTerminalJobStatus(job.Status) {
+		finish()
+		return
+	}
+
+	heartbeat := time.NewTicker(eventsHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			// The terminal update may have been dropped on a full buffer.
+			if current, ok := s.snapshotJob(jobID); ok && isTerminalJobStatus(current.Status) {
+				finish()
+				return
+			}
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		case ev := <-events:
+			data, _ := ev.Data.(map[string]any)
+			if data["jobId"] != jobID {
+				continue
+			}
+			send(ev.Name, data)
+			if status, _ := data["status"].(string); ev.Name == "step" && isTerminalJobStatus(status) {
+				finish()
+				return
+			}
+		}
+	}
+}
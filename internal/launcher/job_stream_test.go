@@ -0,0 +1,85 @@
+package launcher
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestJobStreamSendsStepsOutputAndDone(t *testing.T) {
+	srv := newServiceTestServer(t)
+	release := make(chan struct{})
+	job, err := srv.enqueueProfileJob("alpha", "enable", func(jobID string, ctx context.Context) error {
+		<-release
+		jobOutputFrom(ctx)("compose", "Container kimmio-alpha-app Started")
+		srv.updateJobStep(jobID, "up", "running", "Containers started", 80, "")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(http.HandlerFunc(srv.handleJobRoute))
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/api/jobs/" + job.ID + "/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	var events []string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name, ok := strings.CutPrefix(line, "event: "); ok {
+			events = append(events, name)
+			if name == "job" {
+				close(release)
+			}
+		}
+		if d, ok := strings.CutPrefix(line, "data: "); ok {
+			data = append(data, d)
+		}
+	}
+	joined := strings.Join(events, ",")
+	if !strings.HasPrefix(joined, "job,") || !strings.HasSuffix(joined, ",done") || !strings.Contains(joined, "output") {
+		t.Fatalf("unexpected event sequence %s", joined)
+	}
+	all := strings.Join(data, "\n")
+	if !strings.Contains(all, `"line":"Container kimmio-alpha-app Started"`) || !strings.Contains(data[len(data)-1], `"status":"succeeded"`) {
+		t.Fatalf("unexpected stream data:\n%s", all)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.handleJobRoute(rec, httptest.NewRequest(http.MethodGet, "/api/jobs/missing/stream", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown job, got %d", rec.Code)
+	}
+}
+
+func TestRunCommandStreamedSplitsLines(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses /bin/sh")
+	}
+	var lines []string
+	out, err := runCommandStreamed(exec.Command("/bin/sh", "-c", `printf 'one\ntwo\rthree\n'; printf 'err' >&2`), func(line string) {
+		lines = append(lines, line)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(lines, "|") != "one|two|three|err" {
+		t.Fatalf("unexpected lines %q", lines)
+	}
+	if !strings.Contains(string(out), "err") {
+		t.Fatalf("expected the combined output, got %q", out)
+	}
+}
@@ -20,6 +20,15 @@ type ActionJob struct {
 	Logs       []string `json:"logs,omitempty"`
 	StartedAt  string   `json:"startedAt,omitempty"`
 	FinishedAt string   `json:"finishedAt,omitempty"`
+	// EstimatedMs is the expected total duration from earlier runs;
+	// RemainingMs and ETA are derived from it while the job runs.
+	EstimatedMs int64  `json:"estimatedMs,omitempty"`
+	RemainingMs int64  `json:"remainingMs,omitempty"`
+	ETA         string `json:"eta,omitempty"`
+	// Paused is set while the job's image pulls are paused.
+	Paused  bool `json:"paused,omitempty"`
+	statKey JobStat
+	pause   *pauseGate
 }
 
 func (s *Server) handleJobRoute(w http.ResponseWriter, r *http.Request) {
@@ -38,28 +47,40 @@ func (s *Server) handleJobRoute(w http.ResponseWriter, r *http.Request) {
 		s.handleJobStatus(w, r, jobID)
 		return
 	}
+	if len(parts) == 2 && parts[1] == "stream" && r.Method == http.MethodGet {
+		s.handleJobStream(w, r, jobID)
+		return
+	}
 	if len(parts) == 2 && parts[1] == "cancel" && r.Method == http.MethodPost {
-		if err := s.cancelJob(jobID); err != nil {
+		if err := s.Jobs().Cancel(jobID); err != nil {
 			http.Error(w, err.Error(), http.StatusConflict)
 			return
 		}
 		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "canceled": true})
 		return
 	}
+	if len(parts) == 2 && (parts[1] == "pause" || parts[1] == "resume") && r.Method == http.MethodPost {
+		jobs := s.Jobs()
+		pause := jobs.Resume
+		if parts[1] == "pause" {
+			pause = jobs.Pause
+		}
+		if err := pause(jobID); err != nil {
+			http.Error(w, err.Error(), httpStatusForError(err))
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "paused": parts[1] == "pause"})
+		return
+	}
 	http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 }
 
 func (s *Server) handleJobStatus(w http.ResponseWriter, _ *http.Request, jobID string) {
-	s.jobMu.Lock()
-	job, ok := s.jobs[jobID]
-	if !ok {
-		s.jobMu.Unlock()
+	copyJob, err := s.Jobs().Get(jobID)
+	if err != nil {
 		http.Error(w, "Job not found", http.StatusNotFound)
 		return
 	}
-	copyJob := *job
-	copyJob.Logs = append([]string{}, job.Logs...)
-	s.jobMu.Unlock()
 
 	writeJSON(w, http.StatusOK, map[string]any{
 		"ok":  true,
@@ -67,16 +88,44 @@ func (s *Server) handleJobStatus(w http.ResponseWriter, _ *http.Request, jobID s
 	})
 }
 
+func (s *Server) snapshotJob(jobID string) (ActionJob, bool) {
+	s.jobMu.Lock()
+	defer s.jobMu.Unlock()
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return ActionJob{}, false
+	}
+	copyJob := *job
+	copyJob.Logs = append([]string{}, job.Logs...)
+	applyJobETA(&copyJob, time.Now())
+	return copyJob, true
+}
+
+// appendJobLog adds a line to the job log without changing its step or
+// progress, as for command output.
+func (s *Server) appendJobLog(jobID, step, line string) {
+	s.jobMu.Lock()
+	defer s.jobMu.Unlock()
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return
+	}
+	job.Logs = append(job.Logs, time.Now().UTC().Format(time.RFC3339)+" ["+step+"] "+line)
+	if len(job.Logs) > 100 {
+		job.Logs = job.Logs[len(job.Logs)-100:]
+	}
+}
+
 func (s *Server) cancelJob(jobID string) error {
 	s.jobMu.Lock()
 	job, ok := s.jobs[jobID]
 	if !ok {
 		s.jobMu.Unlock()
-		return errors.New("job not found")
+		return ErrJobNotFound
 	}
-	if job.Status == "succeeded" || job.Status == "failed" || job.Status == "timeout" || job.Status == "rolled_back" || job.Status == "canceled" {
+	if isTerminalJobStatus(job.Status) {
 		s.jobMu.Unlock()
-		return errors.New("job already completed")
+		return ErrJobCompleted
 	}
 	cancel := s.jobCancels[jobID]
 	job.Step = "cancel"
@@ -93,11 +142,29 @@ func (s *Server) cancelJob(jobID string) error {
 	return nil
 }
 
+// activeProfileJob returns the job holding the profile's action lock.
+func (s *Server) activeProfileJob(profileID string) (string, bool) {
+	s.jobMu.Lock()
+	defer s.jobMu.Unlock()
+	jobID, ok := s.activeProfiles[profileID]
+	return jobID, ok
+}
+
+// ensureNoOtherJob refuses to go on while a job other than jobID holds the
+// profile. Jobs are already serialized by enqueueProfileJob; this guards
+// RunAction, which performs actions without taking the lock.
+func (s *Server) ensureNoOtherJob(profileID, jobID string) error {
+	if active, ok := s.activeProfileJob(profileID); ok && active != jobID {
+		return ProfileBusyError{JobID: active}
+	}
+	return nil
+}
+
 func (s *Server) enqueueProfileJob(profileID, action string, run func(jobID string, ctx context.Context) error) (*ActionJob, error) {
 	s.jobMu.Lock()
 	if existingJobID, busy := s.activeProfiles[profileID]; busy {
 		s.jobMu.Unlock()
-		return nil, errors.New("another action is already running for this profile (job " + existingJobID + ")")
+		return nil, ProfileBusyError{JobID: existingJobID}
 	}
 
 	jobID := randomToken(16)
@@ -109,14 +176,18 @@ func (s *Server) enqueueProfileJob(profileID, action string, run func(jobID stri
 		Message:   "Queued",
 		Progress:  0,
 		Logs:      []string{},
+		pause:     newPauseGate(),
 	}
 	ctx, cancel := context.WithCancel(context.Background())
+	ctx = context.WithValue(ctx, pauseGateCtxKey{}, job.pause)
+	ctx = s.withJobOutput(ctx, jobID)
 	s.jobs[jobID] = job
 	s.activeProfiles[profileID] = jobID
 	s.jobCancels[jobID] = cancel
 	s.jobMu.Unlock()
 
 	go func() {
+		started := time.Now()
 		s.updateJobStep(jobID, "prepare", "running", "Preparing action", 5, "")
 		err := run(jobID, ctx)
 		if err != nil {
@@ -125,13 +196,24 @@ func (s *Server) enqueueProfileJob(profileID, action string, run func(jobID stri
 				s.updateJobStep(jobID, "cancel", "canceled", "Canceled", 100, "operation canceled by user")
 			} else if strings.Contains(strings.ToLower(errText), "deadline exceeded") || strings.Contains(strings.ToLower(errText), "timeout") {
 				s.updateJobStep(jobID, "cleanup", "timeout", "Timed out", 100, errText)
+				auditLog("WARN", "job_failed", map[string]any{"profile": profileID, "action": action, "job": jobID, "status": "timeout", "error": errText})
 			} else {
 				s.updateJobStep(jobID, "cleanup", "failed", "Failed", 100, errText)
+				auditLog("WARN", "job_failed", map[string]any{"profile": profileID, "action": action, "job": jobID, "status": "failed", "error": errText})
 			}
 		} else {
 			s.updateJobStep(jobID, "cleanup", "succeeded", "Completed", 100, "")
 		}
 
+		s.jobMu.Lock()
+		stat := job.statKey
+		s.jobMu.Unlock()
+		if err == nil && stat.Action != "" {
+			stat.DurationMs = time.Since(started).Milliseconds()
+			stat.FinishedAt = time.Now().UTC().Format(time.RFC3339)
+			s.recordJobStat(context.Background(), stat)
+		}
+
 		s.jobMu.Lock()
 		delete(s.activeProfiles, profileID)
 		delete(s.jobCancels, jobID)
@@ -152,7 +234,7 @@ func (s *Server) updateJob(jobID, status, message string, progress int, errText
 	if status == "running" && job.StartedAt == "" {
 		job.StartedAt = now
 	}
-	if status == "succeeded" || status == "failed" || status == "timeout" || status == "rolled_back" || status == "canceled" {
+	if isTerminalJobStatus(status) {
 		job.FinishedAt = now
 	}
 	job.Status = status
@@ -165,6 +247,7 @@ func (s *Server) updateJob(jobID, status, message string, progress int, errText
 			job.Logs = job.Logs[len(job.Logs)-100:]
 		}
 	}
+	s.publishJobStepLocked(job)
 }
 
 func (s *Server) updateJobStep(jobID, step, status, message string, progress int, errText string) {
@@ -178,8 +261,9 @@ func (s *Server) updateJobStep(jobID, step, status, message string, progress int
 	if status == "running" && job.StartedAt == "" {
 		job.StartedAt = now
 	}
-	if status == "succeeded" || status == "failed" || status == "timeout" || status == "rolled_back" || status == "canceled" {
+	if isTerminalJobStatus(status) {
 		job.FinishedAt = now
+		job.Paused = false
 	}
 	job.Step = step
 	job.Status = status
@@ -192,4 +276,5 @@ func (s *Server) updateJobStep(jobID, step, status, message string, progress int
 			job.Logs = job.Logs[len(job.Logs)-100:]
 		}
 	}
+	s.publishJobStepLocked(job)
 }
@@ -44,3 +44,17 @@ func TestEnqueueProfileJobLocksByProfile(t *testing.T) {
 		t.Fatalf("expected succeeded status, got %q", stored.Status)
 	}
 }
+
+func TestJobCountsTracksActiveJobs(t *testing.T) {
+	cfg := config.Load("dev")
+	appCfg = cfg
+	srv := NewServer(cfg)
+	srv.jobs["a"] = &ActionJob{ID: "a", Status: "running"}
+	srv.jobs["b"] = &ActionJob{ID: "b", Status: "succeeded"}
+	srv.jobs["c"] = &ActionJob{ID: "c", Status: "queued"}
+
+	counts := srv.jobCounts()
+	if counts["active"] != 2 || counts["total"] != 3 {
+		t.Fatalf("unexpected job counts: %+v", counts)
+	}
+}
@@ -0,0 +1,33 @@
+package launcher
+
+// Labels on every container, network and volume of a profile stack, so
+// launcher-managed resources can be found with docker label filters. The
+// launcher version is only set on containers: it changes on every upgrade,
+// and volumes and networks are never recreated.
+const (
+	labelManagedBy       = "com.kimmio.launcher.managed-by"
+	labelProfileID       = "com.kimmio.launcher.profile-id"
+	labelLauncherVersion = "com.kimmio.launcher.version"
+
+	managedByLauncher = "kimmio-launcher"
+)
+
+// managedResourceLabels returns the "key=value" labels matching the
+// resources of one profile, or of every profile when profileID is empty.
+func managedResourceLabels(profileID string) []string {
+	labels := []string{labelManagedBy + "=" + managedByLauncher}
+	if profileID != "" {
+		labels = append(labels, labelProfileID+"="+profileID)
+	}
+	return labels
+}
+
+// managedResourceFilters returns the docker CLI filter arguments for
+// managedResourceLabels.
+func managedResourceFilters(profileID string) []string {
+	var args []string
+	for _, label := range managedResourceLabels(profileID) {
+		args = append(args, "--filter", "label="+label)
+	}
+	return args
+}
@@ -10,15 +10,24 @@ import (
 	"time"
 )
 
-const launcherRepoLatestReleaseAPI = "https://api.github.com/repos/kimmio-com/launcher/releases/latest"
+const (
+	launcherRepoLatestReleaseAPI = "https://api.github.com/repos/kimmio-com/launcher/releases/latest"
+	launcherRepoReleaseTagAPI    = "https://api.github.com/repos/kimmio-com/launcher/releases/tags/"
+	launcherRepoReleasesAPI      = "https://api.github.com/repos/kimmio-com/launcher/releases?per_page=10"
+)
 
 type githubRelease struct {
 	TagName string `json:"tag_name"`
+	Name    string `json:"name"`
 	HTMLURL string `json:"html_url"`
+	Body    string `json:"body"`
 	Assets  []struct {
 		Name               string `json:"name"`
 		BrowserDownloadURL string `json:"browser_download_url"`
 	} `json:"assets"`
+	// Checksums maps asset names to sha256 digests published inline by an
+	// update manifest; GitHub releases ship checksum files instead.
+	Checksums map[string]string `json:"-"`
 }
 
 func (s *Server) handleLauncherUpdate(w http.ResponseWriter, r *http.Request) {
@@ -28,27 +37,38 @@ func (s *Server) handleLauncherUpdate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	current := strings.TrimSpace(launcherAppVersion)
-	release, err := fetchLatestLauncherRelease()
+	release, err := fetchLatestLauncherRelease(s.settings.get().UpdateChannel)
 	if err != nil {
 		logWarn("launcher_update_check_failed", map[string]any{"error": err.Error()})
-		writeJSON(w, http.StatusOK, map[string]any{
+		payload := map[string]any{
 			"ok":              true,
 			"currentVersion":  current,
 			"latestVersion":   "",
 			"updateAvailable": false,
-		})
+			"checkError":      err.Error(),
+		}
+		var limited GitHubRateLimitError
+		if errors.As(err, &limited) {
+			payload["rateLimitedUntil"] = limited.Until.UTC().Format(time.RFC3339)
+		}
+		writeJSON(w, http.StatusOK, payload)
 		return
 	}
 
 	latest := strings.TrimPrefix(strings.TrimSpace(release.TagName), "v")
 	updateAvailable := isNewerVersion(latest, current)
-	downloadURL := chooseLauncherAssetURL(release, runtime.GOOS, runtime.GOARCH)
+	asset, verifiable := chooseVerifiedLauncherAsset(release, runtime.GOOS, runtime.GOARCH)
+	if asset.URL != "" && !verifiable {
+		logWarn("launcher_asset_unverifiable", map[string]any{"asset": asset.Name, "latest_version": latest})
+		asset = launcherAsset{}
+	}
 	logInfo("launcher_update_checked", map[string]any{
 		"current_version":  current,
 		"latest_version":   latest,
 		"update_available": updateAvailable,
 		"release_url":      release.HTMLURL,
-		"download_url_set": downloadURL != "",
+		"download_url_set": asset.URL != "",
+		"signature_set":    asset.SignatureURL != "",
 		"runtime_goos":     runtime.GOOS,
 		"runtime_goarch":   runtime.GOARCH,
 	})
@@ -59,31 +79,91 @@ func (s *Server) handleLauncherUpdate(w http.ResponseWriter, r *http.Request) {
 		"latestVersion":   latest,
 		"updateAvailable": updateAvailable,
 		"releaseURL":      release.HTMLURL,
-		"downloadURL":     downloadURL,
+		"downloadURL":     asset.URL,
+		"assetName":       asset.Name,
+		"checksumURL":     asset.ChecksumURL,
+		"signatureURL":    asset.SignatureURL,
+		"sha256":          asset.SHA256,
 	})
 }
 
-func fetchLatestLauncherRelease() (githubRelease, error) {
+// fetchLatestLauncherRelease returns the newest release on channel. An
+// update manifest describes a single release and ignores the channel.
+func fetchLatestLauncherRelease(channel string) (githubRelease, error) {
+	if url := strings.TrimSpace(appCfg.UpdateManifestURL); url != "" {
+		return fetchManifestRelease(url)
+	}
+	if channel == updateChannelPrerelease {
+		return fetchNewestGitHubRelease(launcherRepoReleasesAPI)
+	}
+	return fetchGitHubRelease(launcherRepoLatestReleaseAPI)
+}
+
+// fetchLauncherReleaseByVersion loads the release notes of an installed
+// version, which may be older than the latest release.
+func fetchLauncherReleaseByVersion(version string) (githubRelease, error) {
+	if url := strings.TrimSpace(appCfg.UpdateManifestURL); url != "" {
+		release, err := fetchManifestRelease(url)
+		if err != nil {
+			return release, err
+		}
+		if strings.TrimPrefix(release.TagName, "v") != strings.TrimPrefix(strings.TrimSpace(version), "v") {
+			return githubRelease{}, errors.New("update manifest does not describe version " + version)
+		}
+		return release, nil
+	}
+	return fetchGitHubRelease(launcherRepoReleaseTagAPI + "v" + strings.TrimPrefix(strings.TrimSpace(version), "v"))
+}
+
+func fetchGitHubRelease(url string) (githubRelease, error) {
 	var out githubRelease
+	err := fetchGitHubJSON(url, &out)
+	return out, err
+}
+
+// fetchNewestGitHubRelease returns the first published release of a
+// release list, which GitHub orders newest first and which includes
+// prereleases.
+func fetchNewestGitHubRelease(url string) (githubRelease, error) {
+	var releases []struct {
+		githubRelease
+		Draft bool `json:"draft"`
+	}
+	if err := fetchGitHubJSON(url, &releases); err != nil {
+		return githubRelease{}, err
+	}
+	for _, r := range releases {
+		if !r.Draft {
+			return r.githubRelease, nil
+		}
+	}
+	return githubRelease{}, errors.New("no published launcher release found")
+}
+
+func fetchGitHubJSON(url string, out any) error {
 	client := http.Client{Timeout: 5 * time.Second}
-	req, err := http.NewRequest(http.MethodGet, launcherRepoLatestReleaseAPI, nil)
+	if err := githubRate.check(time.Now()); err != nil {
+		return err
+	}
+	req, err := newGitHubRequest(url)
 	if err != nil {
-		return out, err
+		return err
 	}
-	req.Header.Set("Accept", "application/vnd.github+json")
-	req.Header.Set("User-Agent", "kimmio-launcher")
 	resp, err := client.Do(req)
 	if err != nil {
-		return out, err
+		return err
 	}
 	defer resp.Body.Close()
+	if err := githubRate.observe(resp, time.Now()); err != nil {
+		return err
+	}
 	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		return out, errors.New("github release api request failed")
+		return errors.New("github release api request failed")
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return out, err
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return err
 	}
-	return out, nil
+	return nil
 }
 
 func chooseLauncherAssetURL(release githubRelease, goos, goarch string) string {
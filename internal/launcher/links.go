@@ -0,0 +1,137 @@
+package launcher
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultProfileLogLines = 200
+	maxProfileLogLines     = 2000
+)
+
+// ProfileLinks are the addresses worth opening for a profile. App and MinIO
+// are absolute; Logs and Metrics are paths on the launcher, so they work
+// through whatever address the launcher is reached on.
+type ProfileLinks struct {
+	App string `json:"app"`
+	// MinIO is the object storage endpoint, exposed on loopback only in
+	// host networking mode.
+	MinIO   string `json:"minio,omitempty"`
+	Logs    string `json:"logs"`
+	Metrics string `json:"metrics"`
+}
+
+func profileLinks(p ProfileRequest) *ProfileLinks {
+	id := url.PathEscape(p.ID)
+	links := &ProfileLinks{
+		App:     profileBaseURL(p),
+		Logs:    "/api/profiles/" + id + "/logs",
+		Metrics: "/?search=" + url.QueryEscape(p.ID) + "#usage-" + p.ID,
+	}
+	if port := profileHostPort(p); p.Network.HostMode && port > 0 {
+		links.MinIO = "http://127.0.0.1:" + strconv.Itoa(port+3)
+	}
+	return links
+}
+
+// absolute resolves the launcher paths against base, for output outside a
+// browser.
+func (l ProfileLinks) absolute(base string) ProfileLinks {
+	base = strings.TrimRight(base, "/")
+	l.Logs = base + l.Logs
+	l.Metrics = base + l.Metrics
+	return l
+}
+
+// cliLauncherBase is the launcher address the CLI prints links against:
+// the active context, else the port a running launcher recorded, else the
+// configured one.
+func cliLauncherBase() string {
+	if activeCLIContext.URL != "" {
+		return activeCLIContext.URL
+	}
+	port := normalizeListenPort(appCfg.ListenPort)
+	if raw, err := os.ReadFile(filepath.Join(appCfg.DataDir, "launcher-port")); err == nil {
+		if p, err := strconv.Atoi(strings.TrimSpace(string(raw))); err == nil {
+			port = p
+		}
+	}
+	return fmt.Sprintf("http://localhost:%d", port)
+}
+
+// handleProfileLogs returns the last lines of a profile's containers as
+// plain text. service picks one compose service; without it every service
+// is shown under a header. tail (or lines) sets how many lines; follow=true
+// keeps streaming `docker compose logs --follow` until the client leaves.
+func (s *Server) handleProfileLogs(w http.ResponseWriter, r *http.Request, id string) {
+	q := r.URL.Query()
+	lines := defaultProfileLogLines
+	param := "tail"
+	if !q.Has(param) {
+		param = "lines"
+	}
+	if raw := strings.TrimSpace(q.Get(param)); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxProfileLogLines {
+			writeValidationError(w, fieldError(param, "query."+param, fmt.Sprintf("%s must be between 1 and %d", param, maxProfileLogLines)))
+			return
+		}
+		lines = n
+	}
+	service := strings.TrimSpace(q.Get("service"))
+	if service != "" && !slices.Contains(profileLogServices, service) {
+		writeValidationError(w, fieldError("service", "query.service", "service must be one of "+strings.Join(profileLogServices, ", ")))
+		return
+	}
+	follow := false
+	if raw := strings.TrimSpace(q.Get("follow")); raw != "" {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			writeValidationError(w, fieldError("follow", "query.follow", "follow must be true or false"))
+			return
+		}
+		follow = v
+	}
+	if follow {
+		s.handleProfileLogsFollow(w, r, id, service, lines)
+		return
+	}
+
+	p, err := s.Profiles().Get(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatusForError(err))
+		return
+	}
+	var out strings.Builder
+	found := false
+	for _, st := range p.Services {
+		if service != "" && st.Service != service {
+			continue
+		}
+		found = true
+		tail, err := containerLogTail(r.Context(), st.ContainerID, lines)
+		if err != nil {
+			fmt.Fprintf(&out, "==> %s: %v\n", st.Service, err)
+			continue
+		}
+		if service == "" {
+			fmt.Fprintf(&out, "==> %s <==\n", st.Service)
+		}
+		for _, line := range tail {
+			out.WriteString(line + "\n")
+		}
+	}
+	if !found {
+		http.Error(w, "No containers found for "+id+"; enable the profile to see its logs", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte(out.String()))
+}
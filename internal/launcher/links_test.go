@@ -0,0 +1,101 @@
+package launcher
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProfileLinks(t *testing.T) {
+	p := ProfileRequest{ID: "alpha", Ports: []PortMapping{{Container: 3000, Host: 8088}}}
+	links := profileLinks(p)
+	if links.App != "http://localhost:8088" || links.MinIO != "" || links.Logs != "/api/profiles/alpha/logs" || links.Metrics != "/?search=alpha#usage-alpha" {
+		t.Fatalf("unexpected links %+v", links)
+	}
+
+	p.Env = map[string]string{"APP_DOMAIN": "shop.example.com"}
+	p.Network.HostMode = true
+	links = profileLinks(p)
+	if links.App != "https://shop.example.com" || links.MinIO != "http://127.0.0.1:8091" {
+		t.Fatalf("expected the domain and the loopback MinIO port, got %+v", links)
+	}
+	if abs := links.absolute("http://127.0.0.1:7331/"); abs.Logs != "http://127.0.0.1:7331/api/profiles/alpha/logs" || abs.App != links.App {
+		t.Fatalf("expected launcher paths resolved against the base, got %+v", abs)
+	}
+}
+
+func TestProfileLogsAPI(t *testing.T) {
+	srv := newServiceTestServer(t)
+	rec := httptest.NewRecorder()
+	srv.handleProfileAction(rec, httptest.NewRequest(http.MethodGet, "/api/profiles/alpha/logs?lines=0", nil))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"query.lines"`) {
+		t.Fatalf("expected a validation error, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	srv.handleProfileAction(rec, httptest.NewRequest(http.MethodGet, "/api/profiles/alpha/logs", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a profile without containers, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestProfileLogsFollow(t *testing.T) {
+	srv := newServiceTestServer(t)
+	old := streamComposeLogs
+	defer func() { streamComposeLogs = old }()
+	var gotService string
+	var gotTail int
+	var gotFollow bool
+	streamComposeLogs = func(_ context.Context, id, service string, tail int, follow bool, w io.Writer) error {
+		gotService, gotTail, gotFollow = service, tail, follow
+		_, err := io.WriteString(w, "kimmio_app-1  | listening on :3000\n")
+		return err
+	}
+
+	rec := httptest.NewRecorder()
+	srv.handleProfileAction(rec, httptest.NewRequest(http.MethodGet, "/api/profiles/alpha/logs?service=nginx", nil))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"query.service"`) {
+		t.Fatalf("expected an unknown service to be refused, got %d %s", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	srv.handleProfileAction(rec, httptest.NewRequest(http.MethodGet, "/api/profiles/alpha/logs?tail=5000", nil))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"query.tail"`) {
+		t.Fatalf("expected tail to be bounded, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Without a compose project there is nothing to follow.
+	rec = httptest.NewRecorder()
+	srv.handleProfileAction(rec, httptest.NewRequest(http.MethodGet, "/api/profiles/alpha/logs?follow=true", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 before the first enable, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	if err := os.MkdirAll(profileComposeDir("alpha"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(profileComposeDir("alpha"), "compose.yaml"), []byte("services: {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rec = httptest.NewRecorder()
+	srv.handleProfileAction(rec, httptest.NewRequest(http.MethodGet, "/api/profiles/alpha/logs?service=kimmio_app&tail=50&follow=true", nil))
+	if rec.Code != http.StatusOK || !rec.Flushed || !strings.Contains(rec.Body.String(), "listening on :3000") {
+		t.Fatalf("expected streamed logs, got %d %s", rec.Code, rec.Body.String())
+	}
+	if gotService != "kimmio_app" || gotTail != 50 || !gotFollow {
+		t.Fatalf("unexpected compose logs call: service %q tail %d follow %t", gotService, gotTail, gotFollow)
+	}
+
+	var out, errOut bytes.Buffer
+	if code := runProfileLogs(srv, "alpha", []string{"postgres", "--tail", "10"}, &out, &errOut); code != 0 || gotService != "postgres" || gotTail != 10 || gotFollow {
+		t.Fatalf("unexpected CLI result %d (%s): service %q tail %d follow %t", code, errOut.String(), gotService, gotTail, gotFollow)
+	}
+	if code := runProfileLogs(srv, "alpha", []string{"nginx"}, &out, &errOut); code != exitValidation {
+		t.Fatalf("expected an unknown service to fail validation, got %d", code)
+	}
+}
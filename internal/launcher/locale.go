@@ -0,0 +1,77 @@
+package launcher
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	// Time zones are validated against the embedded database so hosts
+	// without zoneinfo, such as Windows, accept the same names as Linux.
+	_ "time/tzdata"
+)
+
+const (
+	defaultProfileTimeZone = "UTC"
+	defaultProfileLocale   = "C.UTF-8"
+)
+
+var localeRe = regexp.MustCompile(`^(C|POSIX|[a-z]{2,3}(_[A-Z]{2})?)(\.[A-Za-z0-9-]+)?(@[a-z]+)?$`)
+
+// normalizeTimeZone accepts an IANA zone name such as Europe/Berlin. Empty
+// keeps the containers on UTC.
+func normalizeTimeZone(v string) (string, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return "", nil
+	}
+	// "Local" would mean the launcher's zone, which the containers don't have.
+	if v == "Local" || strings.HasPrefix(v, "/") || strings.Contains(v, "..") {
+		return "", errors.New("time zone must be an IANA name like Europe/Berlin")
+	}
+	if _, err := time.LoadLocation(v); err != nil {
+		return "", errors.New("unknown time zone: " + v)
+	}
+	return v, nil
+}
+
+// normalizeLocale accepts a POSIX locale such as de_DE.UTF-8. The "utf8"
+// spelling is rewritten to UTF-8, which every image understands.
+func normalizeLocale(v string) (string, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return "", nil
+	}
+	if !localeRe.MatchString(v) {
+		return "", errors.New("locale must look like en_US.UTF-8 or C.UTF-8")
+	}
+	if name, enc, ok := strings.Cut(v, "."); ok && strings.EqualFold(strings.ReplaceAll(enc, "-", ""), "utf8") {
+		v = name + ".UTF-8"
+	}
+	return v, nil
+}
+
+func profileTimeZone(profile ProfileRequest) string {
+	if profile.TimeZone != "" {
+		return profile.TimeZone
+	}
+	return defaultProfileTimeZone
+}
+
+func profileLocale(profile ProfileRequest) string {
+	if profile.Locale != "" {
+		return profile.Locale
+	}
+	return defaultProfileLocale
+}
+
+// composeLocaleEnvironment renders the time zone and locale keys of a
+// service's environment block. Postgres gets no LANG: initdb refuses a
+// locale the image has not generated, and the database locale cannot
+// change after the first start anyway.
+func composeLocaleEnvironment(withLang bool) string {
+	out := "      TZ: ${TZ}\n"
+	if withLang {
+		out += "      LANG: ${LANG}\n"
+	}
+	return out
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,19 +19,40 @@ const (
 type structuredLogger struct {
 	mu        sync.Mutex
 	path      string
+	perm      os.FileMode
 	maxSize   int64
 	maxBackup int
 }
 
 var appLogger *structuredLogger
 
+// auditLogger keeps security events (sign-ins, lockouts) apart from the
+// main log, so they survive its rotation and can be handed over as is. It
+// also records profile status changes and update checks for the activity
+// feed.
+var auditLogger *structuredLogger
+
+// logLevels ranks the levels; records below logMinLevel are dropped.
+var logLevels = map[string]int32{"info": 0, "warn": 1, "error": 2}
+
+var logMinLevel atomic.Int32
+
+// setLogLevel changes the minimum level at runtime; unknown names are
+// ignored.
+func setLogLevel(name string) {
+	if rank, ok := logLevels[strings.ToLower(name)]; ok {
+		logMinLevel.Store(rank)
+	}
+}
+
 func initStructuredLogger(dataDir string) {
 	path := filepath.Join(dataDir, "logs", "launcher.log")
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		fmt.Fprintf(os.Stderr, "failed to create log dir: %v\n", err)
 		return
 	}
-	appLogger = &structuredLogger{path: path, maxSize: defaultLogMaxSizeBytes, maxBackup: defaultLogBackups}
+	appLogger = &structuredLogger{path: path, perm: 0o644, maxSize: defaultLogMaxSizeBytes, maxBackup: defaultLogBackups}
+	auditLogger = &structuredLogger{path: filepath.Join(filepath.Dir(path), "audit.log"), perm: 0o600, maxSize: defaultLogMaxSizeBytes, maxBackup: defaultLogBackups}
 }
 
 func logInfo(msg string, fields map[string]any) {
@@ -45,17 +68,36 @@ func logError(msg string, fields map[string]any) {
 }
 
 func writeStructuredLog(level, msg string, fields map[string]any) {
-	if appLogger == nil {
+	if appLogger == nil || logLevels[strings.ToLower(level)] < logMinLevel.Load() {
+		return
+	}
+	b, ok := marshalLogRecord(level, msg, fields)
+	if !ok {
 		return
 	}
 	appLogger.mu.Lock()
 	defer appLogger.mu.Unlock()
+	_, _ = os.Stdout.Write(append(b, '\n'))
+	appLogger.appendLocked(b)
+}
 
-	if err := appLogger.rotateIfNeeded(); err != nil {
-		fmt.Fprintf(os.Stderr, "log rotation failed: %v\n", err)
+// auditLog records an audit event in audit.log regardless of the log
+// level, and in the main log at the given level.
+func auditLog(level, event string, fields map[string]any) {
+	writeStructuredLog(level, event, fields)
+	if auditLogger == nil {
+		return
+	}
+	b, ok := marshalLogRecord(level, event, fields)
+	if !ok {
 		return
 	}
+	auditLogger.mu.Lock()
+	defer auditLogger.mu.Unlock()
+	auditLogger.appendLocked(b)
+}
 
+func marshalLogRecord(level, msg string, fields map[string]any) ([]byte, bool) {
 	record := map[string]any{
 		"ts":    time.Now().UTC().Format(time.RFC3339),
 		"level": level,
@@ -67,10 +109,17 @@ func writeStructuredLog(level, msg string, fields map[string]any) {
 	b, err := json.Marshal(record)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "log marshal failed: %v\n", err)
+		return nil, false
+	}
+	return b, true
+}
+
+func (l *structuredLogger) appendLocked(b []byte) {
+	if err := l.rotateIfNeeded(); err != nil {
+		fmt.Fprintf(os.Stderr, "log rotation failed: %v\n", err)
 		return
 	}
-	_, _ = os.Stdout.Write(append(b, '\n'))
-	f, err := os.OpenFile(appLogger.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, l.perm)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "open log file failed: %v\n", err)
 		return
@@ -0,0 +1,111 @@
+package launcher
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Failed sign-ins are counted per client address and per username. After
+// loginFreeAttempts failures a key is locked, first for loginBaseLockout
+// and twice as long for every further failure, up to loginMaxLockout.
+// Counts are forgotten once a key has had no failure for loginFailureTTL.
+// While a key is locked the password is not checked at all, so guesses
+// made during a lockout cannot succeed. A sign-in is counted as failed
+// before its password is checked and cleared when it succeeds, so guesses
+// sent in parallel cannot all pass the check while the slow hash runs.
+
+const (
+	loginFreeAttempts = 5
+	loginBaseLockout  = time.Minute
+	loginMaxLockout   = time.Hour
+	loginFailureTTL   = 24 * time.Hour
+	maxLoginKeys      = 10000
+)
+
+type loginFailures struct {
+	count       int
+	last        time.Time
+	lockedUntil time.Time
+}
+
+type loginLimiter struct {
+	mu   sync.Mutex
+	keys map[string]*loginFailures
+}
+
+func newLoginLimiter() *loginLimiter {
+	return &loginLimiter{keys: map[string]*loginFailures{}}
+}
+
+func loginKeys(ip, username string) []string {
+	return []string{"ip:" + ip, "user:" + strings.ToLower(strings.TrimSpace(username))}
+}
+
+// reserve starts a sign-in. While the client or the account is locked it
+// returns the wait and counts nothing. Otherwise it counts the attempt as
+// failed until succeed clears it, and returns the failure count of the
+// account and the lockout it started, if any.
+func (l *loginLimiter) reserve(ip, username string, now time.Time) (wait time.Duration, count int, lockout time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range loginKeys(ip, username) {
+		if f := l.keys[key]; f != nil && f.lockedUntil.After(now) {
+			wait = max(wait, f.lockedUntil.Sub(now))
+		}
+	}
+	if wait > 0 {
+		return wait, 0, 0
+	}
+	if len(l.keys) >= maxLoginKeys {
+		l.pruneLocked(now)
+	}
+	for _, key := range loginKeys(ip, username) {
+		f := l.keys[key]
+		if f == nil || now.Sub(f.last) > loginFailureTTL {
+			f = &loginFailures{}
+			l.keys[key] = f
+		}
+		f.count++
+		f.last = now
+		if f.count >= loginFreeAttempts {
+			d := loginMaxLockout
+			if shift := f.count - loginFreeAttempts; shift < 8 {
+				d = min(loginBaseLockout<<shift, loginMaxLockout)
+			}
+			f.lockedUntil = now.Add(d)
+			lockout = max(lockout, d)
+		}
+		count = f.count
+	}
+	return 0, count, lockout
+}
+
+// succeed clears the counts of both keys.
+func (l *loginLimiter) succeed(ip, username string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range loginKeys(ip, username) {
+		delete(l.keys, key)
+	}
+}
+
+func (l *loginLimiter) pruneLocked(now time.Time) {
+	for key, f := range l.keys {
+		if now.Sub(f.last) > loginFailureTTL || (f.count < loginFreeAttempts && now.Sub(f.last) > loginMaxLockout) {
+			delete(l.keys, key)
+		}
+	}
+}
+
+// clientIP is the address that sent the request. Forwarding headers are
+// ignored: they are set by the client and would let it pick its own key.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
+	if err != nil {
+		return strings.TrimSpace(r.RemoteAddr)
+	}
+	return host
+}
@@ -0,0 +1,121 @@
+package launcher
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoginLimiterLocksProgressively(t *testing.T) {
+	l := newLoginLimiter()
+	now := time.Now()
+	for i := 1; i < loginFreeAttempts; i++ {
+		if _, _, lockout := l.reserve("192.0.2.1", "root", now); lockout != 0 {
+			t.Fatalf("expected no lockout after %d failures, got %s", i, lockout)
+		}
+	}
+	if _, _, lockout := l.reserve("192.0.2.1", "root", now); lockout != loginBaseLockout {
+		t.Fatalf("expected the first lockout to last %s, got %s", loginBaseLockout, lockout)
+	}
+	if wait, _, _ := l.reserve("192.0.2.1", "other", now); wait != loginBaseLockout {
+		t.Fatalf("expected the address to be locked for any account, got %s", wait)
+	}
+	if wait, _, _ := l.reserve("198.51.100.7", "root", now); wait != loginBaseLockout {
+		t.Fatalf("expected the account to be locked from any address, got %s", wait)
+	}
+	at := now.Add(time.Minute)
+	if _, _, lockout := l.reserve("192.0.2.1", "root", at); lockout != 2*loginBaseLockout {
+		t.Fatalf("expected the lockout to double, got %s", lockout)
+	}
+	for i := 0; i < 20; i++ {
+		at = at.Add(loginMaxLockout)
+		l.reserve("192.0.2.1", "root", at)
+	}
+	if wait, _, _ := l.reserve("192.0.2.1", "root", at); wait != loginMaxLockout {
+		t.Fatalf("expected the lockout to be capped at %s, got %s", loginMaxLockout, wait)
+	}
+
+	l.succeed("192.0.2.1", "root")
+	if wait, _, _ := l.reserve("192.0.2.1", "root", at); wait != 0 {
+		t.Fatalf("expected a successful sign-in to clear the counts, got %s", wait)
+	}
+	if _, attempts, _ := l.reserve("192.0.2.1", "root", at.Add(loginFailureTTL+time.Minute)); attempts != 1 {
+		t.Fatalf("expected old failures to be forgotten, got %d", attempts)
+	}
+}
+
+func TestLoginLimiterReservesParallelAttempts(t *testing.T) {
+	l := newLoginLimiter()
+	now := time.Now()
+	var wg sync.WaitGroup
+	var checked atomic.Int32
+	for i := 0; i < 4*loginFreeAttempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if wait, _, _ := l.reserve("192.0.2.1", "root", now); wait == 0 {
+				checked.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := checked.Load(); got != loginFreeAttempts {
+		t.Fatalf("expected %d parallel attempts to get a password check, got %d", loginFreeAttempts, got)
+	}
+}
+
+func TestLoginLockoutRefusesCorrectPasswordAndAudits(t *testing.T) {
+	lowerPasswordCost(t)
+	srv := newServiceTestServer(t)
+	if _, err := srv.accounts.put("root", roleAdmin, "long-enough-pw"); err != nil {
+		t.Fatal(err)
+	}
+	prevAudit := auditLogger
+	auditLogger = &structuredLogger{path: filepath.Join(t.TempDir(), "audit.log"), perm: 0o600, maxSize: defaultLogMaxSizeBytes, maxBackup: 1}
+	defer func() { auditLogger = prevAudit }()
+
+	login := func(password string) *httptest.ResponseRecorder {
+		form := url.Values{"username": {"root"}, "password": {password}}
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.RemoteAddr = "192.0.2.1:4000"
+		rec := httptest.NewRecorder()
+		srv.handleLogin(&Templates{})(rec, req)
+		return rec
+	}
+	for i := 0; i < loginFreeAttempts; i++ {
+		login("wrong-password")
+	}
+	rec := login("long-enough-pw")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected a locked account to get 429 with Retry-After, got %d", rec.Code)
+	}
+
+	raw, err := os.ReadFile(auditLogger.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := map[string]int{}
+	for _, line := range strings.Split(strings.TrimSpace(string(raw)), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid audit line %q: %v", line, err)
+		}
+		events[record["msg"].(string)]++
+	}
+	if events["login_failed"] != loginFreeAttempts || events["login_locked"] != 1 || events["login_blocked"] != 1 {
+		t.Fatalf("expected failures, a lockout and a blocked attempt in the audit log, got %v", events)
+	}
+	if info, err := os.Stat(auditLogger.path); err == nil && runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		t.Fatalf("expected the audit log to be owner-only, got %v", info.Mode().Perm())
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
-	"encoding/json"
 	"fmt"
 	"io/fs"
 	"net"
@@ -27,6 +26,48 @@ type Server struct {
 	jobs           map[string]*ActionJob
 	activeProfiles map[string]string
 	jobCancels     map[string]context.CancelFunc
+	// workflows and workflowCancels are guarded by jobMu.
+	workflows       map[string]*Workflow
+	workflowCancels map[string]context.CancelFunc
+	// integrityIssues is filled once at startup before the server accepts requests.
+	integrityIssues []IntegrityIssue
+	health          *healthCache
+	// storeCache is guarded by mu.
+	storeCache storeCache
+	events     *eventHub
+	// jobEvents carries job progress and output to /api/jobs/<id>/stream.
+	jobEvents *eventHub
+	notices   *noticeBoard
+	changelog *changelogTracker
+	// confirmations backs the confirmation tokens for destructive actions.
+	confirmations *confirmTokens
+	// httpMetrics counts requests per route for /api/system/metrics.
+	httpMetrics *httpMetrics
+	// settings are the options that can change without a restart.
+	settings *settingsStore
+	// wake holds the listeners of stopped wake-on-request profiles.
+	wake *wakeListeners
+	// usage holds the per-profile resource usage history.
+	usage *usageHistory
+	// uptime holds the per-profile health check history.
+	uptime *uptimeTracker
+	// fleet lists the remote launchers this one can manage.
+	fleet *fleetStore
+	// accounts are the users who may sign in from other machines.
+	accounts *accountStore
+	// logins counts failed sign-ins to lock out password guessing.
+	logins *loginLimiter
+	// tokens are the API tokens scripts and other launchers send.
+	tokens *tokenStore
+	// summaries tracks the weekly summary.
+	summaries *summaryReporter
+	// schedules records when profile schedules last ran.
+	schedules *scheduleStore
+	// pages renders the HTML pages; the create form is re-rendered with it
+	// when a form post fails validation.
+	pages *Templates
+	// compose runs the compose stacks of profiles.
+	compose composeRunner
 }
 
 var appCfg = config.Load("dev")
@@ -45,22 +86,55 @@ func SetBuildInfo(version, commit string) {
 }
 
 func NewServer(cfg config.Config) *Server {
-	return &Server{
-		dbPath:         filepath.Join(cfg.DataDir, "profiles.json"),
-		jobs:           map[string]*ActionJob{},
-		activeProfiles: map[string]string{},
-		jobCancels:     map[string]context.CancelFunc{},
-	}
+	s := &Server{
+		dbPath:          filepath.Join(cfg.DataDir, "profiles.json"),
+		jobs:            map[string]*ActionJob{},
+		activeProfiles:  map[string]string{},
+		jobCancels:      map[string]context.CancelFunc{},
+		workflows:       map[string]*Workflow{},
+		workflowCancels: map[string]context.CancelFunc{},
+		integrityIssues: []IntegrityIssue{},
+		health:          newHealthCache(),
+		events:          newEventHub(),
+		jobEvents:       newEventHub(),
+		notices:         newNoticeBoard(cfg.DataDir),
+		changelog:       newChangelogTracker(cfg.DataDir, launcherAppVersion),
+		confirmations:   newConfirmTokens(),
+		httpMetrics:     newHTTPMetrics(),
+		settings:        newSettingsStore(cfg.DataDir),
+		wake:            newWakeListeners(),
+		usage:           newUsageHistory(cfg.DataDir),
+		uptime:          newUptimeTracker(cfg.DataDir),
+		fleet:           newFleetStore(cfg.DataDir),
+		accounts:        newAccountStore(cfg.DataDir),
+		logins:          newLoginLimiter(),
+		tokens:          newTokenStore(cfg.DataDir),
+		summaries:       newSummaryReporter(cfg.DataDir),
+		schedules:       newScheduleStore(cfg.DataDir),
+	}
+	s.compose = dockerCompose{srv: s}
+	return s
 }
 
-func Run(embedded fs.FS, cfg config.Config) error {
+func Run(embedded fs.FS, cfg config.Config, opts RunOptions) error {
 	appCfg = cfg
-	initStructuredLogger(cfg.DataDir)
+	launcherStartedAt = time.Now().UTC()
 	preferredPort := normalizeListenPort(cfg.ListenPort)
+	if opts.PrintPortAndExit {
+		// No logger yet: stdout carries the port alone.
+		port := preferredPort
+		if !shouldReuseExistingLauncher(preferredPort) {
+			port = resolveListenPort(preferredPort, cfg.PortSearchRange)
+		}
+		fmt.Println(port)
+		return nil
+	}
+	initStructuredLogger(cfg.DataDir)
 	if shouldReuseExistingLauncher(preferredPort) {
 		launcherURL := fmt.Sprintf("http://localhost:%d", preferredPort)
-		writeLauncherPortFile(preferredPort)
+		writeLauncherPortFile(preferredPort, opts.PortFile)
 		printStartupBanner(launcherURL)
+		writeStartupStatus(os.Stdout, "reused", preferredPort, nil)
 		logInfo("server_reuse_existing_instance", map[string]any{
 			"port": preferredPort,
 			"url":  launcherURL,
@@ -68,45 +142,121 @@ func Run(embedded fs.FS, cfg config.Config) error {
 		openBrowser(preferredPort)
 		return nil
 	}
+	integrityIssues := checkDataDirIntegrity(cfg.DataDir)
+	logIntegrityIssues(integrityIssues)
 	port := resolveListenPort(preferredPort, cfg.PortSearchRange)
-	writeLauncherPortFile(port)
 
 	ts, err := NewTemplatesFromFS(embedded, "templates")
 	if err != nil {
 		return fmt.Errorf("templates: %w", err)
 	}
 
+	logConfigWarnings()
 	srv := NewServer(cfg)
+	srv.integrityIssues = integrityIssues
+	srv.pages = ts
+	srv.startHealthMonitor(context.Background())
+	srv.startStoreWatcher(context.Background(), storeWatchInterval)
+	srv.startUpdateChecker(context.Background(), updateCheckInterval)
+	srv.startTrashPurger(context.Background(), trashPurgeInterval)
+	srv.startScheduler(context.Background(), schedulerInterval)
+	srv.startIdleMonitor(context.Background(), idleCheckInterval)
+	srv.startWakeListeners(context.Background(), wakeCheckInterval)
+	srv.startUsageSampler(context.Background(), usageSampleInterval)
+	srv.startSummaryReporter(context.Background(), summaryCheckInterval)
+	srv.startReconciler(context.Background(), reconcileInterval)
+	if cfg.GRPCPort > 0 {
+		if err := srv.startGRPCServer(cfg.GRPCPort); err != nil {
+			logError("grpc_server_start_failed", map[string]any{"port": cfg.GRPCPort, "error": err.Error()})
+		}
+	}
+
+	mux, err := newServeMux(srv, embedded)
+	if err != nil {
+		return err
+	}
 
+	// Listen before announcing the port, so whoever reads it can connect.
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		writeStartupStatus(os.Stdout, "failed", port, err)
+		return err
+	}
+	writeLauncherPortFile(port, opts.PortFile)
+	launcherURL := fmt.Sprintf("http://localhost:%d", port)
+	printStartupBanner(launcherURL)
+	writeStartupStatus(os.Stdout, "ready", port, nil)
+
+	if cfg.BuildMode == "prod" && srv.settings.get().OpenBrowser {
+		go openBrowserWhenReachable(port, 12*time.Second)
+	}
+	logInfo("server_start", map[string]any{
+		"port":           port,
+		"url":            launcherURL,
+		"data_dir":       cfg.DataDir,
+		"build_mode":     cfg.BuildMode,
+		"app_version":    launcherAppVersion,
+		"build_commit":   launcherGitCommit,
+		"runtime_goos":   runtime.GOOS,
+		"runtime_goarch": runtime.GOARCH,
+	})
+	return newHTTPServer(port, srv.httpHandler(mux)).Serve(ln)
+}
+
+// newServeMux registers the pages, rendered with srv.pages, and the API.
+func newServeMux(srv *Server, embedded fs.FS) (*http.ServeMux, error) {
+	ts := srv.pages
 	staticFS, err := fs.Sub(embedded, "static")
 	if err != nil {
-		return fmt.Errorf("static fs: %w", err)
+		return nil, fmt.Errorf("static fs: %w", err)
 	}
 
 	mux := http.NewServeMux()
 	mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.FS(staticFS))))
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		csrfToken := ensureCSRFCookie(w, r)
-		store := ProfileStore{Profiles: []ProfileRequest{}}
-		b, err := os.ReadFile(srv.dbPath)
-		if err == nil && len(strings.TrimSpace(string(b))) > 0 {
-			_ = json.Unmarshal(b, &store)
+		profiles, err := srv.profilesWithStatus(r.Context())
+		if err != nil {
+			profiles = []ProfileRequest{}
 		}
-		store.Profiles = applyHealthStatus(store.Profiles)
-		if err := ts.RenderPageWithTemplate(w, "profiles.html", map[string]any{
-			"DockerRunning": IsDockerRunning(),
-			"Profiles":      srv.attachActiveJobs(store.Profiles),
-			"ProfileCount":  len(store.Profiles),
-			"MaxProfiles":   appCfg.MaxProfiles,
-			"CSRFToken":     csrfToken,
-		}); err != nil {
+		kept, trashed := splitTrashedProfiles(profiles)
+		active, archived := splitArchivedProfiles(kept)
+		query, err := parseProfileQuery(r.URL.Query(), defaultProfilePageSize)
+		if err != nil {
+			query = profileQuery{Page: 1, PageSize: defaultProfilePageSize}
+		}
+		active, total := query.apply(active)
+		pages := query.pages(total)
+		if err := ts.RenderPageWithTemplate(w, "profiles.html", srv.pageData(r, map[string]any{
+			"DockerRunning":  IsDockerRunning(),
+			"Profiles":       active,
+			"Query":          query,
+			"StatusFilter":   strings.Join(query.Status, ","),
+			"Filtered":       len(query.Status) > 0 || query.Label != "" || query.Search != "",
+			"Total":          total,
+			"Page":           query.Page,
+			"Pages":          pages,
+			"PrevURL":        pageLink(query, query.Page-1, pages),
+			"NextURL":        pageLink(query, query.Page+1, pages),
+			"Archived":       archived,
+			"Trash":          trashView(trashed),
+			"TrashDays":      appCfg.TrashRetentionDays,
+			"ProfileCount":   len(kept),
+			"MaxProfiles":    maxProfilesLimit(),
+			"Remaining":      max(maxProfilesLimit()-len(kept), 0),
+			"Summary":        srv.buildDashboard(profiles),
+			"PendingSheets":  pendingConnectionSheets(r, kept),
+			"Activity":       buildActivity(profiles, readAuditRecords(), activityQuery{Limit: 10}, activityAuthVisible(r)),
+			"CSRFToken":      csrfToken,
+			"SystemWarnings": integrityWarnings(srv.integrityIssues),
+			"WhatsNew":       srv.changelog.pendingVersion(),
+		})); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
 	})
 
 	mux.HandleFunc("/profiles/new", func(w http.ResponseWriter, r *http.Request) {
-		csrfToken := ensureCSRFCookie(w, r)
-		store, err := loadProfileStore(srv.dbPath)
+		store, err := srv.readStore(r.Context())
 		if err != nil {
 			http.Error(w, "Failed to load profiles: "+err.Error(), http.StatusInternalServerError)
 			return
@@ -114,49 +264,145 @@ func Run(embedded fs.FS, cfg config.Config) error {
 		profile := defaultProfile()
 		profile.ID = nextAvailableProfileID(store)
 		profile.Ports[0].Host = nextAvailablePort(store)
-		if err := ts.RenderPageWithTemplate(w, "profile-create.html", map[string]any{
+		if err := ts.RenderPageWithTemplate(w, "profile-create.html", srv.createPageData(w, r, store, profile)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	mux.HandleFunc("/whats-new", func(w http.ResponseWriter, r *http.Request) {
+		csrfToken := ensureCSRFCookie(w, r)
+		version := srv.changelog.pendingVersion()
+		if version == "" {
+			version = strings.TrimSpace(launcherAppVersion)
+		}
+		data := map[string]any{
 			"DockerRunning": IsDockerRunning(),
-			"Profile":       profile,
-			"HostPort":      profile.Ports[0].Host,
-			"IsEdit":        false,
-			"ProfileCount":  len(store.Profiles),
-			"MaxProfiles":   appCfg.MaxProfiles,
-			"MaxReached":    len(store.Profiles) >= appCfg.MaxProfiles,
+			"Version":       version,
+			"Pending":       srv.changelog.pendingVersion() != "",
 			"CSRFToken":     csrfToken,
-		}); err != nil {
+		}
+		if release, err := fetchLauncherReleaseByVersion(version); err != nil {
+			logWarn("launcher_release_notes_failed", map[string]any{"version": version, "error": err.Error()})
+		} else {
+			data["ReleaseName"] = release.Name
+			data["ReleaseNotes"] = strings.TrimSpace(release.Body)
+			data["ReleaseURL"] = release.HTMLURL
+		}
+		if err := ts.RenderPageWithTemplate(w, "whats-new.html", srv.pageData(r, data)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	mux.HandleFunc("/settings", func(w http.ResponseWriter, r *http.Request) {
+		csrfToken := ensureCSRFCookie(w, r)
+		capacity, err := srv.profileCapacity(r.Context())
+		if err != nil {
+			http.Error(w, "Failed to load profiles: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := ts.RenderPageWithTemplate(w, "settings.html", srv.pageData(r, map[string]any{
+			"Capacity":               capacity,
+			"DockerRunning":          IsDockerRunning(),
+			"Settings":               srv.settings.get(),
+			"SummarySMTPPasswordSet": loadSummarySMTPPassword(appCfg.DataDir) != "",
+			"DockerHost":             effectiveDockerHost(),
+			"EnvMaxProfiles":         appCfg.MaxProfiles,
+			"EnvPortMin":             appCfg.ProfilePortMin,
+			"EnvPortMax":             appCfg.ProfilePortMax,
+			"Accounts":               srv.accounts.list(),
+			"APITokens":              srv.tokens.list(),
+			"CSRFToken":              csrfToken,
+		})); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	mux.HandleFunc("/fleet", func(w http.ResponseWriter, r *http.Request) {
+		csrfToken := ensureCSRFCookie(w, r)
+		if err := ts.RenderPageWithTemplate(w, "fleet.html", srv.pageData(r, map[string]any{
+			"DockerRunning": IsDockerRunning(),
+			"Launchers":     srv.fleet.list(),
+			"CSRFToken":     csrfToken,
+		})); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
 	})
 
 	mux.HandleFunc("/profiles/edit", func(w http.ResponseWriter, r *http.Request) {
-		http.Error(w, "Profile updates are disabled", http.StatusForbidden)
+		store, err := srv.readStore(r.Context())
+		if err != nil {
+			http.Error(w, "Failed to load profiles: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		idx := findProfileIndex(store, normalizeProfileID(r.URL.Query().Get("id")))
+		if idx < 0 || store.Profiles[idx].DeletedAt != "" {
+			http.NotFound(w, r)
+			return
+		}
+		profile := store.Profiles[idx]
+		if len(profile.Ports) == 0 {
+			profile.Ports = []PortMapping{{Container: 3000}}
+		}
+		data := srv.createPageData(w, r, store, profile)
+		data["IsEdit"] = true
+		if err := ts.RenderPageWithTemplate(w, "profile-create.html", data); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
 	})
 
-	mux.HandleFunc("/api/profiles", withMutationGuard(srv.handleCreateProfile))
-	mux.HandleFunc("/api/profiles/", withMutationGuard(srv.handleProfileAction))
-	mux.HandleFunc("/api/jobs/", withMutationGuard(srv.handleJobRoute))
+	mux.HandleFunc("/api/dashboard", srv.handleDashboard)
+	mux.HandleFunc("/api/activity", srv.handleActivity)
+	mux.HandleFunc("/api/export/", srv.handleExport)
+	mux.HandleFunc("/api/summary", srv.handleSummary)
+	mux.HandleFunc("/api/summary/", srv.handleSummary)
+	mux.HandleFunc("/api/profiles", srv.handleProfiles)
+	mux.HandleFunc("/api/profiles/", srv.handleProfileAction)
+	mux.HandleFunc("/api/jobs/", srv.handleJobRoute)
+	mux.HandleFunc("/api/trash", srv.handleTrash)
+	mux.HandleFunc("/api/workflows", srv.handleWorkflows)
+	mux.HandleFunc("/api/workflows/", srv.handleWorkflowRoute)
 	mux.HandleFunc("/api/kimmio/versions", srv.handleKimmioVersions)
 	mux.HandleFunc("/api/launcher/update", srv.handleLauncherUpdate)
-	mux.HandleFunc("/api/server/stop", withMutationGuard(handleServerStop))
+	mux.HandleFunc("/api/notifications", srv.handleNotifications)
+	mux.HandleFunc("/api/notifications/", srv.handleNotificationRoute)
+	mux.HandleFunc("/api/launcher/whats-new/seen", srv.handleWhatsNewSeen)
+	mux.HandleFunc("/api/launcher/about", srv.handleLauncherAbout)
+	mux.HandleFunc("/api/system/health", srv.handleSystemHealth)
+	mux.HandleFunc("/api/system/info", srv.handleSystemInfo)
+	mux.HandleFunc("/api/system/metrics", srv.handleHTTPMetrics)
+	mux.HandleFunc("/api/maintenance", srv.handleMaintenance)
+	mux.HandleFunc("/api/schedules", srv.handleSchedules)
+	mux.HandleFunc("/api/settings", srv.handleSettings)
+	mux.HandleFunc("/api/fleet", srv.handleFleet)
+	mux.HandleFunc("/api/fleet/", srv.handleFleetRoute)
+	mux.HandleFunc("/api/users", srv.handleUsers)
+	mux.HandleFunc("/api/users/", srv.handleUsers)
+	mux.HandleFunc("/api/tokens", srv.handleAPITokens)
+	mux.HandleFunc("/api/tokens/", srv.handleAPITokens)
+	mux.HandleFunc(deployHookPrefix, srv.handleDeployHook)
+	mux.HandleFunc("/login", srv.handleLogin(ts))
+	mux.HandleFunc("/logout", srv.handleLogout)
+	mux.HandleFunc("/api/env-schema", srv.handleEnvSchema)
+	mux.HandleFunc("/api/server/stop", handleServerStop)
+	mux.HandleFunc("/api/ws", srv.handleWebSocket)
+	mux.HandleFunc("/api/events", srv.handleEvents)
 	mux.HandleFunc("/__livereload", liveReloadHandler)
+	return mux, nil
+}
 
-	launcherURL := fmt.Sprintf("http://localhost:%d", port)
-	printStartupBanner(launcherURL)
-
-	if cfg.BuildMode == "prod" {
-		go openBrowserWhenReachable(port, 12*time.Second)
+// newHTTPServer bounds how long a client may take to send a request and
+// how much header it may send, so a slow or malicious client cannot hold
+// connections open. Streaming endpoints lift the write deadline themselves.
+func newHTTPServer(port int, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      60 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+		MaxHeaderBytes:    64 << 10,
 	}
-	logInfo("server_start", map[string]any{
-		"port":           port,
-		"url":            launcherURL,
-		"data_dir":       cfg.DataDir,
-		"build_mode":     cfg.BuildMode,
-		"app_version":    launcherAppVersion,
-		"build_commit":   launcherGitCommit,
-		"runtime_goos":   runtime.GOOS,
-		"runtime_goarch": runtime.GOARCH,
-	})
-	return http.ListenAndServe(fmt.Sprintf(":%d", port), mux)
 }
 
 func printStartupBanner(url string) {
@@ -217,17 +463,19 @@ func handleServerStop(w http.ResponseWriter, r *http.Request) {
 	}()
 }
 
-func writeLauncherPortFile(currentPort int) {
+// writeLauncherPortFile writes the port to launcher-port in the data
+// directory and to extra, the --port-file path, if set.
+func writeLauncherPortFile(currentPort int, extra string) {
 	if currentPort <= 0 {
 		return
 	}
-	if err := os.MkdirAll(appCfg.DataDir, 0o755); err != nil {
-		logError("runtime_data_dir_create_failed", map[string]any{"error": err.Error(), "data_dir": appCfg.DataDir})
-		return
-	}
-	portFile := filepath.Join(appCfg.DataDir, "launcher-port")
-	if err := os.WriteFile(portFile, []byte(strconv.Itoa(currentPort)+"\n"), 0o644); err != nil {
-		logError("launcher_port_write_failed", map[string]any{"error": err.Error(), "port_file": portFile})
+	for _, portFile := range []string{filepath.Join(appCfg.DataDir, "launcher-port"), extra} {
+		if portFile == "" {
+			continue
+		}
+		if err := writePortFile(portFile, currentPort); err != nil {
+			logError("launcher_port_write_failed", map[string]any{"error": err.Error(), "port_file": portFile})
+		}
 	}
 }
 
@@ -280,11 +528,12 @@ func isLauncherLiveReloadReachable(port int) bool {
 }
 
 func defaultProfile() ProfileRequest {
+	portMin, _ := profilePortRange()
 	profile := ProfileRequest{
 		ID:      "kimmio-default",
 		Version: "latest",
 		Ports: []PortMapping{
-			{Container: 3000, Host: appCfg.ProfilePortMin},
+			{Container: 3000, Host: portMin},
 		},
 		Env: map[string]string{
 			"APP_DOMAIN": "localhost",
@@ -304,12 +553,14 @@ func nextAvailablePort(store ProfileStore) int {
 			used[profile.Ports[0].Host] = true
 		}
 	}
-	for p := appCfg.ProfilePortMin; p < appCfg.ProfilePortMax; p++ {
-		if !used[p] && isTCPPortAvailable(p) {
+	reserved := reservedPorts()
+	portMin, portMax := profilePortRange()
+	for p := portMin; p < portMax; p++ {
+		if !used[p] && !avoidForAutoAssign(p, reserved) && isTCPPortAvailable(p) {
 			return p
 		}
 	}
-	return appCfg.ProfilePortMin
+	return portMin
 }
 
 func nextAvailableProfileID(store ProfileStore) string {
@@ -382,20 +633,28 @@ func normalizeEncryptionKeyValue(v string) (string, bool) {
 	return "", false
 }
 
-func applyHealthStatus(profiles []ProfileRequest) []ProfileRequest {
+func applyHealthStatus(ctx context.Context, profiles []ProfileRequest) []ProfileRequest {
 	updated := make([]ProfileRequest, len(profiles))
 	copy(updated, profiles)
 	for i := range updated {
 		profile := &updated[i]
 		profile.Running = false
 		profile.RuntimeStatus = "stopped"
+		if profile.DeletedAt != "" {
+			profile.RuntimeStatus = "deleted"
+			continue
+		}
+		if profile.Archived {
+			profile.RuntimeStatus = "archived"
+			continue
+		}
 
 		if !profile.Enabled {
 			continue
 		}
 
 		if isWithinStartingWindow(profile.StartingUntil) {
-			if retryProfileHealth(*profile, 2, 400*time.Millisecond) {
+			if retryProfileHealth(ctx, *profile, 2, 400*time.Millisecond) {
 				profile.Running = true
 				profile.RuntimeStatus = "running"
 			} else {
@@ -404,12 +663,13 @@ func applyHealthStatus(profiles []ProfileRequest) []ProfileRequest {
 			continue
 		}
 
-		if retryProfileHealth(*profile, 4, 500*time.Millisecond) {
+		if retryProfileHealth(ctx, *profile, 4, 500*time.Millisecond) {
 			profile.Running = true
 			profile.RuntimeStatus = "running"
 		} else {
 			profile.RuntimeStatus = "unhealthy"
 		}
+		applyServiceStates(ctx, profile)
 	}
 	return updated
 }
@@ -436,31 +696,14 @@ func isWithinStartingWindow(v string) bool {
 	return time.Now().UTC().Before(t)
 }
 
-func retryProfileHealth(profile ProfileRequest, attempts int, sleep time.Duration) bool {
+func retryProfileHealth(ctx context.Context, profile ProfileRequest, attempts int, sleep time.Duration) bool {
 	for i := 0; i < attempts; i++ {
-		if isProfileHealthy(profile) {
+		if isProfileHealthy(ctx, profile) {
 			return true
 		}
-		time.Sleep(sleep)
+		if sleepContext(ctx, sleep) != nil {
+			return false
+		}
 	}
 	return false
 }
-
-func isProfileHealthy(profile ProfileRequest) bool {
-	hostPort := 0
-	if len(profile.Ports) > 0 {
-		hostPort = profile.Ports[0].Host
-	}
-	if hostPort <= 0 {
-		return false
-	}
-
-	client := http.Client{Timeout: 2 * time.Second}
-	url := "http://localhost:" + strconv.Itoa(hostPort) + "/health"
-	resp, err := client.Get(url)
-	if err != nil {
-		return false
-	}
-	defer resp.Body.Close()
-	return resp.StatusCode >= 200 && resp.StatusCode < 300
-}
@@ -1,6 +1,7 @@
 package launcher
 
 import (
+	"context"
 	"launcher/internal/config"
 	"net"
 	"net/http"
@@ -47,7 +48,7 @@ func TestApplyHealthStatusStarting(t *testing.T) {
 		},
 	}
 
-	got := applyHealthStatus(profiles)
+	got := applyHealthStatus(context.Background(), profiles)
 	if len(got) != 1 {
 		t.Fatalf("expected 1 profile, got %d", len(got))
 	}
@@ -0,0 +1,222 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Scheduled restarts and the jobs a reconcile pass starts may only begin
+// inside a maintenance window. Windows are
+// written as "[days ]HH:MM-HH:MM" in the launcher's local time, for example
+// "sat,sun 02:00-05:00" or "mon-fri 22:00-02:00"; several windows are
+// separated by ";". An end before the start runs past midnight and belongs
+// to the day it starts on. An empty window places no restriction.
+
+type maintenanceSpan struct {
+	days  [7]bool
+	start int // minutes after midnight
+	end   int
+}
+
+type maintenanceWindow []maintenanceSpan
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+func parseMaintenanceWindow(raw string) (maintenanceWindow, error) {
+	var window maintenanceWindow
+	for _, part := range strings.Split(raw, ";") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		span, err := parseMaintenanceSpan(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maintenance window %q: %w", part, err)
+		}
+		window = append(window, span)
+	}
+	return window, nil
+}
+
+func parseMaintenanceSpan(part string) (maintenanceSpan, error) {
+	var span maintenanceSpan
+	fields := strings.Fields(part)
+	times := fields[len(fields)-1]
+	switch len(fields) {
+	case 1:
+		for i := range span.days {
+			span.days[i] = true
+		}
+	case 2:
+		if err := parseMaintenanceDays(fields[0], &span.days); err != nil {
+			return span, err
+		}
+	default:
+		return span, errors.New(`expected "[days ]HH:MM-HH:MM"`)
+	}
+	from, to, ok := strings.Cut(times, "-")
+	if !ok {
+		return span, errors.New("time range must look like HH:MM-HH:MM")
+	}
+	var err error
+	if span.start, err = parseClock(from); err != nil {
+		return span, err
+	}
+	if span.end, err = parseClock(to); err != nil {
+		return span, err
+	}
+	if span.start == span.end {
+		return span, errors.New("window start and end must differ")
+	}
+	return span, nil
+}
+
+func parseMaintenanceDays(v string, days *[7]bool) error {
+	for _, item := range strings.Split(v, ",") {
+		from, to, isRange := strings.Cut(strings.TrimSpace(item), "-")
+		first, ok := weekdayNames[from]
+		if !ok {
+			return fmt.Errorf("unknown day %q", from)
+		}
+		last := first
+		if isRange {
+			if last, ok = weekdayNames[to]; !ok {
+				return fmt.Errorf("unknown day %q", to)
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func parseClock(v string) (int, error) {
+	h, m, ok := strings.Cut(strings.TrimSpace(v), ":")
+	if !ok {
+		return 0, fmt.Errorf("time %q must be HH:MM", v)
+	}
+	hour, errH := strconv.Atoi(h)
+	minute, errM := strconv.Atoi(m)
+	if errH != nil || errM != nil || hour < 0 || hour > 24 || minute < 0 || minute > 59 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("time %q must be HH:MM", v)
+	}
+	return hour*60 + minute, nil
+}
+
+// contains reports whether t (in its own location) falls inside the window.
+// An empty window always contains t.
+func (w maintenanceWindow) contains(t time.Time) bool {
+	if len(w) == 0 {
+		return true
+	}
+	minute := t.Hour()*60 + t.Minute()
+	today := t.Weekday()
+	yesterday := (today + 6) % 7
+	for _, span := range w {
+		if span.start < span.end {
+			if span.days[today] && minute >= span.start && minute < span.end {
+				return true
+			}
+			continue
+		}
+		if (span.days[today] && minute >= span.start) || (span.days[yesterday] && minute < span.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// nextMaintenanceOpen returns the first minute at or after t where every
+// window is open, or the zero time when that does not happen within a week.
+func nextMaintenanceOpen(t time.Time, windows ...maintenanceWindow) time.Time {
+	t = t.Truncate(time.Minute)
+	for i := 0; i <= 7*24*60; i++ {
+		candidate := t.Add(time.Duration(i) * time.Minute)
+		open := true
+		for _, w := range windows {
+			if !w.contains(candidate) {
+				open = false
+				break
+			}
+		}
+		if open {
+			return candidate
+		}
+	}
+	return time.Time{}
+}
+
+func normalizeMaintenanceWindow(v string) (string, error) {
+	v = strings.TrimSpace(v)
+	if len(v) > 256 {
+		return "", errors.New("maintenance window must be at most 256 characters")
+	}
+	if _, err := parseMaintenanceWindow(v); err != nil {
+		return "", err
+	}
+	return v, nil
+}
+
+// maintenanceAllows reports whether an automatic operation may start for
+// the profile at t. Both the global and the profile's own window must be
+// open; manual actions are never restricted.
+func maintenanceAllows(profile ProfileRequest, t time.Time) bool {
+	global, err := parseMaintenanceWindow(appCfg.MaintenanceWindow)
+	if err != nil {
+		return false
+	}
+	own, err := parseMaintenanceWindow(profile.MaintenanceWindow)
+	if err != nil {
+		return false
+	}
+	return global.contains(t) && own.contains(t)
+}
+
+func (s *Server) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	store, err := s.readStore(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load profiles: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	now := time.Now()
+	global, _ := parseMaintenanceWindow(appCfg.MaintenanceWindow)
+	profiles := make([]map[string]any, 0, len(store.Profiles))
+	for _, p := range store.Profiles {
+		own, _ := parseMaintenanceWindow(p.MaintenanceWindow)
+		profiles = append(profiles, map[string]any{
+			"id":       p.ID,
+			"window":   p.MaintenanceWindow,
+			"open":     maintenanceAllows(p, now),
+			"nextOpen": formatMaintenanceTime(nextMaintenanceOpen(now, global, own)),
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"ok":             true,
+		"globalWindow":   appCfg.MaintenanceWindow,
+		"globalOpen":     global.contains(now),
+		"globalNextOpen": formatMaintenanceTime(nextMaintenanceOpen(now, global)),
+		"profiles":       profiles,
+	})
+}
+
+func formatMaintenanceTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,62 @@
+package launcher
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaintenanceWindowContains(t *testing.T) {
+	window, err := parseMaintenanceWindow("mon-fri 22:00-02:00; sat 10:00-12:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(day, hour, minute int) time.Time {
+		// 2024-01-01 was a Monday.
+		return time.Date(2024, 1, day, hour, minute, 0, 0, time.UTC)
+	}
+	cases := []struct {
+		t    time.Time
+		want bool
+	}{
+		{at(1, 23, 0), true},  // Monday night
+		{at(2, 1, 59), true},  // spills into Tuesday
+		{at(2, 2, 0), false},  // end is exclusive
+		{at(6, 1, 0), true},   // Friday window spills into Saturday
+		{at(6, 11, 0), true},  // Saturday window
+		{at(7, 1, 0), false},  // Saturday has no night window
+		{at(3, 12, 0), false}, // business hours
+	}
+	for _, tc := range cases {
+		if got := window.contains(tc.t); got != tc.want {
+			t.Fatalf("contains(%s) = %v, want %v", tc.t.Format(time.RFC3339), got, tc.want)
+		}
+	}
+	if next := nextMaintenanceOpen(at(3, 12, 0), window); !next.Equal(at(3, 22, 0)) {
+		t.Fatalf("expected next opening at 22:00, got %s", next)
+	}
+}
+
+func TestMaintenanceWindowValidation(t *testing.T) {
+	for _, raw := range []string{"02:00", "funday 02:00-03:00", "25:00-03:00", "02:00-02:00", "mon tue 02:00-03:00"} {
+		if _, err := normalizeMaintenanceWindow(raw); err == nil {
+			t.Fatalf("expected %q to be rejected", raw)
+		}
+	}
+	if _, err := normalizeMaintenanceWindow(""); err != nil {
+		t.Fatalf("empty window should be allowed: %v", err)
+	}
+}
+
+func TestMaintenanceAllowsRequiresBothWindows(t *testing.T) {
+	prev := appCfg.MaintenanceWindow
+	defer func() { appCfg.MaintenanceWindow = prev }()
+	appCfg.MaintenanceWindow = "01:00-05:00"
+	night := time.Date(2024, 1, 6, 3, 0, 0, 0, time.UTC) // Saturday
+
+	if !maintenanceAllows(ProfileRequest{}, night) {
+		t.Fatalf("expected global window to allow 03:00")
+	}
+	if maintenanceAllows(ProfileRequest{MaintenanceWindow: "sun 01:00-05:00"}, night) {
+		t.Fatalf("expected profile window to restrict Saturday")
+	}
+}
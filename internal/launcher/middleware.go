@@ -0,0 +1,301 @@
+package launcher
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// middleware wraps a handler. Protections live in the chain applied to the
+// whole mux, so a new route gets them without opting in.
+type middleware func(http.Handler) http.Handler
+
+// chain applies mws to h; the first middleware is the outermost.
+func chain(h http.Handler, mws ...middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+// httpHandler is the launcher's middleware stack around the mux. Metrics
+// sit outside recovery so a recovered panic is counted as a 500.
+func (s *Server) httpHandler(mux http.Handler) http.Handler {
+	return chain(mux,
+		withMetrics(s.httpMetrics),
+		withRecovery,
+		withRequestLogging,
+		withBodyLimit,
+		s.withAPIToken,
+		s.withAccounts,
+		withAuth,
+		withCSRF,
+	)
+}
+
+// statusRecorder captures the status of a response while keeping the
+// streaming (SSE) and hijacking (WebSocket) capabilities of the writer.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func recordResponse(w http.ResponseWriter) *statusRecorder {
+	if rec, ok := w.(*statusRecorder); ok {
+		return rec
+	}
+	return &statusRecorder{ResponseWriter: w}
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	// A hijacked connection answers with 101 itself.
+	r.status = http.StatusSwitchingProtocols
+	return hj.Hijack()
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter { return r.ResponseWriter }
+
+// statusCode is the status sent so far; handlers that never write one
+// answer 200.
+func (r *statusRecorder) statusCode() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
+
+func withRecovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := recordResponse(w)
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			logError("http_panic", map[string]any{
+				"method": r.Method,
+				"path":   r.URL.Path,
+				"panic":  fmt.Sprint(v),
+				"stack":  string(debug.Stack()),
+			})
+			if rec.status == 0 {
+				http.Error(rec, "Internal server error", http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// withRequestLogging logs mutations and server errors; reads are too
+// frequent (polling, static assets) to be worth a line each.
+func withRequestLogging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := recordResponse(w)
+		started := time.Now()
+		next.ServeHTTP(rec, r)
+		status := rec.statusCode()
+		if !requiresMutationGuard(r.Method) && status < 500 {
+			return
+		}
+		fields := map[string]any{
+			"method":      r.Method,
+			"path":        r.URL.Path,
+			"status":      status,
+			"duration_ms": time.Since(started).Milliseconds(),
+		}
+		switch {
+		case status >= 500:
+			logError("http_request", fields)
+		case status >= 400:
+			logWarn("http_request", fields)
+		default:
+			logInfo("http_request", fields)
+		}
+	})
+}
+
+// maxRequestBodyBytes bounds every request body. The largest legitimate
+// one is a profile create form, which stays far below it.
+const maxRequestBodyBytes = 1 << 20
+
+// withBodyLimit caps request bodies before anything reads them, including
+// the CSRF check's form parsing.
+func withBodyLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bodyErrorStatus answers 413 for bodies cut off by withBodyLimit and 400
+// for any other decoding failure.
+func bodyErrorStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// disableWriteDeadline lifts the server's WriteTimeout for streaming
+// responses that stay open for the life of the page.
+func disableWriteDeadline(w http.ResponseWriter) {
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+}
+
+// withAuth only lets local callers and signed-in admins change state.
+// Reads stay open to the same listener unless accounts exist. Requests
+// authenticated by an API token skip this and the CSRF check.
+func withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requiresMutationGuard(r.Method) && !isTokenRequest(r) {
+			if reason := validateMutationOrigin(r); reason != "" {
+				blockRequest(w, r, reason)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func withCSRF(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requiresMutationGuard(r.Method) && !isTokenRequest(r) {
+			if reason := validateCSRFToken(r); reason != "" {
+				blockRequest(w, r, reason)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func blockRequest(w http.ResponseWriter, r *http.Request, reason string) {
+	logWarn("request_blocked", map[string]any{"reason": reason, "path": r.URL.Path, "method": r.Method})
+	http.Error(w, reason, http.StatusForbidden)
+}
+
+// routeStats aggregates requests for one route.
+type routeStats struct {
+	Route        string `json:"route"`
+	Count        int64  `json:"count"`
+	ClientErrors int64  `json:"clientErrors"`
+	ServerErrors int64  `json:"serverErrors"`
+	TotalMs      int64  `json:"totalMs"`
+	MaxMs        int64  `json:"maxMs"`
+}
+
+type httpMetrics struct {
+	mu     sync.Mutex
+	routes map[string]*routeStats
+}
+
+func newHTTPMetrics() *httpMetrics {
+	return &httpMetrics{routes: map[string]*routeStats{}}
+}
+
+// metricsRoute groups paths so profile and job IDs do not create a series
+// each, e.g. /api/profiles/demo/enable counts as /api/profiles/*.
+func metricsRoute(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case parts[0] == "":
+		return "/"
+	case parts[0] == "api" && len(parts) > 2:
+		return "/api/" + parts[1] + "/*"
+	case parts[0] != "api" && len(parts) > 1:
+		return "/" + parts[0] + "/*"
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+func (m *httpMetrics) observe(method, path string, status int, elapsed time.Duration) {
+	key := method + " " + metricsRoute(path)
+	ms := elapsed.Milliseconds()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st, ok := m.routes[key]
+	if !ok {
+		st = &routeStats{Route: key}
+		m.routes[key] = st
+	}
+	st.Count++
+	st.TotalMs += ms
+	if ms > st.MaxMs {
+		st.MaxMs = ms
+	}
+	switch {
+	case status >= 500:
+		st.ServerErrors++
+	case status >= 400:
+		st.ClientErrors++
+	}
+}
+
+func (m *httpMetrics) snapshot() []routeStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]routeStats, 0, len(m.routes))
+	for _, st := range m.routes {
+		out = append(out, *st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Route < out[j].Route })
+	return out
+}
+
+func withMetrics(m *httpMetrics) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := recordResponse(w)
+			started := time.Now()
+			defer func() {
+				m.observe(r.Method, r.URL.Path, rec.statusCode(), time.Since(started))
+			}()
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
+
+func (s *Server) handleHTTPMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "routes": s.httpMetrics.snapshot()})
+}
@@ -0,0 +1,120 @@
+package launcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPHandlerRecoversPanics(t *testing.T) {
+	srv := &Server{httpMetrics: newHTTPMetrics()}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/boom", func(http.ResponseWriter, *http.Request) { panic("boom") })
+	h := srv.httpHandler(mux)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/boom", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rec.Code)
+	}
+	stats := srv.httpMetrics.snapshot()
+	if len(stats) != 1 || stats[0].Route != "GET /api/boom" || stats[0].ServerErrors != 1 {
+		t.Fatalf("expected the panic to be counted, got %+v", stats)
+	}
+}
+
+func TestHTTPHandlerGuardsEveryMutation(t *testing.T) {
+	srv := &Server{httpMetrics: newHTTPMetrics()}
+	mux := http.NewServeMux()
+	// A route registered without any explicit protection.
+	mux.HandleFunc("/api/new-endpoint", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+	})
+	h := srv.httpHandler(mux)
+
+	req := httptest.NewRequest(http.MethodPost, "http://localhost/api/new-endpoint", nil)
+	req.RemoteAddr = "127.0.0.1:5000"
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "csrf") {
+		t.Fatalf("expected missing CSRF to be rejected, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "http://localhost/api/new-endpoint", nil)
+	req.RemoteAddr = "10.0.0.5:5000"
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "local requests only") {
+		t.Fatalf("expected a remote mutation to be rejected, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "http://localhost/api/new-endpoint", nil)
+	req.RemoteAddr = "127.0.0.1:5000"
+	req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: "tok"})
+	req.Header.Set("X-CSRF-Token", "tok")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected a valid local mutation to pass, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestStatusRecorderKeepsFlusher(t *testing.T) {
+	srv := &Server{httpMetrics: newHTTPMetrics()}
+	flushed := false
+	h := srv.httpHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("expected the wrapped writer to implement http.Flusher")
+		}
+		f.Flush()
+		flushed = true
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/events", nil))
+	if !flushed || !rec.Flushed {
+		t.Fatal("expected the flush to reach the underlying writer")
+	}
+}
+
+func TestMetricsRoute(t *testing.T) {
+	for path, want := range map[string]string{
+		"/":                         "/",
+		"/api/profiles":             "/api/profiles",
+		"/api/profiles/demo/enable": "/api/profiles/*",
+		"/api/jobs/job-1":           "/api/jobs/*",
+		"/static/app.css":           "/static/*",
+		"/profiles/new":             "/profiles/*",
+	} {
+		if got := metricsRoute(path); got != want {
+			t.Fatalf("%s: expected %s, got %s", path, want, got)
+		}
+	}
+}
+
+func TestHTTPHandlerRejectsOversizedBodies(t *testing.T) {
+	srv := &Server{httpMetrics: newHTTPMetrics()}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/profiles", srv.handleProfiles)
+	h := srv.httpHandler(mux)
+
+	body := `{"id":"` + strings.Repeat("a", maxRequestBodyBytes) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "http://localhost/api/profiles", strings.NewReader(body))
+	req.RemoteAddr = "127.0.0.1:5000"
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: "tok"})
+	req.Header.Set("X-CSRF-Token", "tok")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestNewHTTPServerSetsLimits(t *testing.T) {
+	s := newHTTPServer(7331, http.NotFoundHandler())
+	if s.ReadHeaderTimeout == 0 || s.ReadTimeout == 0 || s.WriteTimeout == 0 || s.IdleTimeout == 0 || s.MaxHeaderBytes == 0 {
+		t.Fatalf("expected all server limits to be set, got %+v", s)
+	}
+}
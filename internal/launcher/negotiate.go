@@ -0,0 +1,53 @@
+package launcher
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Handlers that serve both scripts and the UI pick their answer from the
+// request headers instead of growing a second endpoint. htmx marks its
+// requests with "HX-Request: true" and gets an HTML fragment to swap into
+// the page; "Accept: application/json" gets JSON; anything else gets the
+// handler's default.
+
+type responseFormat int
+
+const (
+	formatJSON responseFormat = iota
+	formatFragment
+	formatPage
+)
+
+func negotiateFormat(r *http.Request, fallback responseFormat) responseFormat {
+	switch {
+	case isHTMXRequest(r):
+		return formatFragment
+	case acceptsJSON(r):
+		return formatJSON
+	}
+	return fallback
+}
+
+func isHTMXRequest(r *http.Request) bool {
+	return strings.EqualFold(strings.TrimSpace(r.Header.Get("HX-Request")), "true")
+}
+
+func acceptsJSON(r *http.Request) bool {
+	return strings.Contains(strings.ToLower(r.Header.Get("Accept")), "application/json")
+}
+
+// writeFragment renders one of the named templates on its own, without
+// the page layout, for htmx to swap in.
+func (s *Server) writeFragment(w http.ResponseWriter, status int, name string, data any) {
+	if s.pages == nil {
+		http.Error(w, "HTML fragments are not available", http.StatusNotAcceptable)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Add("Vary", "HX-Request")
+	w.WriteHeader(status)
+	if err := s.pages.RenderFragment(w, name, data); err != nil {
+		logError("fragment_render_failed", map[string]any{"template": name, "error": err.Error()})
+	}
+}
@@ -0,0 +1,72 @@
+package launcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	cases := []struct {
+		headers  map[string]string
+		fallback responseFormat
+		want     responseFormat
+	}{
+		{nil, formatPage, formatPage},
+		{map[string]string{"Accept": "application/json"}, formatPage, formatJSON},
+		{map[string]string{"HX-Request": "true", "Accept": "application/json"}, formatJSON, formatFragment},
+		{map[string]string{"HX-Request": "false"}, formatJSON, formatJSON},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/api/profiles", nil)
+		for k, v := range tc.headers {
+			req.Header.Set(k, v)
+		}
+		if got := negotiateFormat(req, tc.fallback); got != tc.want {
+			t.Fatalf("headers %v: expected %d, got %d", tc.headers, tc.want, got)
+		}
+	}
+}
+
+func TestHTMXRequestsGetFragments(t *testing.T) {
+	srv := newServiceTestServer(t)
+	pages, err := NewTemplatesFromFS(fstest.MapFS{
+		"templates/+layout.html":                 {Data: []byte(`{{ define "layout" }}<html>{{ template "page" . }}</html>{{ end }}`)},
+		"templates/profiles.html":                {Data: []byte(`{{ define "page:profiles.html" }}{{ template "profile-list" .Profiles }}{{ end }}`)},
+		"templates/components/profile-list.html": {Data: []byte(`{{ define "profile-list" }}{{ range . }}{{ template "profile-row" . }}{{ end }}{{ end }}`)},
+		"templates/components/profile-row.html":  {Data: []byte(`{{ define "profile-row" }}<div class="profile-card" data-profile-id="{{ .ID }}"></div>{{ end }}`)},
+		"templates/components/form-errors.html":  {Data: []byte(`{{ define "form-errors" }}{{ range .Fields }}<li data-code="{{ .Code }}">{{ .Message }}</li>{{ end }}{{ end }}`)},
+	}, "templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.pages = pages
+
+	req := httptest.NewRequest(http.MethodGet, "/api/profiles", nil)
+	req.Header.Set("HX-Request", "true")
+	rec := httptest.NewRecorder()
+	srv.handleProfiles(rec, req)
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") || !strings.Contains(rec.Body.String(), `data-profile-id="alpha"`) {
+		t.Fatalf("expected the profile list fragment, got %d %q", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "<html>") {
+		t.Fatalf("expected no layout around a fragment, got %q", rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/profiles", strings.NewReader("id=NO&hostPort=8090"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("HX-Request", "true")
+	rec = httptest.NewRecorder()
+	srv.handleProfiles(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `data-code="id.invalid"`) {
+		t.Fatalf("expected the error fragment, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	// Rendering fragments must not stop full pages from rendering.
+	page := httptest.NewRecorder()
+	if err := pages.RenderPageWithTemplate(page, "profiles.html", map[string]any{"Profiles": []ProfileRequest{{ID: "alpha"}}}); err != nil {
+		t.Fatalf("expected the page to render after fragments, got %v", err)
+	}
+}
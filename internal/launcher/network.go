@@ -0,0 +1,197 @@
+package launcher
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// NetworkSettings overrides Docker's default bridge networks for a profile,
+// for hosts where a corporate VPN claims the ranges Docker would pick.
+// Empty fields keep Docker's choice; MTU falls back to KIMMIO_NETWORK_MTU.
+//
+// ExtraHosts adds "name:address" entries to the app's /etc/hosts, where the
+// address may be "host-gateway". HostMode runs the app on the host network
+// so it can reach services like a local SMTP relay or LDAP server; the
+// database, Redis and MinIO are then published on 127.0.0.1 at the three
+// ports following the app port.
+type NetworkSettings struct {
+	PublicSubnet   string   `json:"publicSubnet,omitempty"`
+	InternalSubnet string   `json:"internalSubnet,omitempty"`
+	MTU            int      `json:"mtu,omitempty"`
+	ExtraHosts     []string `json:"extraHosts,omitempty"`
+	HostMode       bool     `json:"hostMode,omitempty"`
+}
+
+const (
+	minNetworkMTU = 576
+	maxNetworkMTU = 9000
+
+	maxExtraHosts = 20
+	hostGateway   = "host-gateway"
+)
+
+var extraHostNameRe = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9.-]{0,251}[a-zA-Z0-9])?$`)
+
+func normalizeNetworkSettings(n *NetworkSettings) error {
+	var err error
+	if n.PublicSubnet, err = normalizeSubnet("public", n.PublicSubnet); err != nil {
+		return fieldError("networkPublicSubnet", "network.subnet", err.Error())
+	}
+	if n.InternalSubnet, err = normalizeSubnet("internal", n.InternalSubnet); err != nil {
+		return fieldError("networkInternalSubnet", "network.subnet", err.Error())
+	}
+	if n.MTU != 0 && (n.MTU < minNetworkMTU || n.MTU > maxNetworkMTU) {
+		return fieldError("networkMTU", "network.mtu", fmt.Sprintf("network MTU must be between %d and %d", minNetworkMTU, maxNetworkMTU))
+	}
+	if n.PublicSubnet != "" && n.PublicSubnet == n.InternalSubnet {
+		return fieldError("networkInternalSubnet", "network.overlap", "public and internal subnets must differ")
+	}
+	if subnetsOverlap(n.PublicSubnet, n.InternalSubnet) {
+		return fieldError("networkInternalSubnet", "network.overlap", "public and internal subnets overlap")
+	}
+	if len(n.ExtraHosts) > maxExtraHosts {
+		return fieldError("networkExtraHosts", "network.extra_hosts", fmt.Sprintf("at most %d extra hosts are allowed", maxExtraHosts))
+	}
+	hosts := make([]string, 0, len(n.ExtraHosts))
+	for _, entry := range n.ExtraHosts {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		normalized, err := normalizeExtraHost(entry)
+		if err != nil {
+			return fieldError("networkExtraHosts", "network.extra_hosts", err.Error())
+		}
+		hosts = append(hosts, normalized)
+	}
+	n.ExtraHosts = nil
+	if len(hosts) > 0 {
+		n.ExtraHosts = hosts
+	}
+	return nil
+}
+
+// normalizeExtraHost accepts "name:address" or "name=address". IPv6
+// addresses contain colons, so the name ends at the first separator.
+func normalizeExtraHost(entry string) (string, error) {
+	sep := strings.IndexAny(entry, ":=")
+	if sep < 0 {
+		return "", fmt.Errorf("extra host %q must look like name:address", entry)
+	}
+	name := strings.ToLower(strings.TrimSpace(entry[:sep]))
+	addr := strings.TrimSpace(entry[sep+1:])
+	if !extraHostNameRe.MatchString(name) {
+		return "", fmt.Errorf("extra host %q has an invalid hostname", entry)
+	}
+	if addr != hostGateway && net.ParseIP(addr) == nil {
+		return "", fmt.Errorf("extra host %q must map to an IP address or %s", entry, hostGateway)
+	}
+	return name + ":" + addr, nil
+}
+
+func normalizeSubnet(name, v string) (string, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return "", nil
+	}
+	_, ipNet, err := net.ParseCIDR(v)
+	if err != nil || ipNet.IP.To4() == nil {
+		return "", fmt.Errorf("%s subnet must be an IPv4 CIDR such as 10.42.0.0/24", name)
+	}
+	// The stack needs room for four containers plus the gateway.
+	if ones, _ := ipNet.Mask.Size(); ones < 8 || ones > 29 {
+		return "", fmt.Errorf("%s subnet prefix must be between /8 and /29", name)
+	}
+	return ipNet.String(), nil
+}
+
+func subnetsOverlap(a, b string) bool {
+	_, na, errA := net.ParseCIDR(a)
+	_, nb, errB := net.ParseCIDR(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return na.Contains(nb.IP) || nb.Contains(na.IP)
+}
+
+// checkSubnetConflicts rejects subnets that overlap one configured by
+// another profile; Docker refuses to create overlapping bridge networks.
+func checkSubnetConflicts(p ProfileRequest, others []ProfileRequest) error {
+	for _, other := range others {
+		if other.ID == p.ID {
+			continue
+		}
+		for _, mine := range []string{p.Network.PublicSubnet, p.Network.InternalSubnet} {
+			for _, theirs := range []string{other.Network.PublicSubnet, other.Network.InternalSubnet} {
+				if mine != "" && theirs != "" && subnetsOverlap(mine, theirs) {
+					return fmt.Errorf("subnet %s overlaps %s used by profile %s", mine, theirs, other.ID)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// profileHostPorts lists every host port the profile binds: the app port
+// and, in host mode, the ports its dependencies are published on.
+func profileHostPorts(p ProfileRequest) []int {
+	if len(p.Ports) == 0 || p.Ports[0].Host <= 0 {
+		return nil
+	}
+	host := p.Ports[0].Host
+	if !p.Network.HostMode {
+		return []int{host}
+	}
+	return []int{host, host + 1, host + 2, host + 3}
+}
+
+func effectiveNetworkMTU(n NetworkSettings) int {
+	if n.MTU > 0 {
+		return n.MTU
+	}
+	return appCfg.NetworkMTU
+}
+
+// composeNetworkOptions renders the driver_opts and ipam keys of one
+// compose network, indented to sit under the network's name.
+func composeNetworkOptions(subnet string, mtu int) string {
+	var b strings.Builder
+	if mtu > 0 {
+		b.WriteString("    driver_opts:\n")
+		b.WriteString("      com.docker.network.driver.mtu: \"" + strconv.Itoa(mtu) + "\"\n")
+	}
+	if subnet != "" {
+		b.WriteString("    ipam:\n      config:\n        - subnet: " + subnet + "\n")
+	}
+	return b.String()
+}
+
+// composeAppNetworking renders the app service's network keys.
+func composeAppNetworking(n NetworkSettings) string {
+	var b strings.Builder
+	if n.HostMode {
+		b.WriteString("    network_mode: host\n")
+	} else {
+		b.WriteString("    ports:\n      - \"${APP_PORT}:${APP_PORT}\"\n")
+		b.WriteString("    networks:\n      - public\n      - internal\n")
+	}
+	if len(n.ExtraHosts) > 0 {
+		b.WriteString("    extra_hosts:\n")
+		for _, h := range n.ExtraHosts {
+			b.WriteString("      - \"" + h + "\"\n")
+		}
+	}
+	return b.String()
+}
+
+// composeLoopbackPort publishes a dependency on 127.0.0.1 in host mode,
+// where the app can no longer resolve it by service name.
+func composeLoopbackPort(n NetworkSettings, hostVar, containerPort string) string {
+	if !n.HostMode {
+		return ""
+	}
+	return "    ports:\n      - \"127.0.0.1:${" + hostVar + "}:" + containerPort + "\"\n"
+}
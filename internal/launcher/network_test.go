@@ -0,0 +1,88 @@
+package launcher
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeNetworkSettings(t *testing.T) {
+	n := NetworkSettings{PublicSubnet: " 10.42.0.7/24 ", InternalSubnet: "10.43.0.0/24", MTU: 1400}
+	if err := normalizeNetworkSettings(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n.PublicSubnet != "10.42.0.0/24" {
+		t.Fatalf("expected canonical subnet, got %q", n.PublicSubnet)
+	}
+	for _, bad := range []NetworkSettings{
+		{PublicSubnet: "10.42.0.0"},
+		{PublicSubnet: "fd00::/64"},
+		{PublicSubnet: "10.42.0.0/30"},
+		{PublicSubnet: "10.42.0.0/16", InternalSubnet: "10.42.1.0/24"},
+		{MTU: 100},
+	} {
+		if err := normalizeNetworkSettings(&bad); err == nil {
+			t.Fatalf("expected %+v to be rejected", bad)
+		}
+	}
+}
+
+func TestCheckSubnetConflicts(t *testing.T) {
+	others := []ProfileRequest{{ID: "alpha", Network: NetworkSettings{InternalSubnet: "172.30.0.0/16"}}}
+	if err := checkSubnetConflicts(ProfileRequest{ID: "beta", Network: NetworkSettings{PublicSubnet: "172.30.5.0/24"}}, others); err == nil {
+		t.Fatalf("expected overlap with alpha to be rejected")
+	}
+	if err := checkSubnetConflicts(ProfileRequest{ID: "beta", Network: NetworkSettings{PublicSubnet: "172.31.0.0/24"}}, others); err != nil {
+		t.Fatalf("unexpected conflict: %v", err)
+	}
+	if err := checkSubnetConflicts(others[0], others); err != nil {
+		t.Fatalf("profile must not conflict with itself: %v", err)
+	}
+}
+
+func TestComposeNetworksUseSubnetAndMTU(t *testing.T) {
+	yaml := buildComposeYAML(ProfileRequest{Network: NetworkSettings{PublicSubnet: "10.42.0.0/24", MTU: 1400}})
+	if !strings.Contains(yaml, "    ipam:\n      config:\n        - subnet: 10.42.0.0/24\n  internal:") {
+		t.Fatalf("expected public subnet in compose yaml:\n%s", yaml)
+	}
+	if strings.Count(yaml, `com.docker.network.driver.mtu: "1400"`) != 2 {
+		t.Fatalf("expected MTU on both networks:\n%s", yaml)
+	}
+	if strings.Contains(buildComposeYAML(ProfileRequest{}), "ipam:") {
+		t.Fatalf("expected default networks without ipam")
+	}
+}
+
+func TestNormalizeExtraHosts(t *testing.T) {
+	n := NetworkSettings{ExtraHosts: []string{" Host.Docker.Internal=host-gateway ", "", "ldap.corp:fd00::5"}}
+	if err := normalizeNetworkSettings(&n); err != nil {
+		t.Fatal(err)
+	}
+	if len(n.ExtraHosts) != 2 || n.ExtraHosts[0] != "host.docker.internal:host-gateway" || n.ExtraHosts[1] != "ldap.corp:fd00::5" {
+		t.Fatalf("unexpected extra hosts: %v", n.ExtraHosts)
+	}
+	for _, bad := range []string{"ldap.corp", "ldap corp:10.0.0.1", "ldap.corp:not-an-ip"} {
+		if err := normalizeNetworkSettings(&NetworkSettings{ExtraHosts: []string{bad}}); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestHostModeComposeAndPorts(t *testing.T) {
+	p := ProfileRequest{ID: "alpha", Ports: []PortMapping{{Container: 3000, Host: 8088}}, Network: NetworkSettings{HostMode: true}}
+	yaml := buildComposeYAML(p)
+	if !strings.Contains(yaml, "    network_mode: host\n") || strings.Contains(yaml, `"${APP_PORT}:${APP_PORT}"`) {
+		t.Fatalf("expected app on host network:\n%s", yaml)
+	}
+	if !strings.Contains(yaml, `"127.0.0.1:${POSTGRES_PORT}:5432"`) || strings.Contains(yaml, "internal: true") {
+		t.Fatalf("expected loopback-published dependencies:\n%s", yaml)
+	}
+	env := buildComposeEnv(p)
+	if !strings.Contains(env, "POSTGRES_HOST=127.0.0.1\n") || !strings.Contains(env, "MINIO_ROOT_PORT=8091\n") {
+		t.Fatalf("expected loopback dependency env:\n%s", env)
+	}
+
+	other := ProfileRequest{ID: "beta", Ports: []PortMapping{{Container: 3000, Host: 8090}}}
+	if err := validateStoredProfiles(ProfileStore{Profiles: []ProfileRequest{p, other}}); err == nil {
+		t.Fatalf("expected port 8090 to conflict with alpha's redis port")
+	}
+}
@@ -0,0 +1,403 @@
+package launcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Notifications leave the launcher through the channels of the
+// notificationChannels setting. A channel has a type, routing rules (the
+// events and profiles it wants, all when empty) and an optional message
+// template. Delivery is best effort: a failing channel is logged and the
+// others still get the event.
+//
+// Templates use text/template with the notification as data: .Kind,
+// .ProfileID, .Version, .Metric, .Message, .URL and .CreatedAt. Webhook
+// channels post the event as JSON with the rendered text in "text"; the
+// other types send only the text.
+
+const (
+	channelWebhook = "webhook"
+	channelSlack   = "slack"
+	channelDiscord = "discord"
+	channelSMTP    = "smtp"
+	channelDesktop = "desktop"
+	channelCommand = "command"
+
+	eventProfileDown      = "profile_down"
+	eventProfileRecovered = "profile_recovered"
+	eventSummary          = "summary"
+	eventTest             = "test"
+
+	maxNotificationChannels = 10
+	maxChannelTemplate      = 2048
+	channelTimeout          = 10 * time.Second
+	channelCommandTimeout   = 30 * time.Second
+	defaultChannelTemplate  = "{{.Message}}"
+)
+
+var (
+	channelTypes  = []string{channelWebhook, channelSlack, channelDiscord, channelSMTP, channelDesktop, channelCommand}
+	channelEvents = []string{noticeLauncherUpdate, noticeProfileUpdate, noticeResourceAlert, eventProfileDown, eventProfileRecovered, eventSummary}
+	channelIDRe   = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+)
+
+var ErrChannelNotFound = errors.New("notification channel not found")
+
+// NotificationChannel is one place notifications are sent to.
+type NotificationChannel struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	// URL is where webhook, slack and discord channels post.
+	URL string `json:"url,omitempty"`
+	// To is the address smtp channels mail, through the summarySmtp server.
+	To string `json:"to,omitempty"`
+	// Command is the shell command of a command channel. It gets the event
+	// in KIMMIO_EVENT, KIMMIO_PROFILE_ID and KIMMIO_MESSAGE, and the JSON
+	// webhook body on stdin.
+	Command  string   `json:"command,omitempty"`
+	Events   []string `json:"events,omitempty"`
+	Profiles []string `json:"profiles,omitempty"`
+	Template string   `json:"template,omitempty"`
+	Disabled bool     `json:"disabled,omitempty"`
+}
+
+func (c NotificationChannel) clone() NotificationChannel {
+	c.Events = slices.Clone(c.Events)
+	c.Profiles = slices.Clone(c.Profiles)
+	return c
+}
+
+// normalize trims and lower-cases the channel in place; validate reports
+// what is still wrong.
+func (c *NotificationChannel) normalize() {
+	c.ID = strings.ToLower(strings.TrimSpace(c.ID))
+	c.Type = strings.ToLower(strings.TrimSpace(c.Type))
+	c.URL = strings.TrimSpace(c.URL)
+	c.To = strings.TrimSpace(c.To)
+	c.Command = strings.TrimSpace(c.Command)
+	clean := func(values []string) []string {
+		out := []string{}
+		for _, v := range values {
+			if v = strings.ToLower(strings.TrimSpace(v)); v != "" && !slices.Contains(out, v) {
+				out = append(out, v)
+			}
+		}
+		return out
+	}
+	c.Events = clean(c.Events)
+	c.Profiles = clean(c.Profiles)
+}
+
+func (c NotificationChannel) validate(smtp SMTPSettings) error {
+	if !channelIDRe.MatchString(c.ID) {
+		return fmt.Errorf("channel id %q must be 1-32 lowercase letters, digits or dashes", c.ID)
+	}
+	switch c.Type {
+	case channelWebhook, channelSlack, channelDiscord:
+		u, err := url.Parse(c.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("channel %s: url must be an http(s) URL", c.ID)
+		}
+	case channelSMTP:
+		if _, err := mail.ParseAddress(c.To); err != nil {
+			return fmt.Errorf("channel %s: to must be an email address", c.ID)
+		}
+		if !smtp.configured() {
+			return fmt.Errorf("channel %s: summarySmtp host is required to send email", c.ID)
+		}
+	case channelCommand:
+		if c.Command == "" || len(c.Command) > maxHookLength {
+			return fmt.Errorf("channel %s: command must be 1-%d characters", c.ID, maxHookLength)
+		}
+	case channelDesktop:
+	default:
+		return fmt.Errorf("channel %s: type must be one of %s", c.ID, strings.Join(channelTypes, ", "))
+	}
+	for _, event := range c.Events {
+		if !slices.Contains(channelEvents, event) {
+			return fmt.Errorf("channel %s: events must be among %s", c.ID, strings.Join(channelEvents, ", "))
+		}
+	}
+	for _, id := range c.Profiles {
+		if !profileIDRe.MatchString(id) {
+			return fmt.Errorf("channel %s: %q is not a profile id", c.ID, id)
+		}
+	}
+	if len(c.Template) > maxChannelTemplate {
+		return fmt.Errorf("channel %s: template must be at most %d characters", c.ID, maxChannelTemplate)
+	}
+	// Executing against a sample catches unknown fields, which parsing
+	// alone lets through.
+	if _, err := c.render(Notification{Kind: eventTest, Message: "test"}); err != nil {
+		return fmt.Errorf("channel %s: %w", c.ID, err)
+	}
+	return nil
+}
+
+// wants applies the routing rules. Profile rules only filter events about a
+// profile, so launcher updates and summaries reach every channel that
+// takes their event. Test events reach the channel they are sent to.
+func (c NotificationChannel) wants(n Notification) bool {
+	if c.Disabled {
+		return false
+	}
+	if n.Kind == eventTest {
+		return true
+	}
+	if len(c.Events) > 0 && !slices.Contains(c.Events, n.Kind) {
+		return false
+	}
+	return n.ProfileID == "" || len(c.Profiles) == 0 || slices.Contains(c.Profiles, n.ProfileID)
+}
+
+func (c NotificationChannel) render(n Notification) (string, error) {
+	text := c.Template
+	if strings.TrimSpace(text) == "" {
+		text = defaultChannelTemplate
+	}
+	tmpl, err := template.New(c.ID).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("template: %w", err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, n); err != nil {
+		return "", fmt.Errorf("template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// legacyWebhookChannels turns the notificationWebhooks of older settings
+// files into webhook channels.
+func legacyWebhookChannels(hooks []string) []NotificationChannel {
+	out := []NotificationChannel{}
+	for i, hook := range hooks {
+		if hook = strings.TrimSpace(hook); hook != "" {
+			out = append(out, NotificationChannel{ID: fmt.Sprintf("webhook-%d", i+1), Type: channelWebhook, URL: hook})
+		}
+	}
+	return out
+}
+
+// commandChannelsChanged reports whether next adds or changes a command
+// channel.
+func commandChannelsChanged(before, next []NotificationChannel) bool {
+	known := map[string]bool{}
+	for _, c := range before {
+		if c.Type == channelCommand {
+			known[c.ID+"\x00"+c.Command] = true
+		}
+	}
+	for _, c := range next {
+		c.normalize()
+		if c.Type == channelCommand && !known[c.ID+"\x00"+c.Command] {
+			return true
+		}
+	}
+	return false
+}
+
+// sendNotifications delivers each new notification to the channels that
+// want it.
+func (s *Server) sendNotifications(notices []Notification) {
+	for _, n := range notices {
+		_, _ = s.notifyChannels(context.Background(), n, nil)
+	}
+}
+
+// notifyChannels sends n to every channel that wants it and returns how
+// many deliveries succeeded and the errors of the others. body replaces
+// the notification in what webhook channels post.
+func (s *Server) notifyChannels(ctx context.Context, n Notification, body map[string]any) (int, error) {
+	st := s.settings.get()
+	delivered := 0
+	var errs []error
+	for _, c := range st.NotificationChannels {
+		if !c.wants(n) {
+			continue
+		}
+		if err := deliverToChannel(ctx, st, c, n, body); err != nil {
+			logWarn("notification_channel_failed", map[string]any{"channel": c.ID, "type": c.Type, "event": n.Kind, "error": err.Error()})
+			errs = append(errs, fmt.Errorf("%s: %w", c.ID, err))
+			continue
+		}
+		delivered++
+	}
+	return delivered, errors.Join(errs...)
+}
+
+func deliverToChannel(parent context.Context, st Settings, c NotificationChannel, n Notification, body map[string]any) error {
+	text, err := c.render(n)
+	if err != nil {
+		return err
+	}
+	if body == nil {
+		body = map[string]any{"event": "notification", "notification": n}
+	}
+	client := http.Client{Timeout: channelTimeout}
+	post := func(payload map[string]any) error {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		return postWebhook(&client, c.URL, raw)
+	}
+	switch c.Type {
+	case channelWebhook:
+		payload := map[string]any{"text": text}
+		for k, v := range body {
+			payload[k] = v
+		}
+		return post(payload)
+	case channelSlack:
+		return post(map[string]any{"text": text})
+	case channelDiscord:
+		return post(map[string]any{"content": text})
+	case channelSMTP:
+		ctx, cancel := context.WithTimeout(parent, summaryEmailTimeout)
+		defer cancel()
+		return sendEmail(ctx, st.SummarySMTP, loadSummarySMTPPassword(appCfg.DataDir), c.To, channelTitle(n), text)
+	case channelDesktop:
+		ctx, cancel := context.WithTimeout(parent, channelTimeout)
+		defer cancel()
+		return runDesktopNotification(ctx, channelTitle(n), text)
+	case channelCommand:
+		ctx, cancel := context.WithTimeout(parent, channelCommandTimeout)
+		defer cancel()
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		cmd := shellCommand(ctx, c.Command)
+		cmd.Env = append(os.Environ(), "KIMMIO_EVENT="+n.Kind, "KIMMIO_PROFILE_ID="+n.ProfileID, "KIMMIO_MESSAGE="+text)
+		cmd.Stdin = bytes.NewReader(raw)
+		cmd.WaitDelay = 5 * time.Second
+		if out, err := cmd.CombinedOutput(); err != nil {
+			if msg := strings.TrimSpace(string(out)); msg != "" {
+				return fmt.Errorf("%w: %s", err, truncateText(msg, 200))
+			}
+			return err
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown channel type %q", c.Type)
+}
+
+func truncateText(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+// channelTitle is the subject of emails and the title of desktop
+// notifications.
+func channelTitle(n Notification) string {
+	switch n.Kind {
+	case noticeLauncherUpdate:
+		return "Kimmio Launcher update available"
+	case noticeProfileUpdate:
+		return "Kimmio update available for " + n.ProfileID
+	case noticeResourceAlert:
+		return "Resource alert on " + n.ProfileID
+	case eventProfileDown:
+		return n.ProfileID + " is down"
+	case eventProfileRecovered:
+		return n.ProfileID + " recovered"
+	case eventSummary:
+		return summarySubject
+	}
+	return "Kimmio Launcher"
+}
+
+// Swappable so tests do not pop up notifications.
+var runDesktopNotification = desktopNotification
+
+// desktopNotification shows a notification on the computer the launcher
+// runs on. Title and text travel as arguments or environment variables,
+// never through a script, so they need no quoting.
+func desktopNotification(ctx context.Context, title, text string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.CommandContext(ctx, "osascript",
+			"-e", "on run argv",
+			"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+			"-e", "end run", title, text)
+	case "windows":
+		cmd = exec.CommandContext(ctx, "powershell", "-NoProfile", "-Command",
+			"Add-Type -AssemblyName System.Windows.Forms; $n = New-Object System.Windows.Forms.NotifyIcon; "+
+				"$n.Icon = [System.Drawing.SystemIcons]::Information; $n.Visible = $true; "+
+				"$n.ShowBalloonTip(10000, $env:KIMMIO_TITLE, $env:KIMMIO_MESSAGE, 'Info'); Start-Sleep -Seconds 5; $n.Dispose()")
+		cmd.Env = append(os.Environ(), "KIMMIO_TITLE="+title, "KIMMIO_MESSAGE="+text)
+	default:
+		cmd = exec.CommandContext(ctx, "notify-send", "--app-name=Kimmio Launcher", title, text)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%w: %s", err, truncateText(msg, 200))
+		}
+		return err
+	}
+	return nil
+}
+
+// notifyStatusChange sends profile_down when a profile turns unhealthy or
+// crash-looping, and profile_recovered when it runs again.
+func (s *Server) notifyStatusChange(change statusTransition, now time.Time) {
+	isDown := func(status string) bool { return status == "unhealthy" || status == runtimeCrashLooping }
+	n := Notification{ProfileID: change.ProfileID, CreatedAt: now.UTC().Format(time.RFC3339)}
+	switch {
+	case isDown(change.To) && !isDown(change.From):
+		n.Kind = eventProfileDown
+		n.Message = fmt.Sprintf("%s is %s (was %s)", change.ProfileID, change.To, change.From)
+	case change.To == "running" && isDown(change.From):
+		n.Kind = eventProfileRecovered
+		n.Message = fmt.Sprintf("%s is running again (was %s)", change.ProfileID, change.From)
+	default:
+		return
+	}
+	n.ID = n.Kind + ":" + change.ProfileID + ":" + now.UTC().Format("20060102T150405")
+	s.sendNotifications([]Notification{n})
+}
+
+// handleChannelTest sends a test event to one channel, so a new channel
+// can be checked before anything goes wrong.
+func (s *Server) handleChannelTest(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	st := s.settings.get()
+	idx := slices.IndexFunc(st.NotificationChannels, func(c NotificationChannel) bool { return c.ID == id })
+	if idx < 0 {
+		http.Error(w, ErrChannelNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	n := Notification{
+		ID:        eventTest,
+		Kind:      eventTest,
+		Message:   "Test notification from Kimmio Launcher",
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := deliverToChannel(r.Context(), st, st.NotificationChannels[idx], n, nil); err != nil {
+		logWarn("notification_channel_failed", map[string]any{"channel": id, "event": eventTest, "error": err.Error()})
+		http.Error(w, "Test notification failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "sent": true, "channel": id})
+}
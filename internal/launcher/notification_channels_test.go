@@ -0,0 +1,164 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotificationChannelsRouteAndRender(t *testing.T) {
+	received := make(chan map[string]any, 8)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		body["path"] = r.URL.Path
+		received <- body
+	}))
+	defer ts.Close()
+	var desktop []string
+	defer func(orig func(context.Context, string, string) error) { runDesktopNotification = orig }(runDesktopNotification)
+	runDesktopNotification = func(_ context.Context, title, text string) error {
+		desktop = append(desktop, title+"|"+text)
+		return nil
+	}
+
+	srv := &Server{settings: newSettingsStore(t.TempDir()), notices: newNoticeBoard(t.TempDir())}
+	defer publishSettings(defaultSettings())
+	channels := []NotificationChannel{
+		{ID: "all", Type: channelWebhook, URL: ts.URL + "/all"},
+		{ID: "alerts", Type: channelSlack, URL: ts.URL + "/slack", Events: []string{noticeResourceAlert}, Profiles: []string{"alpha"}, Template: "{{.Kind}} on {{.ProfileID}}: {{.Message}}"},
+		{ID: "off", Type: channelDiscord, URL: ts.URL + "/discord", Disabled: true},
+		{ID: "desk", Type: channelDesktop, Events: []string{eventProfileDown}},
+	}
+	if _, err := srv.settings.update(SettingsPatch{NotificationChannels: &channels}); err != nil {
+		t.Fatal(err)
+	}
+
+	update := Notification{ID: "launcher_update:2.0.0", Kind: noticeLauncherUpdate, Version: "2.0.0", Message: "2.0.0 is out"}
+	srv.sendNotifications(srv.notices.replace([]Notification{update}))
+	// A notice already known is not sent again.
+	srv.sendNotifications(srv.notices.replace([]Notification{update}))
+	if len(received) != 1 {
+		t.Fatalf("expected only the catch-all webhook to get the update once, got %d", len(received))
+	}
+	if body := <-received; body["path"] != "/all" || body["event"] != "notification" || body["text"] != "2.0.0 is out" ||
+		body["notification"].(map[string]any)["id"] != update.ID {
+		t.Fatalf("unexpected webhook body %v", body)
+	}
+
+	srv.sendNotifications([]Notification{
+		{Kind: noticeResourceAlert, ProfileID: "beta", Message: "disk full"},
+		{Kind: noticeResourceAlert, ProfileID: "alpha", Message: "memory high"},
+	})
+	var slack []string
+	for len(received) > 0 {
+		if body := <-received; body["path"] == "/slack" {
+			slack = append(slack, body["text"].(string))
+		}
+	}
+	if len(slack) != 1 || slack[0] != "resource_alert on alpha: memory high" {
+		t.Fatalf("expected the alpha alert rendered for slack only, got %q", slack)
+	}
+
+	srv.notifyStatusChange(statusTransition{ProfileID: "alpha", From: "running", To: "unhealthy"}, time.Now())
+	srv.notifyStatusChange(statusTransition{ProfileID: "alpha", From: "unhealthy", To: runtimeCrashLooping}, time.Now())
+	srv.notifyStatusChange(statusTransition{ProfileID: "alpha", From: runtimeCrashLooping, To: "running"}, time.Now())
+	if len(desktop) != 1 || desktop[0] != "alpha is down|alpha is unhealthy (was running)" {
+		t.Fatalf("expected one desktop notification for going down, got %q", desktop)
+	}
+	if len(received) != 2 {
+		t.Fatalf("expected down and recovered at the catch-all webhook, got %d", len(received))
+	}
+}
+
+func TestCommandChannel(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	out := filepath.Join(t.TempDir(), "event")
+	c := NotificationChannel{ID: "cmd", Type: channelCommand, Command: `printf '%s %s ' "$KIMMIO_EVENT" "$KIMMIO_MESSAGE" > ` + out + ` && cat >> ` + out}
+	n := Notification{Kind: eventProfileDown, ProfileID: "alpha", Message: "alpha is down"}
+	if err := deliverToChannel(context.Background(), Settings{}, c, n, nil); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(out)
+	if err != nil || !strings.HasPrefix(string(raw), "profile_down alpha is down {") {
+		t.Fatalf("expected the event in the environment and on stdin, got %q %v", raw, err)
+	}
+	c.Command = "echo broken >&2; exit 3"
+	if err := deliverToChannel(context.Background(), Settings{}, c, n, nil); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Fatalf("expected the command's output in the error, got %v", err)
+	}
+}
+
+func TestCommandChannelsOnlyFromThisComputer(t *testing.T) {
+	srv := &Server{settings: newSettingsStore(t.TempDir())}
+	defer publishSettings(defaultSettings())
+	body := `{"notificationChannels":[{"id":"cmd","type":"command","command":"true"}]}`
+
+	put := func(target, remote, body string) int {
+		req := httptest.NewRequest(http.MethodPut, target, strings.NewReader(body))
+		req.RemoteAddr = remote
+		rec := httptest.NewRecorder()
+		srv.handleSettings(rec, req)
+		return rec.Code
+	}
+	if code := put("http://launcher.lan/api/settings", "192.0.2.1:1234", body); code != http.StatusForbidden {
+		t.Fatalf("expected a remote request to be refused, got %d", code)
+	}
+	if code := put("http://localhost/api/settings", "127.0.0.1:1234", body); code != http.StatusOK {
+		t.Fatalf("expected a local request to be accepted, got %d", code)
+	}
+	// Keeping the command channel as it is needs no local request.
+	if code := put("http://launcher.lan/api/settings", "192.0.2.1:1234", `{"logLevel":"warn",`+body[1:]); code != http.StatusOK {
+		t.Fatalf("expected an unchanged command channel to be accepted, got %d", code)
+	}
+}
+
+func TestLegacyWebhooksBecomeChannels(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "settings.json"), []byte(`{"logLevel":"info","healthInterval":"15s","updateChannel":"stable","notificationWebhooks":["https://hooks.example.com/a","https://hooks.example.com/b"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defer publishSettings(defaultSettings())
+	got := newSettingsStore(dir).get().NotificationChannels
+	if len(got) != 2 || got[1].ID != "webhook-2" || got[1].Type != channelWebhook || got[1].URL != "https://hooks.example.com/b" {
+		t.Fatalf("expected the webhooks as channels, got %+v", got)
+	}
+}
+
+func TestChannelTestEndpoint(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+	srv := &Server{settings: newSettingsStore(t.TempDir())}
+	defer publishSettings(defaultSettings())
+	channels := []NotificationChannel{
+		{ID: "good", Type: channelDiscord, URL: ts.URL + "/ok"},
+		{ID: "bad", Type: channelWebhook, URL: ts.URL + "/fail"},
+	}
+	if _, err := srv.settings.update(SettingsPatch{NotificationChannels: &channels}); err != nil {
+		t.Fatal(err)
+	}
+	for path, code := range map[string]int{
+		"/api/notifications/channels/good/test": http.StatusOK,
+		"/api/notifications/channels/bad/test":  http.StatusBadGateway,
+		"/api/notifications/channels/nope/test": http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		srv.handleNotificationRoute(rec, httptest.NewRequest(http.MethodPost, path, nil))
+		if rec.Code != code {
+			t.Fatalf("%s: expected %d, got %d %s", path, code, rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	updateCheckInterval = 6 * time.Hour
+
+	noticeLauncherUpdate = "launcher_update"
+	noticeProfileUpdate  = "profile_update"
+)
+
+var ErrNoticeNotFound = errors.New("notification not found")
+
+// Notification is an update notice produced by the background checker or a
+// resource alert. An update notice's ID includes the version, so dismissing
+// one hides it until a newer release.
+type Notification struct {
+	ID        string `json:"id"`
+	Kind      string `json:"kind"`
+	ProfileID string `json:"profileId,omitempty"`
+	Version   string `json:"version"`
+	// Metric names the usage metric of a resource alert.
+	Metric    string `json:"metric,omitempty"`
+	Message   string `json:"message"`
+	URL       string `json:"url,omitempty"`
+	CreatedAt string `json:"createdAt"`
+}
+
+type noticeBoard struct {
+	mu        sync.Mutex
+	path      string
+	notices   []Notification
+	dismissed map[string]bool
+	// checkError explains why the last launcher update check failed, for
+	// example "GitHub API rate-limited until ...".
+	checkError error
+}
+
+type noticeFile struct {
+	Dismissed []string `json:"dismissed"`
+}
+
+func newNoticeBoard(dataDir string) *noticeBoard {
+	b := &noticeBoard{path: filepath.Join(dataDir, "notifications.json"), dismissed: map[string]bool{}}
+	raw, err := os.ReadFile(b.path)
+	if err != nil {
+		return b
+	}
+	var f noticeFile
+	if err := json.Unmarshal(raw, &f); err != nil {
+		logWarn("notifications_file_invalid", map[string]any{"error": err.Error()})
+		return b
+	}
+	for _, id := range f.Dismissed {
+		b.dismissed[id] = true
+	}
+	return b
+}
+
+// replace swaps in the latest update notices, keeping creation times of
+// notices that were already known, and returns the ones that are new.
+func (b *noticeBoard) replace(notices []Notification) []Notification {
+	return b.replaceKinds([]string{noticeLauncherUpdate, noticeProfileUpdate}, notices)
+}
+
+// replaceKinds is replace for the notices of the given kinds; notices of
+// other kinds are kept as they are.
+func (b *noticeBoard) replaceKinds(kinds []string, notices []Notification) []Notification {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	known := map[string]string{}
+	kept := []Notification{}
+	for _, n := range b.notices {
+		known[n.ID] = n.CreatedAt
+		if !slices.Contains(kinds, n.Kind) {
+			kept = append(kept, n)
+		}
+	}
+	added := []Notification{}
+	for i := range notices {
+		if createdAt, ok := known[notices[i].ID]; ok {
+			notices[i].CreatedAt = createdAt
+		} else if !b.dismissed[notices[i].ID] {
+			added = append(added, notices[i])
+		}
+	}
+	b.notices = append(kept, notices...)
+	return added
+}
+
+func (b *noticeBoard) setCheckError(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.checkError = err
+}
+
+func (b *noticeBoard) lastCheckError() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.checkError
+}
+
+func (b *noticeBoard) ofKind(kind string) []Notification {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := []Notification{}
+	for _, n := range b.notices {
+		if n.Kind == kind {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func (b *noticeBoard) active() []Notification {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := []Notification{}
+	for _, n := range b.notices {
+		if !b.dismissed[n.ID] {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func (b *noticeBoard) dismiss(id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	found := false
+	for _, n := range b.notices {
+		if n.ID == id {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return ErrNoticeNotFound
+	}
+	b.dismissed[id] = true
+	f := noticeFile{Dismissed: make([]string, 0, len(b.dismissed))}
+	for dismissedID := range b.dismissed {
+		f.Dismissed = append(f.Dismissed, dismissedID)
+	}
+	sort.Strings(f.Dismissed)
+	raw, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := b.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, b.path)
+}
+
+// startUpdateChecker refreshes update notices in the background so page
+// loads never wait on GitHub or Docker Hub.
+func (s *Server) startUpdateChecker(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			s.refreshNotifications(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *Server) refreshNotifications(ctx context.Context) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	notices := []Notification{}
+
+	current := strings.TrimSpace(launcherAppVersion)
+	release, err := fetchLatestLauncherRelease(s.settings.get().UpdateChannel)
+	if err != nil {
+		// Keep the last known launcher notice; a failed check says nothing
+		// about whether the update is still available.
+		logWarn("launcher_update_check_failed", map[string]any{"error": err.Error()})
+		s.notices.setCheckError(err)
+		notices = append(notices, s.notices.ofKind(noticeLauncherUpdate)...)
+	} else {
+		s.notices.setCheckError(nil)
+	}
+	if latest := strings.TrimPrefix(strings.TrimSpace(release.TagName), "v"); err == nil && isNewerVersion(latest, current) {
+		url := release.HTMLURL
+		if asset, ok := chooseVerifiedLauncherAsset(release, runtime.GOOS, runtime.GOARCH); ok {
+			url = asset.URL
+		}
+		notices = append(notices, Notification{
+			ID:        noticeLauncherUpdate + ":" + latest,
+			Kind:      noticeLauncherUpdate,
+			Version:   latest,
+			Message:   "Kimmio Launcher " + latest + " is available (running " + current + ")",
+			URL:       url,
+			CreatedAt: now,
+		})
+	}
+
+	check := map[string]any{"profileUpdates": 0}
+	if err != nil {
+		check["error"] = err.Error()
+	} else if len(notices) > 0 {
+		check["launcher"] = notices[0].Version
+	}
+	store, err := s.readStore(ctx)
+	if err == nil {
+		profileNotices := profileUpdateNotices(store.Profiles, fetchKnownKimmioVersions(), now)
+		check["profileUpdates"] = len(profileNotices)
+		notices = append(notices, profileNotices...)
+	}
+	auditLog("INFO", "update_check", check)
+	s.sendNotifications(s.notices.replace(notices))
+}
+
+// profileUpdateNotices flags profiles pinned to a release older than the
+// newest known tag. Profiles tracking "latest" update on their own.
+func profileUpdateNotices(profiles []ProfileRequest, versions []string, now string) []Notification {
+	newest := ""
+	for _, v := range versions {
+		if v == "latest" {
+			continue
+		}
+		if newest == "" || isNewerVersion(v, newest) {
+			newest = v
+		}
+	}
+	out := []Notification{}
+	if newest == "" {
+		return out
+	}
+	for _, p := range profiles {
+		pinned := strings.TrimSpace(p.Version)
+		if pinned == "" || pinned == "latest" || !isNewerVersion(newest, pinned) {
+			continue
+		}
+		out = append(out, Notification{
+			ID:        noticeProfileUpdate + ":" + p.ID + ":" + newest,
+			Kind:      noticeProfileUpdate,
+			ProfileID: p.ID,
+			Version:   newest,
+			Message:   "Kimmio " + newest + " is available for " + p.ID + " (running " + pinned + ")",
+			CreatedAt: now,
+		})
+	}
+	return out
+}
+
+func (s *Server) handleNotifications(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	payload := map[string]any{
+		"ok":            true,
+		"notifications": s.notices.active(),
+	}
+	if err := s.notices.lastCheckError(); err != nil {
+		payload["updateCheckError"] = err.Error()
+		var limited GitHubRateLimitError
+		if errors.As(err, &limited) {
+			payload["rateLimitedUntil"] = limited.Until.UTC().Format(time.RFC3339)
+		}
+	}
+	writeJSON(w, http.StatusOK, payload)
+}
+
+func (s *Server) handleNotificationRoute(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/notifications/"), "/"), "/")
+	if len(parts) == 3 && parts[0] == "channels" && parts[2] == "test" {
+		s.handleChannelTest(w, r, parts[1])
+		return
+	}
+	if len(parts) != 2 || parts[1] != "dismiss" || strings.TrimSpace(parts[0]) == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := s.notices.dismiss(strings.TrimSpace(parts[0])); err != nil {
+		if errors.Is(err, ErrNoticeNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "dismissed": true})
+}
@@ -0,0 +1,48 @@
+package launcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProfileUpdateNoticesSkipLatestAndCurrent(t *testing.T) {
+	profiles := []ProfileRequest{
+		{ID: "pinned", Version: "1.0.0"},
+		{ID: "tracking", Version: "latest"},
+		{ID: "current", Version: "1.2.0"},
+	}
+	notices := profileUpdateNotices(profiles, []string{"latest", "1.0.0", "1.2.0", "1.1.0"}, "2024-01-01T00:00:00Z")
+	if len(notices) != 1 {
+		t.Fatalf("expected one notice, got %+v", notices)
+	}
+	if notices[0].ID != "profile_update:pinned:1.2.0" || notices[0].ProfileID != "pinned" {
+		t.Fatalf("unexpected notice %+v", notices[0])
+	}
+}
+
+func TestDismissedNoticesPersistPerVersion(t *testing.T) {
+	dir := t.TempDir()
+	board := newNoticeBoard(dir)
+	board.replace([]Notification{{ID: "launcher_update:2.0.0", Kind: noticeLauncherUpdate, Version: "2.0.0"}})
+
+	srv := &Server{notices: board}
+	rec := httptest.NewRecorder()
+	srv.handleNotificationRoute(rec, httptest.NewRequest(http.MethodPost, "/api/notifications/launcher_update%3A2.0.0/dismiss", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	reloaded := newNoticeBoard(dir)
+	reloaded.replace([]Notification{
+		{ID: "launcher_update:2.0.0", Kind: noticeLauncherUpdate, Version: "2.0.0"},
+		{ID: "launcher_update:2.1.0", Kind: noticeLauncherUpdate, Version: "2.1.0"},
+	})
+	active := reloaded.active()
+	if len(active) != 1 || active[0].Version != "2.1.0" {
+		t.Fatalf("expected only the newer release to remain, got %+v", active)
+	}
+	if err := reloaded.dismiss("missing"); err != ErrNoticeNotFound {
+		t.Fatalf("expected ErrNoticeNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package launcher
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+)
+
+// platformAMD64 is the only override offered: it lets ARM hosts run tags
+// that were published without an arm64 image.
+const platformAMD64 = "linux/amd64"
+
+const emulationWarning = "Running the amd64 image under emulation; expect noticeably slower performance."
+
+func normalizePlatform(v string) (string, error) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	switch v {
+	case "", "native":
+		return "", nil
+	case platformAMD64:
+		return v, nil
+	}
+	return "", errors.New("platform must be empty (native) or " + platformAMD64)
+}
+
+// profileArch is the CPU architecture whose image the profile pulls.
+func profileArch(profile ProfileRequest) string {
+	if profile.Platform != "" {
+		return strings.TrimPrefix(profile.Platform, "linux/")
+	}
+	return hostArch()
+}
+
+// isEmulated reports whether the profile runs an image built for another
+// architecture than the host's.
+func isEmulated(profile ProfileRequest) bool {
+	return profileArch(profile) != hostArch()
+}
+
+// hostArch is replaced in tests.
+var hostArch = detectHostArch
+
+// detectHostArch is the CPU architecture Docker runs natively. An amd64
+// launcher started under Rosetta on an Apple Silicon Mac still drives an
+// arm64 Docker, so the translation is seen through.
+func detectHostArch() string {
+	if runtime.GOARCH == "amd64" && processTranslated() {
+		return "arm64"
+	}
+	return runtime.GOARCH
+}
+
+func isAppleSilicon() bool {
+	return runtime.GOOS == "darwin" && hostArch() == "arm64"
+}
+
+// platformForTag picks the platform override a profile needs to run tag.
+// On arm64 hosts a profile without an override falls back to the amd64
+// image when the tag was published without an arm64 one. ok is false when
+// the tag has no image the host can run; a tag without image details is
+// left to Docker.
+func platformForTag(profile ProfileRequest, tag registryTag) (platform string, ok bool) {
+	if len(tag.Images) == 0 {
+		return profile.Platform, true
+	}
+	if _, found := tag.imageForPlatform(profileArch(profile)); found {
+		return profile.Platform, true
+	}
+	if profile.Platform == "" && hostArch() == "arm64" {
+		if _, found := tag.imageForPlatform("amd64"); found {
+			return platformAMD64, true
+		}
+	}
+	return profile.Platform, false
+}
+
+// emulationGuidance is emulationWarning, plus how to make emulation faster
+// on Apple Silicon when Docker Desktop does not use Rosetta for it.
+func emulationGuidance() string {
+	if isAppleSilicon() {
+		if enabled, known := dockerDesktopRosetta(); known && !enabled {
+			return emulationWarning + " " + rosettaGuidance
+		}
+	}
+	return emulationWarning
+}
+
+const rosettaGuidance = `Turn on "Use Rosetta for x86_64/amd64 emulation on Apple Silicon" in Docker Desktop > Settings > General to speed it up.`
@@ -0,0 +1,37 @@
+//go:build darwin
+
+package launcher
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var translatedOnce = sync.OnceValue(func() bool {
+	out, err := exec.Command("sysctl", "-n", "sysctl.proc_translated").Output()
+	return err == nil && strings.TrimSpace(string(out)) == "1"
+})
+
+// processTranslated reports whether the launcher runs under Rosetta.
+func processTranslated() bool {
+	return translatedOnce()
+}
+
+// dockerDesktopRosetta reads whether Docker Desktop emulates amd64 images
+// with Rosetta. known is false without a Docker Desktop settings file.
+func dockerDesktopRosetta() (enabled, known bool) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return false, false
+	}
+	dir := filepath.Join(home, "Library", "Group Containers", "group.com.docker")
+	for _, name := range []string{"settings-store.json", "settings.json"} {
+		if b, err := os.ReadFile(filepath.Join(dir, name)); err == nil {
+			return dockerDesktopSetting(b, "useVirtualizationFrameworkRosetta")
+		}
+	}
+	return false, false
+}
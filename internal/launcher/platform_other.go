@@ -0,0 +1,13 @@
+//go:build !darwin
+
+package launcher
+
+// processTranslated is only meaningful on macOS, where Rosetta runs amd64
+// binaries on Apple Silicon.
+func processTranslated() bool {
+	return false
+}
+
+func dockerDesktopRosetta() (enabled, known bool) {
+	return false, false
+}
@@ -0,0 +1,105 @@
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// portOwner is the process listening on a host port.
+type portOwner struct {
+	PID       int
+	Name      string
+	Container string
+}
+
+func (o portOwner) String() string {
+	switch {
+	case o.Container != "":
+		return "Docker container " + o.Container
+	case o.Name != "" && o.PID > 0:
+		return fmt.Sprintf("%s (pid %d)", o.Name, o.PID)
+	case o.PID > 0:
+		return fmt.Sprintf("pid %d", o.PID)
+	default:
+		return o.Name
+	}
+}
+
+const portOwnerTimeout = 3 * time.Second
+
+// lookupPortOwner is swapped out in tests.
+var lookupPortOwner = findPortOwner
+
+// findPortOwner names whatever holds the port, preferring a Docker
+// container name over the docker-proxy process that fronts it. The second
+// result is false when the owner cannot be determined, for example when the
+// process belongs to another user.
+func findPortOwner(port int) (portOwner, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), portOwnerTimeout)
+	defer cancel()
+	if name := dockerContainerOnPort(ctx, port); name != "" {
+		return portOwner{Container: name}, true
+	}
+	owner, ok := platformPortOwner(ctx, port)
+	if !ok || (owner.PID == 0 && owner.Name == "") {
+		return portOwner{}, false
+	}
+	return owner, true
+}
+
+func dockerContainerOnPort(ctx context.Context, port int) string {
+	dockerBin, err := dockerBinaryPath()
+	if err != nil {
+		return ""
+	}
+	out, err := dockerCommandWithContext(ctx, dockerBin, "ps", "--filter", "publish="+strconv.Itoa(port), "--format", "{{.Names}}").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.Split(strings.TrimSpace(string(out)), "\n")[0])
+}
+
+// portInUseMessage is the validation error for an unavailable host port.
+func portInUseMessage(port int) string {
+	msg := fmt.Sprintf("host port %d is unavailable on this machine", port)
+	if owner, ok := lookupPortOwner(port); ok {
+		msg += " (in use by " + owner.String() + ")"
+	}
+	return msg
+}
+
+var dockerPortConflictRes = []*regexp.Regexp{
+	regexp.MustCompile(`Bind for \S*:(\d+) failed: port is already allocated`),
+	regexp.MustCompile(`listen tcp[46]? \S*:(\d+): bind: address already in use`),
+}
+
+// conflictingPortFromDockerError pulls the host port out of Docker's
+// "Bind for 0.0.0.0:8080 failed: port is already allocated" and
+// "listen tcp4 0.0.0.0:8080: bind: address already in use" messages.
+func conflictingPortFromDockerError(raw string) int {
+	for _, re := range dockerPortConflictRes {
+		if m := re.FindStringSubmatch(raw); m != nil {
+			if port, err := strconv.Atoi(m[1]); err == nil && port > 0 && port <= 65535 {
+				return port
+			}
+		}
+	}
+	return 0
+}
+
+// portConflictMessage explains a Docker port conflict, naming the process
+// that holds the port when it can be found.
+func portConflictMessage(raw string) string {
+	port := conflictingPortFromDockerError(raw)
+	if port == 0 {
+		return "Host port is already in use by another process. Choose another profile port."
+	}
+	if owner, ok := lookupPortOwner(port); ok {
+		return fmt.Sprintf("Host port %d is already in use by %s. Stop it or choose another profile port.", port, owner)
+	}
+	return fmt.Sprintf("Host port %d is already in use by another process. Choose another profile port.", port)
+}
@@ -0,0 +1,74 @@
+//go:build linux
+
+package launcher
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// platformPortOwner maps the listening socket's inode from /proc/net/tcp*
+// to the process holding it. Processes of other users are invisible without
+// root, so lsof is tried as a fallback.
+func platformPortOwner(ctx context.Context, port int) (portOwner, bool) {
+	inodes := listeningInodes("/proc/net/tcp", port)
+	for inode := range listeningInodes("/proc/net/tcp6", port) {
+		inodes[inode] = true
+	}
+	if len(inodes) == 0 {
+		return portOwner{}, false
+	}
+	procs, _ := filepath.Glob("/proc/[0-9]*")
+	for _, proc := range procs {
+		if ctx.Err() != nil {
+			break
+		}
+		fds, err := os.ReadDir(filepath.Join(proc, "fd"))
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(proc, "fd", fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			if inodes[strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")] {
+				pid, _ := strconv.Atoi(filepath.Base(proc))
+				comm, _ := os.ReadFile(filepath.Join(proc, "comm"))
+				return portOwner{PID: pid, Name: strings.TrimSpace(string(comm))}, true
+			}
+		}
+	}
+	return lsofPortOwner(ctx, port)
+}
+
+// listeningInodes returns inodes of sockets in LISTEN state (0A) bound to
+// port in a /proc/net/tcp style table.
+func listeningInodes(path string, port int) map[string]bool {
+	out := map[string]bool{}
+	f, err := os.Open(path)
+	if err != nil {
+		return out
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Scan() // header
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 10 || fields[3] != "0A" {
+			continue
+		}
+		_, hexPort, ok := strings.Cut(fields[1], ":")
+		if !ok {
+			continue
+		}
+		if p, err := strconv.ParseInt(hexPort, 16, 32); err == nil && int(p) == port {
+			out[fields[9]] = true
+		}
+	}
+	return out
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !windows
+
+package launcher
+
+import "context"
+
+func platformPortOwner(ctx context.Context, port int) (portOwner, bool) {
+	return lsofPortOwner(ctx, port)
+}
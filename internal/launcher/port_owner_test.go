@@ -0,0 +1,76 @@
+package launcher
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestConflictingPortFromDockerError(t *testing.T) {
+	cases := map[string]int{
+		"Error response from daemon: driver failed programming external connectivity on endpoint x: Bind for 0.0.0.0:8088 failed: port is already allocated": 8088,
+		"ports are not available: exposing port TCP 0.0.0.0:9001 -> 0.0.0.0:0: listen tcp 0.0.0.0:9001: bind: address already in use":                        9001,
+		"listen tcp4 [::]:7000: bind: address already in use": 7000,
+		"no space left on device":                             0,
+	}
+	for raw, want := range cases {
+		if got := conflictingPortFromDockerError(raw); got != want {
+			t.Fatalf("%q: expected %d, got %d", raw, want, got)
+		}
+	}
+}
+
+func TestPortConflictMessageNamesOwner(t *testing.T) {
+	old := lookupPortOwner
+	defer func() { lookupPortOwner = old }()
+	lookupPortOwner = func(port int) (portOwner, bool) {
+		return portOwner{PID: 4242, Name: "nginx"}, port == 8088
+	}
+
+	msg := friendlyDockerError("Bind for 0.0.0.0:8088 failed: port is already allocated")
+	if !strings.Contains(msg, "8088") || !strings.Contains(msg, "nginx (pid 4242)") {
+		t.Fatalf("expected owner in message, got %q", msg)
+	}
+	if msg := friendlyDockerError("Bind for 0.0.0.0:9000 failed: port is already allocated"); strings.Contains(msg, "nginx") {
+		t.Fatalf("unexpected owner for unknown port: %q", msg)
+	}
+	if msg := portInUseMessage(8088); !strings.Contains(msg, "in use by nginx (pid 4242)") {
+		t.Fatalf("unexpected validation message %q", msg)
+	}
+	if got := (portOwner{Container: "kimmio-alpha-kimmio_app-1", PID: 1}).String(); got != "Docker container kimmio-alpha-kimmio_app-1" {
+		t.Fatalf("unexpected container owner %q", got)
+	}
+}
+
+func TestParseLsofOwner(t *testing.T) {
+	owner, ok := parseLsofOwner("p812\ncnode\nf23\n")
+	if !ok || owner.PID != 812 || owner.Name != "node" {
+		t.Fatalf("unexpected owner %+v ok=%v", owner, ok)
+	}
+	if _, ok := parseLsofOwner(""); ok {
+		t.Fatalf("expected no owner for empty output")
+	}
+}
+
+func TestPlatformPortOwnerFindsOwnListener(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("reads /proc")
+	}
+	if _, err := os.Stat(filepath.Join("/proc", "self", "fd")); err != nil {
+		t.Skip("no /proc")
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	port := ln.Addr().(*net.TCPAddr).Port
+	owner, ok := platformPortOwner(context.Background(), port)
+	if !ok || owner.PID != os.Getpid() {
+		t.Fatalf("expected this test process to own port %d, got %+v ok=%v", port, owner, ok)
+	}
+}
@@ -0,0 +1,44 @@
+//go:build !windows
+
+package launcher
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// lsofPortOwner asks lsof for the listener in its field output format:
+// "p<pid>" and "c<command>" lines.
+func lsofPortOwner(ctx context.Context, port int) (portOwner, bool) {
+	lsof, err := exec.LookPath("lsof")
+	if err != nil {
+		return portOwner{}, false
+	}
+	out, err := exec.CommandContext(ctx, lsof, "-nP", "-iTCP:"+strconv.Itoa(port), "-sTCP:LISTEN", "-Fpc").Output()
+	if err != nil {
+		return portOwner{}, false
+	}
+	return parseLsofOwner(string(out))
+}
+
+func parseLsofOwner(out string) (portOwner, bool) {
+	var owner portOwner
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) < 2 {
+			continue
+		}
+		switch line[0] {
+		case 'p':
+			if owner.PID != 0 {
+				return owner, true
+			}
+			owner.PID, _ = strconv.Atoi(line[1:])
+		case 'c':
+			owner.Name = line[1:]
+		}
+	}
+	return owner, owner.PID != 0
+}
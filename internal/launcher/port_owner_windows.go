@@ -0,0 +1,48 @@
+//go:build windows
+
+package launcher
+
+import (
+	"context"
+	"encoding/csv"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// platformPortOwner reads the owning PID from netstat and its image name
+// from tasklist.
+func platformPortOwner(ctx context.Context, port int) (portOwner, bool) {
+	out, err := exec.CommandContext(ctx, "netstat", "-ano", "-p", "TCP").Output()
+	if err != nil {
+		return portOwner{}, false
+	}
+	pid := parseNetstatListener(string(out), port)
+	if pid == 0 {
+		return portOwner{}, false
+	}
+	owner := portOwner{PID: pid}
+	list, err := exec.CommandContext(ctx, "tasklist", "/FI", "PID eq "+strconv.Itoa(pid), "/FO", "CSV", "/NH").Output()
+	if err == nil {
+		if rec, err := csv.NewReader(strings.NewReader(string(list))).Read(); err == nil && len(rec) > 0 {
+			owner.Name = rec[0]
+		}
+	}
+	return owner, true
+}
+
+// parseNetstatListener finds "TCP 0.0.0.0:8080 0.0.0.0:0 LISTENING 1234".
+func parseNetstatListener(out string, port int) int {
+	suffix := ":" + strconv.Itoa(port)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || !strings.EqualFold(fields[0], "TCP") || fields[3] != "LISTENING" {
+			continue
+		}
+		if strings.HasSuffix(fields[1], suffix) {
+			pid, _ := strconv.Atoi(fields[4])
+			return pid
+		}
+	}
+	return 0
+}
@@ -0,0 +1,149 @@
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Images of the services next to kimmio-app. They are pinned in the compose
+// file, so they are pulled only when missing, like compose itself would.
+const (
+	postgresImage = "pgvector/pgvector:pg16"
+	redisImage    = "redis:7.2"
+	minioImage    = "minio/minio:RELEASE.2024-01-31T20-20-33Z"
+)
+
+var serviceImages = []string{postgresImage, redisImage, minioImage}
+
+// Pull progress spans this range of the job; compose up starts after it.
+const (
+	pullProgressStart = 30
+	pullProgressEnd   = 55
+)
+
+// pullTracker merges the progress of images pulled in parallel into one
+// job step: the message lists every image still pulling and the progress
+// is their average.
+type pullTracker struct {
+	mu      sync.Mutex
+	notify  composeProgressFn
+	order   []string
+	message map[string]string
+	percent map[string]int
+}
+
+func newPullTracker(notify composeProgressFn, images []string) *pullTracker {
+	t := &pullTracker{notify: notify, order: images, message: map[string]string{}, percent: map[string]int{}}
+	for _, image := range images {
+		t.percent[image] = pullProgressStart
+	}
+	return t
+}
+
+// progressFor is the composeProgressFn of one image.
+func (t *pullTracker) progressFor(image string) composeProgressFn {
+	return func(_, message string, progress int) {
+		t.update(image, message, progress)
+	}
+}
+
+// finish marks image pulled, or not needed, with a closing message.
+func (t *pullTracker) finish(image, message string) {
+	t.update(image, message, pullProgressEnd)
+}
+
+func (t *pullTracker) update(image, message string, progress int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.percent[image] = min(max(progress, pullProgressStart), pullProgressEnd)
+	t.message[image] = message
+
+	var active []string
+	total := 0
+	for _, img := range t.order {
+		total += t.percent[img]
+		if t.percent[img] < pullProgressEnd && t.message[img] != "" {
+			active = append(active, t.message[img])
+		}
+	}
+	if progress >= pullProgressEnd || len(active) == 0 {
+		// A finished image reports its own message once.
+		active = []string{message}
+	}
+	t.notify("pull", strings.Join(active, "; "), total/len(t.order))
+}
+
+// prePullImages pulls the kimmio-app image as policy says and the missing
+// service images, all at once. The first failure cancels the other pulls.
+func (s *Server) prePullImages(ctx context.Context, dockerBin string, profile ProfileRequest, appImage, policy string, notify composeProgressFn) error {
+	skipped, err := skipImagePull(ctx, policy, appImage)
+	if err != nil {
+		return err
+	}
+	if policy == pullNever {
+		for _, image := range serviceImages {
+			if _, err := skipImagePull(ctx, pullNever, image); err != nil {
+				return err
+			}
+		}
+	}
+	images := append([]string{appImage}, serviceImages...)
+	tracker := newPullTracker(notify, images)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errMu    sync.Mutex
+		firstErr error
+	)
+	run := func(f func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := f(); err != nil {
+				errMu.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				errMu.Unlock()
+			}
+		}()
+	}
+
+	if skipped != "" {
+		tracker.finish(appImage, skipped)
+	} else {
+		run(func() error {
+			if err := s.pullProfileImage(ctx, dockerBin, profile, appImage, tracker.progressFor(appImage)); err != nil {
+				return err
+			}
+			tracker.finish(appImage, "Image "+appImage+" is ready")
+			return nil
+		})
+	}
+	for _, image := range serviceImages {
+		if policy == pullNever || localImageExists(ctx, image) {
+			tracker.finish(image, "Using local image "+image)
+			continue
+		}
+		run(func() error {
+			tracker.update(image, "Pulling Docker image "+image, pullProgressStart)
+			_, err := pullImageWithRetry(ctx, dockerBin, image, "", 3, nil, func(p *pullProgress) {
+				if len(p.layers) > 0 {
+					tracker.update(image, image+": "+p.describe(), p.within(pullProgressStart, pullProgressEnd))
+				}
+			})
+			if err != nil {
+				return fmt.Errorf("pull %s: %w", image, err)
+			}
+			tracker.finish(image, "Pulled "+image)
+			return nil
+		})
+	}
+	wg.Wait()
+	return firstErr
+}
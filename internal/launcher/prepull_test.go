@@ -0,0 +1,63 @@
+package launcher
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPullTrackerMergesImages(t *testing.T) {
+	type report struct {
+		message string
+		percent int
+	}
+	var reports []report
+	tracker := newPullTracker(func(_, message string, percent int) {
+		reports = append(reports, report{message, percent})
+	}, []string{"app", "db"})
+
+	tracker.progressFor("app")("pull", "app: 1/4 layers", 35)
+	tracker.update("db", "db: 2/4 layers", 40)
+	last := reports[len(reports)-1]
+	if last.message != "app: 1/4 layers; db: 2/4 layers" || last.percent != 37 {
+		t.Fatalf("expected both images reported, got %+v", last)
+	}
+	tracker.finish("db", "Pulled db")
+	if last := reports[len(reports)-1]; last.message != "Pulled db" || last.percent != 45 {
+		t.Fatalf("expected the finished image reported once, got %+v", last)
+	}
+	tracker.progressFor("app")("pull", "app: 2/4 layers", 42)
+	if last := reports[len(reports)-1]; last.message != "app: 2/4 layers" {
+		t.Fatalf("expected only the image still pulling, got %+v", last)
+	}
+	tracker.finish("app", "Image app is ready")
+	if last := reports[len(reports)-1]; last.percent != pullProgressEnd {
+		t.Fatalf("expected the pull step complete, got %+v", last)
+	}
+}
+
+func TestPrePullUsesLocalImages(t *testing.T) {
+	defer func(orig func(context.Context, string) bool) { localImageExists = orig }(localImageExists)
+	srv := newServiceTestServer(t)
+	ctx := context.Background()
+	profile := ProfileRequest{ID: "alpha", Version: "1.0.0"}
+
+	missing := minioImage
+	localImageExists = func(_ context.Context, image string) bool { return image != missing }
+	err := srv.prePullImages(ctx, "docker", profile, "kimmio/kimmio-app:1.0.0", pullNever, func(string, string, int) {})
+	if err == nil || !strings.Contains(err.Error(), minioImage) {
+		t.Fatalf("expected never to fail on the missing service image, got %v", err)
+	}
+
+	missing = ""
+	var messages []string
+	err = srv.prePullImages(ctx, "docker", profile, "kimmio/kimmio-app:1.0.0", pullIfNotPresent, func(_, message string, _ int) {
+		messages = append(messages, message)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1+len(serviceImages) || !strings.Contains(messages[0], "kimmio/kimmio-app:1.0.0") {
+		t.Fatalf("expected one message per local image, got %v", messages)
+	}
+}
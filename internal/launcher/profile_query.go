@@ -0,0 +1,205 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Large installations list profiles a page at a time. The list API and the
+// profiles page accept the same query parameters:
+//
+//	status    runtime status, comma-separated (running, starting, unhealthy, ...)
+//	label     a label the profile carries
+//	search    case-insensitive text in the id, version, domain or labels
+//	sort      id, status, version or port; "-" in front sorts descending.
+//	          Without it profiles keep the order they were created in.
+//	page      1-based page number
+//	pageSize  profiles per page, at most maxProfilePageSize
+//
+// The API returns every matching profile unless page or pageSize is set, so
+// existing clients keep getting the whole list.
+
+const (
+	defaultProfilePageSize = 50
+	maxProfilePageSize     = 200
+	maxProfileLabels       = 10
+)
+
+var profileLabelRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,31}$`)
+
+var profileSortKeys = map[string]func(a, b ProfileRequest) int{
+	"id":      func(a, b ProfileRequest) int { return strings.Compare(a.ID, b.ID) },
+	"status":  func(a, b ProfileRequest) int { return strings.Compare(a.RuntimeStatus, b.RuntimeStatus) },
+	"version": func(a, b ProfileRequest) int { return strings.Compare(a.Version, b.Version) },
+	"port":    func(a, b ProfileRequest) int { return profileHostPort(a) - profileHostPort(b) },
+}
+
+type profileQuery struct {
+	Status   []string
+	Label    string
+	Search   string
+	Sort     string
+	Page     int
+	PageSize int
+}
+
+// normalizeProfileLabels lowercases, de-duplicates and sorts labels.
+func normalizeProfileLabels(labels []string) ([]string, error) {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, label := range labels {
+		label = strings.ToLower(strings.TrimSpace(label))
+		if label == "" || seen[label] {
+			continue
+		}
+		if !profileLabelRe.MatchString(label) {
+			return nil, fmt.Errorf("label %q must be up to 32 lowercase letters, digits, '.', '_' or '-'", label)
+		}
+		seen[label] = true
+		out = append(out, label)
+	}
+	if len(out) > maxProfileLabels {
+		return nil, fmt.Errorf("a profile can carry at most %d labels", maxProfileLabels)
+	}
+	sort.Strings(out)
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return out, nil
+}
+
+func parseProfileQuery(values url.Values, pageSize int) (profileQuery, error) {
+	var errs fieldErrors
+	q := profileQuery{
+		Label:    strings.ToLower(strings.TrimSpace(values.Get("label"))),
+		Search:   strings.TrimSpace(values.Get("search")),
+		Sort:     strings.ToLower(strings.TrimSpace(values.Get("sort"))),
+		PageSize: pageSize,
+	}
+	for _, status := range strings.Split(values.Get("status"), ",") {
+		if status = strings.ToLower(strings.TrimSpace(status)); status != "" {
+			q.Status = append(q.Status, status)
+		}
+	}
+	if _, ok := profileSortKeys[strings.TrimPrefix(q.Sort, "-")]; q.Sort != "" && !ok {
+		errs.add("sort", "query.sort", errors.New("sort must be id, status, version or port, optionally prefixed with '-'"))
+	}
+	if raw := strings.TrimSpace(values.Get("pageSize")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxProfilePageSize {
+			errs.add("pageSize", "query.page_size", fmt.Errorf("pageSize must be between 1 and %d", maxProfilePageSize))
+		}
+		q.PageSize = n
+	}
+	if raw := strings.TrimSpace(values.Get("page")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			errs.add("page", "query.page", errors.New("page must be a positive number"))
+		}
+		q.Page = n
+		if q.PageSize == 0 {
+			q.PageSize = defaultProfilePageSize
+		}
+	}
+	if q.PageSize > 0 && q.Page == 0 {
+		q.Page = 1
+	}
+	return q, errs.err()
+}
+
+func (q profileQuery) matches(p ProfileRequest) bool {
+	if len(q.Status) > 0 && !slices.Contains(q.Status, p.RuntimeStatus) {
+		return false
+	}
+	if q.Label != "" && !slices.Contains(p.Labels, q.Label) {
+		return false
+	}
+	if q.Search != "" {
+		needle := strings.ToLower(q.Search)
+		haystack := strings.ToLower(strings.Join(append([]string{p.ID, p.DisplayName, p.Version, p.Env["APP_DOMAIN"]}, p.Labels...), " "))
+		if !strings.Contains(haystack, needle) {
+			return false
+		}
+	}
+	return true
+}
+
+// apply filters and sorts profiles and cuts out the requested page. It
+// also returns how many profiles matched in total.
+func (q profileQuery) apply(profiles []ProfileRequest) ([]ProfileRequest, int) {
+	out := []ProfileRequest{}
+	for _, p := range profiles {
+		if q.matches(p) {
+			out = append(out, p)
+		}
+	}
+	if cmp := profileSortKeys[strings.TrimPrefix(q.Sort, "-")]; cmp != nil {
+		desc := strings.HasPrefix(q.Sort, "-")
+		sort.SliceStable(out, func(i, j int) bool {
+			c := cmp(out[i], out[j])
+			if c == 0 {
+				c = strings.Compare(out[i].ID, out[j].ID)
+			}
+			if desc {
+				return c > 0
+			}
+			return c < 0
+		})
+	}
+	total := len(out)
+	if q.PageSize > 0 {
+		start := min((q.Page-1)*q.PageSize, total)
+		end := min(start+q.PageSize, total)
+		out = out[start:end]
+	}
+	return out, total
+}
+
+// pages is the number of pages the matching profiles fill.
+func (q profileQuery) pages(total int) int {
+	if q.PageSize <= 0 || total == 0 {
+		return 1
+	}
+	return (total + q.PageSize - 1) / q.PageSize
+}
+
+// pageURL links to page n of the same query on the profiles page.
+func (q profileQuery) pageURL(n int) string {
+	values := url.Values{}
+	if len(q.Status) > 0 {
+		values.Set("status", strings.Join(q.Status, ","))
+	}
+	if q.Label != "" {
+		values.Set("label", q.Label)
+	}
+	if q.Search != "" {
+		values.Set("search", q.Search)
+	}
+	if q.Sort != "" {
+		values.Set("sort", q.Sort)
+	}
+	if q.PageSize != defaultProfilePageSize {
+		values.Set("pageSize", strconv.Itoa(q.PageSize))
+	}
+	if n > 1 {
+		values.Set("page", strconv.Itoa(n))
+	}
+	if len(values) == 0 {
+		return "/"
+	}
+	return "/?" + values.Encode()
+}
+
+// pageLink is pageURL for pages that exist and empty otherwise.
+func pageLink(q profileQuery, n, pages int) string {
+	if n < 1 || n > pages {
+		return ""
+	}
+	return q.pageURL(n)
+}
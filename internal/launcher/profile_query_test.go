@@ -0,0 +1,96 @@
+package launcher
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestProfileQueryFiltersSortsAndPages(t *testing.T) {
+	profiles := []ProfileRequest{
+		{ID: "gamma", Version: "1.2.0", RuntimeStatus: "running", Labels: []string{"prod"}, Ports: []PortMapping{{Host: 8082}}},
+		{ID: "alpha", Version: "1.0.0", RuntimeStatus: "stopped", Labels: []string{"dev"}, Ports: []PortMapping{{Host: 8080}}},
+		{ID: "beta", Version: "1.1.0", RuntimeStatus: "running", Labels: []string{"prod"}, Ports: []PortMapping{{Host: 8081}}, Env: map[string]string{"APP_DOMAIN": "shop.example.com"}},
+	}
+	ids := func(ps []ProfileRequest) string {
+		out := []string{}
+		for _, p := range ps {
+			out = append(out, p.ID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	q, err := parseProfileQuery(url.Values{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, total := q.apply(profiles); ids(got) != "gamma,alpha,beta" || total != 3 {
+		t.Fatalf("expected every profile in stored order, got %s (%d)", ids(got), total)
+	}
+
+	q, _ = parseProfileQuery(url.Values{"status": {"running"}, "label": {"PROD"}, "sort": {"-port"}}, 0)
+	if got, _ := q.apply(profiles); ids(got) != "gamma,beta" {
+		t.Fatalf("expected running prod profiles by port descending, got %s", ids(got))
+	}
+
+	q, _ = parseProfileQuery(url.Values{"search": {"SHOP"}}, 0)
+	if got, _ := q.apply(profiles); ids(got) != "beta" {
+		t.Fatalf("expected the search to match the domain, got %s", ids(got))
+	}
+
+	q, _ = parseProfileQuery(url.Values{"sort": {"id"}, "page": {"2"}, "pageSize": {"2"}}, 0)
+	got, total := q.apply(profiles)
+	if ids(got) != "gamma" || total != 3 || q.pages(total) != 2 {
+		t.Fatalf("expected the second page to hold gamma, got %s (%d of %d pages)", ids(got), total, q.pages(total))
+	}
+	if link := pageLink(q, 1, 2); link != "/?pageSize=2&sort=id" {
+		t.Fatalf("expected the previous link to keep the query, got %q", link)
+	}
+	if link := pageLink(q, 3, 2); link != "" {
+		t.Fatalf("expected no link past the last page, got %q", link)
+	}
+
+	_, err = parseProfileQuery(url.Values{"sort": {"size"}, "pageSize": {"1000"}, "page": {"0"}}, 0)
+	var ve ValidationError
+	if !errors.As(err, &ve) || len(ve.Fields) != 3 {
+		t.Fatalf("expected three field errors, got %v", err)
+	}
+}
+
+func TestNormalizeProfileLabels(t *testing.T) {
+	labels, err := normalizeProfileLabels([]string{" Prod", "team-a", "prod", ""})
+	if err != nil || strings.Join(labels, ",") != "prod,team-a" {
+		t.Fatalf("expected sorted unique labels, got %v %v", labels, err)
+	}
+	if _, err := normalizeProfileLabels([]string{"no spaces"}); err == nil {
+		t.Fatal("expected a label with a space to be rejected")
+	}
+}
+
+func TestListProfilesAPIPages(t *testing.T) {
+	srv := newServiceTestServer(t)
+	rec := httptest.NewRecorder()
+	srv.handleProfiles(rec, httptest.NewRequest(http.MethodGet, "/api/profiles?pageSize=1", nil))
+	var payload struct {
+		Profiles []ProfileRequest `json:"profiles"`
+		Total    int              `json:"total"`
+		Page     int              `json:"page"`
+		Pages    int              `json:"pages"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Profiles) != 1 || payload.Total != 1 || payload.Page != 1 || payload.Pages != 1 {
+		t.Fatalf("unexpected page %+v", payload)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.handleProfiles(rec, httptest.NewRequest(http.MethodGet, "/api/profiles?sort=bogus", nil))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"query.sort"`) {
+		t.Fatalf("expected a validation error, got %d %s", rec.Code, rec.Body.String())
+	}
+}
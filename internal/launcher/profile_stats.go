@@ -0,0 +1,200 @@
+package launcher
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServiceStats is what docker stats reports for one container of a
+// profile's compose project right now.
+type ServiceStats struct {
+	Service          string  `json:"service"`
+	Container        string  `json:"container"`
+	CPUPercent       float64 `json:"cpuPercent"`
+	MemoryBytes      int64   `json:"memoryBytes"`
+	MemoryLimitBytes int64   `json:"memoryLimitBytes"`
+	NetRxBytes       int64   `json:"netRxBytes"`
+	NetTxBytes       int64   `json:"netTxBytes"`
+	PIDs             int     `json:"pids"`
+}
+
+// ProfileStats is the live usage of a profile: every service, their sum,
+// and the limits the profile sets on its app container. CPU percentages
+// count one core as 100%, as docker stats does.
+type ProfileStats struct {
+	ProfileID string         `json:"profileId"`
+	At        time.Time      `json:"at"`
+	Services  []ServiceStats `json:"services"`
+	Total     struct {
+		CPUPercent  float64 `json:"cpuPercent"`
+		MemoryBytes int64   `json:"memoryBytes"`
+		NetRxBytes  int64   `json:"netRxBytes"`
+		NetTxBytes  int64   `json:"netTxBytes"`
+	} `json:"total"`
+	Limits struct {
+		MemoryBytes int64   `json:"memoryBytes"`
+		CPUs        float64 `json:"cpus"`
+		CPUPercent  float64 `json:"cpuPercent"`
+	} `json:"limits"`
+}
+
+// readServiceStats is swappable so tests can read stats without Docker.
+var readServiceStats = dockerServiceStats
+
+// dockerServiceStats reads the running containers of one profile. A
+// stopped profile has none.
+func dockerServiceStats(parent context.Context, profileID string) ([]ServiceStats, error) {
+	ctx, cancel := context.WithTimeout(parent, usageStatsTimeout)
+	defer cancel()
+	if engine, err := newDockerEngine(); err == nil {
+		return engineServiceStats(ctx, engine, profileID)
+	}
+	dockerBin, err := dockerBinaryPath()
+	if err != nil {
+		return nil, err
+	}
+	psArgs := append([]string{"ps", "--no-trunc"}, managedResourceFilters(profileID)...)
+	psArgs = append(psArgs, "--format", `{{.ID}}	{{.Label "com.docker.compose.service"}}	{{.Names}}`)
+	out, err := dockerCommandWithContext(ctx, dockerBin, psArgs...).Output()
+	if err != nil {
+		return nil, err
+	}
+	containers := parseServiceContainers(string(out))
+	if len(containers) == 0 {
+		return []ServiceStats{}, nil
+	}
+	statsArgs := []string{"stats", "--no-stream", "--no-trunc", "--format", "{{.ID}}\t{{.CPUPerc}}\t{{.MemUsage}}\t{{.NetIO}}\t{{.PIDs}}"}
+	for id := range containers {
+		statsArgs = append(statsArgs, id)
+	}
+	out, err = dockerCommandWithContext(ctx, dockerBin, statsArgs...).Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseServiceStats(string(out), containers), nil
+}
+
+func engineServiceStats(ctx context.Context, engine *dockerEngine, profileID string) ([]ServiceStats, error) {
+	containers, err := engine.listContainers(ctx, managedResourceLabels(profileID)...)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(containers))
+	for _, c := range containers {
+		ids = append(ids, c.ID)
+	}
+	samples := engine.containerStats(ctx, ids)
+	stats := []ServiceStats{}
+	for _, c := range containers {
+		sample, ok := samples[c.ID]
+		if !ok {
+			continue
+		}
+		st := ServiceStats{
+			Service:          c.Labels["com.docker.compose.service"],
+			CPUPercent:       sample.cpuPercent(),
+			MemoryBytes:      sample.memoryUsed(),
+			MemoryLimitBytes: sample.MemoryStats.Limit,
+			PIDs:             sample.PidsStats.Current,
+		}
+		if len(c.Names) > 0 {
+			st.Container = strings.TrimPrefix(c.Names[0], "/")
+		}
+		st.NetRxBytes, st.NetTxBytes = sample.network()
+		stats = append(stats, st)
+	}
+	sortServiceStats(stats)
+	return stats, nil
+}
+
+// parseServiceContainers maps container IDs to their compose service and
+// name from docker ps lines of "<id>\t<service>\t<name>".
+func parseServiceContainers(out string) map[string]ServiceStats {
+	containers := map[string]ServiceStats{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if len(fields) != 3 || fields[0] == "" {
+			continue
+		}
+		containers[fields[0]] = ServiceStats{Service: fields[1], Container: fields[2]}
+	}
+	return containers
+}
+
+// parseServiceStats reads docker stats lines of "<id>\t<cpu%>\t<used> /
+// <limit>\t<rx> / <tx>\t<pids>", sorted by service.
+func parseServiceStats(out string, containers map[string]ServiceStats) []ServiceStats {
+	stats := []ServiceStats{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if len(fields) != 5 {
+			continue
+		}
+		st, ok := containers[fields[0]]
+		if !ok {
+			continue
+		}
+		if cpu, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(fields[1]), "%"), 64); err == nil {
+			st.CPUPercent = cpu
+		}
+		used, limit, _ := strings.Cut(fields[2], "/")
+		st.MemoryBytes, _ = parseDockerSize(used)
+		st.MemoryLimitBytes, _ = parseDockerSize(limit)
+		rx, tx, _ := strings.Cut(fields[3], "/")
+		st.NetRxBytes, _ = parseDockerSize(rx)
+		st.NetTxBytes, _ = parseDockerSize(tx)
+		st.PIDs, _ = strconv.Atoi(strings.TrimSpace(fields[4]))
+		stats = append(stats, st)
+	}
+	sortServiceStats(stats)
+	return stats
+}
+
+func sortServiceStats(stats []ServiceStats) {
+	slices.SortFunc(stats, func(a, b ServiceStats) int {
+		return strings.Compare(a.Service+"\x00"+a.Container, b.Service+"\x00"+b.Container)
+	})
+}
+
+// Stats reads the live usage of a profile's containers.
+func (p *ProfileService) Stats(ctx context.Context, id string) (ProfileStats, error) {
+	profile, err := p.Get(ctx, id)
+	if err != nil {
+		return ProfileStats{}, err
+	}
+	services, err := readServiceStats(ctx, profile.ID)
+	if err != nil {
+		return ProfileStats{}, err
+	}
+	stats := ProfileStats{ProfileID: profile.ID, At: time.Now().UTC().Truncate(time.Second), Services: services}
+	for _, st := range services {
+		stats.Total.CPUPercent += st.CPUPercent
+		stats.Total.MemoryBytes += st.MemoryBytes
+		stats.Total.NetRxBytes += st.NetRxBytes
+		stats.Total.NetTxBytes += st.NetTxBytes
+	}
+	stats.Limits.MemoryBytes = memoryLimitBytes(profile.Resources.Limits.Memory)
+	stats.Limits.CPUs = profile.Resources.Limits.CPUs
+	if stats.Limits.CPUs <= 0 {
+		stats.Limits.CPUs = 1.0
+	}
+	stats.Limits.CPUPercent = stats.Limits.CPUs * 100
+	return stats, nil
+}
+
+func (s *Server) handleProfileStats(w http.ResponseWriter, r *http.Request, id string) {
+	stats, err := s.Profiles().Stats(r.Context(), id)
+	if err != nil {
+		if status := httpStatusForError(err); status != http.StatusInternalServerError {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		http.Error(w, "Failed to read container stats: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "stats": stats})
+}
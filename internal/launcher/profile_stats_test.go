@@ -0,0 +1,74 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseServiceStats(t *testing.T) {
+	containers := parseServiceContainers("aaa\tkimmio_app\talpha-kimmio_app-1\nbbb\tpostgres\talpha-postgres-1\nccc\n")
+	stats := parseServiceStats("bbb\t0.25%\t100MiB / 1.944GiB\t1.5kB / 2MB\t12\n"+
+		"aaa\t12.50%\t1GiB / 3.93GiB\t10MB / 250kB\t31\nzzz\t9%\t1GiB / 2GiB\t0B / 0B\t1\n", containers)
+	if len(stats) != 2 || stats[0].Service != appServiceName || stats[1].Service != "postgres" {
+		t.Fatalf("expected the profile's services sorted, got %+v", stats)
+	}
+	app := stats[0]
+	if app.Container != "alpha-kimmio_app-1" || app.CPUPercent != 12.5 || app.MemoryBytes != 1<<30 ||
+		app.NetRxBytes != 10_000_000 || app.NetTxBytes != 250_000 || app.PIDs != 31 {
+		t.Fatalf("unexpected app stats %+v", app)
+	}
+}
+
+func TestProfileStatsEndpoint(t *testing.T) {
+	srv := newServiceTestServer(t)
+	prev := readServiceStats
+	defer func() { readServiceStats = prev }()
+	readServiceStats = func(_ context.Context, id string) ([]ServiceStats, error) {
+		if id != "alpha" {
+			t.Fatalf("unexpected profile %q", id)
+		}
+		return []ServiceStats{
+			{Service: appServiceName, CPUPercent: 40, MemoryBytes: 512 << 20, NetRxBytes: 1000, NetTxBytes: 10},
+			{Service: "postgres", CPUPercent: 2.5, MemoryBytes: 64 << 20, NetRxBytes: 20, NetTxBytes: 300},
+		}, nil
+	}
+
+	rec := httptest.NewRecorder()
+	srv.handleProfileAction(rec, httptest.NewRequest(http.MethodGet, "/api/profiles/alpha/stats", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var body struct {
+		Stats ProfileStats `json:"stats"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	st := body.Stats
+	if len(st.Services) != 2 || st.Total.CPUPercent != 42.5 || st.Total.MemoryBytes != 576<<20 ||
+		st.Total.NetRxBytes != 1020 || st.Total.NetTxBytes != 310 {
+		t.Fatalf("unexpected totals %+v", st)
+	}
+	if st.Limits.CPUs != 1.0 || st.Limits.CPUPercent != 100 || st.Limits.MemoryBytes != memoryLimitBytes(defaultMemoryLimit) {
+		t.Fatalf("expected default limits, got %+v", st.Limits)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.handleProfileAction(rec, httptest.NewRequest(http.MethodGet, "/api/profiles/missing/stats", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a missing profile, got %d", rec.Code)
+	}
+
+	readServiceStats = func(context.Context, string) ([]ServiceStats, error) {
+		return nil, errors.New("docker is not running")
+	}
+	rec = httptest.NewRecorder()
+	srv.handleProfileAction(rec, httptest.NewRequest(http.MethodGet, "/api/profiles/alpha/stats", nil))
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502 when docker fails, got %d", rec.Code)
+	}
+}
@@ -2,14 +2,20 @@ package launcher
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"launcher/internal/config"
 	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 func TestSplitSecretEnv(t *testing.T) {
@@ -68,11 +74,11 @@ func TestCreateProfileStoresSecretsOutsideProfilesJSON(t *testing.T) {
 		},
 	}
 
-	if err := srv.createProfile(req); err != nil {
+	if err := srv.createProfile(context.Background(), req); err != nil {
 		t.Fatalf("createProfile failed: %v", err)
 	}
 
-	store, err := loadProfileStore(srv.dbPath)
+	store, err := loadProfileStore(context.Background(), srv.dbPath)
 	if err != nil {
 		t.Fatalf("loadProfileStore failed: %v", err)
 	}
@@ -130,7 +136,7 @@ func TestCreateProfileGeneratesSecretsWhenMissing(t *testing.T) {
 		},
 	}
 
-	if err := srv.createProfile(req); err != nil {
+	if err := srv.createProfile(context.Background(), req); err != nil {
 		t.Fatalf("createProfile failed: %v", err)
 	}
 
@@ -226,3 +232,175 @@ func TestValidateCreateConstraints_DuplicatePort(t *testing.T) {
 		t.Fatalf("expected duplicate port validation error")
 	}
 }
+
+func TestPlatformOverrideInComposeYAML(t *testing.T) {
+	req := ProfileRequest{ID: "arm-user", Platform: " Linux/AMD64 "}
+	if err := validateAndNormalize(&req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Platform != platformAMD64 {
+		t.Fatalf("expected normalized platform, got %q", req.Platform)
+	}
+	if !strings.Contains(buildComposeYAML(req), "image: ${KIMMIO_APP_IMAGE}\n    platform: linux/amd64\n") {
+		t.Fatalf("expected platform under the app image")
+	}
+	if strings.Contains(buildComposeYAML(ProfileRequest{}), "platform:") {
+		t.Fatalf("expected no platform for native profiles")
+	}
+	bad := ProfileRequest{ID: "arm-user", Platform: "linux/s390x"}
+	if err := validateAndNormalize(&bad); err == nil {
+		t.Fatalf("expected unsupported platform to be rejected")
+	}
+}
+
+func TestTimeZoneAndLocaleReachEveryService(t *testing.T) {
+	req := ProfileRequest{ID: "berlin", TimeZone: " Europe/Berlin ", Locale: "de_DE.utf8"}
+	if err := validateAndNormalize(&req); err != nil {
+		t.Fatal(err)
+	}
+	if req.TimeZone != "Europe/Berlin" || req.Locale != "de_DE.UTF-8" {
+		t.Fatalf("expected normalized values, got %q %q", req.TimeZone, req.Locale)
+	}
+	env := buildComposeEnv(req)
+	if !strings.Contains(env, "TZ=Europe/Berlin\n") || !strings.Contains(env, "LANG=de_DE.UTF-8\n") {
+		t.Fatalf("expected time zone and locale in compose env:\n%s", env)
+	}
+	yaml := buildComposeYAML(req)
+	if got := strings.Count(yaml, "      TZ: ${TZ}\n"); got != 4 {
+		t.Fatalf("expected TZ on all 4 services, got %d", got)
+	}
+	if !strings.Contains(yaml, `"timezone=${TZ}"`) {
+		t.Fatalf("expected the postgres server time zone to follow TZ")
+	}
+	if env := buildComposeEnv(ProfileRequest{ID: "default-tz"}); !strings.Contains(env, "TZ=UTC\n") || !strings.Contains(env, "LANG=C.UTF-8\n") {
+		t.Fatalf("expected UTC and C.UTF-8 by default:\n%s", env)
+	}
+
+	for _, bad := range []ProfileRequest{
+		{ID: "bad-tz", TimeZone: "Mars/Olympus"},
+		{ID: "bad-tz", TimeZone: "Local"},
+		{ID: "bad-locale", Locale: "de_DE.UTF-8; rm -rf /"},
+	} {
+		if err := validateAndNormalize(&bad); err == nil {
+			t.Fatalf("expected %+v to be rejected", bad)
+		}
+	}
+
+	legacy := ProfileRequest{ID: "legacy", Env: map[string]string{"TZ": "America/New_York"}}
+	if err := validateAndNormalize(&legacy); err != nil || legacy.TimeZone != "America/New_York" || legacy.Env["TZ"] != "" {
+		t.Fatalf("expected the TZ variable to move to the time zone, got %+v (%v)", legacy, err)
+	}
+}
+
+func TestComposeStackCarriesLauncherLabels(t *testing.T) {
+	yaml := buildComposeYAML(ProfileRequest{})
+	if got := strings.Count(yaml, "labels: *launcher-service-labels"); got != 4 {
+		t.Fatalf("expected labels on all 4 services, got %d", got)
+	}
+	if got := strings.Count(yaml, "labels: *launcher-labels"); got != 7 {
+		t.Fatalf("expected labels on 2 networks and 5 volumes, got %d", got)
+	}
+	env := buildComposeEnv(ProfileRequest{ID: "label-check", Version: "1.0.0"})
+	if !strings.Contains(env, "KIMMIO_PROFILE_ID=label-check\n") || !strings.Contains(env, "KIMMIO_LAUNCHER_VERSION="+launcherAppVersion+"\n") {
+		t.Fatalf("expected label values in compose env")
+	}
+	filters := strings.Join(managedResourceFilters("label-check"), " ")
+	if filters != "--filter label=com.kimmio.launcher.managed-by=kimmio-launcher --filter label=com.kimmio.launcher.profile-id=label-check" {
+		t.Fatalf("unexpected filters: %s", filters)
+	}
+}
+
+func TestValidateAndNormalizeReportsEveryField(t *testing.T) {
+	req := ProfileRequest{
+		ID:    "Bad ID!",
+		Ports: []PortMapping{{Container: 3000, Host: 70000}},
+	}
+	req.Resources.Limits.Memory = "lots"
+	err := validateAndNormalize(&req)
+	var ve ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+	codes := map[string]string{}
+	for _, f := range ve.Fields {
+		codes[f.Field] = f.Code
+	}
+	want := map[string]string{"id": "id.invalid", "hostPort": "port.range", "memory": "memory.format"}
+	for field, code := range want {
+		if codes[field] != code {
+			t.Fatalf("expected %s for %s, got fields %+v", code, field, ve.Fields)
+		}
+	}
+}
+
+func TestCreateProfileValidationEnvelope(t *testing.T) {
+	srv := newServiceTestServer(t)
+	post := func(body, contentType, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/profiles", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rec := httptest.NewRecorder()
+		srv.handleCreateProfile(rec, req)
+		return rec
+	}
+
+	rec := post(`{"id":"alpha","ports":[{"container":3000,"host":0}]}`, "application/json", "")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d %s", rec.Code, rec.Body.String())
+	}
+	var payload struct {
+		OK     bool         `json:"ok"`
+		Error  string       `json:"error"`
+		Fields []FieldError `json:"fields"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("expected a JSON envelope, got %q", rec.Body.String())
+	}
+	if payload.OK || len(payload.Fields) == 0 || payload.Fields[0].Code == "" || !strings.HasPrefix(payload.Error, "Validation error: ") {
+		t.Fatalf("unexpected envelope %+v", payload)
+	}
+
+	form := url.Values{"id": {"NO"}, "version": {"latest"}, "hostPort": {"8090"}}.Encode()
+	if rec := post(form, "application/x-www-form-urlencoded", "application/json"); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"id.invalid"`) {
+		t.Fatalf("expected the create page to get field codes, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := post(form, "application/x-www-form-urlencoded", ""); rec.Code != http.StatusBadRequest || strings.Contains(rec.Body.String(), `"fields"`) {
+		t.Fatalf("expected plain form posts not to get the JSON envelope, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestCreateFormPostRerendersWithErrors(t *testing.T) {
+	srv := newServiceTestServer(t)
+	pages, err := NewTemplatesFromFS(fstest.MapFS{
+		"templates/+layout.html": {Data: []byte(`{{ define "layout" }}{{ template "page" . }}{{ end }}`)},
+		"templates/profile-create.html": {Data: []byte(`{{ define "page:profile-create.html" }}` +
+			`id={{ .Profile.ID }} port={{ .HostPort }} smtp={{ index .Profile.Env "SMTP_PASSWORD" }} ` +
+			`idErr={{ index .FieldErrors "id" }} form={{ .FormError }}{{ end }}`)},
+	}, "templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.pages = pages
+
+	form := url.Values{"id": {"No Good"}, "version": {"latest"}, "hostPort": {"8090"}, "smtpPassword": {"hunter2"}}
+	req := httptest.NewRequest(http.MethodPost, "/api/profiles", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	srv.handleCreateProfile(rec, req)
+
+	body := rec.Body.String()
+	if rec.Code != http.StatusBadRequest || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("expected the form back with 400, got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(body, "id=No Good port=8090") {
+		t.Fatalf("expected the submitted values, got %q", body)
+	}
+	if !strings.Contains(body, "idErr=id must be") || !strings.Contains(body, "form=Validation error: ") {
+		t.Fatalf("expected inline errors, got %q", body)
+	}
+	if strings.Contains(body, "hunter2") {
+		t.Fatalf("expected passwords not to be echoed, got %q", body)
+	}
+}
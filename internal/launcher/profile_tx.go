@@ -0,0 +1,197 @@
+package launcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Changes that touch profiles.json and a profile's own files, such as its
+// secrets, go through a redo journal so a crash between two writes cannot
+// leave a profile without its secrets or a deleted profile's secrets
+// behind. The journal entry holds the final content of every file and
+// every removal. It is synced to disk before any file is touched and
+// deleted once all of them are written. Each step can be repeated safely,
+// so at startup the entries still present are applied again: a change
+// either happened completely or, when the crash came before its entry was
+// complete, not at all.
+
+const journalDirName = "journal"
+
+// journalFailedDirName, under the journal, keeps entries that could not be
+// applied at startup. They are never replayed: a later start would write
+// their old content over changes made since.
+const journalFailedDirName = "failed"
+
+// txFileOp is one file of a transaction: its final content, or its removal.
+type txFileOp struct {
+	Path string      `json:"path"`
+	Data []byte      `json:"data,omitempty"`
+	Mode os.FileMode `json:"mode,omitempty"`
+	// Remove deletes the path; a directory goes with its contents.
+	Remove bool `json:"remove,omitempty"`
+}
+
+type profileTx struct {
+	Op        string     `json:"op"`
+	ProfileID string     `json:"profileId"`
+	StartedAt string     `json:"startedAt"`
+	Files     []txFileOp `json:"files"`
+}
+
+func txWrite(path string, data []byte, mode os.FileMode) txFileOp {
+	return txFileOp{Path: path, Data: data, Mode: mode}
+}
+
+func txRemove(path string) txFileOp {
+	return txFileOp{Path: path, Remove: true}
+}
+
+// commitStoreTxLocked writes store together with files as one transaction.
+// Callers hold s.mu. An error while applying is returned like any write
+// error and drops the entry: replaying it after later changes would undo
+// them. The journal only covers the process dying part-way.
+func (s *Server) commitStoreTxLocked(op, profileID string, store ProfileStore, files ...txFileOp) error {
+	b, err := encodeProfileStore(store)
+	if err != nil {
+		return err
+	}
+	tx := profileTx{
+		Op:        op,
+		ProfileID: profileID,
+		StartedAt: time.Now().UTC().Format(time.RFC3339Nano),
+		Files:     append([]txFileOp{txWrite(s.storePath(), b, 0o644)}, files...),
+	}
+	entry, err := writeTxJournal(appCfg.DataDir, tx)
+	if err != nil {
+		return fmt.Errorf("journal %s: %w", op, err)
+	}
+	applyErr := applyTx(tx)
+	if err := os.Remove(entry); err != nil {
+		logWarn("profile_tx_journal_remove_failed", map[string]any{"journal": entry, "error": err.Error()})
+	}
+	if applyErr != nil {
+		s.storeCache = storeCache{}
+		logError("profile_tx_apply_failed", map[string]any{"op": op, "profile_id": profileID, "error": applyErr.Error()})
+		return applyErr
+	}
+	s.refreshStoreCacheLocked(store)
+	return nil
+}
+
+// writeTxJournal stores tx under the journal directory and returns the
+// entry's path. Entry names sort in the order they were written.
+func writeTxJournal(dataDir string, tx profileTx) (string, error) {
+	dir := filepath.Join(dataDir, journalDirName)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(tx)
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%d-%s-%s.json", time.Now().UnixNano(), tx.Op, tx.ProfileID)
+	path := filepath.Join(dir, name)
+	if err := writeFileSynced(path, b, 0o600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func applyTx(tx profileTx) error {
+	for _, f := range tx.Files {
+		if f.Remove {
+			if err := os.RemoveAll(f.Path); err != nil {
+				return err
+			}
+			continue
+		}
+		// Private files get a private directory, as the secrets do.
+		dirMode := os.FileMode(0o755)
+		if f.Mode&0o077 == 0 {
+			dirMode = 0o700
+		}
+		if err := os.MkdirAll(filepath.Dir(f.Path), dirMode); err != nil {
+			return err
+		}
+		if err := writeFileSynced(f.Path, f.Data, f.Mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recoverProfileTransactions applies the journal entries left by an
+// interrupted run, oldest first. An entry that fails is moved to
+// journal/failed for the operator to look at.
+func recoverProfileTransactions(dataDir string) []IntegrityIssue {
+	dir := filepath.Join(dataDir, journalDirName)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	names := []string{}
+	for _, e := range entries {
+		// A .tmp file is an entry that was never completed: the change did
+		// not start.
+		if strings.HasSuffix(e.Name(), ".tmp") {
+			_ = os.Remove(filepath.Join(dir, e.Name()))
+			continue
+		}
+		if strings.HasSuffix(e.Name(), ".json") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	issues := []IntegrityIssue{}
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		var tx profileTx
+		raw, err := os.ReadFile(path)
+		if err == nil {
+			err = json.Unmarshal(raw, &tx)
+		}
+		if err == nil {
+			err = applyTx(tx)
+		}
+		if err != nil {
+			msg := "Interrupted change " + name + " could not be completed: " + err.Error()
+			switch kept, moveErr := moveFailedTxEntry(dir, name); {
+			case moveErr != nil:
+				msg += "; remove it by hand before the next start, it could not be moved aside: " + moveErr.Error()
+			case kept != "":
+				msg += "; kept in " + kept
+			default:
+				msg += "; the entry was dropped"
+			}
+			logError("profile_tx_recover_failed", map[string]any{"journal": path, "error": err.Error()})
+			issues = append(issues, IntegrityIssue{Check: "journal", Severity: integrityWarning, Message: msg})
+			continue
+		}
+		_ = os.Remove(path)
+		issues = append(issues, IntegrityIssue{Check: "journal", Severity: integrityRepaired, Message: fmt.Sprintf("Completed interrupted %s of profile %s", tx.Op, tx.ProfileID)})
+	}
+	return issues
+}
+
+// moveFailedTxEntry takes a failed entry out of the journal and returns
+// where it was kept; "" means it could only be deleted.
+func moveFailedTxEntry(dir, name string) (string, error) {
+	failed := filepath.Join(dir, journalFailedDirName)
+	err := os.MkdirAll(failed, 0o700)
+	if err == nil {
+		err = os.Rename(filepath.Join(dir, name), filepath.Join(failed, name))
+	}
+	if err == nil {
+		return filepath.Join(failed, name), nil
+	}
+	// Dropping the entry is still better than replaying it.
+	if rmErr := os.Remove(filepath.Join(dir, name)); rmErr != nil {
+		return "", err
+	}
+	return "", nil
+}
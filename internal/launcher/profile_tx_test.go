@@ -0,0 +1,105 @@
+package launcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func journalEntries(t *testing.T) []os.DirEntry {
+	t.Helper()
+	entries, err := os.ReadDir(filepath.Join(appCfg.DataDir, journalDirName))
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func TestCreateProfileCommitsStoreAndSecretsTogether(t *testing.T) {
+	srv := newServiceTestServer(t)
+	req := ProfileRequest{ID: "beta", Version: "1.0.0", Ports: []PortMapping{{Container: 3000, Host: 8089}}, Env: map[string]string{}}
+	if err := srv.createProfile(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if secrets := loadProfileSecrets("beta"); secrets["JWT_SECRET"] == "" || secrets["ENC_KEY_V0"] == "" {
+		t.Fatalf("expected generated secrets, got %v", secrets)
+	}
+	if !connectionSheetPending("beta") {
+		t.Fatal("expected the connection sheet to be pending")
+	}
+	if _, err := srv.Profiles().Get(context.Background(), "beta"); err != nil {
+		t.Fatalf("expected the profile to be stored: %v", err)
+	}
+	if entries := journalEntries(t); len(entries) != 0 {
+		t.Fatalf("expected the journal to be empty after commit, got %d entries", len(entries))
+	}
+}
+
+func TestRecoverProfileTransactions(t *testing.T) {
+	srv := newServiceTestServer(t)
+	dataDir := appCfg.DataDir
+	// A crash after the journal entry was written but before its files:
+	// only the journal knows about profile beta.
+	tx := profileTx{Op: "create", ProfileID: "beta", Files: []txFileOp{
+		txWrite(srv.storePath(), []byte(`{"profiles":[{"id":"beta","version":"1.0.0","ports":[],"env":{},"resources":{"limits":{"memory":"","cpus":0}},"enabled":false}]}`), 0o644),
+		txWrite(secretFilePath("beta"), profileSecretsContent(map[string]string{"JWT_SECRET": "s3cret"}), 0o600),
+		txRemove(filepath.Join(dataDir, "compose", "gone")),
+	}}
+	if _, err := writeTxJournal(dataDir, tx); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dataDir, "compose", "gone"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	partial := filepath.Join(dataDir, journalDirName, "1-create-gamma.json.tmp")
+	if err := os.WriteFile(partial, []byte(`{"op":`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	issues := recoverProfileTransactions(dataDir)
+	if len(issues) != 1 || issues[0].Severity != integrityRepaired || !strings.Contains(issues[0].Message, "create of profile beta") {
+		t.Fatalf("expected one completed transaction, got %+v", issues)
+	}
+	if loadProfileSecrets("beta")["JWT_SECRET"] != "s3cret" {
+		t.Fatal("expected the secrets to be written")
+	}
+	if _, err := srv.Profiles().Get(context.Background(), "beta"); err != nil {
+		t.Fatalf("expected the store to be written: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "compose", "gone")); !os.IsNotExist(err) {
+		t.Fatalf("expected the removal to be applied, got %v", err)
+	}
+	if entries := journalEntries(t); len(entries) != 0 {
+		t.Fatalf("expected the journal to be emptied, got %d entries", len(entries))
+	}
+	if again := recoverProfileTransactions(dataDir); len(again) != 0 {
+		t.Fatalf("expected nothing left to recover, got %+v", again)
+	}
+}
+
+func TestRecoverProfileTransactionsMovesFailedEntriesAside(t *testing.T) {
+	newServiceTestServer(t)
+	dataDir := appCfg.DataDir
+	blocker := filepath.Join(dataDir, "blocker")
+	if err := os.WriteFile(blocker, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tx := profileTx{Op: "create", ProfileID: "beta", Files: []txFileOp{txWrite(filepath.Join(blocker, "beta.env"), []byte("X=1\n"), 0o600)}}
+	entry, err := writeTxJournal(dataDir, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issues := recoverProfileTransactions(dataDir)
+	if len(issues) != 1 || issues[0].Severity != integrityWarning || !strings.Contains(issues[0].Message, "kept in") {
+		t.Fatalf("expected one failed transaction kept aside, got %+v", issues)
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, journalDirName, journalFailedDirName, filepath.Base(entry))); err != nil {
+		t.Fatalf("expected the entry under journal/failed: %v", err)
+	}
+	if again := recoverProfileTransactions(dataDir); len(again) != 0 {
+		t.Fatalf("expected the failed entry not to be replayed, got %+v", again)
+	}
+}
@@ -0,0 +1,179 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Update changes the settings a profile can take after it was created: its
+// host port, memory and CPU limits, TLS settings and its app variables, the
+// domain among them as APP_DOMAIN. The other fields of req are ignored. req.Env replaces
+// the public variables; a secret left empty keeps its stored value, as the
+// edit page never shows secrets. An enabled profile picks the change up in
+// an enable job, which is returned; a stopped one on its next start.
+func (p *ProfileService) Update(ctx context.Context, id string, req ProfileRequest, expectedRevision int) (ProfileRequest, *ActionJob, error) {
+	id = normalizeProfileID(id)
+	if !profileIDRe.MatchString(id) {
+		return ProfileRequest{}, nil, ValidationError{Msg: "invalid profile id"}
+	}
+	s := p.srv
+	if jobID, busy := s.activeProfileJob(id); busy {
+		return ProfileRequest{}, nil, ProfileBusyError{JobID: jobID}
+	}
+	updated, changed, err := s.updateProfile(ctx, id, req, expectedRevision)
+	if err != nil || !changed || !updated.Enabled {
+		return updated, nil, err
+	}
+	job, err := p.StartAction(ctx, id, "enable", "", updated.Revision, false)
+	if err != nil {
+		return updated, nil, fmt.Errorf("profile updated, but its containers could not be restarted: %w", err)
+	}
+	return updated, job, nil
+}
+
+// updateProfile stores the new settings and reports whether anything
+// changed.
+func (s *Server) updateProfile(ctx context.Context, id string, req ProfileRequest, expectedRevision int) (ProfileRequest, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	store, err := s.loadStoreLocked(ctx)
+	if err != nil {
+		return ProfileRequest{}, false, err
+	}
+	idx := findProfileIndex(store, id)
+	if idx < 0 {
+		return ProfileRequest{}, false, ErrProfileNotFound
+	}
+	current := store.Profiles[idx]
+	if expectedRevision > 0 && current.Revision != expectedRevision {
+		return ProfileRequest{}, false, RevisionConflictError{Expected: expectedRevision, Current: current}
+	}
+	if err := checkTrashedAction(store, idx, "update"); err != nil {
+		return ProfileRequest{}, false, err
+	}
+	if current.Archived {
+		return ProfileRequest{}, false, ValidationError{Msg: "profile " + id + " is archived; unarchive it first"}
+	}
+
+	// The candidate is validated with its secrets, as at create, and split
+	// again afterwards.
+	secrets := loadProfileSecrets(id)
+	next := current
+	next.Ports = slices.Clone(current.Ports)
+	if len(req.Ports) > 0 && req.Ports[0].Host != 0 {
+		if len(next.Ports) == 0 {
+			next.Ports = []PortMapping{{Container: 3000}}
+		}
+		next.Ports[0].Host = req.Ports[0].Host
+	}
+	next.Resources = req.Resources
+	next.TLS = req.TLS
+	env := maps.Clone(current.Env)
+	if req.Env != nil {
+		publicEnv, secretEnv := splitSecretEnv(req.Env)
+		env = publicEnv
+		for key, value := range secretEnv {
+			if strings.TrimSpace(value) != "" {
+				secrets[key] = value
+			}
+		}
+	}
+	if env == nil {
+		env = map[string]string{}
+	}
+	maps.Copy(env, secrets)
+	next.Env = env
+	if err := validateAndNormalize(&next); err != nil {
+		return ProfileRequest{}, false, err
+	}
+	next.Env, secrets = splitSecretEnv(next.Env)
+
+	if profilePort(next) != profilePort(current) {
+		others := ProfileStore{Profiles: slices.Delete(slices.Clone(store.Profiles), idx, idx+1)}
+		if err := validateCreateConstraints(next, others); err != nil {
+			return ProfileRequest{}, false, err
+		}
+	}
+	secretsChanged := !maps.Equal(secrets, loadProfileSecrets(id))
+	if slices.Equal(next.Ports, current.Ports) && next.Resources == current.Resources && next.TLS == current.TLS &&
+		maps.Equal(next.Env, current.Env) && !secretsChanged {
+		return current, false, nil
+	}
+
+	next.Revision++
+	entry := time.Now().UTC().Format(time.RFC3339) + " [update] success: settings updated"
+	next.ActionLog = append([]string{entry}, current.ActionLog...)
+	if len(next.ActionLog) > 8 {
+		next.ActionLog = next.ActionLog[:8]
+	}
+	store.Profiles[idx] = next
+	if secretsChanged {
+		err = s.commitStoreTxLocked("update", id, store, secretsTxOps(id, secrets)...)
+	} else {
+		err = s.writeStoreLocked(store)
+	}
+	if err != nil {
+		return ProfileRequest{}, false, err
+	}
+	auditLog("INFO", "profile_updated", map[string]any{
+		"profile_id": id,
+		"port":       profilePort(next),
+		"memory":     next.Resources.Limits.Memory,
+		"cpus":       next.Resources.Limits.CPUs,
+		"revision":   next.Revision,
+	})
+	return next, true, nil
+}
+
+// handleProfileUpdate serves PUT /api/profiles/<id> with the same JSON or
+// form body as create. A job id comes back when the profile is restarted
+// with the new settings.
+func (s *Server) handleProfileUpdate(w http.ResponseWriter, r *http.Request, id string) {
+	expectedRevision, err := expectedRevisionFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req, _, err := decodeProfileRequest(r)
+	if err != nil {
+		var ve ValidationError
+		if errors.As(err, &ve) {
+			writeValidationError(w, ve)
+			return
+		}
+		http.Error(w, "Invalid request: "+err.Error(), bodyErrorStatus(err))
+		return
+	}
+	if !isLoopbackRequest(r) || isTokenRequest(r) {
+		current, err := s.Profiles().Get(r.Context(), id)
+		if err != nil {
+			s.writeActionError(w, "update", err)
+			return
+		}
+		if !req.TLS.sameFiles(current.TLS) {
+			http.Error(w, tlsFilesForbidden, http.StatusForbidden)
+			return
+		}
+	}
+	profile, job, err := s.Profiles().Update(r.Context(), id, req, expectedRevision)
+	if err != nil {
+		var ve ValidationError
+		if errors.As(err, &ve) {
+			writeValidationError(w, ve)
+			return
+		}
+		s.writeActionError(w, "update", err)
+		return
+	}
+	resp := map[string]any{"ok": true, "profile": profile}
+	if job != nil {
+		resp["jobId"] = job.ID
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
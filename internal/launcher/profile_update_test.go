@@ -0,0 +1,86 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestUpdateProfileChangesSettings(t *testing.T) {
+	srv := newServiceTestServer(t)
+	if err := saveProfileSecrets("alpha", map[string]string{"JWT_SECRET": "keep-me-keep-me-keep-me-keep-me-keep"}); err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	body := `{"ports":[{"container":3000,"host":` + strconv.Itoa(port) + `}],"resources":{"limits":{"memory":"2gb","cpus":1.5}},"env":{"APP_DOMAIN":"shop.example.com","JWT_SECRET":""}}`
+	req := httptest.NewRequest(http.MethodPut, "/api/profiles/alpha", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"1"`)
+	rec := httptest.NewRecorder()
+	srv.handleProfileAction(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var payload struct {
+		Profile ProfileRequest `json:"profile"`
+		JobID   string         `json:"jobId"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+		t.Fatal(err)
+	}
+	p := payload.Profile
+	if profilePort(p) != port || p.Resources.Limits.Memory != "2gb" || p.Resources.Limits.CPUs != 1.5 || p.Env["APP_DOMAIN"] != "shop.example.com" || p.Revision != 2 {
+		t.Fatalf("unexpected profile after update: %+v", p)
+	}
+	if payload.JobID != "" {
+		t.Fatalf("a stopped profile should not be restarted, got job %s", payload.JobID)
+	}
+	if _, leaked := p.Env["JWT_SECRET"]; leaked {
+		t.Fatal("secrets must stay out of profiles.json")
+	}
+	if got := loadProfileSecrets("alpha")["JWT_SECRET"]; got != "keep-me-keep-me-keep-me-keep-me-keep" {
+		t.Fatalf("an empty secret should keep the stored one, got %q", got)
+	}
+
+	// Sending the same settings again changes nothing.
+	same, job, err := srv.Profiles().Update(context.Background(), "alpha", p, 0)
+	if err != nil || job != nil || same.Revision != 2 {
+		t.Fatalf("expected a no-op update, got revision %d, job %v, err %v", same.Revision, job, err)
+	}
+}
+
+func TestUpdateProfileRevalidates(t *testing.T) {
+	srv := newServiceTestServer(t)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/profiles/alpha", strings.NewReader(`{"resources":{"limits":{"memory":"lots"}}}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	srv.handleProfileAction(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"field":"memory"`) {
+		t.Fatalf("expected a memory validation error, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/api/profiles/alpha", strings.NewReader(`{"ports":[{"container":3000,"host":80}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	srv.handleProfileAction(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "port.reserved") {
+		t.Fatalf("expected a reserved port error, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	srv.activeProfiles["alpha"] = "job-1"
+	if _, _, err := srv.Profiles().Update(context.Background(), "alpha", ProfileRequest{}, 0); err == nil || !strings.Contains(err.Error(), "job-1") {
+		t.Fatalf("expected the profile to be busy, got %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Docker has no bandwidth limit for pulls, so with pullBandwidthMbps set
+// the launcher pulls Docker Hub images through a small registry proxy of
+// its own on 127.0.0.1, which the daemon trusts without TLS. The proxy
+// fetches from Docker Hub, following blob redirects to the CDN itself, and
+// hands the bytes on no faster than the limit, shared by all pulls. The
+// image is then tagged under its usual name.
+//
+// This needs a daemon on this computer: Docker Desktop's VM and rootless
+// Docker cannot reach the launcher's loopback address. Where the proxied
+// pull fails, the image is pulled directly at full speed.
+
+var registryUpstream = "https://registry-1.docker.io"
+
+// pullLimitChunk is the most the proxy sends before waiting for the limit.
+const pullLimitChunk = 32 << 10
+
+// pullBytesPerSecond is the pull bandwidth limit, or 0 for none.
+func pullBytesPerSecond() int64 {
+	st := liveSettings.Load()
+	if st == nil || st.PullBandwidthMbps <= 0 {
+		return 0
+	}
+	return int64(st.PullBandwidthMbps) * 1_000_000 / 8
+}
+
+// bandwidthLimiter spaces out reads so their total stays under the limit.
+// It keeps no credit for idle time, so a new pull does not start with a
+// burst.
+type bandwidthLimiter struct {
+	mu   sync.Mutex
+	next time.Time
+}
+
+var pullLimiter = &bandwidthLimiter{}
+
+func (l *bandwidthLimiter) wait(ctx context.Context, n int) error {
+	rate := pullBytesPerSecond()
+	if rate <= 0 || n <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	l.next = l.next.Add(time.Duration(float64(n) / float64(rate) * float64(time.Second)))
+	delay := l.next.Sub(now)
+	l.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	return sleepContext(ctx, delay)
+}
+
+type limitedReader struct {
+	ctx context.Context
+	r   io.Reader
+	l   *bandwidthLimiter
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	if len(p) > pullLimitChunk {
+		p = p[:pullLimitChunk]
+	}
+	n, err := lr.r.Read(p)
+	if waitErr := lr.l.wait(lr.ctx, n); waitErr != nil && err == nil {
+		err = waitErr
+	}
+	return n, err
+}
+
+// pullLimitAvailable reports whether the daemon can reach a proxy on this
+// computer's loopback address.
+func pullLimitAvailable() bool {
+	if runtime.GOOS != "linux" {
+		return false
+	}
+	host := effectiveDockerHost()
+	return strings.HasPrefix(host, "unix://") && !strings.Contains(host, "/.docker/desktop/") && !strings.HasPrefix(host, "unix:///run/user/")
+}
+
+// proxiedImageRef is image as pulled through the proxy at addr, or "" for
+// images not on Docker Hub.
+func proxiedImageRef(addr, image string) string {
+	first, _, hasSlash := strings.Cut(image, "/")
+	if hasSlash && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return ""
+	}
+	if !hasSlash {
+		image = "library/" + image
+	}
+	return addr + "/" + image
+}
+
+// registryProxy forwards registry requests to the upstream registry.
+type registryProxy struct {
+	client *http.Client
+}
+
+func (p *registryProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, registryUpstream+r.URL.RequestURI(), nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, h := range []string{"Accept", "Authorization", "Range", "User-Agent"} {
+		for _, v := range r.Header.Values(h) {
+			req.Header.Add(h, v)
+		}
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	for _, h := range []string{"Content-Type", "Content-Length", "Content-Range", "Docker-Content-Digest", "Docker-Distribution-Api-Version", "WWW-Authenticate", "Etag"} {
+		if v := resp.Header.Get(h); v != "" {
+			w.Header().Set(h, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, &limitedReader{ctx: r.Context(), r: resp.Body, l: pullLimiter})
+}
+
+var (
+	pullProxyOnce sync.Once
+	pullProxyHost string
+	pullProxyErr  error
+)
+
+// pullProxyAddr starts the registry proxy on first use and returns its
+// host:port.
+func pullProxyAddr() (string, error) {
+	pullProxyOnce.Do(func() {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			pullProxyErr = err
+			return
+		}
+		pullProxyHost = ln.Addr().String()
+		srv := &http.Server{Handler: &registryProxy{client: &http.Client{}}, ReadHeaderTimeout: 10 * time.Second}
+		go func() { _ = srv.Serve(ln) }()
+		logInfo("pull_proxy_started", map[string]any{"addr": pullProxyHost})
+	})
+	return pullProxyHost, pullProxyErr
+}
+
+// pullThroughProxy pulls image through the rate-limited proxy and tags it
+// under its own name. It returns errPullLimitUnavailable when the image
+// has to be pulled directly.
+func pullThroughProxy(ctx context.Context, dockerBin, image, platform string, onLine func(string)) (string, error) {
+	if pullBytesPerSecond() <= 0 || !pullLimitAvailable() {
+		return "", errPullLimitUnavailable
+	}
+	addr, err := pullProxyAddr()
+	if err != nil {
+		return "", errPullLimitUnavailable
+	}
+	ref := proxiedImageRef(addr, image)
+	if ref == "" {
+		return "", errPullLimitUnavailable
+	}
+	out, err := runDockerPullRef(ctx, dockerBin, ref, platform, onLine)
+	if err != nil {
+		return out, err
+	}
+	if engine, err := newDockerEngine(); err == nil {
+		if err := engine.tagImage(ctx, ref, image); err != nil {
+			return out, err
+		}
+		// Only the proxy's tag goes; the image stays under its own name.
+		_ = engine.removeImage(ctx, ref)
+		return out, nil
+	}
+	if tagOut, err := dockerCommandWithContext(ctx, dockerBin, "tag", ref, image).CombinedOutput(); err != nil {
+		return string(tagOut), err
+	}
+	_ = dockerCommandWithContext(ctx, dockerBin, "image", "rm", ref).Run()
+	return out, nil
+}
+
+var errPullLimitUnavailable = errors.New("pull bandwidth limit unavailable")
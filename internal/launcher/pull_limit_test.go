@@ -0,0 +1,86 @@
+package launcher
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProxiedImageRef(t *testing.T) {
+	cases := map[string]string{
+		"redis:7.2":                  "127.0.0.1:5000/library/redis:7.2",
+		"kimmio/kimmio-app:1.0.0":    "127.0.0.1:5000/kimmio/kimmio-app:1.0.0",
+		"ghcr.io/acme/app:1":         "",
+		"localhost:5000/acme/app:1":  "",
+		"registry.local/acme/app:v2": "",
+	}
+	for image, want := range cases {
+		if got := proxiedImageRef("127.0.0.1:5000", image); got != want {
+			t.Fatalf("%s: expected %q, got %q", image, want, got)
+		}
+	}
+}
+
+func TestPullBandwidthLimit(t *testing.T) {
+	defer publishSettings(defaultSettings())
+	st := defaultSettings()
+	st.PullBandwidthMbps = 8 // 1 MB/s
+	publishSettings(st)
+
+	l := &bandwidthLimiter{}
+	started := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.wait(context.Background(), 50_000); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(started); elapsed < 90*time.Millisecond {
+		t.Fatalf("expected 150 KB to take about 150ms at 1 MB/s, took %s", elapsed)
+	}
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/library/redis/blobs/sha256:abc":
+			if r.Header.Get("Authorization") != "Bearer pull-token" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="https://auth.example.com/token"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			http.Redirect(w, r, "/cdn/abc", http.StatusTemporaryRedirect)
+		case "/cdn/abc":
+			w.Header().Set("Docker-Content-Digest", "sha256:abc")
+			_, _ = io.WriteString(w, "layer-bytes")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+	defer func(orig string) { registryUpstream = orig }(registryUpstream)
+	registryUpstream = upstream.URL
+	proxy := httptest.NewServer(&registryProxy{client: upstream.Client()})
+	defer proxy.Close()
+
+	resp, err := http.Get(proxy.URL + "/v2/library/redis/blobs/sha256:abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized || !strings.Contains(resp.Header.Get("WWW-Authenticate"), "auth.example.com") {
+		t.Fatalf("expected the auth challenge passed on, got %d", resp.StatusCode)
+	}
+	req, _ := http.NewRequest(http.MethodGet, proxy.URL+"/v2/library/redis/blobs/sha256:abc", nil)
+	req.Header.Set("Authorization", "Bearer pull-token")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "layer-bytes" || resp.Header.Get("Docker-Content-Digest") != "sha256:abc" {
+		t.Fatalf("expected the proxy to follow the blob redirect, got %d %q", resp.StatusCode, body)
+	}
+}
@@ -0,0 +1,118 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// A pull policy decides whether starting a profile pulls the kimmio-app
+// image first:
+//
+//	always          pull unless the local image already has the registry's
+//	                digest (the default)
+//	if-not-present  pull only when the image is not on this computer
+//	never           never pull; a missing image fails the start
+//
+// A profile sets a default and may override it for enable, update and
+// recreate; other actions that start containers use the default.
+
+const (
+	pullAlways       = "always"
+	pullIfNotPresent = "if-not-present"
+	pullNever        = "never"
+)
+
+// PullPolicy is a profile's pull policy per action; empty fields fall back
+// to Default, and an empty Default is always.
+type PullPolicy struct {
+	Default  string `json:"default,omitempty"`
+	Enable   string `json:"enable,omitempty"`
+	Update   string `json:"update,omitempty"`
+	Recreate string `json:"recreate,omitempty"`
+}
+
+func (p PullPolicy) forAction(action string) string {
+	policy := ""
+	switch action {
+	case "enable":
+		policy = p.Enable
+	case "version":
+		policy = p.Update
+	case "recreate":
+		policy = p.Recreate
+	}
+	if policy == "" {
+		policy = p.Default
+	}
+	if policy == "" {
+		policy = pullAlways
+	}
+	return policy
+}
+
+func normalizePullPolicy(p *PullPolicy) error {
+	fields := []struct {
+		name string
+		v    *string
+	}{
+		{"pullPolicy", &p.Default}, {"pullPolicyEnable", &p.Enable},
+		{"pullPolicyUpdate", &p.Update}, {"pullPolicyRecreate", &p.Recreate},
+	}
+	for _, f := range fields {
+		*f.v = strings.ToLower(strings.TrimSpace(*f.v))
+		switch *f.v {
+		case "", pullAlways, pullIfNotPresent, pullNever:
+		default:
+			return fieldError(f.name, "pull.policy", "pull policy must be always, if-not-present or never")
+		}
+	}
+	return nil
+}
+
+// composeUpArgs are the compose arguments that start a profile's stack. With
+// the never policy compose may not pull the other services' images either;
+// otherwise it keeps its default of pulling only missing ones, and older
+// compose releases without --pull keep working.
+func composeUpArgs(project, policy string) []string {
+	args := []string{"-p", project, "-f", "compose.yaml", "up", "-d", "--build"}
+	if policy == pullNever {
+		args = append(args, "--pull", "never")
+	}
+	return args
+}
+
+// localImageExists reports whether image is on this computer; var for
+// tests.
+var localImageExists = func(ctx context.Context, image string) bool {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if engine, err := newDockerEngine(); err == nil {
+		_, err := engine.inspectImage(ctx, image)
+		return err == nil
+	}
+	dockerBin, err := dockerBinaryPath()
+	if err != nil {
+		return false
+	}
+	return dockerCommandWithContext(ctx, dockerBin, "image", "inspect", "--format", "{{.Id}}", image).Run() == nil
+}
+
+// skipImagePull applies policy before a pull. It returns a message when
+// the pull is not needed, or an error when the image is missing and the
+// policy forbids pulling it.
+func skipImagePull(ctx context.Context, policy, image string) (string, error) {
+	switch policy {
+	case pullNever:
+		if !localImageExists(ctx, image) {
+			return "", errors.New("image " + image + " is not on this computer and the pull policy is never")
+		}
+		return "Using local image " + image + " (pull policy never)", nil
+	case pullIfNotPresent:
+		if localImageExists(ctx, image) {
+			return "Using local image " + image + " (pull policy if-not-present)", nil
+		}
+	}
+	return "", nil
+}
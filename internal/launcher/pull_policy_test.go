@@ -0,0 +1,56 @@
+package launcher
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestPullPolicy(t *testing.T) {
+	policy := PullPolicy{Default: " If-Not-Present ", Update: "ALWAYS"}
+	if err := normalizePullPolicy(&policy); err != nil {
+		t.Fatal(err)
+	}
+	for action, want := range map[string]string{"enable": pullIfNotPresent, "version": pullAlways, "recreate": pullIfNotPresent, "regenerate-secrets": pullIfNotPresent} {
+		if got := policy.forAction(action); got != want {
+			t.Fatalf("%s: expected %s, got %s", action, want, got)
+		}
+	}
+	if got := (PullPolicy{}).forAction("enable"); got != pullAlways {
+		t.Fatalf("expected always by default, got %s", got)
+	}
+	if err := normalizePullPolicy(&PullPolicy{Enable: "sometimes"}); err == nil {
+		t.Fatal("expected an unknown policy to be rejected")
+	}
+
+	if args := composeUpArgs("kimmio-alpha", pullNever); !slices.Contains(args, "never") {
+		t.Fatalf("expected compose to be kept from pulling, got %v", args)
+	}
+	if args := composeUpArgs("kimmio-alpha", pullAlways); slices.Contains(args, "--pull") {
+		t.Fatalf("expected compose defaults for other policies, got %v", args)
+	}
+}
+
+func TestSkipImagePull(t *testing.T) {
+	defer func(orig func(context.Context, string) bool) { localImageExists = orig }(localImageExists)
+	ctx := context.Background()
+	image := "kimmio/kimmio-app:1.0.0"
+
+	localImageExists = func(context.Context, string) bool { return false }
+	if msg, err := skipImagePull(ctx, pullIfNotPresent, image); err != nil || msg != "" {
+		t.Fatalf("expected a missing image pulled, got %q %v", msg, err)
+	}
+	if _, err := skipImagePull(ctx, pullNever, image); err == nil {
+		t.Fatal("expected never to fail without a local image")
+	}
+
+	localImageExists = func(context.Context, string) bool { return true }
+	for _, policy := range []string{pullIfNotPresent, pullNever} {
+		if msg, err := skipImagePull(ctx, policy, image); err != nil || msg == "" {
+			t.Fatalf("%s: expected the local image used, got %q %v", policy, msg, err)
+		}
+	}
+	if msg, _ := skipImagePull(ctx, pullAlways, image); msg != "" {
+		t.Fatalf("expected always to go on to the digest check, got %q", msg)
+	}
+}
@@ -0,0 +1,311 @@
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxPullStats = 20
+
+// PullStat records one completed image pull. Bytes is the compressed size
+// the registry reports for the host platform; it is zero when the registry
+// could not be reached.
+type PullStat struct {
+	Version    string `json:"version"`
+	Bytes      int64  `json:"bytes"`
+	DurationMs int64  `json:"durationMs"`
+	PulledAt   string `json:"pulledAt"`
+}
+
+// pullProgress follows the layer status lines docker pull prints when its
+// output is not a terminal ("<id>: Pull complete", "<id>: Already exists").
+// Lines with sizes ("<id>: Downloading 12.5 MiB/45.2 MiB"), as the Engine
+// API pull reports them, also move a layer along while it downloads.
+type pullProgress struct {
+	layers   map[string]bool
+	sizes    map[string]*layerTransfer
+	existing int
+	upToDate bool
+	// percent is the overall progress when parse last reported a change.
+	percent int
+}
+
+// layerTransfer is how far one layer got: the fractions downloaded and
+// extracted, and its compressed size once known.
+type layerTransfer struct {
+	size      int64
+	download  float64
+	extracted float64
+}
+
+const (
+	// A layer counts as this much done once downloaded; extracting is the
+	// rest.
+	layerDownloadShare = 0.7
+	// pullReportStep is how many percent a pull advances between reports
+	// driven by sizes.
+	pullReportStep = 5
+)
+
+func newPullProgress() *pullProgress {
+	return &pullProgress{layers: map[string]bool{}, sizes: map[string]*layerTransfer{}}
+}
+
+// parse consumes one output line and reports whether layer counts or the
+// overall percentage changed.
+func (p *pullProgress) parse(line string) bool {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "Status: Image is up to date") {
+		p.upToDate = true
+		return false
+	}
+	id, status, ok := strings.Cut(line, ": ")
+	if !ok || strings.ContainsAny(id, " /") {
+		return false
+	}
+	changed := false
+	switch {
+	case status == "Pulling fs layer", status == "Waiting":
+		if _, known := p.layers[id]; !known {
+			p.layers[id] = false
+			changed = true
+		}
+	case status == "Already exists":
+		p.layers[id] = true
+		p.existing++
+		changed = true
+	case status == "Pull complete":
+		p.layers[id] = true
+		changed = true
+	case status == "Verifying Checksum", status == "Download complete":
+		p.transfer(id).download = 1
+	case strings.HasPrefix(status, "Downloading"):
+		if current, total, ok := parseTransferSizes(status); ok {
+			t := p.transfer(id)
+			t.size = total
+			t.download = min(float64(current)/float64(total), 1)
+		}
+	case strings.HasPrefix(status, "Extracting"):
+		if current, total, ok := parseTransferSizes(status); ok {
+			t := p.transfer(id)
+			t.download = 1
+			t.extracted = min(float64(current)/float64(total), 1)
+		}
+	}
+	// Sizes alone report every few percent, so the job log is not flooded.
+	if percent := int(p.fraction() * 100); changed || percent >= p.percent+pullReportStep {
+		p.percent = percent
+		changed = true
+	}
+	return changed
+}
+
+func (p *pullProgress) transfer(id string) *layerTransfer {
+	if _, known := p.layers[id]; !known {
+		p.layers[id] = false
+	}
+	t, ok := p.sizes[id]
+	if !ok {
+		t = &layerTransfer{}
+		p.sizes[id] = t
+	}
+	return t
+}
+
+// fraction estimates how much of the pull is done, from 0 to 1. Layers
+// weigh by their size; those whose size is not known yet weigh as much as
+// the average known layer.
+func (p *pullProgress) fraction() float64 {
+	if len(p.layers) == 0 {
+		return 0
+	}
+	var known int64
+	sized := 0
+	for _, t := range p.sizes {
+		if t.size > 0 {
+			known += t.size
+			sized++
+		}
+	}
+	fallback := 1.0
+	if sized > 0 {
+		fallback = float64(known) / float64(sized)
+	}
+	var done, total float64
+	for id, complete := range p.layers {
+		weight := fallback
+		progress := 0.0
+		if t, ok := p.sizes[id]; ok {
+			if t.size > 0 {
+				weight = float64(t.size)
+			}
+			progress = layerDownloadShare*t.download + (1-layerDownloadShare)*t.extracted
+		}
+		if complete {
+			progress = 1
+		}
+		done += weight * progress
+		total += weight
+	}
+	return done / total
+}
+
+// within maps the pull's progress onto the job's range start..end.
+func (p *pullProgress) within(start, end int) int {
+	return start + int(p.fraction()*float64(end-start))
+}
+
+// describe summarizes the pull for a job step, e.g. "3/7 layers, 120.0 MiB
+// of 300.0 MiB".
+func (p *pullProgress) describe() string {
+	done, total := p.counts()
+	text := fmt.Sprintf("%d/%d layers", done, total)
+	var got, size int64
+	for id, t := range p.sizes {
+		if t.size <= 0 {
+			continue
+		}
+		size += t.size
+		if p.layers[id] {
+			got += t.size
+		} else {
+			got += int64(t.download * float64(t.size))
+		}
+	}
+	if size > 0 {
+		text += fmt.Sprintf(", %s of %s", formatBytes(got), formatBytes(size))
+	}
+	return text
+}
+
+// parseTransferSizes reads the "<current>/<total>" sizes that end a
+// Downloading or Extracting status, in the form the Engine API pull writes
+// ("12.5 MiB/45.2 MiB") or a docker pull progress bar ("12.5MB/45.2MB").
+func parseTransferSizes(status string) (current, total int64, ok bool) {
+	if i := strings.LastIndex(status, "]"); i >= 0 {
+		status = status[i+1:]
+	} else if _, rest, found := strings.Cut(status, " "); found {
+		status = rest
+	} else {
+		return 0, 0, false
+	}
+	a, b, found := strings.Cut(status, "/")
+	if !found {
+		return 0, 0, false
+	}
+	current, okA := parseByteSize(a)
+	total, okB := parseByteSize(b)
+	if !okA || !okB || total <= 0 {
+		return 0, 0, false
+	}
+	return current, total, true
+}
+
+var byteSizeUnits = map[string]float64{
+	"b": 1, "kb": 1e3, "mb": 1e6, "gb": 1e9, "tb": 1e12,
+	"kib": 1 << 10, "mib": 1 << 20, "gib": 1 << 30, "tib": 1 << 40,
+}
+
+// parseByteSize reads sizes such as "512B", "12.5MB" or "1.2 GiB".
+func parseByteSize(s string) (int64, bool) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), " ", "")
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i <= 0 {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(s[:i], 64)
+	unit, known := byteSizeUnits[strings.ToLower(s[i:])]
+	if err != nil || !known {
+		return 0, false
+	}
+	return int64(n * unit), true
+}
+
+func (p *pullProgress) counts() (done, total int) {
+	for _, complete := range p.layers {
+		if complete {
+			done++
+		}
+	}
+	return done, len(p.layers)
+}
+
+func (s *Server) recordPullStat(ctx context.Context, stat PullStat) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	store, err := s.loadStoreLocked(ctx)
+	if err != nil {
+		logWarn("pull_stat_record_failed", map[string]any{"error": err.Error()})
+		return
+	}
+	store.PullStats = append(store.PullStats, stat)
+	if len(store.PullStats) > maxPullStats {
+		store.PullStats = store.PullStats[len(store.PullStats)-maxPullStats:]
+	}
+	if err := s.writeStoreLocked(store); err != nil {
+		logWarn("pull_stat_record_failed", map[string]any{"error": err.Error()})
+	}
+}
+
+// estimatePullDuration predicts how long pulling bytes will take from the
+// throughput of earlier pulls, falling back to the median duration of
+// earlier pulls of the same version. It returns zero without history.
+func estimatePullDuration(stats []PullStat, version string, bytes int64) time.Duration {
+	var totalBytes, totalMs int64
+	var sameVersion []int64
+	for _, st := range stats {
+		if st.DurationMs <= 0 {
+			continue
+		}
+		if st.Bytes > 0 {
+			totalBytes += st.Bytes
+			totalMs += st.DurationMs
+		}
+		if st.Version == version {
+			sameVersion = append(sameVersion, st.DurationMs)
+		}
+	}
+	if bytes > 0 && totalBytes > 0 {
+		return time.Duration(float64(bytes) / float64(totalBytes) * float64(totalMs) * float64(time.Millisecond))
+	}
+	if len(sameVersion) > 0 {
+		sort.Slice(sameVersion, func(i, j int) bool { return sameVersion[i] < sameVersion[j] })
+		return time.Duration(sameVersion[len(sameVersion)/2]) * time.Millisecond
+	}
+	return 0
+}
+
+func formatETA(d time.Duration) string {
+	if d < time.Minute {
+		return "under a minute"
+	}
+	return fmt.Sprintf("about %d min", int((d+30*time.Second)/time.Minute))
+}
+
+// pullStatsSummary is the pull history shown in the system info API.
+func pullStatsSummary(stats []PullStat) map[string]any {
+	var totalBytes, totalMs int64
+	for _, st := range stats {
+		if st.Bytes > 0 && st.DurationMs > 0 {
+			totalBytes += st.Bytes
+			totalMs += st.DurationMs
+		}
+	}
+	var bytesPerSecond int64
+	if totalMs > 0 {
+		bytesPerSecond = totalBytes * 1000 / totalMs
+	}
+	recent := append([]PullStat{}, stats...)
+	if len(recent) > 5 {
+		recent = recent[len(recent)-5:]
+	}
+	return map[string]any{
+		"count":          len(stats),
+		"bytesPerSecond": bytesPerSecond,
+		"recent":         recent,
+	}
+}
@@ -0,0 +1,95 @@
+package launcher
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPullProgressParse(t *testing.T) {
+	p := newPullProgress()
+	for _, line := range []string{
+		"1.0.0: Pulling from kimmio/kimmio-app",
+		"a1b2: Already exists",
+		"c3d4: Pulling fs layer",
+		"e5f6: Pulling fs layer",
+		"c3d4: Verifying Checksum",
+		"c3d4: Download complete",
+		"c3d4: Pull complete",
+		"Digest: sha256:abc",
+	} {
+		p.parse(line)
+	}
+	if done, total := p.counts(); done != 2 || total != 3 {
+		t.Fatalf("expected 2/3 layers, got %d/%d", done, total)
+	}
+	if p.existing != 1 || p.upToDate {
+		t.Fatalf("unexpected progress state: %+v", p)
+	}
+	p.parse("Status: Image is up to date for kimmio/kimmio-app:1.0.0")
+	if !p.upToDate {
+		t.Fatalf("expected up-to-date status")
+	}
+}
+
+func TestEstimatePullDuration(t *testing.T) {
+	stats := []PullStat{
+		{Version: "1.0.0", Bytes: 100 << 20, DurationMs: 10_000},
+		{Version: "1.0.1", Bytes: 300 << 20, DurationMs: 30_000},
+	}
+	if got := estimatePullDuration(stats, "2.0.0", 200<<20); got != 20*time.Second {
+		t.Fatalf("expected throughput-based ETA of 20s, got %s", got)
+	}
+	noBytes := []PullStat{{Version: "1.0.0", DurationMs: 90_000}, {Version: "1.0.0", DurationMs: 60_000}, {Version: "1.0.0", DurationMs: 120_000}}
+	if got := estimatePullDuration(noBytes, "1.0.0", 0); got != 90*time.Second {
+		t.Fatalf("expected median duration of 90s, got %s", got)
+	}
+	if got := estimatePullDuration(nil, "1.0.0", 100); got != 0 {
+		t.Fatalf("expected no ETA without history, got %s", got)
+	}
+}
+
+func TestRecordPullStatKeepsRecentHistory(t *testing.T) {
+	srv := newServiceTestServer(t)
+	for i := 0; i < maxPullStats+3; i++ {
+		srv.recordPullStat(context.Background(), PullStat{Version: "1.0.0", DurationMs: int64(i + 1)})
+	}
+	store, err := srv.readStore(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(store.PullStats) != maxPullStats || store.PullStats[0].DurationMs != 4 {
+		t.Fatalf("expected the last %d pulls, got %d starting at %d", maxPullStats, len(store.PullStats), store.PullStats[0].DurationMs)
+	}
+	if len(store.Profiles) != 1 {
+		t.Fatalf("expected profiles to be preserved")
+	}
+}
+
+func TestPullProgressTracksBytes(t *testing.T) {
+	p := newPullProgress()
+	p.parse("a1b2: Pulling fs layer")
+	p.parse("c3d4: Pulling fs layer")
+	if !p.parse("a1b2: Downloading 50.0 MiB/100.0 MiB") {
+		t.Fatalf("expected a size update to be reported")
+	}
+	if p.parse("a1b2: Downloading 50.5 MiB/100.0 MiB") {
+		t.Fatalf("expected a small step not to be reported")
+	}
+	// c3d4's size is unknown, so it weighs as much as a1b2.
+	if got := p.within(0, 100); got != 17 {
+		t.Fatalf("expected 17%%, got %d", got)
+	}
+	p.parse("c3d4: Downloading [=====>        ]  10MB/20MB")
+	p.parse("a1b2: Extracting 100.0 MiB/100.0 MiB")
+	p.parse("a1b2: Pull complete")
+	if got, want := p.fraction(), (float64(100<<20)+0.7*0.5*20e6)/(float64(100<<20)+20e6); got < want-0.001 || got > want+0.001 {
+		t.Fatalf("expected fraction %.3f, got %.3f", want, got)
+	}
+	if got := p.describe(); got != "1/2 layers, 109.5 MiB of 119.1 MiB" {
+		t.Fatalf("unexpected description %q", got)
+	}
+	if _, _, ok := parseTransferSizes("Downloading"); ok {
+		t.Fatalf("expected no sizes without a progress detail")
+	}
+}
@@ -0,0 +1,118 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+)
+
+// With a reconcile file set in Settings, the launcher applies it on its own
+// every reconcileInterval, so profiles drifting from the file (containers
+// stopped outside the launcher, a version changed in the UI) are put back.
+// Store edits run inline; every profile action is started as a job, one per
+// profile per pass, and the pass after it finishes picks up the next step.
+
+const reconcileInterval = time.Minute
+
+// dockerReady reports whether the Docker daemon answers; tests replace it.
+var dockerReady = func() bool { return IsDockerRunning() == "installed" }
+
+func (s *Server) startReconciler(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		lastErr := ""
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			msg := ""
+			if err := s.reconcileOnce(ctx); err != nil {
+				msg = err.Error()
+			}
+			// A broken file would otherwise be reported every pass.
+			if msg != "" && msg != lastErr {
+				auditLog("WARN", "reconcile_failed", map[string]any{"file": s.settings.get().ReconcileFile, "error": msg})
+			}
+			lastErr = msg
+		}
+	}()
+}
+
+// reconcileOnce runs one pass over the reconcile file. Passes are skipped
+// while Docker is unreachable, as every profile would look stopped.
+func (s *Server) reconcileOnce(ctx context.Context) error {
+	path := s.settings.get().ReconcileFile
+	if path == "" {
+		return nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	specs, err := parseApplyFile(raw)
+	if err != nil {
+		return err
+	}
+	if !dockerReady() {
+		return nil
+	}
+	current, err := s.applyState(ctx)
+	if err != nil {
+		return err
+	}
+	steps, err := planApply(specs, current)
+	if err != nil {
+		return err
+	}
+	busy := map[string]bool{}
+	profiles := map[string]ProfileRequest{}
+	for _, p := range current {
+		busy[p.ID] = p.ActiveJobID != ""
+		profiles[p.ID] = p
+	}
+	now := time.Now()
+	var errs []error
+	for _, st := range steps {
+		if busy[st.ProfileID] {
+			continue
+		}
+		fields := map[string]any{"profile": st.ProfileID, "action": st.Action, "detail": st.Detail}
+		if st.Action == "create" || st.Action == "configure" {
+			if err := s.runApplyStep(ctx, st); err != nil {
+				fields["error"] = err.Error()
+				auditLog("WARN", "reconcile_step_failed", fields)
+				errs = append(errs, err)
+				busy[st.ProfileID] = true
+				continue
+			}
+			auditLog("INFO", "reconcile_step_applied", fields)
+			continue
+		}
+		// Jobs wait for the maintenance window; a later pass starts them.
+		if !maintenanceAllows(profiles[st.ProfileID], now) {
+			continue
+		}
+		busy[st.ProfileID] = true
+		version := ""
+		if st.Action == "version" {
+			version = st.spec.Version
+		}
+		job, err := s.Profiles().StartAction(ctx, st.ProfileID, st.Action, version, 0, true)
+		if errors.Is(err, ErrProfileBusy) {
+			continue
+		}
+		if err != nil {
+			fields["error"] = err.Error()
+			auditLog("WARN", "reconcile_step_failed", fields)
+			errs = append(errs, err)
+			continue
+		}
+		fields["job_id"] = job.ID
+		auditLog("INFO", "reconcile_job_started", fields)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,117 @@
+package launcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReconcileCorrectsDrift(t *testing.T) {
+	srv := newServiceTestServer(t)
+	defer publishSettings(defaultSettings())
+	defer func(orig func() bool) { dockerReady = orig }(dockerReady)
+	dockerReady = func() bool { return true }
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "profiles.yaml")
+	spec := "profiles:\n  - id: alpha\n    version: 1.1.0\n    env:\n      APP_DOMAIN: shop.example.com\n"
+	if err := os.WriteFile(path, []byte(spec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	relative := "profiles.yaml"
+	if _, err := srv.settings.update(SettingsPatch{ReconcileFile: &relative}); err == nil {
+		t.Fatal("expected a relative reconcile file to be rejected")
+	}
+	if _, err := srv.settings.update(SettingsPatch{ReconcileFile: &path}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := srv.reconcileOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	alpha, err := srv.Profiles().Get(ctx, "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alpha.Env["APP_DOMAIN"] != "shop.example.com" {
+		t.Fatalf("expected the env applied inline, got %v", alpha.Env)
+	}
+	jobID := alpha.ActiveJobID
+	if jobID == "" {
+		t.Fatal("expected the version change to run as a job")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := srv.Jobs().Get(jobID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.FinishedAt != "" {
+			if job.Action != "version" || job.Status != "succeeded" {
+				t.Fatalf("unexpected job %+v", job)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s did not finish", jobID)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	current, err := srv.applyState(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	specs, _ := parseApplyFile([]byte(spec))
+	if steps, err := planApply(specs, current); err != nil || len(steps) != 0 {
+		t.Fatalf("expected no drift left, got %v %v", steps, err)
+	}
+}
+
+func TestPlanApplyRestartsStoppedContainers(t *testing.T) {
+	current := []ProfileRequest{{ID: "alpha", Version: "1.0.0", Enabled: true, RuntimeStatus: "unhealthy", Services: []ServiceState{{Service: "app", State: "exited"}}}}
+	steps, err := planApply([]ApplySpec{{ID: "alpha"}}, current)
+	if err != nil || len(steps) != 1 || steps[0].String() != "alpha: enable (containers stopped)" {
+		t.Fatalf("expected the stopped profile started, got %v %v", steps, err)
+	}
+	current[0].RuntimeStatus = runtimeCrashLooping
+	if steps, _ := planApply([]ApplySpec{{ID: "alpha"}}, current); len(steps) != 0 {
+		t.Fatalf("expected a crash-looping profile left alone, got %v", steps)
+	}
+}
+
+func TestReconcileWaitsForMaintenanceWindow(t *testing.T) {
+	srv := newServiceTestServer(t)
+	defer publishSettings(defaultSettings())
+	defer func(orig func() bool) { dockerReady = orig }(dockerReady)
+	dockerReady = func() bool { return true }
+	prev := appCfg.MaintenanceWindow
+	defer func() { appCfg.MaintenanceWindow = prev }()
+	closed := time.Now().Add(2 * time.Hour)
+	appCfg.MaintenanceWindow = closed.Format("15:04") + "-" + closed.Add(time.Minute).Format("15:04")
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "profiles.yaml")
+	spec := "profiles:\n  - id: alpha\n    version: 1.1.0\n    env:\n      APP_DOMAIN: shop.example.com\n"
+	if err := os.WriteFile(path, []byte(spec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.settings.update(SettingsPatch{ReconcileFile: &path}); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.reconcileOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	alpha, err := srv.Profiles().Get(ctx, "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alpha.Env["APP_DOMAIN"] != "shop.example.com" {
+		t.Fatalf("expected the env applied inline, got %v", alpha.Env)
+	}
+	if alpha.ActiveJobID != "" || alpha.Version != "1.0.0" {
+		t.Fatalf("expected the version change held for the window, got job %q version %s", alpha.ActiveJobID, alpha.Version)
+	}
+}
@@ -0,0 +1,50 @@
+package launcher
+
+import "sort"
+
+// wellKnownDevPorts are ports local dev servers and databases commonly
+// bind (Node, Vite, Angular, Django, Jupyter, debuggers, ...). Automatic
+// assignment skips them so a profile does not grab a port another project
+// expects to find free later.
+var wellKnownDevPorts = []int{
+	3000, 3001, 4000, 4200, 5000, 5173, 5432, 6379, 8000, 8008, 8081,
+	8443, 8888, 9000, 9090, 9229, 27017,
+}
+
+// reservedPorts are ports profiles may never use: the launcher's own
+// listeners plus KIMMIO_RESERVED_PORTS.
+func reservedPorts() map[int]bool {
+	reserved := map[int]bool{appCfg.ListenPort: true}
+	if appCfg.GRPCPort > 0 {
+		reserved[appCfg.GRPCPort] = true
+	}
+	for _, p := range appCfg.ReservedPorts {
+		reserved[p] = true
+	}
+	return reserved
+}
+
+// avoidForAutoAssign reports whether nextAvailablePort should skip port.
+func avoidForAutoAssign(port int, reserved map[int]bool) bool {
+	if reserved[port] {
+		return true
+	}
+	if !appCfg.AvoidDevPorts {
+		return false
+	}
+	for _, p := range wellKnownDevPorts {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedPorts(set map[int]bool) []int {
+	out := make([]int, 0, len(set))
+	for p := range set {
+		out = append(out, p)
+	}
+	sort.Ints(out)
+	return out
+}
@@ -0,0 +1,27 @@
+package launcher
+
+import "testing"
+
+func TestAvoidForAutoAssign(t *testing.T) {
+	prev := appCfg
+	defer func() { appCfg = prev }()
+	appCfg.ListenPort = 7000
+	appCfg.GRPCPort = 0
+	appCfg.ReservedPorts = []int{8085}
+	appCfg.AvoidDevPorts = true
+
+	reserved := reservedPorts()
+	if !reserved[7000] || !reserved[8085] {
+		t.Fatalf("expected listen and configured ports reserved, got %v", sortedPorts(reserved))
+	}
+	if !avoidForAutoAssign(8085, reserved) || !avoidForAutoAssign(5173, reserved) {
+		t.Fatalf("expected reserved and dev ports to be skipped")
+	}
+	if avoidForAutoAssign(8080, reserved) {
+		t.Fatalf("8080 should stay assignable")
+	}
+	appCfg.AvoidDevPorts = false
+	if avoidForAutoAssign(5173, reserved) {
+		t.Fatalf("dev ports should be assignable when avoidance is off")
+	}
+}
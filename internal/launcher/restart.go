@@ -0,0 +1,59 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// performRestart restarts the running containers without recreating them
+// and waits for the instance to report healthy again.
+func (s *Server) performRestart(id, jobID string, parent context.Context) error {
+	ctx, cancel := context.WithTimeout(parent, appCfg.ActionTimeout)
+	defer cancel()
+	record := context.WithoutCancel(parent)
+
+	store, idx, err := s.getProfileForAction(ctx, id)
+	if err != nil {
+		return err
+	}
+	profile := store.Profiles[idx]
+	if !profile.Enabled {
+		return ValidationError{Msg: "profile " + id + " is not running"}
+	}
+
+	s.updateJobStep(jobID, "restart", "running", "Restarting containers", 35, "")
+	if err := s.compose.restart(ctx, id); err != nil {
+		_ = s.markProfileResult(record, id, "restart", "failed", err.Error(), "")
+		return err
+	}
+	startingUntil := time.Now().UTC().Add(45 * time.Second).Format(time.RFC3339)
+	if err := s.markProfileResult(record, id, "restart", "success", "Restart requested; waiting for health", startingUntil); err != nil {
+		return err
+	}
+	s.updateJobStep(jobID, "health", "running", "Waiting for health", 80, "")
+	if ok := waitForProfileHealthOrCanceled(ctx, profile, 6, 2*time.Second); !ok {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return ctx.Err()
+		}
+		logWarn("profile_restart_health_pending", map[string]any{"profile_id": id})
+		_ = s.markProfileResult(record, id, "restart", "warning", "Instance did not become healthy yet", startingUntil)
+		return nil
+	}
+	return s.markProfileResult(record, id, "restart", "success", "Instance is healthy", "")
+}
+
+func runProfileComposeRestart(ctx context.Context, id string) error {
+	cmd, err := composeCommandWithContext(ctx, "-p", dockerProjectName(id), "-f", "compose.yaml", "restart")
+	if err != nil {
+		return err
+	}
+	cmd.Dir = profileComposeDir(id)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package launcher
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRestartScheduleDueBetween(t *testing.T) {
+	schedule, err := parseSchedule("03:30; sat,sun 12:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wed := time.Date(2026, 3, 4, 3, 29, 30, 0, time.Local)
+	if !schedule.dueBetween(wed, wed.Add(time.Minute)) {
+		t.Fatalf("expected the nightly restart to be due")
+	}
+	if schedule.dueBetween(wed.Add(time.Minute), wed.Add(2*time.Minute)) {
+		t.Fatalf("expected each scheduled minute to fire once")
+	}
+	noon := time.Date(2026, 3, 4, 11, 59, 30, 0, time.Local)
+	if schedule.dueBetween(noon, noon.Add(time.Minute)) {
+		t.Fatalf("expected the weekend restart not to run on a Wednesday")
+	}
+	if !schedule.dueBetween(noon.AddDate(0, 0, 3), noon.AddDate(0, 0, 3).Add(time.Minute)) {
+		t.Fatalf("expected the weekend restart on Saturday")
+	}
+
+	for _, bad := range []string{"3pm", "24:00", "daily 03:00", "mon 03:00 extra"} {
+		if _, err := normalizeSchedule(bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestScheduledRestartSkipsBusyProfiles(t *testing.T) {
+	srv := newServiceTestServer(t)
+	if err := writeProfileStoreAtomic(srv.dbPath, ProfileStore{Profiles: []ProfileRequest{
+		{ID: "alpha", Version: "1.0.0", Enabled: true, RestartSchedule: "03:30"},
+		{ID: "beta", Version: "1.0.0", RestartSchedule: "03:30"},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2026, 3, 4, 3, 29, 30, 0, time.Local)
+	to := from.Add(time.Minute)
+
+	srv.activeProfiles["alpha"] = "user-job"
+	srv.runSchedules(context.Background(), from, to)
+	if len(srv.jobs) != 0 {
+		t.Fatalf("expected no restart while a job runs, got %d jobs", len(srv.jobs))
+	}
+	delete(srv.activeProfiles, "alpha")
+
+	srv.runSchedules(context.Background(), from, to.Add(scheduleCatchUpLimit))
+	if len(srv.jobs) != 0 {
+		t.Fatalf("expected restarts missed during a long gap to be skipped")
+	}
+
+	srv.runSchedules(context.Background(), from, to)
+	srv.jobMu.Lock()
+	var jobID string
+	for id, job := range srv.jobs {
+		if job.ProfileID != "alpha" || job.Action != "restart" {
+			t.Fatalf("expected only a restart of the running profile, got %+v", job)
+		}
+		jobID = id
+	}
+	srv.jobMu.Unlock()
+	if jobID == "" {
+		t.Fatal("expected a scheduled restart job")
+	}
+	// Wait for the job so it does not write to the data dir after cleanup.
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if job, err := srv.Jobs().Get(jobID); err == nil && isTerminalJobStatus(job.Status) {
+			srv.jobMu.Lock()
+			_, busy := srv.activeProfiles["alpha"]
+			srv.jobMu.Unlock()
+			if !busy {
+				return
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("restart job did not finish")
+}
@@ -0,0 +1,249 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// The scheduler runs every per-profile schedule: restarts, starts and
+// stops, each a cron schedule (see cron.go) evaluated in the profile's time
+// zone, or the launcher's when the profile has none. Due actions start as
+// ordinary jobs. The last check and each schedule's last run are kept in
+// schedules.json, so a launcher restarted within scheduleCatchUpLimit still
+// runs what fell due while it was down and nothing runs twice.
+
+const (
+	schedulerInterval = time.Minute
+	// scheduleCatchUpLimit skips runs missed while the host slept or the
+	// launcher was stopped: after a longer gap nothing is caught up.
+	scheduleCatchUpLimit = 5 * time.Minute
+	schedulesFileName    = "schedules.json"
+)
+
+// scheduleKind is one schedulable profile action.
+type scheduleKind struct {
+	Name   string
+	Action string
+	expr   func(ProfileRequest) string
+	// runs reports whether the action applies to the profile's state.
+	runs func(ProfileRequest) bool
+	// maintenance is set for actions held to the maintenance window.
+	maintenance bool
+}
+
+var scheduleKinds = []scheduleKind{
+	{Name: "restart", Action: "restart", expr: func(p ProfileRequest) string { return p.RestartSchedule }, runs: func(p ProfileRequest) bool { return p.Enabled }, maintenance: true},
+	{Name: "start", Action: "enable", expr: func(p ProfileRequest) string { return p.StartSchedule }, runs: func(p ProfileRequest) bool { return !p.Enabled }},
+	{Name: "stop", Action: "stop", expr: func(p ProfileRequest) string { return p.StopSchedule }, runs: func(p ProfileRequest) bool { return p.Enabled }},
+}
+
+// ScheduledRun describes one profile schedule for GET /api/schedules.
+type ScheduledRun struct {
+	ProfileID string `json:"profileId"`
+	Kind      string `json:"kind"`
+	Schedule  string `json:"schedule"`
+	TimeZone  string `json:"timeZone"`
+	NextRun   string `json:"nextRun,omitempty"`
+	LastRun   string `json:"lastRun,omitempty"`
+	LastJobID string `json:"lastJobId,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type scheduleRun struct {
+	At    string `json:"at"`
+	JobID string `json:"jobId,omitempty"`
+}
+
+type schedulerState struct {
+	CheckedAt string                 `json:"checkedAt,omitempty"`
+	LastRuns  map[string]scheduleRun `json:"lastRuns,omitempty"`
+}
+
+// scheduleStore guards schedules.json.
+type scheduleStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+func newScheduleStore(dataDir string) *scheduleStore {
+	return &scheduleStore{path: filepath.Join(dataDir, schedulesFileName)}
+}
+
+func (st *scheduleStore) load() schedulerState {
+	state := schedulerState{LastRuns: map[string]scheduleRun{}}
+	if raw, err := os.ReadFile(st.path); err == nil {
+		if err := json.Unmarshal(raw, &state); err != nil {
+			logWarn("schedule_state_invalid", map[string]any{"error": err.Error()})
+		}
+	}
+	if state.LastRuns == nil {
+		state.LastRuns = map[string]scheduleRun{}
+	}
+	return state
+}
+
+func (st *scheduleStore) save(state schedulerState) error {
+	raw, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := st.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, st.path)
+}
+
+func scheduleKey(profileID, kind string) string {
+	return profileID + "/" + kind
+}
+
+// scheduleLocation is where a profile's schedules are evaluated.
+func scheduleLocation(p ProfileRequest) *time.Location {
+	if p.TimeZone != "" {
+		if loc, err := time.LoadLocation(p.TimeZone); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// startScheduler checks the schedules every interval, starting from the
+// last check saved before the launcher stopped.
+func (s *Server) startScheduler(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		last := time.Now()
+		if t, err := time.Parse(time.RFC3339, s.schedules.load().CheckedAt); err == nil && t.Before(last) {
+			last = t
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.runSchedules(ctx, last, now)
+				last = now
+			}
+		}
+	}()
+}
+
+// runSchedules starts every action with a scheduled minute in (from, to]. A
+// profile that already has a job, such as an update the user started, is
+// skipped until its next scheduled time.
+func (s *Server) runSchedules(ctx context.Context, from, to time.Time) {
+	s.schedules.mu.Lock()
+	defer s.schedules.mu.Unlock()
+	state := s.schedules.load()
+	defer func() {
+		state.CheckedAt = to.UTC().Format(time.RFC3339)
+		if err := s.schedules.save(state); err != nil {
+			logWarn("schedule_state_save_failed", map[string]any{"error": err.Error()})
+		}
+	}()
+	if to.Sub(from) > scheduleCatchUpLimit {
+		logInfo("schedules_missed", map[string]any{"from": from.UTC().Format(time.RFC3339), "to": to.UTC().Format(time.RFC3339)})
+		return
+	}
+	store, err := s.readStore(ctx)
+	if err != nil {
+		logWarn("schedule_load_failed", map[string]any{"error": err.Error()})
+		return
+	}
+	for _, p := range store.Profiles {
+		if p.Archived || p.DeletedAt != "" {
+			continue
+		}
+		loc := scheduleLocation(p)
+		for _, kind := range scheduleKinds {
+			raw := kind.expr(p)
+			if raw == "" || !kind.runs(p) {
+				continue
+			}
+			sched, err := parseSchedule(raw)
+			if err != nil || !sched.dueBetween(from.In(loc), to.In(loc)) {
+				continue
+			}
+			fields := map[string]any{"profile_id": p.ID, "schedule": kind.Name}
+			if kind.maintenance && !maintenanceAllows(p, to) {
+				fields["reason"] = "maintenance_window_closed"
+				logInfo("scheduled_action_skipped", fields)
+				continue
+			}
+			job, err := s.Profiles().StartAction(ctx, p.ID, kind.Action, "", 0, false)
+			var busy ProfileBusyError
+			if errors.As(err, &busy) {
+				fields["reason"], fields["job_id"] = "job_running", busy.JobID
+				logInfo("scheduled_action_skipped", fields)
+				continue
+			}
+			if err != nil {
+				fields["error"] = err.Error()
+				logWarn("scheduled_action_failed", fields)
+				continue
+			}
+			fields["job_id"] = job.ID
+			logInfo("scheduled_action_started", fields)
+			state.LastRuns[scheduleKey(p.ID, kind.Name)] = scheduleRun{At: to.UTC().Format(time.RFC3339), JobID: job.ID}
+			// The profile's state changed; its other schedules wait for
+			// the next check.
+			break
+		}
+	}
+}
+
+// scheduledRuns lists every schedule with its next run after now.
+func (s *Server) scheduledRuns(profiles []ProfileRequest, now time.Time) []ScheduledRun {
+	s.schedules.mu.Lock()
+	state := s.schedules.load()
+	s.schedules.mu.Unlock()
+	runs := []ScheduledRun{}
+	for _, p := range profiles {
+		if p.Archived || p.DeletedAt != "" {
+			continue
+		}
+		loc := scheduleLocation(p)
+		for _, kind := range scheduleKinds {
+			raw := kind.expr(p)
+			if raw == "" {
+				continue
+			}
+			run := ScheduledRun{ProfileID: p.ID, Kind: kind.Name, Schedule: raw, TimeZone: loc.String()}
+			if last, ok := state.LastRuns[scheduleKey(p.ID, kind.Name)]; ok {
+				run.LastRun, run.LastJobID = last.At, last.JobID
+			}
+			if sched, err := parseSchedule(raw); err != nil {
+				run.Error = err.Error()
+			} else if next := sched.next(now.In(loc)); !next.IsZero() {
+				run.NextRun = next.Format(time.RFC3339)
+			}
+			runs = append(runs, run)
+		}
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].NextRun < runs[j].NextRun })
+	return runs
+}
+
+// handleSchedules serves GET /api/schedules: every profile schedule with
+// its next and last run.
+func (s *Server) handleSchedules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	store, err := s.readStore(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load profiles: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "schedules": s.scheduledRuns(store.Profiles, time.Now())})
+}
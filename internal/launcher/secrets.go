@@ -10,10 +10,9 @@ func splitSecretEnv(env map[string]string) (map[string]string, map[string]string
 	publicEnv := map[string]string{}
 	secretEnv := map[string]string{}
 	for k, v := range env {
-		switch k {
-		case "JWT_SECRET", "ENC_KEY_V0", "FLUMIO_ENC_KEY_V0":
+		if isSecretEnvKey(k) {
 			secretEnv[k] = v
-		default:
+		} else {
 			publicEnv[k] = v
 		}
 	}
@@ -31,12 +30,24 @@ func saveProfileSecrets(profileID string, secrets map[string]string) error {
 	if err := os.MkdirAll(filepath.Dir(secretFilePath(profileID)), 0o700); err != nil {
 		return err
 	}
+	return os.WriteFile(secretFilePath(profileID), profileSecretsContent(secrets), 0o600)
+}
+
+func profileSecretsContent(secrets map[string]string) []byte {
 	lines := make([]string, 0, len(secrets))
 	for k, v := range secrets {
 		lines = append(lines, k+"="+strings.TrimSpace(v))
 	}
-	content := strings.Join(lines, "\n") + "\n"
-	return os.WriteFile(secretFilePath(profileID), []byte(content), 0o600)
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+// secretsTxOps are the transaction steps that save secrets; none when
+// there are none, as saveProfileSecrets writes nothing then.
+func secretsTxOps(profileID string, secrets map[string]string) []txFileOp {
+	if len(secrets) == 0 {
+		return nil
+	}
+	return []txFileOp{txWrite(secretFilePath(profileID), profileSecretsContent(secrets), 0o600)}
 }
 
 func loadProfileSecrets(profileID string) map[string]string {
@@ -10,19 +10,6 @@ import (
 
 const csrfCookieName = "kimmio_csrf"
 
-func withMutationGuard(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if requiresMutationGuard(r.Method) {
-			if reason := validateMutationRequest(r); reason != "" {
-				logWarn("request_blocked", map[string]any{"reason": reason, "path": r.URL.Path, "method": r.Method})
-				http.Error(w, reason, http.StatusForbidden)
-				return
-			}
-		}
-		next(w, r)
-	}
-}
-
 func requiresMutationGuard(method string) bool {
 	switch strings.ToUpper(strings.TrimSpace(method)) {
 	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
@@ -48,13 +35,24 @@ func ensureCSRFCookie(w http.ResponseWriter, r *http.Request) string {
 	return token
 }
 
-func validateMutationRequest(r *http.Request) string {
-	if !isLoopbackRequest(r) {
+func validateMutationOrigin(r *http.Request) string {
+	if !isLoopbackRequest(r) && !isSignedInAdmin(r) && r.URL.Path != "/login" && r.URL.Path != "/logout" {
 		return "forbidden: local requests only"
 	}
 	if !hasValidOriginOrReferer(r) {
 		return "forbidden: invalid request origin"
 	}
+	return ""
+}
+
+// isSignedInAdmin reports whether withAccounts resolved an admin session,
+// which may change state from other machines.
+func isSignedInAdmin(r *http.Request) bool {
+	acc, ok := requestAccount(r)
+	return ok && acc.Role == roleAdmin
+}
+
+func validateCSRFToken(r *http.Request) string {
 	expected, err := r.Cookie(csrfCookieName)
 	if err != nil || strings.TrimSpace(expected.Value) == "" {
 		return "forbidden: missing csrf cookie"
@@ -94,23 +92,32 @@ func isLoopbackRequest(r *http.Request) bool {
 	return false
 }
 
+// hasValidOriginOrReferer runs after validateMutationOrigin has let the
+// request through, so one from another machine is a signed-in admin or a
+// sign-in; for those a page served by this launcher under its own name
+// is enough.
 func hasValidOriginOrReferer(r *http.Request) bool {
+	remote := !isLoopbackRequest(r)
 	origin := strings.TrimSpace(r.Header.Get("Origin"))
 	if origin != "" {
-		if !isAllowedRequestURL(origin, r.Host) {
+		if !isAllowedRequestURL(origin, r.Host, remote) {
 			return false
 		}
 	}
 	referer := strings.TrimSpace(r.Header.Get("Referer"))
 	if referer != "" {
-		if !isAllowedRequestURL(referer, r.Host) {
+		if !isAllowedRequestURL(referer, r.Host, remote) {
 			return false
 		}
 	}
 	return true
 }
 
-func isAllowedRequestURL(raw, expectedHost string) bool {
+// isAllowedRequestURL reports whether raw points at expectedHost. A
+// loopback request must also name this machine as localhost, so a site
+// whose name was rebound to 127.0.0.1 cannot pass; remote accepts any
+// name the launcher is reached by, such as launcher.lan.
+func isAllowedRequestURL(raw, expectedHost string, remote bool) bool {
 	u, err := url.Parse(raw)
 	if err != nil {
 		return false
@@ -120,6 +127,9 @@ func isAllowedRequestURL(raw, expectedHost string) bool {
 	if host != exp {
 		return false
 	}
+	if remote {
+		return true
+	}
 	name := strings.ToLower(u.Hostname())
 	return name == "localhost" || name == "127.0.0.1" || name == "::1"
 }
@@ -0,0 +1,314 @@
+package launcher
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"launcher/internal/config"
+)
+
+// `launcher selftest` lets packagers check a build on each platform
+// without Docker. It boots the launcher on a random loopback port with a
+// temporary data directory and fakeCompose, then drives the HTTP API the
+// way the UI does: pages, profile create/read/delete and the enable and
+// stop jobs.
+
+const (
+	selfTestProfileID  = "selftest"
+	selfTestJobTimeout = 30 * time.Second
+)
+
+// fakeCompose stands in for Docker. up serves /health on the profile's host
+// port, as the app would, so the enable job's health wait passes; down
+// closes it.
+type fakeCompose struct {
+	mu   sync.Mutex
+	apps map[string]*http.Server
+}
+
+func newFakeCompose() *fakeCompose {
+	return &fakeCompose{apps: map[string]*http.Server{}}
+}
+
+func (f *fakeCompose) up(_ context.Context, profile ProfileRequest, _ string, onProgress composeProgressFn) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.apps[profile.ID]; ok {
+		return nil
+	}
+	if onProgress != nil {
+		onProgress("up", "Starting fake stack", 60)
+	}
+	ln, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", profileHostPort(profile)))
+	if err != nil {
+		return err
+	}
+	app := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}), ReadHeaderTimeout: 5 * time.Second}
+	go func() { _ = app.Serve(ln) }()
+	f.apps[profile.ID] = app
+	return nil
+}
+
+func (f *fakeCompose) down(_ context.Context, id string, _ bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if app, ok := f.apps[id]; ok {
+		_ = app.Close()
+		delete(f.apps, id)
+	}
+	return nil
+}
+
+func (f *fakeCompose) restart(context.Context, string) error {
+	return nil
+}
+
+func (f *fakeCompose) closeAll() {
+	for id := range f.apps {
+		_ = f.down(context.Background(), id, true)
+	}
+}
+
+// RunSelfTest handles `launcher selftest`. It needs the embedded pages, so
+// main calls it next to RunCLI.
+func RunSelfTest(embedded fs.FS, cfg config.Config, args []string, stdout, stderr io.Writer) (handled bool, exitCode int) {
+	args = normalizeCLIArgs(args)
+	if len(args) == 0 || strings.ToLower(strings.TrimSpace(args[0])) != "selftest" {
+		return false, 0
+	}
+	if len(args) > 1 {
+		fmt.Fprintln(stderr, "Usage: selftest")
+		return true, exitUsage
+	}
+
+	dir, err := os.MkdirTemp("", "kimmio-selftest-")
+	if err != nil {
+		return true, cliFail(stderr, "Failed to create a data directory", err)
+	}
+	defer os.RemoveAll(dir)
+	cfg.DataDir = dir
+	cfg.GRPCPort = 0
+	// Deleting purges right away, so the profile is gone afterwards.
+	cfg.TrashRetentionDays = 0
+	appCfg = cfg
+
+	ts, err := NewTemplatesFromFS(embedded, "templates")
+	if err != nil {
+		return true, cliFail(stderr, "Failed to load templates", err)
+	}
+	srv := NewServer(cfg)
+	srv.pages = ts
+	compose := newFakeCompose()
+	defer compose.closeAll()
+	srv.compose = compose
+	mux, err := newServeMux(srv, embedded)
+	if err != nil {
+		return true, cliFail(stderr, "Failed to register routes", err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return true, cliFail(stderr, "Failed to listen", err)
+	}
+	httpSrv := newHTTPServer(0, srv.httpHandler(mux))
+	go func() { _ = httpSrv.Serve(ln) }()
+	defer httpSrv.Close()
+
+	port, err := freeProfilePort()
+	if err != nil {
+		return true, cliFail(stderr, "Failed to find a port for the test profile", err)
+	}
+	st := &selfTest{base: "http://" + ln.Addr().String(), client: &http.Client{Timeout: 10 * time.Second}, csrf: randomHex(16)}
+	checks := []struct {
+		name string
+		run  func() error
+	}{
+		{"pages render", st.checkPages},
+		{"create profile", func() error { return st.createProfile(port) }},
+		{"read profile", func() error { return st.expectEnabled(false) }},
+		{"enable job", func() error { return st.runJob(http.MethodPost, "/api/profiles/"+selfTestProfileID+"/enable", nil) }},
+		{"profile enabled", func() error { return st.expectEnabled(true) }},
+		{"stop job", func() error { return st.runJob(http.MethodPost, "/api/profiles/"+selfTestProfileID+"/stop", nil) }},
+		{"profile stopped", func() error { return st.expectEnabled(false) }},
+		{"delete job", func() error {
+			return st.runJob(http.MethodDelete, "/api/profiles/"+selfTestProfileID, map[string]string{"confirm": selfTestProfileID})
+		}},
+		{"profile deleted", st.expectDeleted},
+	}
+
+	fmt.Fprintf(stdout, "Self-test of Kimmio Launcher %s (%s)\n", launcherAppVersion, launcherGitCommit)
+	for _, check := range checks {
+		started := time.Now()
+		if err := check.run(); err != nil {
+			fmt.Fprintf(stdout, "FAIL %s: %v\n", check.name, err)
+			fmt.Fprintln(stderr, "Self-test failed")
+			return true, exitFailure
+		}
+		fmt.Fprintf(stdout, "ok   %s (%s)\n", check.name, time.Since(started).Round(time.Millisecond))
+	}
+	fmt.Fprintf(stdout, "Self-test passed: %d checks\n", len(checks))
+	return true, exitOK
+}
+
+// freeProfilePort picks a profile port nothing listens on.
+func freeProfilePort() (int, error) {
+	portMin, portMax := profilePortRange()
+	reserved := reservedPorts()
+	for p := portMin; p <= portMax; p++ {
+		if !reserved[p] && isTCPPortAvailable(p) {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("no free port between %d and %d", portMin, portMax)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// selfTest is an API client of the launcher under test.
+type selfTest struct {
+	base   string
+	client *http.Client
+	csrf   string
+}
+
+func (st *selfTest) do(method, path string, body any, out any) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, st.base+path, reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: st.csrf})
+	req.Header.Set("X-CSRF-Token", st.csrf)
+	resp, err := st.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode >= 400 {
+		return resp.StatusCode, fmt.Errorf("%s %s: %d %s", method, path, resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	if out != nil {
+		if err := json.Unmarshal(raw, out); err != nil {
+			return resp.StatusCode, fmt.Errorf("%s %s: %w", method, path, err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+func (st *selfTest) checkPages() error {
+	for _, path := range []string{"/", "/profiles/new", "/settings", "/static/styles.css"} {
+		resp, err := st.client.Get(st.base + path)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("GET %s: %d", path, resp.StatusCode)
+		}
+	}
+	return nil
+}
+
+func (st *selfTest) createProfile(port int) error {
+	body := map[string]any{
+		"id":      selfTestProfileID,
+		"version": "latest",
+		"ports":   []PortMapping{{Container: 3000, Host: port}},
+	}
+	status, err := st.do(http.MethodPost, "/api/profiles", body, nil)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusCreated {
+		return fmt.Errorf("expected 201, got %d", status)
+	}
+	return nil
+}
+
+func (st *selfTest) expectEnabled(enabled bool) error {
+	var status struct {
+		ID      string `json:"id"`
+		Enabled bool   `json:"enabled"`
+	}
+	if _, err := st.do(http.MethodGet, "/api/profiles/"+selfTestProfileID+"/status", nil, &status); err != nil {
+		return err
+	}
+	if status.ID != selfTestProfileID || status.Enabled != enabled {
+		return fmt.Errorf("expected profile %s with enabled=%t, got %s with enabled=%t", selfTestProfileID, enabled, status.ID, status.Enabled)
+	}
+	return nil
+}
+
+func (st *selfTest) expectDeleted() error {
+	status, err := st.do(http.MethodGet, "/api/profiles/"+selfTestProfileID+"/status", nil, nil)
+	if status == http.StatusNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("expected 404, got %d", status)
+}
+
+// runJob starts an action job and waits for it to succeed.
+func (st *selfTest) runJob(method, path string, body any) error {
+	var started struct {
+		JobID string `json:"jobId"`
+	}
+	if _, err := st.do(method, path, body, &started); err != nil {
+		return err
+	}
+	if started.JobID == "" {
+		return errors.New("no job id in the response")
+	}
+	deadline := time.Now().Add(selfTestJobTimeout)
+	for time.Now().Before(deadline) {
+		var got struct {
+			Job ActionJob `json:"job"`
+		}
+		if _, err := st.do(http.MethodGet, "/api/jobs/"+started.JobID, nil, &got); err != nil {
+			return err
+		}
+		switch got.Job.Status {
+		case "succeeded":
+			return nil
+		case "failed", "canceled", "timeout":
+			return fmt.Errorf("job %s %s: %s", started.JobID, got.Job.Status, got.Job.Error)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return fmt.Errorf("job %s did not finish within %s", started.JobID, selfTestJobTimeout)
+}
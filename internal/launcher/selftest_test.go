@@ -0,0 +1,26 @@
+package launcher
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"launcher/internal/config"
+)
+
+func TestRunSelfTest(t *testing.T) {
+	defer func(orig config.Config) { appCfg = orig }(appCfg)
+	var stdout, stderr bytes.Buffer
+	embedded := os.DirFS("../../cmd/launcher")
+	if handled, _ := RunSelfTest(embedded, config.Load("dev"), []string{"profile", "list"}, &stdout, &stderr); handled {
+		t.Fatal("expected other commands left to RunCLI")
+	}
+	handled, code := RunSelfTest(embedded, config.Load("dev"), []string{"selftest"}, &stdout, &stderr)
+	if !handled || code != exitOK {
+		t.Fatalf("expected the self-test to pass, got %d:\n%s%s", code, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "ok   enable job") || !strings.Contains(stdout.String(), "Self-test passed") {
+		t.Fatalf("expected every check reported, got:\n%s", stdout.String())
+	}
+}
@@ -0,0 +1,292 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// jobCancelWait bounds how long a forced delete waits for the canceled job
+// to release the profile.
+const jobCancelWait = 30 * time.Second
+
+var (
+	ErrProfileNotFound  = fmt.Errorf("profile not found: %w", os.ErrNotExist)
+	ErrProfileBusy      = errors.New("another action is already running for this profile")
+	ErrUnknownAction    = errors.New("unknown profile action")
+	ErrJobNotFound      = errors.New("job not found")
+	ErrJobCompleted     = errors.New("job already completed")
+	ErrJobNotPausable   = errors.New("job is not pulling an image")
+	ErrRevisionConflict = errors.New("profile was changed by another client")
+)
+
+// ProfileBusyError reports the job currently holding a profile's action lock.
+type ProfileBusyError struct {
+	JobID string
+}
+
+func (e ProfileBusyError) Error() string {
+	return ErrProfileBusy.Error() + " (job " + e.JobID + ")"
+}
+
+func (e ProfileBusyError) Is(target error) bool { return target == ErrProfileBusy }
+
+// RevisionConflictError carries the stored profile when a caller's expected
+// revision is stale, so clients can refresh without another round trip.
+type RevisionConflictError struct {
+	Expected int
+	Current  ProfileRequest
+}
+
+func (e RevisionConflictError) Error() string {
+	return fmt.Sprintf("%s (expected revision %d, current %d)", ErrRevisionConflict.Error(), e.Expected, e.Current.Revision)
+}
+
+func (e RevisionConflictError) Is(target error) bool { return target == ErrRevisionConflict }
+
+// ProfileService holds profile business logic shared by the HTTP, gRPC and
+// CLI front ends. Callers only translate inputs and errors.
+type ProfileService struct {
+	srv *Server
+}
+
+// JobService exposes background job state and cancellation.
+type JobService struct {
+	srv *Server
+}
+
+func (s *Server) Profiles() *ProfileService { return &ProfileService{srv: s} }
+
+func (s *Server) Jobs() *JobService { return &JobService{srv: s} }
+
+func (p *ProfileService) List(ctx context.Context) ([]ProfileRequest, error) {
+	return p.srv.profilesWithStatus(ctx)
+}
+
+func (p *ProfileService) Get(ctx context.Context, id string) (ProfileRequest, error) {
+	id = normalizeProfileID(id)
+	if !profileIDRe.MatchString(id) {
+		return ProfileRequest{}, ValidationError{Msg: "invalid profile id"}
+	}
+	profiles, err := p.srv.profilesWithStatus(ctx)
+	if err != nil {
+		return ProfileRequest{}, err
+	}
+	idx := findProfileIndex(ProfileStore{Profiles: profiles}, id)
+	if idx < 0 {
+		return ProfileRequest{}, ErrProfileNotFound
+	}
+	return profiles[idx], nil
+}
+
+func (p *ProfileService) Create(ctx context.Context, req ProfileRequest) (ProfileRequest, error) {
+	if err := validateAndNormalize(&req); err != nil {
+		return ProfileRequest{}, err
+	}
+	if err := p.srv.createProfile(ctx, req); err != nil {
+		return ProfileRequest{}, err
+	}
+	return req, nil
+}
+
+// StartAction validates the request and queues the action as a background job.
+// A positive expectedRevision must match the stored profile; zero skips the
+// check. Destructive actions are refused with ErrConfirmationRequired unless
+// the caller has confirmed them.
+func (p *ProfileService) StartAction(ctx context.Context, id, action, version string, expectedRevision int, confirmed bool) (*ActionJob, error) {
+	id, action, version, err := p.prepareAction(ctx, id, action, version, expectedRevision, confirmed)
+	if err != nil {
+		return nil, err
+	}
+	run, err := p.srv.actionRunner(id, action, version)
+	if err != nil {
+		return nil, err
+	}
+	key := p.srv.jobStatKey(ctx, id, action, version)
+	job, err := p.srv.enqueueProfileJob(id, action, run)
+	if err != nil {
+		return nil, err
+	}
+	p.srv.attachJobEstimate(ctx, job.ID, key)
+	return job, nil
+}
+
+// RunAction performs the action synchronously without creating a job, as
+// the CLI does.
+func (p *ProfileService) RunAction(ctx context.Context, id, action, version string, expectedRevision int, confirmed bool) error {
+	id, action, version, err := p.prepareAction(ctx, id, action, version, expectedRevision, confirmed)
+	if err != nil {
+		return err
+	}
+	run, err := p.srv.actionRunner(id, action, version)
+	if err != nil {
+		return err
+	}
+	return normalizeNotFound(run("", ctx))
+}
+
+func (p *ProfileService) prepareAction(ctx context.Context, id, action, version string, expectedRevision int, confirmed bool) (string, string, string, error) {
+	id = normalizeProfileID(id)
+	action = strings.ToLower(strings.TrimSpace(action))
+	version = strings.TrimSpace(version)
+	if !profileIDRe.MatchString(id) {
+		return "", "", "", ValidationError{Msg: "invalid profile id"}
+	}
+	if !isProfileAction(action) {
+		return "", "", "", ErrUnknownAction
+	}
+	if isDestructiveAction(action) && !confirmed {
+		return "", "", "", ErrConfirmationRequired
+	}
+	if action == "version" {
+		if version == "" {
+			return "", "", "", ValidationError{Msg: "version is required"}
+		}
+		if !versionTagRe.MatchString(version) {
+			return "", "", "", ValidationError{Msg: "invalid version tag"}
+		}
+	}
+	store, idx, err := p.srv.getProfileForAction(ctx, id)
+	if err != nil {
+		return "", "", "", normalizeNotFound(err)
+	}
+	if current := store.Profiles[idx]; expectedRevision > 0 && current.Revision != expectedRevision {
+		return "", "", "", RevisionConflictError{Expected: expectedRevision, Current: current}
+	}
+	if err := checkTrashedAction(store, idx, action); err != nil {
+		return "", "", "", err
+	}
+	if err := checkArchivedAction(store.Profiles[idx], action); err != nil {
+		return "", "", "", err
+	}
+	return id, action, version, nil
+}
+
+func (j *JobService) Get(id string) (ActionJob, error) {
+	job, ok := j.srv.snapshotJob(strings.TrimSpace(id))
+	if !ok {
+		return ActionJob{}, ErrJobNotFound
+	}
+	return job, nil
+}
+
+func (j *JobService) Cancel(id string) error {
+	return j.srv.cancelJob(strings.TrimSpace(id))
+}
+
+// CancelProfileJob cancels the job holding the profile's action lock and
+// waits, up to jobCancelWait, for it to wind down and release the lock, so
+// a following action does not race its cleanup. Without a job it returns
+// at once; a job that does not stop in time is reported as busy.
+func (j *JobService) CancelProfileJob(ctx context.Context, profileID string) error {
+	jobID, ok := j.srv.activeProfileJob(normalizeProfileID(profileID))
+	if !ok {
+		return nil
+	}
+	if err := j.srv.cancelJob(jobID); err != nil && !errors.Is(err, ErrJobCompleted) && !errors.Is(err, ErrJobNotFound) {
+		return err
+	}
+	logInfo("profile_job_force_canceled", map[string]any{"profile_id": profileID, "job_id": jobID})
+	ctx, cancel := context.WithTimeout(ctx, jobCancelWait)
+	defer cancel()
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if active, ok := j.srv.activeProfileJob(profileID); !ok || active != jobID {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ProfileBusyError{JobID: jobID}
+		case <-ticker.C:
+		}
+	}
+}
+
+func normalizeProfileID(id string) string {
+	return strings.ToLower(strings.TrimSpace(id))
+}
+
+func normalizeNotFound(err error) error {
+	if err != nil && errors.Is(err, os.ErrNotExist) && !errors.Is(err, ErrProfileNotFound) {
+		return ErrProfileNotFound
+	}
+	return err
+}
+
+func isProfileAction(action string) bool {
+	switch action {
+	case "enable", "stop", "restart", "recreate", "version", "regenerate-secrets", "delete", "restore", "purge", "archive", "unarchive":
+		return true
+	default:
+		return false
+	}
+}
+
+// actionRunner returns the function performing action, wrapped in the
+// profile's action hooks.
+func (s *Server) actionRunner(id, action, version string) (func(jobID string, ctx context.Context) error, error) {
+	run, err := s.baseActionRunner(id, action, version)
+	if err != nil {
+		return nil, err
+	}
+	return s.withActionHooks(id, action, version, run), nil
+}
+
+func (s *Server) baseActionRunner(id, action, version string) (func(jobID string, ctx context.Context) error, error) {
+	switch action {
+	case "enable":
+		return func(jobID string, ctx context.Context) error {
+			return s.performEnable(id, jobID, ctx)
+		}, nil
+	case "stop":
+		return func(jobID string, ctx context.Context) error {
+			return s.performStop(id, jobID, ctx)
+		}, nil
+	case "restart":
+		return func(jobID string, ctx context.Context) error {
+			return s.performRestart(id, jobID, ctx)
+		}, nil
+	case "recreate":
+		return func(jobID string, ctx context.Context) error {
+			return s.performRecreate(id, jobID, ctx)
+		}, nil
+	case "version":
+		return func(jobID string, ctx context.Context) error {
+			return s.performVersionUpdate(id, version, jobID, ctx)
+		}, nil
+	case "regenerate-secrets":
+		return func(jobID string, ctx context.Context) error {
+			return s.performRegenerateSecrets(id, jobID, ctx)
+		}, nil
+	case "archive":
+		return func(jobID string, ctx context.Context) error {
+			return s.performArchive(id, jobID, ctx)
+		}, nil
+	case "unarchive":
+		return func(jobID string, ctx context.Context) error {
+			return s.performUnarchive(id, ctx)
+		}, nil
+	case "delete":
+		if appCfg.TrashRetentionDays <= 0 {
+			return s.baseActionRunner(id, "purge", version)
+		}
+		return func(jobID string, ctx context.Context) error {
+			return s.performTrash(id, jobID, ctx)
+		}, nil
+	case "restore":
+		return func(jobID string, ctx context.Context) error {
+			return s.performRestore(id, ctx)
+		}, nil
+	case "purge":
+		return func(jobID string, ctx context.Context) error {
+			s.updateJobStep(jobID, "down", "running", "Stopping profile", 20, "")
+			return s.performDelete(id, jobID, ctx)
+		}, nil
+	default:
+		return nil, ErrUnknownAction
+	}
+}
@@ -0,0 +1,114 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"launcher/internal/config"
+)
+
+func newServiceTestServer(t *testing.T) *Server {
+	t.Helper()
+	tmp := t.TempDir()
+	cfg := config.Load("dev")
+	cfg.DataDir = tmp
+	appCfg = cfg
+	srv := NewServer(cfg)
+	srv.dbPath = filepath.Join(tmp, "profiles.json")
+	if err := writeProfileStoreAtomic(srv.dbPath, ProfileStore{Profiles: []ProfileRequest{
+		{ID: "alpha", Version: "1.0.0", Ports: []PortMapping{{Container: 3000, Host: 8088}}},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	return srv
+}
+
+func TestProfileServiceStartActionErrors(t *testing.T) {
+	srv := newServiceTestServer(t)
+
+	if _, err := srv.Profiles().StartAction(context.Background(), "missing", "stop", "", 0, false); !errors.Is(err, ErrProfileNotFound) {
+		t.Fatalf("expected ErrProfileNotFound, got %v", err)
+	}
+	if _, err := srv.Profiles().StartAction(context.Background(), "alpha", "explode", "", 0, false); !errors.Is(err, ErrUnknownAction) {
+		t.Fatalf("expected ErrUnknownAction, got %v", err)
+	}
+	var ve ValidationError
+	if _, err := srv.Profiles().StartAction(context.Background(), "alpha", "version", "bad tag!", 0, false); !errors.As(err, &ve) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	for _, action := range []string{"delete", "purge", "recreate", "regenerate-secrets"} {
+		if _, err := srv.Profiles().StartAction(context.Background(), "alpha", action, "", 0, false); !errors.Is(err, ErrConfirmationRequired) {
+			t.Fatalf("expected unconfirmed %s to be refused, got %v", action, err)
+		}
+	}
+
+	srv.activeProfiles["alpha"] = "job-1"
+	_, err := srv.Profiles().StartAction(context.Background(), "alpha", "stop", "", 0, false)
+	if !errors.Is(err, ErrProfileBusy) {
+		t.Fatalf("expected ErrProfileBusy, got %v", err)
+	}
+	if httpStatusForError(err) != http.StatusConflict {
+		t.Fatalf("expected busy error to map to 409")
+	}
+}
+
+func TestProfileServiceRunActionVersion(t *testing.T) {
+	srv := newServiceTestServer(t)
+	if err := srv.Profiles().RunAction(context.Background(), "alpha", "version", "2.0.0", 0, false); err != nil {
+		t.Fatalf("RunAction failed: %v", err)
+	}
+	p, err := srv.Profiles().Get(context.Background(), "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Version != "2.0.0" {
+		t.Fatalf("expected version 2.0.0, got %s", p.Version)
+	}
+}
+
+func TestProfileActionRejectsStaleRevision(t *testing.T) {
+	srv := newServiceTestServer(t)
+	if err := srv.Profiles().RunAction(context.Background(), "alpha", "version", "2.0.0", 1, false); err != nil {
+		t.Fatalf("RunAction with current revision failed: %v", err)
+	}
+	p, err := srv.Profiles().Get(context.Background(), "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Revision <= 1 {
+		t.Fatalf("expected revision to advance, got %d", p.Revision)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/profiles/alpha/stop", nil)
+	req.Header.Set("If-Match", `"1"`)
+	rec := httptest.NewRecorder()
+	srv.handleProfileAction(rec, req)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var body struct {
+		Profile ProfileRequest `json:"profile"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Profile.Revision != p.Revision || body.Profile.Version != "2.0.0" {
+		t.Fatalf("expected current profile in conflict body, got %+v", body.Profile)
+	}
+}
+
+func TestJobServiceErrors(t *testing.T) {
+	srv := newServiceTestServer(t)
+	if _, err := srv.Jobs().Get("nope"); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("expected ErrJobNotFound, got %v", err)
+	}
+	srv.jobs["done"] = &ActionJob{ID: "done", Status: "succeeded"}
+	if err := srv.Jobs().Cancel("done"); !errors.Is(err, ErrJobCompleted) {
+		t.Fatalf("expected ErrJobCompleted, got %v", err)
+	}
+}
@@ -0,0 +1,456 @@
+package launcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	updateChannelStable     = "stable"
+	updateChannelPrerelease = "prerelease"
+
+	minHealthInterval = 5 * time.Second
+	maxHealthInterval = 10 * time.Minute
+)
+
+// Settings are the options that can change while the launcher runs. They
+// are persisted in settings.json in the data directory and take precedence
+// over the environment; the listen port and data directory stay in the
+// environment. Zero limits mean "use the environment value".
+type Settings struct {
+	LogLevel             string                `json:"logLevel"`
+	HealthInterval       string                `json:"healthInterval"`
+	UpdateChannel        string                `json:"updateChannel"`
+	NotificationChannels []NotificationChannel `json:"notificationChannels"`
+	MaxProfiles          int                   `json:"maxProfiles,omitempty"`
+	ProfilePortMin       int                   `json:"profilePortMin,omitempty"`
+	ProfilePortMax       int                   `json:"profilePortMax,omitempty"`
+	// OpenBrowser opens the UI when the launcher starts in a release build.
+	OpenBrowser bool `json:"openBrowser"`
+	// WeeklySummary sends a summary of the past week to the notification
+	// channels and, when SummaryEmail is set, by email through SummarySMTP,
+	// which smtp channels use too. The SMTP password is kept in the secrets
+	// directory, not here.
+	WeeklySummary bool `json:"weeklySummary"`
+	// DockerHost is the daemon address docker commands use, such as a
+	// rootless socket; empty keeps DOCKER_HOST or the detected socket.
+	DockerHost string `json:"dockerHost,omitempty"`
+	// ReconcileFile is an apply file the launcher keeps the profiles in
+	// line with; empty turns the reconcile loop off.
+	ReconcileFile string `json:"reconcileFile,omitempty"`
+	// AllowActionHooks lets profiles run their pre and post action hooks,
+	// which are shell commands run as the launcher's user.
+	AllowActionHooks bool `json:"allowActionHooks"`
+	// PullBandwidthMbps caps image pulls in megabits per second; 0 is no
+	// limit.
+	PullBandwidthMbps int          `json:"pullBandwidthMbps,omitempty"`
+	SummaryEmail      string       `json:"summaryEmail,omitempty"`
+	SummarySMTP       SMTPSettings `json:"summarySmtp"`
+}
+
+// SettingsPatch is the body of PUT /api/settings; fields left out keep
+// their current value.
+type SettingsPatch struct {
+	LogLevel             *string                `json:"logLevel"`
+	HealthInterval       *string                `json:"healthInterval"`
+	UpdateChannel        *string                `json:"updateChannel"`
+	NotificationChannels *[]NotificationChannel `json:"notificationChannels"`
+	MaxProfiles          *int                   `json:"maxProfiles"`
+	ProfilePortMin       *int                   `json:"profilePortMin"`
+	ProfilePortMax       *int                   `json:"profilePortMax"`
+	OpenBrowser          *bool                  `json:"openBrowser"`
+	WeeklySummary        *bool                  `json:"weeklySummary"`
+	DockerHost           *string                `json:"dockerHost"`
+	ReconcileFile        *string                `json:"reconcileFile"`
+	AllowActionHooks     *bool                  `json:"allowActionHooks"`
+	PullBandwidthMbps    *int                   `json:"pullBandwidthMbps"`
+	SummaryEmail         *string                `json:"summaryEmail"`
+	SummarySMTP          *SMTPSettings          `json:"summarySmtp"`
+	// SummarySMTPPassword replaces the saved password; an empty string
+	// removes it.
+	SummarySMTPPassword *string `json:"summarySmtpPassword"`
+}
+
+const (
+	maxProfilesCeiling   = 100
+	maxPullBandwidthMbps = 10000
+)
+
+func defaultSettings() Settings {
+	return Settings{
+		LogLevel:             "info",
+		HealthInterval:       healthCacheInterval.String(),
+		UpdateChannel:        updateChannelStable,
+		NotificationChannels: []NotificationChannel{},
+		OpenBrowser:          true,
+	}
+}
+
+// liveSettings is the current settings for code outside the Server, such as
+// the profile defaults and the logger.
+var liveSettings atomic.Pointer[Settings]
+
+func publishSettings(st Settings) {
+	liveSettings.Store(&st)
+	setLogLevel(st.LogLevel)
+}
+
+// maxProfilesLimit is the effective profile limit.
+func maxProfilesLimit() int {
+	if st := liveSettings.Load(); st != nil && st.MaxProfiles > 0 {
+		return st.MaxProfiles
+	}
+	return appCfg.MaxProfiles
+}
+
+// profilePortRange is the effective range for automatically assigned
+// profile ports; max is exclusive.
+func profilePortRange() (int, int) {
+	if st := liveSettings.Load(); st != nil && st.ProfilePortMin > 0 && st.ProfilePortMax > 0 {
+		return st.ProfilePortMin, st.ProfilePortMax
+	}
+	return appCfg.ProfilePortMin, appCfg.ProfilePortMax
+}
+
+func (st Settings) healthInterval() time.Duration {
+	d, err := time.ParseDuration(st.HealthInterval)
+	if err != nil {
+		return healthCacheInterval
+	}
+	return d
+}
+
+func (st Settings) validate() error {
+	if _, ok := logLevels[st.LogLevel]; !ok {
+		return ValidationError{Msg: "logLevel must be info, warn or error"}
+	}
+	d, err := time.ParseDuration(st.HealthInterval)
+	if err != nil || d < minHealthInterval || d > maxHealthInterval {
+		return ValidationError{Msg: fmt.Sprintf("healthInterval must be a duration between %s and %s", minHealthInterval, maxHealthInterval)}
+	}
+	if st.UpdateChannel != updateChannelStable && st.UpdateChannel != updateChannelPrerelease {
+		return ValidationError{Msg: "updateChannel must be stable or prerelease"}
+	}
+	if st.MaxProfiles < 0 || st.MaxProfiles > maxProfilesCeiling {
+		return ValidationError{Msg: fmt.Sprintf("maxProfiles must be between 1 and %d, or 0 for the environment value", maxProfilesCeiling)}
+	}
+	if (st.ProfilePortMin == 0) != (st.ProfilePortMax == 0) {
+		return ValidationError{Msg: "profilePortMin and profilePortMax must be set together"}
+	}
+	if st.ProfilePortMin != 0 && (st.ProfilePortMin < 1024 || st.ProfilePortMax <= st.ProfilePortMin || st.ProfilePortMax > 65535) {
+		return ValidationError{Msg: "profile port range must be within 1024-65535 with min below max"}
+	}
+	if _, err := normalizeDockerHost(st.DockerHost); err != nil {
+		return ValidationError{Msg: err.Error()}
+	}
+	if st.ReconcileFile != "" && !filepath.IsAbs(st.ReconcileFile) {
+		return ValidationError{Msg: "reconcileFile must be an absolute path"}
+	}
+	if st.PullBandwidthMbps < 0 || st.PullBandwidthMbps > maxPullBandwidthMbps {
+		return ValidationError{Msg: fmt.Sprintf("pullBandwidthMbps must be between 1 and %d, or 0 for no limit", maxPullBandwidthMbps)}
+	}
+	summarySMTP := st.SummarySMTP
+	if err := normalizeSMTPSettings(&summarySMTP, ""); err != nil {
+		return ValidationError{Msg: "summarySmtp: " + err.Error()}
+	}
+	if st.SummaryEmail != "" {
+		if _, err := mail.ParseAddress(st.SummaryEmail); err != nil {
+			return ValidationError{Msg: "summaryEmail must be an email address"}
+		}
+		if !summarySMTP.configured() {
+			return ValidationError{Msg: "summarySmtp host is required to email the summary"}
+		}
+	}
+	if len(st.NotificationChannels) > maxNotificationChannels {
+		return ValidationError{Msg: fmt.Sprintf("at most %d notification channels are allowed", maxNotificationChannels)}
+	}
+	seen := map[string]bool{}
+	for _, c := range st.NotificationChannels {
+		if err := c.validate(summarySMTP); err != nil {
+			return ValidationError{Msg: "notificationChannels: " + err.Error()}
+		}
+		if seen[c.ID] {
+			return ValidationError{Msg: "notificationChannels: channel id " + c.ID + " is used twice"}
+		}
+		seen[c.ID] = true
+	}
+	return nil
+}
+
+// apply returns st with the patch applied and normalized.
+func (st Settings) apply(p SettingsPatch) Settings {
+	if p.LogLevel != nil {
+		st.LogLevel = strings.ToLower(strings.TrimSpace(*p.LogLevel))
+	}
+	if p.HealthInterval != nil {
+		st.HealthInterval = strings.TrimSpace(*p.HealthInterval)
+	}
+	if p.UpdateChannel != nil {
+		st.UpdateChannel = strings.ToLower(strings.TrimSpace(*p.UpdateChannel))
+	}
+	if p.NotificationChannels != nil {
+		channels := make([]NotificationChannel, 0, len(*p.NotificationChannels))
+		for _, c := range *p.NotificationChannels {
+			c = c.clone()
+			c.normalize()
+			channels = append(channels, c)
+		}
+		st.NotificationChannels = channels
+	}
+	if p.MaxProfiles != nil {
+		st.MaxProfiles = *p.MaxProfiles
+	}
+	if p.ProfilePortMin != nil {
+		st.ProfilePortMin = *p.ProfilePortMin
+	}
+	if p.ProfilePortMax != nil {
+		st.ProfilePortMax = *p.ProfilePortMax
+	}
+	if p.OpenBrowser != nil {
+		st.OpenBrowser = *p.OpenBrowser
+	}
+	if p.WeeklySummary != nil {
+		st.WeeklySummary = *p.WeeklySummary
+	}
+	if p.DockerHost != nil {
+		// An invalid address is kept as given for validate to report.
+		st.DockerHost = strings.TrimSpace(*p.DockerHost)
+		if host, err := normalizeDockerHost(st.DockerHost); err == nil {
+			st.DockerHost = host
+		}
+	}
+	if p.ReconcileFile != nil {
+		st.ReconcileFile = strings.TrimSpace(*p.ReconcileFile)
+	}
+	if p.AllowActionHooks != nil {
+		st.AllowActionHooks = *p.AllowActionHooks
+	}
+	if p.PullBandwidthMbps != nil {
+		st.PullBandwidthMbps = *p.PullBandwidthMbps
+	}
+	if p.SummaryEmail != nil {
+		st.SummaryEmail = strings.TrimSpace(*p.SummaryEmail)
+	}
+	if p.SummarySMTP != nil {
+		// Errors surface from validate, which normalizes a copy again.
+		st.SummarySMTP = *p.SummarySMTP
+		_ = normalizeSMTPSettings(&st.SummarySMTP, "")
+	}
+	return st
+}
+
+// settingsStore holds the current settings. Components that cache a value
+// (the health monitor's interval) wait on changed() to pick up updates.
+type settingsStore struct {
+	mu      sync.RWMutex
+	path    string
+	current Settings
+	changes chan struct{}
+}
+
+func newSettingsStore(dataDir string) *settingsStore {
+	s := &settingsStore{path: filepath.Join(dataDir, "settings.json"), current: defaultSettings(), changes: make(chan struct{})}
+	s.current = readSettingsFile(s.path)
+	publishSettings(s.current)
+	return s
+}
+
+// readSettingsFile returns the saved settings, or the defaults when the file
+// is missing or invalid.
+func readSettingsFile(path string) Settings {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return defaultSettings()
+	}
+	loaded := defaultSettings()
+	if err := json.Unmarshal(raw, &loaded); err != nil {
+		logWarn("settings_file_invalid", map[string]any{"error": err.Error()})
+		return defaultSettings()
+	}
+	// Files from before notification channels list plain webhook URLs.
+	var legacy struct {
+		NotificationWebhooks []string `json:"notificationWebhooks"`
+	}
+	if json.Unmarshal(raw, &legacy) == nil && len(legacy.NotificationWebhooks) > 0 {
+		loaded.NotificationChannels = append(loaded.NotificationChannels, legacyWebhookChannels(legacy.NotificationWebhooks)...)
+	}
+	if err := loaded.validate(); err != nil {
+		logWarn("settings_file_invalid", map[string]any{"error": err.Error()})
+		return defaultSettings()
+	}
+	return loaded
+}
+
+func (s *settingsStore) get() Settings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	st := s.current
+	st.NotificationChannels = make([]NotificationChannel, 0, len(s.current.NotificationChannels))
+	for _, c := range s.current.NotificationChannels {
+		st.NotificationChannels = append(st.NotificationChannels, c.clone())
+	}
+	return st
+}
+
+// changed returns a channel that is closed on the next update.
+func (s *settingsStore) changed() <-chan struct{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.changes
+}
+
+// update validates and persists the patched settings, then applies them.
+func (s *settingsStore) update(p SettingsPatch) (Settings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := s.current.apply(p)
+	if err := next.validate(); err != nil {
+		return s.current, err
+	}
+	if p.SummarySMTPPassword != nil {
+		summarySMTP := next.SummarySMTP
+		if err := normalizeSMTPSettings(&summarySMTP, *p.SummarySMTPPassword); err != nil {
+			return s.current, ValidationError{Msg: "summarySmtp: " + err.Error()}
+		}
+		if err := saveSummarySMTPPassword(filepath.Dir(s.path), *p.SummarySMTPPassword); err != nil {
+			return s.current, err
+		}
+	}
+	b, err := json.MarshalIndent(next, "", "  ")
+	if err != nil {
+		return s.current, err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return s.current, err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return s.current, err
+	}
+	s.current = next
+	publishSettings(next)
+	close(s.changes)
+	s.changes = make(chan struct{})
+	return next, nil
+}
+
+func (s *Server) handleSettings(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		capacity, err := s.profileCapacity(r.Context())
+		if err != nil {
+			http.Error(w, "Failed to load profiles: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"ok":                     true,
+			"settings":               s.settings.get(),
+			"effective":              effectiveSettings(),
+			"capacity":               capacity,
+			"summarySmtpPasswordSet": loadSummarySMTPPassword(appCfg.DataDir) != "",
+		})
+	case http.MethodPut:
+		var patch SettingsPatch
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&patch); err != nil {
+			http.Error(w, "invalid JSON body", bodyErrorStatus(err))
+			return
+		}
+		before := s.settings.get()
+		// Hooks run commands on this computer, so they can only be turned
+		// on from it, not by a remote account or an API token.
+		if patch.AllowActionHooks != nil && *patch.AllowActionHooks && !before.AllowActionHooks && (!isLoopbackRequest(r) || isTokenRequest(r)) {
+			http.Error(w, "Forbidden: action hooks can only be turned on from this computer", http.StatusForbidden)
+			return
+		}
+		// Command channels run shell commands too.
+		if patch.NotificationChannels != nil && commandChannelsChanged(before.NotificationChannels, *patch.NotificationChannels) && (!isLoopbackRequest(r) || isTokenRequest(r)) {
+			http.Error(w, "Forbidden: command channels can only be set up from this computer", http.StatusForbidden)
+			return
+		}
+		updated, err := s.settings.update(patch)
+		if err != nil {
+			var ve ValidationError
+			if errors.As(err, &ve) {
+				http.Error(w, "Validation error: "+ve.Error(), http.StatusBadRequest)
+				return
+			}
+			http.Error(w, "Failed to save settings: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		logInfo("settings_updated", map[string]any{
+			"log_level":       updated.LogLevel,
+			"health_interval": updated.HealthInterval,
+			"update_channel":  updated.UpdateChannel,
+			"channels":        len(updated.NotificationChannels),
+			"max_profiles":    updated.MaxProfiles,
+			"open_browser":    updated.OpenBrowser,
+			"weekly_summary":  updated.WeeklySummary,
+			"docker_host":     updated.DockerHost,
+			"action_hooks":    updated.AllowActionHooks,
+		})
+		if before.UpdateChannel != updated.UpdateChannel {
+			go s.refreshNotifications(context.Background())
+		}
+		payload := map[string]any{"ok": true, "settings": updated, "effective": effectiveSettings()}
+		// The settings are saved by now; a capacity report that cannot be
+		// built must not turn that into an error.
+		if capacity, err := s.profileCapacity(r.Context()); err == nil {
+			payload["capacity"] = capacity
+			if before.MaxProfiles != updated.MaxProfiles && len(capacity.Warnings) > 0 {
+				logWarn("profile_capacity_warning", map[string]any{"max_profiles": capacity.Max, "warnings": capacity.Warnings})
+			}
+		}
+		writeJSON(w, http.StatusOK, payload)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// effectiveSettings reports the limits in force, whether they come from the
+// settings or the environment.
+func effectiveSettings() map[string]any {
+	portMin, portMax := profilePortRange()
+	return map[string]any{
+		"maxProfiles":    maxProfilesLimit(),
+		"profilePortMin": portMin,
+		"profilePortMax": portMax,
+	}
+}
+
+// postWebhook delivers one JSON body. Webhook URLs often embed a secret, so
+// only the host is logged or returned.
+func postWebhook(client *http.Client, hook string, body []byte) error {
+	host := hook
+	if u, err := url.Parse(hook); err == nil {
+		host = u.Host
+	}
+	resp, err := client.Post(hook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		logWarn("notification_webhook_failed", map[string]any{"host": host, "error": err.Error()})
+		return fmt.Errorf("webhook %s: %w", host, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		logWarn("notification_webhook_failed", map[string]any{"host": host, "status": resp.StatusCode})
+		return fmt.Errorf("webhook %s: status %d", host, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package launcher
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSettingsUpdatePersistsAndValidates(t *testing.T) {
+	dir := t.TempDir()
+	srv := &Server{settings: newSettingsStore(dir)}
+	defer publishSettings(defaultSettings())
+
+	put := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.handleSettings(rec, httptest.NewRequest(http.MethodPut, "/api/settings", strings.NewReader(body)))
+		return rec
+	}
+	for _, body := range []string{
+		`{"logLevel":"verbose"}`,
+		`{"healthInterval":"1s"}`,
+		`{"updateChannel":"nightly"}`,
+		`{"notificationWebhooks":["https://example.com/hook"]}`,
+		`{"notificationChannels":[{"id":"ops","type":"webhook","url":"ftp://example.com/hook"}]}`,
+		`{"notificationChannels":[{"id":"ops","type":"pager"}]}`,
+		`{"notificationChannels":[{"id":"ops","type":"desktop","events":["everything"]}]}`,
+		`{"notificationChannels":[{"id":"ops","type":"desktop","template":"{{.Nope}}"}]}`,
+		`{"notificationChannels":[{"id":"ops","type":"smtp","to":"ops@example.com"}]}`,
+		`{"notificationChannels":[{"id":"ops","type":"desktop"},{"id":"OPS","type":"desktop"}]}`,
+		`{"maxProfiles":500}`,
+		`{"profilePortMin":2000}`,
+		`{"profilePortMin":2000,"profilePortMax":1500}`,
+		`{"unknown":true}`,
+	} {
+		if rec := put(body); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d %s", body, rec.Code, rec.Body.String())
+		}
+	}
+
+	changed := srv.settings.changed()
+	rec := put(`{"logLevel":"WARN","healthInterval":"30s","notificationChannels":[{"id":" Ops ","type":"Slack","url":"https://hooks.example.com/x","events":["profile_down"," "]}]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %s", rec.Code, rec.Body.String())
+	}
+	select {
+	case <-changed:
+	default:
+		t.Fatal("expected the update to signal a change")
+	}
+	if logMinLevel.Load() != logLevels["warn"] {
+		t.Fatal("expected the log level to apply immediately")
+	}
+
+	reloaded := newSettingsStore(dir).get()
+	if reloaded.LogLevel != "warn" || reloaded.healthInterval() != 30*time.Second ||
+		reloaded.UpdateChannel != updateChannelStable || len(reloaded.NotificationChannels) != 1 {
+		t.Fatalf("expected settings to persist, got %+v", reloaded)
+	}
+	if c := reloaded.NotificationChannels[0]; c.ID != "ops" || c.Type != channelSlack || len(c.Events) != 1 {
+		t.Fatalf("expected the channel normalized, got %+v", c)
+	}
+}
+
+func TestFetchNewestGitHubReleaseSkipsDrafts(t *testing.T) {
+	prevRate := githubRate
+	githubRate = &githubRateLimit{}
+	defer func() { githubRate = prevRate }()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `[{"tag_name":"v3.0.0","draft":true},{"tag_name":"v2.1.0-rc.1","prerelease":true},{"tag_name":"v2.0.0"}]`)
+	}))
+	defer ts.Close()
+
+	release, err := fetchNewestGitHubRelease(ts.URL)
+	if err != nil || release.TagName != "v2.1.0-rc.1" {
+		t.Fatalf("expected the newest published release, got %q (%v)", release.TagName, err)
+	}
+}
+
+func TestSettingsOverrideEnvironmentLimits(t *testing.T) {
+	prev := appCfg
+	appCfg.MaxProfiles, appCfg.ProfilePortMin, appCfg.ProfilePortMax = 3, 8080, 9000
+	defer func() { appCfg = prev }()
+	store := newSettingsStore(t.TempDir())
+	defer publishSettings(defaultSettings())
+
+	if maxProfilesLimit() != 3 {
+		t.Fatalf("expected the environment limit without settings, got %d", maxProfilesLimit())
+	}
+	maxProfiles, portMin, portMax := 5, 20000, 20100
+	if _, err := store.update(SettingsPatch{MaxProfiles: &maxProfiles, ProfilePortMin: &portMin, ProfilePortMax: &portMax}); err != nil {
+		t.Fatal(err)
+	}
+	if got := maxProfilesLimit(); got != 5 {
+		t.Fatalf("expected the settings limit, got %d", got)
+	}
+	if min, max := profilePortRange(); min != 20000 || max != 20100 {
+		t.Fatalf("expected the settings port range, got %d-%d", min, max)
+	}
+	if p := defaultProfile(); p.Ports[0].Host != 20000 {
+		t.Fatalf("expected new profiles to start in the settings range, got %d", p.Ports[0].Host)
+	}
+}
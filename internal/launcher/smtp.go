@@ -0,0 +1,213 @@
+package launcher
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	smtpSecurityStartTLS = "starttls"
+	smtpSecurityTLS      = "tls"
+	smtpSecurityNone     = "none"
+
+	smtpPasswordKey  = "SMTP_PASSWORD"
+	smtpTestTimeout  = 20 * time.Second
+	smtpTestSubject  = "Kimmio Launcher test email"
+	maxSMTPFieldSize = 254
+)
+
+// SMTPSettings configures outbound email for the app. The password is not
+// part of the profile: it travels as SMTP_PASSWORD in the env and is kept
+// in the profile's secrets file like the other credentials.
+type SMTPSettings struct {
+	Host     string `json:"host,omitempty"`
+	Port     int    `json:"port,omitempty"`
+	Username string `json:"username,omitempty"`
+	From     string `json:"from,omitempty"`
+	Security string `json:"security,omitempty"`
+}
+
+func (c SMTPSettings) configured() bool {
+	return c.Host != ""
+}
+
+func normalizeSMTPSettings(c *SMTPSettings, password string) error {
+	c.Host = strings.ToLower(strings.TrimSpace(c.Host))
+	c.Username = strings.TrimSpace(c.Username)
+	c.From = strings.TrimSpace(c.From)
+	c.Security = strings.ToLower(strings.TrimSpace(c.Security))
+	if !c.configured() {
+		if c.Port != 0 || c.Username != "" || c.From != "" || password != "" {
+			return fieldError("smtpHost", "smtp.host_required", "SMTP host is required when other SMTP settings are set")
+		}
+		c.Security = ""
+		return nil
+	}
+	if len(c.Host) > maxSMTPFieldSize || len(c.Username) > maxSMTPFieldSize || len(c.From) > maxSMTPFieldSize {
+		return fieldError("smtpHost", "smtp.length", fmt.Sprintf("SMTP settings must be at most %d characters", maxSMTPFieldSize))
+	}
+	if net.ParseIP(c.Host) == nil && !isValidDomain(c.Host) {
+		return fieldError("smtpHost", "smtp.host", "SMTP host must be a hostname or IP address")
+	}
+	switch c.Security {
+	case "":
+		c.Security = smtpSecurityStartTLS
+	case smtpSecurityStartTLS, smtpSecurityTLS, smtpSecurityNone:
+	default:
+		return fieldError("smtpSecurity", "smtp.security", "SMTP security must be starttls, tls or none")
+	}
+	if c.Port == 0 {
+		c.Port = defaultSMTPPort(c.Security)
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		return fieldError("smtpPort", "port.range", "SMTP port must be between 1 and 65535")
+	}
+	if c.From == "" {
+		return fieldError("smtpFrom", "smtp.from_required", "SMTP from address is required")
+	}
+	addr, err := mail.ParseAddress(c.From)
+	if err != nil {
+		return fieldError("smtpFrom", "smtp.from", "SMTP from must be an email address")
+	}
+	c.From = addr.String()
+	if strings.ContainsAny(password, "\r\n") {
+		return fieldError("smtpPassword", "smtp.password", "SMTP password must not contain line breaks")
+	}
+	if password != "" && c.Username == "" {
+		return fieldError("smtpUsername", "smtp.username_required", "SMTP username is required when a password is set")
+	}
+	return nil
+}
+
+func defaultSMTPPort(security string) int {
+	switch security {
+	case smtpSecurityTLS:
+		return 465
+	case smtpSecurityNone:
+		return 25
+	default:
+		return 587
+	}
+}
+
+// sendTestEmail delivers a short message with the profile's settings. It
+// runs from the launcher, so it proves the server and credentials work but
+// not that the app container can reach a host only visible from inside it.
+func sendTestEmail(ctx context.Context, cfg SMTPSettings, password, to string) error {
+	return sendEmail(ctx, cfg, password, to, smtpTestSubject, "SMTP settings for this Kimmio instance work.\n")
+}
+
+// sendEmail delivers a plain text message. body uses \n line endings.
+func sendEmail(ctx context.Context, cfg SMTPSettings, password, to, subject, body string) error {
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return err
+	}
+	rcpt, err := mail.ParseAddress(to)
+	if err != nil {
+		return errors.New("recipient must be an email address")
+	}
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	dialer := &net.Dialer{}
+	var conn net.Conn
+	if cfg.Security == smtpSecurityTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: cfg.Host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("connect to %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if cfg.Security == smtpSecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("server does not offer STARTTLS; choose tls or none")
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: cfg.Host}); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+	if cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", cfg.Username, password, cfg.Host)); err != nil {
+			return fmt.Errorf("authenticate: %w", err)
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(rcpt.Address); err != nil {
+		return err
+	}
+	wc, err := client.Data()
+	if err != nil {
+		return err
+	}
+	msg := "From: " + from.String() + "\r\n" +
+		"To: " + rcpt.String() + "\r\n" +
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n" +
+		"Date: " + time.Now().Format(time.RFC1123Z) + "\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" +
+		strings.ReplaceAll(body, "\n", "\r\n")
+	if _, err := wc.Write([]byte(msg)); err != nil {
+		return err
+	}
+	if err := wc.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func (s *Server) handleProfileTestEmail(w http.ResponseWriter, r *http.Request, id string) {
+	var body struct {
+		To string `json:"to"`
+	}
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid JSON body", bodyErrorStatus(err))
+			return
+		}
+	}
+	profile, err := s.Profiles().Get(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatusForError(err))
+		return
+	}
+	if !profile.SMTP.configured() {
+		http.Error(w, "SMTP is not configured for this profile", http.StatusBadRequest)
+		return
+	}
+	to := strings.TrimSpace(body.To)
+	if to == "" {
+		to = profile.SMTP.From
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), smtpTestTimeout)
+	defer cancel()
+	if err := sendTestEmail(ctx, profile.SMTP, loadProfileSecrets(id)[smtpPasswordKey], to); err != nil {
+		logWarn("smtp_test_failed", map[string]any{"profile_id": id, "host": profile.SMTP.Host, "error": err.Error()})
+		http.Error(w, "Test email failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	logInfo("smtp_test_sent", map[string]any{"profile_id": id, "host": profile.SMTP.Host})
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "sent": true, "to": to})
+}
@@ -0,0 +1,113 @@
+package launcher
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizeSMTPSettings(t *testing.T) {
+	c := SMTPSettings{Host: " SMTP.Example.com ", Username: "mailer", From: "Kimmio <noreply@example.com>"}
+	if err := normalizeSMTPSettings(&c, "pw"); err != nil {
+		t.Fatal(err)
+	}
+	if c.Host != "smtp.example.com" || c.Port != 587 || c.Security != smtpSecurityStartTLS {
+		t.Fatalf("unexpected defaults: %+v", c)
+	}
+	tlsDefault := SMTPSettings{Host: "smtp.example.com", From: "a@example.com", Security: "TLS"}
+	if err := normalizeSMTPSettings(&tlsDefault, ""); err != nil || tlsDefault.Port != 465 {
+		t.Fatalf("expected tls default port 465: %+v err=%v", tlsDefault, err)
+	}
+	for _, tc := range []struct {
+		cfg      SMTPSettings
+		password string
+	}{
+		{SMTPSettings{From: "a@example.com"}, ""},
+		{SMTPSettings{Host: "smtp.example.com"}, ""},
+		{SMTPSettings{Host: "smtp.example.com", From: "not an address"}, ""},
+		{SMTPSettings{Host: "smtp://example.com", From: "a@example.com"}, ""},
+		{SMTPSettings{Host: "smtp.example.com", From: "a@example.com", Security: "ssl"}, ""},
+		{SMTPSettings{Host: "smtp.example.com", From: "a@example.com"}, "orphan-password"},
+		{SMTPSettings{Host: "smtp.example.com", From: "a@example.com", Username: "u"}, "line\nbreak"},
+	} {
+		cfg := tc.cfg
+		if err := normalizeSMTPSettings(&cfg, tc.password); err == nil {
+			t.Fatalf("expected %+v to be rejected", tc.cfg)
+		}
+	}
+}
+
+func TestSMTPPasswordIsStoredAsSecret(t *testing.T) {
+	public, secret := splitSecretEnv(map[string]string{smtpPasswordKey: "pw", "APP_DOMAIN": "localhost"})
+	if _, ok := public[smtpPasswordKey]; ok || secret[smtpPasswordKey] != "pw" {
+		t.Fatalf("expected SMTP password in secrets only: public=%v", public)
+	}
+}
+
+func TestSendTestEmail(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) }
+		reply("220 test ESMTP")
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					reply("250 queued")
+					continue
+				}
+				data.WriteString(line)
+				continue
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"):
+				reply("250 test")
+			case cmd == "DATA":
+				inData = true
+				reply("354 go ahead")
+			case cmd == "QUIT":
+				reply("221 bye")
+				received <- data.String()
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	port := ln.Addr().(*net.TCPAddr).Port
+	cfg := SMTPSettings{Host: "127.0.0.1", Port: port, From: "noreply@example.com", Security: smtpSecurityNone}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sendTestEmail(ctx, cfg, "", "ops@example.com"); err != nil {
+		t.Fatalf("sendTestEmail: %v", err)
+	}
+	msg := <-received
+	if !strings.Contains(msg, "Subject: "+smtpTestSubject) || !strings.Contains(msg, "To: <ops@example.com>") {
+		t.Fatalf("unexpected message:\n%s", msg)
+	}
+
+	if err := sendTestEmail(ctx, cfg, "", "not-an-address"); err == nil {
+		t.Fatalf("expected invalid recipient to be rejected")
+	}
+}
@@ -0,0 +1,123 @@
+package launcher
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	ssoProviderGoogle    = "google"
+	ssoProviderGitHub    = "github"
+	ssoProviderMicrosoft = "microsoft"
+	ssoProviderOIDC      = "oidc"
+
+	ssoClientSecretKey = "SSO_CLIENT_SECRET"
+	ssoCallbackPath    = "/auth/sso/callback"
+)
+
+var (
+	ssoClientIDRe = regexp.MustCompile(`^[A-Za-z0-9._@:/-]{1,255}$`)
+	ssoTenantRe   = regexp.MustCompile(`^[A-Za-z0-9.-]{1,128}$`)
+)
+
+// SSOSettings is the guided form of the app's SSO_* variables. The client
+// secret is kept in the profile's secrets file as SSO_CLIENT_SECRET; the
+// issuer and redirect URL are derived so users only enter what their
+// identity provider shows them.
+type SSOSettings struct {
+	Provider  string `json:"provider,omitempty"`
+	ClientID  string `json:"clientId,omitempty"`
+	Tenant    string `json:"tenant,omitempty"`
+	IssuerURL string `json:"issuerUrl,omitempty"`
+}
+
+func (c SSOSettings) configured() bool {
+	return c.Provider != ""
+}
+
+func normalizeSSOSettings(c *SSOSettings, clientSecret string) error {
+	c.Provider = strings.ToLower(strings.TrimSpace(c.Provider))
+	c.ClientID = strings.TrimSpace(c.ClientID)
+	c.Tenant = strings.TrimSpace(c.Tenant)
+	c.IssuerURL = strings.TrimRight(strings.TrimSpace(c.IssuerURL), "/")
+	if !c.configured() {
+		if c.ClientID != "" || c.Tenant != "" || c.IssuerURL != "" || clientSecret != "" {
+			return fieldError("ssoProvider", "sso.provider_required", "SSO provider is required when other SSO settings are set")
+		}
+		return nil
+	}
+	if !ssoClientIDRe.MatchString(c.ClientID) {
+		return fieldError("ssoClientId", "sso.client_id", "SSO client ID is required and may not contain spaces")
+	}
+	if strings.TrimSpace(clientSecret) == "" {
+		return fieldError("ssoClientSecret", "sso.secret_required", "SSO client secret is required")
+	}
+	if strings.ContainsAny(clientSecret, "\r\n") {
+		return fieldError("ssoClientSecret", "sso.secret", "SSO client secret must not contain line breaks")
+	}
+	switch c.Provider {
+	case ssoProviderGoogle:
+		c.Tenant = ""
+		c.IssuerURL = "https://accounts.google.com"
+	case ssoProviderGitHub:
+		// GitHub speaks plain OAuth 2.0; there is no issuer to discover.
+		c.Tenant = ""
+		c.IssuerURL = ""
+	case ssoProviderMicrosoft:
+		if c.Tenant == "" {
+			c.Tenant = "common"
+		}
+		if !ssoTenantRe.MatchString(c.Tenant) {
+			return fieldError("ssoTenant", "sso.tenant", "Microsoft tenant must be a tenant ID, domain, common or organizations")
+		}
+		c.IssuerURL = "https://login.microsoftonline.com/" + c.Tenant + "/v2.0"
+	case ssoProviderOIDC:
+		c.Tenant = ""
+		u, err := url.Parse(c.IssuerURL)
+		if err != nil || u.Scheme != "https" || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+			return fieldError("ssoIssuerUrl", "sso.issuer", "OIDC issuer URL must be an https URL without query or fragment")
+		}
+	default:
+		return fieldError("ssoProvider", "sso.provider", "SSO provider must be google, github, microsoft or oidc")
+	}
+	return nil
+}
+
+// profileBaseURL is the address users reach the app on, matching the
+// DOMAIN value the compose env hands the app.
+func profileBaseURL(profile ProfileRequest) string {
+	hostPort := 8080
+	if len(profile.Ports) > 0 && profile.Ports[0].Host > 0 {
+		hostPort = profile.Ports[0].Host
+	}
+	domain := strings.TrimSpace(profileEnvValue(profile, "APP_DOMAIN", "localhost"))
+	if strings.EqualFold(domain, "localhost") {
+		return "http://localhost:" + strconv.Itoa(hostPort)
+	}
+	return "https://" + domain
+}
+
+// ssoRedirectURL is the callback URL to register with the identity
+// provider.
+func ssoRedirectURL(profile ProfileRequest) string {
+	return profileBaseURL(profile) + ssoCallbackPath
+}
+
+// ssoEnvLines renders the SSO_* variables; they are always present so the
+// compose file never references an unset variable.
+func ssoEnvLines(profile ProfileRequest, env map[string]string) []string {
+	c := profile.SSO
+	redirect := ""
+	if c.configured() {
+		redirect = ssoRedirectURL(profile)
+	}
+	return []string{
+		"SSO_PROVIDER=" + envValue(env, "SSO_PROVIDER", c.Provider),
+		"SSO_CLIENT_ID=" + envValue(env, "SSO_CLIENT_ID", c.ClientID),
+		"SSO_CLIENT_SECRET=" + envValue(env, ssoClientSecretKey, ""),
+		"SSO_ISSUER_URL=" + envValue(env, "SSO_ISSUER_URL", c.IssuerURL),
+		"SSO_REDIRECT_URL=" + envValue(env, "SSO_REDIRECT_URL", redirect),
+	}
+}
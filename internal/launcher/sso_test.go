@@ -0,0 +1,57 @@
+package launcher
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeSSOSettingsDerivesIssuer(t *testing.T) {
+	ms := SSOSettings{Provider: " Microsoft ", ClientID: "abc-123"}
+	if err := normalizeSSOSettings(&ms, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if ms.Tenant != "common" || ms.IssuerURL != "https://login.microsoftonline.com/common/v2.0" {
+		t.Fatalf("unexpected microsoft settings: %+v", ms)
+	}
+	gh := SSOSettings{Provider: "github", ClientID: "Iv1.abc", IssuerURL: "https://ignored.example"}
+	if err := normalizeSSOSettings(&gh, "secret"); err != nil || gh.IssuerURL != "" {
+		t.Fatalf("expected github without issuer: %+v err=%v", gh, err)
+	}
+	for _, tc := range []struct {
+		cfg    SSOSettings
+		secret string
+	}{
+		{SSOSettings{ClientID: "abc"}, ""},
+		{SSOSettings{Provider: "okta", ClientID: "abc"}, "s"},
+		{SSOSettings{Provider: "google", ClientID: "abc"}, ""},
+		{SSOSettings{Provider: "google", ClientID: "has space"}, "s"},
+		{SSOSettings{Provider: "oidc", ClientID: "abc", IssuerURL: "http://id.example.com"}, "s"},
+		{SSOSettings{Provider: "microsoft", ClientID: "abc", Tenant: "bad/tenant"}, "s"},
+	} {
+		cfg := tc.cfg
+		if err := normalizeSSOSettings(&cfg, tc.secret); err == nil {
+			t.Fatalf("expected %+v to be rejected", tc.cfg)
+		}
+	}
+}
+
+func TestSSOEnvUsesDerivedRedirectURL(t *testing.T) {
+	p := ProfileRequest{
+		ID:    "alpha",
+		Ports: []PortMapping{{Container: 3000, Host: 8088}},
+		SSO:   SSOSettings{Provider: "google", ClientID: "cid", IssuerURL: "https://accounts.google.com"},
+	}
+	env := strings.Join(ssoEnvLines(p, map[string]string{ssoClientSecretKey: "shh"}), "\n")
+	for _, want := range []string{"SSO_REDIRECT_URL=http://localhost:8088/auth/sso/callback", "SSO_CLIENT_SECRET=shh", "SSO_CLIENT_ID=cid"} {
+		if !strings.Contains(env, want) {
+			t.Fatalf("expected %q in env:\n%s", want, env)
+		}
+	}
+	p.Env = map[string]string{"APP_DOMAIN": "app.example.com"}
+	if got := ssoRedirectURL(p); got != "https://app.example.com/auth/sso/callback" {
+		t.Fatalf("unexpected redirect url %q", got)
+	}
+	if env := strings.Join(ssoEnvLines(ProfileRequest{ID: "beta"}, nil), "\n"); !strings.Contains(env, "SSO_REDIRECT_URL=\n") && !strings.HasSuffix(env, "SSO_REDIRECT_URL=") {
+		t.Fatalf("expected empty redirect url without SSO:\n%s", env)
+	}
+}
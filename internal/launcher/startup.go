@@ -0,0 +1,96 @@
+package launcher
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Desktop shells and supervisors that start the launcher need its port
+// without parsing the banner. They can pass --port-file, ask for the port
+// alone with --print-port-and-exit, or read the startup status line the
+// launcher prints on stdout once it accepts connections.
+
+// RunOptions are the command-line flags of the server itself.
+type RunOptions struct {
+	// PortFile is written with the port once the launcher listens, in
+	// addition to launcher-port in the data directory.
+	PortFile string
+	// PrintPortAndExit prints the port the launcher would listen on, or
+	// the port of the launcher already running, and exits.
+	PrintPortAndExit bool
+}
+
+// ParseRunFlags reads the server flags. When ok is false the process
+// should exit with exitCode, e.g. after -h or an unknown flag.
+func ParseRunFlags(args []string, stderr io.Writer) (opts RunOptions, exitCode int, ok bool) {
+	fs := flag.NewFlagSet("launcher", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&opts.PortFile, "port-file", "", "also write the listen port to this file")
+	fs.BoolVar(&opts.PrintPortAndExit, "print-port-and-exit", false, "print the port the launcher would use and exit")
+	if err := fs.Parse(normalizeCLIArgs(args)); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return opts, exitOK, false
+		}
+		return opts, exitUsage, false
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "Unknown command: %s\n", fs.Arg(0))
+		return opts, exitUsage, false
+	}
+	opts.PortFile = strings.TrimSpace(opts.PortFile)
+	return opts, exitOK, true
+}
+
+// startupStatus is the one JSON line on stdout that reports how startup
+// went. Log records on stdout have "event" and "level" instead of
+// "startup".
+type startupStatus struct {
+	// Startup is "ready" once the launcher accepts connections, "reused"
+	// when another launcher already serves the port, or "failed".
+	Startup string `json:"startup"`
+	Port    int    `json:"port,omitempty"`
+	URL     string `json:"url,omitempty"`
+	PID     int    `json:"pid"`
+	Version string `json:"version"`
+	DataDir string `json:"dataDir"`
+	Error   string `json:"error,omitempty"`
+}
+
+func writeStartupStatus(w io.Writer, status string, port int, err error) {
+	st := startupStatus{
+		Startup: status,
+		Port:    port,
+		PID:     os.Getpid(),
+		Version: launcherAppVersion,
+		DataDir: appCfg.DataDir,
+	}
+	if port > 0 {
+		st.URL = fmt.Sprintf("http://localhost:%d", port)
+	}
+	if err != nil {
+		st.Error = err.Error()
+	}
+	b, _ := json.Marshal(st)
+	// One write, so the line does not interleave with log records.
+	_, _ = w.Write(append(b, '\n'))
+}
+
+// writePortFile replaces path with the port, so a reader never sees a
+// partly written file.
+func writePortFile(path string, port int) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.Itoa(port)+"\n"), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
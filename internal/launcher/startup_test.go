@@ -0,0 +1,65 @@
+package launcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseRunFlags(t *testing.T) {
+	var stderr bytes.Buffer
+	opts, _, ok := ParseRunFlags([]string{"--port-file", " /tmp/port ", "--print-port-and-exit"}, &stderr)
+	if !ok || opts.PortFile != "/tmp/port" || !opts.PrintPortAndExit {
+		t.Fatalf("expected both flags parsed, got %+v ok=%v", opts, ok)
+	}
+	if _, code, ok := ParseRunFlags([]string{"-h"}, &stderr); ok || code != exitOK {
+		t.Fatalf("expected -h to exit 0, got %d ok=%v", code, ok)
+	}
+	if _, code, ok := ParseRunFlags([]string{"--bogus"}, &stderr); ok || code != exitUsage {
+		t.Fatalf("expected an unknown flag to exit %d, got %d ok=%v", exitUsage, code, ok)
+	}
+	if _, code, ok := ParseRunFlags([]string{"serve"}, &stderr); ok || code != exitUsage {
+		t.Fatalf("expected an unknown command to exit %d, got %d ok=%v", exitUsage, code, ok)
+	}
+}
+
+func TestStartupStatusAndPortFile(t *testing.T) {
+	dir := t.TempDir()
+	defer func(orig string) { appCfg.DataDir = orig }(appCfg.DataDir)
+	appCfg.DataDir = dir
+
+	extra := filepath.Join(dir, "shell", "port")
+	writeLauncherPortFile(17331, extra)
+	for _, path := range []string{filepath.Join(dir, "launcher-port"), extra} {
+		if b, err := os.ReadFile(path); err != nil || string(b) != "17331\n" {
+			t.Fatalf("expected %s to hold the port, got %q %v", path, b, err)
+		}
+	}
+	if _, err := os.Stat(extra + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("expected no temporary file left, got %v", err)
+	}
+
+	var out bytes.Buffer
+	writeStartupStatus(&out, "ready", 17331, nil)
+	writeStartupStatus(&out, "failed", 17332, errors.New("address already in use"))
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("expected one line per status, got %q", out.String())
+	}
+	var ready, failed startupStatus
+	if err := json.Unmarshal(lines[0], &ready); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(lines[1], &failed); err != nil {
+		t.Fatal(err)
+	}
+	if ready.Startup != "ready" || ready.URL != "http://localhost:17331" || ready.PID != os.Getpid() || ready.DataDir != dir || ready.Error != "" {
+		t.Fatalf("unexpected ready status %+v", ready)
+	}
+	if failed.Startup != "failed" || failed.Error != "address already in use" {
+		t.Fatalf("unexpected failed status %+v", failed)
+	}
+}
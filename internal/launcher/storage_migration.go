@@ -0,0 +1,324 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A storage migration moves a profile's data between its named volumes and
+// a data directory, or from one data directory to another, as a job. The
+// stack is stopped, each volume is copied by a helper container and the
+// copy is compared with the original. Only when every copy matches does
+// DataDir switch, which is what the compose file is written from; then the
+// old copies are removed and a profile that was running starts again. A
+// failed copy or check removes the partial copies and leaves the profile
+// on its old storage.
+
+// storageMigrationTimeout bounds a migration; copying a large database
+// takes longer than an action.
+const storageMigrationTimeout = 2 * time.Hour
+
+// dataDigest sums up the files of one data location, so two copies can be
+// compared without listing them.
+type dataDigest struct {
+	Files int
+	Bytes int64
+	Sum   string
+}
+
+func (d dataDigest) String() string {
+	return fmt.Sprintf("%d files, %s", d.Files, formatBytes(d.Bytes))
+}
+
+// dataLocation is where volume v of profile keeps its data with dataDir as
+// the data directory: a named volume, or a host directory. Docker mounts
+// either with -v.
+func dataLocation(profile ProfileRequest, dataDir string, v profileDataVolume) string {
+	if dataDir == "" {
+		return profileInstanceID(profile) + "_" + v.Volume
+	}
+	return filepath.Join(dataDir, v.Dir)
+}
+
+// The docker calls of a migration; tests replace them.
+var (
+	copyDataLocation = func(ctx context.Context, from, to string) error {
+		return runDataHelper(ctx, []string{"-v", from + ":/from:ro", "-v", to + ":/to"}, "cp -a /from/. /to/")
+	}
+	digestDataLocation = func(ctx context.Context, location string) (dataDigest, error) {
+		out, err := runDataHelperOutput(ctx, []string{"-v", location + ":/data:ro"},
+			"cd /data && find . -type f -print0 | sort -z | xargs -0 -r sha256sum | sha256sum | cut -d' ' -f1"+
+				" && find . -type f | wc -l"+
+				" && find . -type f -exec stat -c %s {} + | awk '{s+=$1} END {print s+0}'")
+		if err != nil {
+			return dataDigest{}, err
+		}
+		return parseDataDigest(out)
+	}
+	clearDataLocation = func(ctx context.Context, location string) error {
+		return runDataHelper(ctx, []string{"-v", location + ":/data"}, "find /data -mindepth 1 -delete")
+	}
+	// createDataVolume labels the volume as compose would, so compose
+	// adopts it on the next start.
+	createDataVolume = func(ctx context.Context, profile ProfileRequest, v profileDataVolume) error {
+		labels := map[string]string{
+			"com.docker.compose.project": dockerProjectName(profile.ID),
+			"com.docker.compose.volume":  v.Volume,
+			labelManagedBy:               managedByLauncher,
+			labelProfileID:               profile.ID,
+		}
+		name := dataLocation(profile, "", v)
+		if engine, err := newDockerEngine(); err == nil {
+			return engine.createVolume(ctx, name, labels)
+		}
+		dockerBin, err := dockerBinaryPath()
+		if err != nil {
+			return err
+		}
+		args := []string{"volume", "create"}
+		for key, value := range labels {
+			args = append(args, "--label", key+"="+value)
+		}
+		out, err := dockerCommandWithContext(ctx, dockerBin, append(args, name)...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+)
+
+func parseDataDigest(out string) (dataDigest, error) {
+	fields := strings.Fields(out)
+	if len(fields) < 3 {
+		return dataDigest{}, fmt.Errorf("unexpected digest output %q", strings.TrimSpace(out))
+	}
+	fields = fields[len(fields)-3:]
+	files, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return dataDigest{}, fmt.Errorf("unexpected file count %q", fields[1])
+	}
+	size, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return dataDigest{}, fmt.Errorf("unexpected size %q", fields[2])
+	}
+	return dataDigest{Files: files, Bytes: size, Sum: fields[0]}, nil
+}
+
+// StartStorageMigration queues a job moving the profile's data to dataDir,
+// or back to named volumes when dataDir is empty.
+func (p *ProfileService) StartStorageMigration(ctx context.Context, id, dataDir string, expectedRevision int) (*ActionJob, error) {
+	id = normalizeProfileID(id)
+	if !profileIDRe.MatchString(id) {
+		return nil, ValidationError{Msg: "invalid profile id"}
+	}
+	dataDir, err := normalizeProfileDataDir(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	s := p.srv
+	store, idx, err := s.getProfileForAction(ctx, id)
+	if err != nil {
+		return nil, normalizeNotFound(err)
+	}
+	profile := store.Profiles[idx]
+	if expectedRevision > 0 && profile.Revision != expectedRevision {
+		return nil, RevisionConflictError{Expected: expectedRevision, Current: profile}
+	}
+	if err := checkTrashedAction(store, idx, "migrate-storage"); err != nil {
+		return nil, err
+	}
+	if err := checkArchivedAction(profile, "migrate-storage"); err != nil {
+		return nil, err
+	}
+	if profile.DataDir == dataDir {
+		return nil, fieldError("dataDir", "dataDir.unchanged", "the profile already keeps its data there")
+	}
+	if err := checkDataDirConflicts(id, dataDir, store.Profiles); err != nil {
+		return nil, err
+	}
+	if profile.DataDir != "" && dataDir != "" && (pathWithin(dataDir, profile.DataDir) || pathWithin(profile.DataDir, dataDir)) {
+		return nil, fieldError("dataDir", "dataDir.nested", "the new data directory must not overlap the current one")
+	}
+	return s.enqueueProfileJob(id, "migrate-storage", func(jobID string, ctx context.Context) error {
+		return s.performStorageMigration(id, dataDir, jobID, ctx)
+	})
+}
+
+func (s *Server) performStorageMigration(id, dataDir, jobID string, parent context.Context) error {
+	ctx, cancel := context.WithTimeout(parent, storageMigrationTimeout)
+	defer cancel()
+	record := context.WithoutCancel(parent)
+
+	store, idx, err := s.getProfileForAction(ctx, id)
+	if err != nil {
+		return err
+	}
+	profile := store.Profiles[idx]
+	from := profile.DataDir
+	wasRunning := profile.Enabled
+	fail := func(err error) error {
+		_ = s.markProfileResult(record, id, "migrate-storage", "failed", err.Error(), "")
+		if wasRunning {
+			s.updateJobStep(jobID, "up", "running", "Starting the stack again on its old storage", 90, "")
+			if upErr := s.performEnable(id, jobID, record); upErr != nil {
+				logWarn("storage_migration_restart_failed", map[string]any{"profile_id": id, "error": upErr.Error()})
+			}
+		}
+		return err
+	}
+
+	if wasRunning {
+		s.updateJobStep(jobID, "down", "running", "Stopping compose stack", 10, "")
+		if err := s.compose.down(ctx, id, false); err != nil {
+			return fail(err)
+		}
+	}
+	if dataDir != "" {
+		if err := prepareProfileDataDir(dataDir); err != nil {
+			return fail(err)
+		}
+	}
+
+	copied := []string{}
+	abort := func(err error) error {
+		for _, location := range copied {
+			if err := removeDataLocation(record, location, dataDir == ""); err != nil {
+				logWarn("storage_migration_cleanup_failed", map[string]any{"profile_id": id, "location": location, "error": err.Error()})
+			}
+		}
+		return fail(err)
+	}
+	moved := []string{}
+	for i, v := range profileDataVolumes {
+		src, dst := dataLocation(profile, from, v), dataLocation(profile, dataDir, v)
+		percent := 20 + 60*i/len(profileDataVolumes)
+		if !dataLocationExists(ctx, src, from == "") {
+			continue
+		}
+		if dataDir == "" {
+			if err := createDataVolume(ctx, profile, v); err != nil {
+				return abort(fmt.Errorf("create volume %s: %w", dst, err))
+			}
+		}
+		existing, err := digestDataLocation(ctx, dst)
+		if err != nil {
+			return abort(fmt.Errorf("check %s: %w", dst, err))
+		}
+		if existing.Files > 0 {
+			return abort(fmt.Errorf("%s already holds %s; empty it or pick another location", dst, existing))
+		}
+		copied = append(copied, dst)
+		s.updateJobStep(jobID, "copy", "running", fmt.Sprintf("Copying %s data to %s", v.Dir, dst), percent, "")
+		if err := copyDataLocation(ctx, src, dst); err != nil {
+			return abort(fmt.Errorf("copy %s to %s: %w", src, dst, err))
+		}
+		s.updateJobStep(jobID, "verify", "running", fmt.Sprintf("Verifying %s data", v.Dir), percent+5, "")
+		want, err := digestDataLocation(ctx, src)
+		if err != nil {
+			return abort(fmt.Errorf("check %s: %w", src, err))
+		}
+		got, err := digestDataLocation(ctx, dst)
+		if err != nil {
+			return abort(fmt.Errorf("check %s: %w", dst, err))
+		}
+		if got != want {
+			return abort(fmt.Errorf("the copy of %s does not match: %s holds %s, %s holds %s", v.Dir, src, want, dst, got))
+		}
+		logInfo("storage_migration_verified", map[string]any{"profile_id": id, "from": src, "to": dst, "files": want.Files, "bytes": want.Bytes})
+		moved = append(moved, src)
+	}
+
+	s.updateJobStep(jobID, "switch", "running", "Switching the stack to the new storage", 85, "")
+	if err := s.switchProfileDataDir(record, id, dataDir); err != nil {
+		return abort(err)
+	}
+	for _, location := range moved {
+		if err := removeDataLocation(record, location, from == ""); err != nil {
+			logWarn("storage_migration_cleanup_failed", map[string]any{"profile_id": id, "location": location, "error": err.Error()})
+		}
+	}
+	target := dataDir
+	if target == "" {
+		target = "Docker volumes"
+	}
+	auditLog("INFO", "profile_storage_migrated", map[string]any{"profile_id": id, "from": from, "to": dataDir, "volumes": len(moved)})
+	if err := s.markProfileResult(record, id, "migrate-storage", "success", "Data moved to "+target, ""); err != nil {
+		return err
+	}
+	if wasRunning {
+		return s.performEnable(id, jobID, parent)
+	}
+	return nil
+}
+
+// dataLocationExists reports whether there is data to move at location.
+// A profile that never started has no volumes yet.
+func dataLocationExists(ctx context.Context, location string, volume bool) bool {
+	if volume {
+		return dockerVolumeExists(ctx, location)
+	}
+	_, err := os.Stat(location)
+	return err == nil
+}
+
+// removeDataLocation removes a named volume, or empties a data
+// subdirectory; the directories stay, as the user chose them.
+func removeDataLocation(ctx context.Context, location string, volume bool) error {
+	if volume {
+		return removeDockerVolume(ctx, location)
+	}
+	return clearDataLocation(ctx, location)
+}
+
+func (s *Server) switchProfileDataDir(ctx context.Context, id, dataDir string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	store, err := s.loadStoreLocked(ctx)
+	if err != nil {
+		return err
+	}
+	idx := findProfileIndex(store, id)
+	if idx < 0 {
+		return ErrProfileNotFound
+	}
+	store.Profiles[idx].DataDir = dataDir
+	store.Profiles[idx].Revision++
+	return s.writeStoreLocked(store)
+}
+
+// handleProfileStorage serves POST /api/profiles/<id>/storage with a body
+// of {"dataDir": "/mnt/data/shop"}, or an empty dataDir to go back to
+// named volumes.
+func (s *Server) handleProfileStorage(w http.ResponseWriter, r *http.Request, id string) {
+	expectedRevision, err := expectedRevisionFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var body struct {
+		DataDir string `json:"dataDir"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", bodyErrorStatus(err))
+		return
+	}
+	job, err := s.Profiles().StartStorageMigration(r.Context(), id, body.DataDir, expectedRevision)
+	if err != nil {
+		var ve ValidationError
+		if errors.As(err, &ve) {
+			writeValidationError(w, ve)
+			return
+		}
+		s.writeActionError(w, "migrate-storage", err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"ok": true, "jobId": job.ID})
+}
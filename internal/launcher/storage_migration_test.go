@@ -0,0 +1,149 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeDataLocations stands in for the helper containers, keeping a digest
+// per location.
+type fakeDataLocations struct {
+	data    map[string]dataDigest
+	removed []string
+	// corrupt makes the copy to this location differ from its source.
+	corrupt string
+}
+
+func installFakeDataLocations(t *testing.T, f *fakeDataLocations) {
+	t.Helper()
+	exists, remove := dockerVolumeExists, removeDockerVolume
+	copyOld, digestOld, clearOld, createOld := copyDataLocation, digestDataLocation, clearDataLocation, createDataVolume
+	t.Cleanup(func() {
+		dockerVolumeExists, removeDockerVolume = exists, remove
+		copyDataLocation, digestDataLocation, clearDataLocation, createDataVolume = copyOld, digestOld, clearOld, createOld
+	})
+	dockerVolumeExists = func(_ context.Context, name string) bool {
+		_, ok := f.data[name]
+		return ok
+	}
+	removeDockerVolume = func(_ context.Context, name string) error {
+		f.removed = append(f.removed, name)
+		delete(f.data, name)
+		return nil
+	}
+	clearDataLocation = func(_ context.Context, location string) error {
+		f.removed = append(f.removed, location)
+		delete(f.data, location)
+		return nil
+	}
+	createDataVolume = func(_ context.Context, profile ProfileRequest, v profileDataVolume) error {
+		name := dataLocation(profile, "", v)
+		if _, ok := f.data[name]; !ok {
+			f.data[name] = dataDigest{}
+		}
+		return nil
+	}
+	copyDataLocation = func(_ context.Context, from, to string) error {
+		d := f.data[from]
+		if to == f.corrupt {
+			d.Bytes--
+		}
+		f.data[to] = d
+		return nil
+	}
+	digestDataLocation = func(_ context.Context, location string) (dataDigest, error) {
+		return f.data[location], nil
+	}
+}
+
+func TestStorageMigrationToDataDir(t *testing.T) {
+	srv := newServiceTestServer(t)
+	dir := filepath.Join(t.TempDir(), "alpha-data")
+	f := &fakeDataLocations{data: map[string]dataDigest{
+		"alpha_postgres_data": {Files: 1200, Bytes: 48 << 20, Sum: "pg"},
+		"alpha_kimmio_data":   {Files: 3, Bytes: 4096, Sum: "app"},
+	}}
+	installFakeDataLocations(t, f)
+
+	if err := srv.performStorageMigration("alpha", dir, "", context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	p, err := srv.Profiles().Get(context.Background(), "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.DataDir != dir || p.LastAction != "migrate-storage" || p.LastActionStatus != "success" {
+		t.Fatalf("expected the profile on its data directory, got %+v", p)
+	}
+	if f.data[filepath.Join(dir, "postgres")].Sum != "pg" || f.data[filepath.Join(dir, "kimmio")].Sum != "app" {
+		t.Fatalf("expected the volumes copied into the directory, got %v", f.data)
+	}
+	if !slices.Equal(f.removed, []string{"alpha_postgres_data", "alpha_kimmio_data"}) {
+		t.Fatalf("expected the moved volumes removed, got %v", f.removed)
+	}
+
+	// And back again, into freshly created volumes.
+	f.removed = nil
+	if err := srv.performStorageMigration("alpha", "", "", context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := srv.Profiles().Get(context.Background(), "alpha"); p.DataDir != "" {
+		t.Fatalf("expected the profile back on named volumes, got %q", p.DataDir)
+	}
+	if f.data["alpha_postgres_data"].Sum != "pg" || len(f.removed) != 4 {
+		t.Fatalf("expected the data back in volumes and every subdirectory emptied, got %v removed %v", f.data, f.removed)
+	}
+}
+
+func TestStorageMigrationKeepsOldStorageOnMismatch(t *testing.T) {
+	srv := newServiceTestServer(t)
+	dir := filepath.Join(t.TempDir(), "alpha-data")
+	f := &fakeDataLocations{
+		data: map[string]dataDigest{
+			"alpha_postgres_data": {Files: 10, Bytes: 1 << 20, Sum: "pg"},
+			"alpha_redis_data":    {Files: 1, Bytes: 100, Sum: "redis"},
+		},
+		corrupt: filepath.Join(dir, "redis"),
+	}
+	installFakeDataLocations(t, f)
+
+	err := srv.performStorageMigration("alpha", dir, "", context.Background())
+	if err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("expected a verification failure, got %v", err)
+	}
+	p, _ := srv.Profiles().Get(context.Background(), "alpha")
+	if p.DataDir != "" || p.LastActionStatus != "failed" {
+		t.Fatalf("expected the profile left on its volumes, got %+v", p)
+	}
+	if _, ok := f.data["alpha_postgres_data"]; !ok {
+		t.Fatal("the source volumes must be kept")
+	}
+	if !slices.Equal(f.removed, []string{filepath.Join(dir, "postgres"), filepath.Join(dir, "redis")}) {
+		t.Fatalf("expected the partial copies removed, got %v", f.removed)
+	}
+}
+
+func TestStartStorageMigrationValidates(t *testing.T) {
+	srv := newServiceTestServer(t)
+	var ve ValidationError
+	if _, err := srv.Profiles().StartStorageMigration(context.Background(), "alpha", "", 0); !errors.As(err, &ve) || ve.Fields[0].Code != "dataDir.unchanged" {
+		t.Fatalf("expected dataDir.unchanged, got %v", err)
+	}
+	rec := httptest.NewRecorder()
+	srv.handleProfileAction(rec, httptest.NewRequest(http.MethodPost, "/api/profiles/alpha/storage", strings.NewReader(`{"dataDir":"relative/dir"}`)))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "dataDir.relative") {
+		t.Fatalf("expected a relative path to be refused, got %d %s", rec.Code, rec.Body.String())
+	}
+	if _, err := parseDataDigest("abc123\n 12\n 4096\n"); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := parseDataDigest("abc123\n12\n4096\n"); d != (dataDigest{Files: 12, Bytes: 4096, Sum: "abc123"}) {
+		t.Fatalf("unexpected digest %+v", d)
+	}
+}
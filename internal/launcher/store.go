@@ -1,6 +1,7 @@
 package launcher
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -12,22 +13,54 @@ import (
 )
 
 type ProfileRequest struct {
-	ID                   string            `json:"id"`
-	Version              string            `json:"version"`
-	Ports                []PortMapping     `json:"ports"`
-	Env                  map[string]string `json:"env"`
-	Resources            Resources         `json:"resources"`
-	Enabled              bool              `json:"enabled"`
-	Running              bool              `json:"-"`
-	RuntimeStatus        string            `json:"runtimeStatus,omitempty"`
-	StartingUntil        string            `json:"startingUntil,omitempty"`
-	LastAction           string            `json:"lastAction,omitempty"`
-	LastActionStatus     string            `json:"lastActionStatus,omitempty"`
-	LastActionResult     string            `json:"lastActionResult,omitempty"`
-	LastActionAt         string            `json:"lastActionAt,omitempty"`
-	LastRequestedVersion string            `json:"lastRequestedVersion,omitempty"`
-	ActionLog            []string          `json:"actionLog,omitempty"`
-	ActiveJobID          string            `json:"-"`
+	ID                string            `json:"id"`
+	DisplayName       string            `json:"displayName,omitempty"`
+	Version           string            `json:"version"`
+	Labels            []string          `json:"labels,omitempty"`
+	Revision          int               `json:"revision"`
+	Ports             []PortMapping     `json:"ports"`
+	Env               map[string]string `json:"env"`
+	Resources         Resources         `json:"resources"`
+	Health            HealthSettings    `json:"health,omitempty"`
+	MaintenanceWindow string            `json:"maintenanceWindow,omitempty"`
+	RestartSchedule   string            `json:"restartSchedule,omitempty"`
+	StartSchedule     string            `json:"startSchedule,omitempty"`
+	StopSchedule      string            `json:"stopSchedule,omitempty"`
+	AutoStopHours     int               `json:"autoStopHours,omitempty"`
+	WakeOnRequest     bool              `json:"wakeOnRequest,omitempty"`
+	Alerts            AlertSettings     `json:"alerts,omitempty"`
+	Platform          string            `json:"platform,omitempty"`
+	DataDir           string            `json:"dataDir,omitempty"`
+	// ContainerRuntime is the runtime that last started the stack, docker
+	// or podman.
+	ContainerRuntime     string          `json:"containerRuntime,omitempty"`
+	PullPolicy           PullPolicy      `json:"pullPolicy,omitempty"`
+	TimeZone             string          `json:"timeZone,omitempty"`
+	Locale               string          `json:"locale,omitempty"`
+	Network              NetworkSettings `json:"network,omitempty"`
+	SMTP                 SMTPSettings    `json:"smtp,omitempty"`
+	SSO                  SSOSettings     `json:"sso,omitempty"`
+	TLS                  TLSSettings     `json:"tls,omitempty"`
+	Hooks                ActionHooks     `json:"hooks,omitempty"`
+	Enabled              bool            `json:"enabled"`
+	Archived             bool            `json:"archived,omitempty"`
+	ArchivedAt           string          `json:"archivedAt,omitempty"`
+	DeletedAt            string          `json:"deletedAt,omitempty"`
+	Running              bool            `json:"-"`
+	RuntimeStatus        string          `json:"runtimeStatus,omitempty"`
+	Alerting             []string        `json:"alerting,omitempty"`
+	StartingUntil        string          `json:"startingUntil,omitempty"`
+	LastAction           string          `json:"lastAction,omitempty"`
+	LastActionStatus     string          `json:"lastActionStatus,omitempty"`
+	LastActionResult     string          `json:"lastActionResult,omitempty"`
+	LastActionAt         string          `json:"lastActionAt,omitempty"`
+	LastRequestedVersion string          `json:"lastRequestedVersion,omitempty"`
+	ActionLog            []string        `json:"actionLog,omitempty"`
+	ActiveJobID          string          `json:"-"`
+	Services             []ServiceState  `json:"-"`
+	CrashLog             []string        `json:"-"`
+	Links                *ProfileLinks   `json:"links,omitempty"`
+	Uptime               *ProfileUptime  `json:"uptime,omitempty"`
 }
 
 type PortMapping struct {
@@ -43,32 +76,73 @@ type Resources struct {
 }
 
 type ProfileStore struct {
-	Profiles []ProfileRequest `json:"profiles"`
+	// Checksum lets a damaged file be told from a good one; see
+	// storeChecksum.
+	Checksum  string           `json:"checksum,omitempty"`
+	Profiles  []ProfileRequest `json:"profiles"`
+	PullStats []PullStat       `json:"pullStats,omitempty"`
+	JobStats  []JobStat        `json:"jobStats,omitempty"`
 }
 
 var ErrProfileLimitReached = errors.New("profile limit reached")
 var ErrProfileExists = errors.New("profile already exists")
 
+// ValidationError is a request the launcher refuses as invalid. Fields,
+// when set, names the offending inputs so a form can point at them.
 type ValidationError struct {
-	Msg string
+	Msg    string
+	Fields []FieldError
 }
 
 func (e ValidationError) Error() string { return e.Msg }
 
-func (s *Server) createProfile(req ProfileRequest) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// FieldError is one invalid input. Field is the form field name (the
+// JSON name for API clients) and Code a stable identifier such as
+// "port.range" that does not change with the wording of Message.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func fieldError(field, code, msg string) ValidationError {
+	return ValidationError{Msg: msg, Fields: []FieldError{{Field: field, Code: code, Message: msg}}}
+}
+
+// fieldErrors collects every invalid input of a request, so one answer
+// reports all of them instead of the first.
+type fieldErrors []FieldError
 
-	path := strings.TrimSpace(s.dbPath)
-	if path == "" {
-		path = filepath.Join(appCfg.DataDir, "profiles.json")
+// add records err against field, unless err already names its fields.
+func (f *fieldErrors) add(field, code string, err error) {
+	var ve ValidationError
+	if errors.As(err, &ve) && len(ve.Fields) > 0 {
+		*f = append(*f, ve.Fields...)
+		return
 	}
+	*f = append(*f, FieldError{Field: field, Code: code, Message: err.Error()})
+}
+
+func (f fieldErrors) err() error {
+	if len(f) == 0 {
+		return nil
+	}
+	msgs := make([]string, 0, len(f))
+	for _, fe := range f {
+		msgs = append(msgs, fe.Message)
+	}
+	return ValidationError{Msg: strings.Join(msgs, "; "), Fields: f}
+}
 
-	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+func (s *Server) createProfile(ctx context.Context, req ProfileRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.storePath()), 0o755); err != nil {
 		return err
 	}
 
-	store, err := loadProfileStore(path)
+	store, err := s.loadStoreLocked(ctx)
 	if err != nil {
 		return err
 	}
@@ -78,12 +152,20 @@ func (s *Server) createProfile(req ProfileRequest) error {
 			return ErrProfileExists
 		}
 	}
-	if len(store.Profiles) >= appCfg.MaxProfiles {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if activeProfileCount(store) >= maxProfilesLimit() {
 		return ErrProfileLimitReached
 	}
 	if err := validateCreateConstraints(req, store); err != nil {
 		return err
 	}
+	if req.DataDir != "" {
+		if err := prepareProfileDataDir(req.DataDir); err != nil {
+			return fieldError("dataDir", "dataDir.unwritable", err.Error())
+		}
+	}
 
 	publicEnv, secretEnv := splitSecretEnv(req.Env)
 	if strings.TrimSpace(secretEnv["JWT_SECRET"]) == "" {
@@ -93,6 +175,7 @@ func (s *Server) createProfile(req ProfileRequest) error {
 		secretEnv["ENC_KEY_V0"] = randomBase64Key32()
 	}
 	req.Env = publicEnv
+	req.Revision = 1
 	req.Enabled = false
 	req.Running = false
 	req.RuntimeStatus = "stopped"
@@ -104,21 +187,15 @@ func (s *Server) createProfile(req ProfileRequest) error {
 	req.ActionLog = []string{req.LastActionAt + " profile created"}
 	store.Profiles = append(store.Profiles, req)
 
-	if err := writeProfileStoreAtomic(path, store); err != nil {
-		return err
-	}
-	if err := saveProfileSecrets(req.ID, secretEnv); err != nil {
-		return err
-	}
-
-	return nil
+	files := append(secretsTxOps(req.ID, secretEnv), txWrite(connectionSheetMarkerPath(req.ID), nil, 0o600))
+	return s.commitStoreTxLocked("create", req.ID, store, files...)
 }
 
-func (s *Server) restoreVersion(id, version string, rollbackOK bool) error {
+func (s *Server) restoreVersion(ctx context.Context, id, version string, rollbackOK bool) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	store, err := loadProfileStore(s.dbPath)
+	store, err := s.loadStoreLocked(ctx)
 	if err != nil {
 		return err
 	}
@@ -127,6 +204,7 @@ func (s *Server) restoreVersion(id, version string, rollbackOK bool) error {
 		return os.ErrNotExist
 	}
 	store.Profiles[idx].Version = version
+	store.Profiles[idx].Revision++
 	if rollbackOK {
 		store.Profiles[idx].LastActionResult = "Version update failed and rolled back"
 	} else {
@@ -135,13 +213,13 @@ func (s *Server) restoreVersion(id, version string, rollbackOK bool) error {
 	store.Profiles[idx].LastAction = "version"
 	store.Profiles[idx].LastActionStatus = "failed"
 	store.Profiles[idx].LastActionAt = time.Now().UTC().Format(time.RFC3339)
-	return writeProfileStoreAtomic(s.dbPath, store)
+	return s.writeStoreLocked(store)
 }
 
-func (s *Server) getProfileForAction(id string) (ProfileStore, int, error) {
+func (s *Server) getProfileForAction(ctx context.Context, id string) (ProfileStore, int, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	store, err := loadProfileStore(s.dbPath)
+	store, err := s.loadStoreLocked(ctx)
 	if err != nil {
 		return ProfileStore{}, -1, err
 	}
@@ -152,11 +230,11 @@ func (s *Server) getProfileForAction(id string) (ProfileStore, int, error) {
 	return store, idx, nil
 }
 
-func (s *Server) markProfileResult(id, action, result, message, startingUntil string) error {
+func (s *Server) markProfileResult(ctx context.Context, id, action, result, message, startingUntil string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	store, err := loadProfileStore(s.dbPath)
+	store, err := s.loadStoreLocked(ctx)
 	if err != nil {
 		return err
 	}
@@ -165,26 +243,52 @@ func (s *Server) markProfileResult(id, action, result, message, startingUntil st
 		return os.ErrNotExist
 	}
 
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	now := time.Now().UTC().Format(time.RFC3339)
 	profile := &store.Profiles[idx]
+	profile.Revision++
 	profile.LastAction = action
 	profile.LastActionStatus = result
 	profile.LastActionAt = now
 	profile.LastActionResult = message
-	if (action == "enable" || action == "recreate") && result != "failed" {
+	if (action == "enable" || action == "recreate" || action == "restart") && result != "failed" {
 		profile.Enabled = true
 		profile.StartingUntil = startingUntil
 	}
-	if action == "stop" && result != "failed" {
+	if (action == "stop" || action == "auto-stop") && result != "failed" {
+		profile.Enabled = false
+		profile.StartingUntil = ""
+	}
+	if action == "archive" && result != "failed" {
 		profile.Enabled = false
 		profile.StartingUntil = ""
+		profile.Archived = true
+		profile.ArchivedAt = now
+	}
+	if action == "delete" && result != "failed" {
+		profile.Enabled = false
+		profile.StartingUntil = ""
+		profile.DeletedAt = now
+	}
+	if action == "restore" && result != "failed" {
+		profile.DeletedAt = ""
+	}
+	if action == "unarchive" && result != "failed" {
+		profile.Archived = false
+		profile.ArchivedAt = ""
 	}
 	entry := now + " [" + action + "] " + result + ": " + message
 	profile.ActionLog = append([]string{entry}, profile.ActionLog...)
 	if len(profile.ActionLog) > 8 {
 		profile.ActionLog = profile.ActionLog[:8]
 	}
-	return writeProfileStoreAtomic(s.dbPath, store)
+	err = s.writeStoreLocked(store)
+	if s.health != nil {
+		s.health.invalidate(id)
+	}
+	return err
 }
 
 func findProfileIndex(store ProfileStore, id string) int {
@@ -196,8 +300,13 @@ func findProfileIndex(store ProfileStore, id string) int {
 	return -1
 }
 
-func loadProfileStore(path string) (ProfileStore, error) {
+// loadProfileStore reads profiles.json, returning early once ctx is done so
+// callers holding s.mu do not keep doing work for an abandoned request.
+func loadProfileStore(ctx context.Context, path string) (ProfileStore, error) {
 	var store ProfileStore
+	if err := ctx.Err(); err != nil {
+		return store, err
+	}
 
 	b, err := os.ReadFile(path)
 	if err != nil {
@@ -218,23 +327,30 @@ func loadProfileStore(path string) (ProfileStore, error) {
 	if store.Profiles == nil {
 		store.Profiles = []ProfileRequest{}
 	}
+	for i := range store.Profiles {
+		p := &store.Profiles[i]
+		// Profiles written before revisions existed start at 1.
+		if p.Revision < 1 {
+			p.Revision = 1
+		}
+		// Older profiles kept their time zone in the TZ app variable.
+		if tz := strings.TrimSpace(p.Env["TZ"]); tz != "" {
+			if p.TimeZone == "" {
+				p.TimeZone = tz
+			}
+			delete(p.Env, "TZ")
+		}
+	}
 
 	return store, nil
 }
 
 func writeProfileStoreAtomic(path string, store ProfileStore) error {
-	tmp := path + ".tmp"
-
-	b, err := json.MarshalIndent(store, "", "  ")
+	b, err := encodeProfileStore(store)
 	if err != nil {
 		return err
 	}
-
-	if err := os.WriteFile(tmp, b, 0o644); err != nil {
-		return err
-	}
-
-	return os.Rename(tmp, path)
+	return writeFileSynced(path, b, 0o644)
 }
 
 func bytesTrimSpace(b []byte) []byte {
@@ -0,0 +1,146 @@
+package launcher
+
+import (
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// storeCache holds the last profiles.json contents read or written by this
+// process. An entry is reused only while the file's size and modification
+// time are unchanged, so edits made outside the launcher are still noticed.
+// Whoever reads the file first after such an edit validates it; the store
+// watcher then reports the outcome.
+type storeCache struct {
+	valid   bool
+	modTime time.Time
+	size    int64
+	store   ProfileStore
+	// rejected is set when an external edit is invalid.
+	rejected *storeConflict
+	// external lists the profiles before and after an external edit that
+	// was loaded but not yet announced by the watcher.
+	external []string
+}
+
+func (s *Server) storePath() string {
+	if path := strings.TrimSpace(s.dbPath); path != "" {
+		return path
+	}
+	return filepath.Join(appCfg.DataDir, "profiles.json")
+}
+
+// readStore returns a private copy of the profile store.
+func (s *Server) readStore(ctx context.Context) (ProfileStore, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadStoreLocked(ctx)
+}
+
+// loadStoreLocked is readStore for callers already holding s.mu. The result
+// may be modified freely and handed to writeStoreLocked.
+func (s *Server) loadStoreLocked(ctx context.Context) (ProfileStore, error) {
+	if err := ctx.Err(); err != nil {
+		return ProfileStore{}, err
+	}
+	path := s.storePath()
+	info, err := os.Stat(path)
+	if err != nil {
+		s.storeCache = storeCache{}
+		if os.IsNotExist(err) {
+			return ProfileStore{Profiles: []ProfileRequest{}}, nil
+		}
+		return ProfileStore{}, err
+	}
+	c := s.storeCache
+	if c.valid && c.size == info.Size() && c.modTime.Equal(info.ModTime()) {
+		return cloneProfileStore(c.store), nil
+	}
+	if r := c.rejected; c.valid && r != nil && r.size == info.Size() && r.modTime.Equal(info.ModTime()) {
+		return cloneProfileStore(c.store), nil
+	}
+
+	store, err := loadProfileStore(ctx, path)
+	if !c.valid {
+		// Nothing cached yet, so there is no last good copy to fall back to.
+		if err != nil {
+			s.storeCache = storeCache{}
+			return store, err
+		}
+		s.storeCache = storeCache{valid: true, modTime: info.ModTime(), size: info.Size(), store: cloneProfileStore(store)}
+		return store, nil
+	}
+
+	// The file changed behind the launcher's back.
+	if err == nil {
+		err = validateStoredProfiles(store)
+	}
+	if err != nil {
+		s.storeCache.rejected = &storeConflict{modTime: info.ModTime(), size: info.Size(), message: err.Error()}
+		return cloneProfileStore(c.store), nil
+	}
+	ids := append([]string(nil), c.external...)
+	for _, p := range c.store.Profiles {
+		ids = append(ids, p.ID)
+	}
+	for _, p := range store.Profiles {
+		ids = append(ids, p.ID)
+	}
+	s.storeCache = storeCache{valid: true, modTime: info.ModTime(), size: info.Size(), store: cloneProfileStore(store), external: ids}
+	return store, nil
+}
+
+// writeStoreLocked persists store and refreshes the cache. Callers hold s.mu.
+func (s *Server) writeStoreLocked(store ProfileStore) error {
+	path := s.storePath()
+	if err := writeProfileStoreAtomic(path, store); err != nil {
+		s.storeCache = storeCache{}
+		return err
+	}
+	s.refreshStoreCacheLocked(store)
+	return nil
+}
+
+// refreshStoreCacheLocked caches store as just written to profiles.json.
+func (s *Server) refreshStoreCacheLocked(store ProfileStore) {
+	info, err := os.Stat(s.storePath())
+	if err != nil {
+		s.storeCache = storeCache{}
+		return
+	}
+	s.storeCache = storeCache{valid: true, modTime: info.ModTime(), size: info.Size(), store: cloneProfileStore(store), external: s.storeCache.external}
+}
+
+// cloneProfileStore copies store down to every slice, map and pointer, so
+// callers may change what readStore returns without touching the cache.
+// A field added to ProfileRequest that holds a reference belongs here too.
+func cloneProfileStore(store ProfileStore) ProfileStore {
+	out := store
+	out.Profiles = make([]ProfileRequest, len(store.Profiles))
+	out.PullStats = slices.Clone(store.PullStats)
+	out.JobStats = slices.Clone(store.JobStats)
+	for i, p := range store.Profiles {
+		p.Labels = slices.Clone(p.Labels)
+		p.Ports = slices.Clone(p.Ports)
+		p.Env = maps.Clone(p.Env)
+		p.Network.ExtraHosts = slices.Clone(p.Network.ExtraHosts)
+		p.Alerting = slices.Clone(p.Alerting)
+		p.ActionLog = slices.Clone(p.ActionLog)
+		p.Services = slices.Clone(p.Services)
+		p.CrashLog = slices.Clone(p.CrashLog)
+		if p.Links != nil {
+			links := *p.Links
+			p.Links = &links
+		}
+		if p.Uptime != nil {
+			uptime := *p.Uptime
+			p.Uptime = &uptime
+		}
+		out.Profiles[i] = p
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package launcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStoreCacheReturnsCopiesAndSeesExternalEdits(t *testing.T) {
+	tmp := t.TempDir()
+	srv := &Server{dbPath: filepath.Join(tmp, "profiles.json")}
+	ctx := context.Background()
+
+	srv.mu.Lock()
+	err := srv.writeStoreLocked(ProfileStore{Profiles: []ProfileRequest{{ID: "alpha", Version: "1.0.0", Env: map[string]string{"A": "1"}}}})
+	srv.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := srv.readStore(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Profiles[0].Version = "mutated"
+	first.Profiles[0].Env["A"] = "mutated"
+
+	second, err := srv.readStore(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Profiles[0].Version != "1.0.0" || second.Profiles[0].Env["A"] != "1" {
+		t.Fatalf("expected cached store to be isolated from callers, got %+v", second.Profiles[0])
+	}
+
+	external := []byte(`{"profiles":[{"id":"beta","version":"2.0.0"}]}`)
+	if err := os.WriteFile(srv.dbPath, external, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(2 * time.Second)
+	if err := os.Chtimes(srv.dbPath, later, later); err != nil {
+		t.Fatal(err)
+	}
+	third, err := srv.readStore(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(third.Profiles) != 1 || third.Profiles[0].ID != "beta" {
+		t.Fatalf("expected external edit to be picked up, got %+v", third.Profiles)
+	}
+}
+
+func TestStoreCacheCopiesEveryReference(t *testing.T) {
+	srv := &Server{dbPath: filepath.Join(t.TempDir(), "profiles.json")}
+	ctx := context.Background()
+
+	srv.mu.Lock()
+	err := srv.writeStoreLocked(ProfileStore{
+		Profiles: []ProfileRequest{{
+			ID: "alpha", Version: "1.0.0",
+			Labels:    []string{"shop"},
+			Ports:     []PortMapping{{Container: 3000, Host: 8088}},
+			Env:       map[string]string{"A": "1"},
+			Network:   NetworkSettings{ExtraHosts: []string{"db.internal:10.0.0.5"}},
+			Alerting:  []string{"memory"},
+			ActionLog: []string{"enable"},
+			Links:     &ProfileLinks{App: "http://localhost:8088"},
+			Uptime:    &ProfileUptime{Day: UptimeWindow{Percent: 99}},
+		}},
+		PullStats: []PullStat{{Version: "1.0.0"}},
+		JobStats:  []JobStat{{Action: "enable"}},
+	})
+	srv.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := srv.readStore(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &loaded.Profiles[0]
+	p.Labels[0] = "mutated"
+	p.Ports[0].Host = 1
+	p.Env["A"] = "mutated"
+	p.Network.ExtraHosts[0] = "mutated"
+	p.Alerting[0] = "mutated"
+	p.ActionLog[0] = "mutated"
+	p.Links.App = "mutated"
+	p.Uptime.Day.Percent = 0
+	loaded.PullStats[0].Version = "mutated"
+	loaded.JobStats[0].Action = "mutated"
+
+	reloaded, err := srv.readStore(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := reloaded.Profiles[0]
+	if got.Labels[0] != "shop" || got.Ports[0].Host != 8088 || got.Env["A"] != "1" ||
+		got.Network.ExtraHosts[0] != "db.internal:10.0.0.5" || got.Alerting[0] != "memory" || got.ActionLog[0] != "enable" ||
+		got.Links.App != "http://localhost:8088" || got.Uptime.Day.Percent != 99 {
+		t.Fatalf("expected the cached profile untouched by the caller, got %+v", got)
+	}
+	if reloaded.PullStats[0].Version != "1.0.0" || reloaded.JobStats[0].Action != "enable" {
+		t.Fatalf("expected the cached stats untouched by the caller, got %+v %+v", reloaded.PullStats, reloaded.JobStats)
+	}
+}
@@ -0,0 +1,120 @@
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const storeWatchInterval = 2 * time.Second
+
+// storeConflict records an external edit of profiles.json that failed
+// validation. Reads keep serving the last good copy until the file changes.
+type storeConflict struct {
+	modTime time.Time
+	size    int64
+	message string
+	// reported is set once the watcher has published the conflict.
+	reported bool
+}
+
+// startStoreWatcher polls profiles.json for edits made outside the launcher.
+// Polling the size and mtime keeps the launcher free of platform specific
+// notification APIs and matches what the store cache already compares.
+func (s *Server) startStoreWatcher(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.checkStoreFile(ctx)
+			}
+		}
+	}()
+}
+
+// checkStoreFile reloads profiles.json when it changed on disk, publishing
+// either the refreshed profiles or a conflict warning.
+func (s *Server) checkStoreFile(ctx context.Context) {
+	reloaded, ids, conflict := s.reloadExternalStore(ctx)
+	if conflict != "" {
+		logWarn("profile_store_conflict", map[string]any{"error": conflict})
+		s.publishEvent("store_conflict", map[string]any{
+			"message": "profiles.json was edited outside the launcher and could not be loaded: " + conflict,
+		})
+		return
+	}
+	if !reloaded {
+		return
+	}
+	logInfo("profile_store_reloaded", map[string]any{"profiles": len(ids)})
+	if s.health != nil {
+		for _, id := range ids {
+			s.health.invalidate(id)
+		}
+	}
+	profiles, err := s.profilesWithStatus(ctx)
+	if err != nil {
+		return
+	}
+	s.publishEvent("profiles", map[string]any{"reason": "external_edit", "profiles": profiles})
+}
+
+// reloadExternalStore loads profiles.json, which validates an external
+// edit, and takes the outcome not yet announced: the profiles of an
+// accepted edit or the message of a rejected one.
+func (s *Server) reloadExternalStore(ctx context.Context) (bool, []string, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.loadStoreLocked(ctx); err != nil {
+		return false, nil, ""
+	}
+	c := &s.storeCache
+	if r := c.rejected; r != nil && !r.reported {
+		r.reported = true
+		return false, nil, r.message
+	}
+	if c.external != nil {
+		ids := c.external
+		c.external = nil
+		return true, ids, ""
+	}
+	return false, nil, ""
+}
+
+// validateStoredProfiles checks the invariants the launcher relies on when
+// it did not write the file itself.
+func validateStoredProfiles(store ProfileStore) error {
+	ids := map[string]bool{}
+	ports := map[int]string{}
+	for _, p := range store.Profiles {
+		if !profileIDRe.MatchString(p.ID) {
+			return fmt.Errorf("invalid profile id %q", p.ID)
+		}
+		if ids[p.ID] {
+			return fmt.Errorf("duplicate profile id %q", p.ID)
+		}
+		ids[p.ID] = true
+		if err := checkSubnetConflicts(p, store.Profiles); err != nil {
+			return err
+		}
+		if len(p.Ports) == 0 {
+			continue
+		}
+		host := p.Ports[0].Host
+		if host <= 0 || host > 65535 {
+			return fmt.Errorf("profile %s has invalid host port %d", p.ID, host)
+		}
+		for _, port := range profileHostPorts(p) {
+			if other, ok := ports[port]; ok {
+				return fmt.Errorf("host port %d is used by both %s and %s", port, other, p.ID)
+			}
+			ports[port] = p.ID
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package launcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckStoreFileReloadsAndRejectsExternalEdits(t *testing.T) {
+	tmp := t.TempDir()
+	srv := NewServer(appCfg)
+	srv.dbPath = filepath.Join(tmp, "profiles.json")
+	ctx := context.Background()
+
+	srv.mu.Lock()
+	err := srv.writeStoreLocked(ProfileStore{Profiles: []ProfileRequest{{ID: "alpha", Ports: []PortMapping{{Container: 3000, Host: 18080}}}}})
+	srv.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, unsubscribe := srv.events.subscribe()
+	defer unsubscribe()
+
+	externalWrite := func(body string, offset time.Duration) {
+		t.Helper()
+		if err := os.WriteFile(srv.dbPath, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		when := time.Now().Add(offset)
+		if err := os.Chtimes(srv.dbPath, when, when); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	externalWrite(`{"profiles":[{"id":"alpha","ports":[{"container":3000,"host":18080}]},{"id":"alpha","ports":[{"container":3000,"host":18081}]}]}`, 2*time.Second)
+	srv.checkStoreFile(ctx)
+	ev := <-events
+	if ev.Name != "store_conflict" || !strings.Contains(ev.Data.(map[string]any)["message"].(string), "duplicate profile id") {
+		t.Fatalf("expected duplicate id conflict, got %+v", ev)
+	}
+	store, err := srv.readStore(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(store.Profiles) != 1 {
+		t.Fatalf("expected last good store to be served, got %+v", store.Profiles)
+	}
+
+	externalWrite(`{"profiles":[{"id":"alpha","enabled":false,"ports":[{"container":3000,"host":18080}]},{"id":"beta","enabled":false,"ports":[{"container":3000,"host":18081}]}]}`, 4*time.Second)
+	srv.checkStoreFile(ctx)
+	ev = <-events
+	if ev.Name != "profiles" {
+		t.Fatalf("expected profiles event, got %+v", ev)
+	}
+	if got := ev.Data.(map[string]any)["profiles"].([]ProfileRequest); len(got) != 2 {
+		t.Fatalf("expected reloaded profiles, got %+v", got)
+	}
+}
+
+func TestStoreFileEditsReadByHandlersStillReported(t *testing.T) {
+	tmp := t.TempDir()
+	srv := NewServer(appCfg)
+	srv.dbPath = filepath.Join(tmp, "profiles.json")
+	ctx := context.Background()
+
+	srv.mu.Lock()
+	err := srv.writeStoreLocked(ProfileStore{Profiles: []ProfileRequest{{ID: "alpha", Ports: []PortMapping{{Container: 3000, Host: 18080}}}}})
+	srv.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, unsubscribe := srv.events.subscribe()
+	defer unsubscribe()
+	externalWrite := func(body string, offset time.Duration) {
+		t.Helper()
+		if err := os.WriteFile(srv.dbPath, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		when := time.Now().Add(offset)
+		if err := os.Chtimes(srv.dbPath, when, when); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	externalWrite(`{"profiles":[{"id":"alpha","ports":[{"container":3000,"host":18080}]},{"id":"beta","ports":[{"container":3000,"host":18080}]}]}`, 2*time.Second)
+	store, err := srv.readStore(ctx)
+	if err != nil || len(store.Profiles) != 1 {
+		t.Fatalf("expected a handler read to keep the last good store, got %+v %v", store.Profiles, err)
+	}
+	srv.checkStoreFile(ctx)
+	if ev := <-events; ev.Name != "store_conflict" || !strings.Contains(ev.Data.(map[string]any)["message"].(string), "host port 18080") {
+		t.Fatalf("expected the watcher to report the conflict, got %+v", ev)
+	}
+	srv.checkStoreFile(ctx)
+	select {
+	case ev := <-events:
+		t.Fatalf("expected the conflict to be reported once, got %+v", ev)
+	default:
+	}
+
+	externalWrite(`{"profiles":[{"id":"beta","enabled":false,"ports":[{"container":3000,"host":18081}]}]}`, 4*time.Second)
+	if store, err := srv.readStore(ctx); err != nil || len(store.Profiles) != 1 || store.Profiles[0].ID != "beta" {
+		t.Fatalf("expected a valid edit to be loaded, got %+v %v", store.Profiles, err)
+	}
+	srv.checkStoreFile(ctx)
+	if ev := <-events; ev.Name != "profiles" {
+		t.Fatalf("expected the watcher to announce the reload, got %+v", ev)
+	}
+}
@@ -0,0 +1,394 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The weekly summary is a heartbeat for launchers nobody looks at: health,
+// updates and disk usage of the past week, sent to the notification
+// channels and optionally by email. It is built from what the launcher
+// already records, like the activity feed. The time of the last summary and
+// the disk sizes it reported are kept in summary.json, so disk trends span
+// the whole week although usage history only covers a day.
+
+const (
+	summaryPeriod        = 7 * 24 * time.Hour
+	summaryCheckInterval = time.Hour
+	summaryEmailTimeout  = 30 * time.Second
+	summarySubject       = "Kimmio Launcher weekly summary"
+)
+
+// SummaryReport is the body of a weekly summary.
+type SummaryReport struct {
+	From     string            `json:"from"`
+	To       string            `json:"to"`
+	Profiles DashboardProfiles `json:"profiles"`
+	Health   []SummaryHealth   `json:"health"`
+	// UpdatesApplied are the version changes that succeeded in the period.
+	UpdatesApplied   []ActivityEntry  `json:"updatesApplied"`
+	UpdatesAvailable DashboardUpdates `json:"updatesAvailable"`
+	// Failures are the actions that failed in the period.
+	Failures    []ActivityEntry `json:"failures"`
+	Disk        []SummaryDisk   `json:"disk"`
+	DiskBytes   int64           `json:"diskBytes"`
+	GeneratedAt string          `json:"generatedAt"`
+}
+
+type SummaryHealth struct {
+	ProfileID string `json:"profileId"`
+	Status    string `json:"status"`
+	// Outages counts the status changes to anything but running during the
+	// period; stops from the launcher count too.
+	Outages int `json:"outages"`
+}
+
+type SummaryDisk struct {
+	ProfileID string `json:"profileId"`
+	Bytes     int64  `json:"bytes"`
+	// ChangeBytes is the growth since the last summary, when there was one.
+	ChangeBytes *int64 `json:"changeBytes,omitempty"`
+}
+
+type summaryState struct {
+	LastSentAt string           `json:"lastSentAt,omitempty"`
+	DiskBytes  map[string]int64 `json:"diskBytes,omitempty"`
+}
+
+// summaryReporter guards summary.json, so the scheduled summary and one
+// sent from the settings page do not both go out.
+type summaryReporter struct {
+	mu   sync.Mutex
+	path string
+}
+
+func newSummaryReporter(dataDir string) *summaryReporter {
+	return &summaryReporter{path: filepath.Join(dataDir, "summary.json")}
+}
+
+func (r *summaryReporter) load() summaryState {
+	var st summaryState
+	if raw, err := os.ReadFile(r.path); err == nil {
+		if err := json.Unmarshal(raw, &st); err != nil {
+			logWarn("summary_state_invalid", map[string]any{"error": err.Error()})
+		}
+	}
+	return st
+}
+
+func (r *summaryReporter) save(st summaryState) error {
+	raw, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.path)
+}
+
+func summarySMTPPasswordPath(dataDir string) string {
+	return filepath.Join(dataDir, "secrets", "summary-smtp.password")
+}
+
+// saveSummarySMTPPassword stores the password for the summary email next to
+// the profile secrets; an empty password removes it.
+func saveSummarySMTPPassword(dataDir, password string) error {
+	path := summarySMTPPasswordPath(dataDir)
+	if password == "" {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(password), 0o600)
+}
+
+func loadSummarySMTPPassword(dataDir string) string {
+	raw, err := os.ReadFile(summarySMTPPasswordPath(dataDir))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(raw))
+}
+
+// buildSummaryReport covers from to now. profiles carry their runtime
+// status; previous holds the disk sizes of the last summary.
+func (s *Server) buildSummaryReport(profiles []ProfileRequest, audit []map[string]any, from, now time.Time, previous map[string]int64) SummaryReport {
+	dashboard := s.buildDashboard(profiles)
+	since := from.UTC().Format(time.RFC3339)
+	report := SummaryReport{
+		From:             since,
+		To:               now.UTC().Format(time.RFC3339),
+		Profiles:         dashboard.Profiles,
+		Health:           []SummaryHealth{},
+		UpdatesApplied:   []ActivityEntry{},
+		UpdatesAvailable: dashboard.Updates,
+		Failures:         []ActivityEntry{},
+		Disk:             []SummaryDisk{},
+		DiskBytes:        dashboard.DiskBytes,
+		GeneratedAt:      now.UTC().Format(time.RFC3339),
+	}
+
+	outages := map[string]int{}
+	for _, e := range auditActivity(audit) {
+		if e.Kind == activityStatus && e.At >= since && e.Level == "warn" {
+			outages[e.ProfileID]++
+		}
+	}
+	for _, e := range buildActivity(profiles, nil, activityQuery{Kinds: []string{activityJob}}, false) {
+		if e.At < since {
+			continue
+		}
+		switch {
+		case e.Level == "error":
+			report.Failures = append(report.Failures, e)
+		case strings.HasPrefix(e.Message, e.ProfileID+" version success"):
+			report.UpdatesApplied = append(report.UpdatesApplied, e)
+		}
+	}
+
+	for _, p := range profiles {
+		if p.DeletedAt != "" {
+			continue
+		}
+		if !p.Archived {
+			status := p.RuntimeStatus
+			if status == "" {
+				status = "unknown"
+			}
+			report.Health = append(report.Health, SummaryHealth{ProfileID: p.ID, Status: status, Outages: outages[p.ID]})
+		}
+		if s.usage == nil {
+			continue
+		}
+		disk := SummaryDisk{ProfileID: p.ID, Bytes: s.usage.lastDisk(p.ID)}
+		if before, ok := previous[p.ID]; ok {
+			change := disk.Bytes - before
+			disk.ChangeBytes = &change
+		}
+		report.Disk = append(report.Disk, disk)
+	}
+	sort.Slice(report.Health, func(i, j int) bool { return report.Health[i].ProfileID < report.Health[j].ProfileID })
+	sort.Slice(report.Disk, func(i, j int) bool { return report.Disk[i].Bytes > report.Disk[j].Bytes })
+	return report
+}
+
+// Text renders the report for email.
+func (r SummaryReport) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n%s to %s\n\n", summarySubject, r.From, r.To)
+
+	fmt.Fprintf(&b, "Profiles: %d of %d", r.Profiles.Total, r.Profiles.Max)
+	if r.Profiles.Archived > 0 {
+		fmt.Fprintf(&b, ", %d archived", r.Profiles.Archived)
+	}
+	b.WriteString("\n")
+	for _, h := range r.Health {
+		fmt.Fprintf(&b, "- %s: %s", h.ProfileID, h.Status)
+		if h.Outages > 0 {
+			fmt.Fprintf(&b, ", %d outages this week", h.Outages)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\nUpdates applied:\n")
+	if len(r.UpdatesApplied) == 0 {
+		b.WriteString("- none\n")
+	}
+	for _, e := range r.UpdatesApplied {
+		fmt.Fprintf(&b, "- %s (%s)\n", e.Message, e.At)
+	}
+	if r.UpdatesAvailable.Launcher != "" || len(r.UpdatesAvailable.Profiles) > 0 {
+		b.WriteString("\nUpdates available:\n")
+		if r.UpdatesAvailable.Launcher != "" {
+			fmt.Fprintf(&b, "- Kimmio Launcher %s\n", r.UpdatesAvailable.Launcher)
+		}
+		for _, id := range r.UpdatesAvailable.Profiles {
+			fmt.Fprintf(&b, "- %s\n", id)
+		}
+	}
+
+	if len(r.Failures) > 0 {
+		b.WriteString("\nFailed actions:\n")
+		for _, e := range r.Failures {
+			fmt.Fprintf(&b, "- %s (%s)\n", e.Message, e.At)
+		}
+	}
+
+	fmt.Fprintf(&b, "\nDisk usage: %s\n", formatBytes(r.DiskBytes))
+	for _, d := range r.Disk {
+		fmt.Fprintf(&b, "- %s: %s", d.ProfileID, formatBytes(d.Bytes))
+		if d.ChangeBytes != nil {
+			sign, change := "+", *d.ChangeBytes
+			if change < 0 {
+				sign, change = "-", -change
+			}
+			fmt.Fprintf(&b, " (%s%s since the last summary)", sign, formatBytes(change))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// summaryTargets reports whether the settings name anywhere to send the
+// summary to.
+func summaryTargets(st Settings) bool {
+	probe := Notification{Kind: eventSummary}
+	return slices.ContainsFunc(st.NotificationChannels, func(c NotificationChannel) bool { return c.wants(probe) }) || st.SummaryEmail != ""
+}
+
+// deliverSummary sends the report to the channels that take summaries and
+// emails it when an address is set. It returns how many deliveries
+// succeeded and the errors of the others.
+func (s *Server) deliverSummary(ctx context.Context, report SummaryReport) (int, error) {
+	st := s.settings.get()
+	n := Notification{
+		ID:        eventSummary + ":" + report.To,
+		Kind:      eventSummary,
+		Message:   report.Text(),
+		CreatedAt: report.To,
+	}
+	delivered, err := s.notifyChannels(ctx, n, map[string]any{"event": "summary", "summary": report})
+	var errs []error
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if st.SummaryEmail != "" {
+		ctx, cancel := context.WithTimeout(ctx, summaryEmailTimeout)
+		defer cancel()
+		if err := sendEmail(ctx, st.SummarySMTP, loadSummarySMTPPassword(appCfg.DataDir), st.SummaryEmail, summarySubject, report.Text()); err != nil {
+			logWarn("summary_email_failed", map[string]any{"host": st.SummarySMTP.Host, "error": err.Error()})
+			errs = append(errs, fmt.Errorf("email: %w", err))
+		} else {
+			delivered++
+		}
+	}
+	return delivered, errors.Join(errs...)
+}
+
+// sendSummary builds and delivers the summary for the period since the
+// last one, then starts the next period. The caller holds s.summaries.mu.
+func (s *Server) sendSummary(ctx context.Context, state summaryState, now time.Time) (SummaryReport, int, error) {
+	profiles, err := s.profilesWithStatus(ctx)
+	if err != nil {
+		return SummaryReport{}, 0, err
+	}
+	report := s.buildSummaryReport(profiles, readAuditRecords(), summaryPeriodStart(state, now), now, state.DiskBytes)
+	delivered, err := s.deliverSummary(ctx, report)
+	// A failed delivery still ends the period; retrying every hour would
+	// repeat the summary to the targets that did get it.
+	next := summaryState{LastSentAt: report.To, DiskBytes: map[string]int64{}}
+	for _, d := range report.Disk {
+		next.DiskBytes[d.ProfileID] = d.Bytes
+	}
+	if saveErr := s.summaries.save(next); saveErr != nil {
+		logWarn("summary_state_save_failed", map[string]any{"error": saveErr.Error()})
+	}
+	fields := map[string]any{"delivered": delivered}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+	logInfo("summary_sent", fields)
+	return report, delivered, err
+}
+
+// summaryPeriodStart is the end of the last summary, or a week ago before
+// the first one.
+func summaryPeriodStart(state summaryState, now time.Time) time.Time {
+	if last, err := time.Parse(time.RFC3339, state.LastSentAt); err == nil {
+		return last
+	}
+	return now.Add(-summaryPeriod)
+}
+
+// startSummaryReporter sends the weekly summary once a week has passed since
+// the last one. Turning the summary on starts the first week, rather than
+// sending one right away.
+func (s *Server) startSummaryReporter(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			s.sendDueSummary(ctx, time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *Server) sendDueSummary(ctx context.Context, now time.Time) {
+	if st := s.settings.get(); !st.WeeklySummary || !summaryTargets(st) {
+		return
+	}
+	s.summaries.mu.Lock()
+	defer s.summaries.mu.Unlock()
+	state := s.summaries.load()
+	last, err := time.Parse(time.RFC3339, state.LastSentAt)
+	if err != nil {
+		state.LastSentAt = now.UTC().Format(time.RFC3339)
+		if err := s.summaries.save(state); err != nil {
+			logWarn("summary_state_save_failed", map[string]any{"error": err.Error()})
+		}
+		return
+	}
+	if now.Sub(last) < summaryPeriod {
+		return
+	}
+	_, _, _ = s.sendSummary(ctx, state, now)
+}
+
+// handleSummary previews the summary the next report would send (GET) or
+// sends it now (POST /api/summary/send), which starts a new week.
+func (s *Server) handleSummary(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/api/summary" && r.Method == http.MethodGet:
+		profiles, err := s.profilesWithStatus(r.Context())
+		if err != nil {
+			http.Error(w, "Failed to load profiles: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		now := time.Now()
+		state := s.summaries.load()
+		report := s.buildSummaryReport(profiles, readAuditRecords(), summaryPeriodStart(state, now), now, state.DiskBytes)
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "summary": report, "text": report.Text(), "lastSentAt": state.LastSentAt})
+	case r.URL.Path == "/api/summary/send" && r.Method == http.MethodPost:
+		if !summaryTargets(s.settings.get()) {
+			http.Error(w, "Add a notification webhook or a summary email address first", http.StatusBadRequest)
+			return
+		}
+		s.summaries.mu.Lock()
+		defer s.summaries.mu.Unlock()
+		report, delivered, err := s.sendSummary(r.Context(), s.summaries.load(), time.Now())
+		if err != nil && delivered == 0 {
+			http.Error(w, "Summary not delivered: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+		payload := map[string]any{"ok": true, "delivered": delivered, "summary": report}
+		if err != nil {
+			payload["warning"] = err.Error()
+		}
+		writeJSON(w, http.StatusOK, payload)
+	case r.URL.Path == "/api/summary" || r.URL.Path == "/api/summary/send":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
@@ -0,0 +1,91 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSummaryReportCoversThePeriod(t *testing.T) {
+	srv := newServiceTestServer(t)
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := from.Add(summaryPeriod)
+	if err := srv.usage.add("alpha", UsageSample{At: now, DiskBytes: 3 << 20}); err != nil {
+		t.Fatal(err)
+	}
+	profiles := []ProfileRequest{
+		{ID: "alpha", RuntimeStatus: "running", ActionLog: []string{
+			"2025-12-30T00:00:00Z [version] success: Version updated to 1.0.0",
+			"2026-01-02T00:00:00Z [version] success: Version updated to 1.1.0",
+			"2026-01-03T00:00:00Z [restart] failed: container exited",
+		}},
+		{ID: "old", Archived: true},
+		{ID: "gone", DeletedAt: "2026-01-04T00:00:00Z"},
+	}
+	audit := []map[string]any{
+		{"ts": "2025-12-31T00:00:00Z", "level": "WARN", "msg": "profile_status_changed", "profile": "alpha", "from": "running", "to": "unhealthy"},
+		{"ts": "2026-01-05T00:00:00Z", "level": "WARN", "msg": "profile_status_changed", "profile": "alpha", "from": "running", "to": "unhealthy"},
+		{"ts": "2026-01-05T00:05:00Z", "level": "INFO", "msg": "profile_status_changed", "profile": "alpha", "from": "unhealthy", "to": "running"},
+	}
+
+	report := srv.buildSummaryReport(profiles, audit, from, now, map[string]int64{"alpha": 1 << 20})
+	if len(report.Health) != 1 || report.Health[0] != (SummaryHealth{ProfileID: "alpha", Status: "running", Outages: 1}) {
+		t.Fatalf("expected one outage for alpha in the period, got %+v", report.Health)
+	}
+	if len(report.UpdatesApplied) != 1 || !strings.Contains(report.UpdatesApplied[0].Message, "1.1.0") {
+		t.Fatalf("expected only the update inside the period, got %+v", report.UpdatesApplied)
+	}
+	if len(report.Failures) != 1 || report.Failures[0].Message != "alpha restart failed: container exited" {
+		t.Fatalf("expected the failed restart, got %+v", report.Failures)
+	}
+	if len(report.Disk) != 2 || report.Disk[0].ProfileID != "alpha" || report.Disk[0].ChangeBytes == nil || *report.Disk[0].ChangeBytes != 2<<20 {
+		t.Fatalf("expected alpha's disk to have grown by 2 MiB, got %+v", report.Disk)
+	}
+	if text := report.Text(); !strings.Contains(text, "- alpha: running, 1 outages this week") || !strings.Contains(text, "- alpha: 3.0 MiB (+2.0 MiB since the last summary)") {
+		t.Fatalf("unexpected summary text:\n%s", text)
+	}
+}
+
+func TestWeeklySummaryWaitsAWeek(t *testing.T) {
+	received := make(chan map[string]any, 4)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		received <- body
+	}))
+	defer ts.Close()
+
+	srv := newServiceTestServer(t)
+	defer publishSettings(defaultSettings())
+	srv.storeHealth([]ProfileRequest{{ID: "alpha", RuntimeStatus: "running"}})
+	enabled, channels := true, []NotificationChannel{{ID: "ops", Type: channelWebhook, URL: ts.URL}}
+	if _, err := srv.settings.update(SettingsPatch{WeeklySummary: &enabled, NotificationChannels: &channels}); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	srv.sendDueSummary(context.Background(), start)
+	srv.sendDueSummary(context.Background(), start.Add(6*24*time.Hour))
+	if len(received) != 0 {
+		t.Fatalf("expected no summary before a week has passed, got %d", len(received))
+	}
+	srv.sendDueSummary(context.Background(), start.Add(summaryPeriod+time.Minute))
+	if len(received) != 1 {
+		t.Fatalf("expected one summary after a week, got %d", len(received))
+	}
+	if body := <-received; body["event"] != "summary" || body["summary"].(map[string]any)["from"] != start.UTC().Format(time.RFC3339) {
+		t.Fatalf("unexpected webhook body %v", body)
+	}
+	if state := srv.summaries.load(); state.LastSentAt != start.Add(summaryPeriod+time.Minute).UTC().Format(time.RFC3339) {
+		t.Fatalf("expected the next week to start, got %+v", state)
+	}
+
+	email := "ops@example.com"
+	if _, err := srv.settings.update(SettingsPatch{SummaryEmail: &email}); err == nil {
+		t.Fatal("expected a summary email without an SMTP host to be rejected")
+	}
+}
@@ -0,0 +1,130 @@
+package launcher
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+)
+
+var launcherStartedAt = time.Now().UTC()
+
+func (s *Server) handleSystemInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"ok":   true,
+		"info": s.systemInfo(r.Context()),
+	})
+}
+
+func (s *Server) systemInfo(ctx context.Context) map[string]any {
+	dockerVersion, composeVersion := dockerVersions(ctx)
+	uptime := time.Since(launcherStartedAt).Truncate(time.Second)
+	return map[string]any{
+		"version":        launcherAppVersion,
+		"commit":         launcherGitCommit,
+		"buildMode":      appCfg.BuildMode,
+		"os":             runtime.GOOS,
+		"arch":           runtime.GOARCH,
+		"dockerArch":     hostArch(),
+		"rosetta":        processTranslated(),
+		"goVersion":      runtime.Version(),
+		"dataDir":        appCfg.DataDir,
+		"startedAt":      launcherStartedAt.Format(time.RFC3339),
+		"uptimeSeconds":  int64(uptime.Seconds()),
+		"uptime":         uptime.String(),
+		"docker":         IsDockerRunning(),
+		"runtime":        containerRuntimeName(),
+		"dockerVersion":  dockerVersion,
+		"composeVersion": composeVersion,
+		"config":         sanitizedConfig(),
+		"configWarnings": configWarnings(),
+		"jobs":           s.jobCounts(),
+		"pulls":          s.pullStats(ctx),
+	}
+}
+
+func sanitizedConfig() map[string]any {
+	return map[string]any{
+		"listenPort":         appCfg.ListenPort,
+		"portSearchRange":    appCfg.PortSearchRange,
+		"maxProfiles":        appCfg.MaxProfiles,
+		"actionTimeout":      appCfg.ActionTimeout.String(),
+		"enableTimeout":      appCfg.EnableTimeout.String(),
+		"profilePortMin":     appCfg.ProfilePortMin,
+		"profilePortMax":     appCfg.ProfilePortMax,
+		"grpcPort":           appCfg.GRPCPort,
+		"maintenanceWindow":  appCfg.MaintenanceWindow,
+		"githubTokenSet":     appCfg.GitHubToken != "",
+		"updateManifestURL":  appCfg.UpdateManifestURL,
+		"networkMTU":         appCfg.NetworkMTU,
+		"reservedPorts":      sortedPorts(reservedPorts()),
+		"avoidDevPorts":      appCfg.AvoidDevPorts,
+		"trashRetentionDays": appCfg.TrashRetentionDays,
+	}
+}
+
+func (s *Server) pullStats(ctx context.Context) map[string]any {
+	store, err := s.readStore(ctx)
+	if err != nil {
+		return pullStatsSummary(nil)
+	}
+	return pullStatsSummary(store.PullStats)
+}
+
+func (s *Server) jobCounts() map[string]int {
+	s.jobMu.Lock()
+	defer s.jobMu.Unlock()
+	counts := map[string]int{"active": 0, "total": len(s.jobs)}
+	for _, job := range s.jobs {
+		if !isTerminalJobStatus(job.Status) {
+			counts["active"]++
+		}
+	}
+	return counts
+}
+
+func isTerminalJobStatus(status string) bool {
+	switch status {
+	case "succeeded", "failed", "timeout", "rolled_back", "canceled":
+		return true
+	default:
+		return false
+	}
+}
+
+func dockerVersions(parent context.Context) (string, string) {
+	dockerBin, err := dockerBinaryPath()
+	if err != nil {
+		return "", ""
+	}
+	ctx, cancel := context.WithTimeout(parent, 5*time.Second)
+	defer cancel()
+
+	dockerVersion := ""
+	if engine, err := newDockerEngine(); err == nil {
+		if info, err := engine.info(ctx); err == nil {
+			dockerVersion = info.ServerVersion
+		}
+	} else {
+		// Podman without its service has no server side to report.
+		format := "{{.Server.Version}}"
+		if containerRuntimeName() == runtimePodman {
+			format = "{{.Client.Version}}"
+		}
+		if out, err := dockerCommandWithContext(ctx, dockerBin, "version", "--format", format).Output(); err == nil {
+			dockerVersion = strings.TrimSpace(string(out))
+		}
+	}
+	composeVersion := ""
+	if cmd, err := composeCommandWithContext(ctx, "version", "--short"); err == nil {
+		if out, err := cmd.Output(); err == nil {
+			composeVersion = strings.TrimSpace(string(out))
+		}
+	}
+	return dockerVersion, composeVersion
+}
@@ -3,6 +3,7 @@ package launcher
 import (
 	"fmt"
 	"html/template"
+	"io"
 	"io/fs"
 	"net/http"
 	"path"
@@ -85,3 +86,18 @@ func (ts *Templates) RenderPageWithTemplate(w http.ResponseWriter, pageName stri
 
 	return clone.ExecuteTemplate(w, "layout", data)
 }
+
+// RenderFragment executes one named template without the layout. Like
+// pages it runs on a clone: html/template refuses to clone a template set
+// that has been executed.
+func (ts *Templates) RenderFragment(w io.Writer, name string, data any) error {
+	ts.mu.RLock()
+	base := ts.t
+	ts.mu.RUnlock()
+
+	clone, err := base.Clone()
+	if err != nil {
+		return err
+	}
+	return clone.ExecuteTemplate(w, name, data)
+}
@@ -0,0 +1,147 @@
+package launcher
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Where the app container finds the certificate and key of a profile.
+const (
+	tlsCertTarget = "/run/kimmio/tls/tls.crt"
+	tlsKeyTarget  = "/run/kimmio/tls/tls.key"
+)
+
+// The certificate and key files are host files mounted into the app
+// container, so, as with action hooks, only this computer may pick them.
+const tlsFilesForbidden = "Forbidden: TLS certificate and key files can only be set from this computer"
+
+// TLSSettings tells the app it is served over https on APP_DOMAIN. With a
+// certificate and key, given as host paths such as the ones a reverse proxy
+// or certbot writes, both are mounted read-only into the app container.
+// Proxy is for a reverse proxy that terminates TLS itself; the app then only
+// needs to build https:// and wss:// URLs.
+type TLSSettings struct {
+	CertFile string `json:"certFile,omitempty"`
+	KeyFile  string `json:"keyFile,omitempty"`
+	Proxy    bool   `json:"proxy,omitempty"`
+}
+
+func (c TLSSettings) configured() bool {
+	return c.CertFile != "" || c.Proxy
+}
+
+// sameFiles reports whether c names the certificate and key files of
+// other, ignoring surrounding spaces and unclean paths.
+func (c TLSSettings) sameFiles(other TLSSettings) bool {
+	clean := func(path string) string {
+		if path = strings.TrimSpace(path); path == "" {
+			return ""
+		}
+		return filepath.Clean(path)
+	}
+	return clean(c.CertFile) == clean(other.CertFile) && clean(c.KeyFile) == clean(other.KeyFile)
+}
+
+func normalizeTLSSettings(c *TLSSettings, domain string) error {
+	c.CertFile = strings.TrimSpace(c.CertFile)
+	c.KeyFile = strings.TrimSpace(c.KeyFile)
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return fieldError("tlsCertFile", "tls.pair", "TLS certificate and key must be set together")
+	}
+	if !c.configured() {
+		return nil
+	}
+	domain = strings.TrimSpace(domain)
+	if domain == "" || strings.EqualFold(domain, "localhost") {
+		return fieldError("tlsCertFile", "tls.domain", "TLS needs a domain other than localhost")
+	}
+	if c.CertFile == "" {
+		return nil
+	}
+	for _, f := range []struct {
+		field, label string
+		path         *string
+	}{{"tlsCertFile", "certificate", &c.CertFile}, {"tlsKeyFile", "key", &c.KeyFile}} {
+		if !filepath.IsAbs(*f.path) {
+			return fieldError(f.field, "tls.relative", "TLS "+f.label+" must be an absolute path")
+		}
+		if strings.ContainsAny(*f.path, "$#\"'`\r\n") {
+			return fieldError(f.field, "tls.invalid", "TLS "+f.label+" path must not contain $, #, quotes or line breaks")
+		}
+		*f.path = filepath.Clean(*f.path)
+		// Docker would mount a missing path as an empty directory.
+		if info, err := os.Stat(*f.path); err != nil || !info.Mode().IsRegular() {
+			return fieldError(f.field, "tls.missing", "TLS "+f.label+" "+*f.path+" is not a file")
+		}
+	}
+	return checkTLSCertificate(c.CertFile, c.KeyFile, domain)
+}
+
+// checkTLSCertificate refuses a certificate that is not valid for domain
+// and a key that does not belong to it. Both are mounted into the app
+// container, so a pair the launcher cannot read and match is refused too:
+// otherwise any host file could be handed to the container as the key.
+func checkTLSCertificate(certFile, keyFile, domain string) error {
+	data, err := os.ReadFile(certFile)
+	if err != nil {
+		return fieldError("tlsCertFile", "tls.unreadable", "TLS certificate cannot be read by the launcher: "+err.Error())
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return fieldError("tlsCertFile", "tls.format", "TLS certificate must be a PEM certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return fieldError("tlsCertFile", "tls.format", "TLS certificate cannot be read: "+err.Error())
+	}
+	if err := cert.VerifyHostname(domain); err != nil {
+		return fieldError("tlsCertFile", "tls.hostname", fmt.Sprintf("TLS certificate is not valid for %s", domain))
+	}
+	if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+		return fieldError("tlsKeyFile", "tls.key", "TLS key does not match the certificate: "+err.Error())
+	}
+	return nil
+}
+
+// tlsEnvLines renders the URL and certificate variables of the app; they
+// are always present so the compose file never references an unset
+// variable. The host paths go in as KIMMIO_TLS_* for the mounts.
+func tlsEnvLines(profile ProfileRequest, env map[string]string) []string {
+	c := profile.TLS
+	publicURL, websocketURL, certFile, keyFile := "", "", "", ""
+	if c.configured() {
+		domain := strings.TrimSpace(envValue(env, "APP_DOMAIN", ""))
+		publicURL, websocketURL = "https://"+domain, "wss://"+domain
+	}
+	lines := []string{}
+	if c.CertFile != "" {
+		certFile, keyFile = tlsCertTarget, tlsKeyTarget
+		lines = append(lines, "KIMMIO_TLS_CERT="+c.CertFile, "KIMMIO_TLS_KEY="+c.KeyFile)
+	}
+	return append(lines,
+		"PUBLIC_URL="+envValue(env, "PUBLIC_URL", publicURL),
+		"WEBSOCKET_URL="+envValue(env, "WEBSOCKET_URL", websocketURL),
+		"TLS_CERT_FILE="+certFile,
+		"TLS_KEY_FILE="+keyFile,
+	)
+}
+
+// composeTLSVolumes mounts the certificate and key into the app container.
+func composeTLSVolumes(profile ProfileRequest) string {
+	if profile.TLS.CertFile == "" {
+		return ""
+	}
+	return "      - type: bind\n" +
+		"        source: ${KIMMIO_TLS_CERT}\n" +
+		"        target: " + tlsCertTarget + "\n" +
+		"        read_only: true\n" +
+		"      - type: bind\n" +
+		"        source: ${KIMMIO_TLS_KEY}\n" +
+		"        target: " + tlsKeyTarget + "\n" +
+		"        read_only: true\n"
+}
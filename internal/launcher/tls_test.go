@@ -0,0 +1,163 @@
+package launcher
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTestCertificate(t *testing.T, dir, domain string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: domain},
+		DNSNames:     []string{domain},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := filepath.Join(dir, "fullchain.pem"), filepath.Join(dir, "privkey.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestNormalizeTLSSettings(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCertificate(t, dir, "app.example.com")
+	_, otherKey := writeTestCertificate(t, t.TempDir(), "app.example.com")
+	ok := TLSSettings{CertFile: " " + certFile + " ", KeyFile: keyFile}
+	if err := normalizeTLSSettings(&ok, "app.example.com"); err != nil || ok.CertFile != certFile {
+		t.Fatalf("expected certificate to be accepted: %+v err=%v", ok, err)
+	}
+	proxy := TLSSettings{Proxy: true}
+	if err := normalizeTLSSettings(&proxy, "app.example.com"); err != nil {
+		t.Fatalf("expected proxy without files to be accepted: %v", err)
+	}
+	for _, tc := range []struct {
+		cfg    TLSSettings
+		domain string
+		code   string
+	}{
+		{TLSSettings{CertFile: certFile}, "app.example.com", "tls.pair"},
+		{TLSSettings{Proxy: true}, "localhost", "tls.domain"},
+		{TLSSettings{CertFile: "certs/fullchain.pem", KeyFile: keyFile}, "app.example.com", "tls.relative"},
+		{TLSSettings{CertFile: filepath.Join(dir, "missing.pem"), KeyFile: keyFile}, "app.example.com", "tls.missing"},
+		{TLSSettings{CertFile: certFile, KeyFile: dir}, "app.example.com", "tls.missing"},
+		{TLSSettings{CertFile: keyFile, KeyFile: keyFile}, "app.example.com", "tls.format"},
+		{TLSSettings{CertFile: certFile, KeyFile: keyFile}, "other.example.com", "tls.hostname"},
+		{TLSSettings{CertFile: certFile, KeyFile: otherKey}, "app.example.com", "tls.key"},
+		{TLSSettings{CertFile: certFile, KeyFile: "/etc/hostname"}, "app.example.com", "tls.key"},
+	} {
+		cfg := tc.cfg
+		err := normalizeTLSSettings(&cfg, tc.domain)
+		ve, isValidation := err.(ValidationError)
+		if !isValidation || len(ve.Fields) != 1 || ve.Fields[0].Code != tc.code {
+			t.Fatalf("expected %s for %+v, got %v", tc.code, tc.cfg, err)
+		}
+	}
+}
+
+func TestComposeMountsTLSCertificate(t *testing.T) {
+	p := ProfileRequest{
+		ID:    "alpha",
+		Ports: []PortMapping{{Container: 3000, Host: 8088}},
+		Env:   map[string]string{"APP_DOMAIN": "app.example.com"},
+		TLS:   TLSSettings{CertFile: "/etc/ssl/app/fullchain.pem", KeyFile: "/etc/ssl/app/privkey.pem"},
+	}
+	env := buildComposeEnv(p)
+	for _, want := range []string{
+		"DOMAIN=https://app.example.com\n",
+		"PUBLIC_URL=https://app.example.com\n",
+		"WEBSOCKET_URL=wss://app.example.com\n",
+		"TLS_CERT_FILE=" + tlsCertTarget + "\n",
+		"KIMMIO_TLS_KEY=/etc/ssl/app/privkey.pem\n",
+	} {
+		if !strings.Contains(env, want) {
+			t.Fatalf("expected %q in env:\n%s", want, env)
+		}
+	}
+	yaml := buildComposeYAML(p)
+	if !strings.Contains(yaml, "source: ${KIMMIO_TLS_CERT}\n        target: "+tlsCertTarget+"\n        read_only: true") {
+		t.Fatalf("expected certificate mount in compose file:\n%s", yaml)
+	}
+
+	p.TLS = TLSSettings{Proxy: true}
+	env = buildComposeEnv(p)
+	if !strings.Contains(env, "WEBSOCKET_URL=wss://app.example.com\n") || !strings.Contains(env, "TLS_CERT_FILE=\n") || strings.Contains(env, "KIMMIO_TLS_CERT") {
+		t.Fatalf("expected proxy env without certificate:\n%s", env)
+	}
+	if strings.Contains(buildComposeYAML(p), "KIMMIO_TLS_CERT") {
+		t.Fatal("expected no certificate mount behind a proxy")
+	}
+
+	p.TLS = TLSSettings{}
+	if env := buildComposeEnv(p); !strings.Contains(env, "\nDOMAIN=app.example.com\n") || !strings.Contains(env, "PUBLIC_URL=\n") {
+		t.Fatalf("expected plain domain without TLS:\n%s", env)
+	}
+}
+
+func TestTLSFilesSetLocallyOnly(t *testing.T) {
+	srv := newServiceTestServer(t)
+	certFile, keyFile := writeTestCertificate(t, t.TempDir(), "app.example.com")
+	tlsBody := fmt.Sprintf(`"env":{"APP_DOMAIN":"app.example.com"},"tls":{"certFile":%q,"keyFile":%q}`, certFile, keyFile)
+	remote := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "http://launcher.lan"+target, strings.NewReader(body))
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		if target == "/api/profiles" {
+			srv.handleProfiles(rec, req)
+		} else {
+			srv.handleProfileAction(rec, req)
+		}
+		return rec
+	}
+
+	if rec := remote(http.MethodPost, "/api/profiles", `{"id":"beta","version":"1.0.0","ports":[{"container":3000,"host":8090}],`+tlsBody+`}`); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected a remote create with TLS files to be refused, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := remote(http.MethodPut, "/api/profiles/alpha", `{`+tlsBody+`}`); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected a remote edit of the TLS files to be refused, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	local := httptest.NewRequest(http.MethodPut, "http://localhost/api/profiles/alpha", strings.NewReader(`{`+tlsBody+`}`))
+	local.RemoteAddr = "127.0.0.1:1234"
+	local.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	srv.handleProfileAction(rec, local)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected a local edit to pass, got %d %s", rec.Code, rec.Body.String())
+	}
+	// Sending the same files back, as the edit page does, is not a change.
+	if rec := remote(http.MethodPut, "/api/profiles/alpha", `{`+tlsBody+`}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected a remote edit keeping the TLS files to pass, got %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,181 @@
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const trashPurgeInterval = time.Hour
+
+// trashEntry is a trashed profile as shown on the profiles page and by
+// GET /api/trash.
+type trashEntry struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName,omitempty"`
+	Version     string `json:"version"`
+	DeletedAt   string `json:"deletedAt"`
+	PurgeAt     string `json:"purgeAt"`
+}
+
+// activeProfileCount counts profiles outside the trash; only those count
+// toward MaxProfiles.
+func activeProfileCount(store ProfileStore) int {
+	n := 0
+	for _, p := range store.Profiles {
+		if p.DeletedAt == "" {
+			n++
+		}
+	}
+	return n
+}
+
+// checkTrashedAction allows only restore and purge on trashed profiles, and
+// only on those.
+func checkTrashedAction(store ProfileStore, idx int, action string) error {
+	p := store.Profiles[idx]
+	trashed := p.DeletedAt != ""
+	switch {
+	case trashed && action == "restore":
+		if activeProfileCount(store) >= maxProfilesLimit() {
+			return ValidationError{Msg: fmt.Sprintf("profile limit reached (max %d); delete another profile before restoring %s", maxProfilesLimit(), p.ID)}
+		}
+	case trashed && action != "purge":
+		return ValidationError{Msg: "profile " + p.ID + " is in the trash; restore it first"}
+	case !trashed && (action == "restore" || action == "purge"):
+		return ValidationError{Msg: "profile " + p.ID + " is not in the trash"}
+	}
+	return nil
+}
+
+// trashPurgeTime is when a trashed profile's data is removed for good.
+func trashPurgeTime(p ProfileRequest) (time.Time, bool) {
+	deleted, err := time.Parse(time.RFC3339, p.DeletedAt)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return deleted.AddDate(0, 0, appCfg.TrashRetentionDays), true
+}
+
+func splitTrashedProfiles(profiles []ProfileRequest) (kept, trashed []ProfileRequest) {
+	for _, p := range profiles {
+		if p.DeletedAt != "" {
+			trashed = append(trashed, p)
+		} else {
+			kept = append(kept, p)
+		}
+	}
+	return kept, trashed
+}
+
+func trashView(trashed []ProfileRequest) []trashEntry {
+	entries := make([]trashEntry, 0, len(trashed))
+	for _, p := range trashed {
+		entry := trashEntry{ID: p.ID, DisplayName: p.DisplayName, Version: p.Version, DeletedAt: p.DeletedAt}
+		if at, ok := trashPurgeTime(p); ok {
+			entry.PurgeAt = at.UTC().Format(time.RFC3339)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// performTrash stops the stack but keeps volumes, secrets and compose files
+// until the profile is restored or purged.
+func (s *Server) performTrash(id, jobID string, parent context.Context) error {
+	ctx, cancel := context.WithTimeout(parent, appCfg.ActionTimeout)
+	defer cancel()
+	record := context.WithoutCancel(parent)
+	if err := s.ensureNoOtherJob(id, jobID); err != nil {
+		return err
+	}
+
+	s.updateJobStep(jobID, "down", "running", "Stopping compose stack (volumes are kept)", 35, "")
+	if err := s.compose.down(ctx, id, false); err != nil {
+		_ = s.markProfileResult(record, id, "delete", "failed", err.Error(), "")
+		return err
+	}
+	logInfo("profile_trashed", map[string]any{"profile_id": id, "retention_days": appCfg.TrashRetentionDays})
+	msg := fmt.Sprintf("Moved to trash; data is kept for %d days", appCfg.TrashRetentionDays)
+	return s.markProfileResult(record, id, "delete", "success", msg, "")
+}
+
+// performRestore takes the profile out of the trash, stopped.
+func (s *Server) performRestore(id string, parent context.Context) error {
+	logInfo("profile_restored", map[string]any{"profile_id": id})
+	return s.markProfileResult(context.WithoutCancel(parent), id, "restore", "success", "Profile restored from trash", "")
+}
+
+// startTrashPurger periodically purges profiles whose grace period ended.
+func (s *Server) startTrashPurger(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			s.purgeExpiredTrash(ctx, time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *Server) purgeExpiredTrash(ctx context.Context, now time.Time) {
+	store, err := s.readStore(ctx)
+	if err != nil {
+		logWarn("trash_purge_load_failed", map[string]any{"error": err.Error()})
+		return
+	}
+	for _, p := range store.Profiles {
+		at, ok := trashPurgeTime(p)
+		if !ok || now.Before(at) {
+			continue
+		}
+		// Retention expiry is the confirmation the user agreed to on delete.
+		if _, err := s.Profiles().StartAction(ctx, p.ID, "purge", "", 0, true); err != nil {
+			logWarn("trash_purge_failed", map[string]any{"profile_id": p.ID, "error": err.Error()})
+			continue
+		}
+		logInfo("trash_purge_started", map[string]any{"profile_id": p.ID})
+	}
+}
+
+// handleTrash lists trashed profiles (GET) or purges all of them (DELETE,
+// with {"confirm": "trash"}).
+func (s *Server) handleTrash(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	store, err := s.readStore(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load profiles: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, trashed := splitTrashedProfiles(store.Profiles)
+	if r.Method == http.MethodGet {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"ok":            true,
+			"retentionDays": appCfg.TrashRetentionDays,
+			"profiles":      trashView(trashed),
+		})
+		return
+	}
+	if err := s.checkConfirmation(r, "trash", "purge"); err != nil {
+		http.Error(w, `confirmation required: send {"confirm": "trash"}`, httpStatusForError(err))
+		return
+	}
+	jobs := map[string]string{}
+	for _, p := range trashed {
+		job, err := s.Profiles().StartAction(r.Context(), p.ID, "purge", "", 0, true)
+		if err != nil {
+			http.Error(w, "Failed to purge "+p.ID+": "+err.Error(), httpStatusForError(err))
+			return
+		}
+		jobs[p.ID] = job.ID
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"ok": true, "jobs": jobs})
+}
@@ -0,0 +1,50 @@
+package launcher
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckTrashedAction(t *testing.T) {
+	prev := appCfg
+	defer func() { appCfg = prev }()
+	appCfg.MaxProfiles = 2
+
+	store := ProfileStore{Profiles: []ProfileRequest{
+		{ID: "alpha", DeletedAt: "2026-01-01T00:00:00Z"},
+		{ID: "beta"},
+	}}
+	if err := checkTrashedAction(store, 0, "enable"); err == nil {
+		t.Fatalf("expected enable of a trashed profile to be rejected")
+	}
+	for _, action := range []string{"restore", "purge"} {
+		if err := checkTrashedAction(store, 0, action); err != nil {
+			t.Fatalf("%s should be allowed: %v", action, err)
+		}
+		if err := checkTrashedAction(store, 1, action); err == nil {
+			t.Fatalf("expected %s of an active profile to be rejected", action)
+		}
+	}
+
+	store.Profiles = append(store.Profiles, ProfileRequest{ID: "gamma"})
+	if activeProfileCount(store) != 2 {
+		t.Fatalf("expected trashed profiles not to count, got %d", activeProfileCount(store))
+	}
+	if err := checkTrashedAction(store, 0, "restore"); err == nil {
+		t.Fatalf("expected restore beyond the profile limit to be rejected")
+	}
+}
+
+func TestTrashPurgeTime(t *testing.T) {
+	prev := appCfg
+	defer func() { appCfg = prev }()
+	appCfg.TrashRetentionDays = 7
+
+	at, ok := trashPurgeTime(ProfileRequest{DeletedAt: "2026-03-01T10:00:00Z"})
+	if !ok || !at.Equal(time.Date(2026, 3, 8, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected purge time %v (%v)", at, ok)
+	}
+	if _, ok := trashPurgeTime(ProfileRequest{}); ok {
+		t.Fatalf("profiles outside the trash have no purge time")
+	}
+}
@@ -0,0 +1,304 @@
+package launcher
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const tuiRefreshInterval = 2 * time.Second
+
+// tuiBackend is what the dashboard needs from a launcher: the running one
+// over HTTP, or this process through the service layer.
+type tuiBackend interface {
+	name() string
+	profiles() ([]ProfileRequest, error)
+	dashboard() (Dashboard, error)
+	startAction(id, action string) (string, error)
+	job(id string) (ActionJob, error)
+}
+
+type remoteTUIBackend struct{ c *launcherClient }
+
+func (b remoteTUIBackend) name() string { return b.c.base }
+
+func (b remoteTUIBackend) profiles() ([]ProfileRequest, error) {
+	var resp struct {
+		Profiles []ProfileRequest `json:"profiles"`
+	}
+	err := b.c.do(http.MethodGet, "/api/profiles", &resp)
+	return resp.Profiles, err
+}
+
+func (b remoteTUIBackend) dashboard() (Dashboard, error) {
+	var resp struct {
+		Dashboard Dashboard `json:"dashboard"`
+	}
+	err := b.c.do(http.MethodGet, "/api/dashboard", &resp)
+	return resp.Dashboard, err
+}
+
+func (b remoteTUIBackend) startAction(id, action string) (string, error) {
+	return b.c.startAction(id, action)
+}
+
+func (b remoteTUIBackend) job(id string) (ActionJob, error) { return b.c.job(id) }
+
+type localTUIBackend struct{ srv *Server }
+
+func (b localTUIBackend) name() string { return "local (jobs stop when the dashboard exits)" }
+
+func (b localTUIBackend) profiles() ([]ProfileRequest, error) {
+	return b.srv.Profiles().List(context.Background())
+}
+
+func (b localTUIBackend) dashboard() (Dashboard, error) {
+	return b.srv.dashboard(context.Background())
+}
+
+func (b localTUIBackend) startAction(id, action string) (string, error) {
+	job, err := b.srv.Profiles().StartAction(context.Background(), id, action, "", 0, false)
+	if err != nil {
+		return "", err
+	}
+	return job.ID, nil
+}
+
+func (b localTUIBackend) job(id string) (ActionJob, error) { return b.srv.Jobs().Get(id) }
+
+// tuiModel is the dashboard state. Commands are typed lines, which keeps
+// the dashboard usable over plain SSH sessions and Windows consoles
+// without switching the terminal to raw mode.
+type tuiModel struct {
+	backend  tuiBackend
+	list     []ProfileRequest
+	summary  *Dashboard
+	jobs     []string
+	selected string
+	status   string
+	err      error
+}
+
+// tuiActions maps command letters onto non-destructive profile actions;
+// destructive ones stay in the web UI and the explicit CLI commands.
+var tuiActions = map[string]string{"e": "enable", "s": "stop", "a": "archive", "u": "unarchive"}
+
+func (m *tuiModel) refresh() {
+	m.list, m.err = m.backend.profiles()
+	// Launchers from before the dashboard endpoint only lack the summary.
+	m.summary = nil
+	if d, err := m.backend.dashboard(); err == nil {
+		m.summary = &d
+	}
+}
+
+// handle runs one command line and reports whether the dashboard should
+// exit.
+func (m *tuiModel) handle(line string) bool {
+	fields := strings.Fields(strings.ToLower(line))
+	if len(fields) == 0 {
+		m.status = ""
+		return false
+	}
+	if fields[0] == "q" || fields[0] == "quit" {
+		return true
+	}
+	if len(fields) != 2 {
+		m.status = "Usage: <command> <profile number or id>"
+		return false
+	}
+	id, ok := m.profileRef(fields[1])
+	if !ok {
+		m.status = "Unknown profile: " + fields[1]
+		return false
+	}
+	if fields[0] == "l" {
+		m.selected = id
+		m.status = "Showing activity for " + id
+		return false
+	}
+	action, ok := tuiActions[fields[0]]
+	if !ok {
+		m.status = "Unknown command: " + fields[0]
+		return false
+	}
+	jobID, err := m.backend.startAction(id, action)
+	if err != nil {
+		m.status = fmt.Sprintf("%s %s failed: %v", action, id, err)
+		return false
+	}
+	m.jobs = append([]string{jobID}, m.jobs...)
+	if len(m.jobs) > 5 {
+		m.jobs = m.jobs[:5]
+	}
+	m.selected = id
+	m.status = fmt.Sprintf("Started %s for %s (job %s)", action, id, jobID)
+	return false
+}
+
+// profileRef resolves a 1-based row number, a profile id or a display
+// name.
+func (m *tuiModel) profileRef(ref string) (string, bool) {
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n >= 1 && n <= len(m.list) {
+			return m.list[n-1].ID, true
+		}
+		return "", false
+	}
+	for _, p := range m.list {
+		if p.ID == ref {
+			return p.ID, true
+		}
+	}
+	if id := profileByDisplayName(m.list, ref, ""); id != "" {
+		return id, true
+	}
+	return "", false
+}
+
+func (m *tuiModel) render(w io.Writer) {
+	fmt.Fprintf(w, "Kimmio Launcher - %s - %s\n", m.backend.name(), time.Now().Format("15:04:05"))
+	if m.summary != nil {
+		fmt.Fprintln(w, renderDashboardSummary(*m.summary))
+	}
+	fmt.Fprintln(w)
+	if m.err != nil {
+		fmt.Fprintf(w, "Failed to load profiles: %v\n", m.err)
+	} else {
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "#\tID\tNAME\tVERSION\tPORT\tSTATUS")
+		for i, p := range m.list {
+			port := 0
+			if len(p.Ports) > 0 {
+				port = p.Ports[0].Host
+			}
+			status := p.RuntimeStatus
+			if status == "" {
+				status = "unknown"
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\t%s\n", i+1, p.ID, p.Title(), p.Version, port, status)
+		}
+		_ = tw.Flush()
+		if len(m.list) == 0 {
+			fmt.Fprintln(w, "No profiles found.")
+		}
+	}
+
+	if len(m.jobs) > 0 {
+		fmt.Fprintln(w, "\nJobs")
+		for _, id := range m.jobs {
+			job, err := m.backend.job(id)
+			if err != nil {
+				fmt.Fprintf(w, "  %s: %v\n", id, err)
+				continue
+			}
+			fmt.Fprintf(w, "  %-10s %-10s %s\n", job.ProfileID, job.Action, renderJobProgress(job))
+		}
+	}
+
+	for _, p := range m.list {
+		if p.ID != m.selected {
+			continue
+		}
+		fmt.Fprintf(w, "\nActivity for %s\n", p.Title())
+		for _, entry := range p.ActionLog {
+			fmt.Fprintf(w, "  %s\n", entry)
+		}
+		if len(p.ActionLog) == 0 {
+			fmt.Fprintln(w, "  No recorded actions.")
+		}
+	}
+
+	if m.status != "" {
+		fmt.Fprintf(w, "\n%s\n", m.status)
+	}
+	fmt.Fprint(w, "\n[e]nable [s]top [a]rchive [u]narchive [l]og <#|id>, Enter to refresh, [q]uit\n> ")
+}
+
+// renderDashboardSummary is the one-line summary above the profile table.
+func renderDashboardSummary(d Dashboard) string {
+	statuses := make([]string, 0, len(d.Profiles.ByStatus))
+	for status := range d.Profiles.ByStatus {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	parts := []string{fmt.Sprintf("%d/%d profiles", d.Profiles.Total, d.Profiles.Max)}
+	for _, status := range statuses {
+		parts = append(parts, fmt.Sprintf("%d %s", d.Profiles.ByStatus[status], status))
+	}
+	if n := d.Jobs["active"]; n > 0 {
+		parts = append(parts, fmt.Sprintf("%d active jobs", n))
+	}
+	if d.DiskBytes > 0 {
+		parts = append(parts, d.Disk()+" on disk")
+	}
+	if n := len(d.Updates.Profiles); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d updates available", n))
+	}
+	if d.Updates.Launcher != "" {
+		parts = append(parts, "launcher "+d.Updates.Launcher+" available")
+	}
+	if n := len(d.RecentFailures); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d recent failures", n))
+	}
+	return strings.Join(parts, " · ")
+}
+
+func runTUI(srv *Server, stdin io.Reader, stdout io.Writer) int {
+	var backend tuiBackend = localTUIBackend{srv: srv}
+	if client, ok := findRunningLauncher(); ok {
+		backend = remoteTUIBackend{c: client}
+	}
+	model := &tuiModel{backend: backend}
+	tty := isTerminalWriter(stdout)
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(stdin)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	// Without a terminal there is nothing to redraw, so only input drives
+	// the loop.
+	var tick <-chan time.Time
+	if tty {
+		ticker := time.NewTicker(tuiRefreshInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		model.refresh()
+		if tty {
+			// Clear the screen and move home so the dashboard redraws in place.
+			fmt.Fprint(stdout, "\033[H\033[2J")
+		}
+		model.render(stdout)
+		select {
+		case line, ok := <-lines:
+			if !ok || model.handle(line) {
+				fmt.Fprintln(stdout)
+				return 0
+			}
+		case <-tick:
+		}
+	}
+}
+
+func runTUICLI(srv *Server, args []string, stdout, stderr io.Writer) int {
+	if len(args) != 0 {
+		fmt.Fprintln(stderr, "Usage: tui")
+		return exitUsage
+	}
+	return runTUI(srv, os.Stdin, stdout)
+}
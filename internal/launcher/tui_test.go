@@ -0,0 +1,76 @@
+package launcher
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type fakeTUIBackend struct {
+	list    []ProfileRequest
+	started []string
+}
+
+func (f *fakeTUIBackend) name() string                        { return "fake" }
+func (f *fakeTUIBackend) profiles() ([]ProfileRequest, error) { return f.list, nil }
+func (f *fakeTUIBackend) dashboard() (Dashboard, error) {
+	return Dashboard{Profiles: DashboardProfiles{Total: len(f.list), Max: 3, ByStatus: map[string]int{"running": 1, "stopped": 1}}}, nil
+}
+func (f *fakeTUIBackend) startAction(id, action string) (string, error) {
+	if action == "unarchive" {
+		return "", errors.New("profile is not archived")
+	}
+	f.started = append(f.started, action+":"+id)
+	return "job-1", nil
+}
+func (f *fakeTUIBackend) job(id string) (ActionJob, error) {
+	return ActionJob{ID: id, ProfileID: "beta", Action: "enable", Status: "running", Progress: 50}, nil
+}
+
+func TestTUIModelCommands(t *testing.T) {
+	backend := &fakeTUIBackend{list: []ProfileRequest{
+		{ID: "alpha", Version: "1.0.0", RuntimeStatus: "running", ActionLog: []string{"enabled"}},
+		{ID: "beta", Version: "2.0.0", RuntimeStatus: "stopped"},
+	}}
+	m := &tuiModel{backend: backend}
+	m.refresh()
+
+	if m.handle("e 2") || len(backend.started) != 1 || backend.started[0] != "enable:beta" {
+		t.Fatalf("expected enable for row 2, got %v", backend.started)
+	}
+	if m.handle("s alpha"); backend.started[1] != "stop:alpha" {
+		t.Fatalf("expected stop by id, got %v", backend.started)
+	}
+	m.handle("u alpha")
+	if !strings.Contains(m.status, "not archived") {
+		t.Fatalf("expected action error in status, got %q", m.status)
+	}
+	m.handle("e 9")
+	if !strings.HasPrefix(m.status, "Unknown profile") {
+		t.Fatalf("expected unknown profile, got %q", m.status)
+	}
+	m.handle("l 1")
+
+	var out bytes.Buffer
+	m.render(&out)
+	for _, want := range []string{"2/3 profiles · 1 running · 1 stopped", "alpha", "beta", "[##########----------]  50%", "Activity for alpha", "enabled"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("render missing %q:\n%s", want, out.String())
+		}
+	}
+	if !m.handle("q") {
+		t.Fatalf("expected q to quit")
+	}
+}
+
+func TestRunTUIExitsOnEOF(t *testing.T) {
+	srv := newServiceTestServer(t)
+	var out bytes.Buffer
+	if code := runTUI(srv, strings.NewReader("l 1\n"), &out); code != 0 {
+		t.Fatalf("expected clean exit, got %d", code)
+	}
+	if !strings.Contains(out.String(), "Activity for alpha") {
+		t.Fatalf("expected dashboard output, got:\n%s", out.String())
+	}
+}
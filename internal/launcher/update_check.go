@@ -0,0 +1,218 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const kimmioImageRepo = "kimmio/kimmio-app"
+
+// dockerHubTagAPI is a variable so tests can point it at a local server.
+var dockerHubTagAPI = "https://registry.hub.docker.com/v2/repositories/" + kimmioImageRepo + "/tags/"
+
+// extractedSizeFactor is a rough ratio between the compressed download and
+// the unpacked layers Docker keeps on disk.
+const extractedSizeFactor = 2.5
+
+type registryImage struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant"`
+	Digest       string `json:"digest"`
+	Size         int64  `json:"size"`
+}
+
+type registryTag struct {
+	Name     string          `json:"name"`
+	Digest   string          `json:"digest"`
+	FullSize int64           `json:"full_size"`
+	Images   []registryImage `json:"images"`
+}
+
+// UpdateCheck is a dry run of a version change: nothing is pulled or
+// written, it only reports what the update would need.
+type UpdateCheck struct {
+	ProfileID      string   `json:"profileId"`
+	CurrentVersion string   `json:"currentVersion"`
+	Version        string   `json:"version"`
+	Exists         bool     `json:"exists"`
+	Digest         string   `json:"digest,omitempty"`
+	Platform       string   `json:"platform"`
+	Cached         bool     `json:"cached"`
+	DownloadBytes  int64    `json:"downloadBytes"`
+	RequiredBytes  int64    `json:"requiredBytes"`
+	FreeBytes      int64    `json:"freeBytes,omitempty"`
+	Warnings       []string `json:"warnings"`
+}
+
+func fetchRegistryTag(ctx context.Context, tag string) (registryTag, bool, error) {
+	var out registryTag
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dockerHubTagAPI+url.PathEscape(tag), nil)
+	if err != nil {
+		return out, false, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return out, false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return out, false, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return out, false, fmt.Errorf("registry tag lookup failed with status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return out, false, err
+	}
+	return out, true, nil
+}
+
+// imageForPlatform picks the manifest Docker would pull on this host.
+// Containers always run linux images, on the host's CPU architecture.
+func (t registryTag) imageForPlatform(arch string) (registryImage, bool) {
+	for _, img := range t.Images {
+		if img.OS == "linux" && img.Architecture == arch {
+			return img, true
+		}
+	}
+	return registryImage{}, false
+}
+
+func (p *ProfileService) UpdateCheck(ctx context.Context, id, version string) (UpdateCheck, error) {
+	version = strings.TrimSpace(version)
+	if !versionTagRe.MatchString(version) {
+		return UpdateCheck{}, ValidationError{Msg: "invalid version tag"}
+	}
+	profile, err := p.Get(ctx, id)
+	if err != nil {
+		return UpdateCheck{}, err
+	}
+	check := UpdateCheck{
+		ProfileID:      profile.ID,
+		CurrentVersion: profile.Version,
+		Version:        version,
+		Platform:       "linux/" + profileArch(profile),
+		Warnings:       []string{},
+	}
+	warn := func(msg string) { check.Warnings = append(check.Warnings, msg) }
+
+	tag, exists, err := fetchRegistryTag(ctx, version)
+	if err != nil {
+		return UpdateCheck{}, fmt.Errorf("registry lookup failed: %w", err)
+	}
+	check.Exists = exists
+	if !exists {
+		warn("Tag " + version + " does not exist in " + kimmioImageRepo + ".")
+		return check, nil
+	}
+	check.Digest = tag.Digest
+	check.DownloadBytes = tag.FullSize
+	// On arm64 hosts the update may switch the profile to the amd64 image.
+	target := profile
+	platform, runnable := platformForTag(profile, tag)
+	target.Platform = platform
+	if img, ok := tag.imageForPlatform(profileArch(target)); ok {
+		check.Digest = img.Digest
+		check.DownloadBytes = img.Size
+	} else if !runnable {
+		warn("Tag " + version + " has no image for " + check.Platform + "; the pull will fail on this host.")
+	}
+	if target.Platform != profile.Platform {
+		warn("Tag " + version + " has no image for " + check.Platform + "; the update switches the profile to " + target.Platform + ".")
+		check.Platform = target.Platform
+	}
+
+	if check.Digest != "" && localImageHasDigest(ctx, kimmioImageRepo+":"+version, check.Digest) {
+		check.Cached = true
+		check.DownloadBytes = 0
+	}
+	check.RequiredBytes = int64(float64(check.DownloadBytes) * extractedSizeFactor)
+	if free, ok := dockerDiskFree(ctx); ok {
+		check.FreeBytes = free
+		if check.RequiredBytes > free {
+			warn(fmt.Sprintf("About %s of disk space is needed but only %s is free.", formatBytes(check.RequiredBytes), formatBytes(free)))
+		}
+	}
+
+	if isEmulated(target) {
+		warn(emulationGuidance())
+	}
+
+	current := strings.TrimSpace(profile.Version)
+	switch {
+	case current == version:
+		warn("Profile already runs " + version + "; the update only re-pulls the image.")
+	case current == "" || current == "latest" || version == "latest":
+		warn("Moving between latest and a pinned tag may change the major version; check the release notes.")
+	case isNewerVersion(current, version):
+		warn("Downgrading from " + current + " to " + version + " may not be supported by existing data.")
+	case parseVersionParts(version)[0] > parseVersionParts(current)[0]:
+		warn("Major version change from " + current + " to " + version + "; back up data before updating.")
+	}
+	return check, nil
+}
+
+func localImageHasDigest(ctx context.Context, image, digest string) bool {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if engine, err := newDockerEngine(); err == nil {
+		img, err := engine.inspectImage(ctx, image)
+		return err == nil && strings.Contains(strings.Join(img.RepoDigests, " "), digest)
+	}
+	dockerBin, err := dockerBinaryPath()
+	if err != nil {
+		return false
+	}
+	out, err := dockerCommandWithContext(ctx, dockerBin, "image", "inspect", "--format", "{{json .RepoDigests}}", image).Output()
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(out), digest)
+}
+
+// dockerDiskFree reports free space under Docker's data root. It is unknown
+// when Docker runs inside a VM (Docker Desktop), where the root is not a
+// host path.
+func dockerDiskFree(ctx context.Context) (int64, bool) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	root, err := dockerInfoField(ctx, "{{.DockerRootDir}}", func(info engineInfo) string { return info.DockerRootDir })
+	if err != nil {
+		return 0, false
+	}
+	free, err := diskFreeBytes(root)
+	if err != nil {
+		return 0, false
+	}
+	return free, true
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func (s *Server) handleProfileUpdateCheck(w http.ResponseWriter, r *http.Request, id string) {
+	check, err := s.Profiles().UpdateCheck(r.Context(), id, r.URL.Query().Get("version"))
+	if err != nil {
+		http.Error(w, err.Error(), httpStatusForError(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "check": check})
+}
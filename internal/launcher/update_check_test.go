@@ -0,0 +1,106 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestProfileUpdateCheck(t *testing.T) {
+	srv := newServiceTestServer(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch strings.TrimPrefix(r.URL.Path, "/tags/") {
+		case "2.0.0":
+			_, _ = w.Write([]byte(`{"name":"2.0.0","digest":"sha256:list","full_size":900,"images":[
+				{"architecture":"` + runtime.GOARCH + `","os":"linux","digest":"sha256:native","size":400}]}`))
+		case "0.9.0":
+			_, _ = w.Write([]byte(`{"name":"0.9.0","digest":"sha256:old","full_size":300,"images":[
+				{"architecture":"s390x","os":"linux","digest":"sha256:other","size":300}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	prev := dockerHubTagAPI
+	dockerHubTagAPI = ts.URL + "/tags/"
+	defer func() { dockerHubTagAPI = prev }()
+
+	check, err := srv.Profiles().UpdateCheck(context.Background(), "alpha", "2.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !check.Exists || check.Digest != "sha256:native" || check.DownloadBytes != 400 || check.RequiredBytes != 1000 {
+		t.Fatalf("unexpected check: %+v", check)
+	}
+	if len(check.Warnings) != 1 || !strings.Contains(check.Warnings[0], "Major version") {
+		t.Fatalf("expected major version warning, got %v", check.Warnings)
+	}
+
+	check, err = srv.Profiles().UpdateCheck(context.Background(), "alpha", "0.9.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(check.Warnings) != 2 || !strings.Contains(check.Warnings[0], "no image for") || !strings.Contains(check.Warnings[1], "Downgrading") {
+		t.Fatalf("expected platform and downgrade warnings, got %v", check.Warnings)
+	}
+
+	check, err = srv.Profiles().UpdateCheck(context.Background(), "alpha", "9.9.9")
+	if err != nil || check.Exists {
+		t.Fatalf("expected missing tag to be reported, got %+v err=%v", check, err)
+	}
+
+	var ve ValidationError
+	if _, err := srv.Profiles().UpdateCheck(context.Background(), "alpha", "bad tag!"); !errors.As(err, &ve) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+}
+
+func TestArm64HostFallsBackToAMD64Image(t *testing.T) {
+	srv := newServiceTestServer(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch strings.TrimPrefix(r.URL.Path, "/tags/") {
+		case "1.0.0":
+			_, _ = w.Write([]byte(`{"name":"1.0.0","images":[{"architecture":"amd64","os":"linux","digest":"sha256:amd","size":500}]}`))
+		case "1.1.0":
+			_, _ = w.Write([]byte(`{"name":"1.1.0","images":[{"architecture":"s390x","os":"linux","digest":"sha256:other","size":500}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	prevAPI, prevArch := dockerHubTagAPI, hostArch
+	dockerHubTagAPI = ts.URL + "/tags/"
+	hostArch = func() string { return "arm64" }
+	defer func() { dockerHubTagAPI, hostArch = prevAPI, prevArch }()
+
+	check, err := srv.Profiles().UpdateCheck(context.Background(), "alpha", "1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if check.Platform != platformAMD64 || check.Digest != "sha256:amd" || !strings.Contains(strings.Join(check.Warnings, "\n"), "switches the profile to linux/amd64") {
+		t.Fatalf("expected the check to announce the amd64 image, got %+v", check)
+	}
+
+	profile, err := srv.Profiles().Get(context.Background(), "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	notes := []string{}
+	notify := func(step, message string, progress int) { notes = append(notes, message) }
+	resolved, err := srv.resolveProfilePlatform(context.Background(), profile, notify)
+	if err != nil || resolved.Platform != platformAMD64 || len(notes) != 1 {
+		t.Fatalf("expected the amd64 override before the pull, got %q %v %v", resolved.Platform, notes, err)
+	}
+	if saved, _ := srv.Profiles().Get(context.Background(), "alpha"); saved.Platform != platformAMD64 {
+		t.Fatalf("expected the override to be saved, got %q", saved.Platform)
+	}
+
+	profile.Version = "1.1.0"
+	if _, err := srv.resolveProfilePlatform(context.Background(), profile, notify); err == nil || !strings.Contains(err.Error(), "no image for linux/arm64") {
+		t.Fatalf("expected a tag without a runnable image to fail before the pull, got %v", err)
+	}
+}
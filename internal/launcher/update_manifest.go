@@ -0,0 +1,80 @@
+package launcher
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// updateManifest is the feed format for mirrors that host launcher builds
+// outside GitHub:
+//
+//	{"version": "1.4.0", "releaseURL": "...", "notes": "...",
+//	 "assets": [{"name": "...-linux-amd64.deb", "url": "...", "sha256": "..."}]}
+type updateManifest struct {
+	Version    string `json:"version"`
+	ReleaseURL string `json:"releaseURL"`
+	Notes      string `json:"notes"`
+	Assets     []struct {
+		Name   string `json:"name"`
+		URL    string `json:"url"`
+		SHA256 string `json:"sha256"`
+	} `json:"assets"`
+}
+
+func fetchManifestRelease(url string) (githubRelease, error) {
+	var out githubRelease
+	client := http.Client{Timeout: 5 * time.Second}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return out, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "kimmio-launcher")
+	resp, err := client.Do(req)
+	if err != nil {
+		return out, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return out, errors.New("update manifest request failed")
+	}
+	var manifest updateManifest
+	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		return out, err
+	}
+	return manifest.release()
+}
+
+// release converts the manifest to the GitHub release shape so asset
+// selection and verification work the same for both feeds.
+func (m updateManifest) release() (githubRelease, error) {
+	version := strings.TrimPrefix(strings.TrimSpace(m.Version), "v")
+	if version == "" {
+		return githubRelease{}, errors.New("update manifest has no version")
+	}
+	out := githubRelease{
+		TagName:   "v" + version,
+		Name:      version,
+		HTMLURL:   strings.TrimSpace(m.ReleaseURL),
+		Body:      m.Notes,
+		Checksums: map[string]string{},
+	}
+	for _, a := range m.Assets {
+		name := strings.TrimSpace(a.Name)
+		url := strings.TrimSpace(a.URL)
+		if name == "" || url == "" {
+			continue
+		}
+		out.Assets = append(out.Assets, struct {
+			Name               string `json:"name"`
+			BrowserDownloadURL string `json:"browser_download_url"`
+		}{Name: name, BrowserDownloadURL: url})
+		if sum, err := normalizeSHA256(a.SHA256); err == nil {
+			out.Checksums[strings.ToLower(name)] = sum
+		}
+	}
+	return out, nil
+}
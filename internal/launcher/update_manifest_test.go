@@ -0,0 +1,52 @@
+package launcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchLatestLauncherReleaseFromManifest(t *testing.T) {
+	sum := sha256.Sum256([]byte("mirror-build"))
+	digest := hex.EncodeToString(sum[:])
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{
+			"version": "v2.1.0",
+			"releaseURL": "https://mirror.example/launcher/2.1.0",
+			"notes": "Internal build",
+			"assets": [
+				{"name": "Kimmio-Launcher-2.1.0-linux-amd64.deb", "url": "https://mirror.example/linux.deb", "sha256": "` + digest + `"}
+			]
+		}`))
+	}))
+	defer ts.Close()
+
+	prev := appCfg.UpdateManifestURL
+	appCfg.UpdateManifestURL = ts.URL
+	defer func() { appCfg.UpdateManifestURL = prev }()
+
+	release, err := fetchLatestLauncherRelease(updateChannelStable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if release.TagName != "v2.1.0" || release.Body != "Internal build" {
+		t.Fatalf("unexpected release: %+v", release)
+	}
+	asset, ok := chooseVerifiedLauncherAsset(release, "linux", "amd64")
+	if !ok || asset.URL != "https://mirror.example/linux.deb" || asset.SHA256 != digest {
+		t.Fatalf("expected verifiable mirror asset, got %+v ok=%v", asset, ok)
+	}
+	if err := verifyLauncherAsset(strings.NewReader("mirror-build"), asset.Name, []byte(asset.SHA256)); err != nil {
+		t.Fatalf("expected inline digest to verify: %v", err)
+	}
+
+	if _, err := fetchLauncherReleaseByVersion("2.1.0"); err != nil {
+		t.Fatalf("expected manifest notes for its own version: %v", err)
+	}
+	if _, err := fetchLauncherReleaseByVersion("2.0.0"); err == nil {
+		t.Fatalf("expected other versions to be unavailable from the manifest")
+	}
+}
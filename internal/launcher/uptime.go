@@ -0,0 +1,207 @@
+package launcher
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Every health check of the monitor is folded into hourly buckets per
+// profile, kept for 30 days under DataDir/uptime, so uptime over the last
+// 24 hours, 7 days and 30 days survives a restart. Only time a profile is
+// meant to run counts: a stopped, archived or deleted profile is not down,
+// and neither is one still starting after an action.
+
+const (
+	uptimeRetention = 30 * 24 * time.Hour
+	uptimeDirName   = "uptime"
+	// uptimeSaveEvery bounds how often a profile's buckets are written;
+	// a crash loses at most this much history.
+	uptimeSaveEvery = time.Minute
+)
+
+// uptimeBucket is the checked time of one hour, split into up and down.
+type uptimeBucket struct {
+	Hour        time.Time `json:"hour"`
+	UpSeconds   int64     `json:"up"`
+	DownSeconds int64     `json:"down"`
+}
+
+// UptimeWindow is the share of checked time a profile was healthy.
+// Percent means nothing while CheckedSeconds is 0.
+type UptimeWindow struct {
+	Percent        float64 `json:"percent"`
+	CheckedSeconds int64   `json:"checkedSeconds"`
+}
+
+// ProfileUptime is a profile's uptime over the usual SLA windows.
+type ProfileUptime struct {
+	Day   UptimeWindow `json:"24h"`
+	Week  UptimeWindow `json:"7d"`
+	Month UptimeWindow `json:"30d"`
+}
+
+// uptimeTracker holds the buckets of every profile. They are loaded from
+// disk on first use.
+type uptimeTracker struct {
+	mu      sync.Mutex
+	dir     string
+	buckets map[string][]uptimeBucket
+	savedAt map[string]time.Time
+}
+
+func newUptimeTracker(dataDir string) *uptimeTracker {
+	return &uptimeTracker{
+		dir:     filepath.Join(dataDir, uptimeDirName),
+		buckets: map[string][]uptimeBucket{},
+		savedAt: map[string]time.Time{},
+	}
+}
+
+func (u *uptimeTracker) path(id string) string {
+	return filepath.Join(u.dir, id+".json")
+}
+
+func (u *uptimeTracker) bucketsLocked(id string) []uptimeBucket {
+	if b, ok := u.buckets[id]; ok {
+		return b
+	}
+	var buckets []uptimeBucket
+	raw, err := os.ReadFile(u.path(id))
+	if err == nil {
+		if err := json.Unmarshal(raw, &buckets); err != nil {
+			logWarn("uptime_file_invalid", map[string]any{"profile_id": id, "error": err.Error()})
+			buckets = nil
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		logWarn("uptime_read_failed", map[string]any{"profile_id": id, "error": err.Error()})
+	}
+	u.buckets[id] = buckets
+	return buckets
+}
+
+// record adds d of checked time, up or down, to the hour of at.
+func (u *uptimeTracker) record(id string, up bool, at time.Time, d time.Duration) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	hour := at.UTC().Truncate(time.Hour)
+	buckets := u.bucketsLocked(id)
+	if n := len(buckets); n == 0 || !buckets[n-1].Hour.Equal(hour) {
+		buckets = append(buckets, uptimeBucket{Hour: hour})
+	}
+	last := &buckets[len(buckets)-1]
+	if up {
+		last.UpSeconds += int64(d / time.Second)
+	} else {
+		last.DownSeconds += int64(d / time.Second)
+	}
+	cutoff := hour.Add(-uptimeRetention)
+	for len(buckets) > 0 && !buckets[0].Hour.After(cutoff) {
+		buckets = buckets[1:]
+	}
+	u.buckets[id] = buckets
+	if at.Sub(u.savedAt[id]) < uptimeSaveEvery {
+		return nil
+	}
+	u.savedAt[id] = at
+	return u.saveLocked(id)
+}
+
+func (u *uptimeTracker) saveLocked(id string) error {
+	raw, err := json.Marshal(u.buckets[id])
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(u.dir, 0o755); err != nil {
+		return err
+	}
+	tmp := u.path(id) + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, u.path(id))
+}
+
+// uptime returns the profile's uptime at now, or nil when it was never
+// checked.
+func (u *uptimeTracker) uptime(id string, now time.Time) *ProfileUptime {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	buckets := u.bucketsLocked(id)
+	if len(buckets) == 0 {
+		return nil
+	}
+	window := func(d time.Duration) UptimeWindow {
+		// The current, partial hour counts, so a window spans up to an
+		// hour more than its name.
+		from := now.UTC().Truncate(time.Hour).Add(-d)
+		var up, down int64
+		for _, b := range buckets {
+			if b.Hour.After(from) {
+				up += b.UpSeconds
+				down += b.DownSeconds
+			}
+		}
+		w := UptimeWindow{CheckedSeconds: up + down}
+		if w.CheckedSeconds > 0 {
+			w.Percent = math.Round(float64(up)/float64(w.CheckedSeconds)*10000) / 100
+		}
+		return w
+	}
+	return &ProfileUptime{
+		Day:   window(24 * time.Hour),
+		Week:  window(7 * 24 * time.Hour),
+		Month: window(uptimeRetention),
+	}
+}
+
+// history returns a copy of the profile's hourly buckets, oldest first.
+func (u *uptimeTracker) history(id string) []uptimeBucket {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return append([]uptimeBucket(nil), u.bucketsLocked(id)...)
+}
+
+// forget drops the history of profiles that no longer exist.
+func (u *uptimeTracker) forget(keep map[string]bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	entries, err := os.ReadDir(u.dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || keep[id] {
+			continue
+		}
+		delete(u.buckets, id)
+		delete(u.savedAt, id)
+		if err := os.Remove(filepath.Join(u.dir, e.Name())); err == nil {
+			logInfo("uptime_history_removed", map[string]any{"profile_id": id})
+		}
+	}
+}
+
+// recordUptime counts one monitor pass of interval for every profile that
+// is meant to run.
+func (s *Server) recordUptime(profiles []ProfileRequest, now time.Time, interval time.Duration) {
+	keep := map[string]bool{}
+	for _, p := range profiles {
+		keep[p.ID] = true
+		switch p.RuntimeStatus {
+		case "stopped", "starting", "archived", "deleted", "":
+			continue
+		}
+		if err := s.uptime.record(p.ID, p.RuntimeStatus == "running", now, interval); err != nil {
+			logWarn("uptime_write_failed", map[string]any{"profile_id": p.ID, "error": err.Error()})
+		}
+	}
+	s.uptime.forget(keep)
+}
@@ -0,0 +1,81 @@
+package launcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUptimeWindows(t *testing.T) {
+	dir := t.TempDir()
+	u := newUptimeTracker(dir)
+	now := time.Date(2026, 3, 31, 12, 30, 0, 0, time.UTC)
+
+	// Down for an hour ten days ago, up for an hour two days ago and 45
+	// minutes up, 15 down in the current hour.
+	mustRecord := func(up bool, at time.Time, d time.Duration) {
+		t.Helper()
+		if err := u.record("alpha", up, at, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustRecord(false, now.Add(-10*24*time.Hour), time.Hour)
+	mustRecord(true, now.Add(-2*24*time.Hour), time.Hour)
+	mustRecord(true, now.Add(-30*time.Second), 45*time.Minute)
+	mustRecord(false, now, 15*time.Minute)
+
+	got := u.uptime("alpha", now)
+	if got.Day.Percent != 75 || got.Day.CheckedSeconds != 3600 {
+		t.Fatalf("expected 75%% over the last day, got %+v", got.Day)
+	}
+	if got.Week.Percent != 87.5 || got.Month.Percent != 58.33 || got.Month.CheckedSeconds != 3*3600 {
+		t.Fatalf("unexpected week %+v or month %+v", got.Week, got.Month)
+	}
+	if u.uptime("beta", now) != nil {
+		t.Fatal("expected no uptime for a profile never checked")
+	}
+
+	// A new tracker reads what was saved; the last record came within
+	// uptimeSaveEvery of the previous one and waits for the next save.
+	reloaded := newUptimeTracker(dir).uptime("alpha", now)
+	if reloaded == nil || reloaded.Month.CheckedSeconds != 3*3600-15*60 {
+		t.Fatalf("expected the saved buckets read back, got %+v", reloaded)
+	}
+
+	// Buckets older than the retention are dropped: 29 days on, only the
+	// current hour is left of the earlier checks.
+	later := now.Add(29 * 24 * time.Hour)
+	mustRecord(true, later, time.Hour)
+	if got := u.uptime("alpha", later); got.Month.CheckedSeconds != 2*3600 || got.Month.Percent != 87.5 {
+		t.Fatalf("expected the old buckets dropped, got %+v", got.Month)
+	}
+}
+
+func TestRecordUptimeSkipsProfilesNotMeantToRun(t *testing.T) {
+	srv := newServiceTestServer(t)
+	now := time.Now()
+	srv.recordUptime([]ProfileRequest{
+		{ID: "alpha", RuntimeStatus: "running"},
+		{ID: "beta", RuntimeStatus: "unhealthy"},
+		{ID: "gamma", RuntimeStatus: "stopped"},
+		{ID: "delta", RuntimeStatus: "starting"},
+	}, now, 15*time.Second)
+
+	if got := srv.uptime.uptime("alpha", now); got == nil || got.Day.Percent != 100 {
+		t.Fatalf("expected alpha up, got %+v", got)
+	}
+	if got := srv.uptime.uptime("beta", now); got == nil || got.Day.Percent != 0 || got.Day.CheckedSeconds != 15 {
+		t.Fatalf("expected beta down, got %+v", got)
+	}
+	for _, id := range []string{"gamma", "delta"} {
+		if got := srv.uptime.uptime(id, now); got != nil {
+			t.Fatalf("expected no uptime for %s, got %+v", id, got)
+		}
+	}
+
+	srv.recordUptime([]ProfileRequest{{ID: "alpha", RuntimeStatus: "running"}}, now.Add(time.Minute), 15*time.Second)
+	if _, err := os.Stat(filepath.Join(srv.uptime.dir, "beta.json")); !os.IsNotExist(err) {
+		t.Fatalf("expected the history of a deleted profile removed, got %v", err)
+	}
+}
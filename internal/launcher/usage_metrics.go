@@ -0,0 +1,430 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Per-profile CPU, memory and disk usage is sampled once a minute from
+// docker stats and kept for a day in a ring buffer per profile, persisted
+// under DataDir/metrics so graphs survive a launcher restart. Disk usage
+// comes from docker system df, which walks every volume, so it is only
+// refreshed every usageDiskEvery samples and carried forward in between.
+
+const (
+	usageSampleInterval = time.Minute
+	usageRetention      = 24 * time.Hour
+	usageCapacity       = int(usageRetention / usageSampleInterval)
+	usageDiskEvery      = 15
+	usageDirName        = "metrics"
+	usageStatsTimeout   = 30 * time.Second
+	defaultUsageRange   = time.Hour
+)
+
+// UsageSample is one profile's resource usage summed over its containers.
+type UsageSample struct {
+	At          time.Time `json:"at"`
+	CPUPercent  float64   `json:"cpuPercent"`
+	MemoryBytes int64     `json:"memoryBytes"`
+	DiskBytes   int64     `json:"diskBytes"`
+}
+
+// usageRing keeps the newest usageCapacity samples in insertion order.
+type usageRing struct {
+	samples []UsageSample
+	next    int
+	full    bool
+}
+
+func newUsageRing(samples []UsageSample) *usageRing {
+	r := &usageRing{samples: make([]UsageSample, usageCapacity)}
+	for _, sample := range samples {
+		r.add(sample)
+	}
+	return r
+}
+
+func (r *usageRing) add(sample UsageSample) {
+	r.samples[r.next] = sample
+	r.next = (r.next + 1) % len(r.samples)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+func (r *usageRing) ordered() []UsageSample {
+	if !r.full {
+		return append([]UsageSample(nil), r.samples[:r.next]...)
+	}
+	out := make([]UsageSample, 0, len(r.samples))
+	out = append(out, r.samples[r.next:]...)
+	return append(out, r.samples[:r.next]...)
+}
+
+func (r *usageRing) since(from time.Time) []UsageSample {
+	out := []UsageSample{}
+	for _, sample := range r.ordered() {
+		if !sample.At.Before(from) {
+			out = append(out, sample)
+		}
+	}
+	return out
+}
+
+func (r *usageRing) last() (UsageSample, bool) {
+	if !r.full && r.next == 0 {
+		return UsageSample{}, false
+	}
+	return r.samples[(r.next+len(r.samples)-1)%len(r.samples)], true
+}
+
+// usageHistory holds the rings of every profile. Rings are loaded from disk
+// on first use.
+type usageHistory struct {
+	mu    sync.Mutex
+	dir   string
+	rings map[string]*usageRing
+}
+
+func newUsageHistory(dataDir string) *usageHistory {
+	return &usageHistory{dir: filepath.Join(dataDir, usageDirName), rings: map[string]*usageRing{}}
+}
+
+func (h *usageHistory) path(id string) string {
+	return filepath.Join(h.dir, id+".json")
+}
+
+func (h *usageHistory) ringLocked(id string) *usageRing {
+	if r, ok := h.rings[id]; ok {
+		return r
+	}
+	var samples []UsageSample
+	raw, err := os.ReadFile(h.path(id))
+	if err == nil {
+		if err := json.Unmarshal(raw, &samples); err != nil {
+			logWarn("usage_metrics_file_invalid", map[string]any{"profile_id": id, "error": err.Error()})
+			samples = nil
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		logWarn("usage_metrics_read_failed", map[string]any{"profile_id": id, "error": err.Error()})
+	}
+	r := newUsageRing(samples)
+	h.rings[id] = r
+	return r
+}
+
+func (h *usageHistory) add(id string, sample UsageSample) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r := h.ringLocked(id)
+	r.add(sample)
+	raw, err := json.Marshal(r.ordered())
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(h.dir, 0o755); err != nil {
+		return err
+	}
+	tmp := h.path(id) + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, h.path(id))
+}
+
+func (h *usageHistory) since(id string, from time.Time) []UsageSample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.ringLocked(id).since(from)
+}
+
+func (h *usageHistory) lastDisk(id string) int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if sample, ok := h.ringLocked(id).last(); ok {
+		return sample.DiskBytes
+	}
+	return 0
+}
+
+// forget drops the history of profiles that no longer exist.
+func (h *usageHistory) forget(keep map[string]bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	entries, err := os.ReadDir(h.dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || keep[id] {
+			continue
+		}
+		delete(h.rings, id)
+		if err := os.Remove(filepath.Join(h.dir, e.Name())); err == nil {
+			logInfo("usage_metrics_removed", map[string]any{"profile_id": id})
+		}
+	}
+}
+
+// containerUsage is what docker stats reports for the running containers
+// of each profile, keyed by profile ID.
+type containerUsage struct {
+	cpu    float64
+	memory int64
+}
+
+// Swappable so tests can sample without Docker.
+var (
+	readProfileStats = dockerProfileStats
+	readProfileDisk  = dockerProfileDisk
+)
+
+func dockerProfileStats(parent context.Context) (map[string]containerUsage, error) {
+	ctx, cancel := context.WithTimeout(parent, usageStatsTimeout)
+	defer cancel()
+	if engine, err := newDockerEngine(); err == nil {
+		return engineProfileStats(ctx, engine)
+	}
+	dockerBin, err := dockerBinaryPath()
+	if err != nil {
+		return nil, err
+	}
+	psArgs := append([]string{"ps", "--no-trunc"}, managedResourceFilters("")...)
+	psArgs = append(psArgs, "--format", `{{.ID}}	{{.Label "`+labelProfileID+`"}}`)
+	out, err := dockerCommandWithContext(ctx, dockerBin, psArgs...).Output()
+	if err != nil {
+		return nil, err
+	}
+	owners := parseContainerOwners(string(out))
+	if len(owners) == 0 {
+		return map[string]containerUsage{}, nil
+	}
+	statsArgs := []string{"stats", "--no-stream", "--no-trunc", "--format", "{{.ID}}\t{{.CPUPerc}}\t{{.MemUsage}}"}
+	for id := range owners {
+		statsArgs = append(statsArgs, id)
+	}
+	out, err = dockerCommandWithContext(ctx, dockerBin, statsArgs...).Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseDockerStats(string(out), owners), nil
+}
+
+func engineProfileStats(ctx context.Context, engine *dockerEngine) (map[string]containerUsage, error) {
+	containers, err := engine.listContainers(ctx, managedResourceLabels("")...)
+	if err != nil {
+		return nil, err
+	}
+	owners := map[string]string{}
+	ids := []string{}
+	for _, c := range containers {
+		if profileID := c.Labels[labelProfileID]; profileID != "" {
+			owners[c.ID] = profileID
+			ids = append(ids, c.ID)
+		}
+	}
+	usage := map[string]containerUsage{}
+	for id, sample := range engine.containerStats(ctx, ids) {
+		u := usage[owners[id]]
+		u.cpu += sample.cpuPercent()
+		u.memory += sample.memoryUsed()
+		usage[owners[id]] = u
+	}
+	return usage, nil
+}
+
+// parseContainerOwners maps container IDs to profile IDs from docker ps
+// lines of "<id>\t<profile-id>".
+func parseContainerOwners(out string) map[string]string {
+	owners := map[string]string{}
+	for _, line := range strings.Split(out, "\n") {
+		id, profileID, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if ok && id != "" && profileID != "" {
+			owners[id] = profileID
+		}
+	}
+	return owners
+}
+
+// parseDockerStats sums docker stats lines of "<id>\t<cpu%>\t<used> /
+// <limit>" per profile.
+func parseDockerStats(out string, owners map[string]string) map[string]containerUsage {
+	usage := map[string]containerUsage{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if len(fields) != 3 {
+			continue
+		}
+		profileID, ok := owners[fields[0]]
+		if !ok {
+			continue
+		}
+		u := usage[profileID]
+		if cpu, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(fields[1]), "%"), 64); err == nil {
+			u.cpu += cpu
+		}
+		used, _, _ := strings.Cut(fields[2], "/")
+		if mem, ok := parseDockerSize(used); ok {
+			u.memory += mem
+		}
+		usage[profileID] = u
+	}
+	return usage
+}
+
+func dockerProfileDisk(parent context.Context) (map[string]int64, error) {
+	ctx, cancel := context.WithTimeout(parent, usageStatsTimeout)
+	defer cancel()
+	dockerBin, err := dockerBinaryPath()
+	if err != nil {
+		return nil, err
+	}
+	format := `{{range .Volumes}}{{.Label "` + labelProfileID + `"}}	{{.Size}}
+{{end}}{{range .Containers}}{{.Label "` + labelProfileID + `"}}	{{.Size}}
+{{end}}`
+	out, err := dockerCommandWithContext(ctx, dockerBin, "system", "df", "-v", "--format", format).Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseDockerDisk(string(out)), nil
+}
+
+// parseDockerDisk sums "<profile-id>\t<size>" lines per profile. Container
+// sizes read "12kB (virtual 1.2GB)"; only the writable layer counts, since
+// image layers are shared between profiles.
+func parseDockerDisk(out string) map[string]int64 {
+	disk := map[string]int64{}
+	for _, line := range strings.Split(out, "\n") {
+		profileID, size, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if !ok || profileID == "" {
+			continue
+		}
+		size, _, _ = strings.Cut(strings.TrimSpace(size), " ")
+		if n, ok := parseDockerSize(size); ok {
+			disk[profileID] += n
+		}
+	}
+	return disk
+}
+
+// parseDockerSize reads the sizes the docker CLI prints: decimal units such
+// as "1.5GB" from df and binary units such as "512MiB" from stats.
+func parseDockerSize(s string) (int64, bool) {
+	s = strings.TrimSpace(s)
+	i := 0
+	for i < len(s) && (s[i] == '.' || (s[i] >= '0' && s[i] <= '9')) {
+		i++
+	}
+	value, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, false
+	}
+	units := map[string]float64{
+		"": 1, "b": 1,
+		"kb": 1e3, "mb": 1e6, "gb": 1e9, "tb": 1e12,
+		"kib": 1 << 10, "mib": 1 << 20, "gib": 1 << 30, "tib": 1 << 40,
+	}
+	mult, ok := units[strings.ToLower(strings.TrimSpace(s[i:]))]
+	if !ok {
+		return 0, false
+	}
+	return int64(value * mult), true
+}
+
+func (s *Server) startUsageSampler(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick := 0
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.sampleUsage(ctx, now, tick%usageDiskEvery == 0)
+				tick++
+			}
+		}
+	}()
+}
+
+// sampleUsage records one sample for every enabled profile. Stopped
+// profiles get no samples, so their graphs show a gap rather than zeros.
+func (s *Server) sampleUsage(ctx context.Context, now time.Time, withDisk bool) {
+	store, err := s.readStore(ctx)
+	if err != nil {
+		logWarn("usage_sample_load_failed", map[string]any{"error": err.Error()})
+		return
+	}
+	stats, err := readProfileStats(ctx)
+	if err != nil {
+		logWarn("usage_sample_failed", map[string]any{"error": err.Error()})
+		return
+	}
+	var disk map[string]int64
+	if withDisk {
+		if disk, err = readProfileDisk(ctx); err != nil {
+			logWarn("usage_disk_sample_failed", map[string]any{"error": err.Error()})
+		}
+	}
+	keep := map[string]bool{}
+	for _, p := range store.Profiles {
+		keep[p.ID] = true
+		if !p.Enabled || p.Archived || p.DeletedAt != "" {
+			continue
+		}
+		u := stats[p.ID]
+		sample := UsageSample{At: now.UTC().Truncate(time.Second), CPUPercent: u.cpu, MemoryBytes: u.memory}
+		if n, ok := disk[p.ID]; ok {
+			sample.DiskBytes = n
+		} else {
+			sample.DiskBytes = s.usage.lastDisk(p.ID)
+		}
+		if err := s.usage.add(p.ID, sample); err != nil {
+			logWarn("usage_sample_write_failed", map[string]any{"profile_id": p.ID, "error": err.Error()})
+		}
+	}
+	s.usage.forget(keep)
+	s.checkResourceAlerts(store.Profiles, now)
+}
+
+func parseUsageRange(raw string) (time.Duration, error) {
+	if strings.TrimSpace(raw) == "" {
+		return defaultUsageRange, nil
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(raw))
+	if err != nil || d < usageSampleInterval || d > usageRetention {
+		return 0, ValidationError{Msg: fmt.Sprintf("range must be a duration between %s and %s, such as 1h or 24h", usageSampleInterval, usageRetention)}
+	}
+	return d, nil
+}
+
+func (s *Server) handleProfileMetrics(w http.ResponseWriter, r *http.Request, id string) {
+	d, err := parseUsageRange(r.URL.Query().Get("range"))
+	if err != nil {
+		http.Error(w, err.Error(), httpStatusForError(err))
+		return
+	}
+	if _, err := s.Profiles().Get(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), httpStatusForError(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"ok":              true,
+		"profileId":       id,
+		"range":           d.String(),
+		"intervalSeconds": int(usageSampleInterval / time.Second),
+		"samples":         s.usage.since(id, time.Now().Add(-d)),
+	})
+}
@@ -0,0 +1,96 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParseDockerUsageOutput(t *testing.T) {
+	owners := parseContainerOwners("aaa\talpha\nbbb\talpha\nccc\tbeta\nddd\t\n")
+	stats := parseDockerStats("aaa\t1.50%\t100MiB / 1.944GiB\nbbb\t0.25%\t1.5GiB / 1.944GiB\nccc\t--\t--\nzzz\t9%\t1GiB / 2GiB\n", owners)
+	if got := stats["alpha"]; got.cpu != 1.75 || got.memory != 100<<20+3<<29 {
+		t.Fatalf("unexpected alpha usage %+v", got)
+	}
+	if _, ok := stats["unknown"]; ok || len(stats) != 2 {
+		t.Fatalf("expected only the profiles' containers, got %+v", stats)
+	}
+	disk := parseDockerDisk("alpha\t1.2GB\nalpha\t12.5kB (virtual 1.1GB)\n\t5GB\nbeta\tN/A\n")
+	if disk["alpha"] != 1_200_012_500 || len(disk) != 1 {
+		t.Fatalf("unexpected disk usage %+v", disk)
+	}
+}
+
+func TestUsageRingKeepsTheNewestSamples(t *testing.T) {
+	start := time.Date(2026, 3, 4, 9, 0, 0, 0, time.UTC)
+	r := newUsageRing(nil)
+	for i := 0; i < usageCapacity+10; i++ {
+		r.add(UsageSample{At: start.Add(time.Duration(i) * time.Minute), MemoryBytes: int64(i)})
+	}
+	all := r.ordered()
+	if len(all) != usageCapacity || all[0].MemoryBytes != 10 || all[len(all)-1].MemoryBytes != int64(usageCapacity+9) {
+		t.Fatalf("expected the newest %d samples in order, got %d from %d", usageCapacity, len(all), all[0].MemoryBytes)
+	}
+	if got := r.since(all[len(all)-1].At.Add(-59 * time.Minute)); len(got) != 60 {
+		t.Fatalf("expected an hour of samples, got %d", len(got))
+	}
+}
+
+func TestUsageSamplesArePersistedAndServed(t *testing.T) {
+	srv := newServiceTestServer(t)
+	if err := writeProfileStoreAtomic(srv.dbPath, ProfileStore{Profiles: []ProfileRequest{
+		{ID: "alpha", Version: "1.0.0", Enabled: true},
+		{ID: "beta", Version: "1.0.0"},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	prevStats, prevDisk := readProfileStats, readProfileDisk
+	readProfileStats = func(context.Context) (map[string]containerUsage, error) {
+		return map[string]containerUsage{"alpha": {cpu: 12.5, memory: 256 << 20}}, nil
+	}
+	readProfileDisk = func(context.Context) (map[string]int64, error) {
+		return map[string]int64{"alpha": 4 << 30}, nil
+	}
+	defer func() { readProfileStats, readProfileDisk = prevStats, prevDisk }()
+
+	now := time.Now()
+	srv.sampleUsage(context.Background(), now.Add(-2*time.Hour), true)
+	srv.sampleUsage(context.Background(), now.Add(-time.Minute), false)
+	srv.sampleUsage(context.Background(), now, false)
+	if _, err := os.Stat(srv.usage.path("beta")); !os.IsNotExist(err) {
+		t.Fatalf("expected no samples for a stopped profile, got %v", err)
+	}
+
+	// A fresh history reads the samples back from the data directory.
+	srv.usage = newUsageHistory(appCfg.DataDir)
+	rec := httptest.NewRecorder()
+	srv.handleProfileAction(rec, httptest.NewRequest(http.MethodGet, "/api/profiles/alpha/metrics?range=1h", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var body struct {
+		Samples []UsageSample `json:"samples"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Samples) != 2 || body.Samples[1].CPUPercent != 12.5 || body.Samples[1].DiskBytes != 4<<30 {
+		t.Fatalf("expected the last hour with disk carried forward, got %+v", body.Samples)
+	}
+
+	for path, want := range map[string]int{
+		"/api/profiles/alpha/metrics?range=48h":   http.StatusBadRequest,
+		"/api/profiles/missing/metrics?range=1h":  http.StatusNotFound,
+		"/api/profiles/alpha/metrics?range=weeks": http.StatusBadRequest,
+	} {
+		rec := httptest.NewRecorder()
+		srv.handleProfileAction(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Fatalf("%s: expected %d, got %d", path, want, rec.Code)
+		}
+	}
+}
@@ -10,51 +10,39 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
-	"sync"
 	"time"
 )
 
-var (
-	dockerPathOnce sync.Once
-	dockerPath     string
-	dockerPathErr  error
-)
-
+// dockerBinaryPath is the CLI of the active container runtime: docker, or
+// podman when Docker is not installed.
 func dockerBinaryPath() (string, error) {
-	dockerPathOnce.Do(func() {
-		if p, err := exec.LookPath("docker"); err == nil {
-			dockerPath = p
-			return
-		}
-
-		candidates := []string{
-			"/usr/local/bin/docker",
-			"/opt/homebrew/bin/docker",
-			"/Applications/Docker.app/Contents/Resources/bin/docker",
-			"/usr/bin/docker",
-			"/snap/bin/docker",
-			`C:\Program Files\Docker\Docker\resources\bin\docker.exe`,
-		}
-		for _, candidate := range candidates {
-			if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
-				dockerPath = candidate
-				return
-			}
-		}
-		dockerPathErr = errors.New("docker binary not found")
-	})
-	if dockerPath == "" {
-		return "", dockerPathErr
+	rt, err := activeContainerRuntime()
+	if err != nil {
+		return "", err
 	}
-	return dockerPath, nil
+	return rt.Bin, nil
 }
 
+// IsDockerRunning reports "installed" when the daemon answers, "disabled"
+// when it is installed but down, and "not-installed" otherwise. The Engine
+// API is asked first, so a daemon reached without the docker command still
+// counts as running.
 func IsDockerRunning() string {
+	engine, engineErr := newDockerEngine()
+	if engineErr == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if engine.ping(ctx) == nil {
+			return "installed"
+		}
+	}
 	dockerBin, err := dockerBinaryPath()
 	if err != nil {
 		return "not-installed"
 	}
-
+	if engineErr == nil {
+		return "disabled"
+	}
 	cmd := dockerCommand(dockerBin, "info")
 	if err := cmd.Run(); err != nil {
 		return "disabled"
@@ -63,6 +51,24 @@ func IsDockerRunning() string {
 	return "installed"
 }
 
+// dockerInfoField reads one field of the daemon info, from the Engine API
+// or, failing that, from docker info with format.
+func dockerInfoField(ctx context.Context, format string, field func(engineInfo) string) (string, error) {
+	if engine, err := newDockerEngine(); err == nil {
+		info, err := engine.info(ctx)
+		if err != nil {
+			return "", err
+		}
+		return field(info), nil
+	}
+	dockerBin, err := dockerBinaryPath()
+	if err != nil {
+		return "", err
+	}
+	out, err := dockerCommandWithContext(ctx, dockerBin, "info", "--format", format).Output()
+	return strings.TrimSpace(string(out)), err
+}
+
 func liveReloadHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
@@ -72,6 +78,7 @@ func liveReloadHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
 		return
 	}
+	disableWriteDeadline(w)
 	for {
 		fmt.Fprintf(w, "event: ping\ndata: %d\n\n", time.Now().Unix())
 		flusher.Flush()
@@ -160,23 +167,77 @@ func dockerCommandWithContext(ctx context.Context, dockerBin string, args ...str
 
 func dockerCommandEnv() []string {
 	env := os.Environ()
+	// A socket chosen in the settings wins over the environment; exec uses
+	// the last DOCKER_HOST in env.
+	if st := liveSettings.Load(); st != nil && st.DockerHost != "" {
+		return append(env, "DOCKER_HOST="+st.DockerHost)
+	}
 	if strings.TrimSpace(os.Getenv("DOCKER_HOST")) != "" {
 		return env
 	}
 	// Desktop/icon launches may miss shell-initialized DOCKER_HOST for rootless Docker.
-	xdgRuntime := strings.TrimSpace(os.Getenv("XDG_RUNTIME_DIR"))
-	if xdgRuntime != "" {
-		sock := filepath.Join(xdgRuntime, "docker.sock")
+	if sock := rootlessDockerSocket(); sock != "" {
+		return append(env, "DOCKER_HOST=unix://"+sock)
+	}
+	return env
+}
+
+// rootlessDockerSocket is the socket of the user's rootless daemon, if one
+// is running.
+func rootlessDockerSocket() string {
+	candidates := []string{}
+	if xdgRuntime := strings.TrimSpace(os.Getenv("XDG_RUNTIME_DIR")); xdgRuntime != "" {
+		candidates = append(candidates, filepath.Join(xdgRuntime, "docker.sock"))
+	}
+	if uid := strings.TrimSpace(os.Getenv("UID")); uid != "" {
+		candidates = append(candidates, filepath.Join("/run/user", uid, "docker.sock"))
+	}
+	for _, sock := range candidates {
 		if info, err := os.Stat(sock); err == nil && !info.IsDir() {
-			return append(env, "DOCKER_HOST=unix://"+sock)
+			return sock
 		}
 	}
-	uid := strings.TrimSpace(os.Getenv("UID"))
-	if uid != "" {
-		sock := filepath.Join("/run/user", uid, "docker.sock")
-		if info, err := os.Stat(sock); err == nil && !info.IsDir() {
-			return append(env, "DOCKER_HOST=unix://"+sock)
+	return ""
+}
+
+// effectiveDockerHost is the daemon address docker commands use, for
+// diagnostics and the settings page.
+func effectiveDockerHost() string {
+	host := ""
+	for _, kv := range dockerCommandEnv() {
+		if v, ok := strings.CutPrefix(kv, "DOCKER_HOST="); ok {
+			host = strings.TrimSpace(v)
 		}
 	}
-	return env
+	if host == "" && runtime.GOOS != "windows" {
+		host = defaultDockerHost
+		if containerRuntimeName() == runtimePodman {
+			host = podmanSocketHost()
+		}
+	}
+	return host
+}
+
+const defaultDockerHost = "unix:///var/run/docker.sock"
+
+// normalizeDockerHost accepts a daemon address as the docker CLI does, or a
+// bare socket path.
+func normalizeDockerHost(v string) (string, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return "", nil
+	}
+	if strings.HasPrefix(v, "/") {
+		v = "unix://" + v
+	}
+	scheme, rest, ok := strings.Cut(v, "://")
+	switch {
+	case !ok || rest == "":
+		return "", errors.New("dockerHost must be a socket path or a unix://, tcp://, ssh:// or npipe:// address")
+	case scheme == "unix" && !strings.HasPrefix(rest, "/"):
+		return "", errors.New("dockerHost socket path must be absolute")
+	case scheme != "unix" && scheme != "tcp" && scheme != "ssh" && scheme != "npipe":
+		return "", errors.New("dockerHost must be a socket path or a unix://, tcp://, ssh:// or npipe:// address")
+	}
+	return v, nil
 }
@@ -13,37 +13,46 @@ func (s *Server) handleKimmioVersions(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	versions := fetchKnownKimmioVersions()
+	versions, unsupported := fetchKimmioVersionSupport(hostArch())
 	writeJSON(w, http.StatusOK, map[string]any{
-		"ok":       true,
-		"versions": versions,
+		"ok":          true,
+		"versions":    versions,
+		"platform":    "linux/" + hostArch(),
+		"unsupported": unsupported,
 	})
 }
 
 func fetchKnownKimmioVersions() []string {
+	versions, _ := fetchKimmioVersionSupport(hostArch())
+	return versions
+}
+
+// fetchKimmioVersionSupport lists known tags and the subset that has no
+// linux image for arch. Docker Hub reports per-platform images with each
+// tag; tags without platform data are assumed to be supported.
+func fetchKimmioVersionSupport(arch string) ([]string, []string) {
 	fallback := []string{"latest", "1.0.1", "1.0.0"}
 
 	client := http.Client{Timeout: 3 * time.Second}
-	req, _ := http.NewRequest(http.MethodGet, "https://registry.hub.docker.com/v2/repositories/kimmio/kimmio-app/tags?page_size=20", nil)
+	req, _ := http.NewRequest(http.MethodGet, strings.TrimSuffix(dockerHubTagAPI, "/")+"?page_size=20", nil)
 	resp, err := client.Do(req)
 	if err != nil {
-		return fallback
+		return fallback, []string{}
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		return fallback
+		return fallback, []string{}
 	}
 
 	var payload struct {
-		Results []struct {
-			Name string `json:"name"`
-		} `json:"results"`
+		Results []registryTag `json:"results"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
-		return fallback
+		return fallback, []string{}
 	}
 
 	set := map[string]bool{"latest": true}
+	unsupported := []string{}
 	for _, r := range payload.Results {
 		tag := strings.TrimSpace(r.Name)
 		if tag == "" {
@@ -51,6 +60,9 @@ func fetchKnownKimmioVersions() []string {
 		}
 		if versionTagRe.MatchString(tag) {
 			set[tag] = true
+			if _, ok := r.imageForPlatform(arch); !ok && len(r.Images) > 0 {
+				unsupported = append(unsupported, tag)
+			}
 		}
 	}
 
@@ -67,5 +79,6 @@ func fetchKnownKimmioVersions() []string {
 		}
 		return out[i] > out[j]
 	})
-	return out
+	sort.Strings(unsupported)
+	return out, unsupported
 }
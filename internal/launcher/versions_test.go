@@ -0,0 +1,37 @@
+package launcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestFetchKimmioVersionSupportMarksMissingArch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tags" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"results":[
+			{"name":"1.2.0","images":[{"os":"linux","architecture":"amd64"},{"os":"linux","architecture":"arm64"}]},
+			{"name":"1.0.0","images":[{"os":"linux","architecture":"amd64"}]},
+			{"name":"0.9.0","images":[]}
+		]}`))
+	}))
+	defer ts.Close()
+	prev := dockerHubTagAPI
+	dockerHubTagAPI = ts.URL + "/tags/"
+	defer func() { dockerHubTagAPI = prev }()
+
+	versions, unsupported := fetchKimmioVersionSupport("arm64")
+	if !reflect.DeepEqual(versions, []string{"latest", "1.2.0", "1.0.0", "0.9.0"}) {
+		t.Fatalf("unexpected versions: %v", versions)
+	}
+	if !reflect.DeepEqual(unsupported, []string{"1.0.0"}) {
+		t.Fatalf("expected only 1.0.0 to lack arm64, got %v", unsupported)
+	}
+	if _, unsupported := fetchKimmioVersionSupport("amd64"); len(unsupported) != 0 {
+		t.Fatalf("expected every tag to support amd64, got %v", unsupported)
+	}
+}
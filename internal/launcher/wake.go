@@ -0,0 +1,192 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"html"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Profiles with wakeOnRequest set start on demand. While such a profile is
+// stopped the launcher listens on its host port itself; the first request
+// enqueues an enable job and gets a "starting" page that reloads once the
+// app answers. The launcher has no reverse proxy in front of the app, so it
+// hands the port back right before compose up binds it.
+
+const (
+	wakeCheckInterval = 5 * time.Second
+	wakeHeader        = "X-Kimmio-Wake"
+)
+
+type wakeListener struct {
+	port int
+	srv  *http.Server
+}
+
+// wakeListeners holds the listeners of stopped wake-on-request profiles.
+type wakeListeners struct {
+	mu        sync.Mutex
+	listeners map[string]*wakeListener
+}
+
+func newWakeListeners() *wakeListeners {
+	return &wakeListeners{listeners: map[string]*wakeListener{}}
+}
+
+// release closes the profile's listener so Docker can publish the port. It
+// is safe on a nil receiver for servers built without wake support.
+func (w *wakeListeners) release(id string) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closeLocked(id)
+}
+
+func (w *wakeListeners) closeLocked(id string) {
+	if l, ok := w.listeners[id]; ok {
+		_ = l.srv.Close()
+		delete(w.listeners, id)
+		logInfo("wake_listener_closed", map[string]any{"profile_id": id, "port": l.port})
+	}
+}
+
+func (s *Server) startWakeListeners(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			s.reconcileWakeListeners(ctx)
+			select {
+			case <-ctx.Done():
+				s.wake.mu.Lock()
+				for id := range s.wake.listeners {
+					s.wake.closeLocked(id)
+				}
+				s.wake.mu.Unlock()
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// reconcileWakeListeners listens for every stopped wake-on-request profile
+// without a running job and closes the other listeners. The job check runs
+// under the wake lock, so a job that starts meanwhile releases the port
+// after it was opened rather than racing the open.
+func (s *Server) reconcileWakeListeners(ctx context.Context) {
+	store, err := s.readStore(ctx)
+	if err != nil {
+		return
+	}
+	s.wake.mu.Lock()
+	defer s.wake.mu.Unlock()
+	want := map[string]int{}
+	for _, p := range store.Profiles {
+		if !p.WakeOnRequest || p.Enabled || p.Archived || p.DeletedAt != "" || len(p.Ports) == 0 || p.Network.HostMode {
+			continue
+		}
+		s.jobMu.Lock()
+		_, busy := s.activeProfiles[p.ID]
+		s.jobMu.Unlock()
+		if !busy {
+			want[p.ID] = p.Ports[0].Host
+		}
+	}
+	for id, l := range s.wake.listeners {
+		if port, ok := want[id]; !ok || port != l.port {
+			s.wake.closeLocked(id)
+		}
+	}
+	for id, port := range want {
+		if _, ok := s.wake.listeners[id]; ok {
+			continue
+		}
+		ln, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+		if err != nil {
+			// Something else holds the port; try again on the next pass.
+			continue
+		}
+		srv := &http.Server{Handler: s.wakeHandler(id), ReadHeaderTimeout: 10 * time.Second}
+		s.wake.listeners[id] = &wakeListener{port: port, srv: srv}
+		go func() { _ = srv.Serve(ln) }()
+		logInfo("wake_listener_opened", map[string]any{"profile_id": id, "port": port})
+	}
+}
+
+// wakeHandler answers every request to a sleeping profile with 503 and the
+// starting page, enqueuing the enable job on the first one. After a failed
+// start it stops waking the profile until someone acts on it in the
+// launcher, so a broken instance is not restarted on every page poll.
+func (s *Server) wakeHandler(id string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		if p, getErr := s.Profiles().Get(r.Context(), id); getErr != nil {
+			err = getErr
+		} else if p.LastAction == "enable" && p.LastActionStatus == "failed" {
+			err = errors.New("the last start failed (" + p.LastActionResult + "); enable it from the launcher")
+		} else {
+			var job *ActionJob
+			job, err = s.Profiles().StartAction(context.Background(), id, "enable", "", 0, false)
+			if err == nil {
+				logInfo("profile_wake_started", map[string]any{"profile_id": id, "job_id": job.ID, "path": r.URL.Path})
+			}
+		}
+		if err != nil && !errors.Is(err, ErrProfileBusy) {
+			logWarn("profile_wake_failed", map[string]any{"profile_id": id, "error": err.Error()})
+			w.Header().Set(wakeHeader, "failed")
+			w.Header().Set("Cache-Control", "no-store")
+			http.Error(w, "Could not start "+id+": "+err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set(wakeHeader, "starting")
+		w.Header().Set("Retry-After", "5")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		if r.Method != http.MethodHead {
+			_, _ = w.Write([]byte(wakePage(id)))
+		}
+	})
+}
+
+// wakePage polls its own URL: errors while the port changes hands and
+// responses still carrying the wake header mean "not yet".
+func wakePage(id string) string {
+	return `<!doctype html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Starting ` + html.EscapeString(id) + `…</title>
+<style>
+  body { margin: 0; min-height: 100vh; display: flex; align-items: center; justify-content: center;
+         background: #0e0e11; color: #e9fffa; font-family: system-ui, sans-serif; }
+  main { text-align: center; }
+  p { color: #80808b; }
+</style>
+</head>
+<body>
+<main>
+  <h1>Starting your instance…</h1>
+  <p>` + html.EscapeString(id) + ` was asleep. This page reloads when it is ready.</p>
+</main>
+<script>
+  setInterval(async () => {
+    try {
+      const res = await fetch(location.href, {cache: "no-store"});
+      const state = res.headers.get("` + wakeHeader + `");
+      if (state === "failed" || (!state && res.status < 500)) location.reload();
+    } catch (err) {}
+  }, 2000);
+</script>
+</body>
+</html>
+`
+}
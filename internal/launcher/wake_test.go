@@ -0,0 +1,67 @@
+package launcher
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWakeListenerServesStartingPage(t *testing.T) {
+	srv := newServiceTestServer(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	_ = ln.Close()
+	profile := ProfileRequest{ID: "alpha", Version: "1.0.0", WakeOnRequest: true, Ports: []PortMapping{{Container: 3000, Host: port}}}
+	if err := writeProfileStoreAtomic(srv.dbPath, ProfileStore{Profiles: []ProfileRequest{profile}}); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.wake.release("alpha")
+
+	srv.reconcileWakeListeners(context.Background())
+	if len(srv.wake.listeners) != 1 {
+		t.Fatalf("expected a listener for the stopped profile, got %d", len(srv.wake.listeners))
+	}
+	// A job already running stands in for the enable the request would start.
+	srv.jobMu.Lock()
+	srv.activeProfiles["alpha"] = "job-1"
+	srv.jobMu.Unlock()
+	url := "http://127.0.0.1:" + strconv.Itoa(port) + "/dashboard"
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get(wakeHeader) != "starting" || !strings.Contains(string(body), "Starting your instance") {
+		t.Fatalf("expected the starting page, got %d %q", resp.StatusCode, resp.Header.Get(wakeHeader))
+	}
+
+	profile.LastAction, profile.LastActionStatus = "enable", "failed"
+	if err := writeProfileStoreAtomic(srv.dbPath, ProfileStore{Profiles: []ProfileRequest{profile}}); err != nil {
+		t.Fatal(err)
+	}
+	srv.jobMu.Lock()
+	delete(srv.activeProfiles, "alpha")
+	srv.jobMu.Unlock()
+	resp, err = http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.Header.Get(wakeHeader) != "failed" || len(srv.jobs) != 0 {
+		t.Fatalf("expected no new start after a failed one, got %q and %d jobs", resp.Header.Get(wakeHeader), len(srv.jobs))
+	}
+
+	srv.wake.release("alpha")
+	if c, err := net.Dial("tcp", "127.0.0.1:"+strconv.Itoa(port)); err == nil {
+		c.Close()
+		t.Fatal("expected release to free the port for Docker")
+	}
+}
@@ -0,0 +1,107 @@
+package launcher
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"launcher/internal/config"
+)
+
+func TestWebSocketJobChannel(t *testing.T) {
+	cfg := config.Load("dev")
+	cfg.DataDir = t.TempDir()
+	appCfg = cfg
+	srv := NewServer(cfg)
+	srv.jobs["job1"] = &ActionJob{ID: "job1", ProfileID: "alpha", Action: "enable", Status: "running", Progress: 40}
+
+	ts := httptest.NewServer(http.HandlerFunc(srv.handleWebSocket))
+	defer ts.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(ts.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	handshake := "GET /api/ws HTTP/1.1\r\nHost: localhost\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n" +
+		"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n"
+	if _, err := conn.Write([]byte(handshake)); err != nil {
+		t.Fatal(err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected accept key %q", got)
+	}
+
+	writeMaskedText(t, conn, `{"op":"subscribe","channel":"job:job1"}`)
+	var gotUpdate bool
+	for i := 0; i < 3 && !gotUpdate; i++ {
+		var msg struct {
+			Type    string    `json:"type"`
+			Channel string    `json:"channel"`
+			Data    ActionJob `json:"data"`
+		}
+		if err := json.Unmarshal(readServerFrame(t, br), &msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Type == "update" {
+			gotUpdate = true
+			if msg.Channel != "job:job1" || msg.Data.Progress != 40 {
+				t.Fatalf("unexpected update: %+v", msg)
+			}
+		}
+	}
+	if !gotUpdate {
+		t.Fatalf("expected job update")
+	}
+}
+
+func writeMaskedText(t *testing.T, w io.Writer, text string) {
+	t.Helper()
+	mask := []byte{1, 2, 3, 4}
+	payload := []byte(text)
+	frame := []byte{0x81, 0x80 | byte(len(payload))}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := w.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readServerFrame(t *testing.T, r *bufio.Reader) []byte {
+	t.Helper()
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		t.Fatal(err)
+	}
+	length := int(header[1] & 0x7F)
+	if length == 126 {
+		ext := make([]byte, 2)
+		if _, err := io.ReadFull(r, ext); err != nil {
+			t.Fatal(err)
+		}
+		length = int(ext[0])<<8 | int(ext[1])
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
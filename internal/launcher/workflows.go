@@ -0,0 +1,404 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	workflowSequential = "sequential"
+	workflowParallel   = "parallel"
+
+	workflowMaxSteps     = 50
+	workflowPollInterval = 500 * time.Millisecond
+	// workflowRetention is how long a finished workflow can still be read
+	// before it is forgotten.
+	workflowRetention = 24 * time.Hour
+)
+
+var ErrWorkflowNotFound = errors.New("workflow not found")
+
+// WorkflowStep names one profile action. Profile "*" expands to every
+// profile when the workflow is created. Destructive actions need a named
+// profile repeated in Confirm, so "*" cannot wipe every profile at once.
+type WorkflowStep struct {
+	Profile string `json:"profile"`
+	Action  string `json:"action"`
+	Version string `json:"version,omitempty"`
+	Confirm string `json:"confirm,omitempty"`
+}
+
+type WorkflowRequest struct {
+	Mode        string         `json:"mode"`
+	StopOnError *bool          `json:"stopOnError,omitempty"`
+	Steps       []WorkflowStep `json:"steps"`
+}
+
+type WorkflowStepResult struct {
+	WorkflowStep
+	Status     string `json:"status"`
+	Progress   int    `json:"progress"`
+	JobID      string `json:"jobId,omitempty"`
+	Error      string `json:"error,omitempty"`
+	StartedAt  string `json:"startedAt,omitempty"`
+	FinishedAt string `json:"finishedAt,omitempty"`
+}
+
+// Workflow runs several profile actions as one unit. Each step still runs
+// as a regular ActionJob, so per-profile locking and cancellation apply.
+type Workflow struct {
+	ID          string               `json:"id"`
+	Mode        string               `json:"mode"`
+	StopOnError bool                 `json:"stopOnError"`
+	Status      string               `json:"status"`
+	Progress    int                  `json:"progress"`
+	Steps       []WorkflowStepResult `json:"steps"`
+	StartedAt   string               `json:"startedAt,omitempty"`
+	FinishedAt  string               `json:"finishedAt,omitempty"`
+
+	// failed is set as soon as any step fails; Status stays running until
+	// the remaining steps have finished or been skipped.
+	failed bool
+	// finished is when the workflow reached its final status.
+	finished time.Time
+}
+
+// WorkflowService starts and tracks cross-profile workflows.
+type WorkflowService struct {
+	srv *Server
+}
+
+func (s *Server) Workflows() *WorkflowService { return &WorkflowService{srv: s} }
+
+func (wf *WorkflowService) Start(ctx context.Context, req WorkflowRequest) (Workflow, error) {
+	steps, err := wf.expandSteps(ctx, req.Steps)
+	if err != nil {
+		return Workflow{}, err
+	}
+	mode := strings.ToLower(strings.TrimSpace(req.Mode))
+	switch mode {
+	case "":
+		mode = workflowSequential
+	case workflowSequential, workflowParallel:
+	default:
+		return Workflow{}, ValidationError{Msg: "mode must be sequential or parallel"}
+	}
+	stopOnError := true
+	if req.StopOnError != nil {
+		stopOnError = *req.StopOnError
+	}
+
+	w := &Workflow{
+		ID:          randomToken(16),
+		Mode:        mode,
+		StopOnError: stopOnError,
+		Status:      "running",
+		Steps:       make([]WorkflowStepResult, len(steps)),
+		StartedAt:   time.Now().UTC().Format(time.RFC3339),
+	}
+	for i, step := range steps {
+		w.Steps[i] = WorkflowStepResult{WorkflowStep: step, Status: "pending"}
+	}
+	runCtx, cancel := context.WithCancel(context.Background())
+	s := wf.srv
+	s.jobMu.Lock()
+	s.pruneWorkflowsLocked(time.Now())
+	s.workflows[w.ID] = w
+	s.workflowCancels[w.ID] = cancel
+	snapshot := copyWorkflow(w)
+	s.jobMu.Unlock()
+
+	logInfo("workflow_started", map[string]any{"workflow_id": w.ID, "mode": mode, "steps": len(steps)})
+	go s.runWorkflow(runCtx, w.ID, mode, stopOnError, len(steps))
+	return snapshot, nil
+}
+
+func (wf *WorkflowService) Get(id string) (Workflow, error) {
+	s := wf.srv
+	s.jobMu.Lock()
+	defer s.jobMu.Unlock()
+	s.pruneWorkflowsLocked(time.Now())
+	w, ok := s.workflows[strings.TrimSpace(id)]
+	if !ok {
+		return Workflow{}, ErrWorkflowNotFound
+	}
+	return copyWorkflow(w), nil
+}
+
+// pruneWorkflowsLocked forgets workflows that finished more than
+// workflowRetention ago. The caller holds jobMu.
+func (s *Server) pruneWorkflowsLocked(now time.Time) {
+	for id, w := range s.workflows {
+		if !w.finished.IsZero() && now.Sub(w.finished) > workflowRetention {
+			delete(s.workflows, id)
+			delete(s.workflowCancels, id)
+		}
+	}
+}
+
+// Cancel stops launching further steps and cancels the running ones.
+func (wf *WorkflowService) Cancel(id string) error {
+	s := wf.srv
+	s.jobMu.Lock()
+	w, ok := s.workflows[strings.TrimSpace(id)]
+	if !ok {
+		s.jobMu.Unlock()
+		return ErrWorkflowNotFound
+	}
+	if isTerminalJobStatus(w.Status) {
+		s.jobMu.Unlock()
+		return ErrJobCompleted
+	}
+	cancel := s.workflowCancels[w.ID]
+	s.jobMu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	return nil
+}
+
+func (wf *WorkflowService) expandSteps(ctx context.Context, steps []WorkflowStep) ([]WorkflowStep, error) {
+	if len(steps) == 0 {
+		return nil, ValidationError{Msg: "workflow needs at least one step"}
+	}
+	var all []ProfileRequest
+	out := make([]WorkflowStep, 0, len(steps))
+	for _, step := range steps {
+		step.Profile = normalizeProfileID(step.Profile)
+		step.Action = strings.ToLower(strings.TrimSpace(step.Action))
+		step.Version = strings.TrimSpace(step.Version)
+		step.Confirm = normalizeProfileID(step.Confirm)
+		if !isProfileAction(step.Action) {
+			return nil, ErrUnknownAction
+		}
+		if step.Action == "version" && !versionTagRe.MatchString(step.Version) {
+			return nil, ValidationError{Msg: "steps with action version need a valid version tag"}
+		}
+		if isDestructiveAction(step.Action) {
+			if step.Profile == "*" {
+				return nil, ValidationError{Msg: "steps with action " + step.Action + " must name one profile"}
+			}
+			if step.Confirm != step.Profile {
+				return nil, ErrConfirmationRequired
+			}
+		}
+		if step.Profile != "*" {
+			if !profileIDRe.MatchString(step.Profile) {
+				return nil, ValidationError{Msg: "invalid profile id " + step.Profile}
+			}
+			if _, _, err := wf.srv.getProfileForAction(ctx, step.Profile); err != nil {
+				return nil, normalizeNotFound(err)
+			}
+			out = append(out, step)
+			continue
+		}
+		if all == nil {
+			store, err := wf.srv.readStore(ctx)
+			if err != nil {
+				return nil, err
+			}
+			all = store.Profiles
+		}
+		for _, p := range all {
+			expanded := step
+			expanded.Profile = p.ID
+			out = append(out, expanded)
+		}
+	}
+	if len(out) == 0 {
+		return nil, ValidationError{Msg: "workflow matched no profiles"}
+	}
+	if len(out) > workflowMaxSteps {
+		return nil, ValidationError{Msg: "workflow has too many steps"}
+	}
+	return out, nil
+}
+
+func (s *Server) runWorkflow(ctx context.Context, workflowID, mode string, stopOnError bool, total int) {
+	failed := false
+	if mode == workflowParallel {
+		var wg sync.WaitGroup
+		for i := 0; i < total; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				if !s.runWorkflowStep(ctx, workflowID, i) {
+					s.setWorkflowFailed(workflowID)
+				}
+			}(i)
+		}
+		wg.Wait()
+	} else {
+		for i := 0; i < total; i++ {
+			if ctx.Err() != nil || (failed && stopOnError) {
+				s.updateWorkflowStep(workflowID, i, func(step *WorkflowStepResult) {
+					step.Status = "skipped"
+				})
+				continue
+			}
+			if !s.runWorkflowStep(ctx, workflowID, i) {
+				failed = true
+				s.setWorkflowFailed(workflowID)
+			}
+		}
+	}
+
+	s.jobMu.Lock()
+	defer s.jobMu.Unlock()
+	w, ok := s.workflows[workflowID]
+	if !ok {
+		return
+	}
+	switch {
+	case ctx.Err() != nil:
+		w.Status = "canceled"
+	case w.failed:
+		w.Status = "failed"
+	default:
+		w.Status = "succeeded"
+	}
+	w.Progress = 100
+	w.finished = time.Now()
+	w.FinishedAt = w.finished.UTC().Format(time.RFC3339)
+	if cancel := s.workflowCancels[workflowID]; cancel != nil {
+		cancel()
+	}
+	delete(s.workflowCancels, workflowID)
+	logInfo("workflow_finished", map[string]any{"workflow_id": workflowID, "status": w.Status})
+}
+
+// runWorkflowStep starts the step's job and follows it to completion. It
+// reports whether the step succeeded.
+func (s *Server) runWorkflowStep(ctx context.Context, workflowID string, index int) bool {
+	var step WorkflowStep
+	s.updateWorkflowStep(workflowID, index, func(r *WorkflowStepResult) {
+		step = r.WorkflowStep
+		r.Status = "running"
+		r.StartedAt = time.Now().UTC().Format(time.RFC3339)
+	})
+	finish := func(status, errText string) bool {
+		s.updateWorkflowStep(workflowID, index, func(r *WorkflowStepResult) {
+			r.Status = status
+			r.Error = errText
+			r.Progress = 100
+			r.FinishedAt = time.Now().UTC().Format(time.RFC3339)
+		})
+		return status == "succeeded"
+	}
+	if ctx.Err() != nil {
+		return finish("skipped", "")
+	}
+
+	job, err := s.Profiles().StartAction(ctx, step.Profile, step.Action, step.Version, 0, step.Confirm == step.Profile)
+	if err != nil {
+		return finish("failed", err.Error())
+	}
+	s.updateWorkflowStep(workflowID, index, func(r *WorkflowStepResult) { r.JobID = job.ID })
+
+	ticker := time.NewTicker(workflowPollInterval)
+	defer ticker.Stop()
+	canceled := false
+	for {
+		snap, ok := s.snapshotJob(job.ID)
+		if !ok {
+			return finish("failed", ErrJobNotFound.Error())
+		}
+		if isTerminalJobStatus(snap.Status) {
+			return finish(snap.Status, snap.Error)
+		}
+		s.updateWorkflowStep(workflowID, index, func(r *WorkflowStepResult) { r.Progress = snap.Progress })
+		select {
+		case <-ctx.Done():
+			if !canceled {
+				canceled = true
+				_ = s.cancelJob(job.ID)
+			}
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) updateWorkflowStep(workflowID string, index int, update func(*WorkflowStepResult)) {
+	s.jobMu.Lock()
+	defer s.jobMu.Unlock()
+	w, ok := s.workflows[workflowID]
+	if !ok || index < 0 || index >= len(w.Steps) {
+		return
+	}
+	update(&w.Steps[index])
+	sum := 0
+	for _, step := range w.Steps {
+		if step.Status == "skipped" {
+			sum += 100
+			continue
+		}
+		sum += step.Progress
+	}
+	w.Progress = sum / len(w.Steps)
+}
+
+func (s *Server) setWorkflowFailed(workflowID string) {
+	s.jobMu.Lock()
+	defer s.jobMu.Unlock()
+	if w, ok := s.workflows[workflowID]; ok {
+		w.failed = true
+	}
+}
+
+func copyWorkflow(w *Workflow) Workflow {
+	out := *w
+	out.Steps = append([]WorkflowStepResult{}, w.Steps...)
+	return out
+}
+
+func (s *Server) handleWorkflows(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req WorkflowRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", bodyErrorStatus(err))
+		return
+	}
+	workflow, err := s.Workflows().Start(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatusForError(err))
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"ok": true, "workflowId": workflow.ID, "workflow": workflow})
+}
+
+func (s *Server) handleWorkflowRoute(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/workflows/"), "/"), "/")
+	id := strings.TrimSpace(parts[0])
+	if id == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if len(parts) == 1 && r.Method == http.MethodGet {
+		workflow, err := s.Workflows().Get(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "workflow": workflow})
+		return
+	}
+	if len(parts) == 2 && parts[1] == "cancel" && r.Method == http.MethodPost {
+		if err := s.Workflows().Cancel(id); err != nil {
+			http.Error(w, err.Error(), httpStatusForError(err))
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "canceled": true})
+		return
+	}
+	http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+}
@@ -0,0 +1,98 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWorkflowUpdatesAllProfilesSequentially(t *testing.T) {
+	srv := newServiceTestServer(t)
+	if err := writeProfileStoreAtomic(srv.dbPath, ProfileStore{Profiles: []ProfileRequest{
+		{ID: "alpha", Version: "1.0.0", Ports: []PortMapping{{Container: 3000, Host: 8088}}},
+		{ID: "bravo", Version: "1.0.0", Ports: []PortMapping{{Container: 3000, Host: 8089}}},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	started, err := srv.Workflows().Start(context.Background(), WorkflowRequest{
+		Steps: []WorkflowStep{{Profile: "*", Action: "version", Version: "1.2.0"}},
+	})
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if len(started.Steps) != 2 || started.Mode != workflowSequential {
+		t.Fatalf("expected two sequential steps, got %+v", started)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	var wf Workflow
+	for time.Now().Before(deadline) {
+		wf, err = srv.Workflows().Get(started.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isTerminalJobStatus(wf.Status) {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if wf.Status != "succeeded" || wf.Progress != 100 {
+		t.Fatalf("expected succeeded workflow, got %+v", wf)
+	}
+	for _, id := range []string{"alpha", "bravo"} {
+		p, err := srv.Profiles().Get(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Version != "1.2.0" {
+			t.Fatalf("expected %s at 1.2.0, got %s", id, p.Version)
+		}
+	}
+}
+
+func TestWorkflowRejectsInvalidSteps(t *testing.T) {
+	srv := newServiceTestServer(t)
+	if _, err := srv.Workflows().Start(context.Background(), WorkflowRequest{}); err == nil {
+		t.Fatalf("expected error for empty workflow")
+	}
+	if _, err := srv.Workflows().Start(context.Background(), WorkflowRequest{Steps: []WorkflowStep{{Profile: "ghost", Action: "stop"}}}); !errors.Is(err, ErrProfileNotFound) {
+		t.Fatalf("expected ErrProfileNotFound, got %v", err)
+	}
+	var ve ValidationError
+	if _, err := srv.Workflows().Start(context.Background(), WorkflowRequest{Mode: "random", Steps: []WorkflowStep{{Profile: "alpha", Action: "stop"}}}); !errors.As(err, &ve) {
+		t.Fatalf("expected ValidationError for mode, got %v", err)
+	}
+	if _, err := srv.Workflows().Start(context.Background(), WorkflowRequest{Steps: []WorkflowStep{{Profile: "*", Action: "purge", Confirm: "*"}}}); !errors.As(err, &ve) {
+		t.Fatalf("expected a purge of every profile to be refused, got %v", err)
+	}
+	if _, err := srv.Workflows().Start(context.Background(), WorkflowRequest{Steps: []WorkflowStep{{Profile: "alpha", Action: "recreate"}}}); !errors.Is(err, ErrConfirmationRequired) {
+		t.Fatalf("expected an unconfirmed recreate to be refused, got %v", err)
+	}
+}
+
+func TestFinishedWorkflowsAreForgotten(t *testing.T) {
+	srv := newServiceTestServer(t)
+	now := time.Now()
+	srv.jobMu.Lock()
+	srv.workflows["old"] = &Workflow{ID: "old", Status: "succeeded", finished: now.Add(-workflowRetention - time.Minute)}
+	srv.workflows["recent"] = &Workflow{ID: "recent", Status: "failed", finished: now.Add(-time.Hour)}
+	srv.workflows["running"] = &Workflow{ID: "running", Status: "running"}
+	srv.workflowCancels["running"] = func() {}
+	srv.jobMu.Unlock()
+
+	if _, err := srv.Workflows().Get("old"); !errors.Is(err, ErrWorkflowNotFound) {
+		t.Fatalf("expected an expired workflow forgotten, got %v", err)
+	}
+	for _, id := range []string{"recent", "running"} {
+		if _, err := srv.Workflows().Get(id); err != nil {
+			t.Fatalf("expected %s kept, got %v", id, err)
+		}
+	}
+	srv.jobMu.Lock()
+	defer srv.jobMu.Unlock()
+	if len(srv.workflows) != 2 || srv.workflowCancels["running"] == nil {
+		t.Fatalf("expected only the expired workflow removed, got %v", srv.workflows)
+	}
+}
@@ -0,0 +1,228 @@
+package launcher
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const wsPushInterval = time.Second
+
+type wsClientMessage struct {
+	Op      string `json:"op"`
+	Channel string `json:"channel"`
+}
+
+type wsServerMessage struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel,omitempty"`
+	Data    any    `json:"data,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type wsSubscription struct {
+	lastHash  [32]byte
+	logOffset int64
+}
+
+// handleWebSocket multiplexes the "status", "job:<id>" and "logs" channels
+// over one connection. Clients send {"op":"subscribe","channel":"..."} and
+// {"op":"unsubscribe","channel":"..."}; updates are pushed only on change.
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	// Network clients reach this signed in once accounts exist.
+	remote := !isLoopbackRequest(r) && s.accounts != nil && s.accounts.enabled()
+	if origin := strings.TrimSpace(r.Header.Get("Origin")); origin != "" && !isAllowedRequestURL(origin, r.Host, remote) {
+		http.Error(w, "forbidden: invalid request origin", http.StatusForbidden)
+		return
+	}
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer conn.Close()
+
+	var mu sync.Mutex
+	subs := map[string]*wsSubscription{}
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		for {
+			raw, err := conn.readMessage()
+			if err != nil {
+				return
+			}
+			var msg wsClientMessage
+			if err := json.Unmarshal(raw, &msg); err != nil {
+				_ = conn.writeJSON(wsServerMessage{Type: "error", Error: "invalid message"})
+				continue
+			}
+			channel := strings.TrimSpace(msg.Channel)
+			if !isValidWSChannel(channel) {
+				_ = conn.writeJSON(wsServerMessage{Type: "error", Channel: channel, Error: "unknown channel"})
+				continue
+			}
+			mu.Lock()
+			switch strings.ToLower(strings.TrimSpace(msg.Op)) {
+			case "subscribe":
+				sub := &wsSubscription{}
+				if channel == "logs" {
+					sub.logOffset = initialLogOffset()
+				}
+				subs[channel] = sub
+			case "unsubscribe":
+				delete(subs, channel)
+			default:
+				mu.Unlock()
+				_ = conn.writeJSON(wsServerMessage{Type: "error", Channel: channel, Error: "unknown op"})
+				continue
+			}
+			mu.Unlock()
+			_ = conn.writeJSON(wsServerMessage{Type: msg.Op + "d", Channel: channel})
+		}
+	}()
+
+	ticker := time.NewTicker(wsPushInterval)
+	defer ticker.Stop()
+	for {
+		mu.Lock()
+		for channel, sub := range subs {
+			if err := s.pushWSChannel(r.Context(), conn, channel, sub); err != nil {
+				mu.Unlock()
+				return
+			}
+		}
+		mu.Unlock()
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func isValidWSChannel(channel string) bool {
+	if channel == "status" || channel == "logs" {
+		return true
+	}
+	return strings.HasPrefix(channel, "job:") && len(channel) > len("job:")
+}
+
+func (s *Server) pushWSChannel(ctx context.Context, conn *wsConn, channel string, sub *wsSubscription) error {
+	var data any
+	switch {
+	case channel == "status":
+		profiles, err := s.profilesWithStatus(ctx)
+		if err != nil {
+			return nil
+		}
+		statuses := make([]map[string]any, 0, len(profiles))
+		for _, p := range profiles {
+			statuses = append(statuses, map[string]any{
+				"id":            p.ID,
+				"enabled":       p.Enabled,
+				"running":       p.Running,
+				"runtimeStatus": p.RuntimeStatus,
+				"activeJobId":   p.ActiveJobID,
+			})
+		}
+		data = statuses
+	case channel == "logs":
+		lines, offset := readLogLinesFrom(sub.logOffset)
+		sub.logOffset = offset
+		if len(lines) == 0 {
+			return nil
+		}
+		return conn.writeJSON(wsServerMessage{Type: "update", Channel: channel, Data: lines})
+	case strings.HasPrefix(channel, "job:"):
+		job, ok := s.snapshotJob(strings.TrimPrefix(channel, "job:"))
+		if !ok {
+			return conn.writeJSON(wsServerMessage{Type: "error", Channel: channel, Error: "job not found"})
+		}
+		data = job
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil
+	}
+	hash := sha256.Sum256(b)
+	if hash == sub.lastHash {
+		return nil
+	}
+	sub.lastHash = hash
+	return conn.writeJSON(wsServerMessage{Type: "update", Channel: channel, Data: json.RawMessage(b)})
+}
+
+func (c *wsConn) writeJSON(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.writeText(b)
+}
+
+// initialLogOffset starts log subscribers a few KB before the end so they
+// see recent context without replaying the whole file.
+func initialLogOffset() int64 {
+	if appLogger == nil {
+		return 0
+	}
+	info, err := os.Stat(appLogger.path)
+	if err != nil {
+		return 0
+	}
+	if info.Size() > 4096 {
+		return info.Size() - 4096
+	}
+	return 0
+}
+
+func readLogLinesFrom(offset int64) ([]string, int64) {
+	if appLogger == nil {
+		return nil, offset
+	}
+	f, err := os.Open(appLogger.path)
+	if err != nil {
+		return nil, offset
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, offset
+	}
+	if info.Size() < offset {
+		// Log was rotated; continue from the start of the new file.
+		offset = 0
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset
+	}
+	b, err := io.ReadAll(io.LimitReader(f, 256*1024))
+	if err != nil || len(b) == 0 {
+		return nil, offset
+	}
+	end := strings.LastIndex(string(b), "\n")
+	if end < 0 {
+		return nil, offset
+	}
+	chunk := string(b[:end])
+	lines := []string{}
+	for i, line := range strings.Split(chunk, "\n") {
+		// The first line may be partial when starting mid-file.
+		if i == 0 && offset > 0 && !strings.HasPrefix(line, "{") {
+			continue
+		}
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, offset + int64(end) + 1
+}